@@ -1,18 +1,45 @@
 // Command leaderboardctl operates the leaderboard projection against a live
 // database.
 //
-//	leaderboardctl rebuild
-//	    Truncates leaderboard_entries and recomputes every cell from accepted
-//	    runs, in one transaction. The projection is maintained incrementally by
-//	    the replay worker, so a rebuild should be a no-op — that it CAN be run,
-//	    and that it changes nothing when it is, is the proof that the board is a
-//	    projection of Postgres rather than a second source of truth.
+//	leaderboardctl rebuild [-bucket KEY] [-dry-run]
+//	    Recomputes every cell from accepted runs into a shadow table, diffs it
+//	    against the live boards, prints the difference, and swaps in exactly
+//	    that difference. Readers are served throughout; writers wait only for
+//	    the final swap. The projection is maintained incrementally by the
+//	    replay worker, so a rebuild should be a no-op — that it CAN be run, and
+//	    that it changes nothing when it is, is the proof that the board is a
+//	    projection of Postgres rather than a second source of truth. -bucket
+//	    limits it to one board (a windowed or daily key is a board of its own);
+//	    -dry-run prints the diff and leaves the live boards alone.
 //
-//	leaderboardctl show [-bucket KEY] [-limit N]
+//	leaderboardctl rebuild-tp
+//	    Truncates run_tp and user_tp and re-rates every eligible run with the
+//	    current TP formula, in one transaction. Maintained incrementally like
+//	    the boards, so on an unchanged formula it should move nobody; after a
+//	    formula bump it is how every stored value reaches the new version.
+//
+//	leaderboardctl show [-bucket KEY] [-limit N] [-order score|wpm]
 //	    Prints the board index, or one bucket's ranking, exactly as a reader
-//	    would see it (banned players filtered, same ordering as the API).
+//	    would see it (banned players filtered, same ordering as the API). The
+//	    index here includes the live windowed boards the API's index leaves out.
+//
+//	leaderboardctl freeze
+//	    Archives every window whose settle period has run out — one pass of
+//	    what the server's freezer does on its interval. For a deployment that
+//	    runs with TYPEMORE_LEADERBOARD_FREEZE_INTERVAL=0, or to freeze a window
+//	    now rather than at the next tick.
+//
+//	leaderboardctl snapshot-ranks
+//	    Records a rank point for every player who moved on every board the
+//	    projection has written since the last pass — one pass of what the
+//	    server does every TYPEMORE_LEADERBOARD_RANK_SNAPSHOT_INTERVAL.
 //
-// Both read the same TYPEMORE_ environment as the server, so they see the same
+//	leaderboardctl season add -id SLUG -name NAME -from RFC3339 -to RFC3339
+//	leaderboardctl season list
+//	    Declares a season, or lists them. Seasons may not overlap; the table
+//	    refuses one that would.
+//
+// All of them read the same TYPEMORE_ environment as the server, so they see the same
 // eligibility policy the server projects with. See docs/LEADERBOARDS.md.
 package main
 
@@ -24,10 +51,14 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/typemore/typemore-server/internal/leaderboard"
 	leaderboardpg "github.com/typemore/typemore-server/internal/leaderboard/pgstore"
 	"github.com/typemore/typemore-server/internal/platform"
 	"github.com/typemore/typemore-server/internal/platform/db"
+	"github.com/typemore/typemore-server/internal/rating"
+	ratingpg "github.com/typemore/typemore-server/internal/rating/pgstore"
 )
 
 func main() {
@@ -39,7 +70,7 @@ func main() {
 
 func run() error {
 	if len(os.Args) < 2 {
-		return fmt.Errorf("usage: leaderboardctl <rebuild|show> [flags]")
+		return fmt.Errorf("usage: leaderboardctl <rebuild|rebuild-tp|show|freeze|snapshot-ranks|season> [flags]")
 	}
 	command, args := os.Args[1], os.Args[2:]
 
@@ -55,36 +86,90 @@ func run() error {
 	}
 	defer pool.Close()
 
-	store := leaderboardpg.New(pool, cfg.LeaderboardRequireVerifiedEmail)
+	// The same windows the server projects with: a rebuild must put back the
+	// windowed boards the projection maintains, and the freezer must wait out
+	// the same settle period.
+	windows, err := leaderboard.ParseWindows(cfg.LeaderboardWindows, cfg.LeaderboardWindowSettle)
+	if err != nil {
+		return fmt.Errorf("TYPEMORE_LEADERBOARD_WINDOWS: %w", err)
+	}
+	store := leaderboardpg.New(pool, cfg.LeaderboardRequireVerifiedEmail).WithWindows(windows)
 
 	switch command {
 	case "rebuild":
 		fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+		bucket := fs.String("bucket", "", "rebuild one board only, e.g. time:15000:en:seeded (default: every board)")
+		dryRun := fs.Bool("dry-run", false, "print the diff without applying it")
 		if err := fs.Parse(args); err != nil {
 			return err
 		}
-		return rebuild(ctx, store, cfg)
+		opts := leaderboardpg.RebuildOptions{DryRun: *dryRun}
+		if *bucket != "" {
+			b, err := leaderboard.ParseBucketKey(*bucket)
+			if err != nil {
+				return err
+			}
+			opts.Bucket = &b
+		}
+		return rebuild(ctx, store, cfg, opts)
+
+	case "rebuild-tp":
+		fs := flag.NewFlagSet("rebuild-tp", flag.ExitOnError)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return rebuildTP(ctx, ratingpg.New(pool))
 
 	case "show":
 		fs := flag.NewFlagSet("show", flag.ExitOnError)
 		bucket := fs.String("bucket", "", "one bucket key, e.g. time:15000:en:seeded (default: the index)")
 		limit := fs.Int("limit", 20, "rows per board")
+		orderFlag := fs.String("order", "score", "ranking to print: score or wpm")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		order, err := leaderboard.ParseOrder(*orderFlag)
+		if err != nil {
+			return err
+		}
+		return show(ctx, store, *bucket, order, int32(*limit))
+
+	case "freeze":
+		fs := flag.NewFlagSet("freeze", flag.ExitOnError)
 		if err := fs.Parse(args); err != nil {
 			return err
 		}
-		return show(ctx, store, *bucket, int32(*limit))
+		return freeze(ctx, store)
+
+	case "snapshot-ranks":
+		fs := flag.NewFlagSet("snapshot-ranks", flag.ExitOnError)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return snapshotRanks(ctx, store)
+
+	case "season":
+		return season(ctx, store, args)
 
 	default:
-		return fmt.Errorf("unknown command %q (want rebuild or show)", command)
+		return fmt.Errorf("unknown command %q (want rebuild, rebuild-tp, show, freeze, snapshot-ranks or season)", command)
 	}
 }
 
-func rebuild(ctx context.Context, store *leaderboardpg.Store, cfg platform.Config) error {
-	fmt.Printf("rebuilding leaderboards (verified email required: %t)\n",
-		cfg.LeaderboardRequireVerifiedEmail)
+func rebuild(ctx context.Context, store *leaderboardpg.Store, cfg platform.Config, opts leaderboardpg.RebuildOptions) error {
+	scope := "every board"
+	if opts.Bucket != nil {
+		scope = opts.Bucket.Key()
+	}
+	verb := "rebuilding"
+	if opts.DryRun {
+		verb = "dry-run rebuilding"
+	}
+	fmt.Printf("%s %s (verified email required: %t)\n",
+		verb, scope, cfg.LeaderboardRequireVerifiedEmail)
 
 	started := time.Now()
-	stats, err := store.Rebuild(ctx)
+	stats, err := store.RebuildWith(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -92,20 +177,90 @@ func rebuild(ctx context.Context, store *leaderboardpg.Store, cfg platform.Confi
 	fmt.Printf("\n  eligible cells   %d\n", stats.Cells)
 	fmt.Printf("  entries before   %d\n", stats.Before)
 	fmt.Printf("  entries after    %d\n", stats.After)
+	fmt.Printf("  added            %d\n", stats.Added)
+	fmt.Printf("  removed          %d\n", stats.Removed)
+	fmt.Printf("  changed          %d\n", stats.Changed)
+	fmt.Printf("  elapsed          %s\n\n", time.Since(started).Round(time.Millisecond))
+
+	if len(stats.Diff) == 0 {
+		fmt.Println("unchanged — the incremental projection was already correct")
+		return nil
+	}
+	printDiff(stats.Diff)
+	if opts.DryRun {
+		fmt.Printf("\ndry run: %d slot(s) would change; nothing was written\n", len(stats.Diff))
+	} else {
+		fmt.Printf("\nswapped in %d slot(s) the incremental projection had drifted on\n", len(stats.Diff))
+	}
+	return nil
+}
+
+// diffShown caps the diff printed. The tallies above it are always complete;
+// a rebuild after a policy change can move every board, and a terminal of
+// slot lines is no more use than the count.
+const diffShown = 20
+
+// printDiff lists a rebuild's diff, one slot per line: + a slot the rebuild
+// fills, - one it empties, ~ one it gives a different run (or the same run
+// with a stale copy of its columns).
+func printDiff(diff []leaderboardpg.RebuildDiff) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tBUCKET\tPLAYER\tLIVE RUN\tREBUILT RUN")
+	for i, d := range diff {
+		if i == diffShown {
+			break
+		}
+		sign := "~"
+		switch {
+		case d.Live == nil:
+			sign = "+"
+		case d.Rebuilt == nil:
+			sign = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sign, d.BucketKey, d.UserID, runOrDash(d.Live), runOrDash(d.Rebuilt))
+	}
+	_ = w.Flush()
+	if len(diff) > diffShown {
+		fmt.Printf("… and %d more\n", len(diff)-diffShown)
+	}
+}
+
+func runOrDash(id *uuid.UUID) string {
+	if id == nil {
+		return "-"
+	}
+	return id.String()
+}
+
+func rebuildTP(ctx context.Context, store *ratingpg.Store) error {
+	fmt.Printf("rebuilding TP (formula v%d)\n", rating.Current.Version)
+
+	started := time.Now()
+	stats, err := store.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n  eligible runs    %d\n", stats.Runs)
+	fmt.Printf("  stale runs       %d\n", stats.Stale)
+	fmt.Printf("  players before   %d\n", stats.Before)
+	fmt.Printf("  players after    %d\n", stats.After)
+	fmt.Printf("  totals moved     %d\n", stats.Moved)
 	fmt.Printf("  elapsed          %s\n\n", time.Since(started).Round(time.Millisecond))
 
 	switch {
-	case stats.Before == stats.After:
+	case stats.Moved == 0:
 		fmt.Println("unchanged — the incremental projection was already correct")
-	case stats.Before < stats.After:
-		fmt.Printf("recovered %d entr(y|ies) the incremental projection was missing\n", stats.After-stats.Before)
+	case stats.Stale > 0:
+		fmt.Printf("re-rated %d run(s) onto v%d; %d player total(s) moved\n",
+			stats.Stale, rating.Current.Version, stats.Moved)
 	default:
-		fmt.Printf("dropped %d stale entr(y|ies)\n", stats.Before-stats.After)
+		fmt.Printf("corrected %d player total(s) the incremental projection had drifted on\n", stats.Moved)
 	}
 	return nil
 }
 
-func show(ctx context.Context, store *leaderboardpg.Store, bucketKey string, limit int32) error {
+func show(ctx context.Context, store *leaderboardpg.Store, bucketKey string, order leaderboard.Order, limit int32) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	defer func() { _ = w.Flush() }()
 
@@ -129,7 +284,7 @@ func show(ctx context.Context, store *leaderboardpg.Store, bucketKey string, lim
 	if err != nil {
 		return err
 	}
-	rows, err := store.Page(ctx, bucket, nil, limit)
+	rows, err := store.Page(ctx, bucket, order, nil, limit)
 	if err != nil {
 		return err
 	}
@@ -138,7 +293,7 @@ func show(ctx context.Context, store *leaderboardpg.Store, bucketKey string, lim
 		return nil
 	}
 
-	fmt.Fprintf(w, "%s\n\n", bucket.Key())
+	fmt.Fprintf(w, "%s (by %s)\n\n", bucket.Key(), order)
 	fmt.Fprintln(w, "RANK\tPLAYER\tSCORE\tWPM\tACC\tGRADE\tACHIEVED")
 	for i := range rows {
 		r := &rows[i]
@@ -148,3 +303,74 @@ func show(ctx context.Context, store *leaderboardpg.Store, bucketKey string, lim
 	}
 	return nil
 }
+
+func freeze(ctx context.Context, store *leaderboardpg.Store) error {
+	stats, err := store.FreezeClosed(ctx)
+	// A pass archives every window it can before reporting the ones it could
+	// not, so the counts are worth printing either way.
+	fmt.Printf("  windows frozen   %d\n", stats.Periods)
+	fmt.Printf("  rows archived    %d\n", stats.Archived)
+	fmt.Printf("  stragglers       %d\n", stats.Stragglers)
+	return err
+}
+
+func snapshotRanks(ctx context.Context, store *leaderboardpg.Store) error {
+	stats, err := store.SnapshotRanks(ctx)
+	fmt.Printf("  boards ranked    %d\n", stats.Boards)
+	fmt.Printf("  points recorded  %d\n", stats.Points)
+	fmt.Printf("  ladder rungs     %d\n", stats.Rungs)
+	return err
+}
+
+func season(ctx context.Context, store *leaderboardpg.Store, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: leaderboardctl season <add|list> [flags]")
+	}
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("season add", flag.ExitOnError)
+		id := fs.String("id", "", "slug, e.g. s1-winter: a lowercase letter, then letters, digits and dashes")
+		name := fs.String("name", "", "display name, e.g. \"Season 1: Winter\"")
+		from := fs.String("from", "", "start, RFC 3339 (inclusive)")
+		to := fs.String("to", "", "end, RFC 3339 (exclusive)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		startsAt, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			return fmt.Errorf("-from: %w", err)
+		}
+		endsAt, err := time.Parse(time.RFC3339, *to)
+		if err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+		s := leaderboard.Season{ID: *id, Name: *name, StartsAt: startsAt, EndsAt: endsAt}
+		if err := store.CreateSeason(ctx, s); err != nil {
+			return err
+		}
+		fmt.Printf("season %s declared: %s → %s\n", s.ID,
+			s.StartsAt.UTC().Format(time.RFC3339), s.EndsAt.UTC().Format(time.RFC3339))
+		return nil
+
+	case "list":
+		seasons, err := store.Seasons(ctx)
+		if err != nil {
+			return err
+		}
+		if len(seasons) == 0 {
+			fmt.Println("no seasons declared")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer func() { _ = w.Flush() }()
+		fmt.Fprintln(w, "ID\tNAME\tSTARTS\tENDS")
+		for i := range seasons {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", seasons[i].ID, seasons[i].Name,
+				seasons[i].StartsAt.Format(time.RFC3339), seasons[i].EndsAt.Format(time.RFC3339))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown season command %q (want add or list)", args[0])
+	}
+}
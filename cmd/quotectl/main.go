@@ -7,6 +7,9 @@
 //	    revision beside the old one, which is retired rather than overwritten —
 //	    published text is never edited, because old runs replay against it
 //	    (docs/QUOTES.md, docs/DICTIONARIES.md).
+//	    Every quote is rated on the way in (docs/DIFFICULTY.md); a rating that
+//	    no longer matches — a new model, a moved dictionary — is rewritten in
+//	    place, since it is derived from the text rather than part of it.
 //
 // It reads the same TYPEMORE_ environment as the server, so it hashes with the
 // same vendored core bundle the server and the client use.
@@ -20,6 +23,8 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/typemore/typemore-server/internal/difficulty"
+	"github.com/typemore/typemore-server/internal/keyboard"
 	"github.com/typemore/typemore-server/internal/platform"
 	"github.com/typemore/typemore-server/internal/platform/db"
 	"github.com/typemore/typemore-server/internal/quote"
@@ -77,6 +82,20 @@ func importQuotes(cfg platform.Config, only string) error {
 		return err
 	}
 
+	// Quotes are rated against their language's dictionary, so the registry is
+	// seeded here exactly as the server seeds it: the same letters are "rare"
+	// for a quote as for a seeded run in the same language, or the two star
+	// scales would not be one scale.
+	registry, err := replay.NewRegistry(core)
+	if err != nil {
+		return err
+	}
+	layouts, err := keyboard.Load()
+	if err != nil {
+		return err
+	}
+	analyser := difficulty.New(layouts)
+
 	pool, err := db.NewPool(ctx, cfg.DatabaseURL, cfg.DBMaxConns)
 	if err != nil {
 		return err
@@ -92,7 +111,7 @@ func importQuotes(cfg platform.Config, only string) error {
 	fmt.Printf("importing quotes from %s @ %s\n\n", manifest.Upstream.Repo, manifest.Upstream.Commit)
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "LANGUAGE\tUPSTREAM FILE\tQUOTES\tINSERTED\tSUPERSEDED\tUNCHANGED")
+	fmt.Fprintln(w, "LANGUAGE\tUPSTREAM FILE\tQUOTES\tINSERTED\tSUPERSEDED\tUNCHANGED\tRERATED")
 
 	var total quote.ImportStats
 	started := time.Now()
@@ -108,6 +127,10 @@ func importQuotes(cfg platform.Config, only string) error {
 			_ = w.Flush()
 			return err
 		}
+		profile := registry.Profile(lang.Lang)
+		for i := range incoming {
+			incoming[i].Difficulty = analyser.Text(lang.Lang, incoming[i].Text, profile).Rating
+		}
 		stats, err := store.Import(ctx, lang.Lang, incoming)
 		if err != nil {
 			_ = w.Flush()
@@ -115,11 +138,11 @@ func importQuotes(cfg platform.Config, only string) error {
 		}
 		total.Add(stats)
 
-		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", lang.Lang, lang.File,
-			stats.Total(), stats.Inserted, stats.Superseded, stats.Unchanged)
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", lang.Lang, lang.File,
+			stats.Total(), stats.Inserted, stats.Superseded, stats.Unchanged, stats.Rerated)
 	}
-	fmt.Fprintf(w, "\tTOTAL\t%d\t%d\t%d\t%d\n",
-		total.Total(), total.Inserted, total.Superseded, total.Unchanged)
+	fmt.Fprintf(w, "\tTOTAL\t%d\t%d\t%d\t%d\t%d\n",
+		total.Total(), total.Inserted, total.Superseded, total.Unchanged, total.Rerated)
 	if err := w.Flush(); err != nil {
 		return err
 	}
@@ -135,6 +158,10 @@ func importQuotes(cfg platform.Config, only string) error {
 	fmt.Printf("\n  rows in registry  %d (was %d, retired revisions included)\n", after, before)
 	fmt.Printf("  elapsed           %s\n\n", time.Since(started).Round(time.Millisecond))
 
+	if total.Rerated > 0 {
+		fmt.Printf("%d existing quote(s) re-rated to difficulty model v%d; their text "+
+			"is untouched\n", total.Rerated, difficulty.Current.Version)
+	}
 	switch {
 	case total.Inserted == 0 && total.Superseded == 0:
 		fmt.Println("unchanged — the vendored corpora are already published verbatim")
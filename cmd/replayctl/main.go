@@ -3,19 +3,58 @@
 //	replayctl calibrate [-limit N] [-top N]
 //	    Re-validates stored runs through the current bundle and policy and prints
 //	    what it FINDS — per-flag firing rates, a suspicion histogram, the worst
-//	    offenders, and the status changes the current policy would make. It writes
-//	    nothing. This is the command to run before touching a weight.
+//	    offenders, and the status changes the current policy would make — plus
+//	    what the player-history layer raises and which statuses it moves, on or
+//	    off. It writes nothing. This is the command to run before touching a
+//	    weight, and before turning history on.
 //
-//	replayctl revalidate [-limit N] [-batch N]
+//	replayctl revalidate [-limit N] [-batch N] [-bundle current|recorded|SHA]
 //	    Re-judges runs that are no longer current on EITHER axis — policy_version
 //	    behind the current one, or bundle_sha different from the vendored
 //	    bundle's — and writes the result. Bounded by -limit, idempotent by
 //	    construction: applying a decision writes both columns, so a second pass
-//	    finds nothing.
+//	    finds nothing. -bundle=SHA converges history on that build instead of
+//	    the vendored one (a rollback); -bundle=recorded re-judges only runs
+//	    behind the policy, each on the build that judged it, and leaves
+//	    bundle_sha alone.
 //
-// Both read the same TYPEMORE_ environment as the server, so they judge with
-// exactly the deployment's policy — weight overrides included. See
-// docs/REPLAY.md, "Review policy".
+//	replayctl bundle-diff [-limit N] [-top N] OLD NEW
+//	    Replays stored runs on two core builds — each "current", a SHA or
+//	    unique prefix of a loaded bundle, or a path to a .js file — and reports
+//	    what the new one would change: statuses, reasons, flags, score totals
+//	    and metrics, with the largest movers listed. It writes nothing. This is
+//	    the command to run before vendoring a bundle.
+//
+//	replayctl explain [-rejudge] [-json] RUN_ID
+//	    Judges one stored run again with a trace attached and prints every
+//	    step: which text it was replayed against, what the core made of the
+//	    log, each flag with what the policy weighs it at, the combination
+//	    rules that fired, the threshold check and the client comparisons —
+//	    then the verdict it arrives at beside the one the run carries. It
+//	    writes nothing. -rejudge traces the table `revalidate` uses instead
+//	    of the ingestion one; -json prints what the admin endpoint serves.
+//
+//	replayctl timeline [-words] RUN_ID
+//	    Folds one stored run's log through the core one event at a time and
+//	    writes the state after each as NDJSON: the word the cursor is in, what
+//	    is typed there, whether it is still right, the net WPM so far and the
+//	    words the event completed. -words prints the per-word completion times
+//	    as a table instead, slowest marked. It judges nothing.
+//
+//	replayctl dead-letters [-limit N]
+//	    Lists the runs the worker stopped retrying: replay_timeout or
+//	    replay_error on every one of their attempts. Oldest first, with the
+//	    last error each recorded. Reads only.
+//
+//	replayctl retry [-limit N] (-all | RUN_ID...)
+//	    Releases dead letters back to the queue as new work, with a fresh
+//	    attempt budget. A run a moderator has overridden is never released.
+//
+// The first four read the same TYPEMORE_ environment as the server, so they
+// judge with exactly the deployment's policy — weight overrides included. See
+// docs/REPLAY.md, "Review policy", "Bundles" and "`replayctl explain`". The
+// last three judge nothing and need no policy at all; see docs/REPLAY.md,
+// "`replayctl timeline`" and "Retry and dead letters".
 package main
 
 import (
@@ -33,6 +72,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/typemore/typemore-server/internal/keyboard"
@@ -42,6 +82,7 @@ import (
 	"github.com/typemore/typemore-server/internal/platform/db"
 	"github.com/typemore/typemore-server/internal/quote"
 	quotepg "github.com/typemore/typemore-server/internal/quote/pgstore"
+	ratingpg "github.com/typemore/typemore-server/internal/rating/pgstore"
 	"github.com/typemore/typemore-server/internal/replay"
 	replaypg "github.com/typemore/typemore-server/internal/replay/pgstore"
 	"github.com/typemore/typemore-server/internal/replay/policy"
@@ -56,7 +97,7 @@ func main() {
 
 func run() error {
 	if len(os.Args) < 2 {
-		return fmt.Errorf("usage: replayctl <calibrate|revalidate> [flags]")
+		return fmt.Errorf("usage: replayctl <calibrate|revalidate|bundle-diff|explain|timeline|dead-letters|retry> [flags]")
 	}
 	command, args := os.Args[1], os.Args[2:]
 
@@ -64,6 +105,66 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	ctx := context.Background()
+
+	switch command {
+	case "timeline":
+		fs := flag.NewFlagSet("timeline", flag.ExitOnError)
+		words := fs.Bool("words", false, "print per-word completion times instead of the NDJSON frames")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		ids, err := parseRunIDs(fs.Args())
+		if err != nil {
+			return err
+		}
+		if len(ids) != 1 {
+			return fmt.Errorf("timeline needs exactly one run id")
+		}
+		pool, err := db.NewPool(ctx, cfg.DatabaseURL, cfg.DBMaxConns)
+		if err != nil {
+			return err
+		}
+		defer pool.Close()
+		return timeline(ctx, pool, cfg, ids[0], *words)
+
+	case "dead-letters":
+		fs := flag.NewFlagSet("dead-letters", flag.ExitOnError)
+		limit := fs.Int("limit", 100, "max dead letters to list")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		pool, err := db.NewPool(ctx, cfg.DatabaseURL, cfg.DBMaxConns)
+		if err != nil {
+			return err
+		}
+		defer pool.Close()
+		return deadLetters(ctx, pool, int32(*limit))
+
+	case "retry":
+		fs := flag.NewFlagSet("retry", flag.ExitOnError)
+		all := fs.Bool("all", false, "release every dead letter (up to -limit) instead of the listed ids")
+		limit := fs.Int("limit", 1000, "max dead letters to release in this pass")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		ids, err := parseRunIDs(fs.Args())
+		if err != nil {
+			return err
+		}
+		// Releasing everything is one flag away, never the default: a bare
+		// `replayctl retry` that re-queued the whole dead-letter pile would be
+		// the easiest mistake this tool could make.
+		if *all == (len(ids) > 0) {
+			return fmt.Errorf("retry needs either -all or at least one run id, not both")
+		}
+		pool, err := db.NewPool(ctx, cfg.DatabaseURL, cfg.DBMaxConns)
+		if err != nil {
+			return err
+		}
+		defer pool.Close()
+		return retry(ctx, pool, ids, int32(*limit))
+	}
 	// Resolved before anything touches the database: a typo in a weight
 	// override must stop the tool, not silently judge with a default.
 	//
@@ -83,7 +184,11 @@ func run() error {
 	if errors.Is(err, policy.ErrNoPolicy) {
 		fmt.Fprintln(os.Stderr, "warning: "+err.Error())
 	}
-	if policy.IsNoop(judge) {
+	// bundle-diff and explain are the exceptions: they write nothing, and the
+	// verdicts and numbers bundle-diff compares are the ones no judge can
+	// change, while explain's trace says outright that no judge ran. A build
+	// without a policy still answers the question each asks.
+	if policy.IsNoop(judge) && command != "bundle-diff" && command != "explain" {
 		return fmt.Errorf("this binary has no review policy (built without -tags %s), so there is "+
 			"nothing to %s: calibrate would report zeroes, and revalidate would rewrite every "+
 			"stored verdict as unjudged. Rebuild with -tags %s",
@@ -94,13 +199,37 @@ func run() error {
 		return err
 	}
 
-	ctx := context.Background()
 	pool, err := db.NewPool(ctx, cfg.DatabaseURL, cfg.DBMaxConns)
 	if err != nil {
 		return err
 	}
 	defer pool.Close()
 
+	// The same history the server reads, and switched on by the same variable:
+	// revalidate must re-judge with exactly what the worker judges with.
+	// Calibrate reads it either way, to say what switching it would change.
+	layouts, err := keyboard.Load()
+	if err != nil {
+		return err
+	}
+	history := replaypg.NewHistory(pool, keyboardpg.NewRhythms(pool, layouts))
+	if cfg.ReplayHistoryEnabled {
+		decider = decider.WithHistory(history)
+	}
+	// A candidate policy rides along on the same decider, so revalidation
+	// refreshes its answers exactly as the worker writes them.
+	if cfg.ReplayShadowEnabled {
+		shadow, err := replay.ProvideShadow(cfg.ReplayShadowLabel, policy.Config{
+			FlagWeights:       cfg.ReplayShadowFlagWeights,
+			ReviewThreshold:   cfg.ReplayShadowReviewThreshold,
+			SustainedBurstSec: cfg.ReplayShadowSustainedBurstSec,
+		})
+		if err != nil {
+			return err
+		}
+		decider = decider.WithShadow(shadow)
+	}
+
 	switch command {
 	case "calibrate":
 		fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
@@ -109,7 +238,7 @@ func run() error {
 		if err := fs.Parse(args); err != nil {
 			return err
 		}
-		return calibrate(ctx, pool, decider, cfg, int32(*limit), *top)
+		return calibrate(ctx, pool, decider, history, cfg, int32(*limit), *top)
 
 	case "revalidate":
 		fs := flag.NewFlagSet("revalidate", flag.ExitOnError)
@@ -120,13 +249,42 @@ func run() error {
 		// live database. Two places to reason about a lock window is one too
 		// many.
 		batch := fs.Int("batch", int(cfg.ReplayBatchSize), "runs per transaction")
+		bundle := fs.String("bundle", "current", "build to re-judge on: current, recorded, or a bundle SHA")
 		if err := fs.Parse(args); err != nil {
 			return err
 		}
-		return revalidate(ctx, pool, decider, cfg, *limit, int32(*batch))
+		return revalidate(ctx, pool, decider, layouts, cfg, *bundle, *limit, int32(*batch))
+
+	case "bundle-diff":
+		fs := flag.NewFlagSet("bundle-diff", flag.ExitOnError)
+		limit := fs.Int("limit", 5000, "max runs to replay on both builds")
+		top := fs.Int("top", 10, "how many changed runs to detail")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return fmt.Errorf("bundle-diff needs exactly two bundles: OLD NEW")
+		}
+		return bundleDiff(ctx, pool, decider, cfg, fs.Arg(0), fs.Arg(1), int32(*limit), *top)
+
+	case "explain":
+		fs := flag.NewFlagSet("explain", flag.ExitOnError)
+		rejudge := fs.Bool("rejudge", false, "trace the revalidation table instead of the ingestion one")
+		asJSON := fs.Bool("json", false, "print the explanation as JSON")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		ids, err := parseRunIDs(fs.Args())
+		if err != nil {
+			return err
+		}
+		if len(ids) != 1 {
+			return fmt.Errorf("explain needs exactly one run id")
+		}
+		return explain(ctx, pool, decider, cfg, ids[0], *rejudge, *asJSON)
 
 	default:
-		return fmt.Errorf("unknown command %q (want calibrate or revalidate)", command)
+		return fmt.Errorf("unknown command %q (want calibrate, revalidate, bundle-diff, explain, timeline, dead-letters or retry)", command)
 	}
 }
 
@@ -136,6 +294,12 @@ type auditDoc struct {
 	Reason  string        `json:"reason"`
 	Flags   []replay.Flag `json:"flags"`
 	Policy  *auditPolicy  `json:"policy"`
+	History *auditHistory `json:"history"`
+}
+
+type auditHistory struct {
+	Runs  int           `json:"runs"`
+	Flags []replay.Flag `json:"flags"`
 }
 
 type auditPolicy struct {
@@ -154,7 +318,16 @@ type outcome struct {
 	doc       auditDoc
 }
 
-func calibrate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider, cfg platform.Config, limit int32, top int) error {
+// historyOutcome is one run judged with and without the player-history layer.
+type historyOutcome struct {
+	run     replay.CalibrationRun
+	without string
+	with    string
+	doc     auditDoc
+}
+
+func calibrate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider, history replay.HistorySource,
+	cfg platform.Config, limit int32, top int) error {
 	core, err := replay.NewCore(cfg.ReplayTimeout)
 	if err != nil {
 		return err
@@ -196,11 +369,26 @@ func calibrate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider,
 	// produce — a calibration report that does not match the pass it is
 	// calibrating is worse than none.
 	rejudge := decider.ForRejudgement()
+	histories := make([]historyOutcome, 0, len(rows))
 
 	for i := range rows {
 		// The exact path the worker takes — a report from a different code path
-		// would be a fiction.
-		d := replay.Judge(ctx, core, reg, quotes, rejudge, rows[i].PendingRun, cfg.ReplayCanaryEpoch)
+		// would be a fiction. Judge's two halves are called separately only so
+		// one replay can be decided with the history layer and without it; the
+		// deployment's own setting picks which of the two the report above the
+		// history section describes.
+		res, replayErr := replay.ReplayRun(ctx, core, reg, quotes, rows[i].PendingRun, cfg.ReplayCanaryEpoch)
+		without := rejudge.WithHistory(nil).Resolve(ctx, rows[i].PendingRun, res, replayErr)
+		with := rejudge.WithHistory(history).Resolve(ctx, rows[i].PendingRun, res, replayErr)
+		d := without
+		if cfg.ReplayHistoryEnabled {
+			d = with
+		}
+		var withDoc auditDoc
+		_ = json.Unmarshal(with.Validation, &withDoc)
+		histories = append(histories, historyOutcome{
+			run: rows[i], without: without.Status, with: with.Status, doc: withDoc,
+		})
 
 		var doc auditDoc
 		_ = json.Unmarshal(d.Validation, &doc)
@@ -288,10 +476,73 @@ func calibrate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider,
 			fmt.Printf("  %-26s %4d\n", t, transitions[t])
 		}
 	}
+	printHistory(histories, desc, described, cfg.ReplayHistoryEnabled, top)
 	fmt.Println("\nread-only: nothing was written")
 	return nil
 }
 
+// printHistory reports the player-history layer on its own: what its detectors
+// raised, and every status that differs between judging with it and without
+// it. Printed whichever way TYPEMORE_REPLAY_HISTORY_ENABLED is set — off, it is
+// the forecast for switching it on; on, it is what it is doing.
+func printHistory(outcomes []historyOutcome, desc policy.Description, described, enabled bool, top int) {
+	if enabled {
+		fmt.Println("\nplayer history (TYPEMORE_REPLAY_HISTORY_ENABLED is on; included above)")
+	} else {
+		fmt.Println("\nplayer history (TYPEMORE_REPLAY_HISTORY_ENABLED is off; this is what turning it on would do)")
+	}
+
+	flagRuns := map[string]int{}
+	flagSeverity := map[string]float64{}
+	thin := 0
+	transitions := map[string]int{}
+	var moved []historyOutcome
+	for _, o := range outcomes {
+		if o.doc.History == nil {
+			continue
+		}
+		if o.doc.History.Runs < policy.MinBaselineRuns {
+			thin++
+		}
+		for _, f := range o.doc.History.Flags {
+			flagRuns[f.Code]++
+			flagSeverity[f.Code] += f.Score
+		}
+		if o.without != o.with {
+			transitions[o.without+" -> "+o.with]++
+			moved = append(moved, o)
+		}
+	}
+
+	fmt.Printf("  runs with fewer than %d prior accepted runs (speed not compared): %d\n",
+		policy.MinBaselineRuns, thin)
+	for _, code := range policy.HistoryFlagCodes {
+		n := flagRuns[code]
+		mean := 0.0
+		if n > 0 {
+			mean = flagSeverity[code] / float64(n)
+		}
+		weight := "-"
+		if described {
+			weight = fmt.Sprintf("%.2f", desc.Weights[code])
+		}
+		fmt.Printf("  %-22s %5d %6.1f%%  mean sev %.4f  weight %s\n",
+			code, n, 100*float64(n)/float64(len(outcomes)), mean, weight)
+	}
+
+	if len(transitions) == 0 {
+		fmt.Println("  no status depends on it")
+		return
+	}
+	fmt.Println("  status changes it makes")
+	for _, t := range slices.Sorted(maps.Keys(transitions)) {
+		fmt.Printf("    %-26s %4d\n", t, transitions[t])
+	}
+	for _, o := range moved[:min(top, len(moved))] {
+		fmt.Printf("    %s %-8s -> %-8s %s\n", o.run.ID, o.without, o.with, flagSummary(o.doc.History.Flags))
+	}
+}
+
 type bucket struct {
 	label string
 	count int
@@ -349,9 +600,288 @@ func flagSummary(flags []replay.Flag) string {
 	return strings.Join(parts, " ")
 }
 
+// --- explain -----------------------------------------------------------------
+
+// explain traces one run's verdict. It judges on the vendored Core with the
+// deployment's decider, exactly as the admin endpoint does, so a reviewer and
+// an operator reading the same run read the same steps.
+func explain(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider, cfg platform.Config,
+	id uuid.UUID, rejudge, asJSON bool) error {
+	core, err := replay.NewCore(cfg.ReplayTimeout)
+	if err != nil {
+		return err
+	}
+	reg, err := replay.NewRegistry(core)
+	if err != nil {
+		return err
+	}
+	explainer := replay.NewExplainer(replaypg.New(pool, nil), core, reg,
+		quote.ReplayResolver{Store: quotepg.New(pool)}, decider, cfg.ReplayCanaryEpoch)
+	e, err := explainer.ExplainRun(ctx, id, rejudge)
+	if errors.Is(err, replay.ErrRunNotFound) {
+		return fmt.Errorf("no run %s", id)
+	}
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(e)
+	}
+
+	stored := string(e.Stored.Status)
+	if e.Stored.Reason != "" {
+		stored += " (" + e.Stored.Reason + ")"
+	}
+	if e.Stored.PolicyVersion != nil {
+		stored += fmt.Sprintf(", policy %d", *e.Stored.PolicyVersion)
+	}
+	if e.Stored.BundleSHA != nil {
+		stored += ", bundle " + truncate(*e.Stored.BundleSHA, 12)
+	}
+	fmt.Printf("run     %s\n", e.RunID)
+	fmt.Printf("stored  %s\n", stored)
+	fmt.Printf("traced  %s table, policy %s, bundle %s\n\n", e.Mode, e.PolicyVersion, truncate(e.BundleSHA, 12))
+
+	for _, step := range e.Steps {
+		fmt.Printf("  %-10s %-9s %s\n", step.Step, step.Outcome, step.Detail)
+		if len(step.Flags) == 0 {
+			continue
+		}
+		fmt.Printf("  %-10s %-9s   %-24s %-8s %7s %7s %8s\n", "", "", "flag", "source", "score", "weight", "adds")
+		for _, f := range step.Flags {
+			weight, adds := "-", "-"
+			if f.Unknown {
+				weight = "none"
+			}
+			if f.Weight != nil {
+				weight = fmt.Sprintf("%.3f", *f.Weight)
+				adds = fmt.Sprintf("%.4f", *f.Contribution)
+			}
+			fmt.Printf("  %-10s %-9s   %-24s %-8s %7.3f %7s %8s\n", "", "", f.Code, f.Source, f.Score, weight, adds)
+		}
+	}
+
+	if e.Agrees {
+		fmt.Println("\nthe trace arrives at the verdict the run carries")
+	} else {
+		traced := string(e.Status)
+		if e.Reason != "" {
+			traced += " (" + e.Reason + ")"
+		}
+		fmt.Printf("\nthe trace arrives at %s, not at the stored %s — an override, or a run judged\n"+
+			"under another policy, bundle or table\n", traced, stored)
+	}
+	fmt.Println("\nread-only: nothing was written")
+	return nil
+}
+
+// --- timeline ----------------------------------------------------------------
+
+// timeline folds one run's log on the core build it was judged on: the
+// vendored Core, or the build the run is pinned to when the bundle directory
+// holds it.
+func timeline(ctx context.Context, pool *pgxpool.Pool, cfg platform.Config, id uuid.UUID, words bool) error {
+	bundles, err := replay.LoadBundles(cfg.ReplayBundleDir)
+	if err != nil {
+		return err
+	}
+	core, err := replay.NewCoreFor(bundles.Current(), cfg.ReplayTimeout)
+	if err != nil {
+		return err
+	}
+	reg, err := replay.NewRegistry(core)
+	if err != nil {
+		return err
+	}
+	timeliner := replay.NewTimeliner(replaypg.New(pool, nil), replay.NewPinnedEngine(bundles, core, cfg.ReplayTimeout),
+		reg, quote.ReplayResolver{Store: quotepg.New(pool)}, cfg.ReplayCanaryEpoch)
+	frames, err := timeliner.RunTimeline(ctx, id)
+	if errors.Is(err, replay.ErrRunNotFound) {
+		return fmt.Errorf("no run %s", id)
+	}
+	if err != nil {
+		return err
+	}
+	if !words {
+		return replay.WriteTimeline(os.Stdout, frames)
+	}
+
+	var done []replay.WordCompletion
+	for _, f := range frames {
+		done = append(done, f.Completed...)
+	}
+	if len(done) == 0 {
+		fmt.Println("the run completed no words")
+		return nil
+	}
+	slowest := slices.MaxFunc(done, func(a, b replay.WordCompletion) int { return cmp.Compare(a.Ms, b.Ms) })
+	fmt.Printf("%5s %9s %8s  %-24s %s\n", "word", "at (ms)", "took", "target", "typed")
+	for _, w := range done {
+		mark := ""
+		if !w.Correct {
+			mark = "  wrong"
+		}
+		if w.Word == slowest.Word {
+			mark += "  <- slowest"
+		}
+		fmt.Printf("%5d %9.0f %8.0f  %-24s %s%s\n", w.Word, w.At, w.Ms,
+			truncate(strings.TrimSuffix(w.Target, "\n"), 24), strings.TrimSuffix(w.Typed, "\n"), mark)
+	}
+	last := frames[len(frames)-1]
+	fmt.Printf("\n%d words, %s at %.0f ms, %.2f wpm\n", len(done), last.Phase, last.T, last.WPM)
+	return nil
+}
+
+// --- bundle-diff -------------------------------------------------------------
+
+// bundleDiff replays stored runs on two builds and reports what moving from
+// the first to the second would change. Each run is decided under the
+// deployment's decider, ForRejudgement — the mode a rollout's `revalidate`
+// pass uses — so the report is a forecast of that pass, not of the queue.
+func bundleDiff(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider, cfg platform.Config,
+	oldRef, newRef string, limit int32, top int) error {
+	bundles, err := replay.LoadBundles(cfg.ReplayBundleDir)
+	if err != nil {
+		return err
+	}
+	oldBundle, err := bundles.Resolve(oldRef)
+	if err != nil {
+		return err
+	}
+	newBundle, err := bundles.Resolve(newRef)
+	if err != nil {
+		return err
+	}
+	if oldBundle.SHA == newBundle.SHA {
+		return fmt.Errorf("%s and %s are the same bundle (%s)", oldRef, newRef, oldBundle.Short())
+	}
+	oldCore, err := replay.NewCoreFor(oldBundle, cfg.ReplayTimeout)
+	if err != nil {
+		return err
+	}
+	newCore, err := replay.NewCoreFor(newBundle, cfg.ReplayTimeout)
+	if err != nil {
+		return err
+	}
+	// Dictionary hashes come from the vendored build, as they do everywhere:
+	// a build that moved one would show up below as every run of that
+	// language failing on one side, which is the answer the diff should give.
+	core, err := replay.NewCore(cfg.ReplayTimeout)
+	if err != nil {
+		return err
+	}
+	reg, err := replay.NewRegistry(core)
+	if err != nil {
+		return err
+	}
+	quotes := quote.ReplayResolver{Store: quotepg.New(pool)}
+	rows, err := replaypg.New(pool, nil).ListForCalibration(ctx, limit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("old  %s  %s\n", oldBundle.Short(), oldBundle.Origin)
+	fmt.Printf("new  %s  %s\n", newBundle.Short(), newBundle.Origin)
+	if len(rows) == 0 {
+		fmt.Println("\nno judged runs to compare on")
+		return nil
+	}
+
+	rejudge := decider.ForRejudgement()
+	var changed []replay.RunDiff
+	fields := map[string]int{}
+	transitions := map[string]int{}
+	var totals []float64
+	for i := range rows {
+		d := replay.DiffRun(ctx, oldCore, newCore, reg, quotes, rejudge, rows[i].PendingRun, cfg.ReplayCanaryEpoch)
+		if len(d.Changed) == 0 {
+			continue
+		}
+		changed = append(changed, d)
+		for _, f := range d.Changed {
+			fields[f]++
+		}
+		if d.Old.Status != d.New.Status {
+			transitions[d.Old.Status+" -> "+d.New.Status]++
+		}
+		if delta, ok := d.TotalDelta(); ok && delta != 0 {
+			totals = append(totals, delta)
+		}
+	}
+
+	fmt.Printf("\nreplayed %d judged run(s) on both; %d changed\n", len(rows), len(changed))
+	if len(changed) == 0 {
+		fmt.Println("\nthe new bundle changes nothing on these runs")
+		fmt.Println("\nread-only: nothing was written")
+		return nil
+	}
+
+	fmt.Println("\nwhat changed")
+	for _, f := range []string{replay.DiffStatus, replay.DiffReason, replay.DiffFlags,
+		replay.DiffScore, replay.DiffMetrics, replay.DiffError} {
+		fmt.Printf("  %-8s %5d %6.1f%%\n", f, fields[f], 100*float64(fields[f])/float64(len(rows)))
+	}
+
+	fmt.Println("\nstatus transitions")
+	if len(transitions) == 0 {
+		fmt.Println("  (none — every run keeps its status)")
+	}
+	for _, t := range slices.Sorted(maps.Keys(transitions)) {
+		fmt.Printf("  %-26s %4d\n", t, transitions[t])
+	}
+
+	if len(totals) > 0 {
+		lo, hi, sum := totals[0], totals[0], 0.0
+		for _, v := range totals {
+			lo, hi, sum = min(lo, v), max(hi, v), sum+v
+		}
+		fmt.Printf("\nscore total moved on %d run(s): min %+.2f  mean %+.2f  max %+.2f\n",
+			len(totals), lo, sum/float64(len(totals)), hi)
+	}
+
+	// A status change outranks any score movement; among the rest, the
+	// biggest total moves first.
+	magnitude := func(d replay.RunDiff) float64 {
+		delta, _ := d.TotalDelta()
+		return max(delta, -delta)
+	}
+	slices.SortStableFunc(changed, func(a, b replay.RunDiff) int {
+		if as, bs := a.Old.Status != a.New.Status, b.Old.Status != b.New.Status; as != bs {
+			if as {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(magnitude(b), magnitude(a))
+	})
+	shown := min(top, len(changed))
+	fmt.Printf("\ntop %d changed run(s)\n", shown)
+	for _, d := range changed[:shown] {
+		delta := "-"
+		if v, ok := d.TotalDelta(); ok {
+			delta = fmt.Sprintf("%+.2f", v)
+		}
+		fmt.Printf("  %s %-8s -> %-8s %-20s -> %-20s total %-8s %s\n",
+			d.ID, d.Old.Status, d.New.Status, cmp.Or(d.Old.Reason, "-"), cmp.Or(d.New.Reason, "-"),
+			delta, strings.Join(d.Changed, ","))
+		if d.New.Error != "" && d.New.Error != d.Old.Error {
+			fmt.Printf("      new error: %s\n", d.New.Error)
+		}
+	}
+	fmt.Println("\nread-only: nothing was written")
+	return nil
+}
+
 // --- revalidate --------------------------------------------------------------
 
-func revalidate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider, cfg platform.Config, limit int, batch int32) error {
+func revalidate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider, layouts *keyboard.Layouts,
+	cfg platform.Config, bundleRef string, limit int, batch int32) error {
+	bundles, err := replay.LoadBundles(cfg.ReplayBundleDir)
+	if err != nil {
+		return err
+	}
 	core, err := replay.NewCore(cfg.ReplayTimeout)
 	if err != nil {
 		return err
@@ -360,20 +890,22 @@ func revalidate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider,
 	if err != nil {
 		return err
 	}
+	// Every pass runs on a pinned engine: a run re-judged on an archived build
+	// gets a Core of that build, and everything else the vendored one.
+	engine := replay.NewPinnedEngine(bundles, core, cfg.ReplayTimeout)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	// Revalidation moves real verdicts, so it carries the same leaderboard
 	// projector the server does: a run demoted here leaves its board in the same
 	// transaction (docs/LEADERBOARDS.md, "Maintenance").
 	board := leaderboardpg.New(pool, cfg.LeaderboardRequireVerifiedEmail)
+	// …the TP projection beside it, for the same reason: a demoted run's TP
+	// leaves its player's total in that transaction too…
+	projections := replaypg.Projectors{board, ratingpg.New(pool)}
 	// …and the keyboard projector: revalidate's full pass IS the heatmap's
 	// backfill mechanism — the exactly-once stamp makes walking all history
 	// safe (docs/PROFILE.md, "Keyboard").
-	layouts, err := keyboard.Load()
-	if err != nil {
-		return err
-	}
 	worker := replay.NewWorker(
-		replaypg.New(pool, board).WithKeyboard(keyboardpg.New(layouts)),
+		replaypg.New(pool, projections).WithKeyboard(keyboardpg.New(layouts)),
 		reg,
 		quote.ReplayResolver{Store: quotepg.New(pool)},
 		replay.WorkerConfig{
@@ -389,13 +921,37 @@ func revalidate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider,
 	)
 
 	printPolicy(decider)
-	fmt.Printf("\nre-judging runs with policy_version < %s OR bundle_sha <> %s (limit %d, batch %d)\n\n",
-		decider.Judge().Version(), replay.BundleSHA()[:12], limit, batch)
+	var pass func() (int, error)
+	settled := "every judged run is already at the current policy AND the current bundle"
+	switch bundleRef {
+	case "recorded":
+		shas := bundles.SHAs()
+		fmt.Printf("\nre-judging runs with policy_version < %s on the bundle that judged each, for the %d bundle(s) loaded (limit %d, batch %d)\n\n",
+			decider.Judge().Version(), len(shas), limit, batch)
+		pass = func() (int, error) { return worker.RevalidateOnRecordedBundles(ctx, engine, logger, shas) }
+		settled = "every judged run on a loaded bundle is already at the current policy"
+	default:
+		target, err := bundles.Resolve(bundleRef)
+		if err != nil {
+			return err
+		}
+		if _, held := bundles.Get(target.SHA); !held {
+			// A .js path that is not loaded: the pinned engine could not
+			// build it, and every run would come back a replay error.
+			return fmt.Errorf("bundle %s is not loaded; put it in TYPEMORE_REPLAY_BUNDLE_DIR first", target.Short())
+		}
+		fmt.Printf("\nre-judging runs with policy_version < %s OR bundle_sha <> %s (limit %d, batch %d)\n\n",
+			decider.Judge().Version(), target.Short(), limit, batch)
+		pass = func() (int, error) { return worker.RevalidateOnBundle(ctx, engine, logger, target.SHA) }
+		if target.SHA != bundles.Current().SHA {
+			settled = "every judged run is already at the current policy AND on bundle " + target.Short()
+		}
+	}
 
 	started := time.Now()
 	total := 0
 	for total < limit {
-		n, err := worker.RevalidateBatch(ctx, core, logger)
+		n, err := pass()
 		if err != nil {
 			return err
 		}
@@ -406,11 +962,77 @@ func revalidate(ctx context.Context, pool *pgxpool.Pool, decider replay.Decider,
 	}
 	fmt.Printf("\nrevalidated %d run(s) in %s\n", total, time.Since(started).Round(time.Millisecond))
 	if total == 0 {
-		fmt.Println("nothing was stale: every judged run is already at the current policy AND the current bundle")
+		fmt.Println("nothing was stale: " + settled)
+	}
+	return nil
+}
+
+// --- dead letters --------------------------------------------------------------
+
+func deadLetters(ctx context.Context, pool *pgxpool.Pool, limit int32) error {
+	rows, err := replaypg.New(pool, nil).DeadLetters(ctx, limit)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		fmt.Println("no dead letters: every failed replay is either retrying or was judged")
+		return nil
+	}
+	fmt.Printf("%-36s  %-20s  %-14s  %-8s  %-12s  %s\n",
+		"run", "dead-lettered", "reason", "attempts", "shape", "last error")
+	for _, d := range rows {
+		fmt.Printf("%-36s  %-20s  %-14s  %-8d  %-12s  %s\n",
+			d.RunID, d.DeadLetteredAt.UTC().Format(time.DateTime), d.Reason, d.Attempts,
+			d.Mode+"/"+d.Lang, truncate(d.LastError, 80))
+	}
+	fmt.Printf("\n%d dead letter(s)", len(rows))
+	if len(rows) == int(limit) {
+		fmt.Printf(" (limit reached; raise -limit to see more)")
+	}
+	fmt.Println()
+	return nil
+}
+
+// retry releases dead letters. The ids it was given and the ids that came back
+// are compared, so a typo or an overridden run is reported rather than lost in
+// a count.
+func retry(ctx context.Context, pool *pgxpool.Pool, ids []uuid.UUID, limit int32) error {
+	released, err := replaypg.New(pool, nil).ReleaseDeadLetters(ctx, ids, limit)
+	if err != nil {
+		return err
+	}
+	for _, id := range released {
+		fmt.Println("released", id)
+	}
+	for _, id := range ids {
+		if !slices.Contains(released, id) {
+			fmt.Println("skipped ", id, "(not a dead letter, overridden by a moderator, or locked by a worker)")
+		}
 	}
+	fmt.Printf("\n%d run(s) back in the queue; the worker picks them up on its next poll\n", len(released))
 	return nil
 }
 
+func parseRunIDs(args []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(args))
+	for _, a := range args {
+		id, err := uuid.Parse(a)
+		if err != nil {
+			return nil, fmt.Errorf("run id %q: %w", a, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
 // printPolicy reports what is judging. The arithmetic comes through the optional
 // Describer seam, so a judge that will not explain itself degrades to its
 // version and its bundle rather than being reached into.
@@ -8,7 +8,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
@@ -17,6 +20,7 @@ import (
 	"runtime"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -27,6 +31,8 @@ import (
 	"github.com/typemore/typemore-server/api"
 	"github.com/typemore/typemore-server/internal/auth"
 	"github.com/typemore/typemore-server/internal/auth/pgstore"
+	"github.com/typemore/typemore-server/internal/daily"
+	dailypg "github.com/typemore/typemore-server/internal/daily/pgstore"
 	"github.com/typemore/typemore-server/internal/keyboard"
 	keyboardpg "github.com/typemore/typemore-server/internal/keyboard/pgstore"
 	"github.com/typemore/typemore-server/internal/leaderboard"
@@ -40,6 +46,8 @@ import (
 	profilepg "github.com/typemore/typemore-server/internal/profile/pgstore"
 	"github.com/typemore/typemore-server/internal/quote"
 	quotepg "github.com/typemore/typemore-server/internal/quote/pgstore"
+	"github.com/typemore/typemore-server/internal/rating"
+	ratingpg "github.com/typemore/typemore-server/internal/rating/pgstore"
 	"github.com/typemore/typemore-server/internal/replay"
 	replaypg "github.com/typemore/typemore-server/internal/replay/pgstore"
 	"github.com/typemore/typemore-server/internal/replay/policy"
@@ -173,12 +181,25 @@ func run() error {
 	// from importing each other, and keeps the bucket-key format to its single
 	// producer — the alternative was rebuilding `quote:<id>` inside the
 	// leaderboard's SQL, where nothing would keep it in step.
-	boardStore := leaderboardpg.New(pool, cfg.LeaderboardRequireVerifiedEmail)
+	boardWindows, err := leaderboard.ParseWindows(cfg.LeaderboardWindows, cfg.LeaderboardWindowSettle)
+	if err != nil {
+		return fmt.Errorf("TYPEMORE_LEADERBOARD_WINDOWS: %w", err)
+	}
+	boardStore := leaderboardpg.New(pool, cfg.LeaderboardRequireVerifiedEmail).WithWindows(boardWindows)
+	// TP (docs/LEADERBOARDS.md, "TP") is the second projection of the same
+	// eligible runs, maintained through the same seam: ratingStore answers
+	// ProjectRun exactly as the board store does, and the pair is handed out
+	// as one projector so neither writer can remember one and forget the other.
+	ratingStore := ratingpg.New(pool)
+	projections := replaypg.Projectors{boardStore, ratingStore}
 	// Both writers of runs.status project through the SAME adapter: the replay
 	// worker (below) and an operator override. "accepted" and "on the board" is
 	// one atomic fact whichever of them decided it.
-	runsStore.WithProjector(boardStore)
+	runsStore.WithProjector(projections)
 	runsSvc.WithModerator(runsStore)
+	// A run's detail says where it stands on its board ("top 7%"), read off
+	// the same rank ladder the percentile route uses.
+	runsSvc.WithStandings(runStandings{store: boardStore})
 	boardSvc := leaderboard.NewService(boardStore,
 		func(ctx context.Context) (uuid.UUID, bool) {
 			u, ok := auth.UserFrom(ctx)
@@ -186,7 +207,33 @@ func run() error {
 		},
 		quoteStore.WithdrawnIDs,
 		auth.NewInMemoryRateLimiter(cfg.LeaderboardIndexRateEvery, cfg.LeaderboardIndexRateBurst),
-		logger)
+		logger).WithWindows(boardWindows).WithQuoteGroups(quoteGroups{})
+	// Closed windows are archived by whichever instances run the freezer; the
+	// claim on each window is a primary-key insert, so running it everywhere
+	// is as safe as running it once.
+	if cfg.LeaderboardFreezeInterval > 0 {
+		go leaderboard.RunFreezer(ctx, boardStore, cfg.LeaderboardFreezeInterval, logger)
+	}
+	// Rank history is counted off the projection's marks, one board per claim,
+	// so any number of instances can share the work the same way.
+	if cfg.LeaderboardRankSnapshotInterval > 0 {
+		go leaderboard.RunRankSnapshots(ctx, boardStore, cfg.LeaderboardRankSnapshotInterval, logger)
+	}
+
+	// The daily challenge (docs/DAILY.md) sits between three domains and
+	// imports none of them: ingestion asks it whether a declared attempt is the
+	// day's challenge, it asks the quote corpus for a quote of the day, and it
+	// reads the day's winner off the leaderboard store — so rank 1 in the
+	// archive is exactly rank 1 on the board, ban filter included.
+	if len(cfg.DailyLangs) == 0 {
+		return errors.New("TYPEMORE_DAILY_LANGS must name at least one language")
+	}
+	dailySvc := daily.NewService(dailypg.New(pool), dailyQuotes{quoteStore},
+		dailyBoards{boardStore}, dailyConfig(cfg), logger)
+	runsSvc.WithDaily(dailyGate{dailySvc})
+	if cfg.DailyPrepareInterval > 0 {
+		go daily.RunScheduler(ctx, dailySvc, cfg.DailyPrepareInterval, logger)
+	}
 
 	// Keyboard layouts: the shared data asset (internal/keyboard/layouts) —
 	// the char → physical-key mapping the keyboard projection folds through,
@@ -241,7 +288,9 @@ func run() error {
 				info.WordCount = &dim
 			}
 			return info, true
-		}, layouts.LayoutFor, logger).WithRestrictions(moderationStore)
+		}, layouts.LayoutFor, logger).WithRestrictions(moderationStore).WithRatings(ratingStore)
+
+	ratingSvc := rating.NewService(ratingStore, logger)
 
 	quoteSvc := quote.NewService(quoteStore, logger)
 
@@ -321,6 +370,29 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	// Player history is off until an operator has read what `replayctl
+	// calibrate` says it would change. On, it is part of how every run is
+	// judged — ingestion and revalidation alike — so it hangs off the decider
+	// rather than the worker.
+	if cfg.ReplayHistoryEnabled {
+		decider = decider.WithHistory(replaypg.NewHistory(pool, keyboardpg.NewRhythms(pool, layouts)))
+	}
+	// The candidate is judged wherever the live policy is — ingestion and
+	// revalidation alike — and decides nothing (docs/REPLAY.md, "Shadow
+	// policy").
+	if cfg.ReplayShadowEnabled {
+		shadow, err := replay.ProvideShadow(cfg.ReplayShadowLabel, policy.Config{
+			FlagWeights:       cfg.ReplayShadowFlagWeights,
+			ReviewThreshold:   cfg.ReplayShadowReviewThreshold,
+			SustainedBurstSec: cfg.ReplayShadowSustainedBurstSec,
+		})
+		if err != nil {
+			return err
+		}
+		decider = decider.WithShadow(shadow)
+		logger.Info("shadow policy enabled", "label", shadow.Label(),
+			"policyVersion", shadow.Judge().Version())
+	}
 	// Loud, once, at the top: an instance that judges runs for correctness only
 	// is a legitimate deployment and a SILENT one is the worst outcome of making
 	// the policy removable. The same fact is served by /healthz, because the
@@ -338,24 +410,49 @@ func run() error {
 	} else {
 		logger.Info("review policy enabled", "policyVersion", judge.Version())
 	}
+	// The admin explain route re-judges one run under the worker's decider on
+	// this process's shared Core, whatever REPLAY_ENGINE says: goja is the
+	// reference, and an explanation is for reading, not for throughput.
+	runsSvc.WithExplainer(explainAdapter{replay.NewExplainer(replaypg.New(pool, nil), core, dictReg,
+		quote.ReplayResolver{Store: quoteStore}, decider, cfg.ReplayCanaryEpoch)})
+	// Timelines fold on the same Core. The route is public, so the folds are
+	// rationed per IP rather than by a permission.
+	runsSvc.WithTimeliner(timelineAdapter{replay.NewTimeliner(replaypg.New(pool, nil), core, dictReg,
+		quote.ReplayResolver{Store: quoteStore}, cfg.ReplayCanaryEpoch)},
+		auth.NewInMemoryRateLimiter(cfg.RunsTimelineRateEvery, cfg.RunsTimelineRateBurst))
+	// Match captures share the pool and the worker's core, and their results
+	// are read back through the same store, so it exists whether or not this
+	// replica judges anything.
+	matchStore := replaypg.NewMatchQueue(pool)
 	var workers sync.WaitGroup
 	defer workers.Wait()
 	if cfg.ReplayEnabled {
+		engine, err := replay.ParseEngineKind(cfg.ReplayEngine)
+		if err != nil {
+			return err
+		}
 		// Two text sources, two registries: dictionary bodies by hash for seeded
 		// runs, quote bytes by id for quote runs. The worker declares both as
 		// narrow interfaces; this is the only place that knows they are the
 		// dictionary registry and Postgres.
 		worker := replay.NewWorker(
-			replaypg.New(pool, boardStore).WithKeyboard(keyboardpg.New(layouts)),
+			replaypg.New(pool, projections).WithKeyboard(keyboardpg.New(layouts)),
 			dictReg,
 			quote.ReplayResolver{Store: quoteStore},
 			replay.WorkerConfig{
 				PollInterval:  cfg.ReplayPollInterval,
 				BatchSize:     cfg.ReplayBatchSize,
 				Concurrency:   cfg.ReplayConcurrency,
+				Engine:        engine,
 				ReplayTimeout: cfg.ReplayTimeout,
 				ShutdownGrace: cfg.ReplayShutdownGrace,
-				Decider:       decider,
+				// Retries are an ingestion-queue concern only; the decider
+				// keeps them out of revalidation and match captures itself.
+				Decider: decider.WithRetry(replay.RetryPolicy{
+					MaxAttempts: cfg.ReplayRetryMaxAttempts,
+					BaseDelay:   cfg.ReplayRetryBaseDelay,
+					MaxDelay:    cfg.ReplayRetryMaxDelay,
+				}),
 				// Unset until a human sets it — see the config field. An
 				// instance with no epoch judges every run exactly as it did
 				// before the canary detectors existed, which is the state this
@@ -364,6 +461,9 @@ func run() error {
 			},
 			logger,
 		)
+		if cfg.ReplayMatchesEnabled {
+			worker.WithMatches(matchStore)
+		}
 		workers.Add(1)
 		go func() {
 			defer workers.Done()
@@ -452,6 +552,24 @@ func run() error {
 		// field: the cooldown makes it a consequential act, not a switch flip.
 		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
 			Patch("/me/display-name", authSvc.HandleChangeDisplayName)
+		// The account's signed-in devices: list them, label one, sign one out
+		// or sign out everything but this one. The list is a safe read; the
+		// rest mutate and carry the Origin check like every other /me write.
+		r.With(authSvc.RequireAuth).Get("/me/sessions", authSvc.HandleSessions)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Post("/me/sessions/revoke-others", authSvc.HandleRevokeOtherSessions)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Patch("/me/sessions/{id}", authSvc.HandleRenameSession)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Delete("/me/sessions/{id}", authSvc.HandleRevokeSession)
+		// Personal access tokens: minted, listed and revoked from a browser
+		// session only. RequireAuth reads the cookie alone, so no token can
+		// manage tokens.
+		r.With(authSvc.RequireAuth).Get("/me/tokens", authSvc.HandleAccessTokens)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Post("/me/tokens", authSvc.HandleCreateAccessToken)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Delete("/me/tokens/{id}", authSvc.HandleRevokeAccessToken)
 		// The account's PROFILE — bio, board, links, badge showcase. A
 		// different route from /me/settings on purpose: those two switches
 		// decide who may read the profile, these are the profile. The GET is a
@@ -459,23 +577,45 @@ func run() error {
 		r.With(authSvc.RequireAuth).Get("/me/profile", profileSvc.HandleOwnProfile)
 		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
 			Patch("/me/profile", profileSvc.HandleUpdateProfile)
+		// Who the caller follows — the circle `?scope=following` ranks them
+		// in. On /me rather than /users/{name}: the list is the caller's.
+		r.With(authSvc.RequireAuth).Get("/me/following", profileSvc.HandleFollowing)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Post("/me/following/{name}", profileSvc.HandleFollow)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Delete("/me/following/{name}", profileSvc.HandleUnfollow)
 		// The dictionary catalogue is a public asset too — no session: guests
 		// play client-side and still need to pick a language.
 		r.Mount("/dictionaries", dictSvc.Routes())
 		// Runs: the ingestion and own-runs routes require a session (guests play
 		// client-only); GET /runs/{id}/replay and its /log sibling are public
 		// and the runs router draws that line itself, so the middleware is
-		// passed in rather than wrapped around the whole mount.
-		r.Mount("/runs", runsSvc.Routes(authSvc.RequireOrigin, authSvc.RequireAuth))
+		// passed in rather than wrapped around the whole mount. Both gates also
+		// admit a personal access token with the matching scope — the PB bot
+		// and the stats exporter are the reason tokens exist. The timeline is
+		// public too, but its owner may read a run nobody else can, so its gate
+		// resolves a caller without requiring one.
+		r.Mount("/runs", runsSvc.Routes(
+			authSvc.RequireAuthOrToken(auth.ScopeRunsRead),
+			authSvc.RequireAuthOrToken(auth.ScopeRunsSubmit),
+			authSvc.OptionalAuthOrToken(auth.ScopeRunsRead)))
 		// Leaderboards are public: a board nobody can read without an account is
 		// a board nobody links to. OptionalAuth resolves the session WITHOUT
 		// requiring one, which is what lets /{bucket}/me answer "your rank" on
 		// an otherwise anonymous surface.
 		r.With(authSvc.OptionalAuth).Mount("/leaderboards", boardSvc.Routes())
-		// Profile: session-scoped, the caller's own statistics only — the
-		// subtree carries its own RequireAuth so no route can be added public
-		// by accident (docs/PROFILE.md, "Privacy").
-		r.Mount("/profile", profileSvc.Routes(authSvc.RequireAuth))
+		// The global TP ranking: public like the boards it is summed from, and
+		// with nothing that varies by caller, so no session is resolved at all.
+		r.Mount("/rating", ratingSvc.Routes())
+		// The daily challenge is public for the same reason: today's setup and
+		// the archive of winners are the same for everyone. Submitting an
+		// attempt goes through /runs like any other run.
+		r.Mount("/daily", dailySvc.Routes())
+		// Profile: caller-scoped, the caller's own statistics only — the
+		// subtree carries its own auth gate so no route can be added public
+		// by accident (docs/PROFILE.md, "Privacy"). A profile:read token is
+		// the same caller as their session, so it passes too.
+		r.Mount("/profile", profileSvc.Routes(authSvc.RequireAuthOrToken(auth.ScopeProfileRead)))
 		// Public profiles: /users/{name}/… — no session required, but
 		// OptionalAuth resolves one when present, which is what lets an owner
 		// through their own closed profile. Privacy is enforced inside these
@@ -557,6 +697,9 @@ func run() error {
 		// IP, because the lobby screen polls it.
 		r.Method(http.MethodGet, "/rooms", wsHandler.LobbyHandler(
 			auth.NewInMemoryRateLimiter(cfg.LobbyRateEvery, cfg.LobbyRateBurst)))
+		// A finished match's verified results (docs/MATCH.md §6). Public, like
+		// the lobby: everyone named on it already saw everyone else in the room.
+		r.Mount("/matches", replay.NewMatchResultsService(matchStore, logger).Routes())
 	})
 
 	srv := &http.Server{
@@ -586,6 +729,141 @@ func (m mailerAdapter) Send(ctx context.Context, msg auth.Mail) error {
 	return m.sender.Send(ctx, mail.Message{To: msg.To, Subject: msg.Subject, Body: msg.Body})
 }
 
+// explainAdapter serves runs' Explainer seam from the replay package. runs
+// declares the explanation as JSON so it never imports replay; this is the one
+// place that knows both, and so the one place that maps the not-found error.
+type explainAdapter struct{ explainer *replay.Explainer }
+
+func (a explainAdapter) ExplainRun(ctx context.Context, id uuid.UUID, rejudge bool) (json.RawMessage, error) {
+	e, err := a.explainer.ExplainRun(ctx, id, rejudge)
+	if errors.Is(err, replay.ErrRunNotFound) {
+		return nil, runs.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(e)
+}
+
+// timelineAdapter serves runs' Timeliner seam, encoding the frames as the
+// NDJSON runs streams. The fold completes before the first line is written, so
+// a failed one is still an error runs can answer with a status.
+type timelineAdapter struct{ timeliner *replay.Timeliner }
+
+func (a timelineAdapter) RunTimeline(ctx context.Context, id uuid.UUID, w io.Writer) error {
+	frames, err := a.timeliner.RunTimeline(ctx, id)
+	if errors.Is(err, replay.ErrRunNotFound) {
+		return runs.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return replay.WriteTimeline(w, frames)
+}
+
+// runStandings serves runs' Standings seam from the leaderboard store, naming
+// the board by its key so runs never parses one.
+type runStandings struct{ store *leaderboardpg.Store }
+
+func (a runStandings) RunStanding(ctx context.Context, runID uuid.UUID) (runs.Standing, bool, error) {
+	bucket, st, ok, err := a.store.RunStanding(ctx, runID)
+	if err != nil || !ok {
+		return runs.Standing{}, false, err
+	}
+	return runs.Standing{
+		Board: bucket.Key(), Rank: st.Rank, Entries: st.Entries, Percentile: st.Percentile,
+	}, true, nil
+}
+
+// dailyGate serves runs' DailyGate seam from the daily service, translating
+// its refusals into the runs domain's sentinels.
+type dailyGate struct{ svc *daily.Service }
+
+func (g dailyGate) AdmitDaily(ctx context.Context, a runs.DailyAttempt) error {
+	err := g.svc.Admit(ctx, daily.Attempt{
+		Day: a.Day, Mode: a.Mode, DurationMs: a.DurationMs, WordCount: a.WordCount,
+		Lang: a.Lang, Seed: a.Seed, Setup: a.Setup,
+	})
+	switch {
+	case errors.Is(err, daily.ErrClosed):
+		return runs.ErrDailyClosed
+	case errors.Is(err, daily.ErrMismatch):
+		return runs.ErrDailyMismatch
+	}
+	return err
+}
+
+// dailyQuotes draws the quote of the day from the published corpus: a
+// medium-length quote in the day's language, so a quote day takes about as
+// long as a seeded one.
+type dailyQuotes struct{ store *quotepg.Store }
+
+func (q dailyQuotes) PickQuote(ctx context.Context, lang string) (uuid.UUID, error) {
+	group := quote.LenMedium
+	picked, err := q.store.Random(ctx, quote.Filter{Lang: lang, Group: &group})
+	if errors.Is(err, quote.ErrNotFound) {
+		return uuid.Nil, daily.ErrNoQuote
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return picked.ID, nil
+}
+
+// quoteGroups lends the board index the quote domain's length-group names, so
+// `group=short` means on a board what it means on GET /quotes.
+type quoteGroups struct{}
+
+func (quoteGroups) ParseGroup(name string) (int16, bool) {
+	g, err := quote.ParseLenGroup(name)
+	return int16(g), err == nil
+}
+
+func (quoteGroups) GroupName(group int16) string { return quote.LenGroup(group).String() }
+
+// dailyBoards reads a day's board through the leaderboard store's own page
+// query, which is what keeps a banned player off the archive.
+type dailyBoards struct{ store *leaderboardpg.Store }
+
+// BoardKey spells the key without NewDailyBucket's validation: every day the
+// daily domain hands over is a stored date, already a UTC midnight.
+func (b dailyBoards) BoardKey(day time.Time) string {
+	return leaderboard.Bucket{Day: day}.Key()
+}
+
+func (b dailyBoards) Winner(ctx context.Context, day time.Time) (daily.Winner, bool, error) {
+	bucket, err := leaderboard.NewDailyBucket(day)
+	if err != nil {
+		return daily.Winner{}, false, err
+	}
+	top, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 1)
+	if err != nil || len(top) == 0 {
+		return daily.Winner{}, false, err
+	}
+	e := top[0]
+	return daily.Winner{
+		UserID: e.UserID, DisplayName: e.DisplayName, RunID: e.RunID,
+		Score: e.Score, WPM: e.WPM, Acc: e.Acc,
+	}, true, nil
+}
+
+// dailyConfig translates platform.Config into the daily domain's own config.
+// The shapes are the leaderboard's ranked set: a day drawn at any other size
+// would have a board nobody can place on.
+func dailyConfig(cfg platform.Config) daily.Config {
+	var shapes []daily.Shape
+	for _, ms := range leaderboard.RankedDurationsMs {
+		shapes = append(shapes, daily.Shape{Mode: daily.ModeTime, Size: ms})
+	}
+	for _, n := range leaderboard.RankedWordCounts {
+		shapes = append(shapes, daily.Shape{Mode: daily.ModeWords, Size: n})
+	}
+	return daily.Config{
+		Salt: cfg.DailySalt, Langs: cfg.DailyLangs, Shapes: shapes,
+		QuoteEvery: cfg.DailyQuoteEvery, Grace: cfg.DailyGrace,
+	}
+}
+
 // newMailer picks the SMTP sender when a host is configured, otherwise the dev
 // log sender (which prints the verification/reset link to the logs).
 func newMailer(cfg platform.Config, log *slog.Logger) auth.Mailer {
@@ -631,16 +909,35 @@ func authConfig(cfg platform.Config) auth.Config {
 			ClientSecret: cfg.GoogleClientSecret,
 		}
 	}
+	if cfg.DiscordClientID != "" {
+		providers[auth.ProviderDiscord] = auth.ProviderCredentials{
+			ClientID:     cfg.DiscordClientID,
+			ClientSecret: cfg.DiscordClientSecret,
+		}
+	}
+	// LoadConfig has already vetted these names against the built-ins and the
+	// identities table's CHECK, so none of them can shadow the entries above.
+	for _, p := range cfg.OIDCProviders {
+		providers[p.Name] = auth.ProviderCredentials{
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			Issuer:       p.Issuer,
+			DisplayName:  p.DisplayName,
+		}
+	}
 	return auth.Config{
-		FrontendOrigin:    cfg.FrontendOrigin,
-		CookieName:        cfg.CookieName,
-		CookieDomain:      cfg.CookieDomain,
-		CookieSecure:      cfg.CookieSecure,
-		SessionTTL:        cfg.SessionTTL,
-		OAuthRedirectBase: cfg.OAuthRedirectBase,
-		Providers:         providers,
-		HashConcurrency:   hashConcurrency(cfg),
-		HashWait:          cfg.AuthHashWait,
+		FrontendOrigin:       cfg.FrontendOrigin,
+		PasskeyRPID:          cfg.PasskeyRPID,
+		CookieName:           cfg.CookieName,
+		CookieDomain:         cfg.CookieDomain,
+		CookieSecure:         cfg.CookieSecure,
+		SessionTTL:           cfg.SessionTTL,
+		OAuthRedirectBase:    cfg.OAuthRedirectBase,
+		Providers:            providers,
+		HashConcurrency:      hashConcurrency(cfg),
+		HashWait:             cfg.AuthHashWait,
+		AccessTokenRateEvery: cfg.AccessTokenRateEvery,
+		AccessTokenRateBurst: cfg.AccessTokenRateBurst,
 	}
 }
 
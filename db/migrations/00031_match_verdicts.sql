@@ -0,0 +1,80 @@
+-- +goose Up
+--
+-- Verified match results (docs/MATCH.md §6, docs/REPLAY.md "Match captures").
+--
+-- 00003 landed the capture and said in as many words that nothing validated
+-- it, so a match's "result" has been whatever each client showed its own
+-- player, computed from its own copy of the log. The replay worker now folds
+-- every seat's capture through the same bundle that judges solo runs and
+-- records what the SERVER found.
+--
+-- The shape is 00019's, on purpose. The seat's lifecycle (`status`) lives on
+-- the seat row; the judgement lives in a 1:1 satellite whose row exists if and
+-- only if the seat has been judged, so nothing a decision always produces is
+-- nullable-by-phase. Putting the verdict columns straight onto match_runs would
+-- recreate exactly the table 00019 took apart.
+
+-- --- 1. The seat's lifecycle -------------------------------------------------
+--
+-- The run vocabulary (internal/runstatus), not a new one: a seat is accepted,
+-- flagged for review or rejected on the same rules a solo run is. Every row
+-- already in the table lands as 'pending', and the worker drains that history
+-- exactly as it drains a match that ended a second ago.
+ALTER TABLE match_runs
+    ADD COLUMN status text NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'flagged', 'rejected'));
+
+-- --- 2. The judgement ---------------------------------------------------------
+CREATE TABLE match_run_verdicts (
+    match_run_id   uuid        PRIMARY KEY REFERENCES match_runs (id) ON DELETE CASCADE,
+    -- Denormalized from match_runs.match_id so one match's results are one
+    -- index range here, without a join back through the captures — which are
+    -- the wide rows of this schema (gzip'd logs) and have no business being
+    -- touched by a results read.
+    match_id       text        NOT NULL REFERENCES matches (id) ON DELETE CASCADE,
+
+    -- The core's own Metrics / ScoreResult, never re-encoded by Go. NULL when
+    -- the capture could not be replayed or the log was refused, as on
+    -- run_verdicts.
+    server_metrics jsonb,
+    server_score   jsonb,
+    -- server_score->'total' as a plain integer: placement orders by it and the
+    -- results read returns it, and neither should have to parse jsonb to do so.
+    score          bigint CHECK (score IS NULL OR score >= 0),
+    -- 1-based competition ranking ("1, 1, 3"). NULL for a seat with no
+    -- verified place: not accepted, or never finished.
+    placement      int CHECK (placement IS NULL OR placement >= 1),
+
+    validation     jsonb       NOT NULL,
+    bundle_sha     text        NOT NULL,
+    policy_version smallint,
+    last_error     text,
+    validated_at   timestamptz NOT NULL DEFAULT now(),
+
+    -- A place is a claim about a verified score; it cannot exist without one.
+    -- The converse does not hold — a flagged seat keeps its number and has no
+    -- place until a human says otherwise.
+    CONSTRAINT match_run_verdicts_placement_needs_score CHECK (
+        placement IS NULL OR score IS NOT NULL)
+);
+
+CREATE INDEX match_run_verdicts_match_idx ON match_run_verdicts (match_id);
+
+-- --- 3. The queue ------------------------------------------------------------
+--
+-- The unit the worker claims is the MATCH, not the seat. A placement means
+-- something only once every seat of the match has a verdict, and claiming
+-- seats independently would let two workers each rank half a match. So the
+-- queue marker lives on `matches`, and the claim locks the match row with
+-- FOR UPDATE SKIP LOCKED exactly as the run claim locks a pending run.
+ALTER TABLE matches ADD COLUMN validated_at timestamptz;
+
+-- Unvalidated matches, oldest first. Partial, so the index holds the backlog
+-- rather than every match ever played.
+CREATE INDEX matches_unvalidated_idx ON matches (ended_at) WHERE validated_at IS NULL;
+
+-- +goose Down
+DROP INDEX matches_unvalidated_idx;
+ALTER TABLE matches DROP COLUMN validated_at;
+DROP TABLE match_run_verdicts;
+ALTER TABLE match_runs DROP COLUMN status;
@@ -0,0 +1,53 @@
+-- +goose Up
+--
+-- Automatic retry for transient replay failures (docs/REPLAY.md, "Retry and
+-- dead letters").
+--
+-- Until now a run whose replay timed out or threw was flagged on the spot with
+-- `attempts + 1`, and nothing ever read `attempts` again: the run sat in
+-- 'flagged' until an operator hand-wrote an UPDATE. On a loaded box a goja
+-- timeout is weather, not evidence, so an honest PB could stay in limbo
+-- forever over one slow second.
+--
+-- The worker now re-queues such a run itself. The run STAYS 'pending' — no
+-- verdict row, so the 00019 invariant "status <> 'pending' <=> a verdict row
+-- exists" is untouched — with a time before which the claim must not pick it
+-- up again. After the configured number of attempts it is flagged exactly as
+-- before, and additionally marked dead-lettered so an operator can find it.
+
+-- --- 1. Backoff --------------------------------------------------------------
+--
+-- NULL for every run that has never failed, which is nearly all of them. The
+-- claim reads it as "not before"; a NULL is claimable at once.
+ALTER TABLE runs ADD COLUMN next_attempt_at timestamptz;
+
+-- --- 2. Dead letters ---------------------------------------------------------
+--
+-- A timestamp rather than a fifth status. The run's status is what every
+-- reader already understands — 'flagged', off the boards, in the review queue
+-- — and a new value would ripple through every CHECK, every view and every
+-- client that switches on it to say something those readers do not need to
+-- know. The marker is for operators: "the worker gave up on this, a human has
+-- to release it" (`replayctl dead-letters`, `replayctl retry`).
+ALTER TABLE runs ADD COLUMN dead_lettered_at timestamptz;
+
+-- The operator's read. Partial, so it holds the dead letters and nothing else.
+CREATE INDEX runs_dead_letter_idx ON runs (dead_lettered_at) WHERE dead_lettered_at IS NOT NULL;
+
+-- Every run already flagged for a replay failure IS a dead letter under the
+-- new rules: it failed, and nothing will retry it. Marking them is what puts
+-- the existing limbo in front of `replayctl dead-letters` instead of leaving
+-- it invisible to the tool built to clear it. A run a human has already ruled
+-- on is theirs, not the worker's, and is left alone.
+UPDATE runs r
+SET dead_lettered_at = v.validated_at
+FROM run_verdicts v
+WHERE v.run_id = r.id
+  AND r.status = 'flagged'
+  AND v.validation ->> 'reason' IN ('replay_timeout', 'replay_error')
+  AND NOT EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = r.id);
+
+-- +goose Down
+DROP INDEX runs_dead_letter_idx;
+ALTER TABLE runs DROP COLUMN dead_lettered_at;
+ALTER TABLE runs DROP COLUMN next_attempt_at;
@@ -0,0 +1,50 @@
+-- +goose Up
+--
+-- Shadow evaluation of a candidate review policy (docs/REPLAY.md, "Shadow
+-- policy").
+--
+-- The worker can be given a second judge — different weights, a different
+-- threshold — that is asked about every run the real judge is asked about and
+-- is never acted on. Its answer lands here, beside the verdict it did not
+-- make, so a tuning can be measured against live traffic before it is
+-- shipped as a policy version and `make revalidate` applies it to anyone.
+--
+-- Three columns on the verdict row rather than a table of their own. The
+-- answer is 1:1 with the verdict, is written by the same upsert in the same
+-- transaction, and is only ever read joined to it; a satellite of a satellite
+-- would buy a second insert per run and nothing else.
+--
+--   * shadow_label — the operator's name for the candidate. A candidate built
+--     from the same policy code as the live judge reports the same version,
+--     so the label is what tells one experiment's rows from the next one's.
+--   * shadow_status — what the candidate would have made the run: accepted or
+--     flagged. Never rejected: rejection is decided before any judge runs.
+--   * shadow_validation — the validation document the candidate would have
+--     written, with its own policy block and reason. Same shape as validation,
+--     so the review tooling reads both the same way.
+--
+-- All three or none. A verdict the candidate never saw — a refused log, an
+-- unreplayable one, any verdict written while no candidate was configured —
+-- has none, and a re-judgement rewrites them with the rest of the row.
+ALTER TABLE run_verdicts
+    ADD COLUMN shadow_label      text,
+    ADD COLUMN shadow_status     text,
+    ADD COLUMN shadow_validation jsonb,
+    ADD CONSTRAINT run_verdicts_shadow_check CHECK (
+        (shadow_label IS NULL AND shadow_status IS NULL AND shadow_validation IS NULL)
+        OR (shadow_label IS NOT NULL AND shadow_status IN ('accepted', 'flagged')
+            AND shadow_validation IS NOT NULL)
+    );
+
+-- The admin diff report's read: one candidate's rows, newest first. Partial,
+-- so a deployment that never runs a candidate pays nothing for it.
+CREATE INDEX run_verdicts_shadow_idx ON run_verdicts (shadow_label, validated_at DESC)
+    WHERE shadow_label IS NOT NULL;
+
+-- +goose Down
+DROP INDEX run_verdicts_shadow_idx;
+ALTER TABLE run_verdicts
+    DROP CONSTRAINT run_verdicts_shadow_check,
+    DROP COLUMN shadow_validation,
+    DROP COLUMN shadow_status,
+    DROP COLUMN shadow_label;
@@ -0,0 +1,79 @@
+-- +goose Up
+--
+-- Priority lanes for the replay queue (docs/REPLAY.md, "Priority lanes").
+--
+-- The claim used to be one scan of runs_pending_idx in created_at order. That
+-- is fair to runs and unfair to players: a burst of throwaway runs put in
+-- front of a run that may be a new record makes the record wait behind every
+-- one of them, and the leaderboard is the one place where that wait is
+-- visible to everybody.
+--
+-- Each run is now filed into one of three lanes when it is INSERTED, from what
+-- it claims about itself:
+--
+--   * contender — a ranked shape whose client score beats the player's own
+--     slot on that board, or who holds no slot there yet: the run would move a
+--     board if it is accepted.
+--   * ranked    — a ranked shape that would not.
+--   * unranked  — everything that ranks nowhere: sizes outside the ranked set,
+--     custom text, seeded repeats.
+--
+-- The lane is decided ONCE, at ingestion, and never moves. It is a scheduling
+-- hint and not a judgement, which is why the client's own score is good
+-- enough to file it: a client that lies its way into the contender lane gets
+-- judged sooner, and the lie is a score_mismatch when it is.
+ALTER TABLE runs
+    ADD COLUMN lane text NOT NULL DEFAULT 'ranked'
+        CHECK (lane IN ('contender', 'ranked', 'unranked'));
+
+-- +goose StatementBegin
+-- The lane a run is filed into. STABLE, not IMMUTABLE: it reads the player's
+-- current slot.
+--
+-- The ranked-shape test is leaderboard_eligible_runs' own, minus the parts
+-- only a verdict can answer. The slot is found by the board's COMPONENTS
+-- through the slot's run rather than by bucket_key, which only Go formats
+-- (leaderboard.Bucket.Key): leaderboard_entries_user_idx narrows it to the
+-- player's handful of slots, and each is a primary-key probe into runs.
+CREATE FUNCTION run_lane(
+    p_user_id uuid, p_mode text, p_duration_ms integer, p_word_count integer,
+    p_lang text, p_setup jsonb, p_client_score jsonb
+) RETURNS text
+    LANGUAGE sql STABLE PARALLEL SAFE AS $$
+    SELECT CASE
+        WHEN run_adopted_from(p_setup) IS NOT NULL
+            THEN 'unranked'
+        WHEN run_quote_id(p_setup) IS NULL
+         AND NOT (run_text_source_kind(p_setup) = 'seeded'
+              AND ((p_mode = 'time'  AND p_duration_ms IN (15000, 30000, 60000))
+                OR (p_mode = 'words' AND p_word_count  IN (25, 50, 100))))
+            THEN 'unranked'
+        WHEN jsonb_typeof(p_client_score -> 'total') <> 'number'
+            THEN 'ranked'
+        WHEN (p_client_score ->> 'total')::numeric > coalesce((
+            SELECT max(e.score)
+            FROM leaderboard_entries e
+                     JOIN runs r ON r.id = e.run_id
+            WHERE e.user_id = p_user_id
+              AND CASE WHEN run_quote_id(p_setup) IS NOT NULL
+                  THEN run_quote_id(r.setup) = run_quote_id(p_setup)
+                  ELSE run_quote_id(r.setup) IS NULL
+                       AND r.mode = p_mode
+                       AND r.lang = p_lang
+                       AND r.duration_ms IS NOT DISTINCT FROM p_duration_ms
+                       AND r.word_count  IS NOT DISTINCT FROM p_word_count
+                  END), -1)
+            THEN 'contender'
+        ELSE 'ranked'
+    END
+$$;
+-- +goose StatementEnd
+
+-- The lane-aware claim: one lane's due runs, oldest first. runs_pending_idx
+-- stays — the stats read and every status-keyed scan still use it.
+CREATE INDEX runs_pending_lane_idx ON runs (lane, created_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX runs_pending_lane_idx;
+DROP FUNCTION run_lane(uuid, text, integer, integer, text, jsonb, jsonb);
+ALTER TABLE runs DROP COLUMN lane;
@@ -0,0 +1,62 @@
+-- +goose Up
+--
+-- TP, the profile rating (SCORING_CONCEPT §5, docs/LEADERBOARDS.md "TP").
+--
+-- Two tables, both projections, both rebuildable from leaderboard_eligible_runs
+-- alone (`make rebuild-tp`):
+--
+--   run_tp   one row per eligible run: the TP that run is worth on its own,
+--            and the bucket it is deduplicated within
+--   user_tp  one row per rated player: the decayed sum over their best run
+--            per bucket
+--
+-- The per-run value is COMPUTED IN GO (internal/rating), not here. The formula
+-- is a versioned pure function the way scoreV1 is, and a second spelling of it
+-- in SQL is a rebalance that has to land in two places at once. What SQL keeps
+-- is the part SQL is good at: "the best row per bucket for this player", off an
+-- index, inside the verdict transaction.
+--
+-- tp_version is stored on both. A row whose version is not the running
+-- formula's is stale — still counted, still ranked, and walked forward by the
+-- next rebuild, which is how a formula change ships: deploy, then rebuild, the
+-- same order a leaderboard policy change takes.
+--
+-- `bucket` is the dedup coordinate, not a board key: the quote id for a quote
+-- run (the quote IS the dimension), and the language coordinates otherwise. It
+-- is written by the projection from the eligible view's own columns, so a run
+-- cannot be deduplicated into a bucket the view would not have put it in. The
+-- board key's format stays with its single producer in the leaderboard domain;
+-- nothing ever parses this one.
+--
+-- ON DELETE CASCADE on both, mirroring leaderboard_entries: deleting an account
+-- takes its rating with it, and deleting a run takes its contribution — which
+-- leaves user_tp one recompute behind until the next verdict or rebuild, the
+-- same drift a board slot has in that case.
+CREATE TABLE run_tp (
+    run_id     uuid PRIMARY KEY REFERENCES runs (id) ON DELETE CASCADE,
+    user_id    uuid             NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    bucket     text             NOT NULL,
+    tp         double precision NOT NULL CHECK (tp >= 0),
+    tp_version smallint         NOT NULL
+);
+
+-- The per-player, per-bucket best: DISTINCT ON (bucket) ORDER BY bucket, tp
+-- DESC reads this in order and never sorts.
+CREATE INDEX run_tp_user_bucket_idx ON run_tp (user_id, bucket, tp DESC);
+
+CREATE TABLE user_tp (
+    user_id    uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    tp         double precision NOT NULL CHECK (tp >= 0),
+    tp_version smallint         NOT NULL,
+    -- How many runs the total was taken over (at most the formula's top N).
+    runs       integer          NOT NULL CHECK (runs >= 0),
+    updated_at timestamptz      NOT NULL DEFAULT now()
+);
+
+-- The global ranking and its keyset continuation: best TP first, user_id as the
+-- tiebreak so the order is total and therefore pageable.
+CREATE INDEX user_tp_rank_idx ON user_tp (tp DESC, user_id);
+
+-- +goose Down
+DROP TABLE user_tp;
+DROP TABLE run_tp;
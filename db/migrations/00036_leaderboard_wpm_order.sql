@@ -0,0 +1,29 @@
+-- +goose Up
+--
+-- The WPM ordering of a board (docs/LEADERBOARDS.md, "Orders").
+--
+-- A second ORDERING of the rows leaderboard_entries already holds, not a second
+-- projection: each entry is still the player's best-SCORING eligible run in the
+-- bucket, and `?order=wpm` ranks those same entries by the speed they were set
+-- at. That is what keeps it free — promotion, demotion, rebuild and bans move
+-- both orders at once, because there is only one set of rows to move.
+--
+-- The ranking is (wpm DESC, achieved_at ASC, user_id ASC): fastest first, then
+-- the same tie rule the score order has — who got there first, to the
+-- microsecond — and the player id to make the order total.
+--
+-- Unlike the score order this needs no packed key (00011). wpm is numeric and
+-- stored exact, so it is itself the single descending column a continuation
+-- can start from: `wpm <= cursor` lands the scan on the cursor row, and the
+-- tie-break clauses only filter the handful of rows sharing its exact wpm —
+-- the same shape, and the same plan, as the sort_key continuation. The cursor's
+-- wpm round-trips exactly: every stored wpm is the shortest decimal spelling of
+-- a JSON double, and the cursor carries the same spelling back.
+--
+-- user_id is in the index for the reason it is in leaderboard_sort_idx: the
+-- count of better positions stays index-only through leaderboard_ranked.
+CREATE INDEX leaderboard_wpm_idx
+    ON leaderboard_entries (bucket_key, wpm DESC, achieved_at ASC, user_id ASC);
+
+-- +goose Down
+DROP INDEX leaderboard_wpm_idx;
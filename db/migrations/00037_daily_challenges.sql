@@ -0,0 +1,164 @@
+-- +goose Up
+--
+-- The daily challenge (docs/DAILY.md): one text, one shape and one mod set per
+-- UTC day, shared by everyone, with one ranked attempt each and a board of its
+-- own.
+--
+-- Four pieces, and what each is for:
+--
+--   daily_challenges       the day's challenge, written ONCE and never edited
+--   run_daily_day()        which day, if any, a run declared itself an attempt at
+--   runs_one_daily_attempt the "one attempt" rule, as an index rather than a read
+--   daily_eligible_runs    which attempts may hold a slot on the day's board
+--
+-- and one change to an old piece: a run may now hold TWO board slots — its
+-- ordinary one, and the day's.
+
+-- One row per UTC day. The challenge is DERIVED (internal/daily, from a salted
+-- hash of the date) but STORED, and the stored row is the authority: a salt
+-- rotation, a change to the derivation or a quote withdrawn later must not be
+-- able to rewrite a day people have already played. The scheduler writes today
+-- and tomorrow ahead of time; reads also write on a miss, so a deployment
+-- without the scheduler still has a challenge every day.
+--
+-- The shape mirrors the runs it admits. A seeded day carries a mode, exactly
+-- one dimension, a language, a seed and the text-generating mods; a quote day
+-- carries the quote and nothing else — the quote IS the text, as on its board
+-- (SCORING_CONCEPT §6).
+CREATE TABLE daily_challenges (
+    day         date        PRIMARY KEY,
+    mode        text        NOT NULL,
+    duration_ms int,
+    word_count  int,
+    lang        text,
+    seed        bigint,
+    -- The mods that shape the TEXT (punctuation, numbers, randomCase, reverse),
+    -- in run_mods' spelling so an attempt matches by containment. Everything
+    -- else — difficulty, blind, nospace — stays the player's choice and
+    -- multiplies their score, as it does on every other board.
+    mods        jsonb       NOT NULL DEFAULT '{}'::jsonb,
+    -- RESTRICT: a quote that was a day's text is history, and history is not
+    -- deleted out from under its board. Withdrawal (00025) never deletes.
+    quote_id    uuid        REFERENCES quotes (id) ON DELETE RESTRICT,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+
+    CONSTRAINT daily_challenges_shape CHECK (
+        CASE WHEN quote_id IS NOT NULL
+            THEN mode = 'quote' AND duration_ms IS NULL AND word_count IS NULL
+                 AND lang IS NULL AND seed IS NULL
+            ELSE lang IS NOT NULL AND seed IS NOT NULL
+                 AND ((mode = 'time'  AND duration_ms IS NOT NULL AND word_count IS NULL)
+                   OR (mode = 'words' AND word_count IS NOT NULL AND duration_ms IS NULL))
+        END)
+);
+
+-- +goose StatementBegin
+-- The day a run declared itself an attempt at — `setup.daily`, "YYYY-MM-DD" —
+-- or NULL. Read out of the setup document like run_quote_id and
+-- run_adopted_from, and for the same reason: the declaration is part of what
+-- the player set up, it is stored verbatim with everything else they set up,
+-- and ingestion validates its shape before it gets here.
+--
+-- TEXT, not date. Casting would make a malformed value an ERROR, and this runs
+-- inside the worker's verdict transaction and inside an index; the pattern
+-- check makes a malformed value NULL instead — not an attempt at anything.
+-- Rows older than this migration carry no such key.
+CREATE FUNCTION run_daily_day(setup jsonb) RETURNS text
+    LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT CASE WHEN jsonb_typeof(setup -> 'daily') = 'string'
+                 AND setup ->> 'daily' ~ '^[0-9]{4}-[0-9]{2}-[0-9]{2}$'
+                THEN setup ->> 'daily' END
+$$;
+-- +goose StatementEnd
+
+-- One attempt per player per day, enforced by the table that stores attempts.
+--
+-- Not a pre-check in Go: two tabs submitting at once would both pass a read and
+-- both insert. Here the second insert fails and ingestion answers 409
+-- daily_already_attempted. The attempt is spent when the run is STORED — a run
+-- the worker later rejects was still the player's attempt, which is what "one
+-- attempt" means when the alternative is submitting until one passes.
+CREATE UNIQUE INDEX runs_one_daily_attempt ON runs (user_id, run_daily_day(setup))
+    WHERE run_daily_day(setup) IS NOT NULL;
+
+-- +goose StatementBegin
+-- Whether a run is an attempt at challenge c — the ONE definition, read both by
+-- ingestion (the 422 before the run is stored) and by daily_eligible_runs (who
+-- may hold a slot). The door and the board cannot disagree because they ask
+-- the same function.
+--
+-- Every part of it is a function that already existed: run_mods for the mods,
+-- run_quote_id for the quote, run_text_source_kind, run_adopted_from. A seeded
+-- REPEAT is refused like everywhere else — taking a text from someone else's
+-- run is not sitting the challenge.
+CREATE FUNCTION daily_attempt_matches(
+    c daily_challenges, run_mode text, run_duration_ms int, run_word_count int,
+    run_lang text, run_seed bigint, run_setup jsonb
+) RETURNS boolean
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT coalesce(
+        run_adopted_from(run_setup) IS NULL
+        AND CASE WHEN c.quote_id IS NOT NULL
+            THEN run_quote_id(run_setup) = c.quote_id
+            ELSE run_text_source_kind(run_setup) = 'seeded'
+                 AND run_mode = c.mode
+                 AND run_duration_ms IS NOT DISTINCT FROM c.duration_ms
+                 AND run_word_count IS NOT DISTINCT FROM c.word_count
+                 AND run_lang = c.lang
+                 AND run_seed = c.seed
+                 AND run_mods(run_setup) @> c.mods
+            END,
+        false)
+$$;
+-- +goose StatementEnd
+
+-- Which attempts may hold a slot on their day's board: an eligible run (the
+-- board rules — accepted, not a repeat, numbers well formed) that declared a
+-- day AND is that day's challenge.
+--
+-- Layered on leaderboard_eligible_runs rather than beside it, so a daily slot
+-- can never be held by a run the ordinary boards would refuse. The columns are
+-- the eligible view's plus the day.
+--
+-- The join spells the day as text because run_daily_day does (see there);
+-- to_char rather than ::text so the spelling does not depend on DateStyle.
+CREATE VIEW daily_eligible_runs AS
+SELECT c.day,
+       e.run_id, e.user_id, e.mode, e.duration_ms, e.word_count, e.lang,
+       e.text_source_kind, e.quote_id, e.score, e.wpm, e.raw, e.acc, e.mods,
+       e.achieved_at
+FROM leaderboard_eligible_runs e
+         JOIN runs r ON r.id = e.run_id
+         JOIN daily_challenges c ON to_char(c.day, 'YYYY-MM-DD') = run_daily_day(r.setup)
+WHERE daily_attempt_matches(c, r.mode, r.duration_ms, r.word_count, r.lang, r.seed, r.setup);
+
+-- The day's board lives in leaderboard_entries like every other board, under
+-- the key leaderboard.Bucket.Key() gives it ("daily:YYYY-MM-DD"). daily_day is
+-- set on exactly those rows. It is NOT a second spelling of the key for SQL to
+-- match on — reads still go by bucket_key — it is what lets the two per-run
+-- rules below, and the PB cards, tell the two kinds of slot apart without
+-- parsing a key.
+ALTER TABLE leaderboard_entries ADD COLUMN daily_day date;
+
+-- 00006's "a run holds at most one slot anywhere" stops being true: the day's
+-- attempt is also a run on its ordinary board, and it should be — the daily is
+-- an extra ranking, not a detour that costs the player a PB. What stays true
+-- is split in two: at most one ORDINARY slot per run, and at most one DAILY
+-- slot per run. Either index still makes a projection bug fail loudly.
+DROP INDEX leaderboard_entries_run_idx;
+CREATE UNIQUE INDEX leaderboard_entries_run_idx ON leaderboard_entries (run_id)
+    WHERE daily_day IS NULL;
+CREATE UNIQUE INDEX leaderboard_entries_daily_run_idx ON leaderboard_entries (run_id)
+    WHERE daily_day IS NOT NULL;
+
+-- +goose Down
+DROP INDEX leaderboard_entries_daily_run_idx;
+DROP INDEX leaderboard_entries_run_idx;
+DELETE FROM leaderboard_entries WHERE daily_day IS NOT NULL;
+CREATE UNIQUE INDEX leaderboard_entries_run_idx ON leaderboard_entries (run_id);
+ALTER TABLE leaderboard_entries DROP COLUMN daily_day;
+DROP VIEW daily_eligible_runs;
+DROP FUNCTION daily_attempt_matches(daily_challenges, text, int, int, text, bigint, jsonb);
+DROP INDEX runs_one_daily_attempt;
+DROP FUNCTION run_daily_day(jsonb);
+DROP TABLE daily_challenges;
@@ -0,0 +1,98 @@
+-- +goose Up
+--
+-- Time-windowed boards (docs/LEADERBOARDS.md, "Windows"): every language and
+-- quote board can also be read over this week, this month, or a named season,
+-- and a window that has closed is frozen into an archive.
+--
+--   leaderboard_seasons    the named windows an operator declares
+--   leaderboard_entries    gains `period`: a windowed board's rows live beside
+--                          the all-time ones, under their own bucket key
+--   leaderboard_periods    one row per window that has been frozen
+--   leaderboard_snapshots  the final standings of each frozen board
+
+-- A season is a span with a slug and a name. Seasons may leave gaps between
+-- them but never overlap — "the current season" has to be one answer — and the
+-- exclusion constraint is what makes that true rather than intended.
+CREATE TABLE leaderboard_seasons (
+    id        text        PRIMARY KEY CHECK (id ~ '^[a-z][a-z0-9-]{0,31}$'),
+    name      text        NOT NULL CHECK (btrim(name) <> ''),
+    starts_at timestamptz NOT NULL,
+    ends_at   timestamptz NOT NULL,
+
+    CONSTRAINT leaderboard_seasons_span CHECK (starts_at < ends_at),
+    CONSTRAINT leaderboard_seasons_no_overlap
+        EXCLUDE USING gist (tstzrange(starts_at, ends_at) WITH &&)
+);
+
+-- The window a row belongs to, set on exactly the windowed boards' rows and
+-- written only by RecomputePeriodCell. Like daily_day, it is not a second
+-- spelling of the key for reads to match on — reads still go by bucket_key,
+-- which carries the window after its "@" — it is what lets the per-run index,
+-- the PB cards and the freezer tell the kinds of slot apart without parsing a
+-- key.
+ALTER TABLE leaderboard_entries ADD COLUMN period text;
+
+-- One more way for a run to hold several slots: its all-time slot, and one per
+-- window it falls in. 00037's ordinary index narrows to the all-time rows; the
+-- windowed rows get their own, one slot per run per window, led by the period
+-- because the freezer reads a whole period at once.
+DROP INDEX leaderboard_entries_run_idx;
+CREATE UNIQUE INDEX leaderboard_entries_run_idx ON leaderboard_entries (run_id)
+    WHERE daily_day IS NULL AND period IS NULL;
+CREATE UNIQUE INDEX leaderboard_entries_period_run_idx ON leaderboard_entries (period, run_id)
+    WHERE period IS NOT NULL;
+
+-- A window that has been frozen. The row is written in the same transaction
+-- that archives the window's boards and deletes their live rows, so "frozen"
+-- and "archived" are one fact. starts_at/ends_at are copied rather than looked
+-- up: a week's span is arithmetic, and a season's is not allowed to change
+-- what an archive already says.
+CREATE TABLE leaderboard_periods (
+    id        text        PRIMARY KEY,
+    kind      text        NOT NULL CHECK (kind IN ('week', 'month', 'season')),
+    name      text,
+    starts_at timestamptz NOT NULL,
+    ends_at   timestamptz NOT NULL,
+    frozen_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Final standings, one row per visible entry at freeze time, ranked then. The
+-- rank is stored rather than counted at read time because the board it counts
+-- over is gone: that is what freezing means.
+--
+-- bucket_key is the windowed key the board had while it was live, so the
+-- archive is keyed by the same single producer (leaderboard.Bucket.Key) as
+-- everything else. Account deletion cascades, as it does from the live board.
+CREATE TABLE leaderboard_snapshots (
+    period_id    text        NOT NULL REFERENCES leaderboard_periods (id) ON DELETE CASCADE,
+    bucket_key   text        NOT NULL,
+    rank         bigint      NOT NULL,
+    user_id      uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    run_id       uuid        NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+    score        bigint      NOT NULL,
+    wpm          numeric     NOT NULL,
+    raw          numeric     NOT NULL,
+    acc          numeric     NOT NULL,
+    grade        text        NOT NULL,
+    mods         jsonb       NOT NULL,
+    achieved_at  timestamptz NOT NULL,
+    quote_source text,
+
+    PRIMARY KEY (bucket_key, rank)
+);
+
+CREATE INDEX leaderboard_snapshots_period_idx ON leaderboard_snapshots (period_id);
+
+-- The public replay rule asks whether a run is in any archive, by run alone.
+CREATE INDEX leaderboard_snapshots_run_idx ON leaderboard_snapshots (run_id);
+
+-- +goose Down
+DROP TABLE leaderboard_snapshots;
+DROP TABLE leaderboard_periods;
+DROP INDEX leaderboard_entries_period_run_idx;
+DROP INDEX leaderboard_entries_run_idx;
+DELETE FROM leaderboard_entries WHERE period IS NOT NULL;
+CREATE UNIQUE INDEX leaderboard_entries_run_idx ON leaderboard_entries (run_id)
+    WHERE daily_day IS NULL;
+ALTER TABLE leaderboard_entries DROP COLUMN period;
+DROP TABLE leaderboard_seasons;
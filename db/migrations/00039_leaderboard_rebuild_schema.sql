@@ -0,0 +1,19 @@
+-- +goose Up
+--
+-- The rebuild's scratch space (docs/LEADERBOARDS.md, "Rebuild"). A rebuild no
+-- longer truncates the live board and refills it under its readers' feet: it
+-- builds a copy of leaderboard_entries in this schema, diffs it against the
+-- live table, and applies only the difference.
+--
+-- The schema holds no table between rebuilds. Each rebuild creates
+-- leaderboard_rebuild.leaderboard_entries inside its own transaction and drops
+-- it before committing, so a crash, a rollback or a dry run leaves nothing
+-- behind — and two rebuilds started at once queue on the table's name rather
+-- than sharing it. It is a schema and not a differently named table because
+-- the rebuild writes through the projection's own statements, which name
+-- `leaderboard_entries`; putting this schema first on the search path is what
+-- points them at the copy without a second spelling of any of them.
+CREATE SCHEMA leaderboard_rebuild;
+
+-- +goose Down
+DROP SCHEMA leaderboard_rebuild;
@@ -0,0 +1,54 @@
+-- +goose Up
+--
+-- Rank history (docs/LEADERBOARDS.md, "Rank history"): where a player stood on
+-- an all-time board over time, and how far they have moved since they last
+-- looked.
+--
+--   leaderboard_rank_dirty    boards the projection has written since their
+--                             ranks were last snapshotted
+--   leaderboard_rank_history  one row per (board, player) each time a
+--                             snapshot finds their rank changed
+--   leaderboard_rank_seen     the rank each player was shown on their last
+--                             visit to a board's history
+--
+-- A rank is not a property of an entry: a player's rank moves when ANYONE
+-- above them does. Recording it on every verdict would mean rewriting every
+-- row below the one that changed, inside the replay worker's transaction, so
+-- the projection only marks the board and a periodic pass does the counting.
+
+-- The projection inserts a board's key here in the verdict's transaction,
+-- and does nothing when it is already marked, so a busy board costs one index
+-- probe per verdict and one snapshot per pass however much it moved.
+CREATE TABLE leaderboard_rank_dirty (
+    bucket_key text        PRIMARY KEY,
+    dirtied_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Written only when a player's rank differs from their latest point, so a
+-- board that churns at the top does not add a row for everyone who stood
+-- still — but a new rank 1 does move everybody below it, and that is the row
+-- count this table grows by. score rides along so a chart can tell "I
+-- improved" from "someone passed me".
+CREATE TABLE leaderboard_rank_history (
+    bucket_key text        NOT NULL,
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    taken_at   timestamptz NOT NULL,
+    rank       bigint      NOT NULL CHECK (rank >= 1),
+    score      bigint      NOT NULL,
+
+    PRIMARY KEY (bucket_key, user_id, taken_at)
+);
+
+CREATE TABLE leaderboard_rank_seen (
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    bucket_key text        NOT NULL,
+    rank       bigint      NOT NULL CHECK (rank >= 1),
+    seen_at    timestamptz NOT NULL,
+
+    PRIMARY KEY (user_id, bucket_key)
+);
+
+-- +goose Down
+DROP TABLE leaderboard_rank_seen;
+DROP TABLE leaderboard_rank_history;
+DROP TABLE leaderboard_rank_dirty;
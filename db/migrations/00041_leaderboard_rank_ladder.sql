@@ -0,0 +1,30 @@
+-- +goose Up
+--
+-- The rank ladder (docs/LEADERBOARDS.md, "Percentiles"): every hundredth row of
+-- each all-time board, in each order, with how many visible entries stood at
+-- or above it when the ladder was taken.
+--
+-- Counting the rows above a score is O(rank) however it is indexed
+-- (docs/PERFORMANCE.md, finding 2). With a ladder the count only walks from
+-- the score up to the nearest rung and reads the rest off that rung, so a
+-- percentile lookup touches a rung's worth of rows at any depth. The rung's
+-- figure is as old as the last snapshot pass; the stretch below it is live.
+--
+-- Rebuilt by the rank snapshotter (00040) for each board it claims, in the
+-- same transaction as that board's rank points, and for no other board: a
+-- board nobody has written to since has the ladder it had.
+--
+-- key is the order's own sort column, sort_key or wpm, as numeric so both
+-- orders share one table. Two rows of one tie share a key and an at_or_above,
+-- which is why the key alone can be the rung's identity.
+CREATE TABLE leaderboard_rank_ladder (
+    bucket_key  text    NOT NULL,
+    rank_order  text    NOT NULL CHECK (rank_order IN ('score', 'wpm')),
+    key         numeric NOT NULL,
+    at_or_above bigint  NOT NULL CHECK (at_or_above >= 1),
+
+    PRIMARY KEY (bucket_key, rank_order, key)
+);
+
+-- +goose Down
+DROP TABLE leaderboard_rank_ladder;
@@ -0,0 +1,51 @@
+-- +goose Up
+--
+-- The follow graph (docs/PROFILE.md, "Following"): one row per player who
+-- follows another. Following is one-way and needs nobody's consent, like
+-- bookmarking a profile — which is why it is refused for a CLOSED profile at
+-- the time of the follow, and why a followee who closes theirs later drops out
+-- of every follower's boards until they reopen it. The row stays: the switch
+-- decides what the edge shows, not whether it exists.
+--
+-- Both ends cascade with the account, so a deleted player leaves nothing
+-- pointing at them and nothing of theirs pointing out.
+CREATE TABLE follows (
+    follower_id uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+-- The other direction, for the cascade and for the day somebody asks who
+-- follows them.
+CREATE INDEX follows_followee_idx ON follows (followee_id);
+
+-- +goose StatementBegin
+-- The players a `?scope=following` board ranks (docs/LEADERBOARDS.md,
+-- "Following"): the viewer, and everyone they follow whose profile is open.
+--
+-- One function rather than the predicate spelled out in each scoped read, for
+-- the reason leaderboard_sort_key is one: the rule about closed profiles is a
+-- privacy rule, and a read that spelled it slightly differently would leak
+-- quietly. The viewer is in unconditionally — their own profile's switch is
+-- about strangers, and they are not one.
+CREATE FUNCTION following_circle(viewer uuid)
+    RETURNS TABLE (user_id uuid)
+    LANGUAGE sql
+    STABLE
+AS $$
+SELECT viewer
+UNION
+SELECT f.followee_id
+FROM follows f
+         JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = viewer
+  AND u.profile_public
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION following_circle(uuid);
+DROP TABLE follows;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- A quote's difficulty rating (docs/DIFFICULTY.md): the analyser's value on
+-- [0, 1] and the model version that computed it.
+--
+-- A rating is DERIVED data, not part of the published artefact, and that is
+-- why it may be written on a row whose text never changes. It is computed from
+-- the text, the language's dictionary and the layouts asset, so any of the
+-- three moving can move it; the importer rewrites the pair whenever it differs
+-- from what the current model computes, and leaves `text` and `text_hash` alone
+-- exactly as it always has. Nothing a run replays against is touched.
+--
+-- Both columns are NULL until the first import that knows how to rate — the
+-- rows already published are unrated until `make import-quotes` runs once —
+-- and the CHECK keeps them NULL together: a value without its version cannot
+-- be compared with anything, and a version without a value rates nothing.
+ALTER TABLE quotes
+    ADD COLUMN difficulty         double precision,
+    ADD COLUMN difficulty_version smallint,
+    ADD CONSTRAINT quotes_difficulty_rated
+        CHECK ((difficulty IS NULL) = (difficulty_version IS NULL)),
+    ADD CONSTRAINT quotes_difficulty_range
+        CHECK (difficulty BETWEEN 0 AND 1 AND difficulty_version > 0);
+
+-- +goose Down
+ALTER TABLE quotes
+    DROP CONSTRAINT quotes_difficulty_range,
+    DROP CONSTRAINT quotes_difficulty_rated,
+    DROP COLUMN difficulty_version,
+    DROP COLUMN difficulty;
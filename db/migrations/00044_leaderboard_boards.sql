@@ -0,0 +1,179 @@
+-- +goose Up
+--
+-- The board index's own table (docs/LEADERBOARDS.md, "The board index"): one
+-- row per all-time board that has ever held an entry, with the coordinates the
+-- index filters on, how many entries it holds, and when it last changed.
+--
+-- Before this, GET /leaderboards was a GROUP BY over every live entry, and
+-- every played quote is a board, so the response and its cost both grew with
+-- play. Reading this table instead costs one row per board, and the filters
+-- and sorts the index now takes are predicates on those rows.
+--
+-- Windowed readings (period IS NOT NULL) are not here: the index has never
+-- listed them, and `?window=` on a listed board is how a client reaches one.
+
+-- The coordinates of the board an entry sits on, derived from its run the way
+-- RunBucketCell derives them — never by parsing bucket_key, which has one
+-- producer and it is in Go. A daily entry belongs to its day's board whatever
+-- the run was; a run with a quote to a quote board, which takes its language
+-- and length group from the quote; anything else to a language board.
+--
+-- A quote's lang and len_group belong to its id: an edited quote is a new id
+-- (docs/QUOTES.md, "Immutability"), so copying them here cannot go stale.
+-- +goose StatementBegin
+CREATE FUNCTION leaderboard_board_shape(entry_run_id uuid, entry_daily_day date)
+    RETURNS TABLE (mode text, duration_ms int, word_count int, lang text,
+                   text_source text, quote_id uuid, len_group smallint, day date)
+    LANGUAGE sql STABLE AS $$
+    SELECT CASE WHEN d.is_language THEN r.mode END,
+           CASE WHEN d.is_language THEN r.duration_ms END,
+           CASE WHEN d.is_language THEN r.word_count END,
+           CASE WHEN d.is_language THEN r.lang WHEN d.is_quote THEN q.lang END,
+           CASE WHEN d.is_language THEN run_text_source_kind(r.setup)
+                WHEN d.is_quote THEN 'quote' END,
+           CASE WHEN d.is_quote THEN q.id END,
+           CASE WHEN d.is_quote THEN q.len_group END,
+           entry_daily_day
+    FROM runs r
+             LEFT JOIN quotes q ON q.id = run_quote_id(r.setup)
+             CROSS JOIN LATERAL (
+        SELECT entry_daily_day IS NULL AND q.id IS NOT NULL AS is_quote,
+               entry_daily_day IS NULL AND q.id IS NULL AS is_language) d
+    WHERE r.id = entry_run_id
+$$;
+-- +goose StatementEnd
+
+-- Exactly one of the three shapes, the same discriminator Bucket uses: a quote
+-- id, a day, or neither. mode and text_source are what a language board is; the
+-- quote's language and group are what a quote board carries besides its id.
+CREATE TABLE leaderboard_boards (
+    bucket_key  text        PRIMARY KEY,
+    mode        text,
+    duration_ms int,
+    word_count  int,
+    lang        text,
+    text_source text,
+    quote_id    uuid,
+    len_group   smallint,
+    day         date,
+    entries     bigint      NOT NULL CHECK (entries >= 0),
+    changed_at  timestamptz NOT NULL,
+
+    CONSTRAINT leaderboard_boards_shape CHECK (
+        CASE
+            WHEN quote_id IS NOT NULL THEN
+                day IS NULL AND mode IS NULL AND text_source = 'quote'
+                AND len_group IS NOT NULL
+            WHEN day IS NOT NULL THEN
+                mode IS NULL AND lang IS NULL AND text_source IS NULL
+                AND len_group IS NULL
+            ELSE
+                mode IS NOT NULL AND lang IS NOT NULL AND text_source IS NOT NULL
+                AND len_group IS NULL
+        END)
+);
+
+-- Maintained by triggers on leaderboard_entries — the first in this schema,
+-- and the reason is who else deletes entries. The recompute statements are the
+-- projection's writers, but an account deletion or a run deletion removes
+-- entries by ON DELETE CASCADE, and the rebuild's swap writes the live table
+-- directly (00039). A count kept by the writers would be missed by all three.
+-- A trigger is on the table itself and nothing can get around it. The rebuild's
+-- shadow is created LIKE the live table, which copies no triggers, so the count
+-- moves only when the swap writes to the live table.
+--
+-- An entry that appears or goes moves the count. Either of those, or a new
+-- best replacing the old one, moves changed_at. A worse run leaves the entry
+-- untouched (the recompute's DO UPDATE ... WHERE), so it fires nothing. The
+-- board's row is locked until the verdict commits. That serialises two verdicts
+-- that both CHANGE one board, and no other pair.
+--
+-- The count is every entry. Bans are applied when the index is read, as on
+-- every other read (see "Why bans are filtered on read"). A ban expires by the
+-- clock, and no write happens when it does, so no trigger could follow it.
+-- +goose StatementBegin
+CREATE FUNCTION leaderboard_boards_track() RETURNS trigger
+    LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO leaderboard_boards AS b
+            (bucket_key, mode, duration_ms, word_count, lang, text_source,
+             quote_id, len_group, day, entries, changed_at)
+        SELECT NEW.bucket_key, s.mode, s.duration_ms, s.word_count, s.lang,
+               s.text_source, s.quote_id, s.len_group, s.day, 1, now()
+        FROM leaderboard_board_shape(NEW.run_id, NEW.daily_day) s
+        ON CONFLICT (bucket_key) DO UPDATE
+            SET entries = b.entries + 1, changed_at = now();
+    ELSIF TG_OP = 'DELETE' THEN
+        UPDATE leaderboard_boards
+        SET entries = entries - 1, changed_at = now()
+        WHERE bucket_key = OLD.bucket_key;
+    ELSE
+        UPDATE leaderboard_boards
+        SET changed_at = now()
+        WHERE bucket_key = NEW.bucket_key;
+    END IF;
+    RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER leaderboard_boards_insert
+    AFTER INSERT ON leaderboard_entries
+    FOR EACH ROW WHEN (NEW.period IS NULL)
+    EXECUTE FUNCTION leaderboard_boards_track();
+
+CREATE TRIGGER leaderboard_boards_delete
+    AFTER DELETE ON leaderboard_entries
+    FOR EACH ROW WHEN (OLD.period IS NULL)
+    EXECUTE FUNCTION leaderboard_boards_track();
+
+CREATE TRIGGER leaderboard_boards_update
+    AFTER UPDATE ON leaderboard_entries
+    FOR EACH ROW WHEN (NEW.period IS NULL)
+    EXECUTE FUNCTION leaderboard_boards_track();
+
+-- Row triggers do not fire on TRUNCATE, which empties the table in one step
+-- (the test harnesses do it, and a cascade from runs or users does too), so
+-- the index is emptied along with it.
+-- +goose StatementBegin
+CREATE FUNCTION leaderboard_boards_reset() RETURNS trigger
+    LANGUAGE plpgsql AS $$
+BEGIN
+    DELETE FROM leaderboard_boards;
+    RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER leaderboard_boards_truncate
+    AFTER TRUNCATE ON leaderboard_entries
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION leaderboard_boards_reset();
+
+-- The boards that already exist. changed_at is the newest entry's achieved_at:
+-- the last time the board changed is not recorded anywhere, and the last run
+-- to land on it is the closest thing that is.
+INSERT INTO leaderboard_boards
+    (bucket_key, mode, duration_ms, word_count, lang, text_source,
+     quote_id, len_group, day, entries, changed_at)
+SELECT e.bucket_key, s.mode, s.duration_ms, s.word_count, s.lang, s.text_source,
+       s.quote_id, s.len_group, s.day, e.entries, e.changed_at
+FROM (SELECT DISTINCT ON (bucket_key)
+             bucket_key, run_id, daily_day,
+             count(*) OVER (PARTITION BY bucket_key) AS entries,
+             max(achieved_at) OVER (PARTITION BY bucket_key) AS changed_at
+      FROM leaderboard_entries
+      WHERE period IS NULL
+      ORDER BY bucket_key, run_id) e
+         CROSS JOIN LATERAL leaderboard_board_shape(e.run_id, e.daily_day) s;
+
+-- +goose Down
+DROP TRIGGER leaderboard_boards_truncate ON leaderboard_entries;
+DROP TRIGGER leaderboard_boards_update ON leaderboard_entries;
+DROP TRIGGER leaderboard_boards_delete ON leaderboard_entries;
+DROP TRIGGER leaderboard_boards_insert ON leaderboard_entries;
+DROP FUNCTION leaderboard_boards_reset();
+DROP FUNCTION leaderboard_boards_track();
+DROP TABLE leaderboard_boards;
+DROP FUNCTION leaderboard_board_shape(uuid, date);
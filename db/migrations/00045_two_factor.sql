@@ -0,0 +1,74 @@
+-- +goose Up
+--
+-- TOTP two-factor authentication (docs/AUTH.md, "Two-factor authentication").
+--
+-- users.two_factor_enabled_at is THE fact "this account has a second factor":
+-- NULL means it does not. It lives on the users row, not beside the secret,
+-- because it is read on every authenticated request — the admin permissions
+-- that need a second factor (permissions.go) are resolved from the user row
+-- RequireAuth already loads, and a join per request to learn one timestamp
+-- would be the wrong trade.
+ALTER TABLE users ADD COLUMN two_factor_enabled_at timestamptz;
+
+-- Whether a session was opened by a sign-in that proved the second factor. The
+-- permissions that need a second factor need it of the SESSION, not only of the
+-- account: otherwise whoever holds a stolen one-factor session could enrol an
+-- authenticator of their own and be granted them on the spot. Every session
+-- that exists today was opened with one factor, which is what the default says.
+ALTER TABLE sessions ADD COLUMN two_factor boolean NOT NULL DEFAULT false;
+
+-- The shared secret. A row with the user's two_factor_enabled_at still NULL is
+-- an enrolment that has not been confirmed yet: a fresh enrol replaces it, and
+-- nothing reads it except the confirmation.
+--
+-- The secret is stored as issued, not hashed — the verifier needs it to
+-- compute the code, which is the nature of TOTP. What it protects is the
+-- account's sessions; anyone who can read this table can already read and
+-- write everything a session would reach.
+--
+-- last_step is the newest 30-second step a code was accepted for. A code is
+-- accepted only for a LATER step, in the same UPDATE that records it, so a
+-- code seen over a shoulder or replayed from a log is spent the moment its
+-- owner uses it.
+CREATE TABLE user_totp (
+    user_id    uuid        PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret     bytea       NOT NULL,
+    last_step  bigint      NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Single-use recovery codes, hashed like every other bearer secret in this
+-- schema (only SHA-256 of the code is kept; the codes carry 80 bits of their
+-- own entropy, so a fast hash is enough). Issued as a set on confirmation and
+-- replaced as a set on regeneration.
+CREATE TABLE user_recovery_codes (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  bytea       NOT NULL UNIQUE,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX user_recovery_codes_user_id_idx ON user_recovery_codes (user_id);
+
+-- The half-finished login: the first factor was proven (a password, or an
+-- OAuth provider) and the session is withheld until the second is. Opaque and
+-- hashed like a session token, short-lived, and good for a bounded number of
+-- wrong codes — the per-IP limiter alone would let a distributed caller
+-- holding one stolen password walk the 10^6 code space.
+CREATE TABLE login_challenges (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    token_hash bytea       NOT NULL UNIQUE,
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    attempts   int         NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX login_challenges_user_id_idx ON login_challenges (user_id);
+CREATE INDEX login_challenges_expires_at_idx ON login_challenges (expires_at);
+
+-- +goose Down
+DROP TABLE login_challenges;
+DROP TABLE user_recovery_codes;
+DROP TABLE user_totp;
+ALTER TABLE sessions DROP COLUMN two_factor;
+ALTER TABLE users DROP COLUMN two_factor_enabled_at;
@@ -0,0 +1,66 @@
+-- +goose Up
+--
+-- WebAuthn passkeys (docs/AUTH.md, "Passkeys"): a credential beside the
+-- password (user_credentials) and the OAuth identities (auth_identities), one
+-- row per authenticator an account has registered.
+--
+-- credential_id is the authenticator's own handle for the key pair, and the
+-- lookup key of a sign-in: a discoverable-credential assertion names the
+-- credential, not the account. UNIQUE across all accounts because an
+-- authenticator mints a fresh one per registration — a collision is either a
+-- replayed registration or a broken authenticator, and both are refused.
+--
+-- public_key is the COSE_Key exactly as the authenticator produced it. Storing
+-- the encoded form rather than a parsed one keeps the row algorithm-agnostic:
+-- the verifier decodes it per assertion, and a new algorithm is a code change,
+-- not a migration.
+--
+-- sign_count is the authenticator's signature counter as last seen. A counter
+-- that stops increasing is the spec's signal of a cloned authenticator; many
+-- passkey providers report 0 forever, which is why 0-then-0 is allowed and
+-- only a nonzero counter is held to strictly increasing.
+--
+-- two_factor records whether the passkey was registered from a session that
+-- had proven the second factor (sessions.two_factor, 00045). A sign-in with it
+-- counts as a second-factor sign-in only then: otherwise whoever held a stolen
+-- one-factor session could register a passkey of their own and sign in past
+-- the TOTP step with it.
+CREATE TABLE passkeys (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    credential_id bytea       NOT NULL UNIQUE,
+    public_key    bytea       NOT NULL,
+    sign_count    bigint      NOT NULL DEFAULT 0,
+    transports    text[]      NOT NULL DEFAULT '{}',
+    name          text        NOT NULL CHECK (char_length(name) BETWEEN 1 AND 64),
+    two_factor    boolean     NOT NULL DEFAULT false,
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    last_used_at  timestamptz
+);
+CREATE INDEX passkeys_user_id_idx ON passkeys (user_id);
+
+-- One registration or sign-in ceremony in flight. The browser holds an opaque
+-- token in a flow cookie; the challenge it must sign lives here, hashed-token
+-- keyed like every other bearer secret, and is consumed (deleted) by the
+-- ceremony's second half. A challenge kept only in the cookie would be one the
+-- CLIENT chooses, and a captured assertion could then be replayed by setting
+-- the cookie to match it.
+--
+-- user_id is the registering account for kind 'register', and NULL for
+-- 'login': a passkey sign-in does not know who is signing in until the
+-- assertion names its credential.
+CREATE TABLE webauthn_ceremonies (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    token_hash bytea       NOT NULL UNIQUE,
+    kind       text        NOT NULL CHECK (kind IN ('register', 'login')),
+    user_id    uuid        REFERENCES users (id) ON DELETE CASCADE,
+    challenge  bytea       NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CHECK ((kind = 'register') = (user_id IS NOT NULL))
+);
+CREATE INDEX webauthn_ceremonies_expires_at_idx ON webauthn_ceremonies (expires_at);
+
+-- +goose Down
+DROP TABLE webauthn_ceremonies;
+DROP TABLE passkeys;
@@ -0,0 +1,30 @@
+-- +goose Up
+--
+-- What the account's session list (GET /api/v1/me/sessions, docs/AUTH.md,
+-- "Sessions") shows beside each session, so a player can tell the laptop at
+-- home from the machine they signed in on at a LAN party and revoke the
+-- right one.
+--
+-- user_agent is a coarse description derived from the User-Agent header at
+-- sign-in ("Firefox on Windows"), never the header itself: the full string is
+-- a fingerprint this table has no need to keep, and it is whatever the client
+-- chose to send.
+--
+-- ip_prefix is the network the session was last seen from, truncated to a
+-- /24 (IPv4) or /48 (IPv6) before it is stored: enough to recognise "that is
+-- not my ISP", not enough to locate anyone. It is refreshed by the same
+-- throttled write that slides the expiry, so it costs no extra UPDATE.
+--
+-- name is the player's own label for the session; NULL until they give one.
+-- Existing rows get '' for the two derived columns — the list shows them as
+-- unknown rather than guessing.
+ALTER TABLE sessions
+    ADD COLUMN name       text CHECK (char_length(name) BETWEEN 1 AND 64),
+    ADD COLUMN user_agent text NOT NULL DEFAULT '',
+    ADD COLUMN ip_prefix  text NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE sessions
+    DROP COLUMN ip_prefix,
+    DROP COLUMN user_agent,
+    DROP COLUMN name;
@@ -0,0 +1,42 @@
+-- +goose Up
+--
+-- Personal access tokens (docs/AUTH.md, "Personal access tokens"): a bearer
+-- credential a player mints for a script or a bot — the Discord bot that posts
+-- a team's PBs, the spreadsheet that pulls a month of runs — so those tools
+-- need neither the session cookie nor the frontend's Origin.
+--
+-- token_hash is the SHA-256 of the token's random part, keyed like every other
+-- bearer secret in this schema: the plaintext is shown once, at creation, and
+-- a leaked table authenticates nothing.
+--
+-- scopes is what the token may do, from a closed list: a token that reads runs
+-- cannot submit one, and no token reaches the account's settings, sessions or
+-- other tokens whatever it carries. The CHECK keeps the list closed at the
+-- database too, so a typo in a future handler cannot mint a scope nothing
+-- checks for.
+--
+-- expires_at is mandatory. A token outlives the session that created it by
+-- design, and a forgotten one in an abandoned repository should stop working
+-- on its own; the longest lifetime the API offers is a year.
+--
+-- last_used_at is written at most every few minutes (the same throttle idea
+-- as sessions.last_seen_at), so a bot polling every second is not an UPDATE
+-- per second.
+CREATE TABLE personal_access_tokens (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash   bytea       NOT NULL UNIQUE,
+    name         text        NOT NULL CHECK (char_length(name) BETWEEN 1 AND 64),
+    scopes       text[]      NOT NULL CHECK (
+        cardinality(scopes) > 0
+        AND scopes <@ ARRAY['runs:read', 'runs:submit', 'profile:read']
+    ),
+    expires_at   timestamptz NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    last_used_at timestamptz
+);
+CREATE INDEX personal_access_tokens_user_id_idx ON personal_access_tokens (user_id);
+CREATE INDEX personal_access_tokens_expires_at_idx ON personal_access_tokens (expires_at);
+
+-- +goose Down
+DROP TABLE personal_access_tokens;
@@ -0,0 +1,26 @@
+-- +goose Up
+--
+-- Sign-in providers stop being a closed set (docs/AUTH.md, "OAuth
+-- providers"). Discord joins GitHub and Google, and an operator may add any
+-- OpenID Connect issuer — a self-hosted Keycloak, say — under a name of
+-- their own choosing, which becomes the provider column of every identity it
+-- creates. The old CHECK listed the three names this schema knew about; it
+-- is replaced by one on the SHAPE of a name, the same rule the server
+-- enforces on its configuration at boot, so a typo in the environment fails
+-- there rather than on the first sign-in.
+--
+-- Removing a provider from the configuration leaves its identities in place:
+-- they simply cannot be signed in with until it is configured again, and an
+-- account that has another way in keeps it.
+ALTER TABLE auth_identities
+    DROP CONSTRAINT auth_identities_provider_check,
+    ADD CONSTRAINT auth_identities_provider_check
+        CHECK (provider ~ '^[a-z][a-z0-9-]{1,31}$');
+
+-- +goose Down
+-- Fails while any identity belongs to a provider outside the original three;
+-- delete those (or the accounts that own them) first.
+ALTER TABLE auth_identities
+    DROP CONSTRAINT auth_identities_provider_check,
+    ADD CONSTRAINT auth_identities_provider_check
+        CHECK (provider IN ('github', 'google', 'email'));
@@ -0,0 +1,29 @@
+-- +goose Up
+--
+-- A fourth replay lane, between contender and ranked (docs/REPLAY.md,
+-- "Priority lanes"). 00034 shipped three lanes where the queue was asked for
+-- three TIERS: runs that would move a board first, runs played for a
+-- scheduled event — the daily challenge, a tournament — next, and unranked
+-- shapes last. Its middle lane, ranked, is a ranked run that would not move a
+-- board, and an event run had nowhere to go but there or contender.
+--
+--   * event — a run played for a scheduled event that did not make contender.
+--     The player enters an event's board once, so the run will place on it
+--     whatever it scores; it goes ahead of the ranked lane, whose runs would
+--     change nothing.
+--
+-- run_lane() is not changed here: an event kind files into the lane with its
+-- own branch, in the migration that teaches the function to recognise it.
+ALTER TABLE runs
+    DROP CONSTRAINT runs_lane_check,
+    ADD CONSTRAINT runs_lane_check
+        CHECK (lane IN ('contender', 'event', 'ranked', 'unranked'));
+
+-- +goose Down
+-- A pending event run goes back to the lane it would have been filed in
+-- before; a judged one's lane is read by nothing.
+UPDATE runs SET lane = 'ranked' WHERE lane = 'event';
+ALTER TABLE runs
+    DROP CONSTRAINT runs_lane_check,
+    ADD CONSTRAINT runs_lane_check
+        CHECK (lane IN ('contender', 'ranked', 'unranked'));
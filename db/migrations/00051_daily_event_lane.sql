@@ -0,0 +1,91 @@
+-- +goose Up
+--
+-- Daily attempts get the event lane (docs/REPLAY.md, "Priority lanes";
+-- docs/DAILY.md). 00037 added the daily challenge without touching run_lane(),
+-- which still compared an attempt's client score only with the player's slot
+-- on the ORDINARY board. A player has one attempt a day, so every attempt
+-- places on the day's board, yet one that did not beat their all-time best
+-- was filed as ranked and waited behind runs that would change nothing.
+--
+-- An attempt is now:
+--
+--   * contender — if it would move the player's ordinary board, exactly as
+--     before: the attempt is also a run on that board (00037).
+--   * event     — otherwise, whatever it scores and whatever its shape: the
+--     day's board is the board it is going to place on.
+--
+-- The lane of a run already filed does not move; this applies to attempts
+-- submitted from here on.
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION run_lane(
+    p_user_id uuid, p_mode text, p_duration_ms integer, p_word_count integer,
+    p_lang text, p_setup jsonb, p_client_score jsonb
+) RETURNS text
+    LANGUAGE sql STABLE PARALLEL SAFE AS $$
+    SELECT CASE
+        WHEN run_adopted_from(p_setup) IS NOT NULL
+            THEN 'unranked'
+        WHEN run_quote_id(p_setup) IS NULL
+         AND NOT (run_text_source_kind(p_setup) = 'seeded'
+              AND ((p_mode = 'time'  AND p_duration_ms IN (15000, 30000, 60000))
+                OR (p_mode = 'words' AND p_word_count  IN (25, 50, 100))))
+            THEN CASE WHEN run_daily_day(p_setup) IS NOT NULL THEN 'event' ELSE 'unranked' END
+        WHEN jsonb_typeof(p_client_score -> 'total') <> 'number'
+            THEN CASE WHEN run_daily_day(p_setup) IS NOT NULL THEN 'event' ELSE 'ranked' END
+        WHEN (p_client_score ->> 'total')::numeric > coalesce((
+            SELECT max(e.score)
+            FROM leaderboard_entries e
+                     JOIN runs r ON r.id = e.run_id
+            WHERE e.user_id = p_user_id
+              AND CASE WHEN run_quote_id(p_setup) IS NOT NULL
+                  THEN run_quote_id(r.setup) = run_quote_id(p_setup)
+                  ELSE run_quote_id(r.setup) IS NULL
+                       AND r.mode = p_mode
+                       AND r.lang = p_lang
+                       AND r.duration_ms IS NOT DISTINCT FROM p_duration_ms
+                       AND r.word_count  IS NOT DISTINCT FROM p_word_count
+                  END), -1)
+            THEN 'contender'
+        WHEN run_daily_day(p_setup) IS NOT NULL
+            THEN 'event'
+        ELSE 'ranked'
+    END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION run_lane(
+    p_user_id uuid, p_mode text, p_duration_ms integer, p_word_count integer,
+    p_lang text, p_setup jsonb, p_client_score jsonb
+) RETURNS text
+    LANGUAGE sql STABLE PARALLEL SAFE AS $$
+    SELECT CASE
+        WHEN run_adopted_from(p_setup) IS NOT NULL
+            THEN 'unranked'
+        WHEN run_quote_id(p_setup) IS NULL
+         AND NOT (run_text_source_kind(p_setup) = 'seeded'
+              AND ((p_mode = 'time'  AND p_duration_ms IN (15000, 30000, 60000))
+                OR (p_mode = 'words' AND p_word_count  IN (25, 50, 100))))
+            THEN 'unranked'
+        WHEN jsonb_typeof(p_client_score -> 'total') <> 'number'
+            THEN 'ranked'
+        WHEN (p_client_score ->> 'total')::numeric > coalesce((
+            SELECT max(e.score)
+            FROM leaderboard_entries e
+                     JOIN runs r ON r.id = e.run_id
+            WHERE e.user_id = p_user_id
+              AND CASE WHEN run_quote_id(p_setup) IS NOT NULL
+                  THEN run_quote_id(r.setup) = run_quote_id(p_setup)
+                  ELSE run_quote_id(r.setup) IS NULL
+                       AND r.mode = p_mode
+                       AND r.lang = p_lang
+                       AND r.duration_ms IS NOT DISTINCT FROM p_duration_ms
+                       AND r.word_count  IS NOT DISTINCT FROM p_word_count
+                  END), -1)
+            THEN 'contender'
+        ELSE 'ranked'
+    END
+$$;
+-- +goose StatementEnd
@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Scope is one thing a personal access token may do. The list is closed — the
+// personal_access_tokens CHECK (00048) carries the same three strings — and
+// each names a route group, not a handler: a tool that reads runs gets every
+// run read the API has, now and later.
+type Scope string
+
+const (
+	// ScopeRunsRead reads the owner's runs (GET /runs, /runs/{id}).
+	ScopeRunsRead Scope = "runs:read"
+	// ScopeRunsSubmit submits runs on the owner's behalf (POST /runs).
+	ScopeRunsSubmit Scope = "runs:submit"
+	// ScopeProfileRead reads the owner's statistics (/profile/*).
+	ScopeProfileRead Scope = "profile:read"
+)
+
+// scopes is every Scope, in the order the API documents them.
+var scopes = []Scope{ScopeRunsRead, ScopeRunsSubmit, ScopeProfileRead}
+
+const (
+	// accessTokenPrefix marks the plaintext of a personal access token. It is
+	// not part of the secret: it makes a token recognisable in a pasted config
+	// file or a secret scanner's rules, and keeps a session cookie value from
+	// ever being mistaken for one.
+	accessTokenPrefix = "tmpat_"
+	// maxAccessTokensPerUser bounds how many rows one account can create: a
+	// token per tool is a handful, and the bound is what keeps a session from
+	// filling the table.
+	maxAccessTokensPerUser = 20
+	maxAccessTokenNameLen  = 64
+	// maxAccessTokenDays is the longest lifetime a token can be given. Every
+	// token expires; a year is long enough for a bot nobody wants to babysit.
+	maxAccessTokenDays = 365
+	// accessTokenTouchInterval throttles last_used_at the way
+	// sessionRefreshInterval throttles last_seen_at: a bot polling every second
+	// should cost a write every few minutes, not every request.
+	accessTokenTouchInterval = 5 * time.Minute
+)
+
+// Default per-token bucket: a request a second, sixty in a burst. A script
+// pulling a season of runs page by page stays inside it; one stuck in a retry
+// loop does not take the run routes' share of the database with it.
+const (
+	DefaultAccessTokenRateEvery = time.Second
+	DefaultAccessTokenRateBurst = 60
+)
+
+// RequireAuthOrToken is middleware for the routes a personal access token may
+// reach. It replaces the RequireOrigin + RequireAuth pair on them, and takes
+// one of two paths, decided by the request alone:
+//
+//   - With an `Authorization: Bearer` header, the token is the whole
+//     credential. No cookie is consulted and no Origin is required — a bot
+//     has no origin, and a Bearer header is not something a cross-site page
+//     can make a browser send, which is the attack the Origin check exists
+//     for. The token must be live and carry scope, and each token draws from
+//     its own rate-limit bucket.
+//   - Without one, the request is a browser's, and gets exactly the cookie
+//     checks the route had before: the Origin check on writes, then the
+//     session.
+//
+// A bad Bearer header is a 401, never a fall-through to the cookie: a client
+// that sent a token meant to be judged by it. Routes NOT wrapped in this keep
+// RequireAuth, which reads only the cookie — so no token, whatever it carries,
+// reaches the account's settings, sessions or tokens.
+func (s *Service) RequireAuthOrToken(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withCookie := s.RequireOrigin(s.RequireAuth(next))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := bearerToken(r)
+			if !ok {
+				withCookie.ServeHTTP(w, r)
+				return
+			}
+			user, err := s.authenticateToken(r.Context(), raw, scope)
+			if err != nil {
+				var apiErr *apiError
+				if errors.As(err, &apiErr) && apiErr.status == http.StatusUnauthorized {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="typemore"`)
+				}
+				s.writeError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withUser(r.Context(), user)))
+		})
+	}
+}
+
+// OptionalAuthOrToken is RequireAuthOrToken for a public route that is also
+// the owner's: a run's timeline, for instance, is anyone's for an accepted run
+// and the owner's for their own. Without a Bearer header it is OptionalAuth;
+// with one, the token is judged exactly as RequireAuthOrToken judges it, so a
+// bad token is still a 401 and not a quiet downgrade to an anonymous caller.
+func (s *Service) OptionalAuthOrToken(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withToken := s.RequireAuthOrToken(scope)(next)
+		withCookie := s.OptionalAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := bearerToken(r); ok {
+				withToken.ServeHTTP(w, r)
+				return
+			}
+			withCookie.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bearerToken returns the credential of an `Authorization: Bearer` header.
+// The scheme is case-insensitive (RFC 9110 §11.1); any other scheme is not a
+// token and reports false.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, cred, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(cred), true
+}
+
+// authenticateToken resolves a token's plaintext to its owner, for scope. An
+// unknown, malformed or expired token is apiErrUnauthorized, all alike; a live
+// one without the scope is apiErrInsufficientScope.
+func (s *Service) authenticateToken(ctx context.Context, raw string, scope Scope) (User, error) {
+	secret, ok := strings.CutPrefix(raw, accessTokenPrefix)
+	if !ok {
+		return User{}, apiErrUnauthorized
+	}
+	hash, err := hashToken(secret)
+	if err != nil {
+		return User{}, apiErrUnauthorized
+	}
+	tok, err := s.store.AccessTokenByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return User{}, apiErrUnauthorized
+		}
+		return User{}, err
+	}
+	now := s.now()
+	if !tok.ExpiresAt.After(now) {
+		return User{}, apiErrUnauthorized
+	}
+	// Keyed by the token, not the account or the address: two tools of one
+	// player do not starve each other, and a tool moving between hosts keeps
+	// its own budget.
+	if !s.tokenLimiter.Allow(tok.ID.String()) {
+		return User{}, apiErrRateLimited
+	}
+	if !slices.Contains(tok.Scopes, scope) {
+		return User{}, newAPIError(http.StatusForbidden, "insufficient_scope",
+			fmt.Sprintf("this token does not carry the %s scope", scope))
+	}
+
+	if tok.LastUsedAt == nil || now.Sub(*tok.LastUsedAt) > accessTokenTouchInterval {
+		if err := s.store.TouchAccessToken(ctx, tok.ID); err != nil {
+			// Like a failed session refresh: the request still stands.
+			s.log.WarnContext(ctx, "access token touch failed", "err", err)
+		}
+	}
+
+	user, err := s.store.User(ctx, tok.UserID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return User{}, apiErrUnauthorized
+		}
+		return User{}, err
+	}
+	return user, nil
+}
+
+// --- management: /me/tokens ---
+//
+// Cookie-only (RequireAuth), on purpose: a token that could mint tokens would
+// be a token that outlives its own revocation.
+
+// accessTokenView is one token as the management API shows it. The plaintext
+// appears only in createdAccessTokenView, once.
+type accessTokenView struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type createdAccessTokenView struct {
+	accessTokenView
+	// Token is the plaintext, shown this once: only its hash is kept.
+	Token string `json:"token"`
+}
+
+func toAccessTokenView(t AccessToken) accessTokenView {
+	return accessTokenView{
+		ID: t.ID, Name: t.Name, Scopes: t.Scopes,
+		CreatedAt: t.CreatedAt, ExpiresAt: t.ExpiresAt, LastUsedAt: t.LastUsedAt,
+	}
+}
+
+// HandleAccessTokens serves GET /api/v1/me/tokens: the account's tokens,
+// newest first, expired ones included until the janitor takes them. Mounted
+// beside /me by the caller (RequireAuth).
+func (s *Service) HandleAccessTokens(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	tokens, err := s.store.AccessTokens(r.Context(), user.ID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	out := struct {
+		Tokens []accessTokenView `json:"tokens"`
+	}{Tokens: make([]accessTokenView, len(tokens))}
+	for i, t := range tokens {
+		out.Tokens[i] = toAccessTokenView(t)
+	}
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+type createAccessTokenRequest struct {
+	Name          string  `json:"name"`
+	Scopes        []Scope `json:"scopes"`
+	ExpiresInDays int     `json:"expiresInDays"`
+}
+
+// HandleCreateAccessToken serves POST /api/v1/me/tokens: mint a token and
+// answer with its plaintext, the only time it is ever sent. Mounted by the
+// caller (RequireOrigin + RequireAuth).
+//
+// On an account with a second factor the session must have proven it, for
+// the reason registering a passkey must: a token is a credential that skips
+// the code, and a stolen one-factor session must not be able to mint one.
+func (s *Service) HandleCreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	if user.TwoFactorEnabledAt != nil && !user.TwoFactorSession {
+		s.writeError(w, r, apiErrTwoFactorSessionRequired)
+		return
+	}
+	var req createAccessTokenRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxAccessTokenNameLen {
+		s.writeError(w, r, apiErrBadRequest(fmt.Sprintf("a token name is 1 to %d characters", maxAccessTokenNameLen)))
+		return
+	}
+	granted, apiErr := cleanScopes(req.Scopes)
+	if apiErr != nil {
+		s.writeError(w, r, apiErr)
+		return
+	}
+	if req.ExpiresInDays < 1 || req.ExpiresInDays > maxAccessTokenDays {
+		s.writeError(w, r, apiErrBadRequest(fmt.Sprintf("expiresInDays must be between 1 and %d", maxAccessTokenDays)))
+		return
+	}
+
+	ctx := r.Context()
+	n, err := s.store.CountAccessTokens(ctx, user.ID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if n >= maxAccessTokensPerUser {
+		s.writeError(w, r, newAPIError(http.StatusConflict, "too_many_access_tokens",
+			fmt.Sprintf("an account can hold at most %d access tokens", maxAccessTokensPerUser)))
+		return
+	}
+
+	secret, hash, err := newToken()
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	tok, err := s.store.CreateAccessToken(ctx, AccessTokenParams{
+		UserID:    user.ID,
+		TokenHash: hash,
+		Name:      name,
+		Scopes:    granted,
+		ExpiresAt: s.now().AddDate(0, 0, req.ExpiresInDays),
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, createdAccessTokenView{
+		accessTokenView: toAccessTokenView(tok),
+		Token:           accessTokenPrefix + secret,
+	})
+}
+
+// cleanScopes validates a requested scope list against the closed set and
+// returns it deduplicated, in the documented order.
+func cleanScopes(requested []Scope) ([]Scope, *apiError) {
+	if len(requested) == 0 {
+		return nil, apiErrBadRequest("a token needs at least one scope")
+	}
+	for _, sc := range requested {
+		if !slices.Contains(scopes, sc) {
+			return nil, apiErrBadRequest(fmt.Sprintf("unknown scope %q", sc))
+		}
+	}
+	out := make([]Scope, 0, len(scopes))
+	for _, sc := range scopes {
+		if slices.Contains(requested, sc) {
+			out = append(out, sc)
+		}
+	}
+	return out, nil
+}
+
+// HandleRevokeAccessToken serves DELETE /api/v1/me/tokens/{id}. The token
+// stops working on its next request. Mounted by the caller (RequireOrigin +
+// RequireAuth).
+func (s *Service) HandleRevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, r, apiErrAccessTokenNotFound)
+		return
+	}
+	if err := s.store.DeleteAccessToken(r.Context(), user.ID, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.writeError(w, r, apiErrAccessTokenNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, statusMessage("access token revoked"))
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Internal: the header parsing and scope cleaning are unexported, and the
+// HTTP flows in personal_tokens_test.go only see what they let through.
+
+func TestBearerToken(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{"Bearer tmpat_abc", "tmpat_abc", true},
+		{"bearer tmpat_abc", "tmpat_abc", true},
+		{"Bearer   tmpat_abc  ", "tmpat_abc", true},
+		{"Basic dXNlcjpwYXNz", "", false},
+		{"Bearer", "", false},
+		{"", "", false},
+	}
+	for _, tc := range cases {
+		r, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+		require.NoError(t, err)
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		got, ok := bearerToken(r)
+		assert.Equal(t, tc.ok, ok, tc.header)
+		assert.Equal(t, tc.want, got, tc.header)
+	}
+}
+
+func TestCleanScopes(t *testing.T) {
+	got, err := cleanScopes([]Scope{ScopeProfileRead, ScopeRunsRead, ScopeProfileRead})
+	require.Nil(t, err)
+	assert.Equal(t, []Scope{ScopeRunsRead, ScopeProfileRead}, got, "deduplicated, in documented order")
+
+	_, err = cleanScopes(nil)
+	assert.NotNil(t, err)
+	_, err = cleanScopes([]Scope{ScopeRunsRead, "runs:delete"})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Message, `"runs:delete"`)
+}
@@ -84,8 +84,9 @@ type setPasswordRequest struct {
 // handleSetPassword sets a first-time password for an account that has a
 // verified email identity but no credentials yet (e.g. an OAuth account that
 // added and verified an email). It is a one-time set: an existing credential
-// row makes this a password_already_set conflict, and changing a password stays
-// under the reset flow. After a successful set, email+password login works.
+// row makes this a password_already_set conflict, and changing a password is
+// handlePasswordChange's (or the reset flow's). After a successful set,
+// email+password login works.
 func (s *Service) handleSetPassword(w http.ResponseWriter, r *http.Request) {
 	user, ok := UserFrom(r.Context())
 	if !ok {
@@ -139,3 +140,76 @@ func (s *Service) handleSetPassword(w http.ResponseWriter, r *http.Request) {
 	}
 	s.writeJSON(w, http.StatusOK, statusMessage("password set; you can now sign in with email and password"))
 }
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// handlePasswordChange replaces the password of the signed-in account, given
+// the current one, and signs out every session the account has — the same
+// response as a reset, for the same reason: a player changing their password
+// is often doing it because someone else has it, and a stolen session would
+// otherwise outlive the stolen password. The caller is the exception that
+// proves the rule: its own session is replaced with a fresh one (keeping
+// whether it proved the second factor), so the tab that changed the password
+// stays signed in and every other device is not. Personal access tokens go
+// too, with no exception: one could have been minted from the stolen session.
+func (s *Service) handlePasswordChange(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	var req changePasswordRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if err := validatePassword(req.NewPassword); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	cred, err := s.store.Credential(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.writeError(w, r, apiErrNoPassword)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	ok, err = s.verifyPassword(ctx, req.CurrentPassword, cred.Hash)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !ok {
+		s.writeError(w, r, apiErrInvalidCredentials)
+		return
+	}
+
+	hash, err := s.hashPassword(ctx, req.NewPassword)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.store.UpdateCredential(ctx, user.ID, hash); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.sessions.DeleteUserSessions(ctx, user.ID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.store.DeleteUserAccessTokens(ctx, user.ID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.issueSession(w, r, user.ID, user.TwoFactorSession); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, statusMessage("password changed; every other session and every access token has been revoked"))
+}
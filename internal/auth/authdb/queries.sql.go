@@ -12,13 +12,57 @@ import (
 	"github.com/google/uuid"
 )
 
+const acceptTOTPStep = `-- name: AcceptTOTPStep :execrows
+UPDATE user_totp SET last_step = $2 WHERE user_id = $1 AND last_step < $2
+`
+
+type AcceptTOTPStepParams struct {
+	UserID   uuid.UUID
+	LastStep int64
+}
+
+// Records that a code was accepted for a step, and only if the step is later
+// than the last one accepted: zero rows is a replayed code. Check and write
+// are one statement, so two requests racing with the same code cannot both
+// pass.
+func (q *Queries) AcceptTOTPStep(ctx context.Context, arg AcceptTOTPStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, acceptTOTPStep, arg.UserID, arg.LastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const attemptLoginChallenge = `-- name: AttemptLoginChallenge :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE token_hash = $1 AND expires_at > now() AND attempts < $2
+RETURNING user_id
+`
+
+type AttemptLoginChallengeParams struct {
+	TokenHash []byte
+	Attempts  int32
+}
+
+// Counts one attempt against a live challenge and returns whose it is. The
+// attempt is spent BEFORE the code is checked, in the same statement that
+// finds the row, so concurrent guesses cannot share an attempt; an expired or
+// exhausted challenge returns no row.
+func (q *Queries) AttemptLoginChallenge(ctx context.Context, arg AttemptLoginChallengeParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, attemptLoginChallenge, arg.TokenHash, arg.Attempts)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
 const changeDisplayName = `-- name: ChangeDisplayName :one
 UPDATE users
 SET display_name = $2, display_name_changed_at = now(), updated_at = now()
 WHERE id = $1
   AND (display_name_changed_at IS NULL
        OR display_name_changed_at <= now() - interval '30 days')
-RETURNING id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at
+RETURNING id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at, two_factor_enabled_at
 `
 
 type ChangeDisplayNameParams struct {
@@ -45,6 +89,113 @@ func (q *Queries) ChangeDisplayName(ctx context.Context, arg ChangeDisplayNamePa
 		&i.Bio,
 		&i.Keyboard,
 		&i.DisplayNameChangedAt,
+		&i.TwoFactorEnabledAt,
+	)
+	return i, err
+}
+
+const clearPasskeysTwoFactor = `-- name: ClearPasskeysTwoFactor :exec
+UPDATE passkeys SET two_factor = false WHERE user_id = $1
+`
+
+// The passkey counterpart of ClearSessionsTwoFactor: once the second factor is
+// off, no passkey still vouches for it.
+func (q *Queries) ClearPasskeysTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, clearPasskeysTwoFactor, userID)
+	return err
+}
+
+const clearSessionsTwoFactor = `-- name: ClearSessionsTwoFactor :exec
+UPDATE sessions SET two_factor = false WHERE user_id = $1
+`
+
+// Part of turning a second factor off: the account's sessions stop counting as
+// second-factor sessions, so turning it back on later does not hand the gated
+// permissions back to a session that never proved the new one.
+func (q *Queries) ClearSessionsTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, clearSessionsTwoFactor, userID)
+	return err
+}
+
+const consumeWebAuthnCeremony = `-- name: ConsumeWebAuthnCeremony :one
+DELETE FROM webauthn_ceremonies
+WHERE token_hash = $1 AND kind = $2 AND expires_at > now()
+RETURNING user_id, challenge
+`
+
+type ConsumeWebAuthnCeremonyParams struct {
+	TokenHash []byte
+	Kind      string
+}
+
+type ConsumeWebAuthnCeremonyRow struct {
+	UserID    *uuid.UUID
+	Challenge []byte
+}
+
+// Takes a live ceremony of the given kind, once: the row is deleted in the
+// statement that reads it, so a challenge can be answered only one time.
+func (q *Queries) ConsumeWebAuthnCeremony(ctx context.Context, arg ConsumeWebAuthnCeremonyParams) (ConsumeWebAuthnCeremonyRow, error) {
+	row := q.db.QueryRow(ctx, consumeWebAuthnCeremony, arg.TokenHash, arg.Kind)
+	var i ConsumeWebAuthnCeremonyRow
+	err := row.Scan(&i.UserID, &i.Challenge)
+	return i, err
+}
+
+const countAccessTokens = `-- name: CountAccessTokens :one
+SELECT count(*) FROM personal_access_tokens WHERE user_id = $1
+`
+
+func (q *Queries) CountAccessTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countAccessTokens, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRecoveryCodes = `-- name: CountRecoveryCodes :one
+SELECT count(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAccessToken = `-- name: CreateAccessToken :one
+INSERT INTO personal_access_tokens (user_id, token_hash, name, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, token_hash, name, scopes, expires_at, created_at, last_used_at
+`
+
+type CreateAccessTokenParams struct {
+	UserID    uuid.UUID
+	TokenHash []byte
+	Name      string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateAccessToken(ctx context.Context, arg CreateAccessTokenParams) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, createAccessToken,
+		arg.UserID,
+		arg.TokenHash,
+		arg.Name,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Name,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
@@ -117,20 +268,103 @@ func (q *Queries) CreateIdentity(ctx context.Context, arg CreateIdentityParams)
 	return i, err
 }
 
-const createSession = `-- name: CreateSession :one
-INSERT INTO sessions (token_hash, user_id, expires_at)
+const createLoginChallenge = `-- name: CreateLoginChallenge :exec
+INSERT INTO login_challenges (token_hash, user_id, expires_at)
 VALUES ($1, $2, $3)
-RETURNING id, token_hash, user_id, created_at, expires_at, last_seen_at
+`
+
+type CreateLoginChallengeParams struct {
+	TokenHash []byte
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) error {
+	_, err := q.db.Exec(ctx, createLoginChallenge, arg.TokenHash, arg.UserID, arg.ExpiresAt)
+	return err
+}
+
+const createPasskey = `-- name: CreatePasskey :one
+INSERT INTO passkeys (user_id, credential_id, public_key, sign_count, transports, name, two_factor)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, credential_id, public_key, sign_count, transports, name, two_factor, created_at, last_used_at
+`
+
+type CreatePasskeyParams struct {
+	UserID       uuid.UUID
+	CredentialID []byte
+	PublicKey    []byte
+	SignCount    int64
+	Transports   []string
+	Name         string
+	TwoFactor    bool
+}
+
+func (q *Queries) CreatePasskey(ctx context.Context, arg CreatePasskeyParams) (Passkey, error) {
+	row := q.db.QueryRow(ctx, createPasskey,
+		arg.UserID,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.SignCount,
+		arg.Transports,
+		arg.Name,
+		arg.TwoFactor,
+	)
+	var i Passkey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.SignCount,
+		&i.Transports,
+		&i.Name,
+		&i.TwoFactor,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+VALUES ($1, $2)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   uuid.UUID
+	CodeHash []byte
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.Exec(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (token_hash, user_id, expires_at, two_factor, user_agent, ip_prefix)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, token_hash, user_id, created_at, expires_at, last_seen_at, two_factor, name, user_agent, ip_prefix
 `
 
 type CreateSessionParams struct {
 	TokenHash []byte
 	UserID    uuid.UUID
 	ExpiresAt time.Time
+	TwoFactor bool
+	UserAgent string
+	IpPrefix  string
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
-	row := q.db.QueryRow(ctx, createSession, arg.TokenHash, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRow(ctx, createSession,
+		arg.TokenHash,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.TwoFactor,
+		arg.UserAgent,
+		arg.IpPrefix,
+	)
 	var i Session
 	err := row.Scan(
 		&i.ID,
@@ -139,6 +373,10 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.LastSeenAt,
+		&i.TwoFactor,
+		&i.Name,
+		&i.UserAgent,
+		&i.IpPrefix,
 	)
 	return i, err
 }
@@ -147,7 +385,7 @@ const createUser = `-- name: CreateUser :one
 
 INSERT INTO users (display_name)
 VALUES ($1)
-RETURNING id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at
+RETURNING id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at, two_factor_enabled_at
 `
 
 // Queries for the auth domain. sqlc generates type-safe Go from these into
@@ -167,10 +405,77 @@ func (q *Queries) CreateUser(ctx context.Context, displayName string) (User, err
 		&i.Bio,
 		&i.Keyboard,
 		&i.DisplayNameChangedAt,
+		&i.TwoFactorEnabledAt,
 	)
 	return i, err
 }
 
+const createWebAuthnCeremony = `-- name: CreateWebAuthnCeremony :exec
+INSERT INTO webauthn_ceremonies (token_hash, kind, user_id, challenge, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateWebAuthnCeremonyParams struct {
+	TokenHash []byte
+	Kind      string
+	UserID    *uuid.UUID
+	Challenge []byte
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateWebAuthnCeremony(ctx context.Context, arg CreateWebAuthnCeremonyParams) error {
+	_, err := q.db.Exec(ctx, createWebAuthnCeremony,
+		arg.TokenHash,
+		arg.Kind,
+		arg.UserID,
+		arg.Challenge,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const deleteAccessToken = `-- name: DeleteAccessToken :execrows
+DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2
+`
+
+type DeleteAccessTokenParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Scoped to the owner, so a guessed id revokes nothing of anyone else's.
+func (q *Queries) DeleteAccessToken(ctx context.Context, arg DeleteAccessTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAccessToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredAccessTokens = `-- name: DeleteExpiredAccessTokens :execrows
+DELETE FROM personal_access_tokens WHERE expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredAccessTokens(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredAccessTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredLoginChallenges = `-- name: DeleteExpiredLoginChallenges :execrows
+DELETE FROM login_challenges WHERE expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredLoginChallenges(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredLoginChallenges)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
 DELETE FROM sessions WHERE expires_at < now()
 `
@@ -183,6 +488,71 @@ func (q *Queries) DeleteExpiredSessions(ctx context.Context) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
+const deleteExpiredWebAuthnCeremonies = `-- name: DeleteExpiredWebAuthnCeremonies :execrows
+DELETE FROM webauthn_ceremonies WHERE expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredWebAuthnCeremonies(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredWebAuthnCeremonies)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLoginChallenge = `-- name: DeleteLoginChallenge :exec
+DELETE FROM login_challenges WHERE token_hash = $1
+`
+
+func (q *Queries) DeleteLoginChallenge(ctx context.Context, tokenHash []byte) error {
+	_, err := q.db.Exec(ctx, deleteLoginChallenge, tokenHash)
+	return err
+}
+
+const deleteOtherUserSessions = `-- name: DeleteOtherUserSessions :execrows
+DELETE FROM sessions WHERE user_id = $1 AND id <> $2
+`
+
+type DeleteOtherUserSessionsParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+func (q *Queries) DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOtherUserSessions, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePasskey = `-- name: DeletePasskey :execrows
+DELETE FROM passkeys WHERE id = $1 AND user_id = $2
+`
+
+type DeletePasskeyParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Scoped to the owner, so a guessed id removes nothing of anyone else's.
+func (q *Queries) DeletePasskey(ctx context.Context, arg DeletePasskeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePasskey, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
 const deleteSessionByTokenHash = `-- name: DeleteSessionByTokenHash :exec
 DELETE FROM sessions WHERE token_hash = $1
 `
@@ -209,6 +579,15 @@ func (q *Queries) DeleteStaleEmailTokens(ctx context.Context) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
+const deleteTOTP = `-- name: DeleteTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteTOTP, userID)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM users WHERE id = $1
 `
@@ -218,6 +597,33 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteUserAccessTokens = `-- name: DeleteUserAccessTokens :exec
+DELETE FROM personal_access_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteUserAccessTokens, userID)
+	return err
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :execrows
+DELETE FROM sessions WHERE id = $1 AND user_id = $2
+`
+
+type DeleteUserSessionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Scoped to the owner: a session id from someone else's list matches nothing.
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteUserSessions = `-- name: DeleteUserSessions :exec
 DELETE FROM sessions WHERE user_id = $1
 `
@@ -241,6 +647,51 @@ func (q *Queries) DeleteUserTokensByPurpose(ctx context.Context, arg DeleteUserT
 	return err
 }
 
+const disableTwoFactor = `-- name: DisableTwoFactor :exec
+UPDATE users
+SET two_factor_enabled_at = NULL, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) DisableTwoFactor(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, disableTwoFactor, id)
+	return err
+}
+
+const enableTwoFactor = `-- name: EnableTwoFactor :execrows
+UPDATE users
+SET two_factor_enabled_at = now(), updated_at = now()
+WHERE id = $1 AND two_factor_enabled_at IS NULL
+`
+
+func (q *Queries) EnableTwoFactor(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, enableTwoFactor, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAccessTokenByHash = `-- name: GetAccessTokenByHash :one
+SELECT id, user_id, token_hash, name, scopes, expires_at, created_at, last_used_at FROM personal_access_tokens WHERE token_hash = $1
+`
+
+func (q *Queries) GetAccessTokenByHash(ctx context.Context, tokenHash []byte) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, getAccessTokenByHash, tokenHash)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Name,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
 const getCredentialByUser = `-- name: GetCredentialByUser :one
 SELECT user_id, argon2id_hash, updated_at FROM user_credentials WHERE user_id = $1
 `
@@ -297,8 +748,30 @@ func (q *Queries) GetIdentityByProviderSubject(ctx context.Context, arg GetIdent
 	return i, err
 }
 
+const getPasskeyByCredentialID = `-- name: GetPasskeyByCredentialID :one
+SELECT id, user_id, credential_id, public_key, sign_count, transports, name, two_factor, created_at, last_used_at FROM passkeys WHERE credential_id = $1
+`
+
+func (q *Queries) GetPasskeyByCredentialID(ctx context.Context, credentialID []byte) (Passkey, error) {
+	row := q.db.QueryRow(ctx, getPasskeyByCredentialID, credentialID)
+	var i Passkey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.SignCount,
+		&i.Transports,
+		&i.Name,
+		&i.TwoFactor,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
 const getSessionByTokenHash = `-- name: GetSessionByTokenHash :one
-SELECT id, token_hash, user_id, created_at, expires_at, last_seen_at FROM sessions WHERE token_hash = $1
+SELECT id, token_hash, user_id, created_at, expires_at, last_seen_at, two_factor, name, user_agent, ip_prefix FROM sessions WHERE token_hash = $1
 `
 
 func (q *Queries) GetSessionByTokenHash(ctx context.Context, tokenHash []byte) (Session, error) {
@@ -311,12 +784,32 @@ func (q *Queries) GetSessionByTokenHash(ctx context.Context, tokenHash []byte) (
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.LastSeenAt,
+		&i.TwoFactor,
+		&i.Name,
+		&i.UserAgent,
+		&i.IpPrefix,
+	)
+	return i, err
+}
+
+const getTOTP = `-- name: GetTOTP :one
+SELECT user_id, secret, last_step, created_at FROM user_totp WHERE user_id = $1
+`
+
+func (q *Queries) GetTOTP(ctx context.Context, userID uuid.UUID) (UserTotp, error) {
+	row := q.db.QueryRow(ctx, getTOTP, userID)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.LastStep,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at FROM users WHERE id = $1
+SELECT id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at, two_factor_enabled_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -333,6 +826,7 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Bio,
 		&i.Keyboard,
 		&i.DisplayNameChangedAt,
+		&i.TwoFactorEnabledAt,
 	)
 	return i, err
 }
@@ -360,6 +854,41 @@ func (q *Queries) GetVerifiedIdentityByEmail(ctx context.Context, email *string)
 	return i, err
 }
 
+const listAccessTokens = `-- name: ListAccessTokens :many
+SELECT id, user_id, token_hash, name, scopes, expires_at, created_at, last_used_at FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAccessTokens(ctx context.Context, userID uuid.UUID) ([]PersonalAccessToken, error) {
+	rows, err := q.db.Query(ctx, listAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PersonalAccessToken{}
+	for rows.Next() {
+		var i PersonalAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.TokenHash,
+			&i.Name,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listIdentitiesByUser = `-- name: ListIdentitiesByUser :many
 SELECT id, user_id, provider, provider_subject, email, email_verified, created_at FROM auth_identities
 WHERE user_id = $1
@@ -394,6 +923,83 @@ func (q *Queries) ListIdentitiesByUser(ctx context.Context, userID uuid.UUID) ([
 	return items, nil
 }
 
+const listPasskeys = `-- name: ListPasskeys :many
+SELECT id, user_id, credential_id, public_key, sign_count, transports, name, two_factor, created_at, last_used_at FROM passkeys
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]Passkey, error) {
+	rows, err := q.db.Query(ctx, listPasskeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Passkey{}
+	for rows.Next() {
+		var i Passkey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CredentialID,
+			&i.PublicKey,
+			&i.SignCount,
+			&i.Transports,
+			&i.Name,
+			&i.TwoFactor,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, token_hash, user_id, created_at, expires_at, last_seen_at, two_factor, name, user_agent, ip_prefix FROM sessions
+WHERE user_id = $1 AND expires_at > now()
+ORDER BY last_seen_at DESC, created_at DESC
+`
+
+// The account's live sessions, most recently used first. An expired row the
+// janitor has not reached yet is not a session anyone can use, so it is not
+// listed.
+func (q *Queries) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenHash,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.LastSeenAt,
+			&i.TwoFactor,
+			&i.Name,
+			&i.UserAgent,
+			&i.IpPrefix,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const promoteAdmins = `-- name: PromoteAdmins :execrows
 UPDATE users
 SET role = 'admin', updated_at = now()
@@ -418,6 +1024,36 @@ func (q *Queries) PromoteAdmins(ctx context.Context, emails []string) (int64, er
 	return result.RowsAffected(), nil
 }
 
+const renameSession = `-- name: RenameSession :one
+UPDATE sessions SET name = $3
+WHERE id = $1 AND user_id = $2 AND expires_at > now()
+RETURNING id, token_hash, user_id, created_at, expires_at, last_seen_at, two_factor, name, user_agent, ip_prefix
+`
+
+type RenameSessionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Name   *string
+}
+
+func (q *Queries) RenameSession(ctx context.Context, arg RenameSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, renameSession, arg.ID, arg.UserID, arg.Name)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastSeenAt,
+		&i.TwoFactor,
+		&i.Name,
+		&i.UserAgent,
+		&i.IpPrefix,
+	)
+	return i, err
+}
+
 const setIdentityEmailVerified = `-- name: SetIdentityEmailVerified :exec
 UPDATE auth_identities SET email_verified = true WHERE id = $1
 `
@@ -427,17 +1063,29 @@ func (q *Queries) SetIdentityEmailVerified(ctx context.Context, id uuid.UUID) er
 	return err
 }
 
+const touchAccessToken = `-- name: TouchAccessToken :exec
+UPDATE personal_access_tokens SET last_used_at = now() WHERE id = $1
+`
+
+func (q *Queries) TouchAccessToken(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, touchAccessToken, id)
+	return err
+}
+
 const touchSession = `-- name: TouchSession :exec
-UPDATE sessions SET last_seen_at = now(), expires_at = $2 WHERE id = $1
+UPDATE sessions SET last_seen_at = now(), expires_at = $2, ip_prefix = $3 WHERE id = $1
 `
 
 type TouchSessionParams struct {
 	ID        uuid.UUID
 	ExpiresAt time.Time
+	IpPrefix  string
 }
 
+// The throttled write authenticate makes: slides the expiry, and records when
+// and from which network the session was last used.
 func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
-	_, err := q.db.Exec(ctx, touchSession, arg.ID, arg.ExpiresAt)
+	_, err := q.db.Exec(ctx, touchSession, arg.ID, arg.ExpiresAt, arg.IpPrefix)
 	return err
 }
 
@@ -445,7 +1093,7 @@ const updateUserSettings = `-- name: UpdateUserSettings :one
 UPDATE users
 SET profile_public = $2, keyboard_public = $3, updated_at = now()
 WHERE id = $1
-RETURNING id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at
+RETURNING id, display_name, created_at, profile_public, keyboard_public, updated_at, role, bio, keyboard, display_name_changed_at, two_factor_enabled_at
 `
 
 type UpdateUserSettingsParams struct {
@@ -474,6 +1122,7 @@ func (q *Queries) UpdateUserSettings(ctx context.Context, arg UpdateUserSettings
 		&i.Bio,
 		&i.Keyboard,
 		&i.DisplayNameChangedAt,
+		&i.TwoFactorEnabledAt,
 	)
 	return i, err
 }
@@ -495,6 +1144,31 @@ func (q *Queries) UpsertCredential(ctx context.Context, arg UpsertCredentialPara
 	return err
 }
 
+const upsertPendingTOTP = `-- name: UpsertPendingTOTP :execrows
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret = EXCLUDED.secret, last_step = 0, created_at = now()
+    WHERE (SELECT two_factor_enabled_at FROM users WHERE id = $1) IS NULL
+`
+
+type UpsertPendingTOTPParams struct {
+	UserID uuid.UUID
+	Secret []byte
+}
+
+// An enrolment: a fresh secret, replacing any earlier unconfirmed one. The
+// conflict arm is guarded by the account's enabled flag so a second enrolment
+// can never overwrite the secret of a CONFIRMED second factor — zero rows is
+// that refusal, and the adapter reports it as ErrTwoFactorEnabled.
+func (q *Queries) UpsertPendingTOTP(ctx context.Context, arg UpsertPendingTOTPParams) (int64, error) {
+	result, err := q.db.Exec(ctx, upsertPendingTOTP, arg.UserID, arg.Secret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const useEmailToken = `-- name: UseEmailToken :one
 UPDATE email_tokens
 SET used_at = now()
@@ -527,6 +1201,51 @@ func (q *Queries) UseEmailToken(ctx context.Context, arg UseEmailTokenParams) (E
 	return i, err
 }
 
+const usePasskey = `-- name: UsePasskey :execrows
+UPDATE passkeys
+SET sign_count = $2, last_used_at = now()
+WHERE id = $1 AND (sign_count < $2 OR (sign_count = 0 AND $2 = 0))
+`
+
+type UsePasskeyParams struct {
+	ID        uuid.UUID
+	SignCount int64
+}
+
+// Records a verified assertion. The counter must move strictly forward unless
+// the authenticator does not keep one (0 then 0); check and write are one
+// statement, so two racing assertions cannot both claim the same count. Zero
+// rows is a counter that went backwards or stood still — a cloned
+// authenticator, by the spec's reading.
+func (q *Queries) UsePasskey(ctx context.Context, arg UsePasskeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, usePasskey, arg.ID, arg.SignCount)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   uuid.UUID
+	CodeHash []byte
+}
+
+// Spends one recovery code. Scoped to the account, so a code is worthless
+// against anyone else's login even though the hashes are globally unique.
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const verifyEmailIdentityByUser = `-- name: VerifyEmailIdentityByUser :exec
 UPDATE auth_identities SET email_verified = true WHERE user_id = $1 AND provider = 'email'
 `
@@ -34,11 +34,13 @@ func TestMeJSONContract(t *testing.T) {
 	// (docs/PROFILE.md): the caller's OWN privacy switches, ridden on the
 	// session read the settings UI already makes. They disclose nothing about
 	// anyone else, and this snapshot is where any further field must argue its
-	// way in.
+	// way in. twoFactorEnabled argued its way in with two-factor sign-in
+	// (docs/AUTH.md): the settings screen and the admin surfaces that need a
+	// second factor both key off it, and it says only what the caller set up.
 	assert.Equal(t,
-		[]string{"createdAt", "displayName", "id", "keyboardPublic", "profilePublic", "restricted"},
+		[]string{"createdAt", "displayName", "id", "keyboardPublic", "profilePublic", "restricted", "twoFactorEnabled"},
 		keys,
-		"GET /me must expose exactly {id, displayName, createdAt, restricted, profilePublic, keyboardPublic} in lower-camelCase")
+		"GET /me must expose exactly {id, displayName, createdAt, restricted, profilePublic, keyboardPublic, twoFactorEnabled} in lower-camelCase")
 
 	// `restricted` is a bare boolean and this test is where it stays one. The
 	// banner it drives is deliberately opaque, so a reason, an expiry or an
@@ -1,6 +1,6 @@
 // Package auth is the authentication domain: email/password and OAuth
-// (GitHub, Google) sign-in, opaque server-side sessions, email verification, and
-// password reset.
+// (GitHub, Google, Discord, and any configured OpenID Connect issuer) sign-in,
+// opaque server-side sessions, email verification, and password reset.
 //
 // # Layering
 //
@@ -31,6 +31,25 @@ var ErrEmailOwnedByOtherUser = errors.New("auth: verified email belongs to anoth
 // account_exists_use_linking (an email whose 'email' identity already exists).
 var ErrIdentityExists = errors.New("auth: identity already exists")
 
+// ErrTwoFactorEnabled is returned by BeginTOTPEnrolment and EnableTwoFactor
+// when the account already has a confirmed second factor: a second enrolment
+// must not replace the secret an authenticator app already holds.
+var ErrTwoFactorEnabled = errors.New("auth: two-factor already enabled")
+
+// ErrCodeReplayed is returned by AcceptTOTPStep for a code whose step is not
+// later than the last one accepted — the same code used twice.
+var ErrCodeReplayed = errors.New("auth: totp code already used")
+
+// ErrPasskeyExists is returned by CreatePasskey when the credential id is
+// already registered — to this account or any other. Authenticators mint a
+// fresh id per registration, so this is a replayed registration.
+var ErrPasskeyExists = errors.New("auth: passkey already registered")
+
+// ErrSignCountRegressed is returned by UsePasskey when an assertion's signature
+// counter did not move past the stored one: the spec's sign of a cloned
+// authenticator.
+var ErrSignCountRegressed = errors.New("auth: passkey signature counter did not increase")
+
 // errInvalidToken is an internal sentinel for a malformed token string; it is
 // surfaced to clients as apiErrInvalidToken.
 var errInvalidToken = errors.New("auth: invalid token")
@@ -68,6 +87,11 @@ var (
 		"request origin is not allowed")
 	apiErrProviderUnknown = newAPIError(http.StatusNotFound, "unknown_provider",
 		"unknown or unconfigured OAuth provider")
+	// apiErrProviderUnavailable means an OpenID Connect issuer's discovery
+	// document could not be fetched or did not check out. It is the issuer's
+	// outage, not the caller's mistake, and the next attempt retries it.
+	apiErrProviderUnavailable = newAPIError(http.StatusServiceUnavailable, "provider_unavailable",
+		"the sign-in provider is unreachable; try again shortly")
 	apiErrInternal = newAPIError(http.StatusInternalServerError, "internal",
 		"an unexpected error occurred")
 	apiErrNameTaken = newAPIError(http.StatusConflict, "name_taken",
@@ -79,13 +103,46 @@ var (
 	apiErrNoVerifiedEmail = newAPIError(http.StatusConflict, "no_verified_email",
 		"add and verify an email address before setting a password")
 	apiErrPasswordAlreadySet = newAPIError(http.StatusConflict, "password_already_set",
-		"a password is already set; use the reset flow to change it")
+		"a password is already set; change it with the current one or reset it")
+	apiErrNoPassword = newAPIError(http.StatusConflict, "no_password",
+		"this account has no password to change; set one first")
 	// apiErrOverloaded is load shedding, not a client error: every hashing slot
 	// is busy and admitting this request would commit memory the process does
 	// not have. 503 (not 429) because it is the SERVER that is at capacity, and
 	// retrying shortly really will work. See hashgate.go.
 	apiErrOverloaded = newAPIError(http.StatusServiceUnavailable, "overloaded",
 		"the server is at hashing capacity; retry in a moment")
+	// Two-factor outcomes (twofactor.go). invalid_code covers a wrong code, a
+	// replayed one and a spent recovery code alike: which of the three it was
+	// would tell someone guessing which guesses to stop making.
+	apiErrInvalidCode = newAPIError(http.StatusUnauthorized, "invalid_code",
+		"the code is incorrect or has already been used")
+	apiErrTwoFactorExpired = newAPIError(http.StatusUnauthorized, "two_factor_expired",
+		"the sign-in has expired or had too many wrong codes; sign in again")
+	apiErrTwoFactorEnabled = newAPIError(http.StatusConflict, "two_factor_enabled",
+		"two-factor authentication is already enabled")
+	apiErrTwoFactorNotEnabled = newAPIError(http.StatusConflict, "two_factor_not_enabled",
+		"two-factor authentication is not enabled")
+	apiErrTwoFactorNotEnrolled = newAPIError(http.StatusConflict, "two_factor_not_enrolled",
+		"start an enrolment before confirming it")
+	// Passkey outcomes (passkeys.go). passkey_rejected covers every way an
+	// attestation or assertion can fail to verify, an unknown credential
+	// included: the browser already showed the user what it signed, and the
+	// reason would serve only someone forging one.
+	apiErrPasskeyRejected = newAPIError(http.StatusUnauthorized, "passkey_rejected",
+		"the passkey could not be verified")
+	apiErrPasskeyCeremonyExpired = newAPIError(http.StatusBadRequest, "passkey_ceremony_expired",
+		"the passkey prompt has expired; start again")
+	apiErrPasskeyExists = newAPIError(http.StatusConflict, "passkey_exists",
+		"this passkey is already registered")
+	apiErrPasskeyNotFound = newAPIError(http.StatusNotFound, "passkey_not_found",
+		"no such passkey on this account")
+	apiErrSessionNotFound = newAPIError(http.StatusNotFound, "session_not_found",
+		"no such session on this account")
+	apiErrAccessTokenNotFound = newAPIError(http.StatusNotFound, "access_token_not_found",
+		"no such access token on this account")
+	apiErrTwoFactorSessionRequired = newAPIError(http.StatusForbidden, "two_factor_session_required",
+		"sign in with your second factor to do this")
 	// Captcha outcomes. Both are 400: the request is malformed or unproven, and
 	// the client's remedy in each case is to solve a fresh challenge and retry.
 	// captcha_failed deliberately covers BOTH a provider rejection and a
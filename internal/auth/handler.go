@@ -41,7 +41,11 @@ func (s *Service) AuthRoutes() http.Handler {
 		// Public endpoints (no session required).
 		r.Post("/verify", s.handleVerify)
 		r.Post("/login", s.handleLogin)
+		r.Post("/login/2fa", s.handleLoginSecondFactor)
+		r.Post("/passkeys/login/begin", s.handlePasskeyLoginBegin)
+		r.Post("/passkeys/login/finish", s.handlePasskeyLoginFinish)
 		r.Post("/password-reset/confirm", s.handleResetConfirm)
+		r.Get("/providers", s.handleProviders)
 		r.Get("/oauth/{provider}/start", s.handleOAuthStart)
 		r.Get("/oauth/{provider}/callback", s.handleOAuthCallback)
 
@@ -52,6 +56,16 @@ func (s *Service) AuthRoutes() http.Handler {
 			r.Post("/link/{provider}/start", s.handleLinkStart)
 			r.Post("/email/add", s.handleAddEmail)
 			r.Post("/password/set", s.handleSetPassword)
+			r.Post("/password/change", s.handlePasswordChange)
+			r.Get("/2fa", s.handleTwoFactorStatus)
+			r.Post("/2fa/totp/enroll", s.handleTOTPEnroll)
+			r.Post("/2fa/totp/confirm", s.handleTOTPConfirm)
+			r.Post("/2fa/recovery-codes", s.handleRecoveryCodes)
+			r.Post("/2fa/disable", s.handleTwoFactorDisable)
+			r.Get("/passkeys", s.handlePasskeys)
+			r.Post("/passkeys/register/begin", s.handlePasskeyRegisterBegin)
+			r.Post("/passkeys/register/finish", s.handlePasskeyRegisterFinish)
+			r.Delete("/passkeys/{id}", s.handlePasskeyDelete)
 		})
 	})
 	return r
@@ -202,6 +202,24 @@ func TestJanitorSweep(t *testing.T) {
 	mustExec(t, h.pool, `INSERT INTO email_tokens (user_id, purpose, token_hash, expires_at)
 		VALUES ($1, 'verify', $2, now() + interval '24 hours')`, userID, []byte("tok-active"))
 
+	// Login challenges: one expired (swept), one live (kept).
+	mustExec(t, h.pool, `INSERT INTO login_challenges (token_hash, user_id, expires_at)
+		VALUES ($1, $2, now() - interval '1 minute')`, []byte("challenge-expired"), userID)
+	mustExec(t, h.pool, `INSERT INTO login_challenges (token_hash, user_id, expires_at)
+		VALUES ($1, $2, now() + interval '5 minutes')`, []byte("challenge-live"), userID)
+
+	// Passkey ceremonies: one expired (swept), one live (kept).
+	mustExec(t, h.pool, `INSERT INTO webauthn_ceremonies (token_hash, kind, challenge, expires_at)
+		VALUES ($1, 'login', $2, now() - interval '1 minute')`, []byte("ceremony-expired"), []byte("c1"))
+	mustExec(t, h.pool, `INSERT INTO webauthn_ceremonies (token_hash, kind, user_id, challenge, expires_at)
+		VALUES ($1, 'register', $2, $3, now() + interval '5 minutes')`, []byte("ceremony-live"), userID, []byte("c2"))
+
+	// Access tokens: one expired (swept), one live (kept).
+	mustExec(t, h.pool, `INSERT INTO personal_access_tokens (user_id, token_hash, name, scopes, expires_at)
+		VALUES ($1, $2, 'old bot', '{runs:read}', now() - interval '1 minute')`, userID, []byte("pat-expired"))
+	mustExec(t, h.pool, `INSERT INTO personal_access_tokens (user_id, token_hash, name, scopes, expires_at)
+		VALUES ($1, $2, 'bot', '{runs:read}', now() + interval '30 days')`, userID, []byte("pat-live"))
+
 	nSessions, err := store.DeleteExpiredSessions(ctx)
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), nSessions)
@@ -210,11 +228,31 @@ func TestJanitorSweep(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(2), nTokens)
 
-	var remainingSessions, remainingTokens int
+	nChallenges, err := store.DeleteExpiredLoginChallenges(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), nChallenges)
+
+	nCeremonies, err := store.DeleteExpiredWebAuthnCeremonies(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), nCeremonies)
+
+	nAccessTokens, err := store.DeleteExpiredAccessTokens(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), nAccessTokens)
+
+	var remainingSessions, remainingTokens, remainingChallenges int
 	require.NoError(t, h.pool.QueryRow(ctx, `SELECT count(*) FROM sessions`).Scan(&remainingSessions))
 	require.NoError(t, h.pool.QueryRow(ctx, `SELECT count(*) FROM email_tokens`).Scan(&remainingTokens))
 	assert.Equal(t, 1, remainingSessions, "live session survives")
+	require.NoError(t, h.pool.QueryRow(ctx, `SELECT count(*) FROM login_challenges`).Scan(&remainingChallenges))
 	assert.Equal(t, 3, remainingTokens, "grace-period and active tokens survive")
+	assert.Equal(t, 1, remainingChallenges, "live login challenge survives")
+	var remainingCeremonies int
+	require.NoError(t, h.pool.QueryRow(ctx, `SELECT count(*) FROM webauthn_ceremonies`).Scan(&remainingCeremonies))
+	assert.Equal(t, 1, remainingCeremonies, "live passkey ceremony survives")
+	var remainingAccessTokens int
+	require.NoError(t, h.pool.QueryRow(ctx, `SELECT count(*) FROM personal_access_tokens`).Scan(&remainingAccessTokens))
+	assert.Equal(t, 1, remainingAccessTokens, "live access token survives")
 
 	// RunJanitor honors context cancellation.
 	cctx, cancel := context.WithCancel(ctx)
@@ -98,6 +98,8 @@ type serverOpts struct {
 	// captcha is nil by default — the disabled mode every other test in this
 	// package exercises implicitly.
 	captcha auth.CaptchaVerifier
+	// tokenBurst is the per-access-token bucket; zero takes the service default.
+	tokenBurst int
 }
 
 // newHarness truncates the database, builds the auth service exactly as main
@@ -117,7 +119,7 @@ func newHarness(t *testing.T, mutators ...func(*serverOpts)) *harness {
 	t.Cleanup(pool.Close)
 
 	// Clean slate for this test.
-	_, err = pool.Exec(ctx, `TRUNCATE users, sessions, email_tokens, auth_identities, user_credentials RESTART IDENTITY CASCADE`)
+	_, err = pool.Exec(ctx, `TRUNCATE users, sessions, email_tokens, auth_identities, user_credentials, webauthn_ceremonies RESTART IDENTITY CASCADE`)
 	require.NoError(t, err)
 
 	store := pgstore.New(pool)
@@ -134,6 +136,10 @@ func newHarness(t *testing.T, mutators ...func(*serverOpts)) *harness {
 			SessionTTL:        time.Hour,
 			OAuthRedirectBase: "http://server.test",
 			Providers:         opts.providers,
+			// A bucket that never refills inside a test, so the rate-limit
+			// test counts requests rather than racing the clock.
+			AccessTokenRateEvery: time.Hour,
+			AccessTokenRateBurst: opts.tokenBurst,
 		}, logger)
 
 	r := chi.NewRouter()
@@ -142,6 +148,25 @@ func newHarness(t *testing.T, mutators ...func(*serverOpts)) *harness {
 		r.With(svc.RequireAuth).Get("/me", svc.HandleMe)
 		r.With(svc.RequireOrigin, svc.RequireAuth).
 			Patch("/me/display-name", svc.HandleChangeDisplayName)
+		r.With(svc.RequireAuth).Get("/me/sessions", svc.HandleSessions)
+		r.With(svc.RequireOrigin, svc.RequireAuth).
+			Post("/me/sessions/revoke-others", svc.HandleRevokeOtherSessions)
+		r.With(svc.RequireOrigin, svc.RequireAuth).
+			Patch("/me/sessions/{id}", svc.HandleRenameSession)
+		r.With(svc.RequireOrigin, svc.RequireAuth).
+			Delete("/me/sessions/{id}", svc.HandleRevokeSession)
+		r.With(svc.RequireAuth).Get("/me/tokens", svc.HandleAccessTokens)
+		r.With(svc.RequireOrigin, svc.RequireAuth).
+			Post("/me/tokens", svc.HandleCreateAccessToken)
+		r.With(svc.RequireOrigin, svc.RequireAuth).
+			Delete("/me/tokens/{id}", svc.HandleRevokeAccessToken)
+		// A probe behind the token gate, standing in for the runs routes main.go
+		// wraps the same way: it answers with whoever the gate resolved.
+		r.With(svc.RequireAuthOrToken(auth.ScopeRunsRead)).
+			HandleFunc("/token-probe", func(w http.ResponseWriter, r *http.Request) {
+				u, _ := auth.UserFrom(r.Context())
+				_, _ = io.WriteString(w, u.ID.String())
+			})
 		// A probe behind the permission gate, wired exactly as main.go mounts
 		// the admin subtree (OptionalAuth, then RequirePermission): the
 		// permissions tests assert the 404-invisibility contract against it.
@@ -205,6 +230,17 @@ func (h *harness) patch(path string, body any) *http.Response {
 	return resp
 }
 
+// del sends a DELETE with the required Origin header (CSRF).
+func (h *harness) del(path string) *http.Response {
+	h.t.Helper()
+	req, err := http.NewRequest(http.MethodDelete, h.server.URL+path, http.NoBody)
+	require.NoError(h.t, err)
+	req.Header.Set("Origin", frontendOrigin)
+	resp, err := h.client.Do(req)
+	require.NoError(h.t, err)
+	return resp
+}
+
 // get sends a GET (no Origin needed for safe methods).
 func (h *harness) get(path string) *http.Response {
 	h.t.Helper()
@@ -15,12 +15,24 @@ type Cleaner interface {
 	// DeleteStaleEmailTokens removes email tokens that expired or were
 	// consumed more than 24 hours ago and returns the number of rows deleted.
 	DeleteStaleEmailTokens(ctx context.Context) (int64, error)
+	// DeleteExpiredLoginChallenges removes second-factor logins that expired
+	// unfinished and returns the number of rows deleted.
+	DeleteExpiredLoginChallenges(ctx context.Context) (int64, error)
+	// DeleteExpiredWebAuthnCeremonies removes passkey ceremonies that expired
+	// unanswered and returns the number of rows deleted.
+	DeleteExpiredWebAuthnCeremonies(ctx context.Context) (int64, error)
+	// DeleteExpiredAccessTokens removes personal access tokens past their
+	// expiry and returns the number of rows deleted.
+	DeleteExpiredAccessTokens(ctx context.Context) (int64, error)
 }
 
-// RunJanitor sweeps expired sessions and stale email tokens once immediately
-// and then every interval, until ctx is cancelled. Expiry is already enforced
-// at read time (SessionByTokenHash / UseEmailToken check expires_at), so this
-// is purely hygiene: it keeps dead rows from accumulating forever. Started as
+// RunJanitor sweeps expired sessions, stale email tokens, abandoned
+// second-factor logins, unanswered passkey ceremonies and expired access
+// tokens once immediately and then every interval, until ctx is cancelled.
+// Expiry is already enforced at read time (SessionByTokenHash / UseEmailToken
+// / AttemptLoginChallenge / ConsumeWebAuthnCeremony check expires_at, and the
+// bearer middleware checks an access token's), so this is purely
+// hygiene: it keeps dead rows from accumulating forever. Started as
 // a goroutine from the composition root; ctx is the server shutdown context.
 func RunJanitor(ctx context.Context, c Cleaner, interval time.Duration, log *slog.Logger) {
 	ticker := time.NewTicker(interval)
@@ -41,6 +53,9 @@ func RunJanitor(ctx context.Context, c Cleaner, interval time.Duration, log *slo
 func Sweep(ctx context.Context, c Cleaner, log *slog.Logger) {
 	sessions, serr := c.DeleteExpiredSessions(ctx)
 	tokens, terr := c.DeleteStaleEmailTokens(ctx)
+	challenges, cerr := c.DeleteExpiredLoginChallenges(ctx)
+	ceremonies, werr := c.DeleteExpiredWebAuthnCeremonies(ctx)
+	accessTokens, aerr := c.DeleteExpiredAccessTokens(ctx)
 	// During shutdown the pool may already be closing; that is not an error
 	// worth alarming anyone about.
 	if ctx.Err() != nil {
@@ -52,10 +67,22 @@ func Sweep(ctx context.Context, c Cleaner, log *slog.Logger) {
 	if terr != nil {
 		log.ErrorContext(ctx, "janitor: delete stale email tokens failed", "err", terr)
 	}
-	if serr == nil && terr == nil {
+	if cerr != nil {
+		log.ErrorContext(ctx, "janitor: delete expired login challenges failed", "err", cerr)
+	}
+	if werr != nil {
+		log.ErrorContext(ctx, "janitor: delete expired passkey ceremonies failed", "err", werr)
+	}
+	if aerr != nil {
+		log.ErrorContext(ctx, "janitor: delete expired access tokens failed", "err", aerr)
+	}
+	if serr == nil && terr == nil && cerr == nil && werr == nil && aerr == nil {
 		log.InfoContext(ctx, "janitor sweep complete",
 			"expired_sessions", sessions,
 			"stale_email_tokens", tokens,
+			"expired_login_challenges", challenges,
+			"expired_passkey_ceremonies", ceremonies,
+			"expired_access_tokens", accessTokens,
 		)
 	}
 }
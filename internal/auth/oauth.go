@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
 	"golang.org/x/oauth2/github"
 	"golang.org/x/oauth2/google"
 )
@@ -39,25 +41,53 @@ type oauthUser struct {
 // oauthProvider bundles a configured oauth2.Config with the provider-specific
 // way to fetch the user's profile.
 type oauthProvider struct {
-	name     string
-	cfg      *oauth2.Config
-	userInfo func(ctx context.Context, client *http.Client) (oauthUser, error)
+	name string
+	// displayName is the label GET /auth/providers gives the frontend.
+	displayName string
+	cfg         *oauth2.Config
+	userInfo    func(ctx context.Context, client *http.Client) (oauthUser, error)
+	// discover, when set, fills in cfg's endpoints from an OpenID Connect
+	// discovery document before first use (oidc.go). The built-in providers
+	// have theirs compiled in and leave it nil.
+	discover func(ctx context.Context) error
+}
+
+// ready makes p usable: a no-op for the built-ins, discovery for a generic
+// OIDC provider. Every handler calls it before reading p.cfg.
+func (p *oauthProvider) ready(ctx context.Context) error {
+	if p.discover == nil {
+		return nil
+	}
+	return p.discover(ctx)
 }
 
 // buildProviders constructs the enabled OAuth providers from configuration. A
-// provider with no client id/secret is skipped (disabled).
+// provider with no client id/secret is skipped (disabled), as is a name that
+// is neither built in nor given an OIDC issuer.
 func (s *Service) buildProviders() map[string]*oauthProvider {
 	out := make(map[string]*oauthProvider)
 	for name, creds := range s.cfg.Providers {
 		if creds.ClientID == "" || creds.ClientSecret == "" {
 			continue
 		}
+		var p *oauthProvider
 		switch name {
 		case ProviderGitHub:
-			out[name] = s.buildGitHub(creds)
+			p = s.buildGitHub(creds)
 		case ProviderGoogle:
-			out[name] = s.buildGoogle(creds)
+			p = s.buildGoogle(creds)
+		case ProviderDiscord:
+			p = s.buildDiscord(creds)
+		default:
+			if creds.Issuer == "" {
+				continue
+			}
+			p = s.buildOIDC(name, creds)
 		}
+		if creds.DisplayName != "" {
+			p.displayName = creds.DisplayName
+		}
+		out[name] = p
 	}
 	return out
 }
@@ -88,8 +118,9 @@ func (s *Service) buildGoogle(creds ProviderCredentials) *oauthProvider {
 		Scopes:       []string{"openid", "email", "profile"},
 	}
 	return &oauthProvider{
-		name: ProviderGoogle,
-		cfg:  cfg,
+		name:        ProviderGoogle,
+		displayName: "Google",
+		cfg:         cfg,
 		userInfo: func(ctx context.Context, client *http.Client) (oauthUser, error) {
 			return fetchOIDCUserInfo(ctx, client, userInfoURL)
 		},
@@ -120,14 +151,67 @@ func (s *Service) buildGitHub(creds ProviderCredentials) *oauthProvider {
 		Scopes:       []string{"read:user", "user:email"},
 	}
 	return &oauthProvider{
-		name: ProviderGitHub,
-		cfg:  cfg,
+		name:        ProviderGitHub,
+		displayName: "GitHub",
+		cfg:         cfg,
 		userInfo: func(ctx context.Context, client *http.Client) (oauthUser, error) {
 			return fetchGitHubUserInfo(ctx, client, userURL, emailsURL)
 		},
 	}
 }
 
+// buildDiscord configures Discord's OAuth2. Discord is not an OpenID Connect
+// issuer for our purposes — its profile is the /users/@me object, not a
+// userinfo document — so it is a built-in like GitHub rather than an OIDC
+// entry. "identify" is the profile, "email" adds the address and whether
+// Discord has verified it.
+func (s *Service) buildDiscord(creds ProviderCredentials) *oauthProvider {
+	endpoint := endpoints.Discord
+	if creds.AuthURL != "" {
+		endpoint.AuthURL = creds.AuthURL
+	}
+	if creds.TokenURL != "" {
+		endpoint.TokenURL = creds.TokenURL
+	}
+	userURL := creds.UserInfoURL
+	if userURL == "" {
+		userURL = "https://discord.com/api/users/@me"
+	}
+	cfg := &oauth2.Config{
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		Endpoint:     endpoint,
+		RedirectURL:  s.callbackURL(ProviderDiscord),
+		Scopes:       []string{"identify", "email"},
+	}
+	return &oauthProvider{
+		name:        ProviderDiscord,
+		displayName: "Discord",
+		cfg:         cfg,
+		userInfo: func(ctx context.Context, client *http.Client) (oauthUser, error) {
+			return fetchDiscordUserInfo(ctx, client, userURL)
+		},
+	}
+}
+
+// providerView is one entry of GET /auth/providers.
+type providerView struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// handleProviders lists the configured sign-in providers, so the frontend can
+// draw a button for each — including an OIDC provider it was never built to
+// know about. Sorted by id, so the list is stable across restarts.
+func (s *Service) handleProviders(w http.ResponseWriter, _ *http.Request) {
+	out := make([]providerView, 0, len(s.oauth))
+	for _, p := range s.oauth {
+		out = append(out, providerView{ID: p.name, Name: p.displayName})
+	}
+	slices.SortFunc(out, func(a, b providerView) int { return strings.Compare(a.ID, b.ID) })
+	s.writeJSON(w, http.StatusOK, map[string][]providerView{"providers": out})
+}
+
 // handleOAuthStart begins the login/registration flow: it mints state + a PKCE
 // verifier, stows them in short-lived cookies, and redirects the browser to the
 // provider. It is a top-level GET navigation.
@@ -137,6 +221,9 @@ func (s *Service) handleOAuthStart(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, r, apiErrProviderUnknown)
 		return
 	}
+	if !s.providerReady(w, r, p) {
+		return
+	}
 	authURL, err := s.beginOAuth(w, p, uuid.Nil)
 	if err != nil {
 		s.writeError(w, r, err)
@@ -159,6 +246,9 @@ func (s *Service) handleLinkStart(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, r, apiErrUnauthorized)
 		return
 	}
+	if !s.providerReady(w, r, p) {
+		return
+	}
 	authURL, err := s.beginOAuth(w, p, user.ID)
 	if err != nil {
 		s.writeError(w, r, err)
@@ -167,6 +257,17 @@ func (s *Service) handleLinkStart(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, map[string]string{"authorizeUrl": authURL})
 }
 
+// providerReady runs p.ready for the two start handlers, answering 503
+// provider_unavailable (and logging why) when discovery fails.
+func (s *Service) providerReady(w http.ResponseWriter, r *http.Request, p *oauthProvider) bool {
+	if err := p.ready(r.Context()); err != nil {
+		s.log.ErrorContext(r.Context(), "oauth provider discovery failed", "provider", p.name, "err", err)
+		s.writeError(w, r, apiErrProviderUnavailable)
+		return false
+	}
+	return true
+}
+
 // beginOAuth sets the state/verifier cookies (and the link cookie when linkUser
 // is non-nil) and returns the provider authorize URL with a PKCE challenge.
 func (s *Service) beginOAuth(w http.ResponseWriter, p *oauthProvider, linkUser uuid.UUID) (string, error) {
@@ -219,6 +320,13 @@ func (s *Service) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
 		s.redirectResult(w, r, "oauth_denied")
 		return
 	}
+	// Normally a no-op by now — start needed the same endpoints — unless the
+	// server restarted while the browser was away at the provider.
+	if err := p.ready(ctx); err != nil {
+		s.log.ErrorContext(ctx, "oauth provider discovery failed", "provider", p.name, "err", err)
+		s.redirectResult(w, r, "provider_unavailable")
+		return
+	}
 
 	token, err := p.cfg.Exchange(ctx, code, oauth2.VerifierOption(verifierCookie.Value))
 	if err != nil {
@@ -255,8 +363,24 @@ func (s *Service) completeLogin(w http.ResponseWriter, r *http.Request, p *oauth
 	identity, err := s.store.IdentityByProviderSubject(ctx, p.name, info.Subject)
 	switch {
 	case err == nil:
-		// Known identity: just log in.
-		if serr := s.issueSession(ctx, w, identity.UserID); serr != nil {
+		// Known identity: log in, or, when the account has a second factor,
+		// withhold the session until it is proven. The provider vouched for
+		// the first factor only; the landing page then asks for the code and
+		// finishes through POST /login/2fa, exactly as a password login does.
+		user, uerr := s.store.User(ctx, identity.UserID)
+		if uerr != nil {
+			s.writeError(w, r, uerr)
+			return
+		}
+		if user.TwoFactorEnabledAt != nil {
+			if _, cerr := s.beginSecondFactor(ctx, w, user.ID); cerr != nil {
+				s.writeError(w, r, cerr)
+				return
+			}
+			s.redirectResultParams(w, r, url.Values{"twoFactor": {"required"}})
+			return
+		}
+		if serr := s.issueSession(w, r, identity.UserID, false); serr != nil {
 			s.writeError(w, r, serr)
 			return
 		}
@@ -290,7 +414,8 @@ func (s *Service) completeLogin(w http.ResponseWriter, r *http.Request, p *oauth
 		s.writeError(w, r, err)
 		return
 	}
-	if err := s.issueSession(ctx, w, user.ID); err != nil {
+	// A brand-new account has no second factor to ask for.
+	if err := s.issueSession(w, r, user.ID, false); err != nil {
 		s.writeError(w, r, err)
 		return
 	}
@@ -418,40 +543,53 @@ func (s *Service) setFlowCookie(w http.ResponseWriter, name, value string, ttl t
 	})
 }
 
-// clearFlowCookies expires all three flow cookies.
+// clearFlowCookies expires all three OAuth flow cookies.
 func (s *Service) clearFlowCookies(w http.ResponseWriter) {
 	for _, name := range []string{cookieOAuthState, cookieOAuthVerifier, cookieOAuthLink} {
-		http.SetCookie(w, &http.Cookie{
-			Name:     name,
-			Value:    "",
-			Path:     "/",
-			Domain:   s.cfg.CookieDomain,
-			HttpOnly: true,
-			Secure:   s.cfg.CookieSecure,
-			SameSite: http.SameSiteLaxMode,
-			MaxAge:   -1,
-		})
+		s.expireFlowCookie(w, name)
 	}
 }
 
+// expireFlowCookie expires one short-lived flow cookie set by setFlowCookie.
+func (s *Service) expireFlowCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		Domain:   s.cfg.CookieDomain,
+		HttpOnly: true,
+		Secure:   s.cfg.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	})
+}
+
 // --- provider profile fetchers ---
 
-// fetchOIDCUserInfo reads a standard OIDC userinfo document (Google).
+// fetchOIDCUserInfo reads a standard OIDC userinfo document (Google and every
+// configured OIDC provider). "name" is optional in the standard and a
+// self-hosted issuer often has only the login, so preferred_username stands
+// in for it, the way GitHub's login does.
 func fetchOIDCUserInfo(ctx context.Context, client *http.Client, userInfoURL string) (oauthUser, error) {
 	var body struct {
-		Sub           string `json:"sub"`
-		Email         string `json:"email"`
-		EmailVerified bool   `json:"email_verified"`
-		Name          string `json:"name"`
+		Sub               string `json:"sub"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
 	}
 	if err := getJSON(ctx, client, userInfoURL, &body); err != nil {
 		return oauthUser{}, err
 	}
+	name := body.Name
+	if name == "" {
+		name = body.PreferredUsername
+	}
 	return oauthUser{
 		Subject:       body.Sub,
 		Email:         body.Email,
 		EmailVerified: body.EmailVerified,
-		Name:          body.Name,
+		Name:          name,
 	}, nil
 }
 
@@ -499,6 +637,36 @@ func fetchGitHubUserInfo(ctx context.Context, client *http.Client, userURL, emai
 	}, nil
 }
 
+// fetchDiscordUserInfo reads Discord's current-user object. Its "verified" is
+// about the email address, which is exactly what EmailVerified means here;
+// global_name is the display name the user chose and username the unique
+// handle beneath it.
+func fetchDiscordUserInfo(ctx context.Context, client *http.Client, userURL string) (oauthUser, error) {
+	var user struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+		Email      string `json:"email"`
+		Verified   bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, userURL, &user); err != nil {
+		return oauthUser{}, err
+	}
+	if user.ID == "" {
+		return oauthUser{}, errors.New("discord: empty user id")
+	}
+	name := user.GlobalName
+	if name == "" {
+		name = user.Username
+	}
+	return oauthUser{
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: user.Verified,
+		Name:          name,
+	}, nil
+}
+
 // getJSON performs a GET (with a bounded timeout) and decodes a JSON body.
 func getJSON(ctx context.Context, client *http.Client, endpoint string, dst any) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -516,7 +684,7 @@ func getJSON(ctx context.Context, client *http.Client, endpoint string, dst any)
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("userinfo request to %s: status %d", endpoint, resp.StatusCode)
+		return fmt.Errorf("request to %s: status %d", endpoint, resp.StatusCode)
 	}
 	return json.NewDecoder(resp.Body).Decode(dst)
 }
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// Generic OpenID Connect providers. An operator configures one with nothing
+// but a name, an issuer URL and client credentials; the authorize, token and
+// userinfo endpoints come from the issuer's discovery document (OpenID
+// Connect Discovery 1.0 §4), so a self-hosted Keycloak works with the same
+// three settings as any hosted issuer.
+//
+// Discovery is lazy. The document is fetched the first time the provider is
+// used, not at startup, so an identity provider that is down when we deploy
+// costs its own sign-in button and nothing else: the server boots, and every
+// other way in keeps working. A successful fetch is kept for the life of the
+// process — issuers do not move their endpoints in practice, and a restart
+// picks up one that did. A failed fetch is not kept; the next attempt retries.
+//
+// The profile is read from the userinfo endpoint with the access token, the
+// same way Google's is, rather than from the ID token. Over TLS to the
+// issuer's own endpoint that answer is as authoritative as a signed token, and
+// it spares us a JWKS cache and its key-rotation edge cases.
+
+// oidcDiscoveryPath is appended to the issuer to find its metadata.
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// oidcMetadata is the subset of the discovery document the flow uses.
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// oidcDiscovery resolves one issuer's endpoints into its oauth2.Config. The
+// config's Endpoint is written exactly once, under mu, and every reader goes
+// through resolve first, which takes mu — so the write happens-before every
+// read without the rest of oauth.go knowing any of this exists.
+type oidcDiscovery struct {
+	issuer string
+	cfg    *oauth2.Config
+
+	mu          sync.Mutex
+	userInfoURL string // empty until discovery has succeeded
+}
+
+// resolve fetches and applies the discovery document unless that has already
+// succeeded. Concurrent first uses queue on mu and share one fetch.
+func (d *oidcDiscovery) resolve(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.userInfoURL != "" {
+		return nil
+	}
+	md, err := fetchOIDCMetadata(ctx, d.issuer)
+	if err != nil {
+		return err
+	}
+	d.cfg.Endpoint = oauth2.Endpoint{AuthURL: md.AuthorizationEndpoint, TokenURL: md.TokenEndpoint}
+	d.userInfoURL = md.UserinfoEndpoint
+	return nil
+}
+
+// userInfo is the resolved userinfo endpoint; resolve must have succeeded.
+func (d *oidcDiscovery) userInfo() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.userInfoURL
+}
+
+// fetchOIDCMetadata reads and checks an issuer's discovery document. The
+// issuer the document claims must be the one we asked (Discovery §4.3):
+// anything else is a misconfiguration or a document served by somebody who
+// is not that issuer, and either way it must not get to choose where our
+// users type their passwords. A trailing slash on either side is forgiven,
+// because operators copy the issuer from wherever it was displayed.
+func fetchOIDCMetadata(ctx context.Context, issuer string) (oidcMetadata, error) {
+	var md oidcMetadata
+	endpoint := strings.TrimSuffix(issuer, "/") + oidcDiscoveryPath
+	if err := getJSON(ctx, http.DefaultClient, endpoint, &md); err != nil {
+		return oidcMetadata{}, err
+	}
+	if strings.TrimSuffix(md.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return oidcMetadata{}, fmt.Errorf("oidc discovery: document names issuer %q, configured %q", md.Issuer, issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.UserinfoEndpoint == "" {
+		return oidcMetadata{}, errors.New("oidc discovery: authorization, token or userinfo endpoint missing")
+	}
+	return md, nil
+}
+
+// buildOIDC configures a generic OpenID Connect provider under name. Its
+// Endpoint stays empty until discovery fills it in; oauthProvider.ready is
+// what every handler calls before touching it.
+func (s *Service) buildOIDC(name string, creds ProviderCredentials) *oauthProvider {
+	cfg := &oauth2.Config{
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		RedirectURL:  s.callbackURL(name),
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+	d := &oidcDiscovery{issuer: creds.Issuer, cfg: cfg}
+	return &oauthProvider{
+		name:        name,
+		displayName: name,
+		cfg:         cfg,
+		discover:    d.resolve,
+		userInfo: func(ctx context.Context, client *http.Client) (oauthUser, error) {
+			return fetchOIDCUserInfo(ctx, client, d.userInfo())
+		},
+	}
+}
@@ -0,0 +1,368 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/auth"
+)
+
+const (
+	stubClientID     = "stub-client"
+	stubClientSecret = "stub-secret"
+	// providerKeycloak is the operator-chosen name the OIDC tests configure,
+	// standing in for a self-hosted Keycloak.
+	providerKeycloak = "keycloak"
+)
+
+// --- stub issuer ---
+
+// stubIssuer is a local identity provider that, unlike fakeProvider, plays
+// every part of the flow: the discovery document, an /authorize that mints a
+// code bound to the PKCE challenge it was sent, a /token that refuses any
+// verifier not matching that challenge, and the profile endpoints — OIDC
+// userinfo and Discord's /users/@me, so one stub serves both providers.
+type stubIssuer struct {
+	server *httptest.Server
+
+	mu sync.Mutex
+	// issuer is what discovery claims; empty means the stub's own URL.
+	issuer      string
+	discoveries int
+	codes       map[string]string // code -> code_challenge
+	nextCode    int
+	claims      map[string]any
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	t.Helper()
+	si := &stubIssuer{codes: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		si.mu.Lock()
+		defer si.mu.Unlock()
+		si.discoveries++
+		issuer := si.issuer
+		if issuer == "" {
+			issuer = si.server.URL
+		}
+		writeStubJSON(w, http.StatusOK, map[string]any{
+			"issuer":                           issuer,
+			"authorization_endpoint":           si.server.URL + "/authorize",
+			"token_endpoint":                   si.server.URL + "/token",
+			"userinfo_endpoint":                si.server.URL + "/userinfo",
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("GET /authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != stubClientID || q.Get("response_type") != "code" ||
+			q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+			http.Error(w, "bad authorize request", http.StatusBadRequest)
+			return
+		}
+		si.mu.Lock()
+		si.nextCode++
+		code := "code-" + strconv.Itoa(si.nextCode)
+		si.codes[code] = q.Get("code_challenge")
+		si.mu.Unlock()
+
+		back, err := url.Parse(q.Get("redirect_uri"))
+		if err != nil {
+			http.Error(w, "bad redirect_uri", http.StatusBadRequest)
+			return
+		}
+		params := url.Values{"code": {code}, "state": {q.Get("state")}}
+		back.RawQuery = params.Encode()
+		http.Redirect(w, r, back.String(), http.StatusFound)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+		}
+		if id != stubClientID || secret != stubClientSecret {
+			writeStubJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+		si.mu.Lock()
+		challenge, known := si.codes[r.PostFormValue("code")]
+		delete(si.codes, r.PostFormValue("code")) // codes are single-use
+		si.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if !known || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			writeStubJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeStubJSON(w, http.StatusOK, map[string]any{
+			"access_token": "stub-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	profile := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer stub-access-token" {
+			writeStubJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+			return
+		}
+		si.mu.Lock()
+		defer si.mu.Unlock()
+		writeStubJSON(w, http.StatusOK, si.claims)
+	}
+	mux.HandleFunc("GET /userinfo", profile)
+	mux.HandleFunc("GET /users/@me", profile)
+
+	si.server = httptest.NewServer(mux)
+	t.Cleanup(si.server.Close)
+	return si
+}
+
+func writeStubJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// setClaims replaces the profile the stub hands out, in whichever provider's
+// shape the test is exercising.
+func (si *stubIssuer) setClaims(claims map[string]any) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.claims = claims
+}
+
+func (si *stubIssuer) setIssuer(issuer string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.issuer = issuer
+}
+
+func (si *stubIssuer) discoveryCount() int {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return si.discoveries
+}
+
+// creds configures the stub twice: as a generic OIDC provider found by
+// discovery, and as Discord with its endpoints overridden.
+func (si *stubIssuer) creds() map[string]auth.ProviderCredentials {
+	return map[string]auth.ProviderCredentials{
+		providerKeycloak: {
+			ClientID:     stubClientID,
+			ClientSecret: stubClientSecret,
+			Issuer:       si.server.URL + "/",
+			DisplayName:  "Club SSO",
+		},
+		auth.ProviderDiscord: {
+			ClientID:     stubClientID,
+			ClientSecret: stubClientSecret,
+			AuthURL:      si.server.URL + "/authorize",
+			TokenURL:     si.server.URL + "/token",
+			UserInfoURL:  si.server.URL + "/users/@me",
+		},
+	}
+}
+
+// throughIssuer follows an authorize URL through the stub, which answers with
+// the redirect a consenting browser would get, and delivers that callback to
+// the harness. It returns the callback's final Location.
+func (h *harness) throughIssuer(t *testing.T, authorizeURL string) string {
+	t.Helper()
+	return h.deliverCallback(t, h.authorize(t, authorizeURL))
+}
+
+// authorize asks the stub for a code and returns the callback URL it sends
+// the browser back to.
+func (h *harness) authorize(t *testing.T, authorizeURL string) *url.URL {
+	t.Helper()
+	resp, err := h.client.Get(authorizeURL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return callback
+}
+
+// deliverCallback replays a callback URL (built against OAuthRedirectBase)
+// on the harness server.
+func (h *harness) deliverCallback(t *testing.T, callback *url.URL) string {
+	t.Helper()
+	resp := h.get(callback.RequestURI())
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	return resp.Header.Get("Location")
+}
+
+// startLogin begins a login with provider and returns the authorize URL.
+func (h *harness) startLogin(t *testing.T, provider string) string {
+	t.Helper()
+	resp := h.get(authBase + "/oauth/" + provider + "/start")
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	return resp.Header.Get("Location")
+}
+
+func (h *harness) identityOwner(t *testing.T, provider, subject string) string {
+	t.Helper()
+	var userID string
+	require.NoError(t, h.pool.QueryRow(context.Background(),
+		`SELECT user_id::text FROM auth_identities WHERE provider = $1 AND provider_subject = $2`,
+		provider, subject).Scan(&userID))
+	return userID
+}
+
+// --- tests ---
+
+func TestProvidersList(t *testing.T) {
+	si := newStubIssuer(t)
+	providers := si.creds()
+	// Credentials under a name that is neither built in nor given an issuer
+	// configure nothing.
+	providers["gitlab"] = auth.ProviderCredentials{ClientID: "id", ClientSecret: "secret"}
+	h := newHarness(t, withProviders(providers))
+
+	resp := h.get(authBase + "/providers")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	type provider struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	got := decodeInto[struct {
+		Providers []provider `json:"providers"`
+	}](t, resp).Providers
+	assert.Equal(t, []provider{
+		{ID: "discord", Name: "Discord"},
+		{ID: "keycloak", Name: "Club SSO"},
+	}, got)
+	assert.Zero(t, si.discoveryCount(), "listing providers must not wait on any issuer")
+
+	resp = h.get(authBase + "/oauth/gitlab/start")
+	assert.Equal(t, "unknown_provider", decodeInto[errResponse](t, resp).Error)
+}
+
+func TestOIDCLoginThroughDiscovery(t *testing.T) {
+	si := newStubIssuer(t)
+	si.setClaims(map[string]any{
+		"sub":                "kc-7f3a",
+		"email":              "sso-player@example.com",
+		"email_verified":     true,
+		"preferred_username": "ssoplayer",
+	})
+	h := newHarness(t, withProviders(si.creds()))
+
+	authorizeURL := h.startLogin(t, providerKeycloak)
+	assert.Contains(t, authorizeURL, si.server.URL+"/authorize?",
+		"the authorize endpoint comes from the discovery document")
+	loc := h.throughIssuer(t, authorizeURL)
+	assert.Contains(t, loc, "status=ok")
+
+	created := decodeInto[meResponse](t, h.get(mePath))
+	assert.Equal(t, "ssoplayer", created.DisplayName, "preferred_username stands in for a missing name")
+	assert.Equal(t, created.ID, h.identityOwner(t, providerKeycloak, "kc-7f3a"))
+
+	// The same subject signs in to the same account, and the document is not
+	// fetched again.
+	loc = h.throughIssuer(t, h.startLogin(t, providerKeycloak))
+	assert.Contains(t, loc, "status=ok")
+	again := decodeInto[meResponse](t, h.get(mePath))
+	assert.Equal(t, created.ID, again.ID)
+	assert.Equal(t, 1, si.discoveryCount())
+}
+
+func TestOIDCLinkToExistingAccount(t *testing.T) {
+	si := newStubIssuer(t)
+	si.setClaims(map[string]any{"sub": "kc-link", "email": "elsewhere@example.com", "email_verified": true})
+	h := newHarness(t, withProviders(si.creds()))
+
+	h.registerVerifyLogin("kc-linker@example.com", "password-link-kc", "KcLinker")
+	me := decodeInto[meResponse](t, h.get(mePath))
+
+	resp := h.post(authBase+"/link/"+providerKeycloak+"/start", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	authorizeURL := decodeInto[struct {
+		AuthorizeURL string `json:"authorizeUrl"`
+	}](t, resp).AuthorizeURL
+
+	loc := h.throughIssuer(t, authorizeURL)
+	assert.Contains(t, loc, "linked="+providerKeycloak)
+	assert.Equal(t, me.ID, h.identityOwner(t, providerKeycloak, "kc-link"))
+}
+
+// PKCE is end to end: a code minted for one start cannot be redeemed with
+// the verifier of another, even when the state checks out. The stub's token
+// endpoint is the judge, exactly as a real issuer's would be.
+func TestOIDCCodeBoundToVerifier(t *testing.T) {
+	si := newStubIssuer(t)
+	si.setClaims(map[string]any{"sub": "kc-pkce", "email": "pkce@example.com", "email_verified": true})
+	h := newHarness(t, withProviders(si.creds()))
+
+	first := h.authorize(t, h.startLogin(t, providerKeycloak))
+	// A second start replaces the state and verifier cookies.
+	second := stateFromURL(t, h.startLogin(t, providerKeycloak))
+
+	q := first.Query()
+	q.Set("state", second)
+	first.RawQuery = q.Encode()
+	loc := h.deliverCallback(t, first)
+	assert.Contains(t, loc, "error=oauth_exchange_failed")
+	requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+}
+
+// A discovery document that names a different issuer is refused, and the
+// refusal is not remembered: once the issuer answers correctly, sign-in works
+// without a restart.
+func TestOIDCDiscoveryFailureIsRetried(t *testing.T) {
+	si := newStubIssuer(t)
+	si.setClaims(map[string]any{"sub": "kc-retry", "email": "retry@example.com", "email_verified": true})
+	h := newHarness(t, withProviders(si.creds()))
+
+	si.setIssuer("https://impostor.example.com")
+	resp := h.get(authBase + "/oauth/" + providerKeycloak + "/start")
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "provider_unavailable", decodeInto[errResponse](t, resp).Error)
+
+	si.setIssuer("")
+	loc := h.throughIssuer(t, h.startLogin(t, providerKeycloak))
+	assert.Contains(t, loc, "status=ok")
+	assert.Equal(t, 2, si.discoveryCount())
+}
+
+func TestDiscordLogin(t *testing.T) {
+	si := newStubIssuer(t)
+	si.setClaims(map[string]any{
+		"id":          "80351110224678912",
+		"username":    "racer_42",
+		"global_name": "Discord Racer",
+		"email":       "Racer@Example.com",
+		"verified":    true,
+	})
+	h := newHarness(t, withProviders(si.creds()))
+
+	loc := h.throughIssuer(t, h.startLogin(t, auth.ProviderDiscord))
+	assert.Contains(t, loc, "status=ok")
+
+	me := decodeInto[meResponse](t, h.get(mePath))
+	assert.Equal(t, "DiscordRacer", me.DisplayName, "global_name wins over the username")
+	assert.Equal(t, me.ID, h.identityOwner(t, auth.ProviderDiscord, "80351110224678912"))
+
+	var email string
+	var verified bool
+	require.NoError(t, h.pool.QueryRow(context.Background(),
+		`SELECT email::text, email_verified FROM auth_identities WHERE provider = 'discord'`).
+		Scan(&email, &verified))
+	assert.Equal(t, "racer@example.com", email)
+	assert.True(t, verified, "Discord's verified flag is the email's")
+}
@@ -0,0 +1,535 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Passkeys (docs/AUTH.md, "Passkeys"): WebAuthn credentials registered to an
+// account and used to sign in without a password. webauthn.go verifies the
+// ceremonies; this file runs them.
+//
+// Each ceremony is two requests. The first stores a random challenge
+// server-side and hands the browser an opaque token for it in a flow cookie,
+// the same short-lived cookie the OAuth flows use for their state; the second
+// consumes the challenge — once — and verifies what the authenticator signed
+// over it.
+const (
+	cookiePasskeyCeremony = "tm_passkey"
+	passkeyCeremonyTTL    = 5 * time.Minute
+	passkeyChallengeLen   = 32
+	// passkeyTimeout is the hint the browser gets for how long to wait on the
+	// authenticator, in milliseconds: the ceremony's own lifetime.
+	passkeyTimeout = int64(passkeyCeremonyTTL / time.Millisecond)
+	// maxPasskeysPerUser bounds how many rows one account can create. Far past
+	// anyone's key ring; it exists so a session cannot fill a table.
+	maxPasskeysPerUser = 20
+	maxPasskeyNameLen  = 64
+	defaultPasskeyName = "Passkey"
+	// passkeyRPName is the relying-party name authenticators show beside the
+	// credential.
+	passkeyRPName = "TypeMore"
+)
+
+// passkeyTransports are the AuthenticatorTransport values (§5.8.4) stored
+// with a credential and handed back as a hint on the next registration's
+// exclude list. Anything else a client sends is dropped.
+var passkeyTransports = map[string]bool{
+	"usb": true, "nfc": true, "ble": true, "smart-card": true, "hybrid": true, "internal": true,
+}
+
+// newRelyingParty resolves the relying party from the configuration: the
+// frontend's origin runs the ceremonies, and the RP ID is its host unless
+// PasskeyRPID names a registrable suffix of it.
+func newRelyingParty(cfg Config) relyingParty {
+	id := cfg.PasskeyRPID
+	if id == "" {
+		if u, err := url.Parse(cfg.FrontendOrigin); err == nil {
+			id = u.Hostname()
+		}
+	}
+	return relyingParty{id: id, name: passkeyRPName, origin: cfg.FrontendOrigin}
+}
+
+// --- wire types ---
+//
+// The option and credential shapes are the JSON forms WebAuthn Level 3 defines
+// (PublicKeyCredential.parseCreationOptionsFromJSON / toJSON), so a client can
+// pass them between the browser and this API without touching a byte: every
+// binary field is base64url without padding.
+
+type passkeyCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type passkeyCreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams []passkeyCredParam `json:"pubKeyCredParams"`
+	Timeout          int64              `json:"timeout"`
+	// ExcludeCredentials lists the account's passkeys, so an authenticator
+	// that already holds one declines to mint a second.
+	ExcludeCredentials     []passkeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey        string `json:"residentKey"`
+		RequireResidentKey bool   `json:"requireResidentKey"`
+		UserVerification   string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+type passkeyCredParam struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type passkeyRequestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPID             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"`
+	UserVerification string `json:"userVerification"`
+	// AllowCredentials is always empty: a sign-in does not name the account,
+	// the authenticator offers whichever discoverable credential the user
+	// picks, and the assertion says which it was.
+	AllowCredentials []passkeyCredentialDescriptor `json:"allowCredentials"`
+}
+
+// passkeyCredentialJSON is a PublicKeyCredential as toJSON() renders it, for
+// both ceremonies: registration fills clientDataJSON and attestationObject,
+// sign-in clientDataJSON, authenticatorData, signature and userHandle. Fields
+// the verifier does not read are declared so the strict decoder accepts the
+// browser's output as it comes.
+type passkeyCredentialJSON struct {
+	ID                      string          `json:"id"`
+	RawID                   string          `json:"rawId"`
+	Type                    string          `json:"type"`
+	AuthenticatorAttachment string          `json:"authenticatorAttachment"`
+	ClientExtensionResults  json.RawMessage `json:"clientExtensionResults"`
+	Response                struct {
+		ClientDataJSON     string   `json:"clientDataJSON"`
+		AttestationObject  string   `json:"attestationObject"`
+		AuthenticatorData  string   `json:"authenticatorData"`
+		Signature          string   `json:"signature"`
+		UserHandle         string   `json:"userHandle"`
+		Transports         []string `json:"transports"`
+		PublicKey          string   `json:"publicKey"`
+		PublicKeyAlgorithm int64    `json:"publicKeyAlgorithm"`
+	} `json:"response"`
+}
+
+// rawID decodes the credential id, which toJSON writes twice.
+func (c passkeyCredentialJSON) rawID() ([]byte, error) {
+	if c.Type != "public-key" || c.ID != c.RawID {
+		return nil, webauthnErrorf("not a public-key credential")
+	}
+	id, err := webauthnB64.DecodeString(c.RawID)
+	if err != nil || len(id) == 0 || len(id) > maxCredentialIDLen {
+		return nil, webauthnErrorf("credential id")
+	}
+	return id, nil
+}
+
+// decodeFields base64url-decodes the named response fields, all required.
+func decodeFields(fields ...string) ([][]byte, error) {
+	out := make([][]byte, len(fields))
+	for i, f := range fields {
+		b, err := webauthnB64.DecodeString(f)
+		if err != nil || len(b) == 0 {
+			return nil, webauthnErrorf("credential response field %d", i)
+		}
+		out[i] = b
+	}
+	return out, nil
+}
+
+// passkeyView is one passkey as the management endpoints show it. Neither the
+// credential id nor the key is shown: the account's own settings screen has
+// no use for them.
+type passkeyView struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+func toPasskeyView(p Passkey) passkeyView {
+	return passkeyView{ID: p.ID, Name: p.Name, CreatedAt: p.CreatedAt, LastUsedAt: p.LastUsedAt}
+}
+
+// --- ceremony state ---
+
+// beginCeremony stores a fresh challenge for a ceremony of kind and sets the
+// flow cookie that names it.
+func (s *Service) beginCeremony(ctx context.Context, w http.ResponseWriter, kind string, userID *uuid.UUID) ([]byte, error) {
+	challenge := make([]byte, passkeyChallengeLen)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("generate passkey challenge: %w", err)
+	}
+	token, hash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateWebAuthnCeremony(ctx, hash, kind, userID, challenge, s.now().Add(passkeyCeremonyTTL)); err != nil {
+		return nil, err
+	}
+	s.setFlowCookie(w, cookiePasskeyCeremony, token, passkeyCeremonyTTL)
+	return challenge, nil
+}
+
+// finishCeremony takes back the challenge of the ceremony the cookie names,
+// once, and expires the cookie whatever happens next: a failed ceremony
+// starts over from the beginning, never from the middle.
+func (s *Service) finishCeremony(ctx context.Context, w http.ResponseWriter, r *http.Request, kind string) (*uuid.UUID, []byte, error) {
+	cookie, err := r.Cookie(cookiePasskeyCeremony)
+	if err != nil {
+		return nil, nil, apiErrPasskeyCeremonyExpired
+	}
+	s.expireFlowCookie(w, cookiePasskeyCeremony)
+	hash, err := hashToken(cookie.Value)
+	if err != nil {
+		return nil, nil, apiErrPasskeyCeremonyExpired
+	}
+	userID, challenge, err := s.store.ConsumeWebAuthnCeremony(ctx, hash, kind)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil, apiErrPasskeyCeremonyExpired
+	}
+	return userID, challenge, err
+}
+
+// rejectPasskey logs why a ceremony failed and answers passkey_rejected.
+func (s *Service) rejectPasskey(w http.ResponseWriter, r *http.Request, err error) {
+	s.log.InfoContext(r.Context(), "passkey rejected", "err", err)
+	s.writeError(w, r, apiErrPasskeyRejected)
+}
+
+// --- registration ---
+
+// mayRegisterPasskey is the rule a new passkey has to clear when the account
+// has a second factor: the session asking must have proven it. A passkey is a
+// whole sign-in, so minting one from a one-factor session would turn a stolen
+// session cookie into a standing way past the TOTP step.
+func mayRegisterPasskey(user User) bool {
+	return user.TwoFactorEnabledAt == nil || user.TwoFactorSession
+}
+
+// handlePasskeyRegisterBegin answers the creation options for a new passkey on
+// the signed-in account.
+func (s *Service) handlePasskeyRegisterBegin(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	if !mayRegisterPasskey(user) {
+		s.writeError(w, r, apiErrTwoFactorSessionRequired)
+		return
+	}
+	ctx := r.Context()
+	existing, err := s.store.Passkeys(ctx, user.ID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if len(existing) >= maxPasskeysPerUser {
+		s.writeError(w, r, newAPIError(http.StatusConflict, "too_many_passkeys",
+			fmt.Sprintf("an account can hold at most %d passkeys", maxPasskeysPerUser)))
+		return
+	}
+	challenge, err := s.beginCeremony(ctx, w, CeremonyRegister, &user.ID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var opts passkeyCreationOptions
+	opts.Challenge = webauthnB64.EncodeToString(challenge)
+	opts.RP.ID, opts.RP.Name = s.rp.id, s.rp.name
+	// The user handle is the account id: stable, and carrying nothing a
+	// passkey provider syncing it should not see.
+	opts.User.ID = webauthnB64.EncodeToString(user.ID[:])
+	opts.User.Name, opts.User.DisplayName = user.DisplayName, user.DisplayName
+	for _, alg := range webauthnAlgorithms {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, passkeyCredParam{Type: "public-key", Alg: alg})
+	}
+	opts.Timeout = passkeyTimeout
+	opts.ExcludeCredentials = make([]passkeyCredentialDescriptor, 0, len(existing))
+	for _, p := range existing {
+		opts.ExcludeCredentials = append(opts.ExcludeCredentials, passkeyCredentialDescriptor{
+			Type: "public-key", ID: webauthnB64.EncodeToString(p.CredentialID), Transports: p.Transports,
+		})
+	}
+	opts.AuthenticatorSelection.ResidentKey = "required"
+	opts.AuthenticatorSelection.RequireResidentKey = true
+	opts.AuthenticatorSelection.UserVerification = "required"
+	opts.Attestation = "none"
+	s.writeJSON(w, http.StatusOK, opts)
+}
+
+// handlePasskeyRegisterFinish verifies the authenticator's attestation and
+// stores the new passkey. The answer is 201 with the passkey's view.
+func (s *Service) handlePasskeyRegisterFinish(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	var req struct {
+		Name       string                `json:"name"`
+		Credential passkeyCredentialJSON `json:"credential"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	if utf8.RuneCountInString(name) > maxPasskeyNameLen {
+		s.writeError(w, r, apiErrBadRequest(fmt.Sprintf("a passkey name is at most %d characters", maxPasskeyNameLen)))
+		return
+	}
+	ctx := r.Context()
+
+	owner, challenge, err := s.finishCeremony(ctx, w, r, CeremonyRegister)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	// The ceremony belongs to the account that began it; a session swapped in
+	// between does not inherit it.
+	if owner == nil || *owner != user.ID {
+		s.writeError(w, r, apiErrPasskeyCeremonyExpired)
+		return
+	}
+	// Checked again: the second factor may have been turned on since begin.
+	if !mayRegisterPasskey(user) {
+		s.writeError(w, r, apiErrTwoFactorSessionRequired)
+		return
+	}
+
+	id, err := req.Credential.rawID()
+	if err != nil {
+		s.rejectPasskey(w, r, err)
+		return
+	}
+	fields, err := decodeFields(req.Credential.Response.ClientDataJSON, req.Credential.Response.AttestationObject)
+	if err != nil {
+		s.rejectPasskey(w, r, err)
+		return
+	}
+	cred, err := s.rp.verifyRegistration(challenge, fields[0], fields[1])
+	if err != nil {
+		s.rejectPasskey(w, r, err)
+		return
+	}
+	if !bytes.Equal(cred.id, id) {
+		s.rejectPasskey(w, r, webauthnErrorf("credential id differs from the attested one"))
+		return
+	}
+
+	var transports []string
+	for _, t := range req.Credential.Response.Transports {
+		if passkeyTransports[t] && len(transports) < len(passkeyTransports) {
+			transports = append(transports, t)
+		}
+	}
+	passkey, err := s.store.CreatePasskey(ctx, PasskeyParams{
+		UserID:       user.ID,
+		CredentialID: cred.id,
+		PublicKey:    cred.publicKey,
+		SignCount:    int64(cred.signCount),
+		Transports:   transports,
+		Name:         name,
+		TwoFactor:    user.TwoFactorEnabledAt != nil && user.TwoFactorSession,
+	})
+	if err != nil {
+		if errors.Is(err, ErrPasskeyExists) {
+			s.writeError(w, r, apiErrPasskeyExists)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, toPasskeyView(passkey))
+}
+
+// --- sign-in ---
+
+// handlePasskeyLoginBegin answers the request options for a passkey sign-in.
+// It is public: nothing about the account is known yet, or needed.
+func (s *Service) handlePasskeyLoginBegin(w http.ResponseWriter, r *http.Request) {
+	challenge, err := s.beginCeremony(r.Context(), w, CeremonyLogin, nil)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, passkeyRequestOptions{
+		Challenge:        webauthnB64.EncodeToString(challenge),
+		RPID:             s.rp.id,
+		Timeout:          passkeyTimeout,
+		UserVerification: "required",
+		AllowCredentials: []passkeyCredentialDescriptor{},
+	})
+}
+
+// handlePasskeyLoginFinish verifies an assertion and signs its account in.
+// The answer is the same as POST /login's: the user view and a session, or a
+// 202 and a login challenge when the account has a second factor the passkey
+// does not vouch for (see Passkey.TwoFactor).
+//
+// A user-verified passkey is two factors at once — the device and the PIN or
+// biometric that unlocked it — so when the passkey was registered by someone
+// who had proven the account's TOTP, the TOTP step is not asked for again, and
+// the session counts as a second-factor one.
+func (s *Service) handlePasskeyLoginFinish(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Credential passkeyCredentialJSON `json:"credential"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	ctx := r.Context()
+	_, challenge, err := s.finishCeremony(ctx, w, r, CeremonyLogin)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	id, err := req.Credential.rawID()
+	if err != nil {
+		s.rejectPasskey(w, r, err)
+		return
+	}
+	fields, err := decodeFields(req.Credential.Response.ClientDataJSON,
+		req.Credential.Response.AuthenticatorData, req.Credential.Response.Signature,
+		req.Credential.Response.UserHandle)
+	if err != nil {
+		s.rejectPasskey(w, r, err)
+		return
+	}
+	passkey, err := s.store.PasskeyByCredentialID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		s.rejectPasskey(w, r, webauthnErrorf("unknown credential"))
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	// A discoverable credential names its account in the user handle; it must
+	// be the account the credential is stored under.
+	if !bytes.Equal(fields[3], passkey.UserID[:]) {
+		s.rejectPasskey(w, r, webauthnErrorf("user handle does not match the credential"))
+		return
+	}
+	signCount, err := s.rp.verifyAssertion(challenge, fields[0], fields[1], fields[2], passkey.PublicKey)
+	if err != nil {
+		s.rejectPasskey(w, r, err)
+		return
+	}
+	if err := s.store.UsePasskey(ctx, passkey.ID, int64(signCount)); err != nil {
+		if errors.Is(err, ErrSignCountRegressed) {
+			s.log.WarnContext(ctx, "passkey signature counter did not increase; possible cloned authenticator",
+				"passkeyId", passkey.ID, "userId", passkey.UserID)
+			s.writeError(w, r, apiErrPasskeyRejected)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	user, err := s.store.User(ctx, passkey.UserID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if user.TwoFactorEnabledAt != nil && !passkey.TwoFactor {
+		expiresAt, err := s.beginSecondFactor(ctx, w, user.ID)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, http.StatusAccepted, twoFactorPending{
+			Status:    "two_factor_required",
+			ExpiresAt: expiresAt,
+		})
+		return
+	}
+	twoFactor := user.TwoFactorEnabledAt != nil
+	if err := s.issueSession(w, r, user.ID, twoFactor); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	user.TwoFactorSession = twoFactor
+	s.writeJSON(w, http.StatusOK, toUserView(user))
+}
+
+// --- management ---
+
+// handlePasskeys lists the account's passkeys, oldest first.
+func (s *Service) handlePasskeys(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	passkeys, err := s.store.Passkeys(r.Context(), user.ID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	out := struct {
+		Passkeys []passkeyView `json:"passkeys"`
+	}{Passkeys: make([]passkeyView, len(passkeys))}
+	for i, p := range passkeys {
+		out.Passkeys[i] = toPasskeyView(p)
+	}
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+// handlePasskeyDelete removes one of the account's passkeys. It is allowed to
+// remove the last one: every account keeps the way it was created (a password
+// or an OAuth identity), so no passkey is ever the only way in.
+func (s *Service) handlePasskeyDelete(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, r, apiErrPasskeyNotFound)
+		return
+	}
+	if err := s.store.DeletePasskey(r.Context(), user.ID, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.writeError(w, r, apiErrPasskeyNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, statusMessage("passkey removed"))
+}
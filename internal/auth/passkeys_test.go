@@ -0,0 +1,288 @@
+package auth_test
+
+// WebAuthn passkeys (internal/auth/passkeys.go, migration 00046) end to end:
+// registration and sign-in through the HTTP ceremonies, the single-use
+// challenge, the checks an assertion has to pass, the management endpoints,
+// and how a passkey sits with an account's TOTP second factor.
+//
+// The authenticator is auth.SoftAuthenticator (webauthn_test.go): an ES256 key
+// and the browser's client data, encoded to the spec rather than by the
+// package under test.
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/auth"
+)
+
+var b64url = base64.RawURLEncoding
+
+func newAuthenticator(t *testing.T) *auth.SoftAuthenticator {
+	return auth.NewSoftAuthenticator(t, "localhost", frontendOrigin)
+}
+
+type passkeyView struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+type creationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID string `json:"id"`
+	} `json:"rp"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ExcludeCredentials []struct {
+		ID string `json:"id"`
+	} `json:"excludeCredentials"`
+}
+
+// beginPasskey starts a registration for the signed-in account.
+func (h *harness) beginPasskey() creationOptions {
+	h.t.Helper()
+	resp := h.post(authBase+"/passkeys/register/begin", nil)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	return decodeInto[creationOptions](h.t, resp)
+}
+
+// finishPasskey has authenticator a answer opts and posts its credential.
+func (h *harness) finishPasskey(a *auth.SoftAuthenticator, opts creationOptions, name string) *http.Response {
+	h.t.Helper()
+	challenge, err := b64url.DecodeString(opts.Challenge)
+	require.NoError(h.t, err)
+	clientData, attObj := a.Register(challenge)
+	id := b64url.EncodeToString(a.CredentialID)
+	return h.post(authBase+"/passkeys/register/finish", map[string]any{
+		"name": name,
+		"credential": map[string]any{
+			"id": id, "rawId": id, "type": "public-key",
+			"authenticatorAttachment": "platform",
+			"clientExtensionResults":  map[string]any{},
+			"response": map[string]any{
+				"clientDataJSON":    b64url.EncodeToString(clientData),
+				"attestationObject": b64url.EncodeToString(attObj),
+				"transports":        []string{"internal", "hybrid", "carrier-pigeon"},
+			},
+		},
+	})
+}
+
+// addPasskey registers a with the signed-in account, returning the user
+// handle it will answer sign-ins with.
+func (h *harness) addPasskey(a *auth.SoftAuthenticator, name string) string {
+	h.t.Helper()
+	opts := h.beginPasskey()
+	requireStatus(h.t, h.finishPasskey(a, opts, name), http.StatusCreated)
+	return opts.User.ID
+}
+
+// passkeyLogin runs a sign-in ceremony with a, answering as userHandle.
+func (h *harness) passkeyLogin(a *auth.SoftAuthenticator, userHandle string) *http.Response {
+	h.t.Helper()
+	resp := h.post(authBase+"/passkeys/login/begin", nil)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	opts := decodeInto[struct {
+		Challenge string `json:"challenge"`
+		RPID      string `json:"rpId"`
+	}](h.t, resp)
+	require.Equal(h.t, "localhost", opts.RPID)
+	challenge, err := b64url.DecodeString(opts.Challenge)
+	require.NoError(h.t, err)
+	return h.finishLogin(a, challenge, userHandle)
+}
+
+func (h *harness) finishLogin(a *auth.SoftAuthenticator, challenge []byte, userHandle string) *http.Response {
+	h.t.Helper()
+	clientData, authData, sig := a.Assert(challenge)
+	id := b64url.EncodeToString(a.CredentialID)
+	return h.post(authBase+"/passkeys/login/finish", map[string]any{
+		"credential": map[string]any{
+			"id": id, "rawId": id, "type": "public-key",
+			"authenticatorAttachment": "platform",
+			"clientExtensionResults":  map[string]any{},
+			"response": map[string]any{
+				"clientDataJSON":    b64url.EncodeToString(clientData),
+				"authenticatorData": b64url.EncodeToString(authData),
+				"signature":         b64url.EncodeToString(sig),
+				"userHandle":        userHandle,
+			},
+		},
+	})
+}
+
+func (h *harness) passkeys() []passkeyView {
+	h.t.Helper()
+	resp := h.get(authBase + "/passkeys")
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	return decodeInto[struct {
+		Passkeys []passkeyView `json:"passkeys"`
+	}](h.t, resp).Passkeys
+}
+
+func TestPasskeyRegistrationAndSignIn(t *testing.T) {
+	h := newHarness(t)
+	h.registerVerifyLogin("pk@example.com", "correct horse battery", "pk")
+	a := newAuthenticator(t)
+
+	opts := h.beginPasskey()
+	assert.Equal(t, "localhost", opts.RP.ID)
+	assert.Empty(t, opts.ExcludeCredentials)
+	resp := h.finishPasskey(a, opts, "  Laptop  ")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	created := decodeInto[passkeyView](t, resp)
+	assert.Equal(t, "Laptop", created.Name)
+	assert.Nil(t, created.LastUsedAt)
+
+	var transports []string
+	require.NoError(t, h.pool.QueryRow(context.Background(),
+		`SELECT transports FROM passkeys WHERE id = $1`, created.ID).Scan(&transports))
+	assert.Equal(t, []string{"internal", "hybrid"}, transports, "unknown transports are dropped")
+
+	// The ceremony is spent: the same answer again finds nothing to finish.
+	resp = h.finishPasskey(a, opts, "Laptop")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "passkey_ceremony_expired", decodeInto[errResponse](t, resp).Error)
+
+	// The next registration excludes it, and the same credential twice is
+	// refused if an authenticator ignores the exclusion.
+	opts = h.beginPasskey()
+	require.Len(t, opts.ExcludeCredentials, 1)
+	assert.Equal(t, b64url.EncodeToString(a.CredentialID), opts.ExcludeCredentials[0].ID)
+	resp = h.finishPasskey(a, opts, "")
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "passkey_exists", decodeInto[errResponse](t, resp).Error)
+
+	// Signing in: no email, no password.
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+	resp = h.passkeyLogin(a, opts.User.ID)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "pk", decodeInto[struct {
+		DisplayName string `json:"displayName"`
+	}](t, resp).DisplayName)
+	requireStatus(t, h.get(mePath), http.StatusOK)
+
+	list := h.passkeys()
+	require.Len(t, list, 1)
+	assert.Equal(t, created.ID, list[0].ID)
+	assert.NotNil(t, list[0].LastUsedAt)
+
+	// Removing it ends its use; removing it twice is a 404.
+	requireStatus(t, h.del(authBase+"/passkeys/"+created.ID), http.StatusOK)
+	resp = h.del(authBase + "/passkeys/" + created.ID)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "passkey_not_found", decodeInto[errResponse](t, resp).Error)
+	assert.Empty(t, h.passkeys())
+
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	resp = h.passkeyLogin(a, opts.User.ID)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "passkey_rejected", decodeInto[errResponse](t, resp).Error)
+}
+
+func TestPasskeySignInRejections(t *testing.T) {
+	h := newHarness(t)
+	h.registerVerifyLogin("pkr@example.com", "correct horse battery", "pkr")
+	a := newAuthenticator(t)
+	handle := h.addPasskey(a, "Phone")
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+
+	rejected := func(resp *http.Response, why string) {
+		t.Helper()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, why)
+		assert.Equal(t, "passkey_rejected", decodeInto[errResponse](t, resp).Error, why)
+		requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+	}
+
+	rejected(h.passkeyLogin(newAuthenticator(t), handle), "a credential never registered")
+
+	phished := *a
+	phished.Origin = "https://typem0re.example"
+	rejected(h.passkeyLogin(&phished, handle), "another origin")
+
+	otherHandle := b64url.EncodeToString(make([]byte, 16))
+	rejected(h.passkeyLogin(a, otherHandle), "a user handle that is not the credential's")
+
+	// A counter that does not move forward is a cloned authenticator.
+	requireStatus(t, h.passkeyLogin(a, handle), http.StatusOK)
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	a.SignCount-- // the next assertion repeats the last counter
+	rejected(h.passkeyLogin(a, handle), "a counter that did not increase")
+
+	// A synced passkey that reports 0 forever is fine, again and again.
+	_, err := h.pool.Exec(context.Background(), `UPDATE passkeys SET sign_count = 0`)
+	require.NoError(t, err)
+	a.SignCount = 0
+	requireStatus(t, h.passkeyLogin(a, handle), http.StatusOK)
+	requireStatus(t, h.passkeyLogin(a, handle), http.StatusOK)
+
+	// No ceremony, no sign-in: the challenge is the server's, not the
+	// client's, so an assertion over one it never issued goes nowhere.
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	resp := h.finishLogin(a, []byte("a challenge the client made up"), handle)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "passkey_ceremony_expired", decodeInto[errResponse](t, resp).Error)
+
+	// Registering is for a signed-in account.
+	requireStatus(t, h.post(authBase+"/passkeys/register/begin", nil), http.StatusUnauthorized)
+}
+
+func TestPasskeysAndTheSecondFactor(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+	const email, password = "pk2fa@example.com", "correct horse battery"
+	h.registerVerifyLogin(email, password, "pk2fa")
+	_, err := h.store.PromoteAdmins(ctx, []string{email})
+	require.NoError(t, err)
+
+	// Registered before TOTP was turned on: the passkey vouches for one
+	// factor, and signing in with it asks for the code.
+	before := newAuthenticator(t)
+	handle := h.addPasskey(before, "Old key")
+	secret, _ := h.enableTwoFactor()
+
+	// This session predates the second factor, so it may not mint a passkey
+	// that would stand in for it.
+	resp := h.post(authBase+"/passkeys/register/begin", nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "two_factor_session_required", decodeInto[errResponse](t, resp).Error)
+
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	resp = h.passkeyLogin(before, handle)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "two_factor_required", decodeInto[struct {
+		Status string `json:"status"`
+	}](t, resp).Status)
+	requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+	requireStatus(t, h.post(authBase+"/login/2fa", map[string]string{"code": h.freshCode(secret)}), http.StatusOK)
+
+	// From a second-factor session, a new passkey is two factors on its own:
+	// it signs in without the code, into a session the gated permissions
+	// accept.
+	after := newAuthenticator(t)
+	h.addPasskey(after, "New key")
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.passkeyLogin(after, handle), http.StatusOK)
+	var perms []string
+	require.NoError(t, json.Unmarshal(h.me()["permissions"], &perms))
+	assert.Contains(t, perms, string(auth.PermBansWrite))
+
+	// Turning the second factor off and on again does not bring the old
+	// vouching back: the passkey was vouched for by the previous secret.
+	requireStatus(t, h.post(authBase+"/2fa/disable", map[string]string{"code": h.freshCode(secret)}), http.StatusOK)
+	h.enableTwoFactor()
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.passkeyLogin(after, handle), http.StatusAccepted)
+}
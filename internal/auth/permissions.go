@@ -55,8 +55,29 @@ var rolePermissions = map[string][]Permission{
 	},
 }
 
-// Can reports whether the user's role grants p.
+// twoFactorPermissions are the grants a role confers only on a session that
+// proved a second factor (docs/AUTH.md, "Two-factor authentication"). They are
+// the two acts that rewrite boards — putting a run back on one and taking a
+// player off all of them — so a stolen admin password is exactly what they
+// must not be reachable with. The rest of the admin surface reads, or acts on
+// quotes and reports, and stays available on one factor.
+//
+// Withheld, not refused: the role still grants them, and Can and Permissions
+// answer as if it did not until both halves are true — the ACCOUNT has a
+// confirmed second factor, and the SESSION was opened by proving it. The second
+// half is what stops a one-factor session from enrolling an authenticator of
+// its own and being granted them on the spot.
+var twoFactorPermissions = map[Permission]bool{
+	PermBansWrite:    true,
+	PermRunsOverride: true,
+}
+
+// Can reports whether the user's role grants p, with the two-factor
+// permissions withheld from a session that has not proven a second factor.
 func (u User) Can(p Permission) bool {
+	if twoFactorPermissions[p] && !u.secondFactorProven() {
+		return false
+	}
 	for _, granted := range rolePermissions[u.Role] {
 		if granted == p {
 			return true
@@ -65,17 +86,21 @@ func (u User) Can(p Permission) bool {
 	return false
 }
 
+// secondFactorProven is the condition twoFactorPermissions wait for.
+func (u User) secondFactorProven() bool {
+	return u.TwoFactorEnabledAt != nil && u.TwoFactorSession
+}
+
 // Permissions returns the user's expanded permission set as wire strings —
 // what /me serves so the client knows which surfaces to render. Empty (nil)
-// for a plain player, which the view omits entirely.
+// for a plain player, which the view omits entirely. A withheld two-factor
+// permission is left out, like any other the caller cannot use.
 func (u User) Permissions() []string {
-	granted := rolePermissions[u.Role]
-	if len(granted) == 0 {
-		return nil
-	}
-	out := make([]string, len(granted))
-	for i, p := range granted {
-		out[i] = string(p)
+	var out []string
+	for _, p := range rolePermissions[u.Role] {
+		if u.Can(p) {
+			out = append(out, string(p))
+		}
 	}
 	return out
 }
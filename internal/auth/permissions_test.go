@@ -100,13 +100,15 @@ func TestMeCarriesPermissionsOnlyForAdmins(t *testing.T) {
 	_, err := h.store.PromoteAdmins(ctx, []string{"mod@example.com"})
 	require.NoError(t, err)
 
+	// Without a second-factor session the list stops short of the two
+	// permissions that need one (twofactor_test.go covers earning them).
 	var perms []string
 	require.NoError(t, json.Unmarshal(me()["permissions"], &perms))
 	assert.ElementsMatch(t, []string{
-		string(auth.PermBansRead), string(auth.PermBansWrite),
+		string(auth.PermBansRead),
 		string(auth.PermReportsRead), string(auth.PermReportsWrite),
 		string(auth.PermQuotesWrite),
-		string(auth.PermRunsReviewRead), string(auth.PermRunsOverride),
+		string(auth.PermRunsReviewRead),
 	}, perms,
 		"an admin's /me lists the expanded permission set, which is what the client renders surfaces from")
 }
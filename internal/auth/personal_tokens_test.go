@@ -0,0 +1,157 @@
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	tokensPath     = mePath + "/tokens"
+	tokenProbePath = "/api/v1/token-probe"
+)
+
+type createdToken struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	Token      string   `json:"token"`
+	LastUsedAt *string  `json:"lastUsedAt"`
+}
+
+func (h *harness) createToken(name string, scopes ...string) createdToken {
+	h.t.Helper()
+	resp := h.post(tokensPath, map[string]any{"name": name, "scopes": scopes, "expiresInDays": 30})
+	require.Equal(h.t, http.StatusCreated, resp.StatusCode)
+	return decodeInto[createdToken](h.t, resp)
+}
+
+// bearer sends method to path the way a bot would: the token in the
+// Authorization header, no cookie jar and no Origin.
+func (h *harness) bearer(method, path, token string) *http.Response {
+	h.t.Helper()
+	req, err := http.NewRequest(method, h.server.URL+path, http.NoBody)
+	require.NoError(h.t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(h.t, err)
+	return resp
+}
+
+func (h *harness) tokens() []createdToken {
+	h.t.Helper()
+	resp := h.get(tokensPath)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	return decodeInto[struct {
+		Tokens []createdToken `json:"tokens"`
+	}](h.t, resp).Tokens
+}
+
+func TestAccessTokenLifecycle(t *testing.T) {
+	h := newHarness(t)
+	h.registerVerifyLogin("bot-owner@example.com", "password-for-bots", "BotOwner")
+	me := decodeInto[meResponse](t, h.get(mePath))
+
+	tok := h.createToken("PB bot", "profile:read", "runs:read")
+	assert.Regexp(t, `^tmpat_[A-Za-z0-9_-]{43}$`, tok.Token)
+	assert.Equal(t, []string{"runs:read", "profile:read"}, tok.Scopes)
+
+	// The token is the whole credential: no cookie, no Origin, any method.
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		resp := h.bearer(method, tokenProbePath, tok.Token)
+		require.Equal(t, http.StatusOK, resp.StatusCode, method)
+		assert.Equal(t, me.ID, readBody(t, resp))
+	}
+
+	// The list never shows the plaintext again, and records the use.
+	list := h.tokens()
+	require.Len(t, list, 1)
+	assert.Empty(t, list[0].Token)
+	assert.NotNil(t, list[0].LastUsedAt)
+
+	// Tokens reach only the routes that opt in: /me reads the cookie alone,
+	// and so does the management API.
+	requireStatus(t, h.bearer(http.MethodGet, mePath, tok.Token), http.StatusUnauthorized)
+	requireStatus(t, h.bearer(http.MethodGet, tokensPath, tok.Token), http.StatusUnauthorized)
+
+	// Revoked, it stops working at once.
+	requireStatus(t, h.del(tokensPath+"/"+tok.ID), http.StatusOK)
+	resp := h.bearer(http.MethodGet, tokenProbePath, tok.Token)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Bearer realm="typemore"`, resp.Header.Get("WWW-Authenticate"))
+	_ = resp.Body.Close()
+	requireStatus(t, h.del(tokensPath+"/"+tok.ID), http.StatusNotFound)
+}
+
+func TestAccessTokenRejections(t *testing.T) {
+	h := newHarness(t, func(o *serverOpts) { o.tokenBurst = 2 })
+	h.registerVerifyLogin("strict@example.com", "password-for-bots", "Strict")
+
+	// A live token without the route's scope.
+	profileOnly := h.createToken("exporter", "profile:read")
+	resp := h.bearer(http.MethodGet, tokenProbePath, profileOnly.Token)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "insufficient_scope", decodeInto[errResponse](t, resp).Error)
+
+	// Garbage, a session-shaped value without the prefix, and an expired token
+	// are all the same 401.
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, "tmpat_not-a-token"), http.StatusUnauthorized)
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, profileOnly.Token[len("tmpat_"):]), http.StatusUnauthorized)
+	expired := h.createToken("old", "runs:read")
+	mustExec(t, h.pool, `UPDATE personal_access_tokens SET expires_at = now() - interval '1 second' WHERE name = 'old'`)
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, expired.Token), http.StatusUnauthorized)
+
+	// Each token has its own bucket: two requests, then 429 — and another
+	// token of the same account is unaffected.
+	bot := h.createToken("bot", "runs:read")
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, bot.Token), http.StatusOK)
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, bot.Token), http.StatusOK)
+	resp = h.bearer(http.MethodGet, tokenProbePath, bot.Token)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "rate_limited", decodeInto[errResponse](t, resp).Error)
+	other := h.createToken("other bot", "runs:read")
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, other.Token), http.StatusOK)
+
+	// Creation is validated.
+	for _, body := range []map[string]any{
+		{"name": "x", "scopes": []string{}, "expiresInDays": 30},
+		{"name": "x", "scopes": []string{"admin"}, "expiresInDays": 30},
+		{"name": "  ", "scopes": []string{"runs:read"}, "expiresInDays": 30},
+		{"name": "x", "scopes": []string{"runs:read"}, "expiresInDays": 0},
+		{"name": "x", "scopes": []string{"runs:read"}, "expiresInDays": 366},
+	} {
+		requireStatus(t, h.post(tokensPath, body), http.StatusBadRequest)
+	}
+
+	// Without a Bearer header the route is the browser's, as before: the
+	// session and the Origin.
+	requireStatus(t, h.post(tokenProbePath, nil), http.StatusOK)
+
+	// With a second factor on, a session that has not proven it cannot mint
+	// a credential that skips it.
+	h.enableTwoFactor()
+	resp = h.post(tokensPath, map[string]any{"name": "x", "scopes": []string{"runs:read"}, "expiresInDays": 30})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "two_factor_session_required", decodeInto[errResponse](t, resp).Error)
+}
+
+func TestAccessTokensRevokedWithPassword(t *testing.T) {
+	h := newHarness(t)
+	const email, oldPw, newPw = "rotate@example.com", "old-password-1", "new-password-2"
+	h.registerVerifyLogin(email, oldPw, "Rotator")
+
+	changed := h.createToken("before change", "runs:read")
+	requireStatus(t, h.post(authBase+"/password/change", map[string]string{
+		"currentPassword": oldPw, "newPassword": newPw,
+	}), http.StatusOK)
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, changed.Token), http.StatusUnauthorized)
+
+	reset := h.createToken("before reset", "runs:read")
+	requireStatus(t, h.post(authBase+"/password-reset/request", map[string]string{"email": email}), http.StatusOK)
+	requireStatus(t, h.post(authBase+"/password-reset/confirm", map[string]string{
+		"token": h.mailer.lastToken(t), "newPassword": oldPw,
+	}), http.StatusOK)
+	requireStatus(t, h.bearer(http.MethodGet, tokenProbePath, reset.Token), http.StatusUnauthorized)
+}
@@ -53,6 +53,8 @@ func mapErr(err error) error {
 			return auth.ErrEmailOwnedByOtherUser
 		case "auth_identities_provider_provider_subject_key":
 			return auth.ErrIdentityExists
+		case "passkeys_credential_id_key":
+			return auth.ErrPasskeyExists
 		}
 	}
 	return err
@@ -65,6 +67,7 @@ func toUser(u authdb.User) auth.User {
 		ID: u.ID, DisplayName: u.DisplayName, CreatedAt: u.CreatedAt,
 		ProfilePublic: u.ProfilePublic, KeyboardPublic: u.KeyboardPublic,
 		Role: u.Role, DisplayNameChangedAt: u.DisplayNameChangedAt,
+		TwoFactorEnabledAt: u.TwoFactorEnabledAt,
 	}
 }
 
@@ -91,6 +94,10 @@ func toSession(s authdb.Session) auth.Session {
 		CreatedAt:  s.CreatedAt,
 		ExpiresAt:  s.ExpiresAt,
 		LastSeenAt: s.LastSeenAt,
+		TwoFactor:  s.TwoFactor,
+		Name:       s.Name,
+		UserAgent:  s.UserAgent,
+		IPPrefix:   s.IpPrefix,
 	}
 }
 
@@ -324,13 +331,341 @@ func (s *Store) UseEmailToken(ctx context.Context, tokenHash []byte, purpose str
 	}, nil
 }
 
-// --- SessionStore ---
+// --- Store: two-factor ---
 
-func (s *Store) CreateSession(ctx context.Context, tokenHash []byte, userID uuid.UUID, expiresAt time.Time) (auth.Session, error) {
-	sess, err := s.q.CreateSession(ctx, authdb.CreateSessionParams{
+// BeginTOTPEnrolment stores an unconfirmed secret. The statement's conflict arm
+// refuses to touch a confirmed one, and zero rows is that refusal.
+func (s *Store) BeginTOTPEnrolment(ctx context.Context, userID uuid.UUID, secret []byte) error {
+	n, err := s.q.UpsertPendingTOTP(ctx, authdb.UpsertPendingTOTPParams{UserID: userID, Secret: secret})
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return auth.ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+func (s *Store) TOTP(ctx context.Context, userID uuid.UUID) (auth.TOTP, error) {
+	t, err := s.q.GetTOTP(ctx, userID)
+	if err != nil {
+		return auth.TOTP{}, mapErr(err)
+	}
+	return auth.TOTP{Secret: t.Secret, LastStep: t.LastStep}, nil
+}
+
+// EnableTwoFactor confirms an enrolment in one transaction: the confirming
+// step, the account flag, and the first set of recovery codes land together or
+// not at all, so an account is never marked without the codes that get it back.
+func (s *Store) EnableTwoFactor(ctx context.Context, userID uuid.UUID, step int64, recoveryHashes [][]byte) error {
+	return s.tx(ctx, func(q *authdb.Queries) error {
+		n, err := q.EnableTwoFactor(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("enable two-factor: %w", mapErr(err))
+		}
+		if n == 0 {
+			return auth.ErrTwoFactorEnabled
+		}
+		n, err = q.AcceptTOTPStep(ctx, authdb.AcceptTOTPStepParams{UserID: userID, LastStep: step})
+		if err != nil {
+			return fmt.Errorf("accept totp step: %w", mapErr(err))
+		}
+		if n == 0 {
+			return auth.ErrCodeReplayed
+		}
+		return replaceRecoveryCodes(ctx, q, userID, recoveryHashes)
+	})
+}
+
+func (s *Store) AcceptTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	n, err := s.q.AcceptTOTPStep(ctx, authdb.AcceptTOTPStepParams{UserID: userID, LastStep: step})
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return auth.ErrCodeReplayed
+	}
+	return nil
+}
+
+// DisableTwoFactor removes the second factor in one transaction, and with it
+// the second-factor standing of every session and passkey the account has.
+func (s *Store) DisableTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	return s.tx(ctx, func(q *authdb.Queries) error {
+		if err := q.DisableTwoFactor(ctx, userID); err != nil {
+			return fmt.Errorf("disable two-factor: %w", mapErr(err))
+		}
+		if err := q.ClearSessionsTwoFactor(ctx, userID); err != nil {
+			return fmt.Errorf("clear sessions' second factor: %w", mapErr(err))
+		}
+		if err := q.ClearPasskeysTwoFactor(ctx, userID); err != nil {
+			return fmt.Errorf("clear passkeys' second factor: %w", mapErr(err))
+		}
+		if err := q.DeleteTOTP(ctx, userID); err != nil {
+			return fmt.Errorf("delete totp: %w", mapErr(err))
+		}
+		if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return fmt.Errorf("delete recovery codes: %w", mapErr(err))
+		}
+		return nil
+	})
+}
+
+func (s *Store) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes [][]byte) error {
+	return s.tx(ctx, func(q *authdb.Queries) error {
+		return replaceRecoveryCodes(ctx, q, userID, hashes)
+	})
+}
+
+// replaceRecoveryCodes is the shared body of issuing a set: every earlier code,
+// spent or not, goes, so the set the user was just shown is the only one.
+func replaceRecoveryCodes(ctx context.Context, q *authdb.Queries, userID uuid.UUID, hashes [][]byte) error {
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", mapErr(err))
+	}
+	for _, h := range hashes {
+		if err := q.CreateRecoveryCode(ctx, authdb.CreateRecoveryCodeParams{UserID: userID, CodeHash: h}); err != nil {
+			return fmt.Errorf("create recovery code: %w", mapErr(err))
+		}
+	}
+	return nil
+}
+
+func (s *Store) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash []byte) error {
+	n, err := s.q.UseRecoveryCode(ctx, authdb.UseRecoveryCodeParams{UserID: userID, CodeHash: hash})
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) RecoveryCodesLeft(ctx context.Context, userID uuid.UUID) (int64, error) {
+	n, err := s.q.CountRecoveryCodes(ctx, userID)
+	return n, mapErr(err)
+}
+
+func (s *Store) CreateLoginChallenge(ctx context.Context, tokenHash []byte, userID uuid.UUID, expiresAt time.Time) error {
+	return mapErr(s.q.CreateLoginChallenge(ctx, authdb.CreateLoginChallengeParams{
 		TokenHash: tokenHash,
 		UserID:    userID,
 		ExpiresAt: expiresAt,
+	}))
+}
+
+func (s *Store) AttemptLoginChallenge(ctx context.Context, tokenHash []byte, maxAttempts int32) (uuid.UUID, error) {
+	userID, err := s.q.AttemptLoginChallenge(ctx, authdb.AttemptLoginChallengeParams{
+		TokenHash: tokenHash,
+		Attempts:  maxAttempts,
+	})
+	if err != nil {
+		return uuid.Nil, mapErr(err)
+	}
+	return userID, nil
+}
+
+func (s *Store) DeleteLoginChallenge(ctx context.Context, tokenHash []byte) error {
+	return mapErr(s.q.DeleteLoginChallenge(ctx, tokenHash))
+}
+
+// DeleteExpiredLoginChallenges removes second-factor logins nobody finished
+// (janitor sweep).
+func (s *Store) DeleteExpiredLoginChallenges(ctx context.Context) (int64, error) {
+	n, err := s.q.DeleteExpiredLoginChallenges(ctx)
+	return n, mapErr(err)
+}
+
+// --- Store: passkeys ---
+
+func toPasskey(p authdb.Passkey) auth.Passkey {
+	return auth.Passkey{
+		ID: p.ID, UserID: p.UserID, CredentialID: p.CredentialID, PublicKey: p.PublicKey,
+		SignCount: p.SignCount, Transports: p.Transports, Name: p.Name, TwoFactor: p.TwoFactor,
+		CreatedAt: p.CreatedAt, LastUsedAt: p.LastUsedAt,
+	}
+}
+
+func (s *Store) CreatePasskey(ctx context.Context, p auth.PasskeyParams) (auth.Passkey, error) {
+	transports := p.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	row, err := s.q.CreatePasskey(ctx, authdb.CreatePasskeyParams{
+		UserID:       p.UserID,
+		CredentialID: p.CredentialID,
+		PublicKey:    p.PublicKey,
+		SignCount:    p.SignCount,
+		Transports:   transports,
+		Name:         p.Name,
+		TwoFactor:    p.TwoFactor,
+	})
+	if err != nil {
+		return auth.Passkey{}, mapErr(err)
+	}
+	return toPasskey(row), nil
+}
+
+func (s *Store) PasskeyByCredentialID(ctx context.Context, credentialID []byte) (auth.Passkey, error) {
+	row, err := s.q.GetPasskeyByCredentialID(ctx, credentialID)
+	if err != nil {
+		return auth.Passkey{}, mapErr(err)
+	}
+	return toPasskey(row), nil
+}
+
+func (s *Store) Passkeys(ctx context.Context, userID uuid.UUID) ([]auth.Passkey, error) {
+	rows, err := s.q.ListPasskeys(ctx, userID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	out := make([]auth.Passkey, len(rows))
+	for i, row := range rows {
+		out[i] = toPasskey(row)
+	}
+	return out, nil
+}
+
+func (s *Store) UsePasskey(ctx context.Context, id uuid.UUID, signCount int64) error {
+	n, err := s.q.UsePasskey(ctx, authdb.UsePasskeyParams{ID: id, SignCount: signCount})
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return auth.ErrSignCountRegressed
+	}
+	return nil
+}
+
+func (s *Store) DeletePasskey(ctx context.Context, userID, id uuid.UUID) error {
+	n, err := s.q.DeletePasskey(ctx, authdb.DeletePasskeyParams{ID: id, UserID: userID})
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) CreateWebAuthnCeremony(ctx context.Context, tokenHash []byte, kind string, userID *uuid.UUID, challenge []byte, expiresAt time.Time) error {
+	return mapErr(s.q.CreateWebAuthnCeremony(ctx, authdb.CreateWebAuthnCeremonyParams{
+		TokenHash: tokenHash,
+		Kind:      kind,
+		UserID:    userID,
+		Challenge: challenge,
+		ExpiresAt: expiresAt,
+	}))
+}
+
+func (s *Store) ConsumeWebAuthnCeremony(ctx context.Context, tokenHash []byte, kind string) (*uuid.UUID, []byte, error) {
+	row, err := s.q.ConsumeWebAuthnCeremony(ctx, authdb.ConsumeWebAuthnCeremonyParams{TokenHash: tokenHash, Kind: kind})
+	if err != nil {
+		return nil, nil, mapErr(err)
+	}
+	return row.UserID, row.Challenge, nil
+}
+
+// DeleteExpiredWebAuthnCeremonies removes passkey prompts nobody answered
+// (janitor sweep).
+func (s *Store) DeleteExpiredWebAuthnCeremonies(ctx context.Context) (int64, error) {
+	n, err := s.q.DeleteExpiredWebAuthnCeremonies(ctx)
+	return n, mapErr(err)
+}
+
+// --- Store: personal access tokens ---
+
+func toAccessToken(t authdb.PersonalAccessToken) auth.AccessToken {
+	scopes := make([]auth.Scope, len(t.Scopes))
+	for i, sc := range t.Scopes {
+		scopes[i] = auth.Scope(sc)
+	}
+	return auth.AccessToken{
+		ID: t.ID, UserID: t.UserID, TokenHash: t.TokenHash, Name: t.Name, Scopes: scopes,
+		ExpiresAt: t.ExpiresAt, CreatedAt: t.CreatedAt, LastUsedAt: t.LastUsedAt,
+	}
+}
+
+func (s *Store) CreateAccessToken(ctx context.Context, p auth.AccessTokenParams) (auth.AccessToken, error) {
+	scopes := make([]string, len(p.Scopes))
+	for i, sc := range p.Scopes {
+		scopes[i] = string(sc)
+	}
+	row, err := s.q.CreateAccessToken(ctx, authdb.CreateAccessTokenParams{
+		UserID:    p.UserID,
+		TokenHash: p.TokenHash,
+		Name:      p.Name,
+		Scopes:    scopes,
+		ExpiresAt: p.ExpiresAt,
+	})
+	if err != nil {
+		return auth.AccessToken{}, mapErr(err)
+	}
+	return toAccessToken(row), nil
+}
+
+func (s *Store) AccessTokenByHash(ctx context.Context, tokenHash []byte) (auth.AccessToken, error) {
+	row, err := s.q.GetAccessTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return auth.AccessToken{}, mapErr(err)
+	}
+	return toAccessToken(row), nil
+}
+
+func (s *Store) AccessTokens(ctx context.Context, userID uuid.UUID) ([]auth.AccessToken, error) {
+	rows, err := s.q.ListAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	out := make([]auth.AccessToken, len(rows))
+	for i, row := range rows {
+		out[i] = toAccessToken(row)
+	}
+	return out, nil
+}
+
+func (s *Store) CountAccessTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	n, err := s.q.CountAccessTokens(ctx, userID)
+	return n, mapErr(err)
+}
+
+func (s *Store) TouchAccessToken(ctx context.Context, id uuid.UUID) error {
+	return mapErr(s.q.TouchAccessToken(ctx, id))
+}
+
+func (s *Store) DeleteAccessToken(ctx context.Context, userID, id uuid.UUID) error {
+	n, err := s.q.DeleteAccessToken(ctx, authdb.DeleteAccessTokenParams{ID: id, UserID: userID})
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DeleteUserAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	return mapErr(s.q.DeleteUserAccessTokens(ctx, userID))
+}
+
+// DeleteExpiredAccessTokens removes personal access tokens past their expiry
+// (janitor sweep).
+func (s *Store) DeleteExpiredAccessTokens(ctx context.Context) (int64, error) {
+	n, err := s.q.DeleteExpiredAccessTokens(ctx)
+	return n, mapErr(err)
+}
+
+// --- SessionStore ---
+
+func (s *Store) CreateSession(ctx context.Context, p auth.SessionParams) (auth.Session, error) {
+	sess, err := s.q.CreateSession(ctx, authdb.CreateSessionParams{
+		TokenHash: p.TokenHash,
+		UserID:    p.UserID,
+		ExpiresAt: p.ExpiresAt,
+		TwoFactor: p.TwoFactor,
+		UserAgent: p.UserAgent,
+		IpPrefix:  p.IPPrefix,
 	})
 	if err != nil {
 		return auth.Session{}, mapErr(err)
@@ -346,8 +681,8 @@ func (s *Store) SessionByTokenHash(ctx context.Context, tokenHash []byte) (auth.
 	return toSession(sess), nil
 }
 
-func (s *Store) TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
-	return mapErr(s.q.TouchSession(ctx, authdb.TouchSessionParams{ID: id, ExpiresAt: expiresAt}))
+func (s *Store) TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time, ipPrefix string) error {
+	return mapErr(s.q.TouchSession(ctx, authdb.TouchSessionParams{ID: id, ExpiresAt: expiresAt, IpPrefix: ipPrefix}))
 }
 
 func (s *Store) DeleteSession(ctx context.Context, tokenHash []byte) error {
@@ -358,6 +693,42 @@ func (s *Store) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
 	return mapErr(s.q.DeleteUserSessions(ctx, userID))
 }
 
+func (s *Store) UserSessions(ctx context.Context, userID uuid.UUID) ([]auth.Session, error) {
+	rows, err := s.q.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	out := make([]auth.Session, len(rows))
+	for i, r := range rows {
+		out[i] = toSession(r)
+	}
+	return out, nil
+}
+
+func (s *Store) RenameSession(ctx context.Context, userID, id uuid.UUID, name *string) (auth.Session, error) {
+	sess, err := s.q.RenameSession(ctx, authdb.RenameSessionParams{ID: id, UserID: userID, Name: name})
+	if err != nil {
+		return auth.Session{}, mapErr(err)
+	}
+	return toSession(sess), nil
+}
+
+func (s *Store) DeleteUserSession(ctx context.Context, userID, id uuid.UUID) error {
+	n, err := s.q.DeleteUserSession(ctx, authdb.DeleteUserSessionParams{ID: id, UserID: userID})
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return auth.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DeleteOtherUserSessions(ctx context.Context, userID, keep uuid.UUID) (int64, error) {
+	n, err := s.q.DeleteOtherUserSessions(ctx, authdb.DeleteOtherUserSessionsParams{UserID: userID, ID: keep})
+	return n, mapErr(err)
+}
+
 // tx runs fn inside a database transaction, committing on success and rolling
 // back on any error (or panic). It is the single place transaction lifecycle is
 // handled, so the composite methods above stay readable.
@@ -94,15 +94,17 @@ DO UPDATE SET argon2id_hash = EXCLUDED.argon2id_hash, updated_at = now();
 SELECT * FROM user_credentials WHERE user_id = $1;
 
 -- name: CreateSession :one
-INSERT INTO sessions (token_hash, user_id, expires_at)
-VALUES ($1, $2, $3)
+INSERT INTO sessions (token_hash, user_id, expires_at, two_factor, user_agent, ip_prefix)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetSessionByTokenHash :one
 SELECT * FROM sessions WHERE token_hash = $1;
 
 -- name: TouchSession :exec
-UPDATE sessions SET last_seen_at = now(), expires_at = $2 WHERE id = $1;
+-- The throttled write authenticate makes: slides the expiry, and records when
+-- and from which network the session was last used.
+UPDATE sessions SET last_seen_at = now(), expires_at = $2, ip_prefix = $3 WHERE id = $1;
 
 -- name: DeleteSessionByTokenHash :exec
 DELETE FROM sessions WHERE token_hash = $1;
@@ -110,6 +112,26 @@ DELETE FROM sessions WHERE token_hash = $1;
 -- name: DeleteUserSessions :exec
 DELETE FROM sessions WHERE user_id = $1;
 
+-- name: ListUserSessions :many
+-- The account's live sessions, most recently used first. An expired row the
+-- janitor has not reached yet is not a session anyone can use, so it is not
+-- listed.
+SELECT * FROM sessions
+WHERE user_id = $1 AND expires_at > now()
+ORDER BY last_seen_at DESC, created_at DESC;
+
+-- name: RenameSession :one
+UPDATE sessions SET name = $3
+WHERE id = $1 AND user_id = $2 AND expires_at > now()
+RETURNING *;
+
+-- name: DeleteUserSession :execrows
+-- Scoped to the owner: a session id from someone else's list matches nothing.
+DELETE FROM sessions WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteOtherUserSessions :execrows
+DELETE FROM sessions WHERE user_id = $1 AND id <> $2;
+
 -- name: DeleteExpiredSessions :execrows
 DELETE FROM sessions WHERE expires_at < now();
 
@@ -139,3 +161,155 @@ WHERE token_hash = $1
   AND used_at IS NULL
   AND expires_at > now()
 RETURNING *;
+
+-- name: UpsertPendingTOTP :execrows
+-- An enrolment: a fresh secret, replacing any earlier unconfirmed one. The
+-- conflict arm is guarded by the account's enabled flag so a second enrolment
+-- can never overwrite the secret of a CONFIRMED second factor — zero rows is
+-- that refusal, and the adapter reports it as ErrTwoFactorEnabled.
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret = EXCLUDED.secret, last_step = 0, created_at = now()
+    WHERE (SELECT two_factor_enabled_at FROM users WHERE id = $1) IS NULL;
+
+-- name: GetTOTP :one
+SELECT * FROM user_totp WHERE user_id = $1;
+
+-- name: AcceptTOTPStep :execrows
+-- Records that a code was accepted for a step, and only if the step is later
+-- than the last one accepted: zero rows is a replayed code. Check and write
+-- are one statement, so two requests racing with the same code cannot both
+-- pass.
+UPDATE user_totp SET last_step = $2 WHERE user_id = $1 AND last_step < $2;
+
+-- name: EnableTwoFactor :execrows
+UPDATE users
+SET two_factor_enabled_at = now(), updated_at = now()
+WHERE id = $1 AND two_factor_enabled_at IS NULL;
+
+-- name: DisableTwoFactor :exec
+UPDATE users
+SET two_factor_enabled_at = NULL, updated_at = now()
+WHERE id = $1;
+
+-- name: ClearSessionsTwoFactor :exec
+-- Part of turning a second factor off: the account's sessions stop counting as
+-- second-factor sessions, so turning it back on later does not hand the gated
+-- permissions back to a session that never proved the new one.
+UPDATE sessions SET two_factor = false WHERE user_id = $1;
+
+-- name: DeleteTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+-- Spends one recovery code. Scoped to the account, so a code is worthless
+-- against anyone else's login even though the hashes are globally unique.
+UPDATE user_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountRecoveryCodes :one
+SELECT count(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CreateLoginChallenge :exec
+INSERT INTO login_challenges (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: AttemptLoginChallenge :one
+-- Counts one attempt against a live challenge and returns whose it is. The
+-- attempt is spent BEFORE the code is checked, in the same statement that
+-- finds the row, so concurrent guesses cannot share an attempt; an expired or
+-- exhausted challenge returns no row.
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE token_hash = $1 AND expires_at > now() AND attempts < $2
+RETURNING user_id;
+
+-- name: DeleteLoginChallenge :exec
+DELETE FROM login_challenges WHERE token_hash = $1;
+
+-- name: DeleteExpiredLoginChallenges :execrows
+DELETE FROM login_challenges WHERE expires_at < now();
+
+-- name: CreatePasskey :one
+INSERT INTO passkeys (user_id, credential_id, public_key, sign_count, transports, name, two_factor)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetPasskeyByCredentialID :one
+SELECT * FROM passkeys WHERE credential_id = $1;
+
+-- name: ListPasskeys :many
+SELECT * FROM passkeys
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: UsePasskey :execrows
+-- Records a verified assertion. The counter must move strictly forward unless
+-- the authenticator does not keep one (0 then 0); check and write are one
+-- statement, so two racing assertions cannot both claim the same count. Zero
+-- rows is a counter that went backwards or stood still — a cloned
+-- authenticator, by the spec's reading.
+UPDATE passkeys
+SET sign_count = $2, last_used_at = now()
+WHERE id = $1 AND (sign_count < $2 OR (sign_count = 0 AND $2 = 0));
+
+-- name: DeletePasskey :execrows
+-- Scoped to the owner, so a guessed id removes nothing of anyone else's.
+DELETE FROM passkeys WHERE id = $1 AND user_id = $2;
+
+-- name: ClearPasskeysTwoFactor :exec
+-- The passkey counterpart of ClearSessionsTwoFactor: once the second factor is
+-- off, no passkey still vouches for it.
+UPDATE passkeys SET two_factor = false WHERE user_id = $1;
+
+-- name: CreateWebAuthnCeremony :exec
+INSERT INTO webauthn_ceremonies (token_hash, kind, user_id, challenge, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ConsumeWebAuthnCeremony :one
+-- Takes a live ceremony of the given kind, once: the row is deleted in the
+-- statement that reads it, so a challenge can be answered only one time.
+DELETE FROM webauthn_ceremonies
+WHERE token_hash = $1 AND kind = $2 AND expires_at > now()
+RETURNING user_id, challenge;
+
+-- name: DeleteExpiredWebAuthnCeremonies :execrows
+DELETE FROM webauthn_ceremonies WHERE expires_at < now();
+
+-- name: CreateAccessToken :one
+INSERT INTO personal_access_tokens (user_id, token_hash, name, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetAccessTokenByHash :one
+SELECT * FROM personal_access_tokens WHERE token_hash = $1;
+
+-- name: ListAccessTokens :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: CountAccessTokens :one
+SELECT count(*) FROM personal_access_tokens WHERE user_id = $1;
+
+-- name: TouchAccessToken :exec
+UPDATE personal_access_tokens SET last_used_at = now() WHERE id = $1;
+
+-- name: DeleteAccessToken :execrows
+-- Scoped to the owner, so a guessed id revokes nothing of anyone else's.
+DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteUserAccessTokens :exec
+DELETE FROM personal_access_tokens WHERE user_id = $1;
+
+-- name: DeleteExpiredAccessTokens :execrows
+DELETE FROM personal_access_tokens WHERE expires_at < now();
@@ -173,7 +173,8 @@ type loginRequest struct {
 // handleLogin authenticates an email account and starts a session. Unknown email
 // and wrong password both return the same invalid_credentials (with a decoy hash
 // verify on the unknown-email path to equalize timing). A correct password on an
-// unverified account returns email_not_verified.
+// unverified account returns email_not_verified, and one on an account with a
+// second factor returns 202 with no session — see handleLoginSecondFactor.
 func (s *Service) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req loginRequest
 	if !s.decodeJSON(w, r, &req) {
@@ -230,12 +231,25 @@ func (s *Service) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.issueSession(ctx, w, identity.UserID); err != nil {
+	user, err := s.store.User(ctx, identity.UserID)
+	if err != nil {
 		s.writeError(w, r, err)
 		return
 	}
-	user, err := s.store.User(ctx, identity.UserID)
-	if err != nil {
+	// With a second factor the password is only the first step: the session
+	// is withheld until POST /login/2fa proves the code (twofactor.go).
+	if user.TwoFactorEnabledAt != nil {
+		expiresAt, err := s.beginSecondFactor(ctx, w, user.ID)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, http.StatusAccepted, twoFactorPending{
+			Status: "two_factor_required", ExpiresAt: expiresAt,
+		})
+		return
+	}
+	if err := s.issueSession(w, r, user.ID, false); err != nil {
 		s.writeError(w, r, err)
 		return
 	}
@@ -96,6 +96,12 @@ func (s *Service) handleResetConfirm(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, r, err)
 		return
 	}
+	// And every access token: a session that was stolen could have minted one,
+	// and a token that survived the reset would be the session surviving it.
+	if err := s.store.DeleteUserAccessTokens(ctx, tok.UserID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 	s.writeJSON(w, http.StatusOK, statusMessage("password updated; sign in with your new password"))
 }
 
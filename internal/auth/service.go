@@ -47,8 +47,10 @@ type Config struct {
 	SessionTTL time.Duration
 	// OAuthRedirectBase is this server's public base URL for provider callbacks.
 	OAuthRedirectBase string
-	// Providers holds OAuth client credentials keyed by provider name
-	// (ProviderGitHub, ProviderGoogle). A provider absent here is disabled.
+	// Providers holds OAuth client credentials keyed by provider name:
+	// ProviderGitHub, ProviderGoogle, ProviderDiscord, or any other name with
+	// an Issuer set, which makes it a generic OpenID Connect provider. A
+	// provider absent here is disabled.
 	Providers map[string]ProviderCredentials
 	// HashConcurrency bounds how many argon2id hashes may run at once. Each
 	// costs HashCostBytes (19 MiB) of live heap, so this is a memory ceiling
@@ -59,6 +61,16 @@ type Config struct {
 	// HashWait is how long a request may queue for a hashing slot before it is
 	// shed with 503. Zero uses DefaultHashWait.
 	HashWait time.Duration
+	// PasskeyRPID is the WebAuthn relying-party ID passkeys are scoped to. It
+	// must be FrontendOrigin's host or a registrable suffix of it (a passkey
+	// for "typemore.app" works on "www.typemore.app"); empty uses the host.
+	// Changing it orphans every passkey registered under the old one.
+	PasskeyRPID string
+	// AccessTokenRateEvery / AccessTokenRateBurst are the token bucket each
+	// personal access token draws from (accesstokens.go). Zero uses
+	// DefaultAccessTokenRateEvery / DefaultAccessTokenRateBurst.
+	AccessTokenRateEvery time.Duration
+	AccessTokenRateBurst int
 }
 
 // ProviderCredentials are one OAuth provider's client id/secret.
@@ -72,6 +84,13 @@ type ProviderCredentials struct {
 	UserInfoURL string
 	// EmailsURL is GitHub-specific (its verified-email list endpoint).
 	EmailsURL string
+	// Issuer, for a provider that is not one of the built-ins, is the OpenID
+	// Connect issuer whose discovery document supplies every endpoint; the
+	// overrides above are ignored for it. See oidc.go.
+	Issuer string
+	// DisplayName is what GET /auth/providers tells the frontend to call the
+	// provider. Empty uses the built-in's name, or the provider key.
+	DisplayName string
 }
 
 // Mailer sends a plain-text email. It is consumer-declared here; SMTP and
@@ -140,6 +159,13 @@ type Service struct {
 	// thing standing between the server and a memory-exhaustion DoS made of
 	// ordinary login attempts.
 	hashes *hashGate
+	// rp is the WebAuthn relying party passkey ceremonies run for, resolved
+	// once from cfg (passkeys.go).
+	rp relyingParty
+	// tokenLimiter is the per-token bucket of Bearer requests, keyed by token
+	// id. Separate from limiter, which is keyed by IP and guards the auth
+	// endpoints themselves.
+	tokenLimiter RateLimiter
 }
 
 // NewService wires the auth service. store and sessions may be the same
@@ -152,16 +178,24 @@ func NewService(store Store, sessions SessionStore, mailer Mailer, limiter RateL
 	if wait <= 0 {
 		wait = DefaultHashWait
 	}
+	tokenEvery, tokenBurst := cfg.AccessTokenRateEvery, cfg.AccessTokenRateBurst
+	if tokenEvery <= 0 {
+		tokenEvery = DefaultAccessTokenRateEvery
+	}
+	if tokenBurst <= 0 {
+		tokenBurst = DefaultAccessTokenRateBurst
+	}
 	s := &Service{
-		store:    store,
-		sessions: sessions,
-		mailer:   mailer,
-		limiter:  limiter,
-		captcha:  captcha,
-		cfg:      cfg,
-		log:      log,
-		now:      time.Now,
-		hashes:   newHashGate(cfg.HashConcurrency, wait),
+		store:        store,
+		sessions:     sessions,
+		mailer:       mailer,
+		limiter:      limiter,
+		captcha:      captcha,
+		cfg:          cfg,
+		log:          log,
+		now:          time.Now,
+		hashes:       newHashGate(cfg.HashConcurrency, wait),
+		tokenLimiter: NewInMemoryRateLimiter(tokenEvery, tokenBurst),
 	}
 	// Precompute the timing-decoy hash once, ungated: it runs at startup with no
 	// contention, and a gate that could reject it would leave the decoy empty.
@@ -171,6 +205,7 @@ func NewService(store Store, sessions SessionStore, mailer Mailer, limiter RateL
 		s.dummyHash = h
 	}
 	s.oauth = s.buildProviders()
+	s.rp = newRelyingParty(cfg)
 	return s
 }
 
@@ -305,6 +340,11 @@ type userView struct {
 	// BEFORE the server would refuse it (the cooldown is +30 days from this
 	// instant). Omitted while the name has never been changed.
 	DisplayNameChangedAt *time.Time `json:"displayNameChangedAt,omitempty"`
+	// TwoFactorEnabled says whether the account has a confirmed second factor
+	// — the settings screen's switch, and the client's cue that an admin whose
+	// permissions look short is missing one (permissions.go). Nothing about
+	// the factor itself rides here.
+	TwoFactorEnabled bool `json:"twoFactorEnabled"`
 }
 
 func toUserView(u User) userView {
@@ -312,6 +352,7 @@ func toUserView(u User) userView {
 		ID: u.ID, DisplayName: u.DisplayName, CreatedAt: u.CreatedAt,
 		ProfilePublic: u.ProfilePublic, KeyboardPublic: u.KeyboardPublic,
 		Permissions: u.Permissions(), DisplayNameChangedAt: u.DisplayNameChangedAt,
+		TwoFactorEnabled: u.TwoFactorEnabledAt != nil,
 	}
 }
 
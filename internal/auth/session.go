@@ -3,26 +3,45 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/netip"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/platform/httpx"
 )
 
 // sessionRefreshInterval bounds how often a session's sliding expiry is written
 // back: we only extend expires_at / last_seen_at once the session has not been
 // touched for this long, so a burst of requests is not a burst of UPDATEs.
-const sessionRefreshInterval = 1 * time.Hour
+// It is also the resolution of "last seen" in the session list, which is why
+// it is minutes rather than hours: one UPDATE per active session per quarter
+// hour is still nothing next to the reads every request makes.
+const sessionRefreshInterval = 15 * time.Minute
 
 // issueSession mints a new session for userID, persists only its hash, and sets
 // the session cookie on w. The plaintext token exists only inside the cookie.
-func (s *Service) issueSession(ctx context.Context, w http.ResponseWriter, userID uuid.UUID) error {
+// twoFactor records that the sign-in proved a second factor (twofactor.go); r
+// is the sign-in request, described in the session list by its browser and
+// network.
+func (s *Service) issueSession(w http.ResponseWriter, r *http.Request, userID uuid.UUID, twoFactor bool) error {
 	token, hash, err := newToken()
 	if err != nil {
 		return err
 	}
-	expiresAt := s.now().Add(s.cfg.SessionTTL)
-	if _, err := s.sessions.CreateSession(ctx, hash, userID, expiresAt); err != nil {
+	if _, err := s.sessions.CreateSession(r.Context(), SessionParams{
+		TokenHash: hash,
+		UserID:    userID,
+		ExpiresAt: s.now().Add(s.cfg.SessionTTL),
+		TwoFactor: twoFactor,
+		UserAgent: describeUserAgent(r.UserAgent()),
+		IPPrefix:  ipPrefix(httpx.ClientIP(r)),
+	}); err != nil {
 		return err
 	}
 	http.SetCookie(w, s.sessionCookie(token, s.cfg.SessionTTL))
@@ -60,7 +79,7 @@ func (s *Service) authenticate(ctx context.Context, r *http.Request) (User, erro
 
 	// Sliding expiry: extend, but only occasionally to avoid a write per request.
 	if now.Sub(session.LastSeenAt) > sessionRefreshInterval {
-		if err := s.sessions.TouchSession(ctx, session.ID, now.Add(s.cfg.SessionTTL)); err != nil {
+		if err := s.sessions.TouchSession(ctx, session.ID, now.Add(s.cfg.SessionTTL), ipPrefix(httpx.ClientIP(r))); err != nil {
 			// A failed refresh is not fatal to the request; log and continue.
 			s.log.WarnContext(ctx, "session refresh failed", "err", err)
 		}
@@ -74,6 +93,8 @@ func (s *Service) authenticate(ctx context.Context, r *http.Request) (User, erro
 		}
 		return User{}, err
 	}
+	user.TwoFactorSession = session.TwoFactor
+	user.SessionID = session.ID
 	return user, nil
 }
 
@@ -125,3 +146,215 @@ func (s *Service) sessionCookie(value string, ttl time.Duration) *http.Cookie {
 	}
 	return c
 }
+
+// --- the account's session list ---
+
+// sessionView is one session as GET /me/sessions shows it. The token is never
+// shown — only its hash is stored, and the list is for recognising sessions,
+// not resuming them.
+type sessionView struct {
+	ID         uuid.UUID `json:"id"`
+	Name       *string   `json:"name,omitempty"`
+	UserAgent  string    `json:"userAgent"`
+	IPPrefix   string    `json:"ipPrefix"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// Current marks the session the request itself rides on.
+	Current bool `json:"current"`
+	// TwoFactor is whether the sign-in that opened it proved a second factor.
+	TwoFactor bool `json:"twoFactor"`
+}
+
+func toSessionView(sess Session, current uuid.UUID) sessionView {
+	return sessionView{
+		ID:         sess.ID,
+		Name:       sess.Name,
+		UserAgent:  sess.UserAgent,
+		IPPrefix:   sess.IPPrefix,
+		CreatedAt:  sess.CreatedAt,
+		LastSeenAt: sess.LastSeenAt,
+		Current:    sess.ID == current,
+		TwoFactor:  sess.TwoFactor,
+	}
+}
+
+const maxSessionNameLen = 64
+
+// HandleSessions serves GET /api/v1/me/sessions: the account's live sessions,
+// most recently seen first, with the caller's own marked current. Mounted
+// beside /me by the caller (RequireAuth).
+func (s *Service) HandleSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	sessions, err := s.sessions.UserSessions(r.Context(), user.ID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	out := struct {
+		Sessions []sessionView `json:"sessions"`
+	}{Sessions: make([]sessionView, len(sessions))}
+	for i, sess := range sessions {
+		out.Sessions[i] = toSessionView(sess, user.SessionID)
+	}
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+// HandleRenameSession serves PATCH /api/v1/me/sessions/{id}: the player's
+// label for one of their sessions. A null or blank name clears it. Mounted by
+// the caller (RequireOrigin + RequireAuth).
+func (s *Service) HandleRenameSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, r, apiErrSessionNotFound)
+		return
+	}
+	var req struct {
+		Name *string `json:"name"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	var name *string
+	if req.Name != nil {
+		if trimmed := strings.TrimSpace(*req.Name); trimmed != "" {
+			name = &trimmed
+		}
+	}
+	if name != nil && utf8.RuneCountInString(*name) > maxSessionNameLen {
+		s.writeError(w, r, apiErrBadRequest(fmt.Sprintf("a session name is at most %d characters", maxSessionNameLen)))
+		return
+	}
+	sess, err := s.sessions.RenameSession(r.Context(), user.ID, id, name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.writeError(w, r, apiErrSessionNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, toSessionView(sess, user.SessionID))
+}
+
+// HandleRevokeSession serves DELETE /api/v1/me/sessions/{id}: signs one of the
+// account's sessions out, wherever it is. Revoking the caller's own session is
+// allowed and is a logout — the cookie is cleared with it. Mounted by the
+// caller (RequireOrigin + RequireAuth).
+func (s *Service) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, r, apiErrSessionNotFound)
+		return
+	}
+	if err := s.sessions.DeleteUserSession(r.Context(), user.ID, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.writeError(w, r, apiErrSessionNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	if id == user.SessionID {
+		http.SetCookie(w, s.sessionCookie("", -time.Hour))
+	}
+	s.writeJSON(w, http.StatusOK, statusMessage("session signed out"))
+}
+
+// HandleRevokeOtherSessions serves POST /api/v1/me/sessions/revoke-others:
+// signs out every session of the account except the caller's. Mounted by the
+// caller (RequireOrigin + RequireAuth).
+func (s *Service) HandleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	n, err := s.sessions.DeleteOtherUserSessions(r.Context(), user.ID, user.SessionID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		Revoked int64 `json:"revoked"`
+	}{Revoked: n})
+}
+
+// --- describing the client ---
+
+// describeUserAgent reduces a User-Agent header to the browser and platform
+// ("Firefox on Windows") — what a player needs to recognise a session, and
+// all the session row keeps. Order matters: Edge and Opera also claim Chrome,
+// Chrome also claims Safari, and Android also claims Linux. Anything it does
+// not recognise is "".
+func describeUserAgent(ua string) string {
+	var browser, platform string
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, p := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"CrOS", "ChromeOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, p.token) {
+			platform = p.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	}
+	return platform
+}
+
+// ipPrefix truncates a client address to the network it sits in — a /24 for
+// IPv4, a /48 for IPv6 — which is as much of it as the session list shows or
+// the session row keeps. An unparseable address is "".
+func ipPrefix(addr string) string {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return ""
+	}
+	ip = ip.Unmap().WithZone("")
+	bits := 48
+	if ip.Is4() {
+		bits = 24
+	}
+	prefix, err := ip.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
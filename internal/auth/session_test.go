@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Internal because both helpers are unexported; what they produce is what the
+// session list shows, so the cases read as the list would.
+
+func TestDescribeUserAgent(t *testing.T) {
+	cases := []struct {
+		name, ua, want string
+	}{
+		{"chrome on windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+			"Chrome on Windows"},
+		// Edge carries Chrome/ and Safari/ too; the more specific token wins.
+		{"edge on windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36 Edg/129.0.0.0",
+			"Edge on Windows"},
+		{"firefox on linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0",
+			"Firefox on Linux"},
+		{"safari on macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Safari/605.1.15",
+			"Safari on macOS"},
+		// iPhone says "like Mac OS X"; it must not read as macOS.
+		{"safari on ios",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 18_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Mobile/15E148 Safari/604.1",
+			"Safari on iOS"},
+		// Android says Linux; the more specific platform wins.
+		{"chrome on android",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Mobile Safari/537.36",
+			"Chrome on Android"},
+		{"platform only", "Mozilla/5.0 (Windows NT 10.0) SomeBot/1.0", "Windows"},
+		{"unrecognised", "curl/8.5.0", ""},
+		{"empty", "", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, describeUserAgent(tc.ua))
+		})
+	}
+}
+
+func TestIPPrefix(t *testing.T) {
+	cases := []struct {
+		addr, want string
+	}{
+		{"203.0.113.77", "203.0.113.0/24"},
+		{"2001:db8:abcd:12:34::1", "2001:db8:abcd::/48"},
+		// A v4 address arriving v6-mapped is still a v4 network.
+		{"::ffff:198.51.100.9", "198.51.100.0/24"},
+		{"fe80::1%eth0", "fe80::/48"},
+		{"not-an-ip", ""},
+		{"", ""},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, ipPrefix(tc.addr), tc.addr)
+	}
+}
@@ -0,0 +1,181 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sessionsPath = mePath + "/sessions"
+
+type sessionEntry struct {
+	ID        string  `json:"id"`
+	Name      *string `json:"name"`
+	UserAgent string  `json:"userAgent"`
+	IPPrefix  string  `json:"ipPrefix"`
+	Current   bool    `json:"current"`
+}
+
+type sessionList struct {
+	Sessions []sessionEntry `json:"sessions"`
+}
+
+// userAgentTransport stamps a User-Agent on every request, so a test device
+// looks like a browser rather than Go's default client.
+type userAgentTransport struct{ ua string }
+
+func (t userAgentTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("User-Agent", t.ua)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// device returns a view of the same server with its own cookie jar and
+// User-Agent — a second browser signing in to the same account.
+func (h *harness) device(ua string) *harness {
+	jar, _ := cookiejar.New(nil)
+	d := *h
+	d.client = &http.Client{
+		Jar:           jar,
+		Transport:     userAgentTransport{ua: ua},
+		CheckRedirect: h.client.CheckRedirect,
+	}
+	return &d
+}
+
+func (h *harness) sessions() []sessionEntry {
+	h.t.Helper()
+	resp := h.get(sessionsPath)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	return decodeInto[sessionList](h.t, resp).Sessions
+}
+
+const (
+	firefoxLinux = "Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0"
+	chromeMac    = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36"
+)
+
+func TestSessionListAndRename(t *testing.T) {
+	h := newHarness(t)
+	const email, password = "sessions@example.com", "many-devices-1"
+	h.registerVerifyLogin(email, password, "Roamer")
+
+	laptop := h.device(firefoxLinux)
+	requireStatus(t, laptop.login(email, password), http.StatusOK)
+
+	list := laptop.sessions()
+	require.Len(t, list, 2)
+	// The newest sign-in is the most recently seen, so it leads the list.
+	assert.True(t, list[0].Current)
+	assert.False(t, list[1].Current)
+	assert.Equal(t, "Firefox on Linux", list[0].UserAgent)
+	assert.Equal(t, "", list[1].UserAgent, "Go's client is not a browser the list can name")
+	assert.Equal(t, "127.0.0.0/24", list[0].IPPrefix)
+	assert.Nil(t, list[0].Name)
+
+	// Name it, then clear the name again with blanks.
+	resp := laptop.patch(sessionsPath+"/"+list[0].ID, map[string]string{"name": "  work laptop "})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	named := decodeInto[sessionEntry](t, resp)
+	require.NotNil(t, named.Name)
+	assert.Equal(t, "work laptop", *named.Name)
+	assert.True(t, named.Current)
+
+	resp = laptop.patch(sessionsPath+"/"+list[0].ID, map[string]string{"name": "   "})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, decodeInto[sessionEntry](t, resp).Name)
+
+	// Another account's session is not found, not forbidden.
+	other := h.device(chromeMac)
+	other.registerVerifyLogin("other@example.com", "someone-else-2", "Other")
+	resp = other.patch(sessionsPath+"/"+list[0].ID, map[string]string{"name": "mine now"})
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "session_not_found", decodeInto[errResponse](t, resp).Error)
+	requireStatus(t, other.del(sessionsPath+"/"+list[0].ID), http.StatusNotFound)
+
+	requireStatus(t, laptop.patch(sessionsPath+"/not-a-uuid", map[string]string{"name": "x"}), http.StatusNotFound)
+}
+
+func TestSessionRevoke(t *testing.T) {
+	h := newHarness(t)
+	const email, password = "revoke@example.com", "sign-them-out-3"
+	h.registerVerifyLogin(email, password, "Careful")
+
+	laptop := h.device(firefoxLinux)
+	requireStatus(t, laptop.login(email, password), http.StatusOK)
+	phone := h.device(chromeMac)
+	requireStatus(t, phone.login(email, password), http.StatusOK)
+
+	// Sign the laptop out from the original device.
+	var laptopID string
+	for _, s := range laptop.sessions() {
+		if s.Current {
+			laptopID = s.ID
+		}
+	}
+	require.NotEmpty(t, laptopID)
+	requireStatus(t, h.del(sessionsPath+"/"+laptopID), http.StatusOK)
+	requireStatus(t, laptop.get(mePath), http.StatusUnauthorized)
+	requireStatus(t, h.get(mePath), http.StatusOK)
+	requireStatus(t, h.del(sessionsPath+"/"+laptopID), http.StatusNotFound)
+
+	// Everything but this one: the phone goes, the caller stays.
+	resp := h.post(sessionsPath+"/revoke-others", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(1), decodeInto[struct {
+		Revoked int64 `json:"revoked"`
+	}](t, resp).Revoked)
+	requireStatus(t, phone.get(mePath), http.StatusUnauthorized)
+	require.Len(t, h.sessions(), 1)
+
+	// Revoking the current session is a sign-out.
+	current := h.sessions()[0]
+	require.True(t, current.Current)
+	requireStatus(t, h.del(sessionsPath+"/"+current.ID), http.StatusOK)
+	requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+}
+
+func TestPasswordChangeRevokesOtherSessions(t *testing.T) {
+	h := newHarness(t)
+	const email, oldPw, newPw = "change@example.com", "old-password-1", "new-password-2"
+	h.registerVerifyLogin(email, oldPw, "Changer")
+
+	laptop := h.device(firefoxLinux)
+	requireStatus(t, laptop.login(email, oldPw), http.StatusOK)
+
+	// The wrong current password changes nothing.
+	resp := h.post(authBase+"/password/change", map[string]string{
+		"currentPassword": "not-the-password", "newPassword": newPw,
+	})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_credentials", decodeInto[errResponse](t, resp).Error)
+	requireStatus(t, laptop.get(mePath), http.StatusOK)
+
+	requireStatus(t, h.post(authBase+"/password/change", map[string]string{
+		"currentPassword": oldPw, "newPassword": newPw,
+	}), http.StatusOK)
+
+	// The other device is out; the caller rides a fresh session.
+	requireStatus(t, laptop.get(mePath), http.StatusUnauthorized)
+	list := h.sessions()
+	require.Len(t, list, 1)
+	assert.True(t, list[0].Current)
+
+	requireStatus(t, h.login(email, oldPw), http.StatusUnauthorized)
+	requireStatus(t, h.login(email, newPw), http.StatusOK)
+}
+
+func TestPasswordChangeWithoutPassword(t *testing.T) {
+	fp := newFakeProvider(t)
+	h := newHarness(t, withProviders(fp.creds()))
+	h.oauthOnlyLogin(t, fp, "sub-nopw")
+
+	resp := h.post(authBase+"/password/change", map[string]string{
+		"currentPassword": "anything-at-all", "newPassword": "new-password-2",
+	})
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "no_password", decodeInto[errResponse](t, resp).Error)
+}
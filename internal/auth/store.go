@@ -7,12 +7,14 @@ import (
 	"github.com/google/uuid"
 )
 
-// Provider identifies how an identity authenticates. These strings match the
-// CHECK constraint on auth_identities.provider.
+// Provider identifies how an identity authenticates. These are the built-in
+// names; a configured OpenID Connect provider adds its own, and every one of
+// them fits the CHECK constraint on auth_identities.provider.
 const (
-	ProviderEmail  = "email"
-	ProviderGitHub = "github"
-	ProviderGoogle = "google"
+	ProviderEmail   = "email"
+	ProviderGitHub  = "github"
+	ProviderGoogle  = "google"
+	ProviderDiscord = "discord"
 )
 
 // Token purposes, matching the CHECK constraint on email_tokens.purpose.
@@ -21,6 +23,13 @@ const (
 	PurposeReset  = "reset"
 )
 
+// WebAuthn ceremony kinds, matching the CHECK constraint on
+// webauthn_ceremonies.kind.
+const (
+	CeremonyRegister = "register"
+	CeremonyLogin    = "login"
+)
+
 // --- Domain types ---
 //
 // These are the auth domain's own types. The PostgreSQL adapter converts sqlc
@@ -46,6 +55,18 @@ type User struct {
 	// DisplayNameChangedAt starts the rename cooldown (00030); nil means the
 	// name has never been changed — registration does not start the clock.
 	DisplayNameChangedAt *time.Time
+	// TwoFactorEnabledAt is when a TOTP second factor was confirmed (00045);
+	// nil means the account signs in with one factor. Read fresh with the role,
+	// because some permissions are withheld without it (permissions.go).
+	TwoFactorEnabledAt *time.Time
+	// TwoFactorSession is not a column of the account: it is set by
+	// authenticate from the session the request rides on, and says whether
+	// that sign-in proved the second factor. The gated permissions need both.
+	TwoFactorSession bool
+	// SessionID is, like TwoFactorSession, not a column: the id of the session
+	// the request rides on, so the session list can mark it and revoking it
+	// can also clear the cookie.
+	SessionID uuid.UUID
 }
 
 // SettingsParams is the input to UpdateUserSettings: the full pair, resolved
@@ -74,6 +95,26 @@ type Session struct {
 	CreatedAt  time.Time
 	ExpiresAt  time.Time
 	LastSeenAt time.Time
+	// TwoFactor is whether the sign-in that opened the session proved a second
+	// factor (00045).
+	TwoFactor bool
+	// Name is the player's label for the session; nil until they set one.
+	// UserAgent and IPPrefix are what the session list shows to tell sessions
+	// apart (00047): a coarse description of the browser that signed in, and
+	// the network it was last seen from, truncated. Either is "" when unknown.
+	Name      *string
+	UserAgent string
+	IPPrefix  string
+}
+
+// SessionParams is the input to CreateSession.
+type SessionParams struct {
+	TokenHash []byte
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	TwoFactor bool
+	UserAgent string
+	IPPrefix  string
 }
 
 // Credential is the password material for an email account.
@@ -93,6 +134,44 @@ type EmailToken struct {
 	UsedAt    *time.Time
 }
 
+// TOTP is an account's shared TOTP secret, confirmed or not (the user's
+// TwoFactorEnabledAt says which), and the newest step a code was accepted for.
+type TOTP struct {
+	Secret   []byte
+	LastStep int64
+}
+
+// Passkey is a registered WebAuthn credential (00046).
+type Passkey struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	CredentialID []byte
+	// PublicKey is the COSE_Key as the authenticator encoded it.
+	PublicKey  []byte
+	SignCount  int64
+	Transports []string
+	Name       string
+	// TwoFactor is whether the passkey was registered from a session that had
+	// proven the second factor; only then does signing in with it count as a
+	// second-factor sign-in.
+	TwoFactor  bool
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// AccessToken is a personal access token (00048). The plaintext is never
+// stored; TokenHash is what a Bearer header is looked up by.
+type AccessToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TokenHash  []byte
+	Name       string
+	Scopes     []Scope
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
 // --- Parameter structs for composite writes ---
 
 // EmailAccountParams is the input to CreateEmailAccount.
@@ -119,6 +198,26 @@ type IdentityParams struct {
 	EmailVerified bool
 }
 
+// PasskeyParams is the input to CreatePasskey.
+type PasskeyParams struct {
+	UserID       uuid.UUID
+	CredentialID []byte
+	PublicKey    []byte
+	SignCount    int64
+	Transports   []string
+	Name         string
+	TwoFactor    bool
+}
+
+// AccessTokenParams is the input to CreateAccessToken.
+type AccessTokenParams struct {
+	UserID    uuid.UUID
+	TokenHash []byte
+	Name      string
+	Scopes    []Scope
+	ExpiresAt time.Time
+}
+
 // EmailTokenParams is the input to CreateEmailToken.
 type EmailTokenParams struct {
 	UserID    uuid.UUID
@@ -178,15 +277,96 @@ type Store interface {
 	CreateEmailToken(ctx context.Context, p EmailTokenParams) error
 	DeleteUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error
 	UseEmailToken(ctx context.Context, tokenHash []byte, purpose string) (EmailToken, error)
+
+	// BeginTOTPEnrolment stores a fresh unconfirmed secret, replacing any
+	// earlier unconfirmed one; ErrTwoFactorEnabled if a second factor is
+	// already confirmed. TOTP reads the secret back (ErrNotFound if none).
+	BeginTOTPEnrolment(ctx context.Context, userID uuid.UUID, secret []byte) error
+	TOTP(ctx context.Context, userID uuid.UUID) (TOTP, error)
+	// EnableTwoFactor atomically confirms the enrolment: records the step the
+	// confirming code was accepted for, marks the account, and issues the
+	// recovery codes (by hash). ErrTwoFactorEnabled if it already was.
+	EnableTwoFactor(ctx context.Context, userID uuid.UUID, step int64, recoveryHashes [][]byte) error
+	// AcceptTOTPStep records a code accepted for step; ErrCodeReplayed when
+	// step is not later than the last one accepted.
+	AcceptTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	// DisableTwoFactor atomically removes the secret and the recovery codes,
+	// unmarks the account, and clears the second-factor standing of its
+	// sessions and passkeys.
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID) error
+	// ReplaceRecoveryCodes atomically swaps the account's recovery codes for
+	// a new set; UseRecoveryCode spends one (ErrNotFound if it is not an
+	// unused code of this account); RecoveryCodesLeft counts the unused ones.
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes [][]byte) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash []byte) error
+	RecoveryCodesLeft(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// CreateLoginChallenge stores a pending second-factor login (by token
+	// hash); AttemptLoginChallenge spends one of its attempts and returns the
+	// account (ErrNotFound when it is unknown, expired or out of attempts);
+	// DeleteLoginChallenge retires it.
+	CreateLoginChallenge(ctx context.Context, tokenHash []byte, userID uuid.UUID, expiresAt time.Time) error
+	AttemptLoginChallenge(ctx context.Context, tokenHash []byte, maxAttempts int32) (uuid.UUID, error)
+	DeleteLoginChallenge(ctx context.Context, tokenHash []byte) error
+
+	// CreatePasskey stores a verified registration (ErrPasskeyExists if the
+	// credential id is taken); PasskeyByCredentialID finds the credential an
+	// assertion names; Passkeys lists an account's, oldest first.
+	CreatePasskey(ctx context.Context, p PasskeyParams) (Passkey, error)
+	PasskeyByCredentialID(ctx context.Context, credentialID []byte) (Passkey, error)
+	Passkeys(ctx context.Context, userID uuid.UUID) ([]Passkey, error)
+	// UsePasskey records a verified assertion's signature counter and the
+	// time; ErrSignCountRegressed when the counter did not move forward.
+	UsePasskey(ctx context.Context, id uuid.UUID, signCount int64) error
+	// DeletePasskey removes one of the account's passkeys (ErrNotFound if the
+	// account has no such passkey).
+	DeletePasskey(ctx context.Context, userID, id uuid.UUID) error
+	// CreateWebAuthnCeremony stores a ceremony's challenge under a token hash;
+	// userID is the registering account, nil for a sign-in.
+	// ConsumeWebAuthnCeremony takes it back exactly once (ErrNotFound when it
+	// is unknown, expired, of another kind, or already taken).
+	CreateWebAuthnCeremony(ctx context.Context, tokenHash []byte, kind string, userID *uuid.UUID, challenge []byte, expiresAt time.Time) error
+	ConsumeWebAuthnCeremony(ctx context.Context, tokenHash []byte, kind string) (userID *uuid.UUID, challenge []byte, err error)
+
+	// CreateAccessToken stores a new personal access token (by hash);
+	// AccessTokenByHash finds the one a Bearer header names (expired ones
+	// included — the caller checks); AccessTokens lists an account's, newest
+	// first, and CountAccessTokens counts them.
+	CreateAccessToken(ctx context.Context, p AccessTokenParams) (AccessToken, error)
+	AccessTokenByHash(ctx context.Context, tokenHash []byte) (AccessToken, error)
+	AccessTokens(ctx context.Context, userID uuid.UUID) ([]AccessToken, error)
+	CountAccessTokens(ctx context.Context, userID uuid.UUID) (int64, error)
+	// TouchAccessToken records the token as used now.
+	TouchAccessToken(ctx context.Context, id uuid.UUID) error
+	// DeleteAccessToken revokes one of the account's tokens (ErrNotFound if
+	// the account has no such token); DeleteUserAccessTokens revokes them all.
+	DeleteAccessToken(ctx context.Context, userID, id uuid.UUID) error
+	DeleteUserAccessTokens(ctx context.Context, userID uuid.UUID) error
 }
 
 // SessionStore is the session persistence contract, deliberately separate from
 // Store so it can be swapped to Redis independently (see the package doc's
 // deviation note). A missing/expired session is reported as ErrNotFound.
+//
+// The per-account methods (UserSessions onwards) take the owner's id as well
+// as the session's, and match nothing when the two disagree: a session id is
+// not a secret, and one account's request must never reach another's row.
 type SessionStore interface {
-	CreateSession(ctx context.Context, tokenHash []byte, userID uuid.UUID, expiresAt time.Time) (Session, error)
+	CreateSession(ctx context.Context, p SessionParams) (Session, error)
 	SessionByTokenHash(ctx context.Context, tokenHash []byte) (Session, error)
-	TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	// TouchSession slides the expiry and records the session as seen now,
+	// from ipPrefix.
+	TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time, ipPrefix string) error
 	DeleteSession(ctx context.Context, tokenHash []byte) error
 	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
+	// UserSessions lists the account's unexpired sessions, most recently
+	// seen first.
+	UserSessions(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	// RenameSession sets (or, with nil, clears) a session's name.
+	RenameSession(ctx context.Context, userID, id uuid.UUID, name *string) (Session, error)
+	// DeleteUserSession revokes one of the account's sessions.
+	DeleteUserSession(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteOtherUserSessions revokes every session of the account but keep,
+	// reporting how many it revoked.
+	DeleteOtherUserSessions(ctx context.Context, userID, keep uuid.UUID) (int64, error)
 }
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // HMAC-SHA1 is the TOTP algorithm; see the parameters below
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238). These are the defaults every authenticator app
+// assumes when a provisioning URI does not say otherwise, and they are written
+// into the URI anyway: an app that honours the parameters and one that ignores
+// them must compute the same code.
+//
+// SHA-1 here is HMAC-SHA1, RFC 6238's default and the only algorithm every
+// authenticator app implements; HMAC does not inherit SHA-1's collision
+// weakness.
+const (
+	totpIssuer    = "TypeMore"
+	totpSecretLen = 20 // 160 bits, the length RFC 4226 recommends
+	totpDigits    = 6
+	totpModulus   = 1_000_000 // 10^totpDigits
+	totpPeriod    = 30 * time.Second
+	totpSkewSteps = 1 // accept the previous and next step too: phone clocks drift
+
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10 // 80 bits of entropy, 16 base32 characters
+)
+
+// totpEncoding is the secret's wire form: unpadded upper-case base32, which is
+// what the otpauth URI format and manual-entry fields both expect.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPSecret returns a fresh random secret.
+func newTOTPSecret() ([]byte, error) {
+	secret := make([]byte, totpSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate totp secret: %w", err)
+	}
+	return secret, nil
+}
+
+// totpStep is the RFC 6238 time step an instant falls in.
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod/time.Second)
+}
+
+// totpCode computes the code for one step: HOTP (RFC 4226) over the step
+// counter, dynamically truncated to totpDigits decimal digits.
+func totpCode(secret []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulus)
+}
+
+// matchTOTP returns the step within the skew window around now whose code is
+// code, or false. Every step in the window is computed and compared in
+// constant time whether or not an earlier one matched, so the response time
+// says nothing about which step was close.
+//
+// Matching is only half of accepting: the caller must still record the step
+// (Store.AcceptTOTPStep), which is what refuses the same code twice.
+func matchTOTP(secret []byte, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := totpStep(now)
+	var (
+		matched int64
+		found   bool
+	)
+	for step := current - totpSkewSteps; step <= current+totpSkewSteps; step++ {
+		equal := subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1
+		if equal && !found {
+			matched, found = step, true
+		}
+	}
+	return matched, found
+}
+
+// totpURI is the otpauth:// provisioning URI an authenticator app imports,
+// usually by scanning it as a QR code the client renders. The label names the
+// account by display name: it is what the user sees in the app's list, and it
+// is the one identifier every account has.
+func totpURI(secret []byte, account string) string {
+	q := url.Values{}
+	q.Set("secret", totpEncoding.EncodeToString(secret))
+	q.Set("issuer", totpIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(int(totpPeriod/time.Second)))
+	return "otpauth://totp/" + url.PathEscape(totpIssuer) + ":" + url.PathEscape(account) +
+		"?" + q.Encode()
+}
+
+// newRecoveryCodes returns a fresh set of recovery codes (for showing once)
+// with their hashes (for storing). A code is 16 base32 characters in groups of
+// four; normalizeRecoveryCode undoes the grouping and the case before hashing,
+// so a user may type it either way.
+func newRecoveryCodes() (codes []string, hashes [][]byte, err error) {
+	codes = make([]string, recoveryCodeCount)
+	hashes = make([][]byte, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		plain := totpEncoding.EncodeToString(raw)
+		codes[i] = plain[0:4] + "-" + plain[4:8] + "-" + plain[8:12] + "-" + plain[12:16]
+		hashes[i] = hashRecoveryCode(plain)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode strips the grouping dashes and whitespace a user may
+// type and upper-cases the rest.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ' || r == '\t':
+			return -1
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return r
+	}, code)
+}
+
+// hashRecoveryCode is the stored form of a normalized code. SHA-256 rather than
+// argon2id for the reason session tokens use it: the code has 80 bits of its
+// own entropy, so there is no dictionary for a slow hash to slow down.
+func hashRecoveryCode(normalized string) []byte {
+	sum := sha256.Sum256([]byte(normalized))
+	return sum[:]
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests are internal to the package because the TOTP primitives are
+// unexported. The HTTP flows that use them are pinned in twofactor_test.go;
+// here is where the arithmetic is checked against the RFC rather than against
+// itself.
+
+// TestTOTPMatchesRFC6238 runs the RFC 6238 appendix B vectors for SHA-1. The
+// RFC prints eight digits; a six-digit code is the same truncation taken mod
+// 10^6, so it is the last six.
+func TestTOTPMatchesRFC6238(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		assert.Equal(t, tc.want, totpCode(secret, totpStep(time.Unix(tc.unix, 0))), "T=%d", tc.unix)
+	}
+}
+
+func TestTOTPWindowAcceptsOneStepEitherSide(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1_700_000_000, 0)
+	current := totpStep(now)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		step, ok := matchTOTP(secret, totpCode(secret, current+offset), now)
+		assert.True(t, ok, "offset %d", offset)
+		assert.Equal(t, current+offset, step, "the step a code matched is the one to record")
+	}
+	for _, offset := range []int64{-2, 2} {
+		_, ok := matchTOTP(secret, totpCode(secret, current+offset), now)
+		assert.False(t, ok, "offset %d is outside the window", offset)
+	}
+
+	_, ok := matchTOTP(secret, " "+totpCode(secret, current)+" ", now)
+	assert.True(t, ok, "surrounding whitespace from a paste is forgiven")
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		_, ok := matchTOTP(secret, bad, now)
+		assert.False(t, ok, "%q", bad)
+	}
+}
+
+func TestTOTPURIIsImportable(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	u, err := url.Parse(totpURI(secret, "ada lovelace"))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/TypeMore:ada lovelace", u.Path)
+	q := u.Query()
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", q.Get("secret"), "unpadded base32")
+	assert.Equal(t, "TypeMore", q.Get("issuer"))
+	assert.Equal(t, "6", q.Get("digits"))
+	assert.Equal(t, "30", q.Get("period"))
+}
+
+func TestRecoveryCodesNormalizeToTheirHash(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		assert.Len(t, code, 19, "four groups of four, dash-separated")
+		assert.False(t, seen[code], "codes in a set are distinct")
+		seen[code] = true
+
+		for _, typed := range []string{code, strings.ToLower(code), strings.ReplaceAll(code, "-", " "), strings.ReplaceAll(code, "-", "")} {
+			assert.Equal(t, hashes[i], hashRecoveryCode(normalizeRecoveryCode(typed)), "%q", typed)
+		}
+	}
+}
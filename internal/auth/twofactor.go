@@ -0,0 +1,351 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The half-finished login (docs/AUTH.md, "Two-factor authentication"). After
+// the first factor is proven, the client holds this cookie instead of a
+// session, and POST /login/2fa trades it for one.
+//
+// A cookie rather than a token in the response body, so password logins and
+// OAuth callbacks carry it the same way — the callback is a redirect and has no
+// body to put one in — and so it is as unreadable to page script as the
+// session it stands in for.
+const (
+	cookieLoginChallenge = "tm_2fa"
+	loginChallengeTTL    = 5 * time.Minute
+	// loginChallengeAttempts bounds the wrong codes one proof of the first
+	// factor buys. The per-IP limiter does not bound them for a caller spread
+	// over many addresses; this does, and then the password (or the provider)
+	// has to be proven again.
+	loginChallengeAttempts = 5
+)
+
+// twoFactorPending is the 202 body of a first factor that needs a second.
+type twoFactorPending struct {
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// beginSecondFactor stores a login challenge for userID and sets its cookie.
+// It returns when the challenge expires.
+func (s *Service) beginSecondFactor(ctx context.Context, w http.ResponseWriter, userID uuid.UUID) (time.Time, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiresAt := s.now().Add(loginChallengeTTL)
+	if err := s.store.CreateLoginChallenge(ctx, hash, userID, expiresAt); err != nil {
+		return time.Time{}, err
+	}
+	s.setFlowCookie(w, cookieLoginChallenge, token, loginChallengeTTL)
+	return expiresAt, nil
+}
+
+// secondFactorRequest proves a second factor: a code from the authenticator
+// app, or one of the recovery codes for when the app is gone. Exactly one.
+type secondFactorRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+func (req secondFactorRequest) validate() *apiError {
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		return apiErrBadRequest("send exactly one of code and recoveryCode")
+	}
+	return nil
+}
+
+// checkSecondFactor proves req against the account's confirmed factor, and
+// spends what it proved with: the TOTP step, or the recovery code. Every way of
+// failing is apiErrInvalidCode.
+//
+// Callers establish that the account HAS a confirmed factor first; an
+// unconfirmed enrolment's secret must never pass here, and a login challenge
+// only exists for an account that had one when it was issued (if it was
+// disabled since, the secret is gone and the code fails).
+func (s *Service) checkSecondFactor(ctx context.Context, userID uuid.UUID, req secondFactorRequest) error {
+	if req.RecoveryCode != "" {
+		err := s.store.UseRecoveryCode(ctx, userID, hashRecoveryCode(normalizeRecoveryCode(req.RecoveryCode)))
+		if errors.Is(err, ErrNotFound) {
+			return apiErrInvalidCode
+		}
+		return err
+	}
+	totp, err := s.store.TOTP(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return apiErrInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+	step, ok := matchTOTP(totp.Secret, req.Code, s.now())
+	if !ok {
+		return apiErrInvalidCode
+	}
+	if err := s.store.AcceptTOTPStep(ctx, userID, step); err != nil {
+		if errors.Is(err, ErrCodeReplayed) {
+			return apiErrInvalidCode
+		}
+		return err
+	}
+	return nil
+}
+
+// handleLoginSecondFactor finishes a login the first factor started: it spends
+// one of the challenge's attempts, checks the code, and on success retires the
+// challenge and issues a second-factor session. The body is the user view, as
+// from POST /login.
+//
+// A wrong code leaves the challenge standing with one attempt fewer; an expired
+// or exhausted one answers two_factor_expired, and the only way on is to sign
+// in again.
+func (s *Service) handleLoginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	var req secondFactorRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if aerr := req.validate(); aerr != nil {
+		s.writeError(w, r, aerr)
+		return
+	}
+	ctx := r.Context()
+
+	cookie, err := r.Cookie(cookieLoginChallenge)
+	if err != nil {
+		s.writeError(w, r, apiErrTwoFactorExpired)
+		return
+	}
+	hash, err := hashToken(cookie.Value)
+	if err != nil {
+		s.expireFlowCookie(w, cookieLoginChallenge)
+		s.writeError(w, r, apiErrTwoFactorExpired)
+		return
+	}
+	userID, err := s.store.AttemptLoginChallenge(ctx, hash, loginChallengeAttempts)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.expireFlowCookie(w, cookieLoginChallenge)
+			s.writeError(w, r, apiErrTwoFactorExpired)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.checkSecondFactor(ctx, userID, req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.store.DeleteLoginChallenge(ctx, hash); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.expireFlowCookie(w, cookieLoginChallenge)
+	if err := s.issueSession(w, r, userID, true); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	user, err := s.store.User(ctx, userID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	user.TwoFactorSession = true
+	s.writeJSON(w, http.StatusOK, toUserView(user))
+}
+
+// twoFactorStatus is GET /2fa: what the settings screen shows.
+type twoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabledAt,omitempty"`
+	RecoveryCodesLeft int64      `json:"recoveryCodesLeft"`
+}
+
+func (s *Service) handleTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	status := twoFactorStatus{Enabled: user.TwoFactorEnabledAt != nil, EnabledAt: user.TwoFactorEnabledAt}
+	if status.Enabled {
+		left, err := s.store.RecoveryCodesLeft(r.Context(), user.ID)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		status.RecoveryCodesLeft = left
+	}
+	s.writeJSON(w, http.StatusOK, status)
+}
+
+// totpEnrolment is the enrol response: the secret twice over, as the URI a QR
+// code carries and as the text a user types when they cannot scan one.
+type totpEnrolment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// handleTOTPEnroll starts an enrolment: a fresh secret, stored unconfirmed. It
+// changes nothing about how the account signs in until handleTOTPConfirm sees a
+// code computed from it, so enrolling twice, or never confirming, is harmless.
+func (s *Service) handleTOTPEnroll(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	if user.TwoFactorEnabledAt != nil {
+		s.writeError(w, r, apiErrTwoFactorEnabled)
+		return
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.store.BeginTOTPEnrolment(r.Context(), user.ID, secret); err != nil {
+		if errors.Is(err, ErrTwoFactorEnabled) {
+			s.writeError(w, r, apiErrTwoFactorEnabled)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, totpEnrolment{
+		Secret:     totpEncoding.EncodeToString(secret),
+		OTPAuthURI: totpURI(secret, user.DisplayName),
+	})
+}
+
+// recoveryCodesView carries a freshly issued set. It is the only time the codes
+// are ever shown: only their hashes are kept.
+type recoveryCodesView struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// handleTOTPConfirm finishes an enrolment with a code from the new secret,
+// turns the second factor on, and issues the recovery codes.
+//
+// The session that confirmed does NOT become a second-factor session: it was
+// opened with one factor, and proving possession of a secret it was just handed
+// proves nothing about who opened it. The gated permissions arrive with the
+// next sign-in.
+func (s *Service) handleTOTPConfirm(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if user.TwoFactorEnabledAt != nil {
+		s.writeError(w, r, apiErrTwoFactorEnabled)
+		return
+	}
+	ctx := r.Context()
+	totp, err := s.store.TOTP(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.writeError(w, r, apiErrTwoFactorNotEnrolled)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	step, ok := matchTOTP(totp.Secret, req.Code, s.now())
+	if !ok {
+		s.writeError(w, r, apiErrInvalidCode)
+		return
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	switch err := s.store.EnableTwoFactor(ctx, user.ID, step, hashes); {
+	case errors.Is(err, ErrTwoFactorEnabled):
+		s.writeError(w, r, apiErrTwoFactorEnabled)
+		return
+	case errors.Is(err, ErrCodeReplayed):
+		s.writeError(w, r, apiErrInvalidCode)
+		return
+	case err != nil:
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, recoveryCodesView{RecoveryCodes: codes})
+}
+
+// handleRecoveryCodes replaces the recovery codes with a fresh set, on proof of
+// the second factor — every earlier code, used or not, stops working.
+func (s *Service) handleRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.secondFactorRequired(w, r)
+	if !ok {
+		return
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.store.ReplaceRecoveryCodes(r.Context(), user.ID, hashes); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, recoveryCodesView{RecoveryCodes: codes})
+}
+
+// handleTwoFactorDisable turns the second factor off, on proof of it. A session
+// cookie alone is not enough: turning it off is what a thief holding one would
+// want most.
+func (s *Service) handleTwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.secondFactorRequired(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.DisableTwoFactor(r.Context(), user.ID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, statusMessage("two-factor authentication is off"))
+}
+
+// secondFactorRequired is the shared front of the endpoints that change a
+// confirmed factor: the account must have one, and the body must prove it. On
+// false the response has been written.
+func (s *Service) secondFactorRequired(w http.ResponseWriter, r *http.Request) (User, bool) {
+	user, ok := UserFrom(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return User{}, false
+	}
+	var req secondFactorRequest
+	if !s.decodeJSON(w, r, &req) {
+		return User{}, false
+	}
+	if user.TwoFactorEnabledAt == nil {
+		s.writeError(w, r, apiErrTwoFactorNotEnabled)
+		return User{}, false
+	}
+	if aerr := req.validate(); aerr != nil {
+		s.writeError(w, r, aerr)
+		return User{}, false
+	}
+	if err := s.checkSecondFactor(r.Context(), user.ID, req); err != nil {
+		s.writeError(w, r, err)
+		return User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,353 @@
+package auth_test
+
+// TOTP two-factor authentication (internal/auth/twofactor.go, migration 00045)
+// end to end: enrolment and confirmation, the withheld session on both sign-in
+// paths, single use of codes and recovery codes, the attempt budget of a login
+// challenge, and the admin permissions that need a second-factor session.
+//
+// The codes are computed here from the secret the enrol response hands out,
+// independently of the package's own implementation (which totp_test.go checks
+// against the RFC). Each test rewinds user_totp.last_step before it needs a
+// fresh code, so a test never waits out a 30-second step.
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // the TOTP algorithm
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/auth"
+)
+
+// authenticatorCode is what an authenticator app shows for secret at t.
+func authenticatorCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	require.NoError(t, err)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1_000_000)
+}
+
+// freshCode forgets which step was last accepted for every account and returns
+// the current code, so it is accepted once more.
+func (h *harness) freshCode(secret string) string {
+	h.t.Helper()
+	_, err := h.pool.Exec(context.Background(), `UPDATE user_totp SET last_step = 0`)
+	require.NoError(h.t, err)
+	return authenticatorCode(h.t, secret, time.Now())
+}
+
+// enableTwoFactor enrols and confirms an authenticator for the signed-in
+// account, returning the secret and the recovery codes.
+func (h *harness) enableTwoFactor() (secret string, recovery []string) {
+	h.t.Helper()
+	resp := h.post(authBase+"/2fa/totp/enroll", nil)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	enrol := decodeInto[struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauthUri"`
+	}](h.t, resp)
+	require.NotEmpty(h.t, enrol.Secret)
+	require.Contains(h.t, enrol.OTPAuthURI, "secret="+enrol.Secret)
+
+	resp = h.post(authBase+"/2fa/totp/confirm", map[string]string{"code": h.freshCode(enrol.Secret)})
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	codes := decodeInto[struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}](h.t, resp)
+	require.Len(h.t, codes.RecoveryCodes, 10)
+	return enrol.Secret, codes.RecoveryCodes
+}
+
+func (h *harness) me() map[string]json.RawMessage {
+	h.t.Helper()
+	resp := h.get(mePath)
+	require.Equal(h.t, http.StatusOK, resp.StatusCode)
+	return decodeInto[map[string]json.RawMessage](h.t, resp)
+}
+
+func TestTwoFactorEnrolmentNeedsAConfirmingCode(t *testing.T) {
+	h := newHarness(t)
+	h.registerVerifyLogin("tf@example.com", "correct horse battery", "tf")
+
+	// Confirming with nothing enrolled.
+	resp := h.post(authBase+"/2fa/totp/confirm", map[string]string{"code": "123456"})
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "two_factor_not_enrolled", decodeInto[errResponse](t, resp).Error)
+
+	resp = h.post(authBase+"/2fa/totp/enroll", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	secret := decodeInto[struct {
+		Secret string `json:"secret"`
+	}](t, resp).Secret
+
+	// A wrong code confirms nothing; the account still signs in with one factor.
+	wrong := "000000"
+	if authenticatorCode(t, secret, time.Now()) == wrong {
+		wrong = "000001"
+	}
+	resp = h.post(authBase+"/2fa/totp/confirm", map[string]string{"code": wrong})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_code", decodeInto[errResponse](t, resp).Error)
+	assert.JSONEq(t, "false", string(h.me()["twoFactorEnabled"]))
+
+	// Enrolling again replaces the pending secret: the old one's codes stop
+	// confirming.
+	resp = h.post(authBase+"/2fa/totp/enroll", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	replaced := decodeInto[struct {
+		Secret string `json:"secret"`
+	}](t, resp).Secret
+	require.NotEqual(t, secret, replaced)
+	resp = h.post(authBase+"/2fa/totp/confirm", map[string]string{"code": authenticatorCode(t, secret, time.Now())})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = h.post(authBase+"/2fa/totp/confirm", map[string]string{"code": h.freshCode(replaced)})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, "true", string(h.me()["twoFactorEnabled"]))
+
+	// Once on, neither enrol nor confirm may swap the secret underneath it.
+	resp = h.post(authBase+"/2fa/totp/enroll", nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "two_factor_enabled", decodeInto[errResponse](t, resp).Error)
+
+	resp = h.get(authBase + "/2fa")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	status := decodeInto[struct {
+		Enabled           bool       `json:"enabled"`
+		EnabledAt         *time.Time `json:"enabledAt"`
+		RecoveryCodesLeft int        `json:"recoveryCodesLeft"`
+	}](t, resp)
+	assert.True(t, status.Enabled)
+	assert.NotNil(t, status.EnabledAt)
+	assert.Equal(t, 10, status.RecoveryCodesLeft)
+}
+
+func TestPasswordLoginWithholdsTheSessionUntilTheCode(t *testing.T) {
+	h := newHarness(t)
+	const email, password = "pw2fa@example.com", "correct horse battery"
+	h.registerVerifyLogin(email, password, "pw2fa")
+	secret, _ := h.enableTwoFactor()
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+
+	resp := h.login(email, password)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	pending := decodeInto[struct {
+		Status    string    `json:"status"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}](t, resp)
+	assert.Equal(t, "two_factor_required", pending.Status)
+	assert.True(t, pending.ExpiresAt.After(time.Now()))
+	requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+
+	// Exactly one of the two proofs.
+	resp = h.post(authBase+"/login/2fa", map[string]string{})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	code := h.freshCode(secret)
+	resp = h.post(authBase+"/login/2fa", map[string]string{"code": code})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "pw2fa", decodeInto[struct {
+		DisplayName string `json:"displayName"`
+	}](t, resp).DisplayName)
+	requireStatus(t, h.get(mePath), http.StatusOK)
+
+	// The challenge is spent, and so is the code: a second sign-in replaying
+	// it is refused even though it is still inside the window.
+	resp = h.post(authBase+"/login/2fa", map[string]string{"code": code})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "two_factor_expired", decodeInto[errResponse](t, resp).Error)
+
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.login(email, password), http.StatusAccepted)
+	resp = h.post(authBase+"/login/2fa", map[string]string{"code": code})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_code", decodeInto[errResponse](t, resp).Error,
+		"a code accepted once must not be accepted again")
+}
+
+func TestRecoveryCodesWorkOnceAndRegenerateAsASet(t *testing.T) {
+	h := newHarness(t)
+	const email, password = "rc@example.com", "correct horse battery"
+	h.registerVerifyLogin(email, password, "rc")
+	secret, recovery := h.enableTwoFactor()
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+
+	// Typed lower-case and without dashes: still the same code.
+	typed := strings.ToLower(strings.ReplaceAll(recovery[0], "-", ""))
+	requireStatus(t, h.login(email, password), http.StatusAccepted)
+	requireStatus(t, h.post(authBase+"/login/2fa", map[string]string{"recoveryCode": typed}), http.StatusOK)
+
+	resp := h.get(authBase + "/2fa")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 9, decodeInto[struct {
+		RecoveryCodesLeft int `json:"recoveryCodesLeft"`
+	}](t, resp).RecoveryCodesLeft)
+
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.login(email, password), http.StatusAccepted)
+	resp = h.post(authBase+"/login/2fa", map[string]string{"recoveryCode": recovery[0]})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_code", decodeInto[errResponse](t, resp).Error, "a used recovery code is spent")
+	requireStatus(t, h.post(authBase+"/login/2fa", map[string]string{"recoveryCode": recovery[1]}), http.StatusOK)
+
+	// Regenerating needs the second factor, and retires every earlier code.
+	resp = h.post(authBase+"/2fa/recovery-codes", map[string]string{})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_ = resp.Body.Close()
+	resp = h.post(authBase+"/2fa/recovery-codes", map[string]string{"code": h.freshCode(secret)})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	fresh := decodeInto[struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}](t, resp).RecoveryCodes
+	require.Len(t, fresh, 10)
+
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.login(email, password), http.StatusAccepted)
+	requireStatus(t, h.post(authBase+"/login/2fa", map[string]string{"recoveryCode": recovery[2]}), http.StatusUnauthorized)
+	requireStatus(t, h.post(authBase+"/login/2fa", map[string]string{"recoveryCode": fresh[0]}), http.StatusOK)
+}
+
+func TestLoginChallengeAllowsABoundedNumberOfAttempts(t *testing.T) {
+	h := newHarness(t)
+	const email, password = "guess@example.com", "correct horse battery"
+	h.registerVerifyLogin(email, password, "guess")
+	secret, _ := h.enableTwoFactor()
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+
+	// No challenge at all.
+	resp := h.post(authBase+"/login/2fa", map[string]string{"code": "123456"})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "two_factor_expired", decodeInto[errResponse](t, resp).Error)
+
+	requireStatus(t, h.login(email, password), http.StatusAccepted)
+	right := h.freshCode(secret)
+	wrong := fmt.Sprintf("%06d", (mustAtoi(t, right)+500_000)%1_000_000)
+	for i := 0; i < 5; i++ {
+		resp := h.post(authBase+"/login/2fa", map[string]string{"code": wrong})
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "invalid_code", decodeInto[errResponse](t, resp).Error, "attempt %d", i+1)
+	}
+	resp = h.post(authBase+"/login/2fa", map[string]string{"code": right})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "two_factor_expired", decodeInto[errResponse](t, resp).Error,
+		"an exhausted challenge must not accept even the right code: the password has to be proven again")
+	requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+
+	// Proving the password again buys a fresh budget.
+	requireStatus(t, h.login(email, password), http.StatusAccepted)
+	requireStatus(t, h.post(authBase+"/login/2fa", map[string]string{"code": right}), http.StatusOK)
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	var n int
+	_, err := fmt.Sscanf(s, "%d", &n)
+	require.NoError(t, err)
+	return n
+}
+
+func TestOAuthLoginAsksForTheSecondFactor(t *testing.T) {
+	fp := newFakeProvider(t)
+	h := newHarness(t, withProviders(fp.creds()))
+	h.oauthOnlyLogin(t, fp, "oauth-2fa")
+	secret, _ := h.enableTwoFactor()
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+
+	loc := h.oauthLogin(t, auth.ProviderGoogle)
+	assert.Contains(t, loc, "twoFactor=required")
+	requireStatus(t, h.get(mePath), http.StatusUnauthorized)
+
+	requireStatus(t, h.post(authBase+"/login/2fa", map[string]string{"code": h.freshCode(secret)}), http.StatusOK)
+	requireStatus(t, h.get(mePath), http.StatusOK)
+}
+
+func TestDisablingTwoFactorNeedsTheSecondFactor(t *testing.T) {
+	h := newHarness(t)
+	const email, password = "off@example.com", "correct horse battery"
+	h.registerVerifyLogin(email, password, "off")
+
+	resp := h.post(authBase+"/2fa/disable", map[string]string{"code": "123456"})
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "two_factor_not_enabled", decodeInto[errResponse](t, resp).Error)
+
+	secret, _ := h.enableTwoFactor()
+
+	// The session cookie alone does not turn it off.
+	requireStatus(t, h.post(authBase+"/2fa/disable", map[string]string{}), http.StatusBadRequest)
+	_, err := h.pool.Exec(context.Background(), `UPDATE user_totp SET last_step = 0`)
+	require.NoError(t, err)
+	wrong := fmt.Sprintf("%06d", (mustAtoi(t, authenticatorCode(t, secret, time.Now()))+500_000)%1_000_000)
+	requireStatus(t, h.post(authBase+"/2fa/disable", map[string]string{"code": wrong}), http.StatusUnauthorized)
+
+	requireStatus(t, h.post(authBase+"/2fa/disable", map[string]string{"code": h.freshCode(secret)}), http.StatusOK)
+	assert.JSONEq(t, "false", string(h.me()["twoFactorEnabled"]))
+
+	var rows int
+	require.NoError(t, h.pool.QueryRow(context.Background(),
+		`SELECT (SELECT count(*) FROM user_totp) + (SELECT count(*) FROM user_recovery_codes)`).Scan(&rows))
+	assert.Zero(t, rows, "turning it off drops the secret and the recovery codes")
+
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.login(email, password), http.StatusOK)
+}
+
+func TestGatedAdminPermissionsNeedASecondFactorSession(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+	const email, password = "admin2fa@example.com", "correct horse battery"
+	h.registerVerifyLogin(email, password, "admin2fa")
+	_, err := h.store.PromoteAdmins(ctx, []string{email})
+	require.NoError(t, err)
+
+	perms := func() []string {
+		var out []string
+		require.NoError(t, json.Unmarshal(h.me()["permissions"], &out))
+		return out
+	}
+	gated := []string{string(auth.PermBansWrite), string(auth.PermRunsOverride)}
+
+	got := perms()
+	assert.Contains(t, got, string(auth.PermBansRead))
+	assert.NotContains(t, got, gated[0])
+	assert.NotContains(t, got, gated[1])
+
+	// Enrolling from the one-factor session does not grant them to it: that is
+	// exactly what a stolen session would do.
+	secret, _ := h.enableTwoFactor()
+	got = perms()
+	assert.NotContains(t, got, gated[0])
+	assert.NotContains(t, got, gated[1])
+
+	requireStatus(t, h.post(authBase+"/logout", nil), http.StatusOK)
+	requireStatus(t, h.login(email, password), http.StatusAccepted)
+	resp := h.post(authBase+"/login/2fa", map[string]string{"code": h.freshCode(secret)})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	view := decodeInto[struct {
+		Permissions []string `json:"permissions"`
+	}](t, resp)
+	assert.Subset(t, view.Permissions, gated, "the login response already reflects the second-factor session")
+	assert.Subset(t, perms(), gated)
+
+	// Turning the factor off takes them away from this session too.
+	requireStatus(t, h.post(authBase+"/2fa/disable", map[string]string{"code": h.freshCode(secret)}), http.StatusOK)
+	got = perms()
+	assert.NotContains(t, got, gated[0])
+	assert.NotContains(t, got, gated[1])
+}
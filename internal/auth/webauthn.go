@@ -0,0 +1,531 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// The WebAuthn relying-party checks (W3C WebAuthn Level 2, §7.1 and §7.2),
+// written out here rather than taken from a library: the ceremony this server
+// runs is the narrow passkey profile — attestation "none", user verification
+// required, three signature algorithms — and all of it is a page of parsing
+// and three standard-library verify calls. A general WebAuthn library brings
+// attestation trust chains, TPM and Android formats and a JWT stack for
+// metadata, none of which a passkey-only relying party reads.
+//
+// Every failure below is errWebAuthn wrapped with what failed. The handlers
+// log the detail and answer passkey_rejected, whatever it was.
+
+var errWebAuthn = errors.New("webauthn: verification failed")
+
+func webauthnErrorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errWebAuthn, fmt.Sprintf(format, args...))
+}
+
+// COSE algorithm identifiers (IANA "COSE Algorithms") this relying party
+// accepts, in the preference order the creation options advertise. ES256 is
+// what platform authenticators and security keys produce; EdDSA covers newer
+// keys; RS256 is Windows Hello.
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+var webauthnAlgorithms = []int64{coseAlgES256, coseAlgEdDSA, coseAlgRS256}
+
+// Authenticator-data flags (§6.1).
+const (
+	authFlagUserPresent  = 0x01
+	authFlagUserVerified = 0x04
+	authFlagAttested     = 0x40
+	authFlagExtensions   = 0x80
+)
+
+// maxCredentialIDLen is the spec's bound on a credential id (§5.8.3).
+const maxCredentialIDLen = 1023
+
+// relyingParty is who the ceremonies are run for: the RP ID the credential is
+// scoped to (a registrable domain of the frontend's host) and the one origin
+// whose pages may run them.
+type relyingParty struct {
+	id     string
+	name   string
+	origin string
+}
+
+// webauthnB64 is the base64url-without-padding the browser uses for every
+// binary field of a ceremony: the challenge inside clientDataJSON, and the
+// fields of PublicKeyCredential.toJSON().
+var webauthnB64 = base64.RawURLEncoding
+
+// clientData is the part of CollectedClientData (§5.8.1) the checks read.
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// checkClientData verifies the browser's account of the ceremony: the kind
+// ("webauthn.create" or "webauthn.get"), the challenge we issued, and the
+// origin of the page that asked. The origin is what makes a passkey
+// phishing-proof — a look-alike site gets a different one written here by the
+// browser, not by the page.
+func (rp relyingParty) checkClientData(raw []byte, wantType string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return webauthnErrorf("client data is not JSON")
+	}
+	if cd.Type != wantType {
+		return webauthnErrorf("client data type %q, want %q", cd.Type, wantType)
+	}
+	got, err := webauthnB64.DecodeString(cd.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return webauthnErrorf("challenge mismatch")
+	}
+	if cd.Origin != rp.origin {
+		return webauthnErrorf("origin %q, want %q", cd.Origin, rp.origin)
+	}
+	if cd.CrossOrigin {
+		return webauthnErrorf("cross-origin ceremony")
+	}
+	return nil
+}
+
+// authenticatorData is a parsed authData (§6.1). credentialID and publicKey
+// are set only when the attested-credential flag is, i.e. on registration.
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (authenticatorData, error) {
+	const fixed = 32 + 1 + 4
+	if len(data) < fixed {
+		return authenticatorData{}, webauthnErrorf("authenticator data is %d bytes", len(data))
+	}
+	ad := authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[fixed:]
+	if ad.flags&authFlagAttested != 0 {
+		const aaguidLen = 16
+		if len(rest) < aaguidLen+2 {
+			return authenticatorData{}, webauthnErrorf("attested credential data truncated")
+		}
+		n := int(binary.BigEndian.Uint16(rest[aaguidLen:]))
+		rest = rest[aaguidLen+2:]
+		if n == 0 || n > maxCredentialIDLen || len(rest) < n {
+			return authenticatorData{}, webauthnErrorf("credential id length %d", n)
+		}
+		ad.credentialID, rest = rest[:n], rest[n:]
+		_, used, err := cborDecode(rest)
+		if err != nil {
+			return authenticatorData{}, webauthnErrorf("credential public key: %v", err)
+		}
+		ad.publicKey, rest = rest[:used], rest[used:]
+	}
+	if ad.flags&authFlagExtensions != 0 {
+		_, used, err := cborDecode(rest)
+		if err != nil {
+			return authenticatorData{}, webauthnErrorf("extensions: %v", err)
+		}
+		rest = rest[used:]
+	}
+	if len(rest) != 0 {
+		return authenticatorData{}, webauthnErrorf("%d trailing bytes in authenticator data", len(rest))
+	}
+	return ad, nil
+}
+
+// check verifies what every ceremony requires of authenticator data: it is
+// scoped to this RP ID, and the user was present AND verified — a PIN or a
+// biometric on the authenticator, which is what lets a passkey stand in for
+// the password rather than beside it.
+func (rp relyingParty) check(ad authenticatorData) error {
+	want := sha256.Sum256([]byte(rp.id))
+	if subtle.ConstantTimeCompare(ad.rpIDHash, want[:]) != 1 {
+		return webauthnErrorf("rp id hash mismatch")
+	}
+	if ad.flags&authFlagUserPresent == 0 {
+		return webauthnErrorf("user not present")
+	}
+	if ad.flags&authFlagUserVerified == 0 {
+		return webauthnErrorf("user not verified")
+	}
+	return nil
+}
+
+// registeredCredential is what a verified registration yields for storage.
+type registeredCredential struct {
+	id        []byte
+	publicKey []byte // COSE_Key, as the authenticator encoded it
+	signCount uint32
+}
+
+// verifyRegistration runs the registration checks (§7.1) on a credential's
+// clientDataJSON and attestationObject against the challenge the ceremony
+// issued.
+//
+// The attestation statement is not verified, whatever its format: the
+// creation options ask for attestation "none", browsers honour that by
+// stripping it, and a passkey relying party has no use for a trust chain to
+// the authenticator's maker — it makes no claim about what model the user
+// holds. What it does verify is everything that binds the new key to this
+// ceremony: the client data, the RP ID hash, the flags, and that the public
+// key is one it can use.
+func (rp relyingParty) verifyRegistration(challenge, clientDataJSON, attestationObject []byte) (registeredCredential, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return registeredCredential{}, err
+	}
+	obj, used, err := cborDecode(attestationObject)
+	if err != nil || used != len(attestationObject) {
+		return registeredCredential{}, webauthnErrorf("attestation object is not CBOR")
+	}
+	m, ok := obj.(map[any]any)
+	if !ok {
+		return registeredCredential{}, webauthnErrorf("attestation object is not a map")
+	}
+	raw, ok := m["authData"].([]byte)
+	if !ok {
+		return registeredCredential{}, webauthnErrorf("attestation object has no authData")
+	}
+	ad, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return registeredCredential{}, err
+	}
+	if err := rp.check(ad); err != nil {
+		return registeredCredential{}, err
+	}
+	if ad.credentialID == nil {
+		return registeredCredential{}, webauthnErrorf("no attested credential data")
+	}
+	if _, err := parseCOSEKey(ad.publicKey); err != nil {
+		return registeredCredential{}, err
+	}
+	return registeredCredential{id: ad.credentialID, publicKey: ad.publicKey, signCount: ad.signCount}, nil
+}
+
+// verifyAssertion runs the authentication checks (§7.2) on an assertion made
+// with the stored COSE publicKey, returning the authenticator's signature
+// counter for the caller to record. Matching the credential to an account,
+// and the counter to the stored one, are the caller's.
+func (rp relyingParty) verifyAssertion(challenge, clientDataJSON, authData, signature, publicKey []byte) (uint32, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	ad, err := parseAuthenticatorData(authData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.check(ad); err != nil {
+		return 0, err
+	}
+	key, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientHash := sha256.Sum256(clientDataJSON)
+	signed := make([]byte, 0, len(authData)+len(clientHash))
+	signed = append(append(signed, authData...), clientHash[:]...)
+	if !key.verify(signed, signature) {
+		return 0, webauthnErrorf("bad signature")
+	}
+	return ad.signCount, nil
+}
+
+// coseKey is a credential public key decoded from its COSE_Key form (RFC
+// 9053), restricted to webauthnAlgorithms.
+type coseKey struct {
+	alg int64
+	ec  *ecdsa.PublicKey
+	ed  ed25519.PublicKey
+	rsa *rsa.PublicKey
+}
+
+// COSE_Key labels (RFC 9052 §7, RFC 9053 §7). The negative labels mean
+// different things per key type: crv/x/y for EC2 and OKP, n/e for RSA.
+const (
+	coseKty = 1
+	coseAlg = 3
+	coseCrv = -1
+	coseX   = -2
+	coseY   = -3
+	coseN   = -1
+	coseE   = -2
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// minRSABits refuses RSA keys too short to sign with today.
+const minRSABits = 2048
+
+func parseCOSEKey(raw []byte) (coseKey, error) {
+	v, used, err := cborDecode(raw)
+	if err != nil || used != len(raw) {
+		return coseKey{}, webauthnErrorf("public key is not CBOR")
+	}
+	m, ok := v.(map[any]any)
+	if !ok {
+		return coseKey{}, webauthnErrorf("public key is not a map")
+	}
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+	bytesAt := func(label int64) []byte {
+		b, _ := m[label].([]byte)
+		return b
+	}
+
+	switch {
+	case kty == coseKtyEC2 && alg == coseAlgES256:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, y := bytesAt(coseX), bytesAt(coseY)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return coseKey{}, webauthnErrorf("malformed P-256 key")
+		}
+		point := append(append([]byte{0x04}, x...), y...)
+		pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return coseKey{}, webauthnErrorf("P-256 key: %v", err)
+		}
+		return coseKey{alg: alg, ec: pub}, nil
+
+	case kty == coseKtyOKP && alg == coseAlgEdDSA:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x := bytesAt(coseX)
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return coseKey{}, webauthnErrorf("malformed Ed25519 key")
+		}
+		return coseKey{alg: alg, ed: ed25519.PublicKey(x)}, nil
+
+	case kty == coseKtyRSA && alg == coseAlgRS256:
+		n, e := bytesAt(coseN), bytesAt(coseE)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return coseKey{}, webauthnErrorf("malformed RSA key")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < minRSABits || pub.E < 3 || pub.E%2 == 0 {
+			return coseKey{}, webauthnErrorf("unacceptable RSA key")
+		}
+		return coseKey{alg: alg, rsa: pub}, nil
+	}
+	return coseKey{}, webauthnErrorf("unsupported key type %d / algorithm %d", kty, alg)
+}
+
+func (k coseKey) verify(signed, sig []byte) bool {
+	switch k.alg {
+	case coseAlgES256:
+		digest := sha256.Sum256(signed)
+		return ecdsa.VerifyASN1(k.ec, digest[:], sig)
+	case coseAlgEdDSA:
+		return ed25519.Verify(k.ed, signed, sig)
+	case coseAlgRS256:
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(k.rsa, crypto.SHA256, digest[:], sig) == nil
+	}
+	return false
+}
+
+// --- CBOR ---
+//
+// A decoder for the subset of CBOR (RFC 8949) WebAuthn puts on the wire: the
+// attestation object, the COSE key and the extension map. CTAP2 requires
+// definite lengths, so indefinite ones are refused; tags never appear and are
+// refused too. Values decode to int64, []byte, string, []any, map[any]any
+// (keys int64 or string), bool, float64 and nil.
+//
+// The input is attacker-supplied, so every length is checked against the
+// bytes actually remaining before anything is allocated, and nesting is
+// bounded.
+
+const cborMaxDepth = 16
+
+// cborDecode decodes the first CBOR item in data and reports how many bytes it
+// took. Trailing bytes are the caller's business: authenticator data carries
+// the credential key and the extensions back to back.
+func cborDecode(data []byte) (any, int, error) {
+	d := cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, d.pos, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+var errCBOR = errors.New("malformed CBOR")
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > cborMaxDepth {
+		return nil, errCBOR
+	}
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return -1 - int64(arg), nil
+	case 2, 3:
+		b, err := d.take(arg)
+		if err != nil {
+			return nil, err
+		}
+		if major == 3 {
+			return string(b), nil
+		}
+		return bytes.Clone(b), nil
+	case 4:
+		// Every item is at least one byte, which bounds the count by what is
+		// left before the slice is made.
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBOR
+		}
+		out := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case 5:
+		if arg > uint64(len(d.data)-d.pos)/2 {
+			return nil, errCBOR
+		}
+		out := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, errCBOR
+			}
+			if _, dup := out[k]; dup {
+				return nil, errCBOR
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = v
+		}
+		return out, nil
+	case 7:
+		return cborSimple(info, arg)
+	}
+	return nil, errCBOR // major 6: tags
+}
+
+// head reads an item's initial byte: the major type, the additional
+// information, and the argument it encodes (a length, a value, or for major
+// type 7 a simple value or a float's raw bits).
+func (d *cborDecoder) head() (major, info byte, arg uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, errCBOR
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		raw, err := d.take(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for _, c := range raw {
+			arg = arg<<8 | uint64(c)
+		}
+		return major, info, arg, nil
+	}
+	return 0, 0, 0, errCBOR // reserved, or indefinite length
+}
+
+// cborSimple decodes a major-type-7 item from its head.
+func cborSimple(info byte, arg uint64) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfToFloat64(uint16(arg)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case 27:
+		return math.Float64frombits(arg), nil
+	}
+	return nil, errCBOR
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errCBOR
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// halfToFloat64 widens an IEEE 754 half-precision float.
+func halfToFloat64(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp, frac := int(h>>10)&0x1f, float64(h&0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 0x1f:
+		if frac == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(frac+1024, exp-25)
+}
@@ -0,0 +1,415 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests are internal to the package because the verifier is unexported.
+// They check it against a software authenticator built from the spec's byte
+// layouts, not against the verifier's own parsing: SoftAuthenticator encodes
+// what a browser and an authenticator would send, and the HTTP flows in
+// passkeys_test.go drive the same authenticator through the API.
+
+func testRP() relyingParty {
+	return relyingParty{id: "localhost", name: passkeyRPName, origin: "http://localhost:5173"}
+}
+
+func TestRegistrationAndAssertionRoundTrip(t *testing.T) {
+	rp := testRP()
+	a := NewSoftAuthenticator(t, rp.id, rp.origin)
+
+	challenge := []byte("registration-challenge-32-bytes!")
+	clientData, attObj := a.Register(challenge)
+	cred, err := rp.verifyRegistration(challenge, clientData, attObj)
+	require.NoError(t, err)
+	assert.Equal(t, a.CredentialID, cred.id)
+	assert.Equal(t, a.SignCount, cred.signCount)
+
+	challenge = []byte("assertion-challenge-of-32-bytes!")
+	clientData, authData, sig := a.Assert(challenge)
+	count, err := rp.verifyAssertion(challenge, clientData, authData, sig, cred.publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, a.SignCount, count)
+}
+
+func TestRegistrationRejections(t *testing.T) {
+	rp := testRP()
+	challenge := []byte("registration-challenge-32-bytes!")
+
+	for _, tc := range []struct {
+		name   string
+		mutate func(a *SoftAuthenticator)
+		chal   []byte
+	}{
+		{name: "other origin", mutate: func(a *SoftAuthenticator) { a.Origin = "https://typem0re.example" }},
+		{name: "cross origin", mutate: func(a *SoftAuthenticator) { a.CrossOrigin = true }},
+		{name: "other rp id", mutate: func(a *SoftAuthenticator) { a.RPID = "typem0re.example" }},
+		{name: "user not verified", mutate: func(a *SoftAuthenticator) { a.Flags = authFlagUserPresent }},
+		{name: "user not present", mutate: func(a *SoftAuthenticator) { a.Flags = authFlagUserVerified }},
+		{name: "wrong challenge", chal: []byte("some-other-challenge")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewSoftAuthenticator(t, rp.id, rp.origin)
+			if tc.mutate != nil {
+				tc.mutate(a)
+			}
+			clientData, attObj := a.Register(challenge)
+			want := challenge
+			if tc.chal != nil {
+				want = tc.chal
+			}
+			_, err := rp.verifyRegistration(want, clientData, attObj)
+			assert.ErrorIs(t, err, errWebAuthn)
+		})
+	}
+
+	// A sign-in's client data does not register a credential.
+	a := NewSoftAuthenticator(t, rp.id, rp.origin)
+	_, attObj := a.Register(challenge)
+	getData, _, _ := a.Assert(challenge)
+	_, err := rp.verifyRegistration(challenge, getData, attObj)
+	assert.ErrorIs(t, err, errWebAuthn)
+}
+
+func TestAssertionRejections(t *testing.T) {
+	rp := testRP()
+	a := NewSoftAuthenticator(t, rp.id, rp.origin)
+	clientData, attObj := a.Register([]byte("registration-challenge"))
+	cred, err := rp.verifyRegistration([]byte("registration-challenge"), clientData, attObj)
+	require.NoError(t, err)
+	challenge := []byte("assertion-challenge")
+
+	t.Run("tampered signature", func(t *testing.T) {
+		clientData, authData, sig := a.Assert(challenge)
+		sig[len(sig)-1] ^= 0xff
+		_, err := rp.verifyAssertion(challenge, clientData, authData, sig, cred.publicKey)
+		assert.ErrorIs(t, err, errWebAuthn)
+	})
+	t.Run("authenticator data not the signed one", func(t *testing.T) {
+		clientData, authData, sig := a.Assert(challenge)
+		authData[33+3]++ // the counter
+		_, err := rp.verifyAssertion(challenge, clientData, authData, sig, cred.publicKey)
+		assert.ErrorIs(t, err, errWebAuthn)
+	})
+	t.Run("another credential's key", func(t *testing.T) {
+		other := NewSoftAuthenticator(t, rp.id, rp.origin)
+		clientData, authData, sig := other.Assert(challenge)
+		_, err := rp.verifyAssertion(challenge, clientData, authData, sig, cred.publicKey)
+		assert.ErrorIs(t, err, errWebAuthn)
+	})
+	t.Run("stale challenge", func(t *testing.T) {
+		clientData, authData, sig := a.Assert([]byte("an-earlier-challenge"))
+		_, err := rp.verifyAssertion(challenge, clientData, authData, sig, cred.publicKey)
+		assert.ErrorIs(t, err, errWebAuthn)
+	})
+	t.Run("registration client data", func(t *testing.T) {
+		_, authData, _ := a.Assert(challenge)
+		clientData := a.clientData("webauthn.create", challenge)
+		sig := a.sign(authData, clientData)
+		_, err := rp.verifyAssertion(challenge, clientData, authData, sig, cred.publicKey)
+		assert.ErrorIs(t, err, errWebAuthn)
+	})
+}
+
+func TestParseAuthenticatorDataBounds(t *testing.T) {
+	_, err := parseAuthenticatorData(make([]byte, 36))
+	assert.ErrorIs(t, err, errWebAuthn, "shorter than the fixed header")
+
+	fixed := make([]byte, 37)
+	_, err = parseAuthenticatorData(append(fixed, 0x00))
+	assert.ErrorIs(t, err, errWebAuthn, "trailing bytes")
+
+	attested := append([]byte{}, fixed...)
+	attested[32] = authFlagAttested
+	attested = append(attested, make([]byte, 16)...)        // AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, 900) // claims more id than is there
+	attested = append(attested, make([]byte, 10)...)
+	_, err = parseAuthenticatorData(attested)
+	assert.ErrorIs(t, err, errWebAuthn, "credential id overruns the data")
+
+	ext := append([]byte{}, fixed...)
+	ext[32] = authFlagExtensions
+	ext = append(ext, cborEncode(cborMap{{"credProtect", int64(2)}})...)
+	ad, err := parseAuthenticatorData(ext)
+	require.NoError(t, err, "extensions are skipped, not refused")
+	assert.Nil(t, ad.credentialID)
+}
+
+func TestParseCOSEKey(t *testing.T) {
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	k, err := parseCOSEKey(coseES256(&ec.PublicKey))
+	require.NoError(t, err)
+	assert.True(t, k.ec.Equal(&ec.PublicKey))
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edKey := cborEncode(cborMap{
+		{int64(coseKty), int64(coseKtyOKP)}, {int64(coseAlg), int64(coseAlgEdDSA)},
+		{int64(coseCrv), int64(coseCrvEd25519)}, {int64(coseX), []byte(edPub)},
+	})
+	k, err = parseCOSEKey(edKey)
+	require.NoError(t, err)
+	assert.True(t, k.verify([]byte("msg"), ed25519.Sign(edPriv, []byte("msg"))))
+
+	rsaKey := func(bits int) []byte {
+		priv, err := rsa.GenerateKey(rand.Reader, bits)
+		require.NoError(t, err)
+		return cborEncode(cborMap{
+			{int64(coseKty), int64(coseKtyRSA)}, {int64(coseAlg), int64(coseAlgRS256)},
+			{int64(coseN), priv.N.Bytes()}, {int64(coseE), big.NewInt(int64(priv.E)).Bytes()},
+		})
+	}
+	_, err = parseCOSEKey(rsaKey(2048))
+	assert.NoError(t, err)
+	_, err = parseCOSEKey(rsaKey(1024))
+	assert.ErrorIs(t, err, errWebAuthn, "short RSA keys are refused")
+
+	// A P-256 point that is not on the curve.
+	bad := cborEncode(cborMap{
+		{int64(coseKty), int64(coseKtyEC2)}, {int64(coseAlg), int64(coseAlgES256)},
+		{int64(coseCrv), int64(coseCrvP256)}, {int64(coseX), make([]byte, 32)}, {int64(coseY), make([]byte, 32)},
+	})
+	_, err = parseCOSEKey(bad)
+	assert.ErrorIs(t, err, errWebAuthn)
+
+	// ES256 named on an EdDSA key type, and an algorithm nobody advertised.
+	_, err = parseCOSEKey(cborEncode(cborMap{{int64(coseKty), int64(coseKtyOKP)}, {int64(coseAlg), int64(coseAlgES256)}}))
+	assert.ErrorIs(t, err, errWebAuthn)
+	_, err = parseCOSEKey(cborEncode(cborMap{{int64(coseKty), int64(coseKtyEC2)}, {int64(coseAlg), int64(-35)}}))
+	assert.ErrorIs(t, err, errWebAuthn)
+}
+
+func TestCBORDecode(t *testing.T) {
+	v, n, err := cborDecode(cborEncode(cborMap{
+		{"a", []any{int64(1), int64(-1), "x", []byte{0xff}, true, nil}},
+		{int64(-7), cborMap{{"nested", false}}},
+	}))
+	require.NoError(t, err)
+	assert.Greater(t, n, 0)
+	assert.Equal(t, map[any]any{
+		"a":       []any{int64(1), int64(-1), "x", []byte{0xff}, true, nil},
+		int64(-7): map[any]any{"nested": false},
+	}, v)
+
+	// Floats in all three widths: 1.5 as half, single and double.
+	for _, enc := range [][]byte{
+		{0xf9, 0x3e, 0x00},
+		{0xfa, 0x3f, 0xc0, 0x00, 0x00},
+		{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0},
+	} {
+		v, _, err := cborDecode(enc)
+		require.NoError(t, err)
+		assert.Equal(t, 1.5, v)
+	}
+	assert.Equal(t, math.Inf(-1), halfToFloat64(0xfc00))
+	assert.Equal(t, 5.960464477539063e-08, halfToFloat64(0x0001), "subnormal")
+
+	// The trailing bytes are reported, not consumed.
+	_, n, err = cborDecode([]byte{0x01, 0x02})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	deep := make([]byte, 0, cborMaxDepth+2)
+	for range cborMaxDepth + 2 {
+		deep = append(deep, 0x81) // array of one
+	}
+	deep = append(deep, 0x00)
+
+	for name, enc := range map[string][]byte{
+		"empty":               {},
+		"truncated length":    {0x59, 0x01},
+		"string past the end": {0x45, 'a', 'b'},
+		"array count too big": {0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"map count too big":   {0xbb, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"indefinite length":   {0x5f, 0x41, 'a', 0xff},
+		"tag":                 {0xc0, 0x60},
+		"uint past int64":     {0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"duplicate map key":   {0xa2, 0x01, 0x00, 0x01, 0x00},
+		"byte-string map key": {0xa1, 0x41, 'k', 0x00},
+		"undefined simple":    {0xf8, 0x20},
+		"too deep":            deep,
+	} {
+		_, _, err := cborDecode(enc)
+		assert.Error(t, err, name)
+	}
+}
+
+// --- software authenticator ---
+//
+// SoftAuthenticator is an ES256 platform authenticator in a struct, together
+// with the browser's half of the ceremony (the client data). It is exported
+// from this in-package test file so passkeys_test.go, in package auth_test,
+// can run the HTTP ceremonies with it. Every field is what a real one would
+// send; the tests change them to be what a real one must not.
+type SoftAuthenticator struct {
+	RPID         string
+	Origin       string
+	CrossOrigin  bool
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+
+	key *ecdsa.PrivateKey
+}
+
+func NewSoftAuthenticator(t testing.TB, rpID, origin string) *SoftAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	return &SoftAuthenticator{
+		RPID:         rpID,
+		Origin:       origin,
+		Flags:        authFlagUserPresent | authFlagUserVerified,
+		SignCount:    1,
+		CredentialID: id,
+		key:          key,
+	}
+}
+
+// Register answers navigator.credentials.create: the clientDataJSON and an
+// attestationObject in the "none" format.
+func (a *SoftAuthenticator) Register(challenge []byte) (clientDataJSON, attestationObject []byte) {
+	authData := a.authData(a.Flags | authFlagAttested)
+	authData = append(authData, make([]byte, 16)...) // AAGUID: none
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.CredentialID)))
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, coseES256(&a.key.PublicKey)...)
+	return a.clientData("webauthn.create", challenge), cborEncode(cborMap{
+		{"fmt", "none"}, {"attStmt", cborMap{}}, {"authData", authData},
+	})
+}
+
+// Assert answers navigator.credentials.get, advancing the signature counter
+// the way a hardware authenticator does. A synced passkey that reports 0
+// forever is SignCount = 0 before the first call to Assert.
+func (a *SoftAuthenticator) Assert(challenge []byte) (clientDataJSON, authenticatorData, signature []byte) {
+	if a.SignCount != 0 {
+		a.SignCount++
+	}
+	authData := a.authData(a.Flags)
+	clientData := a.clientData("webauthn.get", challenge)
+	return clientData, authData, a.sign(authData, clientData)
+}
+
+func (a *SoftAuthenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	out := append([]byte{}, rpIDHash[:]...)
+	out = append(out, flags)
+	return binary.BigEndian.AppendUint32(out, a.SignCount)
+}
+
+func (a *SoftAuthenticator) clientData(typ string, challenge []byte) []byte {
+	b, err := json.Marshal(map[string]any{
+		"type":        typ,
+		"challenge":   webauthnB64.EncodeToString(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": a.CrossOrigin,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (a *SoftAuthenticator) sign(authData, clientData []byte) []byte {
+	clientHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func coseES256(pub *ecdsa.PublicKey) []byte {
+	point, err := pub.Bytes()
+	if err != nil {
+		panic(err)
+	}
+	return cborEncode(cborMap{
+		{int64(coseKty), int64(coseKtyEC2)}, {int64(coseAlg), int64(coseAlgES256)},
+		{int64(coseCrv), int64(coseCrvP256)}, {int64(coseX), point[1:33]}, {int64(coseY), point[33:]},
+	})
+}
+
+// cborMap is a CBOR map with its keys in the order to encode them.
+type cborMap [][2]any
+
+// cborEncode writes the values the decoder reads, with CTAP2's canonical key
+// order for maps (shorter encodings first, then bytewise) so the output is
+// what an authenticator would produce.
+func cborEncode(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= math.MaxUint8:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= math.MaxUint16:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		case n <= math.MaxUint32:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+	}
+	switch v := v.(type) {
+	case int64:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		out := head(4, uint64(len(v)))
+		for _, e := range v {
+			out = append(out, cborEncode(e)...)
+		}
+		return out
+	case cborMap:
+		type pair struct{ k, v []byte }
+		pairs := make([]pair, len(v))
+		for i, kv := range v {
+			pairs[i] = pair{cborEncode(kv[0]), cborEncode(kv[1])}
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			if len(pairs[i].k) != len(pairs[j].k) {
+				return len(pairs[i].k) < len(pairs[j].k)
+			}
+			return string(pairs[i].k) < string(pairs[j].k)
+		})
+		out := head(5, uint64(len(v)))
+		for _, p := range pairs {
+			out = append(append(out, p.k...), p.v...)
+		}
+		return out
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case nil:
+		return []byte{0xf6}
+	}
+	panic("cborEncode: unsupported type")
+}
@@ -0,0 +1,105 @@
+package daily
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Modes a challenge can have. ModeTime and ModeWords are the ranked seeded
+// modes; ModeQuote is a quote of the day, whose text and length are the quote's.
+const (
+	ModeTime  = "time"
+	ModeWords = "words"
+	ModeQuote = "quote"
+)
+
+// DayLayout is how a day is spelled on the wire, in `setup.daily`, and in the
+// day's board key.
+const DayLayout = "2006-01-02"
+
+// Shape is one ranked (mode, size) a seeded challenge may be set at. The list
+// is the leaderboard's ranked set, handed in by the composition root: a
+// challenge at a size no board ranks would be a day with an empty board.
+type Shape struct {
+	Mode string
+	// Size is milliseconds for ModeTime and words for ModeWords.
+	Size int32
+}
+
+// Mods are the mods that shape the TEXT, and therefore the ones a challenge
+// pins. The rest — difficulty, blind, nospace and the like — stay the player's
+// choice and multiply their score, exactly as on every other board.
+type Mods struct {
+	Punctuation bool `json:"punctuation"`
+	Numbers     bool `json:"numbers"`
+	RandomCase  bool `json:"randomCase"`
+	Reverse     bool `json:"reverse"`
+}
+
+// Challenge is one day's challenge. A seeded day sets Mode, exactly one of
+// DurationMs / WordCount, Lang, Seed and Mods; a quote day sets QuoteID and
+// Mode = ModeQuote and nothing else, because the quote is the text.
+type Challenge struct {
+	Day        time.Time
+	Mode       string
+	DurationMs *int32
+	WordCount  *int32
+	Lang       string
+	Seed       int64
+	Mods       Mods
+	QuoteID    *uuid.UUID
+}
+
+// IsQuote reports whether this is a quote of the day.
+func (c Challenge) IsQuote() bool { return c.QuoteID != nil }
+
+// Derivation is the day's draw, before the quote corpus has been asked: the
+// seeded challenge the day gets, and whether it is a quote day instead. A quote
+// day whose language has no quote to draw falls back to Seeded.
+type Derivation struct {
+	Seeded   Challenge
+	QuoteDay bool
+}
+
+// Derive draws a day's challenge from a salted hash of its date. It is a pure
+// function of its arguments — the same day under the same config is the same
+// challenge on every instance and on every restart — which is what lets the
+// scheduler and a lazy read both freeze a day without agreeing on anything but
+// the config.
+//
+// Each decision reads its own slice of the digest, so no two are correlated:
+// the quote-day roll, the language, the shape, the seed and the mod bits.
+// The salt is what keeps tomorrow from being computable out of this source
+// file; a deployment that leaves it empty gets challenges anyone can predict.
+func Derive(day time.Time, cfg Config) Derivation {
+	h := sha256.Sum256([]byte(cfg.Salt + "|" + day.Format(DayLayout)))
+	word := func(i int) uint32 { return binary.BigEndian.Uint32(h[4*i : 4*i+4]) }
+
+	c := Challenge{Day: day}
+	c.Lang = cfg.Langs[word(1)%uint32(len(cfg.Langs))]
+	shape := cfg.Shapes[word(2)%uint32(len(cfg.Shapes))]
+	c.Mode = shape.Mode
+	size := shape.Size
+	if shape.Mode == ModeTime {
+		c.DurationMs = &size
+	} else {
+		c.WordCount = &size
+	}
+	// The whole 32-bit word: the seed range IS 32 bits (mulberry32,
+	// PROTOCOL.md §4), and ingestion refuses anything outside it.
+	c.Seed = int64(word(3))
+	c.Mods = Mods{Punctuation: h[16]&1 != 0, Numbers: h[16]&2 != 0}
+
+	return Derivation{
+		Seeded:   c,
+		QuoteDay: cfg.QuoteEvery > 0 && word(0)%uint32(cfg.QuoteEvery) == 0,
+	}
+}
+
+// dayOf truncates an instant to the UTC day it falls in.
+func dayOf(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,101 @@
+package daily_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/daily"
+)
+
+var testShapes = []daily.Shape{
+	{Mode: daily.ModeTime, Size: 15_000}, {Mode: daily.ModeTime, Size: 30_000},
+	{Mode: daily.ModeTime, Size: 60_000}, {Mode: daily.ModeWords, Size: 25},
+	{Mode: daily.ModeWords, Size: 50}, {Mode: daily.ModeWords, Size: 100},
+}
+
+func testConfig() daily.Config {
+	return daily.Config{
+		Salt: "test-salt", Langs: []string{"en", "german", "ru"}, Shapes: testShapes,
+		QuoteEvery: 4, Grace: 15 * time.Minute,
+	}
+}
+
+func day(s string) time.Time {
+	d, err := time.Parse(daily.DayLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Every instance, on every restart, must draw the same day the same way: the
+// scheduler and a lazy read race to freeze it, and only agreement makes that
+// race harmless.
+func TestDeriveIsAPureFunctionOfDayAndConfig(t *testing.T) {
+	d := day("2026-10-17")
+	assert.Equal(t, daily.Derive(d, testConfig()), daily.Derive(d, testConfig()))
+	assert.NotEqual(t, daily.Derive(d, testConfig()), daily.Derive(day("2026-10-18"), testConfig()))
+
+	salted := testConfig()
+	salted.Salt = "rotated"
+	assert.NotEqual(t, daily.Derive(d, testConfig()).Seeded.Seed, daily.Derive(d, salted).Seeded.Seed,
+		"the salt is what keeps a day unpredictable from the source")
+}
+
+// A drawn challenge must be one the rest of the server accepts: a ranked shape
+// with exactly one dimension, a configured language, and a seed ingestion
+// would not refuse.
+func TestDeriveDrawsOnlyPlayableChallenges(t *testing.T) {
+	cfg := testConfig()
+	langs := map[string]bool{}
+	shapes := map[daily.Shape]bool{}
+	start := day("2026-01-01")
+	for i := range 730 {
+		c := daily.Derive(start.AddDate(0, 0, i), cfg).Seeded
+		require.Contains(t, cfg.Langs, c.Lang)
+		require.GreaterOrEqual(t, c.Seed, int64(0))
+		require.LessOrEqual(t, c.Seed, int64(1<<32-1))
+		require.Nil(t, c.QuoteID)
+		require.False(t, c.Mods.RandomCase || c.Mods.Reverse, "only punctuation and numbers are drawn")
+
+		var s daily.Shape
+		switch c.Mode {
+		case daily.ModeTime:
+			require.NotNil(t, c.DurationMs)
+			require.Nil(t, c.WordCount)
+			s = daily.Shape{Mode: c.Mode, Size: *c.DurationMs}
+		case daily.ModeWords:
+			require.NotNil(t, c.WordCount)
+			require.Nil(t, c.DurationMs)
+			s = daily.Shape{Mode: c.Mode, Size: *c.WordCount}
+		default:
+			t.Fatalf("drew mode %q", c.Mode)
+		}
+		require.Contains(t, cfg.Shapes, s)
+		langs[c.Lang], shapes[s] = true, true
+	}
+	// Two years of days reach every option; a draw that never does is biased.
+	assert.Len(t, langs, len(cfg.Langs))
+	assert.Len(t, shapes, len(cfg.Shapes))
+}
+
+func TestQuoteEveryControlsHowOftenADayIsAQuote(t *testing.T) {
+	count := func(every int) int {
+		cfg := testConfig()
+		cfg.QuoteEvery = every
+		n := 0
+		start := day("2026-01-01")
+		for i := range 400 {
+			if daily.Derive(start.AddDate(0, 0, i), cfg).QuoteDay {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Zero(t, count(0), "zero turns quote days off")
+	assert.Equal(t, 400, count(1))
+	assert.InDelta(t, 100, count(4), 35, "about one day in four")
+}
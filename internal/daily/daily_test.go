@@ -0,0 +1,232 @@
+package daily_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/daily"
+)
+
+// seededSetup is a run's setup document with the text-shaping mods set as
+// given, and `daily` declaring the day.
+func seededSetup(day string, punctuation, numbers bool) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+  "daily": %q,
+  "config":      {"mode":"time","difficulty":"expert","nospace":false,"minWpm":0},
+  "generation":  {"mode":"time","punctuation":%t,"numbers":%t,"randomCase":false,"reverse":false},
+  "declaration": {"blind":true,"fading":false,"flashlight":false}
+}`, day, punctuation, numbers))
+}
+
+// attemptAt builds an attempt that IS c, so a test can spoil one field at a time.
+func attemptAt(c daily.Challenge) daily.Attempt {
+	return daily.Attempt{
+		Day: c.Day, Mode: c.Mode, DurationMs: c.DurationMs, WordCount: c.WordCount,
+		Lang: c.Lang, Seed: c.Seed,
+		Setup: seededSetup(c.Day.Format(daily.DayLayout), c.Mods.Punctuation, c.Mods.Numbers),
+	}
+}
+
+// The stored row is the authority. A config change after a day is frozen —
+// here, a rotated salt — must not re-draw it: people have played it.
+func TestAFrozenDayIsNeverRedrawn(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuoteEvery = 0
+	h := newChallenged(t, cfg)
+	ctx := context.Background()
+	d := day("2026-10-17")
+
+	first, err := h.svc.Challenge(ctx, d)
+	require.NoError(t, err)
+	assert.Equal(t, daily.Derive(d, cfg).Seeded, first)
+
+	rotated := cfg
+	rotated.Salt = "rotated"
+	again, err := daily.NewService(h.store, nil, h.boards, rotated, nil).Challenge(ctx, d)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	// Freezing is first-writer-wins, not last: a second instance racing with a
+	// different draw gets the stored one back.
+	other := daily.Derive(d, rotated).Seeded
+	got, err := h.store.Freeze(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+}
+
+func TestAQuoteDayDrawsFromTheCorpusAndFallsBackWithout(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuoteEvery = 1 // every day is a quote day
+	h := newChallenged(t, cfg)
+	ctx := context.Background()
+
+	// Nothing to draw: the day is still a day, seeded.
+	empty, err := h.svc.Challenge(ctx, day("2026-10-17"))
+	require.NoError(t, err)
+	assert.False(t, empty.IsQuote())
+	assert.Equal(t, daily.Derive(day("2026-10-17"), cfg).Seeded, empty)
+
+	h.quotes.id = h.quote("en")
+	c, err := h.svc.Challenge(ctx, day("2026-10-18"))
+	require.NoError(t, err)
+	require.True(t, c.IsQuote())
+	assert.Equal(t, h.quotes.id, *c.QuoteID)
+	assert.Equal(t, daily.ModeQuote, c.Mode)
+	assert.Empty(t, c.Lang, "a quote day carries the quote and nothing else")
+
+	// Stored days are read, not re-drawn: the corpus is asked once per day.
+	picks := h.quotes.picks
+	_, err = h.svc.Challenge(ctx, day("2026-10-18"))
+	require.NoError(t, err)
+	assert.Equal(t, picks, h.quotes.picks)
+}
+
+// A day is open while it is today, and for the grace period into tomorrow —
+// never ahead of time, and never once the grace has run out.
+func TestAdmitOpensADayForTodayAndItsGrace(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuoteEvery = 0
+	h := newChallenged(t, cfg)
+	ctx := context.Background()
+
+	today, err := h.svc.Challenge(ctx, day("2026-10-17"))
+	require.NoError(t, err)
+	tomorrow := daily.Derive(day("2026-10-18"), cfg).Seeded
+
+	h.now = time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC)
+	require.NoError(t, h.svc.Admit(ctx, attemptAt(today)))
+	require.ErrorIs(t, h.svc.Admit(ctx, attemptAt(tomorrow)), daily.ErrClosed,
+		"tomorrow is frozen but not open")
+
+	h.now = time.Date(2026, 10, 18, 0, 14, 59, 0, time.UTC)
+	require.NoError(t, h.svc.Admit(ctx, attemptAt(today)), "inside the grace period")
+
+	h.now = time.Date(2026, 10, 18, 0, 15, 0, 0, time.UTC)
+	require.ErrorIs(t, h.svc.Admit(ctx, attemptAt(today)), daily.ErrClosed)
+}
+
+func TestAdmitRefusesARunThatIsNotTheChallenge(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuoteEvery = 0
+	h := newChallenged(t, cfg)
+	ctx := context.Background()
+
+	c, err := h.svc.Challenge(ctx, h.svc.Today())
+	require.NoError(t, err)
+	require.NoError(t, h.svc.Admit(ctx, attemptAt(c)))
+
+	spoil := map[string]func(*daily.Attempt){
+		"another seed": func(a *daily.Attempt) { a.Seed++ },
+		"another lang": func(a *daily.Attempt) { a.Lang = "zz" },
+		"another size": func(a *daily.Attempt) {
+			n := int32(7)
+			if a.DurationMs != nil {
+				a.DurationMs = &n
+			} else {
+				a.WordCount = &n
+			}
+		},
+		"other mods": func(a *daily.Attempt) {
+			a.Setup = seededSetup(c.Day.Format(daily.DayLayout), !c.Mods.Punctuation, c.Mods.Numbers)
+		},
+		"a seeded repeat": func(a *daily.Attempt) {
+			var m map[string]any
+			require.NoError(t, json.Unmarshal(a.Setup, &m))
+			m["adoptedFromRunId"] = uuid.NewString()
+			a.Setup, _ = json.Marshal(m)
+		},
+	}
+	for name, fn := range spoil {
+		a := attemptAt(c)
+		fn(&a)
+		require.ErrorIs(t, h.svc.Admit(ctx, a), daily.ErrMismatch, name)
+	}
+}
+
+// The mods a challenge does not pin are the player's: difficulty and blind
+// move the score, not the admission.
+func TestAdmitLeavesScoringModsToThePlayer(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuoteEvery = 0
+	h := newChallenged(t, cfg)
+	ctx := context.Background()
+
+	c, err := h.svc.Challenge(ctx, h.svc.Today())
+	require.NoError(t, err)
+	a := attemptAt(c)
+	require.Contains(t, string(a.Setup), `"difficulty":"expert"`)
+	require.Contains(t, string(a.Setup), `"blind":true`)
+	require.NoError(t, h.svc.Admit(ctx, a))
+}
+
+func TestTodayServesTodayAndNeverTomorrow(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuoteEvery = 0
+	h := newChallenged(t, cfg)
+	require.NoError(t, h.svc.Prepare(context.Background()))
+
+	resp := h.get("/api/v1/daily")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	got := decodeInto[map[string]any](t, resp)
+	assert.Equal(t, "2026-10-17", got["day"])
+	assert.Equal(t, "daily:2026-10-17", got["board"])
+	assert.Equal(t, "2026-10-18T00:15:00Z", got["closesAt"])
+	assert.EqualValues(t, daily.Derive(day("2026-10-17"), cfg).Seeded.Seed, got["seed"])
+
+	// Tomorrow is stored by now, and the archive must not leak it either.
+	archive := decodeInto[struct {
+		Entries []map[string]any `json:"entries"`
+	}](t, h.get("/api/v1/daily/archive"))
+	assert.Empty(t, archive.Entries, "neither today nor tomorrow is history")
+}
+
+func TestArchivePagesPastDaysNewestFirstWithTheirWinners(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuoteEvery = 0
+	h := newChallenged(t, cfg)
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		_, err := h.svc.Challenge(ctx, day("2026-10-17").AddDate(0, 0, -i))
+		require.NoError(t, err)
+	}
+	winner := daily.Winner{UserID: uuid.New(), DisplayName: "champ", RunID: uuid.New(), Score: 900, WPM: 120, Acc: 0.99}
+	h.boards.winners["2026-10-15"] = winner
+
+	type page struct {
+		Entries []struct {
+			Challenge struct {
+				Day string `json:"day"`
+			} `json:"challenge"`
+			Winner *struct {
+				DisplayName string `json:"displayName"`
+				Score       int64  `json:"score"`
+			} `json:"winner"`
+		} `json:"entries"`
+		NextCursor string `json:"nextCursor"`
+	}
+
+	first := decodeInto[page](t, h.get("/api/v1/daily/archive?limit=3"))
+	require.Len(t, first.Entries, 3)
+	assert.Equal(t, "2026-10-16", first.Entries[0].Challenge.Day)
+	assert.Equal(t, "2026-10-14", first.Entries[2].Challenge.Day)
+	assert.Nil(t, first.Entries[0].Winner, "nobody placed")
+	require.NotNil(t, first.Entries[1].Winner)
+	assert.Equal(t, "champ", first.Entries[1].Winner.DisplayName)
+	require.NotEmpty(t, first.NextCursor)
+
+	second := decodeInto[page](t, h.get("/api/v1/daily/archive?limit=3&cursor="+first.NextCursor))
+	require.Len(t, second.Entries, 2)
+	assert.Equal(t, "2026-10-13", second.Entries[0].Challenge.Day)
+	assert.Empty(t, second.NextCursor)
+
+	assert.Equal(t, http.StatusBadRequest, h.get("/api/v1/daily/archive?cursor=junk").StatusCode)
+}
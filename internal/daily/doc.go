@@ -0,0 +1,29 @@
+// Package daily is the daily challenge (docs/DAILY.md): one text, one shape and
+// one mod set per UTC day, the same for every player, with one ranked attempt
+// each and a board of its own.
+//
+// # Derived, then frozen
+//
+// A day's challenge is DERIVED — seed, language, shape and mods all come out of
+// a salted hash of the date (Derive), so no operator ever has to pick one and
+// the scheduler needs no state of its own. It is then STORED, once, in
+// daily_challenges, and from that moment the stored row is the only answer:
+// rotating the salt, changing the derivation or withdrawing the day's quote
+// changes days nobody has played yet and never one that has a board. Some days
+// are a quote of the day instead, drawn from the published corpus through
+// internal/quote at the moment the day is frozen.
+//
+// # What this package does not own
+//
+// Ingestion and the board are other domains' code, reached through interfaces
+// declared here and adapted by the composition root:
+//
+//   - the runs domain asks Admit whether a declared attempt is today's
+//     challenge before it stores it, and its own table enforces the one
+//     attempt per player per day;
+//   - the board is a leaderboard bucket like any other ("daily:YYYY-MM-DD"),
+//     projected by the leaderboard store in the verdict transaction; this
+//     package only reads its rank 1 back for the archive.
+//
+// Like every domain it imports no sibling.
+package daily
@@ -0,0 +1,157 @@
+package daily
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/platform/httpx"
+)
+
+// Pagination bounds for an archive page.
+const (
+	defaultLimit = 30
+	maxLimit     = 100
+)
+
+// Routes returns the daily router, mounted at /api/v1/daily.
+//
+// PUBLIC and unauthenticated: the challenge is the same for everyone, and the
+// archive shows what the day boards already show. Tomorrow's challenge is
+// frozen ahead of time but is served by neither route — a day is revealed when
+// it opens.
+func (s *Service) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", s.handleToday)
+	r.Get("/archive", s.handleArchive)
+	return r
+}
+
+type challengeView struct {
+	Day        string     `json:"day"`
+	Board      string     `json:"board"`
+	Mode       string     `json:"mode"`
+	DurationMs *int32     `json:"durationMs,omitempty"`
+	WordCount  *int32     `json:"wordCount,omitempty"`
+	Lang       string     `json:"lang,omitempty"`
+	Seed       *int64     `json:"seed,omitempty"`
+	Mods       *Mods      `json:"mods,omitempty"`
+	QuoteID    *uuid.UUID `json:"quoteId,omitempty"`
+	// ClosesAt is the last instant an attempt is admitted: midnight UTC after
+	// the day, plus the grace period.
+	ClosesAt time.Time `json:"closesAt"`
+}
+
+func (s *Service) toView(c Challenge) challengeView {
+	v := challengeView{
+		Day:      c.Day.Format(DayLayout),
+		Board:    s.boards.BoardKey(c.Day),
+		Mode:     c.Mode,
+		QuoteID:  c.QuoteID,
+		ClosesAt: c.Day.AddDate(0, 0, 1).Add(s.cfg.Grace),
+	}
+	// A quote day is its quote and nothing else; the seeded coordinates would
+	// be zero values a client could mistake for a setup.
+	if !c.IsQuote() {
+		seed, mods := c.Seed, c.Mods
+		v.DurationMs, v.WordCount = c.DurationMs, c.WordCount
+		v.Lang, v.Seed, v.Mods = c.Lang, &seed, &mods
+	}
+	return v
+}
+
+// handleToday returns today's challenge: everything a client needs to set the
+// run up, and the `setup.daily` value that files it as an attempt.
+func (s *Service) handleToday(w http.ResponseWriter, r *http.Request) {
+	c, err := s.Challenge(r.Context(), s.Today())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.toView(c))
+}
+
+type winnerView struct {
+	UserID      uuid.UUID `json:"userId"`
+	DisplayName string    `json:"displayName"`
+	RunID       uuid.UUID `json:"runId"`
+	Score       int64     `json:"score"`
+	WPM         float64   `json:"wpm"`
+	Acc         float64   `json:"acc"`
+}
+
+type archiveEntry struct {
+	Challenge challengeView `json:"challenge"`
+	// Winner is rank 1 on the day's board as it stands NOW — a ban or a
+	// demotion after the day closed is reflected, exactly as on the board.
+	// Absent when nobody placed.
+	Winner *winnerView `json:"winner,omitempty"`
+}
+
+type archiveResponse struct {
+	Entries    []archiveEntry `json:"entries"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+// handleArchive returns past days, newest first, keyset-paginated on the day.
+// Today is not in it: a day joins the archive when it is over, and its winner
+// is not a winner until then.
+func (s *Service) handleArchive(w http.ResponseWriter, r *http.Request) {
+	limit := httpx.ParseLimit(r.URL.Query().Get("limit"), defaultLimit, maxLimit)
+
+	before := s.Today()
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		day, err := decodeCursor(raw)
+		if err != nil {
+			s.writeError(w, r, apiErrBadCursor)
+			return
+		}
+		// A cursor can only move further back; one minted for a day that has
+		// not ended yet would leak the challenges between.
+		if day.Before(before) {
+			before = day
+		}
+	}
+
+	rows, err := s.store.Archive(r.Context(), before, int32(limit+1))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = encodeCursor(rows[limit-1].Day)
+	}
+
+	entries := make([]archiveEntry, len(rows))
+	for i, c := range rows {
+		entries[i].Challenge = s.toView(c)
+		win, ok, err := s.boards.Winner(r.Context(), c.Day)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if ok {
+			entries[i].Winner = &winnerView{
+				UserID: win.UserID, DisplayName: win.DisplayName, RunID: win.RunID,
+				Score: win.Score, WPM: win.WPM, Acc: win.Acc,
+			}
+		}
+	}
+	s.writeJSON(w, http.StatusOK, archiveResponse{Entries: entries, NextCursor: next})
+}
+
+func encodeCursor(day time.Time) string {
+	return httpx.EncodeCursor(day.Format(DayLayout))
+}
+
+func decodeCursor(token string) (time.Time, error) {
+	parts, err := httpx.DecodeCursor(token, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(DayLayout, parts[0])
+}
@@ -0,0 +1,171 @@
+package daily_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/typemore/typemore-server/internal/daily"
+	dailypg "github.com/typemore/typemore-server/internal/daily/pgstore"
+	"github.com/typemore/typemore-server/internal/platform/db"
+	"github.com/typemore/typemore-server/internal/platform/migrate"
+)
+
+// The Postgres testcontainer is started lazily on first use and torn down in
+// TestMain, mirroring the leaderboard suite.
+var (
+	dbOnce      sync.Once
+	dbContainer *postgres.PostgresContainer
+	testDSN     string
+	dbErr       error
+)
+
+func ensureDB(t *testing.T) string {
+	t.Helper()
+	dbOnce.Do(func() {
+		ctx := context.Background()
+		dbContainer, dbErr = postgres.Run(ctx, "postgres:17",
+			postgres.WithDatabase("typemore"),
+			postgres.WithUsername("typemore"),
+			postgres.WithPassword("typemore"),
+			testcontainers.WithWaitStrategy(
+				wait.ForLog("database system is ready to accept connections").
+					WithOccurrence(2).
+					WithStartupTimeout(90*time.Second),
+			),
+		)
+		if dbErr != nil {
+			return
+		}
+		testDSN, dbErr = dbContainer.ConnectionString(ctx, "sslmode=disable")
+		if dbErr != nil {
+			return
+		}
+		dbErr = migrate.Up(ctx, testDSN)
+	})
+	require.NoError(t, dbErr, "start/migrate postgres testcontainer")
+	return testDSN
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if dbContainer != nil {
+		_ = dbContainer.Terminate(context.Background())
+	}
+	os.Exit(code)
+}
+
+// challenged is the daily test harness: a real Postgres and the real store,
+// with the quote corpus and the boards faked — both are other domains', and
+// what is under test is what this one does with their answers. The clock is
+// the harness's to move.
+type challenged struct {
+	t      *testing.T
+	pool   *pgxpool.Pool
+	store  *dailypg.Store
+	svc    *daily.Service
+	quotes *fakeQuotes
+	boards *fakeBoards
+	now    time.Time
+	server *httptest.Server
+}
+
+func newChallenged(t *testing.T, cfg daily.Config) *challenged {
+	t.Helper()
+	ctx := context.Background()
+
+	pool, err := db.NewPool(ctx, ensureDB(t), 5)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	_, err = pool.Exec(ctx, `TRUNCATE daily_challenges, quotes CASCADE`)
+	require.NoError(t, err)
+
+	h := &challenged{
+		t: t, pool: pool, store: dailypg.New(pool),
+		quotes: &fakeQuotes{}, boards: &fakeBoards{winners: map[string]daily.Winner{}},
+		now: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+	}
+	h.svc = daily.NewService(h.store, h.quotes, h.boards, cfg,
+		slog.New(slog.NewTextHandler(io.Discard, nil))).
+		WithClock(func() time.Time { return h.now })
+
+	r := chi.NewRouter()
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Mount("/daily", h.svc.Routes())
+	})
+	h.server = httptest.NewServer(r)
+	t.Cleanup(h.server.Close)
+	return h
+}
+
+// quote publishes one quote and returns its id.
+func (h *challenged) quote(lang string) uuid.UUID {
+	h.t.Helper()
+	var id uuid.UUID
+	require.NoError(h.t, h.pool.QueryRow(context.Background(), `
+		INSERT INTO quotes (id, lang, upstream_id, text, source, length, len_group, text_hash)
+		VALUES (gen_random_uuid(), $1,
+		        (SELECT coalesce(max(upstream_id), 0) + 1 FROM quotes WHERE lang = $1),
+		        'to be or not to be', 'Hamlet', 18, 1, 'deadbeef')
+		RETURNING id`, lang).Scan(&id))
+	return id
+}
+
+func (h *challenged) get(path string) *http.Response {
+	h.t.Helper()
+	resp, err := http.Get(h.server.URL + path)
+	require.NoError(h.t, err)
+	h.t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func decodeInto[T any](t *testing.T, resp *http.Response) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+	return v
+}
+
+// fakeQuotes answers every pick with its one quote, or ErrNoQuote when it has
+// none.
+type fakeQuotes struct {
+	id    uuid.UUID
+	picks int
+}
+
+func (f *fakeQuotes) PickQuote(_ context.Context, _ string) (uuid.UUID, error) {
+	f.picks++
+	if f.id == uuid.Nil {
+		return uuid.Nil, daily.ErrNoQuote
+	}
+	return f.id, nil
+}
+
+// fakeBoards spells keys the way the leaderboard does and holds a planted
+// winner per day.
+type fakeBoards struct {
+	winners map[string]daily.Winner
+}
+
+func (f *fakeBoards) BoardKey(day time.Time) string { return "daily:" + day.Format(daily.DayLayout) }
+
+func (f *fakeBoards) Winner(_ context.Context, day time.Time) (daily.Winner, bool, error) {
+	w, ok := f.winners[day.Format(daily.DayLayout)]
+	return w, ok, nil
+}
@@ -0,0 +1,134 @@
+// Package pgstore implements daily.Store against Postgres (migration 00037).
+//
+// Raw SQL, like the rating store: four statements over one table, and the
+// interesting one — whether a run is the day's challenge — is a single call to
+// the SQL function the day's board reads too, so there is nothing for a query
+// generator to add.
+package pgstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/typemore/typemore-server/internal/daily"
+)
+
+// Store is the daily challenge's persistence.
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// Compile-time check that Store satisfies the consumer interface.
+var _ daily.Store = (*Store)(nil)
+
+// New builds a Store.
+func New(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+const challengeColumns = `day, mode, duration_ms, word_count, lang, seed, mods, quote_id`
+
+// Challenge implements daily.Store.
+func (s *Store) Challenge(ctx context.Context, day time.Time) (daily.Challenge, error) {
+	c, err := scanChallenge(s.pool.QueryRow(ctx,
+		`SELECT `+challengeColumns+` FROM daily_challenges WHERE day = $1`, day))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return daily.Challenge{}, daily.ErrNotFound
+	}
+	return c, err
+}
+
+// Freeze implements daily.Store. The insert and the read are one statement's
+// worth of work in two: ON CONFLICT DO NOTHING returns no row when the day is
+// already stored, and the read then returns the row that won.
+func (s *Store) Freeze(ctx context.Context, c daily.Challenge) (daily.Challenge, error) {
+	var (
+		lang *string
+		seed *int64
+		mods = []byte(`{}`)
+	)
+	if !c.IsQuote() {
+		m, err := json.Marshal(c.Mods)
+		if err != nil {
+			return daily.Challenge{}, err
+		}
+		lang, seed, mods = &c.Lang, &c.Seed, m
+	}
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO daily_challenges (`+challengeColumns+`)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (day) DO NOTHING`,
+		c.Day, c.Mode, c.DurationMs, c.WordCount, lang, seed, mods, c.QuoteID)
+	if err != nil {
+		return daily.Challenge{}, fmt.Errorf("freeze daily challenge: %w", err)
+	}
+	return s.Challenge(ctx, c.Day)
+}
+
+// Matches implements daily.Store.
+func (s *Store) Matches(ctx context.Context, a daily.Attempt) (bool, error) {
+	var ok bool
+	err := s.pool.QueryRow(ctx, `
+SELECT daily_attempt_matches(c, $2, $3, $4, $5, $6, $7::jsonb)
+FROM daily_challenges c
+WHERE c.day = $1`,
+		a.Day, a.Mode, a.DurationMs, a.WordCount, a.Lang, a.Seed, []byte(a.Setup)).Scan(&ok)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, daily.ErrNotFound
+	}
+	return ok, err
+}
+
+// Archive implements daily.Store.
+func (s *Store) Archive(ctx context.Context, before time.Time, limit int32) ([]daily.Challenge, error) {
+	rows, err := s.pool.Query(ctx, `
+SELECT `+challengeColumns+`
+FROM daily_challenges
+WHERE day < $1
+ORDER BY day DESC
+LIMIT $2`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []daily.Challenge
+	for rows.Next() {
+		c, err := scanChallenge(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func scanChallenge(row pgx.Row) (daily.Challenge, error) {
+	var (
+		c    daily.Challenge
+		lang *string
+		seed *int64
+		mods []byte
+		qid  *uuid.UUID
+	)
+	if err := row.Scan(&c.Day, &c.Mode, &c.DurationMs, &c.WordCount, &lang, &seed, &mods, &qid); err != nil {
+		return daily.Challenge{}, err
+	}
+	c.QuoteID = qid
+	if lang != nil {
+		c.Lang = *lang
+	}
+	if seed != nil {
+		c.Seed = *seed
+	}
+	if err := json.Unmarshal(mods, &c.Mods); err != nil {
+		return daily.Challenge{}, fmt.Errorf("daily challenge %s mods: %w", c.Day.Format(daily.DayLayout), err)
+	}
+	return c, nil
+}
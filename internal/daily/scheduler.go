@@ -0,0 +1,31 @@
+package daily
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunScheduler freezes today's and tomorrow's challenges once immediately and
+// then every interval, until ctx is cancelled. Started as a goroutine from the
+// composition root, like the auth janitor.
+//
+// Any interval under a day keeps tomorrow frozen before it starts; an hour is
+// plenty. Several instances may run it at once — Freeze keeps the first row
+// written — and none need run it at all, because reads freeze on a miss.
+func RunScheduler(ctx context.Context, s *Service, interval time.Duration, log *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Prepare(ctx); err != nil && ctx.Err() == nil {
+			// The next tick retries, and a read would freeze the day anyway;
+			// a failure here must never take the server down.
+			log.ErrorContext(ctx, "daily: prepare challenges failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
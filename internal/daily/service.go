@@ -0,0 +1,154 @@
+package daily
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/typemore/typemore-server/internal/platform/httpx"
+)
+
+// Config is how days are drawn and how long they stay open. Everything but
+// Grace feeds Derive, so changing any of it changes future days only — stored
+// days keep the challenge they were frozen with.
+type Config struct {
+	// Salt keeps the draw unpredictable from outside the deployment.
+	Salt string
+	// Langs are the languages a seeded day may be set in. Non-empty.
+	Langs []string
+	// Shapes are the ranked shapes a seeded day may be set at. Non-empty.
+	Shapes []Shape
+	// QuoteEvery makes roughly one day in QuoteEvery a quote of the day;
+	// 0 never does.
+	QuoteEvery int
+	// Grace is how long after midnight UTC an attempt at the day that just
+	// ended is still admitted — long enough for a run started at 23:59 to be
+	// submitted, and no longer.
+	Grace time.Duration
+}
+
+// Service serves the daily challenge: the day's challenge and the archive over
+// HTTP, and the admission check ingestion asks through the composition root.
+//
+// Like the rating service it has no UserIDFunc: both routes are anonymous, and
+// the one per-player rule — one attempt — is the runs table's to enforce.
+type Service struct {
+	store  Store
+	quotes Quotes
+	boards Boards
+	cfg    Config
+	log    *slog.Logger
+
+	// now is time.Now in production; tests move it across midnight.
+	now func() time.Time
+}
+
+// NewService wires the daily service. quotes may be nil, in which case every
+// day is seeded; boards may not be, since the archive and the challenge both
+// name their board.
+func NewService(store Store, quotes Quotes, boards Boards, cfg Config, log *slog.Logger) *Service {
+	return &Service{store: store, quotes: quotes, boards: boards, cfg: cfg, log: log, now: time.Now}
+}
+
+// WithClock replaces the service's clock. For tests, which need to stand on
+// either side of midnight and inside or outside the grace period.
+func (s *Service) WithClock(now func() time.Time) *Service {
+	s.now = now
+	return s
+}
+
+// Today is the UTC day the service's clock is in.
+func (s *Service) Today() time.Time { return dayOf(s.now()) }
+
+// Challenge returns day's challenge, freezing it first if nobody has yet. The
+// scheduler normally gets there first; this is what keeps a deployment that
+// runs without it — or a scheduler that missed midnight — serving a challenge.
+func (s *Service) Challenge(ctx context.Context, day time.Time) (Challenge, error) {
+	c, err := s.store.Challenge(ctx, day)
+	if !errors.Is(err, ErrNotFound) {
+		return c, err
+	}
+	return s.freeze(ctx, day)
+}
+
+// freeze draws day's challenge and stores it. A quote day asks the corpus for
+// a quote in the day's language; a language with none falls back to the seeded
+// draw rather than leaving the day without a challenge.
+func (s *Service) freeze(ctx context.Context, day time.Time) (Challenge, error) {
+	d := Derive(day, s.cfg)
+	c := d.Seeded
+	if d.QuoteDay && s.quotes != nil {
+		id, err := s.quotes.PickQuote(ctx, c.Lang)
+		switch {
+		case err == nil:
+			c = Challenge{Day: day, Mode: ModeQuote, QuoteID: &id}
+		case !errors.Is(err, ErrNoQuote):
+			return Challenge{}, fmt.Errorf("daily: pick quote for %s: %w", day.Format(DayLayout), err)
+		}
+	}
+	return s.store.Freeze(ctx, c)
+}
+
+// Admit decides whether a run that declared itself an attempt at a.Day may be
+// stored as one: nil, ErrClosed or ErrMismatch.
+//
+// A day is open while it is today, and for Grace into tomorrow. Nothing is
+// open ahead of time — tomorrow's challenge is frozen by then, but an attempt
+// at it would be a run played before the day it ranks on.
+func (s *Service) Admit(ctx context.Context, a Attempt) error {
+	now := s.now().UTC()
+	today := dayOf(now)
+	switch {
+	case a.Day.Equal(today):
+	case a.Day.Equal(today.AddDate(0, 0, -1)) && now.Before(today.Add(s.cfg.Grace)):
+	default:
+		return ErrClosed
+	}
+	if _, err := s.Challenge(ctx, a.Day); err != nil {
+		return err
+	}
+	ok, err := s.store.Matches(ctx, a)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// Prepare freezes today's and tomorrow's challenges, so the first request
+// after midnight reads a stored row instead of racing to write one. Idempotent:
+// a day already stored is left exactly as it is.
+func (s *Service) Prepare(ctx context.Context) error {
+	today := s.Today()
+	for _, day := range []time.Time{today, today.AddDate(0, 0, 1)} {
+		if _, err := s.Challenge(ctx, day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- shared HTTP helpers (mirroring the sibling domains', kept private) ---
+
+func (s *Service) writeJSON(w http.ResponseWriter, status int, v any) {
+	if err := httpx.WriteJSON(w, status, v); err != nil {
+		s.log.Error("encode response", "err", err)
+	}
+}
+
+// writeError renders err. Known apiErrors are sent with their status/code;
+// anything else is logged and returned as a generic 500 so internals never leak.
+func (s *Service) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		s.writeJSON(w, apiErr.status, apiErr)
+		return
+	}
+	s.log.ErrorContext(r.Context(), "daily request failed", "err", err, "path", r.URL.Path)
+	s.writeJSON(w, apiErrInternal.status, apiErrInternal)
+}
@@ -0,0 +1,82 @@
+package daily
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sentinel errors. ErrNotFound is the store's; the rest are Admit's answers,
+// which the composition root translates into the runs domain's own.
+var (
+	ErrNotFound = errors.New("daily: no challenge for that day")
+	// ErrClosed: the day is not open for attempts — it is in the future, or
+	// it is over and past the grace period.
+	ErrClosed = errors.New("daily: that day's challenge is not open")
+	// ErrMismatch: the run is not the day's challenge.
+	ErrMismatch = errors.New("daily: run does not match the challenge")
+	// ErrNoQuote is what a Quotes picker answers when the language has nothing
+	// to draw; the day falls back to its seeded challenge.
+	ErrNoQuote = errors.New("daily: no quote to draw")
+)
+
+// Attempt is a run that declared itself an attempt at Day's challenge, as the
+// runs domain hands it over. Setup is the run's setup snapshot, whole: the mods
+// and the quote are read out of it by SQL, by the same functions the boards
+// use (00037), so this package never parses it.
+type Attempt struct {
+	Day        time.Time
+	Mode       string
+	DurationMs *int32
+	WordCount  *int32
+	Lang       string
+	Seed       int64
+	Setup      json.RawMessage
+}
+
+// Store is the daily challenge's persistence, declared here at the consumer.
+type Store interface {
+	// Challenge returns the stored challenge of one day, or ErrNotFound.
+	Challenge(ctx context.Context, day time.Time) (Challenge, error)
+	// Freeze stores c as its day's challenge unless one is already stored, and
+	// returns whichever is stored afterwards. Two instances freezing the same
+	// day at once both get the first one's row.
+	Freeze(ctx context.Context, c Challenge) (Challenge, error)
+	// Matches reports whether a is an attempt at its day's challenge, by
+	// daily_attempt_matches (00037) — the same predicate the board reads — or
+	// ErrNotFound when the day has no challenge.
+	Matches(ctx context.Context, a Attempt) (bool, error)
+	// Archive returns up to limit stored challenges strictly before `before`,
+	// newest first.
+	Archive(ctx context.Context, before time.Time, limit int32) ([]Challenge, error)
+}
+
+// Quotes draws the quote of the day, declared here and served over
+// internal/quote by the composition root. ErrNoQuote when the language has
+// none to offer.
+type Quotes interface {
+	PickQuote(ctx context.Context, lang string) (uuid.UUID, error)
+}
+
+// Winner is rank 1 on a day's board.
+type Winner struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	WPM         float64
+	Acc         float64
+}
+
+// Boards is the day's leaderboard, seen from here: its key, and its rank 1.
+// The leaderboard domain owns both — the key format has one producer, and rank
+// 1 is whatever its ban-filtered ranking says — so the composition root adapts
+// its store rather than this package reading leaderboard_entries itself.
+type Boards interface {
+	BoardKey(day time.Time) string
+	// Winner returns the day board's rank 1; ok is false when it is empty.
+	Winner(ctx context.Context, day time.Time) (w Winner, ok bool, err error)
+}
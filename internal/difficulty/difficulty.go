@@ -0,0 +1,309 @@
+// Package difficulty rates how hard a text is to type: one number per text,
+// shown as a star rating and kept as the input a future score version reads
+// (docs/DIFFICULTY.md).
+//
+// It is a pure function of the text, the language's dictionary and the
+// keyboard layouts asset, and it imports nothing but internal/keyboard. Both
+// text sources rate through it: a quote at import time (`make import-quotes`)
+// and a seeded dictionary when the registry seeds. The two are therefore on
+// one scale — a 3-star quote and a 3-star dictionary are the same claim — and
+// neither owns the analyser.
+//
+// Like the TP formula, the model is versioned. A stored rating carries the
+// version that produced it; changing a weight or a bound is a new Model and a
+// bump of Current, after which the next import re-rates every quote whose
+// stored version is stale. The dictionaries need nothing: they are rated at
+// every startup.
+package difficulty
+
+import (
+	"math"
+	"unicode"
+
+	"github.com/typemore/typemore-server/internal/keyboard"
+)
+
+// Model is one version of the analyser's scale. The zero value is not a
+// model; use V1 or Current.
+//
+// Four factors are measured, each mapped onto [0, 1] between a floor and a
+// ceiling, and the rating is their weighted mean. The bounds are where the
+// real corpus sits: Floor is about the easiest dictionary, Ceil about where a
+// factor stops telling two hard texts apart. A value past either bound clamps.
+type Model struct {
+	// Version is stored beside every rating this model produced.
+	Version int16
+
+	// Rarity is the mean surprisal, in bits, of the text's letters under the
+	// language's dictionary.
+	Rarity Scale
+	// Awkwardness is the mean transition cost of the text's in-word bigrams
+	// on the language's layout (see transition).
+	Awkwardness Scale
+	// WordLength is the mean length of a word, in characters.
+	WordLength Scale
+	// Symbols is the share of the typed characters that are punctuation,
+	// digits or symbols.
+	Symbols Scale
+
+	// NeutralAwkwardness is the factor a text gets when none of its bigrams
+	// are on a layout — a script the asset has no keys for. The analyser
+	// cannot tell an easy one from a hard one, and the middle of the scale is
+	// the answer that misleads least.
+	NeutralAwkwardness float64
+}
+
+// Scale is one factor's bounds and its weight in the rating. The weights of a
+// model sum to 1.
+type Scale struct {
+	Floor, Ceil float64
+	Weight      float64
+}
+
+func (s Scale) of(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return min(max((v-s.Floor)/(s.Ceil-s.Floor), 0), 1)
+}
+
+// V1 is the first model. Letters and layout carry most of the weight: they are
+// what every text is made of, where word length and symbols mostly separate
+// code and prose from each other.
+var V1 = Model{
+	Version:            1,
+	Rarity:             Scale{Floor: 4, Ceil: 7, Weight: 0.3},
+	Awkwardness:        Scale{Floor: 0.15, Ceil: 0.45, Weight: 0.3},
+	WordLength:         Scale{Floor: 3.5, Ceil: 9, Weight: 0.2},
+	Symbols:            Scale{Floor: 0, Ceil: 0.2, Weight: 0.2},
+	NeutralAwkwardness: 0.5,
+}
+
+// Current is the model every rating is written with.
+var Current = V1
+
+// Rating is what is stored and served: the value, and the model version that
+// computed it.
+type Rating struct {
+	// Version is the Model version; 0 means not rated.
+	Version int16
+	// Value is the rating on [0, 1].
+	Value float64
+}
+
+// Rated reports whether the rating came from a model.
+func (r Rating) Rated() bool { return r.Version > 0 }
+
+// Stars is the rating as the catalogues show it: 1.0 to 5.0 in tenths.
+func (r Rating) Stars() float64 { return Stars(r.Value) }
+
+// Stars maps a value on [0, 1] to the star scale.
+func Stars(value float64) float64 {
+	return math.Round((1+4*min(max(value, 0), 1))*10) / 10
+}
+
+// Factors is the analysis behind a rating, each factor already on [0, 1].
+// Only the rating is stored; the factors are for whoever is tuning the model.
+type Factors struct {
+	Rarity      float64
+	Awkwardness float64
+	WordLength  float64
+	Symbols     float64
+}
+
+// Analysis is one text's rating with its factors.
+type Analysis struct {
+	Rating
+	Factors Factors
+}
+
+// Profile is a dictionary's letter frequencies: what "rare" means for one
+// language. Letters are case-folded, so a capital is as rare as its lower case
+// and its cost is the Shift it needs, which the layout factor counts.
+type Profile struct {
+	counts map[rune]int
+	total  int
+}
+
+// NewProfile counts the letters of a word list. Every word counts once,
+// because that is how the seeded generator draws them: uniformly, by index.
+func NewProfile(words []string) *Profile {
+	p := &Profile{counts: make(map[rune]int, 64)}
+	for _, w := range words {
+		for _, r := range w {
+			if unicode.IsLetter(r) {
+				p.counts[unicode.ToLower(r)]++
+				p.total++
+			}
+		}
+	}
+	return p
+}
+
+// surprisal is -log2 of the letter's share. A letter the dictionary never uses
+// counts as half an occurrence, which keeps it finite and rarer than anything
+// the dictionary has.
+func (p *Profile) surprisal(r rune) float64 {
+	n := float64(p.counts[r])
+	if n == 0 {
+		n = 0.5
+	}
+	return math.Log2(float64(p.total) / n)
+}
+
+// key is one character's place on a layout.
+type key struct {
+	id      string
+	hand    string
+	finger  string
+	row     int
+	shifted bool
+}
+
+// Analyser rates texts over the layouts asset. It is immutable once built and
+// safe for concurrent use — the registry rates dictionaries from every seed
+// worker at once.
+type Analyser struct {
+	model   Model
+	layouts *keyboard.Layouts
+	// keys is the rune → key index of each layout, by layout name. The asset
+	// speaks strings; a rating walks megabytes of runes at startup, so the
+	// index is built once here rather than a string per character later.
+	keys map[string]map[rune]key
+}
+
+// New builds an analyser for the current model.
+func New(layouts *keyboard.Layouts) *Analyser { return NewFor(Current, layouts) }
+
+// NewFor builds an analyser for a given model.
+func NewFor(m Model, layouts *keyboard.Layouts) *Analyser {
+	a := &Analyser{model: m, layouts: layouts, keys: make(map[string]map[rune]key)}
+	for _, layout := range layouts.All() {
+		index := make(map[rune]key)
+		for _, k := range layout.Keys {
+			for i, ch := range k.Chars {
+				r := []rune(ch)
+				if len(r) != 1 {
+					continue
+				}
+				// The asset lists a key's unshifted character first.
+				index[r[0]] = key{id: k.ID, hand: k.Hand, finger: k.Finger, row: k.Row, shifted: i > 0}
+			}
+		}
+		a.keys[layout.Name] = index
+	}
+	return a
+}
+
+// Text rates one text — a quote — in a language. profile is the language's
+// dictionary; nil rates the letters against the text's own frequencies, which
+// is what a language without a dictionary has to go on.
+func (a *Analyser) Text(lang, text string, profile *Profile) Analysis {
+	texts := []string{text}
+	if profile == nil {
+		profile = NewProfile(texts)
+	}
+	return a.analyse(lang, texts, profile)
+}
+
+// Words rates a word list as the text the seeded generator draws from it. A
+// seeded run's words are uniform draws from the list, so the mean over the list
+// is the expected value of every run on it at every length: the rating belongs
+// to the dictionary and holds for each of its board sizes alike.
+func (a *Analyser) Words(lang string, words []string, profile *Profile) Analysis {
+	if profile == nil {
+		profile = NewProfile(words)
+	}
+	return a.analyse(lang, words, profile)
+}
+
+func (a *Analyser) analyse(lang string, texts []string, profile *Profile) Analysis {
+	keys := a.keys[a.layouts.LayoutFor(lang)]
+
+	var (
+		letters, typed, symbols, words int
+		bits, cost                     float64
+		bigrams                        int
+	)
+	for _, text := range texts {
+		var prev key
+		inWord, prevMapped := false, false
+		for _, r := range text {
+			if unicode.IsSpace(r) {
+				inWord, prevMapped = false, false
+				continue
+			}
+			if !inWord {
+				words++
+				inWord = true
+			}
+			typed++
+			switch {
+			case unicode.IsLetter(r):
+				letters++
+				bits += profile.surprisal(unicode.ToLower(r))
+			case unicode.IsPunct(r) || unicode.IsDigit(r) || unicode.IsSymbol(r):
+				symbols++
+			}
+			k, mapped := keys[r]
+			if mapped && prevMapped {
+				cost += transition(prev, k)
+				bigrams++
+			}
+			prev, prevMapped = k, mapped
+		}
+	}
+
+	m := a.model
+	f := Factors{
+		Rarity:     m.Rarity.of(ratio(bits, letters)),
+		WordLength: m.WordLength.of(ratio(float64(typed), words)),
+		Symbols:    m.Symbols.of(ratio(float64(symbols), typed)),
+	}
+	if bigrams > 0 {
+		f.Awkwardness = m.Awkwardness.of(cost / float64(bigrams))
+	} else {
+		f.Awkwardness = m.NeutralAwkwardness
+	}
+	value := f.Rarity*m.Rarity.Weight +
+		f.Awkwardness*m.Awkwardness.Weight +
+		f.WordLength*m.WordLength.Weight +
+		f.Symbols*m.Symbols.Weight
+	return Analysis{Rating: Rating{Version: m.Version, Value: value}, Factors: f}
+}
+
+// transition is what typing b straight after a costs, on [0, 1]:
+//
+//   - 0 for a change of hands, the motion touch typing is built around;
+//   - 0.2 for the same key twice — no travel, but no overlap either;
+//   - 0.3 for another finger of the same hand, 0.6 when it also jumps a row;
+//   - 1 for the same finger on another key, which cannot start until the
+//     first has finished.
+//
+// A character that needs Shift adds 0.25, capped at 1.
+func transition(a, b key) float64 {
+	var c float64
+	switch {
+	case a.hand != b.hand:
+		c = 0
+	case a.id == b.id:
+		c = 0.2
+	case a.finger == b.finger:
+		c = 1
+	case a.row-b.row >= 2 || b.row-a.row >= 2:
+		c = 0.6
+	default:
+		c = 0.3
+	}
+	if b.shifted {
+		c += 0.25
+	}
+	return min(c, 1)
+}
+
+func ratio(n float64, d int) float64 {
+	if d == 0 {
+		return math.NaN()
+	}
+	return n / float64(d)
+}
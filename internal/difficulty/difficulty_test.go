@@ -0,0 +1,104 @@
+package difficulty
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/keyboard"
+)
+
+func analyser(t *testing.T) *Analyser {
+	t.Helper()
+	layouts, err := keyboard.Load()
+	require.NoError(t, err)
+	return New(layouts)
+}
+
+// One profile for every comparison below, so only the factor under test moves.
+var english = NewProfile(strings.Fields("the quick brown fox jumps over a lazy dog and then rests"))
+
+// Alternating hands is the motion touch typing is built around; the same
+// finger hopping between keys is the one it cannot overlap.
+func TestAlternatingHandsAreLessAwkwardThanOneFinger(t *testing.T) {
+	a := analyser(t)
+	easy := a.Text("english", "fjfj fjfj fjfj", english)
+	hard := a.Text("english", "frfr frfr frfr", english)
+
+	assert.Zero(t, easy.Factors.Awkwardness)
+	assert.Equal(t, 1.0, hard.Factors.Awkwardness)
+	assert.Less(t, easy.Value, hard.Value)
+}
+
+func TestSymbolsAndLongWordsRaiseTheRating(t *testing.T) {
+	a := analyser(t)
+	plain := a.Text("english", "the dog rests", english)
+
+	symbols := a.Text("english", "the (dog); rests!", english)
+	assert.Greater(t, symbols.Factors.Symbols, plain.Factors.Symbols)
+	assert.Greater(t, symbols.Value, plain.Value)
+
+	long := a.Text("english", "thequickbrown dogrestsover", english)
+	assert.Greater(t, long.Factors.WordLength, plain.Factors.WordLength)
+}
+
+// A letter the dictionary never uses is rarer than any it does — including
+// its rarest — and still finite.
+func TestUnseenLettersAreTheRarest(t *testing.T) {
+	rarest := english.surprisal('z')
+	unseen := english.surprisal('ж')
+	assert.Greater(t, unseen, rarest)
+	assert.False(t, math.IsInf(unseen, 0))
+
+	a := analyser(t)
+	assert.Greater(t,
+		a.Text("english", "jxq", english).Factors.Rarity,
+		a.Text("english", "the", english).Factors.Rarity)
+}
+
+// Case is the layout's business, not the dictionary's: a capital is as rare
+// as its lower case, and its cost is the Shift.
+func TestCapitalsCostShiftNotRarity(t *testing.T) {
+	a := analyser(t)
+	lower := a.Text("english", "the dog", english)
+	upper := a.Text("english", "THE DOG", english)
+	assert.Equal(t, lower.Factors.Rarity, upper.Factors.Rarity)
+	assert.Greater(t, upper.Factors.Awkwardness, lower.Factors.Awkwardness)
+}
+
+// A seeded run draws uniformly from the list, so the rating is the list's and
+// not its size's: a list and the same list twice over are the same text.
+func TestWordListsRateIndependentlyOfSize(t *testing.T) {
+	a := analyser(t)
+	words := strings.Fields("alpha beta gamma delta epsilon")
+	once := a.Words("english", words, nil)
+	twice := a.Words("english", append(words, words...), nil)
+	assert.InDelta(t, once.Value, twice.Value, 1e-12)
+}
+
+// A script the layouts asset has no keys for gets the neutral middle rather
+// than a free pass.
+func TestUnmappedScriptGetsTheNeutralAwkwardness(t *testing.T) {
+	a := analyser(t)
+	got := a.Text("chinese_simplified", "我们都是好朋友", nil)
+	assert.Equal(t, V1.NeutralAwkwardness, got.Factors.Awkwardness)
+}
+
+func TestRatingsStayOnTheScale(t *testing.T) {
+	a := analyser(t)
+	for _, text := range []string{"", " ", "a", "!!!", "12345 67890", "fjfj", "ЖЖЖ ЪЪЪ"} {
+		got := a.Text("english", text, english)
+		assert.True(t, got.Rated(), "%q", text)
+		assert.GreaterOrEqual(t, got.Value, 0.0, "%q", text)
+		assert.LessOrEqual(t, got.Value, 1.0, "%q", text)
+		assert.GreaterOrEqual(t, got.Stars(), 1.0, "%q", text)
+		assert.LessOrEqual(t, got.Stars(), 5.0, "%q", text)
+	}
+	assert.Equal(t, 1.0, Stars(-1))
+	assert.Equal(t, 3.0, Stars(0.5))
+	assert.Equal(t, 5.0, Stars(2))
+	assert.False(t, Rating{}.Rated())
+}
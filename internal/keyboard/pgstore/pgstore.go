@@ -5,7 +5,8 @@
 // keyboard_projected_runs stamp table (migrations 00016, 00020). The stamp is
 // presence: a row means "this run's contribution is counted", stamping is an
 // INSERT, unstamping is a DELETE — and both tables written here belong to this
-// domain; runs is only ever read.
+// domain; runs is only ever read. Rhythms is the read side, for the replay
+// worker's player-history comparison.
 package pgstore
 
 import (
@@ -73,21 +74,7 @@ func (p *Projector) ProjectKeyboard(ctx context.Context, tx pgx.Tx, runID uuid.U
 		return nil
 	}
 
-	// Fold chars onto physical keys. Distinct chars per run are bounded by the
-	// alphabet (~dozens), so this map is tiny whatever the log's size.
-	deltas := make(map[string]*keyDelta)
-	for _, o := range observations {
-		id := p.layouts.KeyOf(lang, o.Char)
-		d := deltas[id]
-		if d == nil {
-			d = &keyDelta{}
-			deltas[id] = d
-		}
-		d.presses += o.Presses
-		d.errors += o.Errors
-		d.intervalSum += o.IntervalSumMs
-		d.intervals += o.IntervalCount
-	}
+	deltas := fold(p.layouts, lang, observations)
 
 	keys := make([]string, 0, len(deltas))
 	presses := make([]int64, 0, len(deltas))
@@ -150,3 +137,23 @@ func (p *Projector) ProjectKeyboard(ctx context.Context, tx pgx.Tx, runID uuid.U
 	}
 	return nil
 }
+
+// fold puts one run's per-character observations onto physical keys. Distinct
+// chars per run are bounded by the alphabet (~dozens), so this map is tiny
+// whatever the log's size.
+func fold(layouts *keyboard.Layouts, lang string, observations []replay.CharObservation) map[string]*keyDelta {
+	deltas := make(map[string]*keyDelta)
+	for _, o := range observations {
+		id := layouts.KeyOf(lang, o.Char)
+		d := deltas[id]
+		if d == nil {
+			d = &keyDelta{}
+			deltas[id] = d
+		}
+		d.presses += o.Presses
+		d.errors += o.Errors
+		d.intervalSum += o.IntervalSumMs
+		d.intervals += o.IntervalCount
+	}
+	return deltas
+}
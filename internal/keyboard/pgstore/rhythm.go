@@ -0,0 +1,100 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/typemore/typemore-server/internal/keyboard"
+	"github.com/typemore/typemore-server/internal/replay"
+	"github.com/typemore/typemore-server/internal/replay/policy"
+)
+
+// Rhythms implements replay/pgstore.RhythmReader: the read side of the
+// projection, for the replay worker's player-history comparison
+// (docs/REPLAY.md, "Player history"). It reads the two tables Projector
+// writes and writes nothing.
+type Rhythms struct {
+	pool    *pgxpool.Pool
+	layouts *keyboard.Layouts
+}
+
+// NewRhythms builds the reader over the same layouts asset the projector
+// folds through — a run's keys and the profile's keys must be the same keys.
+func NewRhythms(pool *pgxpool.Pool, layouts *keyboard.Layouts) *Rhythms {
+	return &Rhythms{pool: pool, layouts: layouts}
+}
+
+// Rhythm returns the account's per-key timing profile and this run's
+// observations folded onto the same keys.
+//
+// A run a re-judgement finds already stamped is counted inside the profile it
+// is about to be compared with, and a run compared with itself matches itself.
+// So the stamp is read exactly as ProjectKeyboard reads it, and a stamped run's
+// contribution is subtracted before the comparison — the arithmetic of a
+// demotion's reversal, without the write.
+//
+// The profile has no time axis, so unlike the wpm baseline it is the account's
+// whole projected history rather than the runs before this one. For the shape
+// of a pair of hands, which moves over months rather than runs, that is the
+// baseline wanted.
+func (r *Rhythms) Rhythm(ctx context.Context, runID uuid.UUID,
+	observations []replay.CharObservation) (profile, run policy.Rhythm, err error) {
+	var userID uuid.UUID
+	var lang string
+	var stamped bool
+	if err := r.pool.QueryRow(ctx,
+		`SELECT r.user_id, r.lang, (k.run_id IS NOT NULL)
+		 FROM runs r
+		          LEFT JOIN keyboard_projected_runs k ON k.run_id = r.id
+		 WHERE r.id = $1`, runID).
+		Scan(&userID, &lang, &stamped); err != nil {
+		return nil, nil, fmt.Errorf("keyboard/pgstore: read run %s: %w", runID, err)
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT key_id, interval_sum_ms, interval_count
+		 FROM user_keyboard_profile
+		 WHERE user_id = $1 AND interval_count > 0`, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("keyboard/pgstore: read profile for run %s: %w", runID, err)
+	}
+	defer rows.Close()
+	sums := map[string]*keyDelta{}
+	for rows.Next() {
+		var key string
+		d := &keyDelta{}
+		if err := rows.Scan(&key, &d.intervalSum, &d.intervals); err != nil {
+			return nil, nil, fmt.Errorf("keyboard/pgstore: scan profile for run %s: %w", runID, err)
+		}
+		sums[key] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("keyboard/pgstore: read profile for run %s: %w", runID, err)
+	}
+
+	own := fold(r.layouts, lang, observations)
+	if stamped {
+		for key, o := range own {
+			if d := sums[key]; d != nil {
+				d.intervalSum = max(0, d.intervalSum-o.intervalSum)
+				d.intervals = max(0, d.intervals-o.intervals)
+			}
+		}
+	}
+	return rhythmOf(sums), rhythmOf(own), nil
+}
+
+// rhythmOf turns interval sums into per-key means, dropping keys with no
+// interval to average.
+func rhythmOf(deltas map[string]*keyDelta) policy.Rhythm {
+	out := make(policy.Rhythm, len(deltas))
+	for key, d := range deltas {
+		if d.intervals > 0 {
+			out[key] = policy.KeyTiming{MeanMs: d.intervalSum / float64(d.intervals), Samples: d.intervals}
+		}
+	}
+	return out
+}
@@ -189,7 +189,7 @@ func TestSupersedingAQuoteLeavesItsBoardAloneAndOpensANewOne(t *testing.T) {
 		require.True(t, ok, "retiring a revision must not evict the runs recorded against it")
 		assert.Equal(t, runID, entry.RunID)
 
-		rows, err := b.store.Page(ctx, originalBoard, nil, 10)
+		rows, err := b.store.Page(ctx, originalBoard, leaderboard.OrderScore, nil, 10)
 		require.NoError(t, err)
 		require.Len(t, rows, 1, "the board is still readable")
 		assert.Equal(t, runID, rows[0].RunID)
@@ -209,7 +209,7 @@ func TestSupersedingAQuoteLeavesItsBoardAloneAndOpensANewOne(t *testing.T) {
 		assert.NotEqual(t, original, corrected,
 			"a corrected quote is a new id — that is what makes the board per-version")
 
-		rows, err := b.store.Page(ctx, quoteBucket(t, corrected), nil, 10)
+		rows, err := b.store.Page(ctx, quoteBucket(t, corrected), leaderboard.OrderScore, nil, 10)
 		require.NoError(t, err)
 		assert.Empty(t, rows, "nobody has played the corrected text yet")
 	})
@@ -274,7 +274,7 @@ func TestQuoteBucketPagesExactlyLikeALanguageBucket(t *testing.T) {
 		})
 	}
 
-	first, err := b.store.Page(ctx, board, nil, 2)
+	first, err := b.store.Page(ctx, board, leaderboard.OrderScore, nil, 2)
 	require.NoError(t, err)
 	require.Len(t, first, 2)
 	assert.Equal(t, rows[0].user, first[0].UserID)
@@ -283,11 +283,11 @@ func TestQuoteBucketPagesExactlyLikeALanguageBucket(t *testing.T) {
 	cursor := leaderboard.Cursor{
 		Score: first[1].Score, AchievedAt: first[1].AchievedAt, UserID: first[1].UserID,
 	}
-	above, err := b.store.RankAbove(ctx, board, cursor)
+	above, err := b.store.RankAbove(ctx, board, leaderboard.OrderScore, cursor)
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, above, "the rank is counted, not carried in the token")
 
-	next, err := b.store.Page(ctx, board, &cursor, 2)
+	next, err := b.store.Page(ctx, board, leaderboard.OrderScore, &cursor, 2)
 	require.NoError(t, err)
 	require.Len(t, next, 2)
 	assert.Equal(t, rows[2].user, next[0].UserID, "the tied twin resumes after the cursor, once")
@@ -298,7 +298,7 @@ func TestQuoteBucketPagesExactlyLikeALanguageBucket(t *testing.T) {
 	seen := map[uuid.UUID]int{}
 	var cur *leaderboard.Cursor
 	for {
-		page, err := b.store.Page(ctx, board, cur, 2)
+		page, err := b.store.Page(ctx, board, leaderboard.OrderScore, cur, 2)
 		require.NoError(t, err)
 		if len(page) == 0 {
 			break
@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/leaderboard"
 )
 
 // A ban hides entries; it never deletes them. That is the whole reason the
@@ -27,7 +29,7 @@ func TestBoardEntriesHideAndRestoreWithNoRebuild(t *testing.T) {
 	b.addRun(runSpec{user: honest, score: 100})
 
 	visible := func() []string {
-		rows, err := b.store.Page(ctx, bucket, nil, 50)
+		rows, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 50)
 		require.NoError(t, err)
 		names := make([]string, len(rows))
 		for i := range rows {
@@ -63,7 +65,7 @@ func TestBoardEntriesRestoreWhenATemporaryBanLapses(t *testing.T) {
 	b.addRun(runSpec{user: banned, score: 900})
 
 	count := func() int {
-		rows, err := b.store.Page(ctx, bucket, nil, 50)
+		rows, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 50)
 		require.NoError(t, err)
 		return len(rows)
 	}
@@ -89,14 +91,14 @@ func TestARevokedBanStopsHidingImmediately(t *testing.T) {
 	b.addRun(runSpec{user: banned, score: 900})
 
 	b.ban(banned, nil)
-	rows, err := b.store.Page(ctx, bucket, nil, 50)
+	rows, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 50)
 	require.NoError(t, err)
 	require.Empty(t, rows)
 
 	_, err = b.pool.Exec(ctx, `UPDATE bans SET revoked_at = now() WHERE user_id = $1`, banned)
 	require.NoError(t, err)
 
-	rows, err = b.store.Page(ctx, bucket, nil, 50)
+	rows, err = b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 50)
 	require.NoError(t, err)
 	require.Len(t, rows, 1, "a revoked ban is still hiding the entry")
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -28,8 +29,19 @@ const (
 	TextSourceQuote  = "quote"
 )
 
-// quoteKeyPrefix opens the second key space. See Key.
-const quoteKeyPrefix = "quote:"
+// quoteKeyPrefix opens the second key space, dailyKeyPrefix the third. See Key.
+// periodSeparator closes a key over a window; it is outside every component's
+// charset, so it can only ever mean that.
+const (
+	quoteKeyPrefix  = "quote:"
+	dailyKeyPrefix  = "daily:"
+	periodSeparator = "@"
+)
+
+// DayLayout is how a daily board spells its day: the calendar date in UTC, and
+// the same spelling a run declares in `setup.daily` (docs/DAILY.md). Exported
+// for the projection, which reads a run's declared day back in this spelling.
+const DayLayout = "2006-01-02"
 
 // Dimension bounds. Read from internal/runlimits, which is the same place the
 // ingest validator reads them: a bucket can only ever describe a run that was
@@ -102,11 +114,17 @@ func contains(set []int32, v int32) bool {
 // characters the key format cannot round-trip.
 var ErrInvalidBucket = errors.New("leaderboard: invalid bucket")
 
-// Bucket names one leaderboard. It is one of two shapes:
+// Bucket names one leaderboard. It is one of three shapes:
 //
 //   - a LANGUAGE board — a mode at one size, in one language, over seeded text;
 //   - a QUOTE board — one quote, identified by QuoteID, with no other dimension
-//     at all.
+//     at all;
+//   - a DAILY board — one day's challenge, identified by Day, whatever shape
+//     that day's challenge happened to have.
+//
+// A language or quote board may also be read over a window — this week, this
+// month, a season — by setting Period. A windowed board is the same board with
+// fewer runs in it, not a fourth shape.
 //
 // Mods are deliberately NOT part of either — they multiply the score
 // (SCORING_CONCEPT §2) instead of splitting the board, so a punctuation run and
@@ -129,11 +147,59 @@ type Bucket struct {
 	// quote boards. It is the discriminator: every other field is empty when it
 	// is set, and it is Nil when any of them is.
 	QuoteID uuid.UUID
+	// Day is the challenge this board ranks — midnight UTC of its date — and is
+	// non-zero for EXACTLY the daily boards, with the same discriminator rule as
+	// QuoteID. The day's mode, size, language or quote are not carried: they are
+	// properties of the challenge (internal/daily), and the day already
+	// determines all of them.
+	Day time.Time
+	// Period is the id of the window this board is read over (ParsePeriodID),
+	// or empty for the all-time board. A daily board is already a window of one
+	// day and takes no other.
+	Period string
 }
 
 // IsQuote reports whether this is a per-quote board.
 func (b Bucket) IsQuote() bool { return b.QuoteID != uuid.Nil }
 
+// IsDaily reports whether this is a daily-challenge board.
+func (b Bucket) IsDaily() bool { return !b.Day.IsZero() }
+
+// IsWindowed reports whether this board is read over a window rather than all
+// time.
+func (b Bucket) IsWindowed() bool { return b.Period != "" }
+
+// Over is this board read over a period. It fails for a daily board, and for an
+// id that names no period — the empty id included, or "key@" would be a second
+// spelling of the all-time board.
+func (b Bucket) Over(periodID string) (Bucket, error) {
+	if periodID == "" {
+		return Bucket{}, fmt.Errorf("%w: an empty window", ErrInvalidBucket)
+	}
+	b.Period = periodID
+	if err := b.validate(); err != nil {
+		return Bucket{}, err
+	}
+	return b, nil
+}
+
+// AllTime is this board without its window.
+func (b Bucket) AllTime() Bucket {
+	b.Period = ""
+	return b
+}
+
+// NewDailyBucket builds the board of one day's challenge. day must be a UTC
+// midnight; a time of day would name the same board under a second spelling,
+// so it is refused rather than truncated.
+func NewDailyBucket(day time.Time) (Bucket, error) {
+	b := Bucket{Day: day}
+	if err := b.validate(); err != nil {
+		return Bucket{}, err
+	}
+	return b, nil
+}
+
 // NewQuoteBucket builds the board a quote run belongs to: the quote, and
 // nothing else. There is no mode, size, language or text-source dimension,
 // because a quote is a fixed map and the only meaningful comparison is against
@@ -174,6 +240,13 @@ func NewBucket(mode string, durationMs, wordCount *int32, lang, textSource strin
 //	quote:<quoteId>
 //	quote:1f5f1f2c-6f0f-4d5a-9f0a-3f2a1b0c9d8e
 //
+//	daily:<YYYY-MM-DD>
+//	daily:2026-10-17
+//
+// and any of the first two may close with a window, after an "@":
+//
+//	time:60000:en:seeded@2026-W42      quote:<quoteId>@s3-autumn
+//
 // The two cannot collide and the parser never has to guess, because the first
 // component of a language key is a MODE and "quote" is not one — `time` and
 // `words` are the whole list (SCORING_CONCEPT §4), and a third would be a
@@ -184,6 +257,11 @@ func NewBucket(mode string, durationMs, wordCount *int32, lang, textSource strin
 // "words:50:en:quote" is not a second spelling of a quote board, it is not a
 // board at all.
 //
+// "daily" is not a mode either, so the third prefix is a discriminator on the
+// same terms, and a daily key carries only its date for the same reason a quote
+// key carries only its id: the day determines the challenge, and a component
+// that could disagree with it would split one board into two.
+//
 // A quote key deliberately carries NOTHING else. Mode, size and language are
 // not dimensions of a quote board: the quote is a fixed map with fixed bytes in
 // one language, so a second component could only ever repeat what the quote id
@@ -195,9 +273,15 @@ func NewBucket(mode string, durationMs, wordCount *int32, lang, textSource strin
 // format grows a component is the day every other producer becomes a silent
 // second board. SQL matches sibling runs on the bucket's COMPONENTS instead.
 func (b Bucket) Key() string {
+	if b.IsWindowed() {
+		return b.AllTime().Key() + periodSeparator + b.Period
+	}
 	if b.IsQuote() {
 		return quoteKeyPrefix + b.QuoteID.String()
 	}
+	if b.IsDaily() {
+		return dailyKeyPrefix + b.Day.Format(DayLayout)
+	}
 	return b.Mode + ":" + strconv.Itoa(int(b.Dimension)) + ":" + b.Lang + ":" + b.TextSource
 }
 
@@ -208,6 +292,13 @@ func (b Bucket) Key() string {
 // 404 as a malformed language key, because both mean "this link names no
 // board".
 func ParseBucketKey(key string) (Bucket, error) {
+	if base, period, ok := strings.Cut(key, periodSeparator); ok {
+		b, err := ParseBucketKey(base)
+		if err != nil {
+			return Bucket{}, err
+		}
+		return b.Over(period)
+	}
 	if rest, ok := strings.CutPrefix(key, quoteKeyPrefix); ok {
 		id, err := uuid.Parse(rest)
 		if err != nil {
@@ -222,10 +313,20 @@ func ParseBucketKey(key string) (Bucket, error) {
 		}
 		return NewQuoteBucket(id)
 	}
+	if rest, ok := strings.CutPrefix(key, dailyKeyPrefix); ok {
+		// time.Parse with this layout already refuses a missing zero-pad and a
+		// 31st of February, so a parsed day has exactly one spelling and Key
+		// gives back the string that was parsed.
+		day, err := time.Parse(DayLayout, rest)
+		if err != nil {
+			return Bucket{}, fmt.Errorf("%w: day %q is not a YYYY-MM-DD date", ErrInvalidBucket, rest)
+		}
+		return NewDailyBucket(day)
+	}
 
 	parts := strings.Split(key, ":")
 	if len(parts) != 4 {
-		return Bucket{}, fmt.Errorf("%w: want mode:dimension:lang:textSource or quote:<id>", ErrInvalidBucket)
+		return Bucket{}, fmt.Errorf("%w: want mode:dimension:lang:textSource, quote:<id> or daily:<date>", ErrInvalidBucket)
 	}
 	dim, err := strconv.Atoi(parts[1])
 	if err != nil {
@@ -240,12 +341,29 @@ func ParseBucketKey(key string) (Bucket, error) {
 
 // validate is the single rule set behind every constructor, so a parsed bucket
 // and a built one are the same thing. It also enforces that the two shapes stay
-// disjoint: a bucket is a quote board or a language board, never a hybrid
-// carrying half of each.
+// disjoint: a bucket is a quote, a daily or a language board, never a hybrid
+// carrying parts of two.
 func (b Bucket) validate() error {
+	if b.IsWindowed() {
+		if b.IsDaily() {
+			return fmt.Errorf("%w: a daily board is already one day's window", ErrInvalidBucket)
+		}
+		if _, err := ParsePeriodID(b.Period); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidBucket, err)
+		}
+	}
 	if b.IsQuote() {
+		if b.Mode != "" || b.Dimension != 0 || b.Lang != "" || b.TextSource != "" || b.IsDaily() {
+			return fmt.Errorf("%w: a quote board has no mode, size, language, text-source or day dimension", ErrInvalidBucket)
+		}
+		return nil
+	}
+	if b.IsDaily() {
 		if b.Mode != "" || b.Dimension != 0 || b.Lang != "" || b.TextSource != "" {
-			return fmt.Errorf("%w: a quote board has no mode, size, language or text-source dimension", ErrInvalidBucket)
+			return fmt.Errorf("%w: a daily board has no dimension but its day", ErrInvalidBucket)
+		}
+		if b.Day.Location() != time.UTC || !b.Day.Equal(b.Day.Truncate(24*time.Hour)) {
+			return fmt.Errorf("%w: a daily board's day must be a UTC midnight", ErrInvalidBucket)
 		}
 		return nil
 	}
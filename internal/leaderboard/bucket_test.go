@@ -2,6 +2,7 @@ package leaderboard_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -120,6 +121,23 @@ func TestParseBucketKeyRejectsJunk(t *testing.T) {
 		{"a quote key wearing a language key's shape", "quote:15000:en:seeded"},
 		{"a language key claiming a quote text source", "words:50:en:quote"},
 		{"a language key claiming a quote mode", "quote:50:en:seeded"},
+		// And the daily key space: a day is a calendar date, spelled one way.
+		{"daily prefix with nothing after it", "daily:"},
+		{"a day that is not a date", "daily:today"},
+		{"a day that is not on the calendar", "daily:2026-02-30"},
+		{"a day with a time", "daily:2026-10-17T00:00:00Z"},
+		{"a day without its zero padding", "daily:2026-1-7"},
+		{"a daily key wearing a language key's shape", "daily:15000:en:seeded"},
+		// And windows, which close a key rather than opening a new space.
+		{"a window with no id", "time:15000:en:seeded@"},
+		{"a window on junk", "zen:15000:en:seeded@2026-W42"},
+		{"a window that is no period", "time:15000:en:seeded@Week 42"},
+		{"a week without its zero padding", "time:15000:en:seeded@2026-W5"},
+		{"a week the year does not have", "time:15000:en:seeded@2025-W53"},
+		{"a month that is not on the calendar", "time:15000:en:seeded@2026-13"},
+		{"two windows", "time:15000:en:seeded@2026-W42@2026-10"},
+		{"a window on a day", "daily:2026-10-17@2026-W42"},
+		{"a window and nothing else", "@2026-W42"},
 	}
 
 	for _, tc := range cases {
@@ -174,3 +192,81 @@ func TestQuoteBucketKeySpace(t *testing.T) {
 		assert.False(t, language.IsQuote())
 	})
 }
+
+// The third key space, on the same terms as the quote one: "daily" is not a
+// mode, so the prefix decides, and a day is one UTC date with one spelling.
+func TestDailyBucketKeySpace(t *testing.T) {
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	b, err := leaderboard.NewDailyBucket(day)
+	require.NoError(t, err)
+	assert.Equal(t, "daily:2026-10-17", b.Key())
+	assert.True(t, b.IsDaily())
+	assert.False(t, b.IsQuote())
+
+	parsed, err := leaderboard.ParseBucketKey(b.Key())
+	require.NoError(t, err)
+	assert.Equal(t, b, parsed, "the key must round-trip: the endpoints parse what the projection wrote")
+	assert.Empty(t, parsed.Mode)
+	assert.Zero(t, parsed.Dimension)
+	assert.Empty(t, parsed.Lang)
+
+	t.Run("a day is a UTC midnight, not an instant", func(t *testing.T) {
+		_, err := leaderboard.NewDailyBucket(day.Add(time.Hour))
+		require.ErrorIs(t, err, leaderboard.ErrInvalidBucket,
+			"a time of day would be a second spelling of the same board")
+		_, err = leaderboard.NewDailyBucket(time.Date(2026, 10, 17, 0, 0, 0, 0, time.FixedZone("MSK", 3*3600)))
+		require.ErrorIs(t, err, leaderboard.ErrInvalidBucket)
+	})
+
+	t.Run("a language board cannot also be a day", func(t *testing.T) {
+		language, err := leaderboard.NewBucket(leaderboard.ModeTime, new(int32(15000)), nil, "en",
+			leaderboard.TextSourceSeeded)
+		require.NoError(t, err)
+		assert.False(t, language.IsDaily())
+		assert.NotEqual(t, language.Key(), b.Key())
+	})
+}
+
+// A window is the fourth thing a key can say, and it is said last: the board's
+// own key, an "@", and the period id. The all-time board and each of its windows
+// are distinct keys that round-trip, and dropping the window gets the all-time
+// board back exactly.
+func TestWindowedBucketKeySpace(t *testing.T) {
+	language, err := leaderboard.NewBucket(leaderboard.ModeTime, new(int32(60000)), nil, "en",
+		leaderboard.TextSourceSeeded)
+	require.NoError(t, err)
+	quote, err := leaderboard.NewQuoteBucket(uuid.MustParse("1f5f1f2c-6f0f-4d5a-9f0a-3f2a1b0c9d8e"))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		board  leaderboard.Bucket
+		period string
+		want   string
+	}{
+		{language, "2026-W42", "time:60000:en:seeded@2026-W42"},
+		{language, "2026-10", "time:60000:en:seeded@2026-10"},
+		{language, "s3-autumn", "time:60000:en:seeded@s3-autumn"},
+		{quote, "2026-W42", "quote:1f5f1f2c-6f0f-4d5a-9f0a-3f2a1b0c9d8e@2026-W42"},
+	} {
+		t.Run(tc.want, func(t *testing.T) {
+			w, err := tc.board.Over(tc.period)
+			require.NoError(t, err)
+			assert.True(t, w.IsWindowed())
+			assert.Equal(t, tc.want, w.Key())
+
+			parsed, err := leaderboard.ParseBucketKey(tc.want)
+			require.NoError(t, err)
+			assert.Equal(t, w, parsed, "the key must round-trip: the endpoints parse what the projection wrote")
+			assert.Equal(t, tc.board, parsed.AllTime())
+			assert.NotEqual(t, tc.board.Key(), w.Key())
+		})
+	}
+
+	t.Run("a daily board takes no window", func(t *testing.T) {
+		day, err := leaderboard.NewDailyBucket(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		_, err = day.Over("2026-W42")
+		require.ErrorIs(t, err, leaderboard.ErrInvalidBucket)
+	})
+}
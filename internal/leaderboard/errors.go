@@ -30,9 +30,43 @@ var (
 	// ranking; a request carrying two is asking for two different pages.
 	apiErrConflictingAsks = newAPIError(http.StatusBadRequest, "bad_request",
 		"cursor, before and around are mutually exclusive")
+	// A board ranks by score or by wpm, and by nothing else.
+	apiErrBadOrder = newAPIError(http.StatusBadRequest, "bad_request",
+		"order must be \"score\" or \"wpm\"")
+	// The index sorts by key, by size or by recency.
+	apiErrBadBoardSort = newAPIError(http.StatusBadRequest, "bad_request",
+		"sort must be \"key\", \"entries\" or \"recent\"")
+	// A filter that could never match a board is malformed rather than empty.
+	apiErrBadBoardFilter = newAPIError(http.StatusBadRequest, "bad_request",
+		"mode must be \"time\" or \"words\", source \"seeded\" or \"quote\", group a quote length group, lang a language code and minEntries a non-negative integer")
+	// A board is read whole or over the caller's circle, and no other way.
+	apiErrBadScope = newAPIError(http.StatusBadRequest, "bad_request",
+		"scope must be \"global\" or \"following\"")
 	// The only supported window anchor is `me`.
 	apiErrBadAround = newAPIError(http.StatusBadRequest, "bad_request",
 		"around must be \"me\"")
+	// A window this deployment does not maintain is refused rather than read:
+	// its board would be empty, and an empty board says nobody played.
+	apiErrBadWindow = newAPIError(http.StatusBadRequest, "bad_request",
+		"window must be a kind this server maintains (week, month, season), on a board without a window of its own")
+	// `?window=season` between seasons. Not an empty board: there is no board.
+	apiErrNoSeason = newAPIError(http.StatusNotFound, "no_season",
+		"no season is running")
+	// An archive that does not exist — a window still open, not yet frozen, or
+	// never a window at all. One answer for all three, like unknown_bucket.
+	apiErrUnknownSeason = newAPIError(http.StatusNotFound, "unknown_season",
+		"no such archived window")
+	// Rank history is kept for the all-time boards only (HasRankHistory). A
+	// day's or a window's board exists; its history does not.
+	apiErrNoHistory = newAPIError(http.StatusNotFound, "no_history",
+		"rank history is kept for all-time boards only")
+	// A percentile is read off the rank ladder, which the all-time boards
+	// alone have.
+	apiErrNoPercentile = newAPIError(http.StatusNotFound, "no_percentile",
+		"percentiles are kept for all-time boards only")
+	// A percentile lookup names one position: a score or a wpm, and in range.
+	apiErrBadPosition = newAPIError(http.StatusBadRequest, "bad_request",
+		"exactly one of score (an integer from 0 to 16777215) or wpm (a non-negative number) is required")
 	apiErrUnauthorized = newAPIError(http.StatusUnauthorized, "unauthorized",
 		"authentication required")
 	// The board-index bucket is empty. Same code and shape the auth, runs and
@@ -0,0 +1,218 @@
+package leaderboard_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The following scope (docs/LEADERBOARDS.md, "Following"): the same rows in
+// the same order, over the caller and the open profiles they follow.
+
+const page15s = "/api/v1/leaderboards/time:15000:en:seeded"
+
+func (b *board) follow(follower, followee uuid.UUID) {
+	b.t.Helper()
+	_, err := b.pool.Exec(context.Background(),
+		`INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)`, follower, followee)
+	require.NoError(b.t, err)
+}
+
+func (b *board) setProfilePublic(userID uuid.UUID, public bool) {
+	b.t.Helper()
+	_, err := b.pool.Exec(context.Background(),
+		`UPDATE users SET profile_public = $2 WHERE id = $1`, userID, public)
+	require.NoError(b.t, err)
+}
+
+type scopedPageBody struct {
+	Scope string `json:"scope"`
+	pageBody
+}
+
+func idsOf(body pageBody) []uuid.UUID {
+	out := make([]uuid.UUID, len(body.Entries))
+	for i, e := range body.Entries {
+		out[i] = e.UserID
+	}
+	return out
+}
+
+// A circle of four — the caller and three players they follow — on a board
+// of six. The two strangers outrank everyone in it.
+func followingBoard(t *testing.T) (b *board, me uuid.UUID, circle []uuid.UUID) {
+	b = newBoard(t)
+	for i, score := range []int64{9000, 8000} {
+		stranger := b.user("stranger-"+string(rune('a'+i)), true)
+		b.addRun(runSpec{user: stranger, score: score, achievedAt: minutesAgo(60)})
+	}
+	top := b.user("top", true)
+	me = b.user("myself", true)
+	mid := b.user("mid", true)
+	low := b.user("low", true)
+	b.addRun(runSpec{user: top, score: 4000, wpm: 90, achievedAt: minutesAgo(40)})
+	b.addRun(runSpec{user: me, score: 3000, wpm: 120, achievedAt: minutesAgo(30)})
+	b.addRun(runSpec{user: mid, score: 2000, wpm: 100, achievedAt: minutesAgo(20)})
+	b.addRun(runSpec{user: low, score: 1000, wpm: 80, achievedAt: minutesAgo(10)})
+	for _, u := range []uuid.UUID{top, mid, low} {
+		b.follow(me, u)
+	}
+	b.asUser = me
+	return b, me, []uuid.UUID{top, me, mid, low}
+}
+
+func TestFollowingScopeRanksTheCircle(t *testing.T) {
+	b, me, circle := followingBoard(t)
+
+	t.Run("the page", func(t *testing.T) {
+		body := decodeInto[scopedPageBody](t, b.get(page15s+"?scope=following"))
+		assert.Equal(t, "following", body.Scope)
+		assert.Equal(t, circle, idsOf(body.pageBody))
+		for i, e := range body.Entries {
+			assert.EqualValues(t, i+1, e.Rank, "ranked within the circle")
+		}
+	})
+
+	t.Run("the global page does not say so", func(t *testing.T) {
+		raw := decodeInto[map[string]any](t, b.get(page15s))
+		assert.NotContains(t, raw, "scope")
+		assert.Len(t, raw["entries"], 6)
+	})
+
+	t.Run("cursors continue within the circle", func(t *testing.T) {
+		one := decodeInto[pageBody](t, b.get(page15s+"?scope=following&limit=2"))
+		require.NotEmpty(t, one.NextCursor)
+		two := decodeInto[pageBody](t, b.get(page15s+"?scope=following&limit=2&cursor="+one.NextCursor))
+		assert.Equal(t, circle[2:], idsOf(two))
+		assert.EqualValues(t, 3, two.Entries[0].Rank)
+		assert.Empty(t, two.NextCursor)
+
+		up := decodeInto[aroundBody](t, b.get(page15s+"?scope=following&limit=5&before="+one.NextCursor))
+		require.Len(t, up.Entries, 1)
+		assert.Equal(t, circle[0], up.Entries[0].UserID)
+		assert.EqualValues(t, 1, up.Entries[0].Rank)
+	})
+
+	t.Run("around me", func(t *testing.T) {
+		body := decodeInto[aroundBody](t, b.get(page15s+"?scope=following&around=me&limit=3"))
+		assert.Equal(t, []int64{1, 2, 3}, ranksOf(body))
+		assert.Equal(t, me, body.Entries[1].UserID)
+	})
+
+	t.Run("me", func(t *testing.T) {
+		body := decodeInto[struct {
+			Scope string `json:"scope"`
+			Entry struct {
+				Rank int64 `json:"rank"`
+			} `json:"entry"`
+		}](t, b.get(page15s+"/me?scope=following"))
+		assert.Equal(t, "following", body.Scope)
+		assert.EqualValues(t, 2, body.Entry.Rank)
+
+		global := decodeInto[struct {
+			Entry struct {
+				Rank int64 `json:"rank"`
+			} `json:"entry"`
+		}](t, b.get(page15s+"/me"))
+		assert.EqualValues(t, 4, global.Entry.Rank)
+	})
+
+	t.Run("the wpm order", func(t *testing.T) {
+		body := decodeInto[pageBody](t, b.get(page15s+"?scope=following&order=wpm"))
+		assert.Equal(t, []uuid.UUID{me, circle[2], circle[0], circle[3]}, idsOf(body))
+	})
+
+	t.Run("percentile", func(t *testing.T) {
+		body := decodeInto[struct {
+			Rank       int64   `json:"rank"`
+			Entries    int64   `json:"entries"`
+			Percentile float64 `json:"percentile"`
+			Above      []struct {
+				UserID uuid.UUID `json:"userId"`
+			} `json:"above"`
+		}](t, b.get(page15s+"/percentile?scope=following&score=2500"))
+		assert.EqualValues(t, 3, body.Rank, "below top and me, ahead of mid")
+		assert.EqualValues(t, 4, body.Entries)
+		assert.InDelta(t, 75.0, body.Percentile, 1e-9)
+		require.Len(t, body.Above, 2)
+		assert.Equal(t, me, body.Above[1].UserID)
+	})
+}
+
+// A followee who closes their profile leaves every follower's board until they
+// reopen it; the follow itself is kept.
+func TestFollowingScopeLeavesOutClosedProfiles(t *testing.T) {
+	b, _, circle := followingBoard(t)
+	top := circle[0]
+
+	b.setProfilePublic(top, false)
+	body := decodeInto[pageBody](t, b.get(page15s+"?scope=following"))
+	assert.Equal(t, circle[1:], idsOf(body))
+	assert.EqualValues(t, 1, body.Entries[0].Rank)
+
+	b.asUser = top
+	own := decodeInto[pageBody](t, b.get(page15s+"?scope=following"))
+	assert.Equal(t, []uuid.UUID{top}, idsOf(own), "a closed profile still sees itself")
+
+	b.asUser = circle[1]
+	b.setProfilePublic(top, true)
+	assert.Equal(t, circle, idsOf(decodeInto[pageBody](t, b.get(page15s+"?scope=following"))))
+}
+
+// A banned member is hidden in the circle exactly as on the board.
+func TestFollowingScopeHidesBannedMembers(t *testing.T) {
+	b, _, circle := followingBoard(t)
+	b.ban(circle[0], nil)
+	body := decodeInto[pageBody](t, b.get(page15s+"?scope=following"))
+	assert.Equal(t, circle[1:], idsOf(body))
+}
+
+func TestFollowingScopeRejects(t *testing.T) {
+	b, _, _ := followingBoard(t)
+
+	assert.Equal(t, http.StatusBadRequest, b.get(page15s+"?scope=friends").StatusCode)
+	assert.Equal(t, http.StatusOK, b.get(page15s+"?scope=global").StatusCode)
+
+	b.asUser = uuid.Nil
+	for _, path := range []string{
+		page15s + "?scope=following",
+		page15s + "/percentile?scope=following&score=100",
+		page15s + "/seasons/2026-W10?scope=following",
+	} {
+		assert.Equal(t, http.StatusUnauthorized, b.get(path).StatusCode, path)
+	}
+}
+
+// A frozen window read in the following scope is the circle's share of the
+// standings, renumbered in the order they froze.
+func TestFollowingScopeOverFrozenStandings(t *testing.T) {
+	b, clock := newWindowedBoard(t)
+	first := b.user("first", true)
+	second := b.user("second", true)
+	third := b.user("third", true)
+	b.addRun(runSpec{user: first, score: 3000, achievedAt: wednesday})
+	b.addRun(runSpec{user: second, score: 2000, achievedAt: wednesday})
+	b.addRun(runSpec{user: third, score: 1000, achievedAt: wednesday})
+	b.follow(third, first)
+
+	clock.at = time.Date(2026, 3, 9, 1, 0, 0, 0, time.UTC)
+	_, err := b.store.FreezeClosed(context.Background())
+	require.NoError(t, err)
+
+	b.asUser = third
+	body := decodeInto[struct {
+		Scope string `json:"scope"`
+		seasonBody
+	}](t, b.get(page15s+"/seasons/2026-W10?scope=following"))
+	assert.Equal(t, "following", body.Scope)
+	require.Len(t, body.Entries, 2)
+	assert.Equal(t, first, body.Entries[0].UserID)
+	assert.EqualValues(t, 1, body.Entries[0].Rank)
+	assert.Equal(t, third, body.Entries[1].UserID)
+	assert.EqualValues(t, 2, body.Entries[1].Rank, "renumbered within the circle")
+}
@@ -0,0 +1,53 @@
+package leaderboard
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// FreezeStats is what one freezer pass did.
+type FreezeStats struct {
+	// Periods is how many windows were archived by this pass.
+	Periods int
+	// Archived is how many rows their final standings hold.
+	Archived int64
+	// Stragglers is how many live rows were deleted from windows an earlier
+	// pass had already archived — a projection that landed after the freeze,
+	// which only a clock skewed past the settle period can produce.
+	Stragglers int64
+}
+
+// Freezer archives the windows that have closed. The Postgres store implements
+// it; it is declared here, at the consumer, like the auth janitor's Cleaner.
+type Freezer interface {
+	FreezeClosed(ctx context.Context) (FreezeStats, error)
+}
+
+// RunFreezer archives closed windows once immediately and then every interval,
+// until ctx is cancelled. Started as a goroutine from the composition root.
+//
+// Any number of instances may run it: claiming a window is an insert that only
+// one of them wins, and the others find nothing left to do.
+func RunFreezer(ctx context.Context, f Freezer, interval time.Duration, log *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stats, err := f.FreezeClosed(ctx)
+		switch {
+		case ctx.Err() != nil:
+		case err != nil:
+			// A window left unfrozen is still readable live and the next tick
+			// retries it; a failure here must never take the server down.
+			log.ErrorContext(ctx, "leaderboard: freeze closed windows failed", "err", err)
+		case stats.Periods > 0 || stats.Stragglers > 0:
+			log.InfoContext(ctx, "leaderboard: froze closed windows",
+				"periods", stats.Periods, "archived", stats.Archived, "stragglers", stats.Stragglers)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
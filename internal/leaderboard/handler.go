@@ -1,8 +1,10 @@
 package leaderboard
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -19,17 +21,51 @@ const (
 	maxLimit     = 100
 )
 
+// Pagination bounds for the board index. A row there is a few fields rather
+// than a player, so a page is larger: the language boards fit on one.
+const (
+	defaultIndexLimit = 100
+	maxIndexLimit     = 500
+)
+
+// Bounds for a rank history: a month of daily movement by default, and a year
+// of it at most.
+const (
+	defaultHistoryLimit = 30
+	maxHistoryLimit     = 365
+)
+
+// Neighbours a percentile lookup returns on EACH side of the position: enough
+// to say who you would be chasing and who you would be holding off.
+const (
+	defaultNeighbours = 3
+	maxNeighbours     = 10
+)
+
+// maxBoardScore is the largest score an entry can hold: the table's CHECK
+// keeps scores under 2^24 so sort_key's packing cannot overflow (00011). A
+// lookup above it asks where a score no run can have would rank.
+const maxBoardScore = 1<<24 - 1
+
 // Routes returns the leaderboard router, mounted at /api/v1/leaderboards.
 //
 // The whole subtree is PUBLIC: a leaderboard nobody can read without an account
-// is a leaderboard nobody links to. `/me` is the one route that needs a session,
-// and it enforces that itself rather than dragging the group behind middleware
-// the other two must not have.
+// is a leaderboard nobody links to. `/me` and `/me/history` are the routes that
+// need a session, and they enforce that themselves rather than dragging the
+// group behind middleware the others must not have — as does every read asked
+// for with `?scope=following`, which is a question about the caller.
+//
+// `/{bucket}/seasons/{id}` reads a window's frozen standings — a week, a month
+// or a named season, archived when it closed. "seasons" names the route after
+// the case people ask for; the id says which kind it is.
 func (s *Service) Routes() http.Handler {
 	r := chi.NewRouter()
 	r.With(s.rateLimitIndex).Get("/", s.handleBuckets)
 	r.Get("/{bucket}", s.handlePage)
 	r.Get("/{bucket}/me", s.handleMe)
+	r.Get("/{bucket}/me/history", s.handleRankHistory)
+	r.Get("/{bucket}/percentile", s.handlePercentile)
+	r.Get("/{bucket}/seasons/{id}", s.handleSeason)
 	return r
 }
 
@@ -52,25 +88,39 @@ func (s *Service) rateLimitIndex(next http.Handler) http.Handler {
 // bucketView is one board in the index. A language board renders its dimension
 // under the name its mode gives it, so a client never has to know that "the
 // number" means milliseconds here and words there; a quote board renders its
-// quote id and NOTHING else, because it has nothing else — the fields a
-// language board fills are absent rather than empty, so a client cannot read a
-// mode off a board that has none.
+// quote id, and the quote's language and length group, since those are what a
+// client picks quotes by — and NOTHING else: the fields only a language board
+// has are absent rather than empty, so a client cannot read a mode off a board
+// that has none. A daily board renders its day, on the same terms; what that
+// day's challenge was is GET /daily/archive's to say.
 type bucketView struct {
 	Bucket     string     `json:"bucket"`
 	QuoteID    *uuid.UUID `json:"quoteId,omitempty"`
+	Day        string     `json:"day,omitempty"`
 	Mode       string     `json:"mode,omitempty"`
 	DurationMs *int32     `json:"durationMs,omitempty"`
 	WordCount  *int32     `json:"wordCount,omitempty"`
 	Lang       string     `json:"lang,omitempty"`
 	TextSource string     `json:"textSource,omitempty"`
+	LenGroup   string     `json:"lenGroup,omitempty"`
 	Entries    int64      `json:"entries"`
+	ChangedAt  time.Time  `json:"changedAt"`
 }
 
-func toBucketView(b Bucket, entries int64) bucketView {
-	v := bucketView{Bucket: b.Key(), Entries: entries}
+func (s *Service) toBucketView(board Board) bucketView {
+	b := board.Bucket
+	v := bucketView{Bucket: b.Key(), Entries: board.Entries, ChangedAt: board.ChangedAt}
 	if b.IsQuote() {
 		id := b.QuoteID
 		v.QuoteID = &id
+		v.Lang = board.Lang
+		if board.LenGroup != nil && s.quoteGroups != nil {
+			v.LenGroup = s.quoteGroups.GroupName(*board.LenGroup)
+		}
+		return v
+	}
+	if b.IsDaily() {
+		v.Day = b.Day.Format(DayLayout)
 		return v
 	}
 	v.Mode, v.Lang, v.TextSource = b.Mode, b.Lang, b.TextSource
@@ -84,51 +134,148 @@ func toBucketView(b Bucket, entries int64) bucketView {
 }
 
 type bucketsResponse struct {
-	Buckets []bucketView `json:"buckets"`
+	// Sort is the order the page is in, echoed for the same reason a board
+	// page echoes its order: a cursor only continues the sort that minted it.
+	Sort       BoardSort    `json:"sort"`
+	Buckets    []bucketView `json:"buckets"`
+	NextCursor string       `json:"nextCursor,omitempty"`
 }
 
-// handleBuckets lists the boards that currently hold at least one visible entry.
-// Empty boards are absent rather than enumerated: a board with nothing in it is
-// not news, and for quotes it is also the only thing keeping this response
-// finite — there are 9 881 of them (docs/LEADERBOARDS.md, "The board index").
+// handleBuckets serves one page of the boards that currently hold at least one
+// visible entry. Empty boards are absent rather than enumerated: a board with
+// nothing in it is not news, and there are 9 881 quote boards that could be
+// (docs/LEADERBOARDS.md, "The board index").
+//
+// Everything is optional. The filters AND together:
+//
+//	mode=time|words      language boards of that mode
+//	lang=                boards in that language; a quote board's is its quote's
+//	source=seeded|quote  language boards, or quote boards
+//	group=               quote boards of that length group (short … thicc)
+//	minEntries=          boards with at least that many visible players
+//	sort=key|entries|recent   key order (default), biggest first, or the
+//	                     most recently changed first
+//
+// A daily board has no mode, language, source or group, so any of those
+// filters leaves the daily boards out; they are listed with none of them set.
+//
 // A withdrawn quote's board is absent from this list and from this list ONLY
 // (docs/REPORTS.md): the board still answers on its own URL, its entries keep
 // their ranks, and the runs behind them stay replayable. What a withdrawal
 // takes away is the quote being OFFERED — in browsing, in random selection, and
 // here — never a result somebody already earned.
 func (s *Service) handleBuckets(w http.ResponseWriter, r *http.Request) {
-	counts, err := s.store.Buckets(r.Context())
+	q := r.URL.Query()
+	sort, err := ParseBoardSort(q.Get("sort"))
 	if err != nil {
-		s.writeError(w, r, err)
+		s.writeError(w, r, apiErrBadBoardSort)
+		return
+	}
+	filter, ok := s.boardFilterParams(w, r)
+	if !ok {
 		return
 	}
+	var after *BoardCursor
+	if raw := q.Get("cursor"); raw != "" {
+		c, err := decodeBoardCursor(sort, raw)
+		if err != nil {
+			s.writeError(w, r, apiErrBadCursor)
+			return
+		}
+		after = &c
+	}
+	limit := httpx.ParseLimit(q.Get("limit"), defaultIndexLimit, maxIndexLimit)
+
 	withdrawn, err := s.withdrawn(r.Context())
 	if err != nil {
 		s.writeError(w, r, err)
 		return
 	}
-	views := make([]bucketView, 0, len(counts))
-	for i := range counts {
-		b := counts[i].Bucket
-		if b.IsQuote() {
-			if _, gone := withdrawn[b.QuoteID]; gone {
-				continue
-			}
-		}
-		views = append(views, toBucketView(b, counts[i].Entries))
+	for id := range withdrawn {
+		filter.Withdrawn = append(filter.Withdrawn, id)
+	}
+
+	boards, err := s.store.Boards(r.Context(), filter, sort, after, int32(limit+1))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	next := ""
+	if len(boards) > limit {
+		boards = boards[:limit]
+		next = encodeBoardCursor(sort, boards[limit-1])
+	}
+	views := make([]bucketView, len(boards))
+	for i := range boards {
+		views[i] = s.toBucketView(boards[i])
 	}
 	// A minute of shared caching, and it is the limiter's other half: the bucket
-	// bounds one caller, this bounds how often ALL of them can reach the two
-	// aggregations behind this route. `public` because the answer carries
-	// nothing reader-specific — the index is the same bytes for everyone, signed
-	// in or not — so a CDN or a browser cache may serve it to anybody.
+	// bounds one caller, this bounds how often ALL of them can reach the reads
+	// behind this route. `public` because the answer carries nothing
+	// reader-specific — the index is the same bytes for everyone, signed in or
+	// not — so a CDN or a browser cache may serve it to anybody. Every filter
+	// and cursor is in the URL, so each page is cached as its own answer.
 	//
 	// A minute is chosen against what the response actually says: a board
 	// appearing in the index at all takes a first accepted run on it, and a
 	// withdrawal is a moderator's deliberate act. Neither is something a reader
-	// is waiting on to the second, and the per-board reads are uncached.
+	// is waiting on to the second, and the per-board reads are uncached. The
+	// recent sort is the one a minute shows: its order can be a minute old.
 	w.Header().Set("Cache-Control", "public, max-age=60")
-	s.writeJSON(w, http.StatusOK, bucketsResponse{Buckets: views})
+	s.writeJSON(w, http.StatusOK, bucketsResponse{Sort: sort, Buckets: views, NextCursor: next})
+}
+
+// boardFilterParams reads the index's filters, writing a 400 for any that is
+// malformed. A well-formed filter that matches nothing is an empty page, not
+// an error: `lang=klingon` is a fine question with no boards in its answer.
+func (s *Service) boardFilterParams(w http.ResponseWriter, r *http.Request) (BoardFilter, bool) {
+	q := r.URL.Query()
+	var f BoardFilter
+
+	switch mode := q.Get("mode"); mode {
+	case "", ModeTime, ModeWords:
+		f.Mode = mode
+	default:
+		s.writeError(w, r, apiErrBadBoardFilter)
+		return BoardFilter{}, false
+	}
+	switch source := q.Get("source"); source {
+	case "", TextSourceSeeded, TextSourceQuote:
+		f.TextSource = source
+	default:
+		s.writeError(w, r, apiErrBadBoardFilter)
+		return BoardFilter{}, false
+	}
+	if lang := q.Get("lang"); lang != "" {
+		// The charset a language component of a key may have, so a filter
+		// can name every language a board can be in and nothing else.
+		if validComponent("lang", lang, maxLangLen) != nil {
+			s.writeError(w, r, apiErrBadBoardFilter)
+			return BoardFilter{}, false
+		}
+		f.Lang = lang
+	}
+	if raw := q.Get("group"); raw != "" {
+		if s.quoteGroups == nil {
+			s.writeError(w, r, apiErrBadBoardFilter)
+			return BoardFilter{}, false
+		}
+		g, ok := s.quoteGroups.ParseGroup(raw)
+		if !ok {
+			s.writeError(w, r, apiErrBadBoardFilter)
+			return BoardFilter{}, false
+		}
+		f.LenGroup = &g
+	}
+	if raw := q.Get("minEntries"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			s.writeError(w, r, apiErrBadBoardFilter)
+			return BoardFilter{}, false
+		}
+		f.MinEntries = n
+	}
+	return f, true
 }
 
 // entryView is one ranked row.
@@ -159,7 +306,13 @@ func toEntryView(e Entry) entryView {
 }
 
 type pageResponse struct {
-	Bucket  string      `json:"bucket"`
+	Bucket string `json:"bucket"`
+	// Order is the ranking the page is in. Echoed because a cursor only
+	// continues the order that minted it.
+	Order Order `json:"order"`
+	// Scope is "following" on a board read over the caller's circle, and
+	// absent on the global one, which is every page served before scopes.
+	Scope   Scope       `json:"scope,omitempty"`
 	Entries []entryView `json:"entries"`
 	// PrevCursor continues UPWARD (rows outranking the first one here), via
 	// `?before=`. Present only when the first row is not rank 1.
@@ -169,6 +322,14 @@ type pageResponse struct {
 
 // handlePage returns one page of a bucket's ranking, keyset-paginated.
 //
+// `?order=` picks the ranking (score by default, or wpm) and applies to every
+// shape below alike; a cursor minted under one order is refused by the other.
+// `?window=` reads the board over the current week, month or season instead of
+// all time; the page's `bucket` is then the windowed key, and its cursors
+// continue that board. `?scope=following` narrows any of it to the caller and
+// the players they follow; a cursor is a position, not a rank, so one minted
+// in either scope continues the other.
+//
 // Three shapes of ask, decided by the query string:
 //
 //	(nothing)     page one, from rank 1
@@ -184,6 +345,18 @@ func (s *Service) handlePage(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	bucket, ok = s.windowParam(w, r, bucket)
+	if !ok {
+		return
+	}
+	order, ok := s.orderParam(w, r)
+	if !ok {
+		return
+	}
+	store, scope, ok := s.scopeParam(w, r)
+	if !ok {
+		return
+	}
 
 	limit := httpx.ParseLimit(r.URL.Query().Get("limit"), defaultLimit, maxLimit)
 
@@ -201,24 +374,24 @@ func (s *Service) handlePage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.URL.Query().Get("around") != "" {
-		s.handleAround(w, r, bucket, limit)
+		s.handleAround(w, r, store, scope, bucket, order, limit)
 		return
 	}
 	if raw := r.URL.Query().Get("before"); raw != "" {
-		s.handleBefore(w, r, bucket, raw, limit)
+		s.handleBefore(w, r, store, scope, bucket, order, raw, limit)
 		return
 	}
 
 	var after *Cursor
 	firstRank := int64(1)
 	if raw := r.URL.Query().Get("cursor"); raw != "" {
-		cur, err := decodeCursor(raw)
+		cur, err := decodeCursor(order, raw)
 		if err != nil {
 			s.writeError(w, r, apiErrBadCursor)
 			return
 		}
 		after = &cur
-		above, err := s.store.RankAbove(r.Context(), bucket, cur)
+		above, err := store.RankAbove(r.Context(), bucket, order, cur)
 		if err != nil {
 			s.writeError(w, r, err)
 			return
@@ -229,7 +402,7 @@ func (s *Service) handlePage(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch one extra row to learn whether another page exists without a second
 	// query or an empty trailing page.
-	rows, err := s.store.Page(r.Context(), bucket, after, int32(limit+1))
+	rows, err := store.Page(r.Context(), bucket, order, after, int32(limit+1))
 	if err != nil {
 		s.writeError(w, r, err)
 		return
@@ -238,10 +411,12 @@ func (s *Service) handlePage(w http.ResponseWriter, r *http.Request) {
 	next := ""
 	if len(rows) > limit {
 		rows = rows[:limit]
-		next = cursorOf(rows[limit-1])
+		next = cursorOf(order, rows[limit-1])
 	}
 
-	s.writeJSON(w, http.StatusOK, s.pageView(bucket, rows, firstRank, next))
+	view := s.pageView(bucket, order, rows, firstRank, next)
+	view.Scope = scopeView(scope)
+	s.writeJSON(w, http.StatusOK, view)
 }
 
 // handleAround serves `?around=me`: the window centred on the caller's own
@@ -251,9 +426,10 @@ func (s *Service) handlePage(w http.ResponseWriter, r *http.Request) {
 //
 // The window is the caller's row, up to half the limit above it (fewer only at
 // the top of the board — the spare capacity goes below), and the remainder
-// below. Both sides come off leaderboard_sort_idx as start-condition scans:
-// the rows above are the same seek as the downward continuation, run backward.
-func (s *Service) handleAround(w http.ResponseWriter, r *http.Request, bucket Bucket, limit int) {
+// below. Both sides come off the order's index (leaderboard_sort_idx or
+// leaderboard_wpm_idx) as start-condition scans: the rows above are the same
+// seek as the downward continuation, run backward.
+func (s *Service) handleAround(w http.ResponseWriter, r *http.Request, store Store, scope Scope, bucket Bucket, order Order, limit int) {
 	if r.URL.Query().Get("around") != "me" {
 		// The only window anyone has asked for is "me"; an unknown anchor is a
 		// bad request, not an empty page.
@@ -266,7 +442,7 @@ func (s *Service) handleAround(w http.ResponseWriter, r *http.Request, bucket Bu
 		return
 	}
 
-	me, err := s.store.EntryFor(r.Context(), bucket, userID)
+	me, err := store.EntryFor(r.Context(), bucket, order, userID)
 	if errors.Is(err, ErrNoEntry) {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -285,7 +461,7 @@ func (s *Service) handleAround(w http.ResponseWriter, r *http.Request, bucket Bu
 		wantAbove = above
 	}
 	wantBelow := limit - 1 - wantAbove
-	below, err := s.store.Page(r.Context(), bucket, ptr(cursorFor(me)), int32(wantBelow+1))
+	below, err := store.Page(r.Context(), bucket, order, ptr(cursorFor(me)), int32(wantBelow+1))
 	if err != nil {
 		s.writeError(w, r, err)
 		return
@@ -294,9 +470,9 @@ func (s *Service) handleAround(w http.ResponseWriter, r *http.Request, bucket Bu
 	if len(below) > wantBelow {
 		below = below[:wantBelow]
 		if wantBelow > 0 {
-			next = cursorOf(below[wantBelow-1])
+			next = cursorOf(order, below[wantBelow-1])
 		} else {
-			next = cursorOf(me)
+			next = cursorOf(order, me)
 		}
 	} else if spare := min(limit-1-len(below), int(me.Rank-1)); spare > wantAbove {
 		// The board ran out below; spend the spare capacity above.
@@ -305,7 +481,7 @@ func (s *Service) handleAround(w http.ResponseWriter, r *http.Request, bucket Bu
 
 	above := []Entry{}
 	if wantAbove > 0 {
-		above, err = s.store.PageBefore(r.Context(), bucket, cursorFor(me), int32(wantAbove))
+		above, err = store.PageBefore(r.Context(), bucket, order, cursorFor(me), int32(wantAbove))
 		if err != nil {
 			s.writeError(w, r, err)
 			return
@@ -320,24 +496,24 @@ func (s *Service) handleAround(w http.ResponseWriter, r *http.Request, bucket Bu
 	firstRank := me.Rank - int64(len(above))
 	prev := ""
 	if firstRank > 1 {
-		prev = cursorOf(rows[0])
+		prev = cursorOf(order, rows[0])
 	}
 
-	view := s.pageView(bucket, rows, firstRank, next)
-	view.PrevCursor = prev
+	view := s.pageView(bucket, order, rows, firstRank, next)
+	view.PrevCursor, view.Scope = prev, scopeView(scope)
 	s.writeJSON(w, http.StatusOK, view)
 }
 
 // handleBefore serves `?before=`: the rows strictly outranking a position,
 // nearest last, so a client prepends the page verbatim.
-func (s *Service) handleBefore(w http.ResponseWriter, r *http.Request, bucket Bucket, raw string, limit int) {
-	cur, err := decodeCursor(raw)
+func (s *Service) handleBefore(w http.ResponseWriter, r *http.Request, store Store, scope Scope, bucket Bucket, order Order, raw string, limit int) {
+	cur, err := decodeCursor(order, raw)
 	if err != nil {
 		s.writeError(w, r, apiErrBadCursor)
 		return
 	}
 
-	rows, err := s.store.PageBefore(r.Context(), bucket, cur, int32(limit))
+	rows, err := store.PageBefore(r.Context(), bucket, order, cur, int32(limit))
 	if err != nil {
 		s.writeError(w, r, err)
 		return
@@ -345,7 +521,7 @@ func (s *Service) handleBefore(w http.ResponseWriter, r *http.Request, bucket Bu
 
 	// The rank of the position, counted fresh; everything returned sits in the
 	// len(rows) slots directly above it.
-	above, err := s.store.RankAbove(r.Context(), bucket, cur)
+	above, err := store.RankAbove(r.Context(), bucket, order, cur)
 	if err != nil {
 		s.writeError(w, r, err)
 		return
@@ -354,53 +530,71 @@ func (s *Service) handleBefore(w http.ResponseWriter, r *http.Request, bucket Bu
 
 	prev := ""
 	if len(rows) > 0 && firstRank > 1 {
-		prev = cursorOf(rows[0])
+		prev = cursorOf(order, rows[0])
 	}
 
-	view := s.pageView(bucket, rows, firstRank, "")
-	view.PrevCursor = prev
+	view := s.pageView(bucket, order, rows, firstRank, "")
+	view.PrevCursor, view.Scope = prev, scopeView(scope)
 	s.writeJSON(w, http.StatusOK, view)
 }
 
 // pageView ranks the rows from firstRank on and wraps them in the wire shape.
-func (s *Service) pageView(bucket Bucket, rows []Entry, firstRank int64, next string) pageResponse {
+func (s *Service) pageView(bucket Bucket, order Order, rows []Entry, firstRank int64, next string) pageResponse {
 	views := make([]entryView, len(rows))
 	for i := range rows {
 		rows[i].Rank = firstRank + int64(i)
 		views[i] = toEntryView(rows[i])
 	}
-	return pageResponse{Bucket: bucket.Key(), Entries: views, NextCursor: next}
+	return pageResponse{Bucket: bucket.Key(), Order: order, Entries: views, NextCursor: next}
 }
 
-// cursorFor is the keyset position OF an entry; cursorOf is its wire token.
+// cursorFor is the keyset position OF an entry, under either order; cursorOf
+// is its wire token under one.
 func cursorFor(e Entry) Cursor {
-	return Cursor{Score: e.Score, AchievedAt: e.AchievedAt, UserID: e.UserID}
+	return Cursor{Score: e.Score, WPM: e.WPM, AchievedAt: e.AchievedAt, UserID: e.UserID}
 }
 
-func cursorOf(e Entry) string { return encodeCursor(cursorFor(e)) }
+func cursorOf(o Order, e Entry) string { return encodeCursor(o, cursorFor(e)) }
 
 func ptr[T any](v T) *T { return &v }
 
 type meResponse struct {
 	Bucket string    `json:"bucket"`
+	Order  Order     `json:"order"`
+	Scope  Scope     `json:"scope,omitempty"`
 	Entry  entryView `json:"entry"`
 }
 
 // handleMe returns the caller's own rank and entry in a bucket, or 204 when they
 // hold no visible slot there. It is the one route in this domain that needs a
-// session, so it checks for one itself.
+// session, so it checks for one itself. `?order=` picks which ranking the rank
+// is counted in; the entry itself is the same row under either. `?window=`
+// asks the same question of the current week, month or season, and
+// `?scope=following` counts the rank among the players the caller follows.
 func (s *Service) handleMe(w http.ResponseWriter, r *http.Request) {
 	bucket, ok := s.bucketParam(w, r)
 	if !ok {
 		return
 	}
+	bucket, ok = s.windowParam(w, r, bucket)
+	if !ok {
+		return
+	}
+	order, ok := s.orderParam(w, r)
+	if !ok {
+		return
+	}
 	userID, ok := s.userID(r.Context())
 	if !ok {
 		s.writeError(w, r, apiErrUnauthorized)
 		return
 	}
+	store, scope, ok := s.scopeParam(w, r)
+	if !ok {
+		return
+	}
 
-	entry, err := s.store.EntryFor(r.Context(), bucket, userID)
+	entry, err := store.EntryFor(r.Context(), bucket, order, userID)
 	if errors.Is(err, ErrNoEntry) {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -409,7 +603,182 @@ func (s *Service) handleMe(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, r, err)
 		return
 	}
-	s.writeJSON(w, http.StatusOK, meResponse{Bucket: bucket.Key(), Entry: toEntryView(entry)})
+	s.writeJSON(w, http.StatusOK, meResponse{
+		Bucket: bucket.Key(), Order: order, Scope: scopeView(scope), Entry: toEntryView(entry),
+	})
+}
+
+type rankPointView struct {
+	At    time.Time `json:"at"`
+	Rank  int64     `json:"rank"`
+	Score int64     `json:"score"`
+}
+
+type lastVisitView struct {
+	Rank   int64     `json:"rank"`
+	SeenAt time.Time `json:"seenAt"`
+}
+
+type rankHistoryResponse struct {
+	Bucket string `json:"bucket"`
+	// Rank is the caller's rank now, in the score order; null when they hold
+	// no visible slot.
+	Rank *int64 `json:"rank"`
+	// LastVisit is the rank this route showed them last time, and Moved how
+	// far they have climbed since — negative for a fall. Both null on a first
+	// visit, or with no slot to compare.
+	LastVisit *lastVisitView `json:"lastVisit"`
+	Moved     *int64         `json:"moved"`
+	// Points is their recorded history, newest first.
+	Points []rankPointView `json:"points"`
+}
+
+// handleRankHistory returns the caller's rank over time on an all-time board,
+// and how far they have moved since they last asked.
+//
+// Points come from the snapshotter and are as fresh as its last pass; Rank is
+// counted now, exactly as /me counts it, so "moved" compares two answers /me
+// would have given. Reading this route IS the visit: it records the rank it
+// showed, and the next read measures from there.
+func (s *Service) handleRankHistory(w http.ResponseWriter, r *http.Request) {
+	bucket, ok := s.bucketParam(w, r)
+	if !ok {
+		return
+	}
+	if !bucket.HasRankHistory() {
+		s.writeError(w, r, apiErrNoHistory)
+		return
+	}
+	userID, ok := s.userID(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return
+	}
+	limit := httpx.ParseLimit(r.URL.Query().Get("limit"), defaultHistoryLimit, maxHistoryLimit)
+
+	points, err := s.store.RankHistory(r.Context(), bucket, userID, int32(limit))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	resp := rankHistoryResponse{Bucket: bucket.Key(), Points: make([]rankPointView, len(points))}
+	for i, p := range points {
+		resp.Points[i] = rankPointView{At: p.At, Rank: p.Rank, Score: p.Score}
+	}
+
+	entry, err := s.store.EntryFor(r.Context(), bucket, OrderScore, userID)
+	switch {
+	case errors.Is(err, ErrNoEntry):
+		// Off the board — banned, demoted, never played. Nothing to measure
+		// from, and nothing worth remembering as the rank they were shown.
+		s.writeJSON(w, http.StatusOK, resp)
+		return
+	case err != nil:
+		s.writeError(w, r, err)
+		return
+	}
+	resp.Rank = &entry.Rank
+	prev, seen, err := s.store.SwapSeenRank(r.Context(), bucket, userID,
+		RankPoint{At: s.now(), Rank: entry.Rank, Score: entry.Score})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if seen {
+		resp.LastVisit = &lastVisitView{Rank: prev.Rank, SeenAt: prev.At}
+		resp.Moved = ptr(prev.Rank - entry.Rank)
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+type percentileResponse struct {
+	Bucket string `json:"bucket"`
+	Order  Order  `json:"order"`
+	Scope  Scope  `json:"scope,omitempty"`
+	// Score or WPM echoes the position asked about; exactly one is present.
+	Score      *int64   `json:"score,omitempty"`
+	WPM        *float64 `json:"wpm,omitempty"`
+	Rank       int64    `json:"rank"`
+	Entries    int64    `json:"entries"`
+	Percentile float64  `json:"percentile"`
+	// Above is the entries nearest above the position, nearest last; Below
+	// the entries nearest below it, nearest first. Ranked as the board
+	// stands: the position itself is nobody's row.
+	Above []entryView `json:"above"`
+	Below []entryView `json:"below"`
+}
+
+// handlePercentile answers "what rank would this be": where a score
+// (`?score=`) or a speed (`?wpm=`) sits on an all-time board, as a rank, a
+// share of the board, and the entries either side of it.
+//
+// The position is a run achieved NOW, so everyone already holding the same
+// number is counted above it — the board's tie rule, applied to a newcomer.
+// The count comes off the rank ladder (Store.Standing), which is what keeps a
+// lookup near the bottom of a large board as cheap as one near the top; the
+// neighbours are the ordinary keyset pages either side. Under
+// `?scope=following` the board is the caller's circle, counted live.
+func (s *Service) handlePercentile(w http.ResponseWriter, r *http.Request) {
+	bucket, ok := s.bucketParam(w, r)
+	if !ok {
+		return
+	}
+	if !bucket.HasRankHistory() {
+		s.writeError(w, r, apiErrNoPercentile)
+		return
+	}
+	store, scope, ok := s.scopeParam(w, r)
+	if !ok {
+		return
+	}
+
+	resp := percentileResponse{Bucket: bucket.Key(), Scope: scopeView(scope)}
+	at := Cursor{AchievedAt: s.now(), UserID: uuid.Max}
+	rawScore, rawWPM := r.URL.Query().Get("score"), r.URL.Query().Get("wpm")
+	switch {
+	case rawScore != "" && rawWPM == "":
+		score, err := strconv.ParseInt(rawScore, 10, 64)
+		if err != nil || score < 0 || score > maxBoardScore {
+			s.writeError(w, r, apiErrBadPosition)
+			return
+		}
+		resp.Order, resp.Score, at.Score = OrderScore, &score, score
+	case rawWPM != "" && rawScore == "":
+		wpm, err := strconv.ParseFloat(rawWPM, 64)
+		if err != nil || wpm < 0 || math.IsInf(wpm, 0) || math.IsNaN(wpm) {
+			s.writeError(w, r, apiErrBadPosition)
+			return
+		}
+		resp.Order, resp.WPM, at.WPM = OrderWPM, &wpm, wpm
+	default:
+		s.writeError(w, r, apiErrBadPosition)
+		return
+	}
+	limit := httpx.ParseLimit(r.URL.Query().Get("limit"), defaultNeighbours, maxNeighbours)
+
+	standing, err := store.Standing(r.Context(), bucket, resp.Order, at)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	resp.Rank, resp.Entries, resp.Percentile = standing.Rank, standing.Entries, standing.Percentile
+
+	above, err := store.PageBefore(r.Context(), bucket, resp.Order, at, int32(limit))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	below, err := store.Page(r.Context(), bucket, resp.Order, &at, int32(limit))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	// The ladder's rank can trail the live rows by what arrived since the last
+	// pass; the neighbours are numbered from it so the response agrees with
+	// itself, and a first row that would number below 1 is numbered 1.
+	resp.Above = s.pageView(bucket, resp.Order, above, max(1, standing.Rank-int64(len(above))), "").Entries
+	resp.Below = s.pageView(bucket, resp.Order, below, standing.Rank, "").Entries
+	s.writeJSON(w, http.StatusOK, resp)
 }
 
 // bucketParam parses the {bucket} path parameter, answering 404 for anything
@@ -423,10 +792,110 @@ func (s *Service) bucketParam(w http.ResponseWriter, r *http.Request) (Bucket, b
 	return b, true
 }
 
-// encodeCursor packs a keyset position into an opaque base64url token.
-// Nanosecond precision is exact for Postgres timestamptz (microseconds), so the
-// round-trip reproduces the stored instant for the equality tie-break.
-func encodeCursor(c Cursor) string {
+// windowParam applies `?window=` to the board named in the path: the same
+// board read over the week, month or season that is current now. Absent, the
+// board is read as named. The kind must be one this server maintains, and the
+// board must not already be a window — a daily board is one day's, and a
+// windowed key already says which window.
+func (s *Service) windowParam(w http.ResponseWriter, r *http.Request, b Bucket) (Bucket, bool) {
+	raw := r.URL.Query().Get("window")
+	if raw == "" {
+		return b, true
+	}
+	kind, err := ParsePeriodKind(raw)
+	if err != nil || !s.windows.Has(kind) || b.IsWindowed() || b.IsDaily() {
+		s.writeError(w, r, apiErrBadWindow)
+		return Bucket{}, false
+	}
+
+	now := s.now()
+	var id string
+	switch kind {
+	case PeriodWeek:
+		id = WeekOf(now).ID
+	case PeriodMonth:
+		id = MonthOf(now).ID
+	case PeriodSeason:
+		season, err := s.store.CurrentSeason(r.Context(), now)
+		if errors.Is(err, ErrNoSeason) {
+			s.writeError(w, r, apiErrNoSeason)
+			return Bucket{}, false
+		}
+		if err != nil {
+			s.writeError(w, r, err)
+			return Bucket{}, false
+		}
+		id = season.ID
+	}
+	windowed, err := b.Over(id)
+	if err != nil {
+		s.writeError(w, r, apiErrBadWindow)
+		return Bucket{}, false
+	}
+	return windowed, true
+}
+
+// orderParam parses `?order=`, answering 400 for anything but an order this
+// domain ranks by.
+func (s *Service) orderParam(w http.ResponseWriter, r *http.Request) (Order, bool) {
+	o, err := ParseOrder(r.URL.Query().Get("order"))
+	if err != nil {
+		s.writeError(w, r, apiErrBadOrder)
+		return "", false
+	}
+	return o, true
+}
+
+// scopeParam parses `?scope=` and returns the store to read the board from:
+// the service's own for the global scope, and that store narrowed to the
+// caller's circle for the following one. The following scope is a question
+// about the caller, so it needs a session like /me does.
+func (s *Service) scopeParam(w http.ResponseWriter, r *http.Request) (Store, Scope, bool) {
+	scope, err := ParseScope(r.URL.Query().Get("scope"))
+	if err != nil {
+		s.writeError(w, r, apiErrBadScope)
+		return nil, "", false
+	}
+	if scope == ScopeGlobal {
+		return s.store, scope, true
+	}
+	userID, ok := s.userID(r.Context())
+	if !ok {
+		s.writeError(w, r, apiErrUnauthorized)
+		return nil, "", false
+	}
+	return s.store.Following(userID), scope, true
+}
+
+// scopeView is a scope as responses echo it: absent for the global one.
+func scopeView(sc Scope) Scope {
+	if sc == ScopeGlobal {
+		return ""
+	}
+	return sc
+}
+
+// wpmCursorTag leads every WPM-order token. It makes the two orders' tokens
+// differ in shape — four fields against three — so a cursor pasted into the
+// other order fails to decode instead of seeking to a score read as a speed.
+const wpmCursorTag = "wpm"
+
+// encodeCursor packs a keyset position into an opaque base64url token under an
+// order. Nanosecond precision is exact for Postgres timestamptz
+// (microseconds), so the round-trip reproduces the stored instant for the
+// equality tie-break; the wpm is spelled with 'g' and -1 precision, the
+// shortest form that parses back to the identical float64 and so to the
+// stored numeric. The score order's token is unchanged from before orders
+// existed, so a cursor a client already holds keeps working.
+func encodeCursor(o Order, c Cursor) string {
+	if o == OrderWPM {
+		return httpx.EncodeCursor(
+			wpmCursorTag,
+			strconv.FormatFloat(c.WPM, 'g', -1, 64),
+			strconv.FormatInt(c.AchievedAt.UTC().UnixNano(), 10),
+			c.UserID.String(),
+		)
+	}
 	return httpx.EncodeCursor(
 		strconv.FormatInt(c.Score, 10),
 		strconv.FormatInt(c.AchievedAt.UTC().UnixNano(), 10),
@@ -434,8 +903,12 @@ func encodeCursor(c Cursor) string {
 	)
 }
 
-// decodeCursor reverses encodeCursor, rejecting anything malformed.
-func decodeCursor(token string) (Cursor, error) {
+// decodeCursor reverses encodeCursor, rejecting anything malformed — including
+// a token minted under the other order.
+func decodeCursor(o Order, token string) (Cursor, error) {
+	if o == OrderWPM {
+		return decodeWPMCursor(token)
+	}
 	parts, err := httpx.DecodeCursor(token, 3)
 	if err != nil {
 		return Cursor{}, err
@@ -454,3 +927,180 @@ func decodeCursor(token string) (Cursor, error) {
 	}
 	return Cursor{Score: score, AchievedAt: time.Unix(0, nanos).UTC(), UserID: id}, nil
 }
+
+// decodeWPMCursor is decodeCursor for the WPM order. A wpm no entry could hold
+// — negative, infinite, NaN — is malformed, not merely a position past the end.
+func decodeWPMCursor(token string) (Cursor, error) {
+	parts, err := httpx.DecodeCursor(token, 4)
+	if err != nil {
+		return Cursor{}, err
+	}
+	if parts[0] != wpmCursorTag {
+		return Cursor{}, errors.New("leaderboard: not a wpm cursor")
+	}
+	wpm, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return Cursor{}, err
+	}
+	if wpm < 0 || math.IsInf(wpm, 0) || math.IsNaN(wpm) {
+		return Cursor{}, strconv.ErrRange
+	}
+	nanos, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Cursor{}, err
+	}
+	id, err := uuid.Parse(parts[3])
+	if err != nil {
+		return Cursor{}, err
+	}
+	return Cursor{WPM: wpm, AchievedAt: time.Unix(0, nanos).UTC(), UserID: id}, nil
+}
+
+// encodeBoardCursor packs an index position under a sort. The sort leads the
+// token, so a cursor pasted into another sort fails to decode rather than
+// seeking to a count read as a time. The key goes last and base64url-encoded:
+// a bucket key has colons in it, and the token's fields are split on colons.
+func encodeBoardCursor(sort BoardSort, b Board) string {
+	var at int64
+	switch sort {
+	case BoardSortEntries:
+		at = b.Entries
+	case BoardSortRecent:
+		at = b.ChangedAt.UTC().UnixNano()
+	}
+	return httpx.EncodeCursor(string(sort), strconv.FormatInt(at, 10),
+		base64.RawURLEncoding.EncodeToString([]byte(b.Bucket.Key())))
+}
+
+// decodeBoardCursor reverses encodeBoardCursor, rejecting a token minted under
+// another sort or naming no board.
+func decodeBoardCursor(sort BoardSort, token string) (BoardCursor, error) {
+	parts, err := httpx.DecodeCursor(token, 3)
+	if err != nil {
+		return BoardCursor{}, err
+	}
+	if BoardSort(parts[0]) != sort {
+		return BoardCursor{}, errors.New("leaderboard: cursor minted under another sort")
+	}
+	at, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return BoardCursor{}, err
+	}
+	key, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return BoardCursor{}, err
+	}
+	if _, err := ParseBucketKey(string(key)); err != nil {
+		return BoardCursor{}, err
+	}
+	c := BoardCursor{Key: string(key)}
+	switch sort {
+	case BoardSortEntries:
+		c.Entries = at
+	case BoardSortRecent:
+		c.ChangedAt = time.Unix(0, at).UTC()
+	}
+	return c, nil
+}
+
+// seasonView is an archived window: its span, when it was frozen, and a name
+// when it is a season.
+type seasonView struct {
+	ID       string     `json:"id"`
+	Kind     PeriodKind `json:"kind"`
+	Name     string     `json:"name,omitempty"`
+	StartsAt time.Time  `json:"startsAt"`
+	EndsAt   time.Time  `json:"endsAt"`
+	FrozenAt time.Time  `json:"frozenAt"`
+}
+
+type seasonPageResponse struct {
+	// Bucket is the all-time key, as it appears in the path; the standings are
+	// that board's over the window.
+	Bucket     string      `json:"bucket"`
+	Season     seasonView  `json:"season"`
+	Scope      Scope       `json:"scope,omitempty"`
+	Entries    []entryView `json:"entries"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// handleSeason serves one page of a closed window's frozen standings. The ranks
+// are the ones the board had when the window froze and are carried in the
+// rows, so the cursor is simply the last rank — there is no live ranking left
+// for a keyset to seek through.
+//
+// 404 unknown_season covers a window that is still open, one that has closed
+// but not yet been frozen, and an id that was never a window: from the
+// outside all three are "there is no archive here yet". A window that froze
+// with nobody on this board is a 200 with no entries.
+//
+// `?scope=following` serves the caller's circle's share of the standings,
+// renumbered from 1 in the order they froze — the circle as it is now, since
+// the question is about the players they follow today.
+func (s *Service) handleSeason(w http.ResponseWriter, r *http.Request) {
+	bucket, ok := s.bucketParam(w, r)
+	if !ok {
+		return
+	}
+	if bucket.IsWindowed() {
+		s.writeError(w, r, apiErrUnknownBucket)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	windowed, err := bucket.Over(id)
+	if err != nil {
+		s.writeError(w, r, apiErrUnknownSeason)
+		return
+	}
+	store, scope, ok := s.scopeParam(w, r)
+	if !ok {
+		return
+	}
+	period, err := s.store.FrozenPeriod(r.Context(), id)
+	if errors.Is(err, ErrNotFrozen) {
+		s.writeError(w, r, apiErrUnknownSeason)
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	limit := httpx.ParseLimit(r.URL.Query().Get("limit"), defaultLimit, maxLimit)
+	var after int64
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		parts, err := httpx.DecodeCursor(raw, 1)
+		if err == nil {
+			after, err = strconv.ParseInt(parts[0], 10, 64)
+		}
+		if err != nil || after < 1 {
+			s.writeError(w, r, apiErrBadCursor)
+			return
+		}
+	}
+
+	rows, err := store.SnapshotPage(r.Context(), windowed, after, int32(limit+1))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = httpx.EncodeCursor(strconv.FormatInt(rows[limit-1].Rank, 10))
+	}
+	views := make([]entryView, len(rows))
+	for i := range rows {
+		views[i] = toEntryView(rows[i])
+	}
+	s.writeJSON(w, http.StatusOK, seasonPageResponse{
+		Bucket: bucket.Key(),
+		Season: seasonView{
+			ID: period.ID, Kind: period.Kind, Name: period.Name,
+			StartsAt: period.Start, EndsAt: period.End, FrozenAt: period.FrozenAt,
+		},
+		Scope:      scopeView(scope),
+		Entries:    views,
+		NextCursor: next,
+	})
+}
@@ -27,6 +27,7 @@ import (
 	leaderboardpg "github.com/typemore/typemore-server/internal/leaderboard/pgstore"
 	"github.com/typemore/typemore-server/internal/platform/db"
 	"github.com/typemore/typemore-server/internal/platform/migrate"
+	"github.com/typemore/typemore-server/internal/quote"
 )
 
 // The Postgres testcontainer is started lazily on first use and torn down in
@@ -99,6 +100,12 @@ type boardOpts struct {
 	// indexLimiter is nil by default: this suite hits the index freely, and the
 	// bucket belongs to the one test that is about the bucket.
 	indexLimiter leaderboard.RateLimiter
+	// windows is off by default, so every other test sees one row per slot;
+	// the windowed boards are exercised by the tests that turn them on.
+	windows leaderboard.Windows
+	// now, when set, is the clock both the store and the service read to
+	// decide which windows are current and which have closed.
+	now func() time.Time
 }
 
 // allowN is a RateLimiter that permits the first n calls and refuses the rest —
@@ -127,11 +134,15 @@ func newBoard(t *testing.T, mutators ...func(*boardOpts)) *board {
 	require.NoError(t, err)
 	t.Cleanup(pool.Close)
 
-	_, err = pool.Exec(ctx, `TRUNCATE leaderboard_entries, bans, runs, users, quotes CASCADE`)
+	_, err = pool.Exec(ctx, `TRUNCATE leaderboard_entries, leaderboard_periods, leaderboard_seasons,
+		leaderboard_rank_dirty, leaderboard_rank_ladder, bans, runs, users, quotes CASCADE`)
 	require.NoError(t, err)
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	store := leaderboardpg.New(pool, opts.requireVerifiedEmail)
+	store := leaderboardpg.New(pool, opts.requireVerifiedEmail).WithWindows(opts.windows)
+	if opts.now != nil {
+		store.WithClock(opts.now)
+	}
 
 	b := &board{t: t, pool: pool, store: store, pendingVerdicts: map[uuid.UUID][]any{}}
 	svc := leaderboard.NewService(store, func(context.Context) (uuid.UUID, bool) {
@@ -145,7 +156,10 @@ func newBoard(t *testing.T, mutators ...func(*boardOpts)) *board {
 		// internal/runs, where a real quote exists to withdraw.
 		func(context.Context) (map[uuid.UUID]struct{}, error) { return nil, nil },
 		opts.indexLimiter,
-		logger)
+		logger).WithWindows(opts.windows).WithQuoteGroups(quoteGroups{})
+	if opts.now != nil {
+		svc.WithClock(opts.now)
+	}
 
 	r := chi.NewRouter()
 	r.Route("/api/v1", func(r chi.Router) {
@@ -156,6 +170,16 @@ func newBoard(t *testing.T, mutators ...func(*boardOpts)) *board {
 	return b
 }
 
+// quoteGroups is cmd/server's adapter for the board index's group names.
+type quoteGroups struct{}
+
+func (quoteGroups) ParseGroup(name string) (int16, bool) {
+	g, err := quote.ParseLenGroup(name)
+	return int16(g), err == nil
+}
+
+func (quoteGroups) GroupName(group int16) string { return quote.LenGroup(group).String() }
+
 // --- fixtures ---
 
 // defaultSetup is a plain seeded run's setup snapshot: the shape today's client
@@ -76,8 +76,8 @@ func TestBucketsIndex(t *testing.T) {
 }
 
 // The index is the one read here whose cost scales with how much the product
-// has grown — an aggregate over every board (up to ~9 881 quote ones) plus the
-// withdrawn-quote read, anonymously, on every hit. So it is the one read that
+// has grown — a row per board (up to ~9 881 quote ones) plus the withdrawn-quote
+// read, anonymously, on every hit. So it is the one read that
 // is rationed, and the two halves of that are asserted here: the per-IP bucket
 // bounds one caller, the cache header bounds all of them. A board PAGE is
 // bounded by its own limit and is deliberately behind neither.
@@ -104,7 +104,7 @@ func TestBucketsIndexIsEmptyNotNull(t *testing.T) {
 	b := newBoard(t)
 	resp := b.get("/api/v1/leaderboards")
 	require.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.JSONEq(t, `{"buckets":[]}`, string(readBody(t, resp)),
+	assert.JSONEq(t, `{"sort":"key","buckets":[]}`, string(readBody(t, resp)),
 		"an empty index must be [] so a client can render it without a null check")
 }
 
@@ -0,0 +1,222 @@
+package leaderboard_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/leaderboard"
+)
+
+type indexBody struct {
+	Sort    string `json:"sort"`
+	Buckets []struct {
+		Bucket     string `json:"bucket"`
+		Lang       string `json:"lang"`
+		TextSource string `json:"textSource"`
+		LenGroup   string `json:"lenGroup"`
+		Entries    int64  `json:"entries"`
+		ChangedAt  string `json:"changedAt"`
+	} `json:"buckets"`
+	NextCursor string `json:"nextCursor"`
+}
+
+func (body indexBody) keys() []string {
+	out := make([]string, len(body.Buckets))
+	for i := range body.Buckets {
+		out[i] = body.Buckets[i].Bucket
+	}
+	return out
+}
+
+// indexFixture is five boards of different shapes and sizes, each changed
+// once, in the order below: so key, size and recency all order them
+// differently.
+//
+//	words:50:en:seeded    3 players
+//	time:15000:de:seeded  1 player
+//	quote:<english>       2 players
+//	time:15000:en:seeded  2 players
+//	time:30000:en:seeded  1 player
+func indexFixture(t *testing.T) (*board, uuid.UUID) {
+	t.Helper()
+	b := newBoard(t)
+	players := []uuid.UUID{b.user("first", true), b.user("second", true), b.user("third", true)}
+	quoteID := b.quote("english", "the index lists this quote", "Somebody")
+
+	for _, p := range players {
+		b.addRun(runSpec{user: p, score: 100, mode: leaderboard.ModeWords, wordCount: new(int32(50))})
+	}
+	b.addRun(runSpec{user: players[0], score: 100, lang: "de"})
+	b.addRun(runSpec{user: players[0], score: 100, quote: quoteID})
+	b.addRun(runSpec{user: players[1], score: 100, quote: quoteID})
+	b.addRun(runSpec{user: players[0], score: 100})
+	b.addRun(runSpec{user: players[1], score: 100})
+	b.addRun(runSpec{user: players[2], score: 100, durationMs: new(int32(30000))})
+	return b, quoteID
+}
+
+func (b *board) index(t *testing.T, query url.Values) indexBody {
+	t.Helper()
+	resp := b.get("/api/v1/leaderboards?" + query.Encode())
+	require.Equal(t, http.StatusOK, resp.StatusCode, "%s", readBody(t, resp))
+	return decodeInto[indexBody](t, resp)
+}
+
+// A client renders a language dropdown off the index without downloading the
+// quote boards, and a quote browser narrows by language and length.
+func TestIndexFilters(t *testing.T) {
+	b, quoteID := indexFixture(t)
+	quoteKey := "quote:" + quoteID.String()
+
+	for _, tc := range []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{"everything", url.Values{},
+			[]string{quoteKey, "time:15000:de:seeded", "time:15000:en:seeded", "time:30000:en:seeded", "words:50:en:seeded"}},
+		{"a mode", url.Values{"mode": {"time"}},
+			[]string{"time:15000:de:seeded", "time:15000:en:seeded", "time:30000:en:seeded"}},
+		{"a language", url.Values{"lang": {"en"}},
+			[]string{"time:15000:en:seeded", "time:30000:en:seeded", "words:50:en:seeded"}},
+		{"seeded text", url.Values{"source": {"seeded"}, "lang": {"de"}},
+			[]string{"time:15000:de:seeded"}},
+		{"quotes in a language", url.Values{"source": {"quote"}, "lang": {"english"}},
+			[]string{quoteKey}},
+		{"a length group", url.Values{"group": {"medium"}},
+			[]string{quoteKey}},
+		{"another length group", url.Values{"group": {"short"}},
+			[]string{}},
+		{"a minimum size", url.Values{"minEntries": {"2"}},
+			[]string{quoteKey, "time:15000:en:seeded", "words:50:en:seeded"}},
+		{"a mode leaves quote boards out", url.Values{"mode": {"words"}, "minEntries": {"3"}},
+			[]string{"words:50:en:seeded"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, b.index(t, tc.query).keys())
+		})
+	}
+
+	t.Run("a quote board carries its quote's language and group", func(t *testing.T) {
+		body := b.index(t, url.Values{"source": {"quote"}})
+		require.Len(t, body.Buckets, 1)
+		assert.Equal(t, "english", body.Buckets[0].Lang)
+		assert.Equal(t, "medium", body.Buckets[0].LenGroup)
+		assert.Empty(t, body.Buckets[0].TextSource)
+		assert.EqualValues(t, 2, body.Buckets[0].Entries)
+		assert.NotEmpty(t, body.Buckets[0].ChangedAt)
+	})
+}
+
+// Every sort walks the same boards a page at a time, crossing page seams, and
+// arrives at the same set as one big page — in its own order.
+func TestIndexSortsAndPages(t *testing.T) {
+	b, quoteID := indexFixture(t)
+	quoteKey := "quote:" + quoteID.String()
+
+	for _, tc := range []struct {
+		sort string
+		want []string
+	}{
+		{"key", []string{quoteKey, "time:15000:de:seeded", "time:15000:en:seeded", "time:30000:en:seeded", "words:50:en:seeded"}},
+		{"entries", []string{"words:50:en:seeded", quoteKey, "time:15000:en:seeded", "time:15000:de:seeded", "time:30000:en:seeded"}},
+		{"recent", []string{"time:30000:en:seeded", "time:15000:en:seeded", quoteKey, "time:15000:de:seeded", "words:50:en:seeded"}},
+	} {
+		t.Run(tc.sort, func(t *testing.T) {
+			whole := b.index(t, url.Values{"sort": {tc.sort}})
+			assert.Equal(t, tc.sort, whole.Sort)
+			assert.Equal(t, tc.want, whole.keys())
+			assert.Empty(t, whole.NextCursor, "one page holds the whole index")
+
+			var walked []string
+			query := url.Values{"sort": {tc.sort}, "limit": {"2"}}
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 10, "the walk is not terminating")
+				page := b.index(t, query)
+				require.LessOrEqual(t, len(page.Buckets), 2)
+				walked = append(walked, page.keys()...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Set("cursor", page.NextCursor)
+			}
+			assert.Equal(t, tc.want, walked)
+		})
+	}
+
+	t.Run("a cursor continues only the sort that minted it", func(t *testing.T) {
+		page := b.index(t, url.Values{"sort": {"entries"}, "limit": {"1"}})
+		require.NotEmpty(t, page.NextCursor)
+		resp := b.get("/api/v1/leaderboards?sort=recent&cursor=" + page.NextCursor)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestIndexRefusesMalformedAsks(t *testing.T) {
+	b := newBoard(t)
+	for _, query := range []string{
+		"sort=size", "mode=zen", "source=custom", "group=huge", "group=1",
+		"minEntries=-1", "minEntries=many", "lang=en:de", "cursor=%21%21",
+	} {
+		resp := b.get("/api/v1/leaderboards?" + query)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		assert.Contains(t, string(readBody(t, resp)), "bad_request", query)
+	}
+}
+
+// The counts are maintained by triggers, because some of what removes an entry
+// never passes through the projection: an account deletion cascades, and a
+// TRUNCATE empties the table in one statement. A ban never touches the table
+// at all, and is taken off at read time.
+func TestIndexCountsFollowEveryWayAnEntryGoes(t *testing.T) {
+	b, _ := indexFixture(t)
+	ctx := context.Background()
+
+	sizes := func() map[string]int64 {
+		t.Helper()
+		boards, err := b.store.Boards(ctx, leaderboard.BoardFilter{}, leaderboard.BoardSortKey, nil, 100)
+		require.NoError(t, err)
+		out := map[string]int64{}
+		for _, board := range boards {
+			out[board.Bucket.Key()] = board.Entries
+		}
+		// The catalogue counts the same thing the slow way; the two must agree.
+		buckets, err := b.store.Buckets(ctx)
+		require.NoError(t, err)
+		counted := map[string]int64{}
+		for _, bc := range buckets {
+			counted[bc.Bucket.Key()] = bc.Entries
+		}
+		require.Equal(t, counted, out)
+		return out
+	}
+	require.EqualValues(t, 3, sizes()["words:50:en:seeded"])
+
+	var third uuid.UUID
+	require.NoError(t, b.pool.QueryRow(ctx,
+		`SELECT id FROM users WHERE display_name = 'third'`).Scan(&third))
+
+	b.ban(third, nil)
+	got := sizes()
+	assert.EqualValues(t, 2, got["words:50:en:seeded"], "a ban is taken off the count")
+	assert.NotContains(t, got, "time:30000:en:seeded", "a board only a banned player holds is not listed")
+
+	b.unban(third)
+	assert.EqualValues(t, 1, sizes()["time:30000:en:seeded"])
+
+	_, err := b.pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, third)
+	require.NoError(t, err)
+	got = sizes()
+	assert.EqualValues(t, 2, got["words:50:en:seeded"], "an account deletion cascades into the count")
+	assert.NotContains(t, got, "time:30000:en:seeded")
+
+	_, err = b.pool.Exec(ctx, `TRUNCATE runs CASCADE`)
+	require.NoError(t, err)
+	assert.Empty(t, sizes(), "a truncate empties the index with the table")
+}
@@ -48,10 +48,30 @@ type EmailToken struct {
 	CreatedAt time.Time
 }
 
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
 type KeyboardProjectedRun struct {
 	RunID uuid.UUID
 }
 
+type LeaderboardBoard struct {
+	BucketKey  string
+	Mode       *string
+	DurationMs *int32
+	WordCount  *int32
+	Lang       *string
+	TextSource *string
+	QuoteID    *uuid.UUID
+	LenGroup   *int16
+	Day        *time.Time
+	Entries    int64
+	ChangedAt  time.Time
+}
+
 type LeaderboardEligibleRun struct {
 	RunID          uuid.UUID
 	UserID         uuid.UUID
@@ -84,6 +104,15 @@ type LeaderboardEntry struct {
 	SortKey     *int64
 }
 
+type LeaderboardPeriod struct {
+	ID       string
+	Kind     string
+	Name     *string
+	StartsAt time.Time
+	EndsAt   time.Time
+	FrozenAt time.Time
+}
+
 type LeaderboardRanked struct {
 	BucketKey   string
 	UserID      uuid.UUID
@@ -115,20 +144,53 @@ type LeaderboardRow struct {
 	SortKey     *int64
 }
 
-type Match struct {
-	ID        string
-	RoomCode  string
-	Name      string
-	Settings  json.RawMessage
-	Freemods  json.RawMessage
-	Seed      int64
-	DictHash  string
-	Lang      string
-	GoAt      time.Time
-	EndedAt   time.Time
+type LeaderboardSeason struct {
+	ID       string
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+type LeaderboardSnapshot struct {
+	PeriodID    string
+	BucketKey   string
+	Rank        int64
+	UserID      uuid.UUID
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+type LoginChallenge struct {
+	ID        uuid.UUID
+	TokenHash []byte
+	UserID    uuid.UUID
+	Attempts  int32
+	ExpiresAt time.Time
 	CreatedAt time.Time
 }
 
+type Match struct {
+	ID          string
+	RoomCode    string
+	Name        string
+	Settings    json.RawMessage
+	Freemods    json.RawMessage
+	Seed        int64
+	DictHash    string
+	Lang        string
+	GoAt        time.Time
+	EndedAt     time.Time
+	CreatedAt   time.Time
+	ValidatedAt *time.Time
+}
+
 type MatchRun struct {
 	ID          uuid.UUID
 	MatchID     string
@@ -140,22 +202,63 @@ type MatchRun struct {
 	BatchCount  int32
 	FinalStatus string
 	CreatedAt   time.Time
+	Status      string
+}
+
+type MatchRunVerdict struct {
+	MatchRunID    uuid.UUID
+	MatchID       string
+	ServerMetrics []byte
+	ServerScore   []byte
+	Score         *int64
+	Placement     *int32
+	Validation    json.RawMessage
+	BundleSha     string
+	PolicyVersion *int16
+	LastError     *string
+	ValidatedAt   time.Time
+}
+
+type Passkey struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	CredentialID []byte
+	PublicKey    []byte
+	SignCount    int64
+	Transports   []string
+	Name         string
+	TwoFactor    bool
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TokenHash  []byte
+	Name       string
+	Scopes     []string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
 }
 
 type Quote struct {
-	ID              uuid.UUID
-	Lang            string
-	UpstreamID      int32
-	Text            string
-	Source          string
-	Length          int32
-	LenGroup        int16
-	TextHash        string
-	Superseded      bool
-	CreatedAt       time.Time
-	WithdrawnAt     *time.Time
-	WithdrawnBy     *uuid.UUID
-	WithdrawnReason *string
+	ID                uuid.UUID
+	Lang              string
+	UpstreamID        int32
+	Text              string
+	Source            string
+	Length            int32
+	LenGroup          int16
+	TextHash          string
+	Superseded        bool
+	CreatedAt         time.Time
+	WithdrawnAt       *time.Time
+	WithdrawnBy       *uuid.UUID
+	WithdrawnReason   *string
+	Difficulty        *float64
+	DifficultyVersion *int16
 }
 
 type Report struct {
@@ -194,6 +297,8 @@ type Run struct {
 	Attempts                int16
 	LastError               *string
 	RestartsSinceLastSubmit int32
+	NextAttemptAt           *time.Time
+	DeadLetteredAt          *time.Time
 }
 
 type RunStatusOverride struct {
@@ -207,14 +312,17 @@ type RunStatusOverride struct {
 }
 
 type RunVerdict struct {
-	RunID         uuid.UUID
-	UserID        uuid.UUID
-	ServerMetrics []byte
-	ServerScore   []byte
-	Validation    json.RawMessage
-	BundleSha     *string
-	PolicyVersion *int16
-	ValidatedAt   time.Time
+	RunID            uuid.UUID
+	UserID           uuid.UUID
+	ServerMetrics    []byte
+	ServerScore      []byte
+	Validation       json.RawMessage
+	BundleSha        *string
+	PolicyVersion    *int16
+	ValidatedAt      time.Time
+	ShadowLabel      *string
+	ShadowStatus     *string
+	ShadowValidation []byte
 }
 
 type Session struct {
@@ -224,6 +332,10 @@ type Session struct {
 	CreatedAt  time.Time
 	ExpiresAt  time.Time
 	LastSeenAt time.Time
+	TwoFactor  bool
+	Name       *string
+	UserAgent  string
+	IpPrefix   string
 }
 
 type User struct {
@@ -237,6 +349,7 @@ type User struct {
 	Bio                  *string
 	Keyboard             *string
 	DisplayNameChangedAt *time.Time
+	TwoFactorEnabledAt   *time.Time
 }
 
 type UserBadge struct {
@@ -270,3 +383,28 @@ type UserLink struct {
 	Kind   string
 	Handle string
 }
+
+type UserRecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  []byte
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type UserTotp struct {
+	UserID    uuid.UUID
+	Secret    []byte
+	LastStep  int64
+	CreatedAt time.Time
+}
+
+type WebauthnCeremony struct {
+	ID        uuid.UUID
+	TokenHash []byte
+	Kind      string
+	UserID    *uuid.UUID
+	Challenge []byte
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
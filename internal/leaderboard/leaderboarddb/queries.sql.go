@@ -13,17 +13,166 @@ import (
 	"github.com/google/uuid"
 )
 
-const clearLeaderboard = `-- name: ClearLeaderboard :exec
-TRUNCATE leaderboard_entries
+const buildRankLadder = `-- name: BuildRankLadder :execrows
+INSERT INTO leaderboard_rank_ladder (bucket_key, rank_order, key, at_or_above)
+SELECT s.bucket_key, 'score', s.sort_key, s.at_or_above
+FROM (SELECT bucket_key, sort_key,
+             row_number() OVER (ORDER BY sort_key DESC) AS n,
+             count(*) OVER (ORDER BY sort_key DESC)     AS at_or_above,
+             count(*) OVER ()                           AS entries
+      FROM leaderboard_ranked
+      WHERE bucket_key = $1) s
+WHERE s.n % $2::bigint = 0 OR s.n = s.entries
+UNION ALL
+SELECT w.bucket_key, 'wpm', w.wpm, w.at_or_above
+FROM (SELECT bucket_key, wpm,
+             row_number() OVER (ORDER BY wpm DESC) AS n,
+             count(*) OVER (ORDER BY wpm DESC)     AS at_or_above,
+             count(*) OVER ()                      AS entries
+      FROM leaderboard_ranked
+      WHERE bucket_key = $1) w
+WHERE w.n % $2::bigint = 0 OR w.n = w.entries
+ON CONFLICT (bucket_key, rank_order, key) DO NOTHING
 `
 
-// Rebuild step one. TRUNCATE is transactional in Postgres, so a failed rebuild
-// leaves the old board untouched rather than an empty one.
-func (q *Queries) ClearLeaderboard(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, clearLeaderboard)
+type BuildRankLadderParams struct {
+	BucketKey string
+	Step      int64
+}
+
+// Every @step-th row of one board in each order, plus its last row, with the
+// count of visible entries at or above it (00041). count(*) over the default
+// RANGE frame takes in the row's whole tie, which is what the lookups below
+// subtract from; row_number picks the rungs, so a long tie cannot stretch the
+// gap between two of them. Rows of one tie share a key: the first one in wins
+// and the rest are the same rung.
+func (q *Queries) BuildRankLadder(ctx context.Context, arg BuildRankLadderParams) (int64, error) {
+	result, err := q.db.Exec(ctx, buildRankLadder, arg.BucketKey, arg.Step)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimRankDirty = `-- name: ClaimRankDirty :one
+DELETE FROM leaderboard_rank_dirty
+WHERE bucket_key = (SELECT d.bucket_key FROM leaderboard_rank_dirty d
+                    WHERE d.dirtied_at <= $1
+                    ORDER BY d.dirtied_at, d.bucket_key
+                    LIMIT 1
+                    FOR UPDATE SKIP LOCKED)
+RETURNING bucket_key
+`
+
+// Take one marked board to snapshot, marked no later than @marked_before — the
+// start of the pass, so a board the projection keeps re-marking cannot keep
+// one pass going. The delete is the claim: SKIP LOCKED hands concurrent
+// snapshotters different boards, and a snapshot that fails rolls the mark
+// back with it. A verdict that re-marks the board meanwhile waits on this row
+// and lands after the commit, so the next pass sees it.
+func (q *Queries) ClaimRankDirty(ctx context.Context, markedBefore time.Time) (string, error) {
+	row := q.db.QueryRow(ctx, claimRankDirty, markedBefore)
+	var bucket_key string
+	err := row.Scan(&bucket_key)
+	return bucket_key, err
+}
+
+const clearRankLadder = `-- name: ClearRankLadder :exec
+DELETE FROM leaderboard_rank_ladder
+WHERE bucket_key = $1
+`
+
+func (q *Queries) ClearRankLadder(ctx context.Context, bucketKey string) error {
+	_, err := q.db.Exec(ctx, clearRankLadder, bucketKey)
 	return err
 }
 
+const countFollowingAbove = `-- name: CountFollowingAbove :one
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+  AND sort_key >= leaderboard_sort_key($3, $4)
+  AND (sort_key > leaderboard_sort_key($3, $4)
+       OR achieved_at < $4::timestamptz
+       OR (achieved_at = $4::timestamptz AND user_id < $5::uuid))
+`
+
+type CountFollowingAboveParams struct {
+	BucketKey  string
+	Viewer     uuid.UUID
+	Score      int64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+}
+
+// How many of the circle outrank this position in the score order.
+func (q *Queries) CountFollowingAbove(ctx context.Context, arg CountFollowingAboveParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFollowingAbove,
+		arg.BucketKey,
+		arg.Viewer,
+		arg.Score,
+		arg.AchievedAt,
+		arg.UserID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countFollowingEntries = `-- name: CountFollowingEntries :one
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+`
+
+type CountFollowingEntriesParams struct {
+	BucketKey string
+	Viewer    uuid.UUID
+}
+
+// The size of a circle's board: the members with a visible entry on it.
+func (q *Queries) CountFollowingEntries(ctx context.Context, arg CountFollowingEntriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFollowingEntries, arg.BucketKey, arg.Viewer)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countFollowingWPMAbove = `-- name: CountFollowingWPMAbove :one
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+  AND wpm >= $3::numeric
+  AND (wpm > $3::numeric
+       OR achieved_at < $4::timestamptz
+       OR (achieved_at = $4::timestamptz AND user_id < $5::uuid))
+`
+
+type CountFollowingWPMAboveParams struct {
+	BucketKey  string
+	Viewer     uuid.UUID
+	Wpm        float64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+}
+
+// How many of the circle outrank this position in the WPM order.
+func (q *Queries) CountFollowingWPMAbove(ctx context.Context, arg CountFollowingWPMAboveParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFollowingWPMAbove,
+		arg.BucketKey,
+		arg.Viewer,
+		arg.Wpm,
+		arg.AchievedAt,
+		arg.UserID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countLeaderboardAbove = `-- name: CountLeaderboardAbove :one
 SELECT count(*)::bigint
 FROM leaderboard_ranked
@@ -61,17 +210,273 @@ func (q *Queries) CountLeaderboardAbove(ctx context.Context, arg CountLeaderboar
 	return column_1, err
 }
 
+const countLeaderboardAboveByLadder = `-- name: CountLeaderboardAboveByLadder :one
+WITH rung AS (
+    SELECT l.key::bigint AS key, l.at_or_above
+    FROM leaderboard_rank_ladder l
+    WHERE l.bucket_key = $1
+      AND l.rank_order = 'score'
+      AND l.key > leaderboard_sort_key($2, $3)
+    ORDER BY l.key
+    LIMIT 1
+)
+SELECT (coalesce((SELECT at_or_above FROM rung), 0)
+        + (SELECT count(*)
+           FROM leaderboard_ranked
+           WHERE bucket_key = $1
+             AND sort_key < coalesce((SELECT key FROM rung), 9223372036854775807)
+             AND sort_key >= leaderboard_sort_key($2, $3)
+             AND (sort_key > leaderboard_sort_key($2, $3)
+                  OR achieved_at < $3::timestamptz
+                  OR (achieved_at = $3::timestamptz AND user_id < $4::uuid))))::bigint AS above,
+       coalesce((SELECT max(at_or_above) FROM leaderboard_rank_ladder
+                 WHERE bucket_key = $1 AND rank_order = 'score'),
+                (SELECT count(*) FROM leaderboard_ranked WHERE bucket_key = $1))::bigint AS entries
+`
+
+type CountLeaderboardAboveByLadderParams struct {
+	BucketKey  string
+	Score      int64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+}
+
+type CountLeaderboardAboveByLadderRow struct {
+	Above   int64
+	Entries int64
+}
+
+// CountLeaderboardAbove's answer, read off the ladder: the nearest rung
+// strictly above the position, plus a live count of the rows between the two.
+// The live part is CountLeaderboardAbove's own predicate with the rung as a
+// ceiling, so it is the same index range scan cut to one rung's length.
+//
+// With no rung above — the position is near the top, or the board has not
+// been laddered yet — the ceiling is lifted and the count is the plain one,
+// which near the top is short anyway. entries is the ladder's last rung, or a
+// live count for a board that has none.
+func (q *Queries) CountLeaderboardAboveByLadder(ctx context.Context, arg CountLeaderboardAboveByLadderParams) (CountLeaderboardAboveByLadderRow, error) {
+	row := q.db.QueryRow(ctx, countLeaderboardAboveByLadder,
+		arg.BucketKey,
+		arg.Score,
+		arg.AchievedAt,
+		arg.UserID,
+	)
+	var i CountLeaderboardAboveByLadderRow
+	err := row.Scan(&i.Above, &i.Entries)
+	return i, err
+}
+
 const countLeaderboardEntries = `-- name: CountLeaderboardEntries :one
 SELECT count(*) FROM leaderboard_entries
+WHERE $1::text IS NULL OR bucket_key = $1::text
 `
 
-func (q *Queries) CountLeaderboardEntries(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countLeaderboardEntries)
+// Every live entry, or one board's when a key is given: the rebuild's before
+// count, over whatever it was asked to rebuild.
+func (q *Queries) CountLeaderboardEntries(ctx context.Context, bucketKey *string) (int64, error) {
+	row := q.db.QueryRow(ctx, countLeaderboardEntries, bucketKey)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const countLeaderboardWPMAbove = `-- name: CountLeaderboardWPMAbove :one
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = $1
+  AND wpm >= $2::numeric
+  AND (wpm > $2::numeric
+       OR achieved_at < $3::timestamptz
+       OR (achieved_at = $3::timestamptz AND user_id < $4::uuid))
+`
+
+type CountLeaderboardWPMAboveParams struct {
+	BucketKey  string
+	Wpm        float64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+}
+
+// How many visible entries outrank this position in the WPM order. Reads
+// leaderboard_ranked for the same reason CountLeaderboardAbove does, and
+// leaderboard_wpm_idx covers it the same way.
+func (q *Queries) CountLeaderboardWPMAbove(ctx context.Context, arg CountLeaderboardWPMAboveParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countLeaderboardWPMAbove,
+		arg.BucketKey,
+		arg.Wpm,
+		arg.AchievedAt,
+		arg.UserID,
+	)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countLeaderboardWPMAboveByLadder = `-- name: CountLeaderboardWPMAboveByLadder :one
+WITH rung AS (
+    SELECT l.key, l.at_or_above
+    FROM leaderboard_rank_ladder l
+    WHERE l.bucket_key = $1
+      AND l.rank_order = 'wpm'
+      AND l.key > $2::numeric
+    ORDER BY l.key
+    LIMIT 1
+)
+SELECT (coalesce((SELECT at_or_above FROM rung), 0)
+        + (SELECT count(*)
+           FROM leaderboard_ranked
+           WHERE bucket_key = $1
+             AND wpm < coalesce((SELECT key FROM rung), 'Infinity'::numeric)
+             AND wpm >= $2::numeric
+             AND (wpm > $2::numeric
+                  OR achieved_at < $3::timestamptz
+                  OR (achieved_at = $3::timestamptz AND user_id < $4::uuid))))::bigint AS above,
+       coalesce((SELECT max(at_or_above) FROM leaderboard_rank_ladder
+                 WHERE bucket_key = $1 AND rank_order = 'wpm'),
+                (SELECT count(*) FROM leaderboard_ranked WHERE bucket_key = $1))::bigint AS entries
+`
+
+type CountLeaderboardWPMAboveByLadderParams struct {
+	BucketKey  string
+	Wpm        float64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+}
+
+type CountLeaderboardWPMAboveByLadderRow struct {
+	Above   int64
+	Entries int64
+}
+
+// CountLeaderboardAboveByLadder for the WPM order, over the wpm rungs and
+// CountLeaderboardWPMAbove's predicate.
+func (q *Queries) CountLeaderboardWPMAboveByLadder(ctx context.Context, arg CountLeaderboardWPMAboveByLadderParams) (CountLeaderboardWPMAboveByLadderRow, error) {
+	row := q.db.QueryRow(ctx, countLeaderboardWPMAboveByLadder,
+		arg.BucketKey,
+		arg.Wpm,
+		arg.AchievedAt,
+		arg.UserID,
+	)
+	var i CountLeaderboardWPMAboveByLadderRow
+	err := row.Scan(&i.Above, &i.Entries)
+	return i, err
+}
+
+const createSeason = `-- name: CreateSeason :exec
+INSERT INTO leaderboard_seasons (id, name, starts_at, ends_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateSeasonParams struct {
+	ID       string
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+func (q *Queries) CreateSeason(ctx context.Context, arg CreateSeasonParams) error {
+	_, err := q.db.Exec(ctx, createSeason,
+		arg.ID,
+		arg.Name,
+		arg.StartsAt,
+		arg.EndsAt,
+	)
+	return err
+}
+
+const deletePeriodEntries = `-- name: DeletePeriodEntries :execrows
+DELETE FROM leaderboard_entries WHERE period = $1
+`
+
+// Freezing, step three: the live boards of the window go. From here the
+// archive is the only copy.
+func (q *Queries) DeletePeriodEntries(ctx context.Context, period *string) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePeriodEntries, period)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const eligibleRunPosition = `-- name: EligibleRunPosition :one
+SELECT user_id, mode, duration_ms, word_count, lang,
+       text_source_kind::text AS text_source_kind, quote_id, score, wpm,
+       achieved_at
+FROM leaderboard_eligible_runs
+WHERE run_id = $1
+`
+
+type EligibleRunPositionRow struct {
+	UserID         uuid.UUID
+	Mode           string
+	DurationMs     *int32
+	WordCount      *int32
+	Lang           string
+	TextSourceKind string
+	QuoteID        *uuid.UUID
+	Score          int64
+	Wpm            float64
+	AchievedAt     time.Time
+}
+
+// Where one run would sit if it held its slot: its cell's coordinates and its
+// key in the score order. Read off the eligible view, so a run that is not
+// accepted, or not rankable at all, has no row — and no standing.
+func (q *Queries) EligibleRunPosition(ctx context.Context, runID uuid.UUID) (EligibleRunPositionRow, error) {
+	row := q.db.QueryRow(ctx, eligibleRunPosition, runID)
+	var i EligibleRunPositionRow
+	err := row.Scan(
+		&i.UserID,
+		&i.Mode,
+		&i.DurationMs,
+		&i.WordCount,
+		&i.Lang,
+		&i.TextSourceKind,
+		&i.QuoteID,
+		&i.Score,
+		&i.Wpm,
+		&i.AchievedAt,
+	)
+	return i, err
+}
+
+const enumerateDailyCells = `-- name: EnumerateDailyCells :many
+SELECT DISTINCT e.user_id, e.day
+FROM daily_eligible_runs e
+WHERE (NOT $1::boolean
+       OR EXISTS (SELECT 1 FROM auth_identities ai
+                  WHERE ai.user_id = e.user_id AND ai.email_verified))
+ORDER BY e.day, e.user_id
+`
+
+type EnumerateDailyCellsRow struct {
+	UserID uuid.UUID
+	Day    time.Time
+}
+
+// Every (player, day) cell that currently has an eligible attempt — the daily
+// half of the rebuild's walk, under the same gate as EnumerateLeaderboardCells.
+func (q *Queries) EnumerateDailyCells(ctx context.Context, requireVerifiedEmail bool) ([]EnumerateDailyCellsRow, error) {
+	rows, err := q.db.Query(ctx, enumerateDailyCells, requireVerifiedEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnumerateDailyCellsRow{}
+	for rows.Next() {
+		var i EnumerateDailyCellsRow
+		if err := rows.Scan(&i.UserID, &i.Day); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const enumerateLeaderboardCells = `-- name: EnumerateLeaderboardCells :many
 SELECT DISTINCT e.user_id, e.mode, e.duration_ms, e.word_count, e.lang,
                 e.text_source_kind, e.quote_id
@@ -130,19 +535,114 @@ func (q *Queries) EnumerateLeaderboardCells(ctx context.Context, requireVerified
 	return items, nil
 }
 
-const getLeaderboardEntry = `-- name: GetLeaderboardEntry :one
+const enumeratePeriodCells = `-- name: EnumeratePeriodCells :many
+SELECT DISTINCT e.user_id, e.mode, e.duration_ms, e.word_count, e.lang,
+                e.text_source_kind, e.quote_id
+FROM leaderboard_eligible_runs e
+WHERE e.achieved_at >= $1
+  AND e.achieved_at < $2
+  AND (NOT $3::boolean
+       OR EXISTS (SELECT 1 FROM auth_identities ai
+                  WHERE ai.user_id = e.user_id AND ai.email_verified))
+ORDER BY e.user_id, e.mode, e.duration_ms, e.word_count, e.lang,
+         e.text_source_kind, e.quote_id
+`
+
+type EnumeratePeriodCellsParams struct {
+	StartsAt             time.Time
+	EndsAt               time.Time
+	RequireVerifiedEmail bool
+}
+
+type EnumeratePeriodCellsRow struct {
+	UserID         uuid.UUID
+	Mode           string
+	DurationMs     *int32
+	WordCount      *int32
+	Lang           string
+	TextSourceKind string
+	QuoteID        *uuid.UUID
+}
+
+// EnumerateLeaderboardCells narrowed to runs played inside one window — the
+// windowed half of the rebuild's walk.
+func (q *Queries) EnumeratePeriodCells(ctx context.Context, arg EnumeratePeriodCellsParams) ([]EnumeratePeriodCellsRow, error) {
+	rows, err := q.db.Query(ctx, enumeratePeriodCells, arg.StartsAt, arg.EndsAt, arg.RequireVerifiedEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnumeratePeriodCellsRow{}
+	for rows.Next() {
+		var i EnumeratePeriodCellsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Mode,
+			&i.DurationMs,
+			&i.WordCount,
+			&i.Lang,
+			&i.TextSourceKind,
+			&i.QuoteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const freezePeriod = `-- name: FreezePeriod :execrows
+INSERT INTO leaderboard_periods (id, kind, name, starts_at, ends_at, frozen_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id) DO NOTHING
+`
+
+type FreezePeriodParams struct {
+	ID       string
+	Kind     string
+	Name     *string
+	StartsAt time.Time
+	EndsAt   time.Time
+	FrozenAt time.Time
+}
+
+// Freezing, step one: claim the window. Zero rows means another freezer (or an
+// earlier pass) already archived it, and what is left live is a straggler to
+// delete, not a second set of standings.
+func (q *Queries) FreezePeriod(ctx context.Context, arg FreezePeriodParams) (int64, error) {
+	result, err := q.db.Exec(ctx, freezePeriod,
+		arg.ID,
+		arg.Kind,
+		arg.Name,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.FrozenAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getFollowingEntry = `-- name: GetFollowingEntry :one
 SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
        achieved_at, quote_source
 FROM leaderboard_rows
-WHERE bucket_key = $1 AND user_id = $2
+WHERE bucket_key = $1
+  AND user_id = $2
+  AND user_id IN (SELECT c.user_id FROM following_circle($3) c)
 `
 
-type GetLeaderboardEntryParams struct {
+type GetFollowingEntryParams struct {
 	BucketKey string
 	UserID    uuid.UUID
+	Viewer    uuid.UUID
 }
 
-type GetLeaderboardEntryRow struct {
+type GetFollowingEntryRow struct {
 	UserID      uuid.UUID
 	DisplayName string
 	RunID       uuid.UUID
@@ -156,11 +656,11 @@ type GetLeaderboardEntryRow struct {
 	QuoteSource *string
 }
 
-// One player's entry in one bucket. A banned player has none (the view), which
-// is exactly the 204 the /me endpoint wants.
-func (q *Queries) GetLeaderboardEntry(ctx context.Context, arg GetLeaderboardEntryParams) (GetLeaderboardEntryRow, error) {
-	row := q.db.QueryRow(ctx, getLeaderboardEntry, arg.BucketKey, arg.UserID)
-	var i GetLeaderboardEntryRow
+// One player's entry on a circle's board: none for a player outside the
+// circle, exactly as none for a banned one.
+func (q *Queries) GetFollowingEntry(ctx context.Context, arg GetFollowingEntryParams) (GetFollowingEntryRow, error) {
+	row := q.db.QueryRow(ctx, getFollowingEntry, arg.BucketKey, arg.UserID, arg.Viewer)
+	var i GetFollowingEntryRow
 	err := row.Scan(
 		&i.UserID,
 		&i.DisplayName,
@@ -177,33 +677,1159 @@ func (q *Queries) GetLeaderboardEntry(ctx context.Context, arg GetLeaderboardEnt
 	return i, err
 }
 
-const listLeaderboardBuckets = `-- name: ListLeaderboardBuckets :many
-SELECT bucket_key, count(*)::bigint AS entries
-FROM leaderboard_ranked
+const getFrozenPeriod = `-- name: GetFrozenPeriod :one
+SELECT id, kind, name, starts_at, ends_at, frozen_at
+FROM leaderboard_periods
+WHERE id = $1
+`
+
+func (q *Queries) GetFrozenPeriod(ctx context.Context, id string) (LeaderboardPeriod, error) {
+	row := q.db.QueryRow(ctx, getFrozenPeriod, id)
+	var i LeaderboardPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
+const getLeaderboardEntry = `-- name: GetLeaderboardEntry :one
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1 AND user_id = $2
+`
+
+type GetLeaderboardEntryParams struct {
+	BucketKey string
+	UserID    uuid.UUID
+}
+
+type GetLeaderboardEntryRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// One player's entry in one bucket. A banned player has none (the view), which
+// is exactly the 204 the /me endpoint wants.
+func (q *Queries) GetLeaderboardEntry(ctx context.Context, arg GetLeaderboardEntryParams) (GetLeaderboardEntryRow, error) {
+	row := q.db.QueryRow(ctx, getLeaderboardEntry, arg.BucketKey, arg.UserID)
+	var i GetLeaderboardEntryRow
+	err := row.Scan(
+		&i.UserID,
+		&i.DisplayName,
+		&i.RunID,
+		&i.Score,
+		&i.Wpm,
+		&i.Raw,
+		&i.Acc,
+		&i.Grade,
+		&i.Mods,
+		&i.AchievedAt,
+		&i.QuoteSource,
+	)
+	return i, err
+}
+
+const getRankSeen = `-- name: GetRankSeen :one
+SELECT rank, seen_at
+FROM leaderboard_rank_seen
+WHERE user_id = $1 AND bucket_key = $2
+`
+
+type GetRankSeenParams struct {
+	UserID    uuid.UUID
+	BucketKey string
+}
+
+type GetRankSeenRow struct {
+	Rank   int64
+	SeenAt time.Time
+}
+
+func (q *Queries) GetRankSeen(ctx context.Context, arg GetRankSeenParams) (GetRankSeenRow, error) {
+	row := q.db.QueryRow(ctx, getRankSeen, arg.UserID, arg.BucketKey)
+	var i GetRankSeenRow
+	err := row.Scan(&i.Rank, &i.SeenAt)
+	return i, err
+}
+
+const getSeason = `-- name: GetSeason :one
+SELECT id, name, starts_at, ends_at
+FROM leaderboard_seasons
+WHERE id = $1
+`
+
+func (q *Queries) GetSeason(ctx context.Context, id string) (LeaderboardSeason, error) {
+	row := q.db.QueryRow(ctx, getSeason, id)
+	var i LeaderboardSeason
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+	)
+	return i, err
+}
+
+const listFollowingPageAfter = `-- name: ListFollowingPageAfter :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+  AND sort_key <= leaderboard_sort_key($3, $4)
+  AND (sort_key < leaderboard_sort_key($3, $4)
+       OR achieved_at > $4::timestamptz
+       OR (achieved_at = $4::timestamptz AND user_id > $5::uuid))
+ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
+LIMIT $6
+`
+
+type ListFollowingPageAfterParams struct {
+	BucketKey  string
+	Viewer     uuid.UUID
+	Score      int64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+	RowLimit   int32
+}
+
+type ListFollowingPageAfterRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// The downward continuation of a circle's board: ListLeaderboardPageAfter's
+// predicate, over the circle.
+func (q *Queries) ListFollowingPageAfter(ctx context.Context, arg ListFollowingPageAfterParams) ([]ListFollowingPageAfterRow, error) {
+	rows, err := q.db.Query(ctx, listFollowingPageAfter,
+		arg.BucketKey,
+		arg.Viewer,
+		arg.Score,
+		arg.AchievedAt,
+		arg.UserID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingPageAfterRow{}
+	for rows.Next() {
+		var i ListFollowingPageAfterRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingPageBefore = `-- name: ListFollowingPageBefore :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+  AND sort_key >= leaderboard_sort_key($3, $4)
+  AND (sort_key > leaderboard_sort_key($3, $4)
+       OR achieved_at < $4::timestamptz
+       OR (achieved_at = $4::timestamptz AND user_id < $5::uuid))
+ORDER BY sort_key ASC, achieved_at DESC, user_id DESC
+LIMIT $6
+`
+
+type ListFollowingPageBeforeParams struct {
+	BucketKey  string
+	Viewer     uuid.UUID
+	Score      int64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+	RowLimit   int32
+}
+
+type ListFollowingPageBeforeRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// The upward continuation of a circle's board, nearest first.
+func (q *Queries) ListFollowingPageBefore(ctx context.Context, arg ListFollowingPageBeforeParams) ([]ListFollowingPageBeforeRow, error) {
+	rows, err := q.db.Query(ctx, listFollowingPageBefore,
+		arg.BucketKey,
+		arg.Viewer,
+		arg.Score,
+		arg.AchievedAt,
+		arg.UserID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingPageBeforeRow{}
+	for rows.Next() {
+		var i ListFollowingPageBeforeRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingPageFirst = `-- name: ListFollowingPageFirst :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
+LIMIT $3
+`
+
+type ListFollowingPageFirstParams struct {
+	BucketKey string
+	Viewer    uuid.UUID
+	RowLimit  int32
+}
+
+type ListFollowingPageFirstRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// Page one of a circle's board, in the score order.
+func (q *Queries) ListFollowingPageFirst(ctx context.Context, arg ListFollowingPageFirstParams) ([]ListFollowingPageFirstRow, error) {
+	rows, err := q.db.Query(ctx, listFollowingPageFirst, arg.BucketKey, arg.Viewer, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingPageFirstRow{}
+	for rows.Next() {
+		var i ListFollowingPageFirstRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingSnapshotPage = `-- name: ListFollowingSnapshotPage :many
+SELECT rank, user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM (SELECT row_number() OVER (ORDER BY s.rank)::bigint AS rank,
+             s.user_id, u.display_name, s.run_id, s.score, s.wpm, s.raw,
+             s.acc, s.grade, s.mods, s.achieved_at, s.quote_source
+      FROM leaderboard_snapshots s
+               JOIN users u ON u.id = s.user_id
+      WHERE s.bucket_key = $1
+        AND s.user_id IN (SELECT c.user_id FROM following_circle($2) c)) circle
+WHERE rank > $3
+  AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = circle.user_id)
+ORDER BY rank
+LIMIT $4
+`
+
+type ListFollowingSnapshotPageParams struct {
+	BucketKey string
+	Viewer    uuid.UUID
+	AfterRank int64
+	RowLimit  int32
+}
+
+type ListFollowingSnapshotPageRow struct {
+	Rank        int64
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// One page of a circle's share of a frozen board, renumbered within the
+// circle by the order the board froze in. The circle is today's, not the one
+// at the freeze — it is the viewer's question about players they follow now.
+//
+// The numbering is taken BEFORE the ban filter, for the reason the global
+// read leaves a banned player's rank as a gap: the standings are history.
+func (q *Queries) ListFollowingSnapshotPage(ctx context.Context, arg ListFollowingSnapshotPageParams) ([]ListFollowingSnapshotPageRow, error) {
+	rows, err := q.db.Query(ctx, listFollowingSnapshotPage,
+		arg.BucketKey,
+		arg.Viewer,
+		arg.AfterRank,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingSnapshotPageRow{}
+	for rows.Next() {
+		var i ListFollowingSnapshotPageRow
+		if err := rows.Scan(
+			&i.Rank,
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingWPMPageAfter = `-- name: ListFollowingWPMPageAfter :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+  AND wpm <= $3::numeric
+  AND (wpm < $3::numeric
+       OR achieved_at > $4::timestamptz
+       OR (achieved_at = $4::timestamptz AND user_id > $5::uuid))
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT $6
+`
+
+type ListFollowingWPMPageAfterParams struct {
+	BucketKey  string
+	Viewer     uuid.UUID
+	Wpm        float64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+	RowLimit   int32
+}
+
+type ListFollowingWPMPageAfterRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// The downward WPM continuation of a circle's board.
+func (q *Queries) ListFollowingWPMPageAfter(ctx context.Context, arg ListFollowingWPMPageAfterParams) ([]ListFollowingWPMPageAfterRow, error) {
+	rows, err := q.db.Query(ctx, listFollowingWPMPageAfter,
+		arg.BucketKey,
+		arg.Viewer,
+		arg.Wpm,
+		arg.AchievedAt,
+		arg.UserID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingWPMPageAfterRow{}
+	for rows.Next() {
+		var i ListFollowingWPMPageAfterRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingWPMPageBefore = `-- name: ListFollowingWPMPageBefore :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+  AND wpm >= $3::numeric
+  AND (wpm > $3::numeric
+       OR achieved_at < $4::timestamptz
+       OR (achieved_at = $4::timestamptz AND user_id < $5::uuid))
+ORDER BY wpm ASC, achieved_at DESC, user_id DESC
+LIMIT $6
+`
+
+type ListFollowingWPMPageBeforeParams struct {
+	BucketKey  string
+	Viewer     uuid.UUID
+	Wpm        float64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+	RowLimit   int32
+}
+
+type ListFollowingWPMPageBeforeRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// The upward WPM continuation of a circle's board, nearest first.
+func (q *Queries) ListFollowingWPMPageBefore(ctx context.Context, arg ListFollowingWPMPageBeforeParams) ([]ListFollowingWPMPageBeforeRow, error) {
+	rows, err := q.db.Query(ctx, listFollowingWPMPageBefore,
+		arg.BucketKey,
+		arg.Viewer,
+		arg.Wpm,
+		arg.AchievedAt,
+		arg.UserID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingWPMPageBeforeRow{}
+	for rows.Next() {
+		var i ListFollowingWPMPageBeforeRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowingWPMPageFirst = `-- name: ListFollowingWPMPageFirst :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND user_id IN (SELECT c.user_id FROM following_circle($2) c)
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT $3
+`
+
+type ListFollowingWPMPageFirstParams struct {
+	BucketKey string
+	Viewer    uuid.UUID
+	RowLimit  int32
+}
+
+type ListFollowingWPMPageFirstRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// Page one of a circle's board, in the WPM order.
+func (q *Queries) ListFollowingWPMPageFirst(ctx context.Context, arg ListFollowingWPMPageFirstParams) ([]ListFollowingWPMPageFirstRow, error) {
+	rows, err := q.db.Query(ctx, listFollowingWPMPageFirst, arg.BucketKey, arg.Viewer, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingWPMPageFirstRow{}
+	for rows.Next() {
+		var i ListFollowingWPMPageFirstRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardBoards = `-- name: ListLeaderboardBoards :many
+WITH hidden AS (
+    SELECT e.bucket_key, count(*) AS entries
+    FROM leaderboard_entries e
+    WHERE e.period IS NULL
+      AND e.user_id IN (SELECT user_id FROM active_bans)
+    GROUP BY e.bucket_key
+), boards AS (
+    SELECT b.bucket_key, b.mode, b.lang, b.text_source, b.quote_id, b.len_group,
+           b.changed_at, b.entries - coalesce(h.entries, 0) AS entries
+    FROM leaderboard_boards b
+             LEFT JOIN hidden h ON h.bucket_key = b.bucket_key
+)
+SELECT bucket_key, lang, len_group, changed_at, entries::bigint AS entries
+FROM boards
+WHERE entries >= greatest($1::bigint, 1)
+  AND ($2::text IS NULL OR mode = $2::text)
+  AND ($3::text IS NULL OR lang = $3::text)
+  AND ($4::text IS NULL OR text_source = $4::text)
+  AND ($5::smallint IS NULL OR len_group = $5::smallint)
+  AND (quote_id IS NULL OR quote_id <> ALL ($6::uuid[]))
+  AND ($7::text IS NULL OR bucket_key > $7::text)
+ORDER BY bucket_key
+LIMIT $8;
+`
+
+type ListLeaderboardBoardsParams struct {
+	MinEntries int64
+	Mode       *string
+	Lang       *string
+	TextSource *string
+	LenGroup   *int16
+	Withdrawn  []uuid.UUID
+	AfterKey   *string
+	RowLimit   int32
+}
+
+type ListLeaderboardBoardsRow struct {
+	BucketKey string
+	Lang      *string
+	LenGroup  *int16
+	ChangedAt time.Time
+	Entries   int64
+}
+
+// The board index in key order, one page of it (00044). It reads
+// leaderboard_boards, one row per board, where the catalogue above reads every
+// entry; the counts there are maintained, and bans are taken off them here.
+//
+// `hidden` is the banned players' share of each board: their entries, through
+// leaderboard_entries_user_idx. It is as many rows as banned players have
+// boards, which is small, and it is what lets a ban stay a read-time rule
+// (see "Why bans are filtered on read") without a count to keep per ban.
+//
+// Every filter is optional and ANDs with the rest. A withdrawn quote's board
+// is left out here and nowhere else (docs/REPORTS.md). Filtering in SQL keeps
+// the page full: a page trimmed after LIMIT is one a client has to ask again.
+func (q *Queries) ListLeaderboardBoards(ctx context.Context, arg ListLeaderboardBoardsParams) ([]ListLeaderboardBoardsRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardBoards,
+		arg.MinEntries,
+		arg.Mode,
+		arg.Lang,
+		arg.TextSource,
+		arg.LenGroup,
+		arg.Withdrawn,
+		arg.AfterKey,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaderboardBoardsRow{}
+	for rows.Next() {
+		var i ListLeaderboardBoardsRow
+		if err := rows.Scan(
+			&i.BucketKey,
+			&i.Lang,
+			&i.LenGroup,
+			&i.ChangedAt,
+			&i.Entries,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardBoardsByEntries = `-- name: ListLeaderboardBoardsByEntries :many
+WITH hidden AS (
+    SELECT e.bucket_key, count(*) AS entries
+    FROM leaderboard_entries e
+    WHERE e.period IS NULL
+      AND e.user_id IN (SELECT user_id FROM active_bans)
+    GROUP BY e.bucket_key
+), boards AS (
+    SELECT b.bucket_key, b.mode, b.lang, b.text_source, b.quote_id, b.len_group,
+           b.changed_at, b.entries - coalesce(h.entries, 0) AS entries
+    FROM leaderboard_boards b
+             LEFT JOIN hidden h ON h.bucket_key = b.bucket_key
+)
+SELECT bucket_key, lang, len_group, changed_at, entries::bigint AS entries
+FROM boards
+WHERE entries >= greatest($1::bigint, 1)
+  AND ($2::text IS NULL OR mode = $2::text)
+  AND ($3::text IS NULL OR lang = $3::text)
+  AND ($4::text IS NULL OR text_source = $4::text)
+  AND ($5::smallint IS NULL OR len_group = $5::smallint)
+  AND (quote_id IS NULL OR quote_id <> ALL ($6::uuid[]))
+  AND ($7::text IS NULL
+       OR entries < $8::bigint
+       OR (entries = $8::bigint AND bucket_key > $7::text))
+ORDER BY entries DESC, bucket_key
+LIMIT $9;
+`
+
+type ListLeaderboardBoardsByEntriesParams struct {
+	MinEntries   int64
+	Mode         *string
+	Lang         *string
+	TextSource   *string
+	LenGroup     *int16
+	Withdrawn    []uuid.UUID
+	AfterKey     *string
+	AfterEntries int64
+	RowLimit     int32
+}
+
+type ListLeaderboardBoardsByEntriesRow struct {
+	BucketKey string
+	Lang      *string
+	LenGroup  *int16
+	ChangedAt time.Time
+	Entries   int64
+}
+
+// ListLeaderboardBoards with the biggest boards first, the key breaking a tie.
+// The order is on the VISIBLE count, so it has to be sorted after the ban
+// correction; with one row per board that sort is cheap.
+func (q *Queries) ListLeaderboardBoardsByEntries(ctx context.Context, arg ListLeaderboardBoardsByEntriesParams) ([]ListLeaderboardBoardsByEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardBoardsByEntries,
+		arg.MinEntries,
+		arg.Mode,
+		arg.Lang,
+		arg.TextSource,
+		arg.LenGroup,
+		arg.Withdrawn,
+		arg.AfterKey,
+		arg.AfterEntries,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaderboardBoardsByEntriesRow{}
+	for rows.Next() {
+		var i ListLeaderboardBoardsByEntriesRow
+		if err := rows.Scan(
+			&i.BucketKey,
+			&i.Lang,
+			&i.LenGroup,
+			&i.ChangedAt,
+			&i.Entries,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardBoardsByRecent = `-- name: ListLeaderboardBoardsByRecent :many
+WITH hidden AS (
+    SELECT e.bucket_key, count(*) AS entries
+    FROM leaderboard_entries e
+    WHERE e.period IS NULL
+      AND e.user_id IN (SELECT user_id FROM active_bans)
+    GROUP BY e.bucket_key
+), boards AS (
+    SELECT b.bucket_key, b.mode, b.lang, b.text_source, b.quote_id, b.len_group,
+           b.changed_at, b.entries - coalesce(h.entries, 0) AS entries
+    FROM leaderboard_boards b
+             LEFT JOIN hidden h ON h.bucket_key = b.bucket_key
+)
+SELECT bucket_key, lang, len_group, changed_at, entries::bigint AS entries
+FROM boards
+WHERE entries >= greatest($1::bigint, 1)
+  AND ($2::text IS NULL OR mode = $2::text)
+  AND ($3::text IS NULL OR lang = $3::text)
+  AND ($4::text IS NULL OR text_source = $4::text)
+  AND ($5::smallint IS NULL OR len_group = $5::smallint)
+  AND (quote_id IS NULL OR quote_id <> ALL ($6::uuid[]))
+  AND ($7::text IS NULL
+       OR changed_at < $8::timestamptz
+       OR (changed_at = $8::timestamptz AND bucket_key > $7::text))
+ORDER BY changed_at DESC, bucket_key
+LIMIT $9;
+`
+
+type ListLeaderboardBoardsByRecentParams struct {
+	MinEntries     int64
+	Mode           *string
+	Lang           *string
+	TextSource     *string
+	LenGroup       *int16
+	Withdrawn      []uuid.UUID
+	AfterKey       *string
+	AfterChangedAt time.Time
+	RowLimit       int32
+}
+
+type ListLeaderboardBoardsByRecentRow struct {
+	BucketKey string
+	Lang      *string
+	LenGroup  *int16
+	ChangedAt time.Time
+	Entries   int64
+}
+
+// ListLeaderboardBoards with the most recently changed board first, the key
+// breaking a tie.
+func (q *Queries) ListLeaderboardBoardsByRecent(ctx context.Context, arg ListLeaderboardBoardsByRecentParams) ([]ListLeaderboardBoardsByRecentRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardBoardsByRecent,
+		arg.MinEntries,
+		arg.Mode,
+		arg.Lang,
+		arg.TextSource,
+		arg.LenGroup,
+		arg.Withdrawn,
+		arg.AfterKey,
+		arg.AfterChangedAt,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaderboardBoardsByRecentRow{}
+	for rows.Next() {
+		var i ListLeaderboardBoardsByRecentRow
+		if err := rows.Scan(
+			&i.BucketKey,
+			&i.Lang,
+			&i.LenGroup,
+			&i.ChangedAt,
+			&i.Entries,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardBuckets = `-- name: ListLeaderboardBuckets :many
+SELECT bucket_key, count(*)::bigint AS entries
+FROM leaderboard_ranked
 GROUP BY bucket_key
 ORDER BY bucket_key
 `
 
-type ListLeaderboardBucketsRow struct {
+type ListLeaderboardBucketsRow struct {
+	BucketKey string
+	Entries   int64
+}
+
+// Every board and its visible count, by a scan over every entry: the board
+// index before leaderboard_boards (00044), and still the reference its maintained
+// counts are checked against. Counts are ban-filtered like every other read, so a
+// bucket whose only player is banned reports zero rather than one.
+//
+// Quote boards are in here alongside the language ones, and they are the reason
+// this result is no longer bounded by the schema — see docs/LEADERBOARDS.md.
+func (q *Queries) ListLeaderboardBuckets(ctx context.Context) ([]ListLeaderboardBucketsRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardBuckets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaderboardBucketsRow{}
+	for rows.Next() {
+		var i ListLeaderboardBucketsRow
+		if err := rows.Scan(&i.BucketKey, &i.Entries); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardPageAfter = `-- name: ListLeaderboardPageAfter :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND sort_key <= leaderboard_sort_key($2, $3)
+  AND (sort_key < leaderboard_sort_key($2, $3)
+       OR achieved_at > $3::timestamptz
+       OR (achieved_at = $3::timestamptz AND user_id > $4::uuid))
+ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
+LIMIT $5
+`
+
+type ListLeaderboardPageAfterParams struct {
+	BucketKey  string
+	Score      int64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+	RowLimit   int32
+}
+
+type ListLeaderboardPageAfterRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// The keyset continuation, as a genuine start condition.
+//
+// `sort_key <= cursor` is the part the index can descend to: it lands the scan
+// ON the cursor row instead of at rank 1. The second clause is what excludes
+// the cursor row itself and orders the players who share its exact sort_key —
+// the same score in the same second, ordered by who got there first to the
+// microsecond — and it can only remove rows the scan has
+// already reached, so it is a Filter over a handful of ties rather than over
+// everything above the cursor. Measured on a 20 000-row bucket at depth 10 000:
+// Rows Removed by Filter 1, 53 buffer hits, against 50 092 and 39 482 for the
+// OR-of-conjunctions this replaced.
+func (q *Queries) ListLeaderboardPageAfter(ctx context.Context, arg ListLeaderboardPageAfterParams) ([]ListLeaderboardPageAfterRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardPageAfter,
+		arg.BucketKey,
+		arg.Score,
+		arg.AchievedAt,
+		arg.UserID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaderboardPageAfterRow{}
+	for rows.Next() {
+		var i ListLeaderboardPageAfterRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardPageBefore = `-- name: ListLeaderboardPageBefore :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND sort_key >= leaderboard_sort_key($2, $3)
+  AND (sort_key > leaderboard_sort_key($2, $3)
+       OR achieved_at < $3::timestamptz
+       OR (achieved_at = $3::timestamptz AND user_id < $4::uuid))
+ORDER BY sort_key ASC, achieved_at DESC, user_id DESC
+LIMIT $5
+`
+
+type ListLeaderboardPageBeforeParams struct {
+	BucketKey  string
+	Score      int64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+	RowLimit   int32
+}
+
+type ListLeaderboardPageBeforeRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// The keyset continuation UPWARD: the rows strictly outranking a position,
+// nearest first — the other half of "load more continues in both directions
+// from the around=me window".
+//
+// Same predicate as CountLeaderboardAbove (they answer about the same set:
+// what outranks this position), but selecting rows and scanning the ranking
+// order REVERSED. leaderboard_sort_idx serves it as a backward index scan —
+// a btree walks either way — so this is the same start-condition seek as the
+// downward continuation, not a filter, and needs no second index. The caller
+// re-reverses the slice; SQL's job is only to make LIMIT take the rows
+// NEAREST the position rather than rank 1's neighbours.
+func (q *Queries) ListLeaderboardPageBefore(ctx context.Context, arg ListLeaderboardPageBeforeParams) ([]ListLeaderboardPageBeforeRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardPageBefore,
+		arg.BucketKey,
+		arg.Score,
+		arg.AchievedAt,
+		arg.UserID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaderboardPageBeforeRow{}
+	for rows.Next() {
+		var i ListLeaderboardPageBeforeRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardPageFirst = `-- name: ListLeaderboardPageFirst :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
+LIMIT $2
+`
+
+type ListLeaderboardPageFirstParams struct {
 	BucketKey string
-	Entries   int64
+	RowLimit  int32
+}
+
+type ListLeaderboardPageFirstRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
 }
 
-// The board index. Counts are ban-filtered like every other read, so a bucket
-// whose only player is banned reports zero rather than one.
+// Page one: best score first, earliest achievement first on a tie, user_id as
+// the final tiebreak so the order is total (and therefore pageable).
 //
-// Quote boards are in here alongside the language ones, and they are the reason
-// this result is no longer bounded by the schema — see docs/LEADERBOARDS.md.
-func (q *Queries) ListLeaderboardBuckets(ctx context.Context) ([]ListLeaderboardBucketsRow, error) {
-	rows, err := q.db.Query(ctx, listLeaderboardBuckets)
+// The order is expressed over sort_key, which packs (score, achieved_at) into
+// one descending integer — see db/migrations/00011. That is what makes the
+// continuation below a btree start condition instead of a filter.
+func (q *Queries) ListLeaderboardPageFirst(ctx context.Context, arg ListLeaderboardPageFirstParams) ([]ListLeaderboardPageFirstRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardPageFirst, arg.BucketKey, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListLeaderboardBucketsRow{}
+	items := []ListLeaderboardPageFirstRow{}
 	for rows.Next() {
-		var i ListLeaderboardBucketsRow
-		if err := rows.Scan(&i.BucketKey, &i.Entries); err != nil {
+		var i ListLeaderboardPageFirstRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -214,28 +1840,104 @@ func (q *Queries) ListLeaderboardBuckets(ctx context.Context) ([]ListLeaderboard
 	return items, nil
 }
 
-const listLeaderboardPageAfter = `-- name: ListLeaderboardPageAfter :many
+const listLeaderboardWPMPageAfter = `-- name: ListLeaderboardWPMPageAfter :many
 SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
        achieved_at, quote_source
 FROM leaderboard_rows
 WHERE bucket_key = $1
-  AND sort_key <= leaderboard_sort_key($2, $3)
-  AND (sort_key < leaderboard_sort_key($2, $3)
+  AND wpm <= $2::numeric
+  AND (wpm < $2::numeric
        OR achieved_at > $3::timestamptz
        OR (achieved_at = $3::timestamptz AND user_id > $4::uuid))
-ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT $5
+`
+
+type ListLeaderboardWPMPageAfterParams struct {
+	BucketKey  string
+	Wpm        float64
+	AchievedAt time.Time
+	UserID     uuid.UUID
+	RowLimit   int32
+}
+
+type ListLeaderboardWPMPageAfterRow struct {
+	UserID      uuid.UUID
+	DisplayName string
+	RunID       uuid.UUID
+	Score       int64
+	Wpm         float64
+	Raw         float64
+	Acc         float64
+	Grade       string
+	Mods        json.RawMessage
+	AchievedAt  time.Time
+	QuoteSource *string
+}
+
+// The WPM keyset continuation. Same shape as ListLeaderboardPageAfter with wpm
+// in sort_key's place: `wpm <= cursor` is the start condition, and the second
+// clause only orders the rows that share the cursor's exact wpm.
+func (q *Queries) ListLeaderboardWPMPageAfter(ctx context.Context, arg ListLeaderboardWPMPageAfterParams) ([]ListLeaderboardWPMPageAfterRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardWPMPageAfter,
+		arg.BucketKey,
+		arg.Wpm,
+		arg.AchievedAt,
+		arg.UserID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaderboardWPMPageAfterRow{}
+	for rows.Next() {
+		var i ListLeaderboardWPMPageAfterRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.DisplayName,
+			&i.RunID,
+			&i.Score,
+			&i.Wpm,
+			&i.Raw,
+			&i.Acc,
+			&i.Grade,
+			&i.Mods,
+			&i.AchievedAt,
+			&i.QuoteSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaderboardWPMPageBefore = `-- name: ListLeaderboardWPMPageBefore :many
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = $1
+  AND wpm >= $2::numeric
+  AND (wpm > $2::numeric
+       OR achieved_at < $3::timestamptz
+       OR (achieved_at = $3::timestamptz AND user_id < $4::uuid))
+ORDER BY wpm ASC, achieved_at DESC, user_id DESC
 LIMIT $5
 `
 
-type ListLeaderboardPageAfterParams struct {
+type ListLeaderboardWPMPageBeforeParams struct {
 	BucketKey  string
-	Score      int64
+	Wpm        float64
 	AchievedAt time.Time
 	UserID     uuid.UUID
 	RowLimit   int32
 }
 
-type ListLeaderboardPageAfterRow struct {
+type ListLeaderboardWPMPageBeforeRow struct {
 	UserID      uuid.UUID
 	DisplayName string
 	RunID       uuid.UUID
@@ -249,21 +1951,12 @@ type ListLeaderboardPageAfterRow struct {
 	QuoteSource *string
 }
 
-// The keyset continuation, as a genuine start condition.
-//
-// `sort_key <= cursor` is the part the index can descend to: it lands the scan
-// ON the cursor row instead of at rank 1. The second clause is what excludes
-// the cursor row itself and orders the players who share its exact sort_key —
-// the same score in the same second, ordered by who got there first to the
-// microsecond — and it can only remove rows the scan has
-// already reached, so it is a Filter over a handful of ties rather than over
-// everything above the cursor. Measured on a 20 000-row bucket at depth 10 000:
-// Rows Removed by Filter 1, 53 buffer hits, against 50 092 and 39 482 for the
-// OR-of-conjunctions this replaced.
-func (q *Queries) ListLeaderboardPageAfter(ctx context.Context, arg ListLeaderboardPageAfterParams) ([]ListLeaderboardPageAfterRow, error) {
-	rows, err := q.db.Query(ctx, listLeaderboardPageAfter,
+// The WPM continuation upward, nearest first: a backward scan of
+// leaderboard_wpm_idx, re-reversed by the caller exactly as for the score order.
+func (q *Queries) ListLeaderboardWPMPageBefore(ctx context.Context, arg ListLeaderboardWPMPageBeforeParams) ([]ListLeaderboardWPMPageBeforeRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardWPMPageBefore,
 		arg.BucketKey,
-		arg.Score,
+		arg.Wpm,
 		arg.AchievedAt,
 		arg.UserID,
 		arg.RowLimit,
@@ -272,9 +1965,9 @@ func (q *Queries) ListLeaderboardPageAfter(ctx context.Context, arg ListLeaderbo
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListLeaderboardPageAfterRow{}
+	items := []ListLeaderboardWPMPageBeforeRow{}
 	for rows.Next() {
-		var i ListLeaderboardPageAfterRow
+		var i ListLeaderboardWPMPageBeforeRow
 		if err := rows.Scan(
 			&i.UserID,
 			&i.DisplayName,
@@ -298,28 +1991,21 @@ func (q *Queries) ListLeaderboardPageAfter(ctx context.Context, arg ListLeaderbo
 	return items, nil
 }
 
-const listLeaderboardPageBefore = `-- name: ListLeaderboardPageBefore :many
+const listLeaderboardWPMPageFirst = `-- name: ListLeaderboardWPMPageFirst :many
 SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
        achieved_at, quote_source
 FROM leaderboard_rows
 WHERE bucket_key = $1
-  AND sort_key >= leaderboard_sort_key($2, $3)
-  AND (sort_key > leaderboard_sort_key($2, $3)
-       OR achieved_at < $3::timestamptz
-       OR (achieved_at = $3::timestamptz AND user_id < $4::uuid))
-ORDER BY sort_key ASC, achieved_at DESC, user_id DESC
-LIMIT $5
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT $2
 `
 
-type ListLeaderboardPageBeforeParams struct {
-	BucketKey  string
-	Score      int64
-	AchievedAt time.Time
-	UserID     uuid.UUID
-	RowLimit   int32
+type ListLeaderboardWPMPageFirstParams struct {
+	BucketKey string
+	RowLimit  int32
 }
 
-type ListLeaderboardPageBeforeRow struct {
+type ListLeaderboardWPMPageFirstRow struct {
 	UserID      uuid.UUID
 	DisplayName string
 	RunID       uuid.UUID
@@ -333,32 +2019,18 @@ type ListLeaderboardPageBeforeRow struct {
 	QuoteSource *string
 }
 
-// The keyset continuation UPWARD: the rows strictly outranking a position,
-// nearest first — the other half of "load more continues in both directions
-// from the around=me window".
-//
-// Same predicate as CountLeaderboardAbove (they answer about the same set:
-// what outranks this position), but selecting rows and scanning the ranking
-// order REVERSED. leaderboard_sort_idx serves it as a backward index scan —
-// a btree walks either way — so this is the same start-condition seek as the
-// downward continuation, not a filter, and needs no second index. The caller
-// re-reverses the slice; SQL's job is only to make LIMIT take the rows
-// NEAREST the position rather than rank 1's neighbours.
-func (q *Queries) ListLeaderboardPageBefore(ctx context.Context, arg ListLeaderboardPageBeforeParams) ([]ListLeaderboardPageBeforeRow, error) {
-	rows, err := q.db.Query(ctx, listLeaderboardPageBefore,
-		arg.BucketKey,
-		arg.Score,
-		arg.AchievedAt,
-		arg.UserID,
-		arg.RowLimit,
-	)
+// Page one of the WPM order (00036): fastest first, then the score order's own
+// tie rule. The rows are the same entries the score order ranks — each one
+// still the player's best-SCORING run here — read off leaderboard_wpm_idx.
+func (q *Queries) ListLeaderboardWPMPageFirst(ctx context.Context, arg ListLeaderboardWPMPageFirstParams) ([]ListLeaderboardWPMPageFirstRow, error) {
+	rows, err := q.db.Query(ctx, listLeaderboardWPMPageFirst, arg.BucketKey, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListLeaderboardPageBeforeRow{}
+	items := []ListLeaderboardWPMPageFirstRow{}
 	for rows.Next() {
-		var i ListLeaderboardPageBeforeRow
+		var i ListLeaderboardWPMPageFirstRow
 		if err := rows.Scan(
 			&i.UserID,
 			&i.DisplayName,
@@ -382,21 +2054,127 @@ func (q *Queries) ListLeaderboardPageBefore(ctx context.Context, arg ListLeaderb
 	return items, nil
 }
 
-const listLeaderboardPageFirst = `-- name: ListLeaderboardPageFirst :many
-SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
-       achieved_at, quote_source
-FROM leaderboard_rows
-WHERE bucket_key = $1
-ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
-LIMIT $2
+const listLivePeriods = `-- name: ListLivePeriods :many
+SELECT DISTINCT period::text AS period
+FROM leaderboard_entries
+WHERE period IS NOT NULL
+ORDER BY period
 `
 
-type ListLeaderboardPageFirstParams struct {
+// Every window that still has live rows: the freezer's worklist, and what the
+// rebuild must put back.
+func (q *Queries) ListLivePeriods(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listLivePeriods)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var period string
+		if err := rows.Scan(&period); err != nil {
+			return nil, err
+		}
+		items = append(items, period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRankHistory = `-- name: ListRankHistory :many
+SELECT taken_at, rank, score
+FROM leaderboard_rank_history
+WHERE bucket_key = $1 AND user_id = $2
+ORDER BY taken_at DESC
+LIMIT $3
+`
+
+type ListRankHistoryParams struct {
 	BucketKey string
+	UserID    uuid.UUID
 	RowLimit  int32
 }
 
-type ListLeaderboardPageFirstRow struct {
+type ListRankHistoryRow struct {
+	TakenAt time.Time
+	Rank    int64
+	Score   int64
+}
+
+// A player's points on one board, newest first.
+func (q *Queries) ListRankHistory(ctx context.Context, arg ListRankHistoryParams) ([]ListRankHistoryRow, error) {
+	rows, err := q.db.Query(ctx, listRankHistory, arg.BucketKey, arg.UserID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRankHistoryRow{}
+	for rows.Next() {
+		var i ListRankHistoryRow
+		if err := rows.Scan(&i.TakenAt, &i.Rank, &i.Score); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSeasons = `-- name: ListSeasons :many
+SELECT id, name, starts_at, ends_at
+FROM leaderboard_seasons
+ORDER BY starts_at
+`
+
+func (q *Queries) ListSeasons(ctx context.Context) ([]LeaderboardSeason, error) {
+	rows, err := q.db.Query(ctx, listSeasons)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LeaderboardSeason{}
+	for rows.Next() {
+		var i LeaderboardSeason
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartsAt,
+			&i.EndsAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnapshotPage = `-- name: ListSnapshotPage :many
+SELECT s.rank, s.user_id, u.display_name, s.run_id, s.score, s.wpm, s.raw,
+       s.acc, s.grade, s.mods, s.achieved_at, s.quote_source
+FROM leaderboard_snapshots s
+         JOIN users u ON u.id = s.user_id
+WHERE s.bucket_key = $1
+  AND s.rank > $2
+  AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = s.user_id)
+ORDER BY s.rank
+LIMIT $3
+`
+
+type ListSnapshotPageParams struct {
+	BucketKey string
+	AfterRank int64
+	RowLimit  int32
+}
+
+type ListSnapshotPageRow struct {
+	Rank        int64
 	UserID      uuid.UUID
 	DisplayName string
 	RunID       uuid.UUID
@@ -410,22 +2188,21 @@ type ListLeaderboardPageFirstRow struct {
 	QuoteSource *string
 }
 
-// Page one: best score first, earliest achievement first on a tie, user_id as
-// the final tiebreak so the order is total (and therefore pageable).
-//
-// The order is expressed over sort_key, which packs (score, achieved_at) into
-// one descending integer — see db/migrations/00011. That is what makes the
-// continuation below a btree start condition instead of a filter.
-func (q *Queries) ListLeaderboardPageFirst(ctx context.Context, arg ListLeaderboardPageFirstParams) ([]ListLeaderboardPageFirstRow, error) {
-	rows, err := q.db.Query(ctx, listLeaderboardPageFirst, arg.BucketKey, arg.RowLimit)
+// One page of a frozen board, by its frozen rank. A player banned since the
+// freeze is hidden like on every other board, and their rank is left as a gap
+// rather than closed: the standings are history, and closing it would move
+// every player below them off the place they finished in.
+func (q *Queries) ListSnapshotPage(ctx context.Context, arg ListSnapshotPageParams) ([]ListSnapshotPageRow, error) {
+	rows, err := q.db.Query(ctx, listSnapshotPage, arg.BucketKey, arg.AfterRank, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListLeaderboardPageFirstRow{}
+	items := []ListSnapshotPageRow{}
 	for rows.Next() {
-		var i ListLeaderboardPageFirstRow
+		var i ListSnapshotPageRow
 		if err := rows.Scan(
+			&i.Rank,
 			&i.UserID,
 			&i.DisplayName,
 			&i.RunID,
@@ -448,6 +2225,113 @@ func (q *Queries) ListLeaderboardPageFirst(ctx context.Context, arg ListLeaderbo
 	return items, nil
 }
 
+const markRankDirty = `-- name: MarkRankDirty :exec
+INSERT INTO leaderboard_rank_dirty (bucket_key)
+VALUES ($1)
+ON CONFLICT (bucket_key) DO NOTHING
+`
+
+// The projection's whole share of rank history (00040): this board's ranks may
+// have moved. A board already marked is left alone, so a verdict on a busy
+// board costs one index probe and no write.
+func (q *Queries) MarkRankDirty(ctx context.Context, bucketKey string) error {
+	_, err := q.db.Exec(ctx, markRankDirty, bucketKey)
+	return err
+}
+
+const markRankSeen = `-- name: MarkRankSeen :exec
+INSERT INTO leaderboard_rank_seen (user_id, bucket_key, rank, seen_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, bucket_key) DO UPDATE
+    SET rank    = EXCLUDED.rank,
+        seen_at = EXCLUDED.seen_at
+`
+
+type MarkRankSeenParams struct {
+	UserID    uuid.UUID
+	BucketKey string
+	Rank      int64
+	SeenAt    time.Time
+}
+
+func (q *Queries) MarkRankSeen(ctx context.Context, arg MarkRankSeenParams) error {
+	_, err := q.db.Exec(ctx, markRankSeen,
+		arg.UserID,
+		arg.BucketKey,
+		arg.Rank,
+		arg.SeenAt,
+	)
+	return err
+}
+
+const recomputeDailyCell = `-- name: RecomputeDailyCell :exec
+WITH best AS (
+    SELECT e.day, e.run_id, e.user_id, e.mode, e.duration_ms, e.word_count, e.lang, e.text_source_kind, e.quote_id, e.score, e.wpm, e.raw, e.acc, e.mods, e.achieved_at
+    FROM daily_eligible_runs e
+    WHERE e.user_id = $1
+      AND e.day = $2::date
+      AND (NOT $3::boolean
+           OR EXISTS (SELECT 1 FROM auth_identities ai
+                      WHERE ai.user_id = $1 AND ai.email_verified))
+    ORDER BY e.achieved_at ASC, e.run_id ASC
+    LIMIT 1
+),
+cleared AS (
+    DELETE FROM leaderboard_entries le
+    WHERE le.bucket_key = $4
+      AND le.user_id = $1
+      AND NOT EXISTS (SELECT 1 FROM best)
+)
+INSERT INTO leaderboard_entries
+    (bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods, achieved_at,
+     quote_source, daily_day)
+SELECT $4, b.user_id, b.run_id, b.score, b.wpm, b.raw, b.acc,
+       run_grade(b.acc), b.mods, b.achieved_at, q.source, b.day
+FROM best b
+         LEFT JOIN quotes q ON q.id = b.quote_id
+ON CONFLICT (bucket_key, user_id) DO UPDATE
+    SET run_id       = EXCLUDED.run_id,
+        score        = EXCLUDED.score,
+        wpm          = EXCLUDED.wpm,
+        raw          = EXCLUDED.raw,
+        acc          = EXCLUDED.acc,
+        grade        = EXCLUDED.grade,
+        mods         = EXCLUDED.mods,
+        achieved_at  = EXCLUDED.achieved_at,
+        quote_source = EXCLUDED.quote_source,
+        daily_day    = EXCLUDED.daily_day
+    WHERE leaderboard_entries.run_id <> EXCLUDED.run_id
+`
+
+type RecomputeDailyCellParams struct {
+	UserID               uuid.UUID
+	Day                  time.Time
+	RequireVerifiedEmail bool
+	BucketKey            string
+}
+
+// Set one player's cell on one day's board to their attempt, or clear it.
+// RecomputeLeaderboardCell's statement in every respect that matters — the
+// same recompute-not-upsert shape, the same verified-email gate correlated the
+// same way, the same no-op when nothing changed — read from daily_eligible_runs
+// instead of the language coordinates.
+//
+// There is at most one candidate: runs_one_daily_attempt allows one attempt per
+// player per day. The ORDER BY is there so that if that ever stops being true
+// the FIRST attempt keeps the slot, which is the rule the index enforces.
+//
+// daily_day is written here and only here; it is what keeps this row out of
+// the ordinary one-slot-per-run index and out of the PB cards (00037).
+func (q *Queries) RecomputeDailyCell(ctx context.Context, arg RecomputeDailyCellParams) error {
+	_, err := q.db.Exec(ctx, recomputeDailyCell,
+		arg.UserID,
+		arg.Day,
+		arg.RequireVerifiedEmail,
+		arg.BucketKey,
+	)
+	return err
+}
+
 const recomputeLeaderboardCell = `-- name: RecomputeLeaderboardCell :exec
 WITH best AS (
     SELECT e.run_id, e.user_id, e.mode, e.duration_ms, e.word_count, e.lang, e.text_source_kind, e.quote_id, e.score, e.wpm, e.raw, e.acc, e.mods, e.achieved_at
@@ -570,11 +2454,104 @@ func (q *Queries) RecomputeLeaderboardCell(ctx context.Context, arg RecomputeLea
 	return err
 }
 
+const recomputePeriodCell = `-- name: RecomputePeriodCell :exec
+WITH best AS (
+    SELECT e.run_id, e.user_id, e.mode, e.duration_ms, e.word_count, e.lang, e.text_source_kind, e.quote_id, e.score, e.wpm, e.raw, e.acc, e.mods, e.achieved_at
+    FROM leaderboard_eligible_runs e
+    WHERE e.user_id = $1
+      AND e.quote_id IS NOT DISTINCT FROM $2::uuid
+      AND (e.quote_id IS NOT NULL
+           OR (e.mode = $3
+               AND e.duration_ms IS NOT DISTINCT FROM $4::int
+               AND e.word_count IS NOT DISTINCT FROM $5::int
+               AND e.lang = $6
+               AND e.text_source_kind = $7))
+      AND e.achieved_at >= $8
+      AND e.achieved_at < $9
+      AND (NOT $10::boolean
+           OR EXISTS (SELECT 1 FROM auth_identities ai
+                      WHERE ai.user_id = $1 AND ai.email_verified))
+    ORDER BY e.score DESC, e.achieved_at ASC, e.run_id ASC
+    LIMIT 1
+),
+cleared AS (
+    DELETE FROM leaderboard_entries le
+    WHERE le.bucket_key = $11
+      AND le.user_id = $1
+      AND NOT EXISTS (SELECT 1 FROM best)
+)
+INSERT INTO leaderboard_entries
+    (bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods, achieved_at,
+     quote_source, period)
+SELECT $11, b.user_id, b.run_id, b.score, b.wpm, b.raw, b.acc,
+       run_grade(b.acc), b.mods, b.achieved_at, q.source, $12::text
+FROM best b
+         LEFT JOIN quotes q ON q.id = b.quote_id
+ON CONFLICT (bucket_key, user_id) DO UPDATE
+    SET run_id       = EXCLUDED.run_id,
+        score        = EXCLUDED.score,
+        wpm          = EXCLUDED.wpm,
+        raw          = EXCLUDED.raw,
+        acc          = EXCLUDED.acc,
+        grade        = EXCLUDED.grade,
+        mods         = EXCLUDED.mods,
+        achieved_at  = EXCLUDED.achieved_at,
+        quote_source = EXCLUDED.quote_source,
+        period       = EXCLUDED.period
+    WHERE leaderboard_entries.run_id <> EXCLUDED.run_id
+`
+
+type RecomputePeriodCellParams struct {
+	UserID               uuid.UUID
+	QuoteID              *uuid.UUID
+	Mode                 string
+	DurationMs           *int32
+	WordCount            *int32
+	Lang                 string
+	TextSourceKind       string
+	StartsAt             time.Time
+	EndsAt               time.Time
+	RequireVerifiedEmail bool
+	BucketKey            string
+	Period               string
+}
+
+// Set one player's cell on one WINDOWED board — a language or quote board read
+// over [starts_at, ends_at) — to their best eligible run played inside the
+// window, or clear it. RecomputeLeaderboardCell's statement with the window
+// added to the candidate filter and `period` written: the same sibling rule,
+// the same verified-email gate correlated the same way, the same no-op on an
+// unchanged best. A windowed board is the all-time board with fewer runs in
+// it, and sharing the statement is what keeps that true.
+//
+// The window is on achieved_at, which the eligible view takes from
+// runs.created_at: a run counts towards the week it was PLAYED in, whenever
+// its verdict lands.
+func (q *Queries) RecomputePeriodCell(ctx context.Context, arg RecomputePeriodCellParams) error {
+	_, err := q.db.Exec(ctx, recomputePeriodCell,
+		arg.UserID,
+		arg.QuoteID,
+		arg.Mode,
+		arg.DurationMs,
+		arg.WordCount,
+		arg.Lang,
+		arg.TextSourceKind,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.RequireVerifiedEmail,
+		arg.BucketKey,
+		arg.Period,
+	)
+	return err
+}
+
 const runBucketCell = `-- name: RunBucketCell :one
 
 SELECT r.user_id, r.mode, r.duration_ms, r.word_count, r.lang,
        run_text_source_kind(r.setup)::text AS text_source_kind,
-       q.id AS quote_id
+       q.id AS quote_id,
+       run_daily_day(r.setup) AS daily_day,
+       r.created_at
 FROM runs r
          LEFT JOIN quotes q ON q.id = run_quote_id(r.setup)
 WHERE r.id = $1
@@ -588,6 +2565,8 @@ type RunBucketCellRow struct {
 	Lang           string
 	TextSourceKind string
 	QuoteID        *uuid.UUID
+	DailyDay       *string
+	CreatedAt      time.Time
 }
 
 // Bucketed score leaderboards (docs/LEADERBOARDS.md).
@@ -607,6 +2586,13 @@ type RunBucketCellRow struct {
 // document, so an id that names nothing yields NULL here exactly as it does in
 // the eligible view — one row, one primary-key probe, on a query that already
 // runs once per verdict.
+//
+// daily_day is the day the run declared itself an attempt at, as the run spells
+// it (00037), or NULL. A run that has one belongs to a SECOND cell — that day's
+// board — which RecomputeDailyCell maintains beside the first.
+//
+// created_at is when the run was played: the windows it counts towards (this
+// week, this month, the season) are the ones that instant falls in.
 func (q *Queries) RunBucketCell(ctx context.Context, runID uuid.UUID) (RunBucketCellRow, error) {
 	row := q.db.QueryRow(ctx, runBucketCell, runID)
 	var i RunBucketCellRow
@@ -618,6 +2604,91 @@ func (q *Queries) RunBucketCell(ctx context.Context, runID uuid.UUID) (RunBucket
 		&i.Lang,
 		&i.TextSourceKind,
 		&i.QuoteID,
+		&i.DailyDay,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const seasonAt = `-- name: SeasonAt :one
+SELECT id, name, starts_at, ends_at
+FROM leaderboard_seasons
+WHERE starts_at <= $1 AND $1 < ends_at
+`
+
+// The season running at an instant, if any. Seasons never overlap
+// (leaderboard_seasons_no_overlap), so this is at most one row.
+func (q *Queries) SeasonAt(ctx context.Context, at time.Time) (LeaderboardSeason, error) {
+	row := q.db.QueryRow(ctx, seasonAt, at)
+	var i LeaderboardSeason
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
 	)
 	return i, err
 }
+
+const snapshotPeriod = `-- name: SnapshotPeriod :execrows
+INSERT INTO leaderboard_snapshots
+    (period_id, bucket_key, rank, user_id, run_id, score, wpm, raw, acc, grade,
+     mods, achieved_at, quote_source)
+SELECT e.period, e.bucket_key,
+       row_number() OVER (PARTITION BY e.bucket_key
+                          ORDER BY e.sort_key DESC, e.achieved_at ASC, e.user_id ASC),
+       e.user_id, e.run_id, e.score, e.wpm, e.raw, e.acc, e.grade, e.mods,
+       e.achieved_at, e.quote_source
+FROM leaderboard_entries e
+WHERE e.period = $1
+  AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = e.user_id)
+`
+
+// Freezing, step two: copy every board of the window into the archive, ranked
+// the way its live page ranked it (sort_key, then achieved_at, then user_id)
+// and filtered by the same active_bans predicate the live reads go through —
+// the standings frozen are the standings a reader could see at the close.
+func (q *Queries) SnapshotPeriod(ctx context.Context, period *string) (int64, error) {
+	result, err := q.db.Exec(ctx, snapshotPeriod, period)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const snapshotRanks = `-- name: SnapshotRanks :execrows
+INSERT INTO leaderboard_rank_history (bucket_key, user_id, taken_at, rank, score)
+SELECT r.bucket_key, r.user_id, $1, r.rank, r.score
+FROM (SELECT bucket_key, user_id, score,
+             row_number() OVER (ORDER BY sort_key DESC, achieved_at ASC, user_id ASC) AS rank
+      FROM leaderboard_rows
+      WHERE bucket_key = $2) r
+         LEFT JOIN LATERAL (
+    SELECT h.rank, h.score
+    FROM leaderboard_rank_history h
+    WHERE h.bucket_key = r.bucket_key AND h.user_id = r.user_id
+    ORDER BY h.taken_at DESC
+    LIMIT 1) last ON true
+WHERE (last.rank, last.score) IS DISTINCT FROM (r.rank, r.score)
+ON CONFLICT (bucket_key, user_id, taken_at) DO UPDATE
+    SET rank  = EXCLUDED.rank,
+        score = EXCLUDED.score
+`
+
+type SnapshotRanksParams struct {
+	TakenAt   time.Time
+	BucketKey string
+}
+
+// One point for every visible player on a board whose rank or score is not
+// what their latest point says. The rank is the score order's, counted the
+// way CountLeaderboardAbove counts it — the ordering is total, so a
+// row_number over it is exactly "players above you, plus one" and a chart
+// never disagrees with /me about the same instant.
+func (q *Queries) SnapshotRanks(ctx context.Context, arg SnapshotRanksParams) (int64, error) {
+	result, err := q.db.Exec(ctx, snapshotRanks, arg.TakenAt, arg.BucketKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,177 @@
+package leaderboard_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/leaderboard"
+)
+
+func TestStandingOf(t *testing.T) {
+	cases := []struct {
+		name           string
+		above, entries int64
+		rank, size     int64
+		percentile     float64
+	}{
+		{"first of many", 0, 20_000, 1, 20_000, 0.01},
+		{"a round share", 6, 100, 7, 100, 7},
+		{"rounded up, never down", 0, 3, 1, 3, 33.34},
+		{"last", 99, 100, 100, 100, 100},
+		// A board that grew since its ladder was taken.
+		{"past the ladder's size", 4, 3, 5, 5, 100},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := leaderboard.StandingOf(c.above, c.entries)
+			assert.Equal(t, c.rank, got.Rank)
+			assert.Equal(t, c.size, got.Entries)
+			assert.InDelta(t, c.percentile, got.Percentile, 1e-9)
+		})
+	}
+}
+
+type percentileBody struct {
+	Bucket     string   `json:"bucket"`
+	Order      string   `json:"order"`
+	Score      *int64   `json:"score"`
+	WPM        *float64 `json:"wpm"`
+	Rank       int64    `json:"rank"`
+	Entries    int64    `json:"entries"`
+	Percentile float64  `json:"percentile"`
+	Above      []struct {
+		Rank  int64 `json:"rank"`
+		Score int64 `json:"score"`
+	} `json:"above"`
+	Below []struct {
+		Rank  int64 `json:"rank"`
+		Score int64 `json:"score"`
+	} `json:"below"`
+}
+
+const percentile15s = "/api/v1/leaderboards/time:15000:en:seeded/percentile"
+
+// ladderedBoard plants n players with distinct scores 1000, 1010, … and
+// speeds 50.0, 50.5, …, then runs a snapshot pass so the board has a ladder.
+func ladderedBoard(t *testing.T, n int) *board {
+	t.Helper()
+	b := newBoard(t)
+	for i := range n {
+		u := b.user(fmt.Sprintf("p%03d", i), true)
+		b.addRun(runSpec{user: u, score: int64(1000 + 10*i), wpm: 50 + float64(i)/2,
+			achievedAt: minutesAgo(1000 - i)})
+	}
+	stats, err := b.store.SnapshotRanks(context.Background())
+	require.NoError(t, err)
+	require.Positive(t, stats.Rungs)
+	return b
+}
+
+// The ladder is a shortcut, not an estimate: on a board nobody has written to
+// since the pass, its answer is the full count's at every depth — between
+// rungs, on a rung, and past the last one.
+func TestPercentileAgreesWithTheCount(t *testing.T) {
+	const n = 250
+	b := ladderedBoard(t, n)
+
+	for _, k := range []int{-1, 0, 49, 99, 100, 150, 199, 200, 248, 249} {
+		score := int64(1000 + 10*k + 5)
+		t.Run(fmt.Sprintf("score %d", score), func(t *testing.T) {
+			resp := b.get(fmt.Sprintf("%s?score=%d", percentile15s, score))
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			body := decodeInto[percentileBody](t, resp)
+			// Everyone from player k+1 up outranks the score.
+			wantRank := int64(n - k)
+			assert.Equal(t, wantRank, body.Rank)
+			// Below the last player the position makes the board one longer.
+			assert.Equal(t, max(int64(n), wantRank), body.Entries)
+			assert.Equal(t, "score", body.Order)
+			require.NotNil(t, body.Score)
+			assert.Equal(t, score, *body.Score)
+		})
+	}
+
+	t.Run("the same in the wpm order", func(t *testing.T) {
+		// 50 + 149/2 = 124.5: players 150 and up are faster than 124.6.
+		body := decodeInto[percentileBody](t, b.get(percentile15s+"?wpm=124.6"))
+		assert.Equal(t, "wpm", body.Order)
+		assert.EqualValues(t, n-149, body.Rank)
+	})
+
+	t.Run("a tie counts as ahead", func(t *testing.T) {
+		body := decodeInto[percentileBody](t, b.get(percentile15s+"?score=1500"))
+		// 1500 is player 50's score; they got there first.
+		assert.EqualValues(t, n-49, body.Rank)
+	})
+}
+
+// Between passes the stretch below a rung is counted live, so a run landing
+// just above a looked-up score moves it at once.
+func TestPercentileCountsBelowTheRungLive(t *testing.T) {
+	b := ladderedBoard(t, 150)
+	path := percentile15s + "?score=1205" // between players 20 and 21, under the rung at row 100
+	before := decodeInto[percentileBody](t, b.get(path))
+
+	b.addRun(runSpec{user: b.user("newcomer", true), score: 1206, achievedAt: minutesAgo(1)})
+	after := decodeInto[percentileBody](t, b.get(path))
+	assert.Equal(t, before.Rank+1, after.Rank)
+}
+
+func TestPercentileNeighbours(t *testing.T) {
+	b := ladderedBoard(t, 20)
+	body := decodeInto[percentileBody](t, b.get(percentile15s+"?score=1095&limit=2"))
+
+	// 1095 sits between 1100 (player 10) and 1090 (player 9): the ten players
+	// from 1100 up outrank it, so rank 11 of 20.
+	assert.EqualValues(t, 11, body.Rank)
+	assert.EqualValues(t, 20, body.Entries)
+	assert.InDelta(t, 55.0, body.Percentile, 1e-9)
+	require.Len(t, body.Above, 2)
+	assert.EqualValues(t, 1110, body.Above[0].Score)
+	assert.EqualValues(t, 9, body.Above[0].Rank)
+	assert.EqualValues(t, 1100, body.Above[1].Score, "nearest last")
+	assert.EqualValues(t, 10, body.Above[1].Rank)
+	require.Len(t, body.Below, 2)
+	assert.EqualValues(t, 1090, body.Below[0].Score, "nearest first")
+	assert.EqualValues(t, 11, body.Below[0].Rank)
+
+	t.Run("above the top", func(t *testing.T) {
+		body := decodeInto[percentileBody](t, b.get(percentile15s+"?score=9999"))
+		assert.EqualValues(t, 1, body.Rank)
+		assert.Empty(t, body.Above)
+	})
+}
+
+func TestPercentileRejects(t *testing.T) {
+	b := newBoard(t)
+	for name, c := range map[string]struct {
+		path   string
+		status int
+	}{
+		"no position":         {percentile15s, http.StatusBadRequest},
+		"both positions":      {percentile15s + "?score=10&wpm=10", http.StatusBadRequest},
+		"a negative score":    {percentile15s + "?score=-1", http.StatusBadRequest},
+		"an impossible score": {percentile15s + "?score=16777216", http.StatusBadRequest},
+		"a junk wpm":          {percentile15s + "?wpm=fast", http.StatusBadRequest},
+		"a windowed board":    {"/api/v1/leaderboards/time:15000:en:seeded@2026-W10/percentile?score=1", http.StatusNotFound},
+		"a daily board":       {"/api/v1/leaderboards/daily:2026-03-04/percentile?score=1", http.StatusNotFound},
+		"an unknown board":    {"/api/v1/leaderboards/nonsense/percentile?score=1", http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.status, b.get(c.path).StatusCode)
+		})
+	}
+
+	t.Run("an empty board is rank 1 of 1", func(t *testing.T) {
+		body := decodeInto[percentileBody](t, b.get(percentile15s+"?score=1"))
+		assert.EqualValues(t, 1, body.Rank)
+		assert.EqualValues(t, 1, body.Entries)
+		assert.NotNil(t, body.Above)
+		assert.NotNil(t, body.Below)
+	})
+}
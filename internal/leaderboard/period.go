@@ -0,0 +1,208 @@
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PeriodKind is one kind of time window a board can be read over. An all-time
+// board has no window; every other reading of it is one of these.
+type PeriodKind string
+
+const (
+	// PeriodWeek is the ISO week in UTC: Monday 00:00 to the next Monday.
+	PeriodWeek PeriodKind = "week"
+	// PeriodMonth is the calendar month in UTC.
+	PeriodMonth PeriodKind = "month"
+	// PeriodSeason is a named span an operator declares (leaderboard_seasons).
+	// Seasons never overlap, so at most one is running at any instant.
+	PeriodSeason PeriodKind = "season"
+)
+
+// PeriodKinds is every kind, in the order they are documented.
+var PeriodKinds = []PeriodKind{PeriodWeek, PeriodMonth, PeriodSeason}
+
+// ErrUnknownPeriod is returned for a window kind or period id that names
+// nothing.
+var ErrUnknownPeriod = errors.New("leaderboard: unknown period")
+
+// ParsePeriodKind reads the `window` query parameter.
+func ParsePeriodKind(s string) (PeriodKind, error) {
+	for _, k := range PeriodKinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("%w: window %q", ErrUnknownPeriod, s)
+}
+
+// Period is one concrete window: [Start, End) in UTC, and the id it is keyed
+// by. The three kinds spell their ids so that no two can collide and the kind
+// can be read back off the id alone:
+//
+//	2026-W42     a week   — ISO year, "W", two-digit ISO week
+//	2026-10      a month  — year, two-digit month
+//	s3-autumn    a season — a slug that starts with a letter
+//
+// A calendar id starts with a digit and a season id cannot, and the "W" keeps
+// a week from ever reading as a month.
+type Period struct {
+	ID    string
+	Kind  PeriodKind
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls inside the window.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// WeekOf is the ISO week t falls in, in UTC.
+func WeekOf(t time.Time) Period {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	// Go's Weekday counts from Sunday; ISO weeks start on Monday.
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	year, week := t.ISOWeek()
+	return Period{
+		ID:    fmt.Sprintf("%04d-W%02d", year, week),
+		Kind:  PeriodWeek,
+		Start: start,
+		End:   start.AddDate(0, 0, 7),
+	}
+}
+
+// MonthOf is the calendar month t falls in, in UTC.
+func MonthOf(t time.Time) Period {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Period{
+		ID:    start.Format(monthLayout),
+		Kind:  PeriodMonth,
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+	}
+}
+
+const monthLayout = "2006-01"
+
+// seasonID is the shape of a season's slug; the schema's CHECK on
+// leaderboard_seasons.id is the same pattern.
+var seasonID = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+
+// ValidSeasonID reports whether id could name a season.
+func ValidSeasonID(id string) bool { return seasonID.MatchString(id) }
+
+// ParsePeriodID resolves a period id. A week or a month is a function of its
+// id and comes back complete; a season's span lives in the database, so a
+// season id comes back with its Kind and ID and a zero span — the caller looks
+// the rest up.
+//
+// As with bucket keys, only the canonical spelling parses: "2026-W5" and
+// "2025-W53" (2025 has 52 weeks) are refused rather than normalised, because an
+// id is a key and a second spelling would be a second board.
+func ParsePeriodID(id string) (Period, error) {
+	if ValidSeasonID(id) {
+		return Period{ID: id, Kind: PeriodSeason}, nil
+	}
+	if year, week, ok := strings.Cut(id, "-W"); ok {
+		y, yerr := strconv.Atoi(year)
+		w, werr := strconv.Atoi(week)
+		if yerr == nil && werr == nil && len(year) == 4 && w >= 1 && w <= 53 {
+			// The Thursday of an ISO week is always in that week's ISO year,
+			// and January 4th is always in week 1.
+			jan4 := time.Date(y, time.January, 4, 0, 0, 0, 0, time.UTC)
+			p := WeekOf(jan4.AddDate(0, 0, 7*(w-1)))
+			if p.ID == id {
+				return p, nil
+			}
+		}
+		return Period{}, fmt.Errorf("%w: %q is not an ISO week", ErrUnknownPeriod, id)
+	}
+	if start, err := time.Parse(monthLayout, id); err == nil {
+		if p := MonthOf(start); p.ID == id {
+			return p, nil
+		}
+	}
+	return Period{}, fmt.Errorf("%w: %q is not a week, a month or a season id", ErrUnknownPeriod, id)
+}
+
+// Season is a named window an operator declared.
+type Season struct {
+	ID       string
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// Period is the season as a window.
+func (s Season) Period() Period {
+	return Period{ID: s.ID, Kind: PeriodSeason, Start: s.StartsAt.UTC(), End: s.EndsAt.UTC()}
+}
+
+// Validate checks a season before it is stored: a slug, a name, and a span that
+// runs forwards. Overlap with another season is the table's to refuse.
+func (s Season) Validate() error {
+	switch {
+	case !ValidSeasonID(s.ID):
+		return fmt.Errorf("%w: season id %q must match %s", ErrUnknownPeriod, s.ID, seasonID)
+	case strings.TrimSpace(s.Name) == "":
+		return fmt.Errorf("%w: season %q needs a name", ErrUnknownPeriod, s.ID)
+	case !s.StartsAt.Before(s.EndsAt):
+		return fmt.Errorf("%w: season %q ends before it starts", ErrUnknownPeriod, s.ID)
+	}
+	return nil
+}
+
+// Windows is which windowed boards are maintained, and how long after a
+// window ends it stays open to late verdicts.
+//
+// Settle exists because a verdict is not instant: a run played at 23:59 on
+// Sunday may be judged minutes into Monday, and it belongs to the week it was
+// PLAYED in. Until End+Settle the projection still writes the window; from
+// End+Settle the freezer may archive it, and nothing writes it again. The two
+// sides read the same number, so no verdict lands in a window after it froze.
+type Windows struct {
+	Kinds  []PeriodKind
+	Settle time.Duration
+}
+
+// Has reports whether the kind is maintained.
+func (w Windows) Has(k PeriodKind) bool {
+	for _, have := range w.Kinds {
+		if have == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reports whether p still accepts projections at now.
+func (w Windows) Open(p Period, now time.Time) bool {
+	return now.Before(p.End.Add(w.Settle))
+}
+
+// ParseWindows reads the configured window kinds. "none" on its own turns every
+// window off; an empty list cannot, because the environment treats an empty
+// value as unset.
+func ParseWindows(kinds []string, settle time.Duration) (Windows, error) {
+	w := Windows{Settle: settle}
+	if len(kinds) == 1 && kinds[0] == "none" {
+		return w, nil
+	}
+	for _, s := range kinds {
+		k, err := ParsePeriodKind(strings.TrimSpace(s))
+		if err != nil {
+			return Windows{}, err
+		}
+		if !w.Has(k) {
+			w.Kinds = append(w.Kinds, k)
+		}
+	}
+	return w, nil
+}
@@ -0,0 +1,161 @@
+package leaderboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/leaderboard"
+)
+
+// A period id is a key component, so the rules are the bucket key's: one
+// spelling per window, computed the same way from any instant inside it, and
+// every id that is produced parses back to the same span.
+func TestCalendarPeriods(t *testing.T) {
+	cases := []struct {
+		name       string
+		at         time.Time
+		week       string
+		weekStart  time.Time
+		month      string
+		monthStart time.Time
+	}{
+		{
+			name: "a Saturday afternoon",
+			at:   time.Date(2026, 10, 17, 15, 4, 5, 0, time.UTC),
+			week: "2026-W42", weekStart: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			month: "2026-10", monthStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "the first instant of a Monday",
+			at:   time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			week: "2026-W42", weekStart: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			month: "2026-10", monthStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "the last instant of a Sunday",
+			at:   time.Date(2026, 10, 11, 23, 59, 59, 999_999_999, time.UTC),
+			week: "2026-W41", weekStart: time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC),
+			month: "2026-10", monthStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// The ISO year is not the calendar year at either end of it.
+			name: "New Year's Day in the previous ISO year",
+			at:   time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC),
+			week: "2020-W53", weekStart: time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC),
+			month: "2021-01", monthStart: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "the last days of December in the next ISO year",
+			at:   time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC),
+			week: "2025-W01", weekStart: time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC),
+			month: "2024-12", monthStart: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// Windows are UTC; a local evening is already tomorrow.
+			name: "an instant carried in another zone",
+			at:   time.Date(2026, 10, 31, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*3600)),
+			week: "2026-W44", weekStart: time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC),
+			month: "2026-11", monthStart: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			week := leaderboard.WeekOf(tc.at)
+			assert.Equal(t, tc.week, week.ID)
+			assert.Equal(t, leaderboard.PeriodWeek, week.Kind)
+			assert.True(t, week.Start.Equal(tc.weekStart), "week starts %s", week.Start)
+			assert.True(t, week.End.Equal(tc.weekStart.AddDate(0, 0, 7)))
+			assert.True(t, week.Contains(tc.at))
+
+			month := leaderboard.MonthOf(tc.at)
+			assert.Equal(t, tc.month, month.ID)
+			assert.True(t, month.Start.Equal(tc.monthStart), "month starts %s", month.Start)
+			assert.True(t, month.End.Equal(tc.monthStart.AddDate(0, 1, 0)))
+			assert.True(t, month.Contains(tc.at))
+
+			for _, p := range []leaderboard.Period{week, month} {
+				parsed, err := leaderboard.ParsePeriodID(p.ID)
+				require.NoError(t, err)
+				assert.Equal(t, p, parsed)
+			}
+		})
+	}
+}
+
+func TestParsePeriodID(t *testing.T) {
+	t.Run("a season is a slug, and its span is the database's", func(t *testing.T) {
+		p, err := leaderboard.ParsePeriodID("s3-autumn")
+		require.NoError(t, err)
+		assert.Equal(t, leaderboard.Period{ID: "s3-autumn", Kind: leaderboard.PeriodSeason}, p)
+	})
+
+	for name, id := range map[string]string{
+		"empty":                     "",
+		"a week without padding":    "2026-W5",
+		"week zero":                 "2026-W00",
+		"a week 2025 does not have": "2025-W53",
+		"a lower-case week":         "2026-w42",
+		"a month without padding":   "2026-1",
+		"month thirteen":            "2026-13",
+		"a day":                     "2026-10-17",
+		"a two-digit year":          "26-10",
+		"a slug with capitals":      "Autumn",
+		"a slug with a digit lead":  "3rd-season",
+		"a slug with an at":         "s3@autumn",
+		"an oversized slug":         "s" + "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := leaderboard.ParsePeriodID(id)
+			require.ErrorIs(t, err, leaderboard.ErrUnknownPeriod)
+		})
+	}
+}
+
+// The settle period is what keeps a late verdict and the freezer from racing:
+// the projection writes a window strictly before End+Settle, and the freezer
+// may take it from End+Settle on. The same instant cannot be on both sides.
+func TestWindowsSettle(t *testing.T) {
+	w := leaderboard.Windows{Kinds: []leaderboard.PeriodKind{leaderboard.PeriodWeek}, Settle: time.Hour}
+	week := leaderboard.WeekOf(time.Date(2026, 10, 11, 23, 59, 0, 0, time.UTC))
+
+	assert.True(t, w.Open(week, week.End.Add(-time.Minute)))
+	assert.True(t, w.Open(week, week.End.Add(59*time.Minute)), "a run judged into Monday still lands")
+	assert.False(t, w.Open(week, week.End.Add(time.Hour)))
+	assert.True(t, w.Has(leaderboard.PeriodWeek))
+	assert.False(t, w.Has(leaderboard.PeriodMonth))
+}
+
+func TestParseWindows(t *testing.T) {
+	w, err := leaderboard.ParseWindows([]string{"week", " season", "week"}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []leaderboard.PeriodKind{leaderboard.PeriodWeek, leaderboard.PeriodSeason}, w.Kinds)
+	assert.Equal(t, time.Hour, w.Settle)
+
+	none, err := leaderboard.ParseWindows([]string{"none"}, time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, none.Kinds)
+
+	_, err = leaderboard.ParseWindows([]string{"week", "fortnight"}, time.Hour)
+	require.ErrorIs(t, err, leaderboard.ErrUnknownPeriod)
+}
+
+func TestSeasonValidate(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, leaderboard.Season{ID: "s3-autumn", Name: "Autumn", StartsAt: from, EndsAt: to}.Validate())
+	for name, s := range map[string]leaderboard.Season{
+		"a calendar id": {ID: "2026-10", Name: "October", StartsAt: from, EndsAt: to},
+		"no name":       {ID: "s3-autumn", Name: "  ", StartsAt: from, EndsAt: to},
+		"backwards":     {ID: "s3-autumn", Name: "Autumn", StartsAt: to, EndsAt: from},
+		"empty":         {ID: "s3-autumn", Name: "Autumn", StartsAt: from, EndsAt: from},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.ErrorIs(t, s.Validate(), leaderboard.ErrUnknownPeriod)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package pgstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/typemore/typemore-server/internal/leaderboard"
+	"github.com/typemore/typemore-server/internal/leaderboard/leaderboarddb"
+)
+
+// followingStore is a Store narrowed to one viewer's circle. It embeds the
+// Store it came from, so the reads a circle does not change — the index,
+// seasons, rank history — are the receiver's own, and overrides the ones that
+// rank.
+//
+// Every override is the global read's query with the circle
+// (following_circle, 00042) as one more predicate. The circle's rows come
+// back as the generated types of the global queries' twins, which is what
+// lets them share the converters below.
+type followingStore struct {
+	*Store
+	viewer uuid.UUID
+}
+
+// Following implements leaderboard.Store.
+func (s *Store) Following(viewer uuid.UUID) leaderboard.Store {
+	return &followingStore{Store: s, viewer: viewer}
+}
+
+// Page implements leaderboard.Store over the circle.
+func (f *followingStore) Page(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, after *leaderboard.Cursor, limit int32) ([]leaderboard.Entry, error) {
+	var out []leaderboard.Entry
+	switch {
+	case o == leaderboard.OrderWPM && after == nil:
+		rows, err := f.q.ListFollowingWPMPageFirst(ctx, leaderboarddb.ListFollowingWPMPageFirstParams{
+			BucketKey: b.Key(), Viewer: f.viewer, RowLimit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = make([]leaderboard.Entry, len(rows))
+		for i := range rows {
+			out[i] = wpmFirstRowToEntry(leaderboarddb.ListLeaderboardWPMPageFirstRow(rows[i]))
+		}
+	case o == leaderboard.OrderWPM:
+		rows, err := f.q.ListFollowingWPMPageAfter(ctx, leaderboarddb.ListFollowingWPMPageAfterParams{
+			BucketKey: b.Key(), Viewer: f.viewer,
+			Wpm: after.WPM, AchievedAt: after.AchievedAt, UserID: after.UserID,
+			RowLimit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = make([]leaderboard.Entry, len(rows))
+		for i := range rows {
+			out[i] = wpmAfterRowToEntry(leaderboarddb.ListLeaderboardWPMPageAfterRow(rows[i]))
+		}
+	case after == nil:
+		rows, err := f.q.ListFollowingPageFirst(ctx, leaderboarddb.ListFollowingPageFirstParams{
+			BucketKey: b.Key(), Viewer: f.viewer, RowLimit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = make([]leaderboard.Entry, len(rows))
+		for i := range rows {
+			out[i] = firstRowToEntry(leaderboarddb.ListLeaderboardPageFirstRow(rows[i]))
+		}
+	default:
+		rows, err := f.q.ListFollowingPageAfter(ctx, leaderboarddb.ListFollowingPageAfterParams{
+			BucketKey: b.Key(), Viewer: f.viewer,
+			Score: after.Score, AchievedAt: after.AchievedAt, UserID: after.UserID,
+			RowLimit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = make([]leaderboard.Entry, len(rows))
+		for i := range rows {
+			out[i] = afterRowToEntry(leaderboarddb.ListLeaderboardPageAfterRow(rows[i]))
+		}
+	}
+	return out, nil
+}
+
+// PageBefore implements leaderboard.Store over the circle, re-reversed like
+// the global read.
+func (f *followingStore) PageBefore(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, before leaderboard.Cursor, limit int32) ([]leaderboard.Entry, error) {
+	if o == leaderboard.OrderWPM {
+		rows, err := f.q.ListFollowingWPMPageBefore(ctx, leaderboarddb.ListFollowingWPMPageBeforeParams{
+			BucketKey: b.Key(), Viewer: f.viewer,
+			Wpm: before.WPM, AchievedAt: before.AchievedAt, UserID: before.UserID,
+			RowLimit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out := make([]leaderboard.Entry, len(rows))
+		for i := range rows {
+			out[len(rows)-1-i] = wpmBeforeRowToEntry(leaderboarddb.ListLeaderboardWPMPageBeforeRow(rows[i]))
+		}
+		return out, nil
+	}
+	rows, err := f.q.ListFollowingPageBefore(ctx, leaderboarddb.ListFollowingPageBeforeParams{
+		BucketKey: b.Key(), Viewer: f.viewer,
+		Score: before.Score, AchievedAt: before.AchievedAt, UserID: before.UserID,
+		RowLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]leaderboard.Entry, len(rows))
+	for i := range rows {
+		out[len(rows)-1-i] = beforeRowToEntry(leaderboarddb.ListLeaderboardPageBeforeRow(rows[i]))
+	}
+	return out, nil
+}
+
+// RankAbove implements leaderboard.Store over the circle.
+func (f *followingStore) RankAbove(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, at leaderboard.Cursor) (int64, error) {
+	if o == leaderboard.OrderWPM {
+		return f.q.CountFollowingWPMAbove(ctx, leaderboarddb.CountFollowingWPMAboveParams{
+			BucketKey: b.Key(), Viewer: f.viewer,
+			Wpm: at.WPM, AchievedAt: at.AchievedAt, UserID: at.UserID,
+		})
+	}
+	return f.q.CountFollowingAbove(ctx, leaderboarddb.CountFollowingAboveParams{
+		BucketKey: b.Key(), Viewer: f.viewer,
+		Score: at.Score, AchievedAt: at.AchievedAt, UserID: at.UserID,
+	})
+}
+
+// Standing implements leaderboard.Store over the circle, counted live: the
+// ladder rungs are global ranks and say nothing about a circle's.
+func (f *followingStore) Standing(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, at leaderboard.Cursor) (leaderboard.Standing, error) {
+	above, err := f.RankAbove(ctx, b, o, at)
+	if err != nil {
+		return leaderboard.Standing{}, err
+	}
+	entries, err := f.q.CountFollowingEntries(ctx, leaderboarddb.CountFollowingEntriesParams{
+		BucketKey: b.Key(), Viewer: f.viewer,
+	})
+	if err != nil {
+		return leaderboard.Standing{}, err
+	}
+	return leaderboard.StandingOf(above, entries), nil
+}
+
+// EntryFor implements leaderboard.Store over the circle: a player outside it
+// has no entry here, whatever they hold on the board.
+func (f *followingStore) EntryFor(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, userID uuid.UUID) (leaderboard.Entry, error) {
+	row, err := f.q.GetFollowingEntry(ctx, leaderboarddb.GetFollowingEntryParams{
+		BucketKey: b.Key(), UserID: userID, Viewer: f.viewer,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return leaderboard.Entry{}, leaderboard.ErrNoEntry
+	}
+	if err != nil {
+		return leaderboard.Entry{}, err
+	}
+	entry := getRowToEntry(leaderboarddb.GetLeaderboardEntryRow(row))
+	above, err := f.RankAbove(ctx, b, o, leaderboard.Cursor{
+		Score: entry.Score, WPM: entry.WPM, AchievedAt: entry.AchievedAt, UserID: entry.UserID,
+	})
+	if err != nil {
+		return leaderboard.Entry{}, err
+	}
+	entry.Rank = above + 1
+	return entry, nil
+}
+
+// SnapshotPage implements leaderboard.Store over the circle: the circle's
+// share of the frozen standings, renumbered from 1 in the order they froze.
+func (f *followingStore) SnapshotPage(ctx context.Context, b leaderboard.Bucket, afterRank int64, limit int32) ([]leaderboard.Entry, error) {
+	rows, err := f.q.ListFollowingSnapshotPage(ctx, leaderboarddb.ListFollowingSnapshotPageParams{
+		BucketKey: b.Key(), Viewer: f.viewer, AfterRank: afterRank, RowLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]leaderboard.Entry, len(rows))
+	for i := range rows {
+		r := rows[i]
+		out[i] = leaderboard.Entry{
+			Rank: r.Rank, UserID: r.UserID, DisplayName: r.DisplayName, RunID: r.RunID,
+			Score: r.Score, WPM: r.Wpm, Raw: r.Raw, Acc: r.Acc, Grade: r.Grade,
+			Mods: r.Mods, AchievedAt: r.AchievedAt, Source: text(r.QuoteSource),
+		}
+	}
+	return out, nil
+}
@@ -9,6 +9,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -26,16 +29,39 @@ type Store struct {
 	// verified email identity. It is deployment policy, not schema, so it
 	// travels with every projection query rather than living in the view.
 	requireVerifiedEmail bool
+	// windows is which windowed boards the projection maintains beside the
+	// all-time ones. None, unless WithWindows says otherwise.
+	windows leaderboard.Windows
+	now     func() time.Time
 }
 
-// Compile-time check that Store satisfies the consumer interface.
-var _ leaderboard.Store = (*Store)(nil)
+// Compile-time checks that Store satisfies the consumer interfaces.
+var (
+	_ leaderboard.Store           = (*Store)(nil)
+	_ leaderboard.Freezer         = (*Store)(nil)
+	_ leaderboard.RankSnapshotter = (*Store)(nil)
+)
 
 // New builds a Store from a pgx pool. requireVerifiedEmail is the eligibility
 // gate described in docs/LEADERBOARDS.md; changing it takes effect on existing
 // runs only after `make rebuild-leaderboards`.
 func New(pool *pgxpool.Pool, requireVerifiedEmail bool) *Store {
-	return &Store{pool: pool, q: leaderboarddb.New(pool), requireVerifiedEmail: requireVerifiedEmail}
+	return &Store{pool: pool, q: leaderboarddb.New(pool), requireVerifiedEmail: requireVerifiedEmail, now: time.Now}
+}
+
+// WithWindows turns on the windowed boards (docs/LEADERBOARDS.md, "Windows").
+// Off, the projection writes the all-time boards only and the freezer finds
+// nothing to do.
+func (s *Store) WithWindows(w leaderboard.Windows) *Store {
+	s.windows = w
+	return s
+}
+
+// WithClock replaces the clock that decides which windows are still open. For
+// tests.
+func (s *Store) WithClock(now func() time.Time) *Store {
+	s.now = now
+	return s
 }
 
 // --- write side: projection ---
@@ -69,6 +95,17 @@ func (s *Store) ProjectRun(ctx context.Context, tx pgx.Tx, runID uuid.UUID) erro
 		mode: row.Mode, durationMs: row.DurationMs, wordCount: row.WordCount,
 		lang: row.Lang, textSourceKind: row.TextSourceKind,
 	}
+	// A daily attempt holds a second slot, on its day's board, and it moves
+	// with the verdict exactly as the first does. Done first so the early
+	// return below — a run whose shape names no ordinary board — can never
+	// skip it: whether the day's board has a cell for this run is
+	// daily_eligible_runs' question, not the ordinary bucket's.
+	if row.DailyDay != nil {
+		if err := s.projectDaily(ctx, q, row.UserID, *row.DailyDay); err != nil {
+			return err
+		}
+	}
+
 	// A run whose shape cannot name a bucket (an unranked mode, a quote id that
 	// resolves to nothing) can never have held a slot, so there is nothing to
 	// recompute for it.
@@ -77,7 +114,121 @@ func (s *Store) ProjectRun(ctx context.Context, tx pgx.Tx, runID uuid.UUID) erro
 		return nil //nolint:nilerr // not an error: unrankable runs simply have no cell
 	}
 
-	return s.recompute(ctx, q, bucket, c)
+	if err := s.recompute(ctx, q, bucket, c); err != nil {
+		return err
+	}
+	// Ranks are counted later, by the snapshotter (RunRankSnapshots); all the
+	// verdict owes rank history is word that this board may have moved.
+	if err := q.MarkRankDirty(ctx, bucket.Key()); err != nil {
+		return fmt.Errorf("leaderboard/pgstore: mark %s for rank history: %w", bucket.Key(), err)
+	}
+	return s.projectWindows(ctx, q, bucket, c, row.CreatedAt)
+}
+
+// projectWindows recomputes the run's cell on every windowed reading of its
+// board that the run was played inside and that is still open. A window that
+// has closed is left alone even when the verdict is for a run inside it: past
+// the settle period its standings belong to the freezer, and a late promotion
+// or demotion moves the all-time board only.
+func (s *Store) projectWindows(ctx context.Context, q *leaderboarddb.Queries, bucket leaderboard.Bucket, c cell, playedAt time.Time) error {
+	periods, err := s.windowsAt(ctx, q, playedAt)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	for _, p := range periods {
+		if !s.windows.Open(p, now) {
+			continue
+		}
+		if err := s.recomputePeriod(ctx, q, bucket, c, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// windowsAt lists the maintained windows an instant falls in: its week, its
+// month, and the season running then, if any.
+func (s *Store) windowsAt(ctx context.Context, q *leaderboarddb.Queries, at time.Time) ([]leaderboard.Period, error) {
+	var out []leaderboard.Period
+	if s.windows.Has(leaderboard.PeriodWeek) {
+		out = append(out, leaderboard.WeekOf(at))
+	}
+	if s.windows.Has(leaderboard.PeriodMonth) {
+		out = append(out, leaderboard.MonthOf(at))
+	}
+	if s.windows.Has(leaderboard.PeriodSeason) {
+		season, err := q.SeasonAt(ctx, at)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return nil, fmt.Errorf("leaderboard/pgstore: season at %s: %w", at, err)
+		default:
+			out = append(out, seasonOf(season).Period())
+		}
+	}
+	return out, nil
+}
+
+// recomputePeriod is recompute for a windowed board: the same coordinates,
+// bounded to the window, through the one statement that writes windowed rows.
+func (s *Store) recomputePeriod(
+	ctx context.Context, q *leaderboarddb.Queries,
+	bucket leaderboard.Bucket, c cell, p leaderboard.Period,
+) error {
+	windowed, err := bucket.Over(p.ID)
+	if err != nil {
+		return fmt.Errorf("leaderboard/pgstore: window %s over %s: %w", p.ID, bucket.Key(), err)
+	}
+	err = q.RecomputePeriodCell(ctx, leaderboarddb.RecomputePeriodCellParams{
+		UserID:               c.userID,
+		QuoteID:              c.quoteID,
+		Mode:                 c.mode,
+		DurationMs:           c.durationMs,
+		WordCount:            c.wordCount,
+		Lang:                 c.lang,
+		TextSourceKind:       c.textSourceKind,
+		StartsAt:             p.Start,
+		EndsAt:               p.End,
+		RequireVerifiedEmail: s.requireVerifiedEmail,
+		BucketKey:            windowed.Key(),
+		Period:               p.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("leaderboard/pgstore: recompute %s for %s: %w", windowed.Key(), c.userID, err)
+	}
+	return nil
+}
+
+// projectDaily recomputes a run's cell on the board of the day it declared. A
+// day that does not parse as a date was never an attempt at anything (the view
+// agrees: it joins on the challenge's own spelling of its date), so it has no
+// cell.
+func (s *Store) projectDaily(ctx context.Context, q *leaderboarddb.Queries, userID uuid.UUID, declared string) error {
+	day, err := time.Parse(leaderboard.DayLayout, declared)
+	if err != nil {
+		return nil //nolint:nilerr // not an error: a malformed day names no board
+	}
+	bucket, err := leaderboard.NewDailyBucket(day)
+	if err != nil {
+		return nil //nolint:nilerr // likewise
+	}
+	return s.recomputeDaily(ctx, q, bucket, userID)
+}
+
+// recomputeDaily is recompute's daily twin: the one statement that writes a
+// daily board, shared by the projection and the rebuild for the same reason.
+func (s *Store) recomputeDaily(ctx context.Context, q *leaderboarddb.Queries, bucket leaderboard.Bucket, userID uuid.UUID) error {
+	err := q.RecomputeDailyCell(ctx, leaderboarddb.RecomputeDailyCellParams{
+		UserID:               userID,
+		Day:                  bucket.Day,
+		RequireVerifiedEmail: s.requireVerifiedEmail,
+		BucketKey:            bucket.Key(),
+	})
+	if err != nil {
+		return fmt.Errorf("leaderboard/pgstore: recompute %s for %s: %w", bucket.Key(), userID, err)
+	}
+	return nil
 }
 
 // cell is one (player, board) coordinate tuple, in the spelling both the
@@ -136,25 +287,70 @@ func (s *Store) recompute(
 
 // RebuildStats is what one rebuild did.
 type RebuildStats struct {
-	// Before / After are the entry counts on either side of the rebuild. They
-	// differ only when the incremental projection had drifted — which is the
-	// whole reason to be able to run this.
+	// Before / After are the entry counts, over the rebuild's scope, on either
+	// side of it. They differ only when the incremental projection had drifted
+	// — which is the whole reason to be able to run this. A dry run reports the
+	// After it would have left.
 	Before int64
 	After  int64
-	// Cells is how many (player, bucket) cells had an eligible run.
+	// Cells is how many (player, board) cells in scope had an eligible run.
 	Cells int
+	// Added, Removed and Changed tally Diff: slots the rebuild fills, empties,
+	// and rewrites.
+	Added, Removed, Changed int
+	// Diff is every slot the rebuilt boards disagree with the live ones about,
+	// in key order. Empty is the healthy answer: the projection had nothing
+	// wrong with it.
+	Diff []RebuildDiff
+}
+
+// RebuildOptions scopes a rebuild.
+type RebuildOptions struct {
+	// Bucket limits the rebuild to one board, matched by key: an all-time
+	// board, one day's board, or one window of a board — each is a board of
+	// its own, and rebuilding one leaves the others alone. Nil rebuilds all of
+	// them.
+	Bucket *leaderboard.Bucket
+	// DryRun builds and diffs, reports, and throws the result away. The live
+	// boards are not written.
+	DryRun bool
+}
+
+// Rebuild recomputes every board from accepted runs and swaps in the result.
+func (s *Store) Rebuild(ctx context.Context) (RebuildStats, error) {
+	return s.RebuildWith(ctx, RebuildOptions{})
 }
 
-// Rebuild recomputes the entire table from accepted runs, in ONE transaction:
-// truncate, then replay every cell through the same statement the worker uses.
+// RebuildWith recomputes the boards in scope from accepted runs, in ONE
+// transaction, without taking them down:
+//
+//  1. build — replay every cell into a shadow copy of the table through the
+//     same statements the worker uses. Nothing is locked: readers are served
+//     the live boards and the projection keeps writing them.
+//  2. catch up — diff the shadow against the live table and recompute, in the
+//     shadow, every slot the diff names. A slot the projection wrote while the
+//     build ran is now equal on both sides; what is left is drift.
+//  3. settle — lock out writers (not readers), diff again, and catch up once
+//     more on any slot a write moved between the first diff and the lock.
+//     This is short by construction: it covers only that gap.
+//  4. swap — delete and insert exactly the final diff into the live table, and
+//     commit. A dry run reports the diff and rolls back instead.
 //
 // Deliberately NOT a bulk INSERT..SELECT. Formatting a bucket key has exactly
 // one implementation and it is in Go, so the rebuild walks cells rather than
 // teaching SQL the format a second time — and reusing the maintenance statement
 // means a rebuild cannot disagree with incremental maintenance about who owns a
-// slot. TRUNCATE is transactional, so a failure leaves the old board intact.
-func (s *Store) Rebuild(ctx context.Context) (RebuildStats, error) {
-	var stats RebuildStats
+// slot. A failure anywhere rolls back with the shadow, and the live boards
+// never saw it.
+func (s *Store) RebuildWith(ctx context.Context, opts RebuildOptions) (RebuildStats, error) {
+	var (
+		stats RebuildStats
+		scope *string
+	)
+	if opts.Bucket != nil {
+		key := opts.Bucket.Key()
+		scope = &key
+	}
 
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
@@ -174,56 +370,553 @@ func (s *Store) Rebuild(ctx context.Context) (RebuildStats, error) {
 		return stats, fmt.Errorf("leaderboard/pgstore: set work_mem: %w", err)
 	}
 
-	if stats.Before, err = q.CountLeaderboardEntries(ctx); err != nil {
+	// Read off the live table, before anything points elsewhere: a window that
+	// has closed but not yet been frozen is no longer one the projection
+	// writes, so the only record that its boards should exist is that they do.
+	live, err := q.ListLivePeriods(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: list live windows: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, createShadow); err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: create shadow: %w", err)
+	}
+	var path string
+	if err := tx.QueryRow(ctx, getSearchPath).Scan(&path); err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: read search_path: %w", err)
+	}
+
+	// Every statement the walk runs names plain `leaderboard_entries`; with
+	// the rebuild schema first on the path, that is the shadow. Postgres
+	// replans a prepared statement when the path it was planned under changes,
+	// so the connection's cached recompute statements follow the switch
+	// rather than keep writing the live table.
+	if err := s.intoShadow(ctx, tx, path); err != nil {
+		return stats, err
+	}
+	if stats.Cells, err = s.walk(ctx, q, opts.Bucket, live); err != nil {
+		return stats, err
+	}
+	if err := s.outOfShadow(ctx, tx, path); err != nil {
+		return stats, err
+	}
+
+	first, err := diffWithShadow(ctx, tx, scope)
+	if err != nil {
+		return stats, err
+	}
+	if err := s.catchUp(ctx, tx, q, path, first); err != nil {
+		return stats, err
+	}
+
+	if _, err := tx.Exec(ctx, lockLive); err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: lock live board: %w", err)
+	}
+	diff, err := diffWithShadow(ctx, tx, scope)
+	if err != nil {
+		return stats, err
+	}
+	if moved := movedSince(first, diff); len(moved) > 0 {
+		if err := s.catchUp(ctx, tx, q, path, moved); err != nil {
+			return stats, err
+		}
+		if diff, err = diffWithShadow(ctx, tx, scope); err != nil {
+			return stats, err
+		}
+	}
+
+	// Counted under the lock, so Before is the board the diff was taken
+	// against and After is exact rather than an estimate.
+	if stats.Before, err = q.CountLeaderboardEntries(ctx, scope); err != nil {
 		return stats, fmt.Errorf("leaderboard/pgstore: count before: %w", err)
 	}
-	cells, err := q.EnumerateLeaderboardCells(ctx, s.requireVerifiedEmail)
+	stats.Diff = diff
+	for _, d := range diff {
+		switch {
+		case d.Live == nil:
+			stats.Added++
+		case d.Rebuilt == nil:
+			stats.Removed++
+		default:
+			stats.Changed++
+		}
+	}
+	stats.After = stats.Before + int64(stats.Added) - int64(stats.Removed)
+	if opts.DryRun || len(diff) == 0 {
+		return stats, nil
+	}
+
+	deleted, err := tx.Exec(ctx, applyDelete, scope)
+	if err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: apply rebuild: %w", err)
+	}
+	inserted, err := tx.Exec(ctx, applyInsert, scope)
 	if err != nil {
-		return stats, fmt.Errorf("leaderboard/pgstore: enumerate cells: %w", err)
+		return stats, fmt.Errorf("leaderboard/pgstore: apply rebuild: %w", err)
+	}
+	// The lock is what makes this hold; if it ever does not, the swap is not
+	// the diff that was reported, and committing it would be a lie.
+	if deleted.RowsAffected() != int64(stats.Removed+stats.Changed) ||
+		inserted.RowsAffected() != int64(stats.Added+stats.Changed) {
+		return stats, fmt.Errorf("leaderboard/pgstore: apply rebuild: deleted %d and inserted %d rows for a diff of +%d -%d ~%d",
+			deleted.RowsAffected(), inserted.RowsAffected(), stats.Added, stats.Removed, stats.Changed)
+	}
+	// The swap moved ranks the way a verdict would, on every board it wrote.
+	// A key the walk could not have produced is junk that was just removed,
+	// and has no history to keep.
+	for _, d := range diff {
+		b, err := leaderboard.ParseBucketKey(d.BucketKey)
+		if err != nil || !b.HasRankHistory() {
+			continue
+		}
+		if err := q.MarkRankDirty(ctx, d.BucketKey); err != nil {
+			return stats, fmt.Errorf("leaderboard/pgstore: mark %s for rank history: %w", d.BucketKey, err)
+		}
 	}
-	if err := q.ClearLeaderboard(ctx); err != nil {
-		return stats, fmt.Errorf("leaderboard/pgstore: clear: %w", err)
+	if _, err := tx.Exec(ctx, dropShadow); err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: drop shadow: %w", err)
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: commit: %w", err)
+	}
+	return stats, nil
+}
+
+// intoShadow puts the rebuild schema in front of path; outOfShadow restores
+// path as it was.
+func (s *Store) intoShadow(ctx context.Context, tx pgx.Tx, path string) error {
+	if _, err := tx.Exec(ctx, setSearchPath, shadowSchema+", "+path); err != nil {
+		return fmt.Errorf("leaderboard/pgstore: search_path into shadow: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) outOfShadow(ctx context.Context, tx pgx.Tx, path string) error {
+	if _, err := tx.Exec(ctx, setSearchPath, path); err != nil {
+		return fmt.Errorf("leaderboard/pgstore: search_path out of shadow: %w", err)
+	}
+	return nil
+}
+
+// walk is the build: every cell in scope, recomputed. It returns how many
+// cells it recomputed. A scoped walk still enumerates every cell and skips
+// the ones on other boards — the enumerations are keyed by coordinates, and
+// the key they collapse into is only known in Go.
+func (s *Store) walk(ctx context.Context, q *leaderboarddb.Queries, scope *leaderboard.Bucket, live []string) (int, error) {
+	inScope := func(b leaderboard.Bucket) bool { return scope == nil || b.Key() == scope.Key() }
 
 	// One recompute per BOARD, not per enumerated coordinate tuple. The two are
 	// the same thing for a language board, but a quote board ignores the mode,
 	// size and language its runs were played at, so two tuples can name it — and
-	// recomputing the same cell twice would double-count it in stats.Cells while
+	// recomputing the same cell twice would double-count it in Cells while
 	// producing exactly one entry. The bucket key is the identity here for the
 	// same reason it is everywhere else: it is what the table is keyed by.
-	seen := make(map[string]struct{}, len(cells))
-	for i := range cells {
-		c := cell{
-			userID: cells[i].UserID, quoteID: cells[i].QuoteID,
-			mode: cells[i].Mode, durationMs: cells[i].DurationMs,
-			wordCount: cells[i].WordCount, lang: cells[i].Lang,
-			textSourceKind: cells[i].TextSourceKind,
-		}
-		bucket, err := c.bucket()
+	seen := make(map[string]struct{})
+	walked := 0
+
+	if scope == nil || (!scope.IsDaily() && !scope.IsWindowed()) {
+		cells, err := q.EnumerateLeaderboardCells(ctx, s.requireVerifiedEmail)
+		if err != nil {
+			return 0, fmt.Errorf("leaderboard/pgstore: enumerate cells: %w", err)
+		}
+		for i := range cells {
+			c := cell{
+				userID: cells[i].UserID, quoteID: cells[i].QuoteID,
+				mode: cells[i].Mode, durationMs: cells[i].DurationMs,
+				wordCount: cells[i].WordCount, lang: cells[i].Lang,
+				textSourceKind: cells[i].TextSourceKind,
+			}
+			bucket, err := c.bucket()
+			if err != nil {
+				// Unreachable through the eligible view, which only admits ranked
+				// shapes and resolvable quotes — but a view change must not
+				// silently drop rows.
+				return 0, fmt.Errorf("leaderboard/pgstore: eligible run in an unrankable cell: %w", err)
+			}
+			slot := bucket.Key() + "|" + c.userID.String()
+			if _, dup := seen[slot]; dup || !inScope(bucket) {
+				continue
+			}
+			seen[slot] = struct{}{}
+			if err := s.recompute(ctx, q, bucket, c); err != nil {
+				return 0, err
+			}
+			walked++
+		}
+	}
+
+	// The daily boards, walked the same way. A day is its own cell, so there is
+	// nothing to collapse: one row per (player, day) is one recompute.
+	if scope == nil || scope.IsDaily() {
+		daily, err := q.EnumerateDailyCells(ctx, s.requireVerifiedEmail)
+		if err != nil {
+			return 0, fmt.Errorf("leaderboard/pgstore: enumerate daily cells: %w", err)
+		}
+		for i := range daily {
+			bucket, err := leaderboard.NewDailyBucket(daily[i].Day)
+			if err != nil {
+				return 0, fmt.Errorf("leaderboard/pgstore: eligible attempt on an unnameable day: %w", err)
+			}
+			if !inScope(bucket) {
+				continue
+			}
+			if err := s.recomputeDaily(ctx, q, bucket, daily[i].UserID); err != nil {
+				return 0, err
+			}
+			walked++
+		}
+	}
+
+	// The windowed boards: the same walk again, once per window, bounded to
+	// the runs played inside it. Every window that had live rows is put back,
+	// and every window open now is built whether it had rows or not — a
+	// drifted projection is exactly the case where it might not have.
+	if scope != nil && !scope.IsWindowed() {
+		return walked, nil
+	}
+	periods, err := s.periodsToRebuild(ctx, q, live)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range periods {
+		if scope != nil && p.ID != scope.Period {
+			continue
+		}
+		cells, err := q.EnumeratePeriodCells(ctx, leaderboarddb.EnumeratePeriodCellsParams{
+			StartsAt: p.Start, EndsAt: p.End, RequireVerifiedEmail: s.requireVerifiedEmail,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("leaderboard/pgstore: enumerate cells in %s: %w", p.ID, err)
+		}
+		for i := range cells {
+			c := cell{
+				userID: cells[i].UserID, quoteID: cells[i].QuoteID,
+				mode: cells[i].Mode, durationMs: cells[i].DurationMs,
+				wordCount: cells[i].WordCount, lang: cells[i].Lang,
+				textSourceKind: cells[i].TextSourceKind,
+			}
+			bucket, err := c.bucket()
+			if err != nil {
+				return 0, fmt.Errorf("leaderboard/pgstore: eligible run in an unrankable cell: %w", err)
+			}
+			windowed, err := bucket.Over(p.ID)
+			if err != nil {
+				return 0, fmt.Errorf("leaderboard/pgstore: window %s over %s: %w", p.ID, bucket.Key(), err)
+			}
+			slot := windowed.Key() + "|" + c.userID.String()
+			if _, dup := seen[slot]; dup || !inScope(windowed) {
+				continue
+			}
+			seen[slot] = struct{}{}
+			if err := s.recomputePeriod(ctx, q, bucket, c, p); err != nil {
+				return 0, err
+			}
+			walked++
+		}
+	}
+	return walked, nil
+}
+
+// catchUp recomputes, in the shadow, each slot a diff names — from the slot
+// alone, the way the projection would for a verdict in it. Run on the first
+// diff it absorbs everything the projection wrote during the build; run on a
+// slot that is simply wrong on the live board it changes nothing, and the slot
+// stays in the diff, which is the point.
+func (s *Store) catchUp(ctx context.Context, tx pgx.Tx, q *leaderboarddb.Queries, path string, diff []RebuildDiff) error {
+	if len(diff) == 0 {
+		return nil
+	}
+	if err := s.intoShadow(ctx, tx, path); err != nil {
+		return err
+	}
+	for _, d := range diff {
+		if err := s.recomputeSlot(ctx, tx, q, d.BucketKey, d.UserID); err != nil {
+			return err
+		}
+	}
+	return s.outOfShadow(ctx, tx, path)
+}
+
+// recomputeSlot recomputes one (board, player) slot given only its key.
+func (s *Store) recomputeSlot(ctx context.Context, tx pgx.Tx, q *leaderboarddb.Queries, key string, userID uuid.UUID) error {
+	bucket, err := leaderboard.ParseBucketKey(key)
+	if err != nil {
+		// Only a live row can carry a key that does not parse — the shadow's
+		// were all written by Bucket.Key. No cell builds it, so the shadow
+		// already has the slot empty, and the swap removes it: the same
+		// outcome the old TRUNCATE gave junk.
+		return nil //nolint:nilerr // not an error: a key no board owns is drift to remove
+	}
+	switch {
+	case bucket.IsDaily():
+		return s.recomputeDaily(ctx, q, bucket, userID)
+	case bucket.IsWindowed():
+		_, err := q.GetFrozenPeriod(ctx, bucket.Period)
+		if err == nil {
+			if _, err := tx.Exec(ctx, dropShadowSlot, key, userID); err != nil {
+				return fmt.Errorf("leaderboard/pgstore: clear %s for %s: %w", key, userID, err)
+			}
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("leaderboard/pgstore: is %s frozen: %w", bucket.Period, err)
+		}
+		p, _, err := s.resolvePeriod(ctx, q, bucket.Period)
+		if err != nil {
+			return err
+		}
+		return s.recomputePeriod(ctx, q, bucket.AllTime(), cellOf(bucket.AllTime(), userID), p)
+	default:
+		return s.recompute(ctx, q, bucket, cellOf(bucket, userID))
+	}
+}
+
+// cellOf is bucket's inverse for one player: the coordinates whose recompute
+// writes this board. A quote board needs only its quote; a language board
+// needs its size back in whichever column the mode keeps it.
+func cellOf(b leaderboard.Bucket, userID uuid.UUID) cell {
+	c := cell{userID: userID}
+	if b.IsQuote() {
+		id := b.QuoteID
+		c.quoteID = &id
+		return c
+	}
+	c.mode, c.lang, c.textSourceKind = b.Mode, b.Lang, b.TextSource
+	dim := b.Dimension
+	if b.Mode == leaderboard.ModeTime {
+		c.durationMs = &dim
+	} else {
+		c.wordCount = &dim
+	}
+	return c
+}
+
+// periodsToRebuild resolves the windows a rebuild must write: those that had
+// live rows, and those open now, minus any already frozen — a live row in a
+// frozen window is a straggler the freezer deletes, not a board to restore.
+func (s *Store) periodsToRebuild(ctx context.Context, q *leaderboarddb.Queries, live []string) ([]leaderboard.Period, error) {
+	current, err := s.windowsAt(ctx, q, s.now())
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]leaderboard.Period, len(live)+len(current))
+	for _, id := range live {
+		p, _, err := s.resolvePeriod(ctx, q, id)
+		if err != nil {
+			return nil, err
+		}
+		byID[id] = p
+	}
+	for _, p := range current {
+		byID[p.ID] = p
+	}
+
+	out := make([]leaderboard.Period, 0, len(byID))
+	for id, p := range byID {
+		_, err := q.GetFrozenPeriod(ctx, id)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("leaderboard/pgstore: is %s frozen: %w", id, err)
+		}
+		out = append(out, p)
+	}
+	slices.SortFunc(out, func(a, b leaderboard.Period) int { return strings.Compare(a.ID, b.ID) })
+	return out, nil
+}
+
+// resolvePeriod turns a stored period id back into its window, and its name
+// when it is a season.
+func (s *Store) resolvePeriod(ctx context.Context, q *leaderboarddb.Queries, id string) (leaderboard.Period, string, error) {
+	p, err := leaderboard.ParsePeriodID(id)
+	if err != nil {
+		// Like a stored bucket key that no longer parses: the format changed
+		// without a migration, and guessing would archive the wrong span.
+		return leaderboard.Period{}, "", fmt.Errorf("leaderboard/pgstore: stored period %q: %w", id, err)
+	}
+	if p.Kind != leaderboard.PeriodSeason {
+		return p, "", nil
+	}
+	row, err := q.GetSeason(ctx, id)
+	if err != nil {
+		return leaderboard.Period{}, "", fmt.Errorf("leaderboard/pgstore: season %q: %w", id, err)
+	}
+	season := seasonOf(row)
+	return season.Period(), season.Name, nil
+}
+
+// --- write side: freezing ---
+
+// FreezeClosed archives every window whose settle period has run out: its
+// boards' visible standings are copied into leaderboard_snapshots with their
+// ranks, and its live rows are deleted, in one transaction per window. A
+// window that fails is left live for the next pass and does not hold up the
+// others.
+func (s *Store) FreezeClosed(ctx context.Context) (leaderboard.FreezeStats, error) {
+	var stats leaderboard.FreezeStats
+	live, err := s.q.ListLivePeriods(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("leaderboard/pgstore: list live windows: %w", err)
+	}
+	now := s.now()
+	var errs []error
+	for _, id := range live {
+		p, name, err := s.resolvePeriod(ctx, s.q, id)
 		if err != nil {
-			// Unreachable through the eligible view, which only admits ranked
-			// shapes and resolvable quotes — but a view change must not
-			// silently drop rows.
-			return stats, fmt.Errorf("leaderboard/pgstore: eligible run in an unrankable cell: %w", err)
+			errs = append(errs, err)
+			continue
 		}
-		slot := bucket.Key() + "|" + c.userID.String()
-		if _, dup := seen[slot]; dup {
+		if s.windows.Open(p, now) {
 			continue
 		}
-		seen[slot] = struct{}{}
-		if err := s.recompute(ctx, q, bucket, c); err != nil {
+		if err := s.freeze(ctx, p, name, now, &stats); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stats, errors.Join(errs...)
+}
+
+// freeze archives one window. Claiming it in leaderboard_periods comes first,
+// so two freezers racing on the same window serialise on its primary key and
+// the loser finds only stragglers to delete.
+func (s *Store) freeze(ctx context.Context, p leaderboard.Period, name string, now time.Time, stats *leaderboard.FreezeStats) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("leaderboard/pgstore: begin freeze %s: %w", p.ID, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := s.q.WithTx(tx)
+
+	var label *string
+	if name != "" {
+		label = &name
+	}
+	claimed, err := q.FreezePeriod(ctx, leaderboarddb.FreezePeriodParams{
+		ID: p.ID, Kind: string(p.Kind), Name: label,
+		StartsAt: p.Start, EndsAt: p.End, FrozenAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("leaderboard/pgstore: claim %s: %w", p.ID, err)
+	}
+	var archived int64
+	if claimed == 1 {
+		if archived, err = q.SnapshotPeriod(ctx, &p.ID); err != nil {
+			return fmt.Errorf("leaderboard/pgstore: archive %s: %w", p.ID, err)
+		}
+	}
+	deleted, err := q.DeletePeriodEntries(ctx, &p.ID)
+	if err != nil {
+		return fmt.Errorf("leaderboard/pgstore: clear %s: %w", p.ID, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("leaderboard/pgstore: commit freeze %s: %w", p.ID, err)
+	}
+
+	if claimed == 1 {
+		stats.Periods++
+		stats.Archived += archived
+	} else {
+		stats.Stragglers += deleted
+	}
+	return nil
+}
+
+// --- write side: rank snapshots ---
+
+// ladderStep is the gap between two rungs of the rank ladder (00041): the
+// most rows a percentile lookup counts live, on top of whatever arrived above
+// it since the last pass.
+const ladderStep = 100
+
+// SnapshotRanks records a rank point for every player who moved on every board
+// marked since the last pass, and rebuilds that board's rank ladder, one board
+// per transaction. A board whose snapshot fails keeps its mark for the next
+// pass and does not hold up the others.
+func (s *Store) SnapshotRanks(ctx context.Context) (leaderboard.RankSnapshotStats, error) {
+	var stats leaderboard.RankSnapshotStats
+	started := s.now()
+	for {
+		ok, err := s.snapshotOne(ctx, started, &stats)
+		if err != nil {
 			return stats, err
 		}
+		if !ok {
+			return stats, nil
+		}
 	}
-	stats.Cells = len(seen)
+}
+
+// snapshotOne claims one marked board, snapshots it and adds what it did to
+// stats. ok is false when no board marked before markedBefore is left
+// unclaimed.
+func (s *Store) snapshotOne(ctx context.Context, markedBefore time.Time, stats *leaderboard.RankSnapshotStats) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("leaderboard/pgstore: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := s.q.WithTx(tx)
 
-	if stats.After, err = q.CountLeaderboardEntries(ctx); err != nil {
-		return stats, fmt.Errorf("leaderboard/pgstore: count after: %w", err)
+	key, err := q.ClaimRankDirty(ctx, markedBefore)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("leaderboard/pgstore: claim a board for rank history: %w", err)
+	}
+	points, err := q.SnapshotRanks(ctx, leaderboarddb.SnapshotRanksParams{TakenAt: s.now(), BucketKey: key})
+	if err != nil {
+		return false, fmt.Errorf("leaderboard/pgstore: snapshot ranks on %s: %w", key, err)
+	}
+	if err := q.ClearRankLadder(ctx, key); err != nil {
+		return false, fmt.Errorf("leaderboard/pgstore: clear rank ladder on %s: %w", key, err)
+	}
+	rungs, err := q.BuildRankLadder(ctx, leaderboarddb.BuildRankLadderParams{BucketKey: key, Step: ladderStep})
+	if err != nil {
+		return false, fmt.Errorf("leaderboard/pgstore: build rank ladder on %s: %w", key, err)
 	}
 	if err := tx.Commit(ctx); err != nil {
-		return stats, fmt.Errorf("leaderboard/pgstore: commit: %w", err)
+		return false, fmt.Errorf("leaderboard/pgstore: commit: %w", err)
 	}
-	return stats, nil
+	stats.Boards++
+	stats.Points += points
+	stats.Rungs += rungs
+	return true, nil
+}
+
+// --- seasons (operator) ---
+
+// CreateSeason declares a season. Overlapping another one is refused by the
+// table's exclusion constraint.
+func (s *Store) CreateSeason(ctx context.Context, season leaderboard.Season) error {
+	if err := season.Validate(); err != nil {
+		return err
+	}
+	err := s.q.CreateSeason(ctx, leaderboarddb.CreateSeasonParams{
+		ID: season.ID, Name: season.Name, StartsAt: season.StartsAt, EndsAt: season.EndsAt,
+	})
+	if err != nil {
+		return fmt.Errorf("leaderboard/pgstore: create season %q: %w", season.ID, err)
+	}
+	return nil
+}
+
+// Seasons lists every declared season, earliest first.
+func (s *Store) Seasons(ctx context.Context) ([]leaderboard.Season, error) {
+	rows, err := s.q.ListSeasons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]leaderboard.Season, len(rows))
+	for i := range rows {
+		out[i] = seasonOf(rows[i])
+	}
+	return out, nil
+}
+
+func seasonOf(r leaderboarddb.LeaderboardSeason) leaderboard.Season {
+	return leaderboard.Season{ID: r.ID, Name: r.Name, StartsAt: r.StartsAt.UTC(), EndsAt: r.EndsAt.UTC()}
 }
 
 // --- read side ---
@@ -248,9 +941,93 @@ func (s *Store) Buckets(ctx context.Context) ([]leaderboard.BucketCount, error)
 	return out, nil
 }
 
-// Page returns up to limit entries of a bucket's ranking, continuing after the
-// keyset position when non-nil.
-func (s *Store) Page(ctx context.Context, b leaderboard.Bucket, after *leaderboard.Cursor, limit int32) ([]leaderboard.Entry, error) {
+// Boards returns one page of the board index off leaderboard_boards.
+func (s *Store) Boards(ctx context.Context, f leaderboard.BoardFilter, sort leaderboard.BoardSort, after *leaderboard.BoardCursor, limit int32) ([]leaderboard.Board, error) {
+	arg := leaderboarddb.ListLeaderboardBoardsParams{
+		MinEntries: f.MinEntries,
+		Mode:       optional(f.Mode),
+		Lang:       optional(f.Lang),
+		TextSource: optional(f.TextSource),
+		LenGroup:   f.LenGroup,
+		Withdrawn:  f.Withdrawn,
+		RowLimit:   limit,
+	}
+	if arg.Withdrawn == nil {
+		// A nil slice is a NULL array, and `<> ALL (NULL)` is NULL, which
+		// would leave out every quote board rather than none.
+		arg.Withdrawn = []uuid.UUID{}
+	}
+	var pos leaderboard.BoardCursor
+	if after != nil {
+		pos = *after
+		arg.AfterKey = &pos.Key
+	}
+
+	var rows []leaderboarddb.ListLeaderboardBoardsRow
+	var err error
+	switch sort {
+	case leaderboard.BoardSortEntries:
+		var got []leaderboarddb.ListLeaderboardBoardsByEntriesRow
+		got, err = s.q.ListLeaderboardBoardsByEntries(ctx, leaderboarddb.ListLeaderboardBoardsByEntriesParams{
+			MinEntries: arg.MinEntries, Mode: arg.Mode, Lang: arg.Lang, TextSource: arg.TextSource,
+			LenGroup: arg.LenGroup, Withdrawn: arg.Withdrawn, AfterKey: arg.AfterKey,
+			AfterEntries: pos.Entries, RowLimit: arg.RowLimit,
+		})
+		for i := range got {
+			rows = append(rows, leaderboarddb.ListLeaderboardBoardsRow(got[i]))
+		}
+	case leaderboard.BoardSortRecent:
+		var got []leaderboarddb.ListLeaderboardBoardsByRecentRow
+		got, err = s.q.ListLeaderboardBoardsByRecent(ctx, leaderboarddb.ListLeaderboardBoardsByRecentParams{
+			MinEntries: arg.MinEntries, Mode: arg.Mode, Lang: arg.Lang, TextSource: arg.TextSource,
+			LenGroup: arg.LenGroup, Withdrawn: arg.Withdrawn, AfterKey: arg.AfterKey,
+			AfterChangedAt: pos.ChangedAt, RowLimit: arg.RowLimit,
+		})
+		for i := range got {
+			rows = append(rows, leaderboarddb.ListLeaderboardBoardsRow(got[i]))
+		}
+	default:
+		rows, err = s.q.ListLeaderboardBoards(ctx, arg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]leaderboard.Board, 0, len(rows))
+	for i := range rows {
+		bucket, err := leaderboard.ParseBucketKey(rows[i].BucketKey)
+		if err != nil {
+			return nil, fmt.Errorf("leaderboard/pgstore: stored bucket key %q: %w", rows[i].BucketKey, err)
+		}
+		board := leaderboard.Board{
+			Bucket:    bucket,
+			LenGroup:  rows[i].LenGroup,
+			Entries:   rows[i].Entries,
+			ChangedAt: rows[i].ChangedAt.UTC(),
+		}
+		if rows[i].Lang != nil {
+			board.Lang = *rows[i].Lang
+		}
+		out = append(out, board)
+	}
+	return out, nil
+}
+
+// optional is a filter field as a query parameter: empty matches everything,
+// which the queries spell NULL.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Page returns up to limit entries of a bucket's ranking under an order,
+// continuing after the keyset position when non-nil.
+func (s *Store) Page(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, after *leaderboard.Cursor, limit int32) ([]leaderboard.Entry, error) {
+	if o == leaderboard.OrderWPM {
+		return s.wpmPage(ctx, b, after, limit)
+	}
 	if after == nil {
 		rows, err := s.q.ListLeaderboardPageFirst(ctx, leaderboarddb.ListLeaderboardPageFirstParams{
 			BucketKey: b.Key(), RowLimit: limit,
@@ -281,11 +1058,60 @@ func (s *Store) Page(ctx context.Context, b leaderboard.Bucket, after *leaderboa
 	return out, nil
 }
 
+// wpmPage is Page for the WPM order: the same two queries, keyed on wpm.
+func (s *Store) wpmPage(ctx context.Context, b leaderboard.Bucket, after *leaderboard.Cursor, limit int32) ([]leaderboard.Entry, error) {
+	if after == nil {
+		rows, err := s.q.ListLeaderboardWPMPageFirst(ctx, leaderboarddb.ListLeaderboardWPMPageFirstParams{
+			BucketKey: b.Key(), RowLimit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out := make([]leaderboard.Entry, len(rows))
+		for i := range rows {
+			out[i] = wpmFirstRowToEntry(rows[i])
+		}
+		return out, nil
+	}
+	rows, err := s.q.ListLeaderboardWPMPageAfter(ctx, leaderboarddb.ListLeaderboardWPMPageAfterParams{
+		BucketKey:  b.Key(),
+		Wpm:        after.WPM,
+		AchievedAt: after.AchievedAt,
+		UserID:     after.UserID,
+		RowLimit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]leaderboard.Entry, len(rows))
+	for i := range rows {
+		out[i] = wpmAfterRowToEntry(rows[i])
+	}
+	return out, nil
+}
+
 // PageBefore returns up to limit entries strictly outranking the position, in
 // ranking order. SQL hands them back nearest-first (that is what makes LIMIT
 // take the position's neighbours instead of rank 1's), so the slice is
 // reversed here once rather than by every caller.
-func (s *Store) PageBefore(ctx context.Context, b leaderboard.Bucket, before leaderboard.Cursor, limit int32) ([]leaderboard.Entry, error) {
+func (s *Store) PageBefore(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, before leaderboard.Cursor, limit int32) ([]leaderboard.Entry, error) {
+	if o == leaderboard.OrderWPM {
+		rows, err := s.q.ListLeaderboardWPMPageBefore(ctx, leaderboarddb.ListLeaderboardWPMPageBeforeParams{
+			BucketKey:  b.Key(),
+			Wpm:        before.WPM,
+			AchievedAt: before.AchievedAt,
+			UserID:     before.UserID,
+			RowLimit:   limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out := make([]leaderboard.Entry, len(rows))
+		for i := range rows {
+			out[len(rows)-1-i] = wpmBeforeRowToEntry(rows[i])
+		}
+		return out, nil
+	}
 	rows, err := s.q.ListLeaderboardPageBefore(ctx, leaderboarddb.ListLeaderboardPageBeforeParams{
 		BucketKey:  b.Key(),
 		Score:      before.Score,
@@ -303,8 +1129,16 @@ func (s *Store) PageBefore(ctx context.Context, b leaderboard.Bucket, before lea
 	return out, nil
 }
 
-// RankAbove counts the visible entries that outrank a position.
-func (s *Store) RankAbove(ctx context.Context, b leaderboard.Bucket, at leaderboard.Cursor) (int64, error) {
+// RankAbove counts the visible entries that outrank a position under an order.
+func (s *Store) RankAbove(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, at leaderboard.Cursor) (int64, error) {
+	if o == leaderboard.OrderWPM {
+		return s.q.CountLeaderboardWPMAbove(ctx, leaderboarddb.CountLeaderboardWPMAboveParams{
+			BucketKey:  b.Key(),
+			Wpm:        at.WPM,
+			AchievedAt: at.AchievedAt,
+			UserID:     at.UserID,
+		})
+	}
 	return s.q.CountLeaderboardAbove(ctx, leaderboarddb.CountLeaderboardAboveParams{
 		BucketKey:  b.Key(),
 		Score:      at.Score,
@@ -313,8 +1147,65 @@ func (s *Store) RankAbove(ctx context.Context, b leaderboard.Bucket, at leaderbo
 	})
 }
 
-// EntryFor returns one player's entry with its rank, or leaderboard.ErrNoEntry.
-func (s *Store) EntryFor(ctx context.Context, b leaderboard.Bucket, userID uuid.UUID) (leaderboard.Entry, error) {
+// Standing answers RankAbove off the rank ladder, and sizes the board.
+func (s *Store) Standing(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, at leaderboard.Cursor) (leaderboard.Standing, error) {
+	if o == leaderboard.OrderWPM {
+		row, err := s.q.CountLeaderboardWPMAboveByLadder(ctx, leaderboarddb.CountLeaderboardWPMAboveByLadderParams{
+			BucketKey:  b.Key(),
+			Wpm:        at.WPM,
+			AchievedAt: at.AchievedAt,
+			UserID:     at.UserID,
+		})
+		if err != nil {
+			return leaderboard.Standing{}, err
+		}
+		return leaderboard.StandingOf(row.Above, row.Entries), nil
+	}
+	row, err := s.q.CountLeaderboardAboveByLadder(ctx, leaderboarddb.CountLeaderboardAboveByLadderParams{
+		BucketKey:  b.Key(),
+		Score:      at.Score,
+		AchievedAt: at.AchievedAt,
+		UserID:     at.UserID,
+	})
+	if err != nil {
+		return leaderboard.Standing{}, err
+	}
+	return leaderboard.StandingOf(row.Above, row.Entries), nil
+}
+
+// RunStanding is where one run's score sits on the all-time board its shape
+// ranks on, whether or not the run holds the player's slot there: the rank it
+// would take, counting everyone who outranks it — the player's own better run
+// included. ok is false for a run that is not accepted or ranks nowhere.
+func (s *Store) RunStanding(ctx context.Context, runID uuid.UUID) (leaderboard.Bucket, leaderboard.Standing, bool, error) {
+	row, err := s.q.EligibleRunPosition(ctx, runID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return leaderboard.Bucket{}, leaderboard.Standing{}, false, nil
+	}
+	if err != nil {
+		return leaderboard.Bucket{}, leaderboard.Standing{}, false, err
+	}
+	bucket, err := cell{
+		userID: row.UserID, quoteID: row.QuoteID,
+		mode: row.Mode, durationMs: row.DurationMs, wordCount: row.WordCount,
+		lang: row.Lang, textSourceKind: row.TextSourceKind,
+	}.bucket()
+	if err != nil {
+		return leaderboard.Bucket{}, leaderboard.Standing{}, false, nil //nolint:nilerr // an eligible run always names a board; one that does not has no standing
+	}
+	standing, err := s.Standing(ctx, bucket, leaderboard.OrderScore, leaderboard.Cursor{
+		Score: row.Score, AchievedAt: row.AchievedAt, UserID: row.UserID,
+	})
+	if err != nil {
+		return leaderboard.Bucket{}, leaderboard.Standing{}, false, err
+	}
+	return bucket, standing, true, nil
+}
+
+// EntryFor returns one player's entry with its rank under the order, or
+// leaderboard.ErrNoEntry. The row is the same whichever order is asked for;
+// only the count that ranks it differs.
+func (s *Store) EntryFor(ctx context.Context, b leaderboard.Bucket, o leaderboard.Order, userID uuid.UUID) (leaderboard.Entry, error) {
 	row, err := s.q.GetLeaderboardEntry(ctx, leaderboarddb.GetLeaderboardEntryParams{
 		BucketKey: b.Key(), UserID: userID,
 	})
@@ -325,8 +1216,8 @@ func (s *Store) EntryFor(ctx context.Context, b leaderboard.Bucket, userID uuid.
 		return leaderboard.Entry{}, err
 	}
 	entry := getRowToEntry(row)
-	above, err := s.RankAbove(ctx, b, leaderboard.Cursor{
-		Score: entry.Score, AchievedAt: entry.AchievedAt, UserID: entry.UserID,
+	above, err := s.RankAbove(ctx, b, o, leaderboard.Cursor{
+		Score: entry.Score, WPM: entry.WPM, AchievedAt: entry.AchievedAt, UserID: entry.UserID,
 	})
 	if err != nil {
 		return leaderboard.Entry{}, err
@@ -335,9 +1226,102 @@ func (s *Store) EntryFor(ctx context.Context, b leaderboard.Bucket, userID uuid.
 	return entry, nil
 }
 
+// CurrentSeason returns the season running at an instant, or
+// leaderboard.ErrNoSeason.
+func (s *Store) CurrentSeason(ctx context.Context, at time.Time) (leaderboard.Season, error) {
+	row, err := s.q.SeasonAt(ctx, at)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return leaderboard.Season{}, leaderboard.ErrNoSeason
+	}
+	if err != nil {
+		return leaderboard.Season{}, err
+	}
+	return seasonOf(row), nil
+}
+
+// FrozenPeriod returns an archived window, or leaderboard.ErrNotFrozen.
+func (s *Store) FrozenPeriod(ctx context.Context, id string) (leaderboard.FrozenPeriod, error) {
+	row, err := s.q.GetFrozenPeriod(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return leaderboard.FrozenPeriod{}, leaderboard.ErrNotFrozen
+	}
+	if err != nil {
+		return leaderboard.FrozenPeriod{}, err
+	}
+	return leaderboard.FrozenPeriod{
+		Period: leaderboard.Period{
+			ID: row.ID, Kind: leaderboard.PeriodKind(row.Kind),
+			Start: row.StartsAt.UTC(), End: row.EndsAt.UTC(),
+		},
+		Name:     text(row.Name),
+		FrozenAt: row.FrozenAt.UTC(),
+	}, nil
+}
+
+// SnapshotPage returns a page of a windowed board's frozen standings, with the
+// ranks they were frozen at.
+func (s *Store) SnapshotPage(ctx context.Context, b leaderboard.Bucket, afterRank int64, limit int32) ([]leaderboard.Entry, error) {
+	rows, err := s.q.ListSnapshotPage(ctx, leaderboarddb.ListSnapshotPageParams{
+		BucketKey: b.Key(), AfterRank: afterRank, RowLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]leaderboard.Entry, len(rows))
+	for i := range rows {
+		r := rows[i]
+		out[i] = leaderboard.Entry{
+			Rank: r.Rank, UserID: r.UserID, DisplayName: r.DisplayName, RunID: r.RunID,
+			Score: r.Score, WPM: r.Wpm, Raw: r.Raw, Acc: r.Acc, Grade: r.Grade,
+			Mods: r.Mods, AchievedAt: r.AchievedAt, Source: text(r.QuoteSource),
+		}
+	}
+	return out, nil
+}
+
+// RankHistory implements leaderboard.Store.
+func (s *Store) RankHistory(ctx context.Context, b leaderboard.Bucket, userID uuid.UUID, limit int32) ([]leaderboard.RankPoint, error) {
+	rows, err := s.q.ListRankHistory(ctx, leaderboarddb.ListRankHistoryParams{
+		BucketKey: b.Key(), UserID: userID, RowLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]leaderboard.RankPoint, len(rows))
+	for i, r := range rows {
+		out[i] = leaderboard.RankPoint{At: r.TakenAt, Rank: r.Rank, Score: r.Score}
+	}
+	return out, nil
+}
+
+// SwapSeenRank implements leaderboard.Store. Two statements rather than one
+// upsert returning the old row: a player racing themselves across two tabs
+// can at worst see the same movement twice, which is not worth a lock.
+func (s *Store) SwapSeenRank(ctx context.Context, b leaderboard.Bucket, userID uuid.UUID, now leaderboard.RankPoint) (leaderboard.RankPoint, bool, error) {
+	var (
+		prev leaderboard.RankPoint
+		ok   bool
+	)
+	row, err := s.q.GetRankSeen(ctx, leaderboarddb.GetRankSeenParams{UserID: userID, BucketKey: b.Key()})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return prev, false, err
+	default:
+		prev, ok = leaderboard.RankPoint{At: row.SeenAt, Rank: row.Rank}, true
+	}
+	err = s.q.MarkRankSeen(ctx, leaderboarddb.MarkRankSeenParams{
+		UserID: userID, BucketKey: b.Key(), Rank: now.Rank, SeenAt: now.At,
+	})
+	if err != nil {
+		return prev, false, err
+	}
+	return prev, ok, nil
+}
+
 // --- row conversions ---
 //
-// The three ranked-row queries emit distinct-but-identical generated types, so
+// The ranked-row queries emit distinct-but-identical generated types, so
 // each gets a tiny converter into the shared leaderboard.Entry.
 
 func firstRowToEntry(r leaderboarddb.ListLeaderboardPageFirstRow) leaderboard.Entry {
@@ -364,6 +1348,30 @@ func beforeRowToEntry(r leaderboarddb.ListLeaderboardPageBeforeRow) leaderboard.
 	}
 }
 
+func wpmFirstRowToEntry(r leaderboarddb.ListLeaderboardWPMPageFirstRow) leaderboard.Entry {
+	return leaderboard.Entry{
+		UserID: r.UserID, DisplayName: r.DisplayName, RunID: r.RunID,
+		Score: r.Score, WPM: r.Wpm, Raw: r.Raw, Acc: r.Acc, Grade: r.Grade,
+		Mods: r.Mods, AchievedAt: r.AchievedAt, Source: text(r.QuoteSource),
+	}
+}
+
+func wpmAfterRowToEntry(r leaderboarddb.ListLeaderboardWPMPageAfterRow) leaderboard.Entry {
+	return leaderboard.Entry{
+		UserID: r.UserID, DisplayName: r.DisplayName, RunID: r.RunID,
+		Score: r.Score, WPM: r.Wpm, Raw: r.Raw, Acc: r.Acc, Grade: r.Grade,
+		Mods: r.Mods, AchievedAt: r.AchievedAt, Source: text(r.QuoteSource),
+	}
+}
+
+func wpmBeforeRowToEntry(r leaderboarddb.ListLeaderboardWPMPageBeforeRow) leaderboard.Entry {
+	return leaderboard.Entry{
+		UserID: r.UserID, DisplayName: r.DisplayName, RunID: r.RunID,
+		Score: r.Score, WPM: r.Wpm, Raw: r.Raw, Acc: r.Acc, Grade: r.Grade,
+		Mods: r.Mods, AchievedAt: r.AchievedAt, Source: text(r.QuoteSource),
+	}
+}
+
 func getRowToEntry(r leaderboarddb.GetLeaderboardEntryRow) leaderboard.Entry {
 	return leaderboard.Entry{
 		UserID: r.UserID, DisplayName: r.DisplayName, RunID: r.RunID,
@@ -0,0 +1,158 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// The rebuild's shadow table, and the statements that compare it with the live
+// one and carry the difference across.
+//
+// These are not sqlc queries because the table they read does not exist in
+// any migration: it is created per rebuild, inside the rebuild's transaction
+// (00039). The statements that FILL it are the projection's own — see
+// Store.RebuildWith for how the search path points them here.
+const (
+	shadowSchema = "leaderboard_rebuild"
+
+	// LIKE .. INCLUDING ALL copies the defaults, the CHECK that keeps a score
+	// packable, the generated sort_key and every index — the primary key the
+	// recompute statements' ON CONFLICT names, and the one-slot-per-run indexes
+	// that make a rebuild refuse a state the live table would refuse.
+	createShadow = `CREATE TABLE leaderboard_rebuild.leaderboard_entries
+    (LIKE leaderboard_entries INCLUDING ALL)`
+
+	dropShadow = `DROP TABLE leaderboard_rebuild.leaderboard_entries`
+
+	// set_config's third argument makes the setting transaction-local, like SET
+	// LOCAL: the pooled connection goes back with the path it came with, and a
+	// rollback restores it too.
+	getSearchPath = `SELECT current_setting('search_path')`
+	setSearchPath = `SELECT set_config('search_path', $1, true)`
+
+	// EXCLUSIVE conflicts with every writer (ROW EXCLUSIVE) and with no reader
+	// (ACCESS SHARE): from here to commit the projection waits and the boards
+	// are served as they were.
+	lockLive = `LOCK TABLE leaderboard_entries IN EXCLUSIVE MODE`
+
+	// diffShadow lists every slot, in scope, that the two tables disagree on:
+	// present on one side only, or present on both with any stored column
+	// different. sort_key is left out because it is computed from score.
+	//
+	// live_sig fingerprints the live row, so that two diffs taken either side
+	// of a concurrent write can tell that the write happened.
+	diffShadow = `SELECT coalesce(l.bucket_key, r.bucket_key) AS bucket_key,
+       coalesce(l.user_id, r.user_id)       AS user_id,
+       l.run_id                             AS live_run_id,
+       r.run_id                             AS rebuilt_run_id,
+       CASE WHEN l.bucket_key IS NOT NULL THEN md5(l::text) END AS live_sig
+FROM (SELECT * FROM leaderboard_entries
+      WHERE $1::text IS NULL OR bucket_key = $1::text) l
+FULL JOIN (SELECT * FROM leaderboard_rebuild.leaderboard_entries
+           WHERE $1::text IS NULL OR bucket_key = $1::text) r
+       ON r.bucket_key = l.bucket_key AND r.user_id = l.user_id
+WHERE l.bucket_key IS NULL
+   OR r.bucket_key IS NULL
+   OR (l.run_id, l.score, l.wpm, l.raw, l.acc, l.grade, l.mods, l.achieved_at,
+       l.quote_source, l.daily_day, l.period)
+      IS DISTINCT FROM
+      (r.run_id, r.score, r.wpm, r.raw, r.acc, r.grade, r.mods, r.achieved_at,
+       r.quote_source, r.daily_day, r.period)
+ORDER BY 1, 2`
+
+	// applyDelete and applyInsert are the swap. Every live row in scope without
+	// an identical shadow row goes; every shadow row whose slot is then empty
+	// comes in. Rows the two agree on are not touched at all, so an unchanged
+	// board keeps its physical rows, and the delete runs first so a run that
+	// moved between slots never meets itself in the one-slot-per-run index.
+	applyDelete = `DELETE FROM leaderboard_entries l
+WHERE ($1::text IS NULL OR l.bucket_key = $1::text)
+  AND NOT EXISTS (
+      SELECT 1 FROM leaderboard_rebuild.leaderboard_entries r
+      WHERE r.bucket_key = l.bucket_key AND r.user_id = l.user_id
+        AND (r.run_id, r.score, r.wpm, r.raw, r.acc, r.grade, r.mods,
+             r.achieved_at, r.quote_source, r.daily_day, r.period)
+            IS NOT DISTINCT FROM
+            (l.run_id, l.score, l.wpm, l.raw, l.acc, l.grade, l.mods,
+             l.achieved_at, l.quote_source, l.daily_day, l.period))`
+
+	applyInsert = `INSERT INTO leaderboard_entries
+    (bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods,
+     achieved_at, quote_source, daily_day, period)
+SELECT r.bucket_key, r.user_id, r.run_id, r.score, r.wpm, r.raw, r.acc,
+       r.grade, r.mods, r.achieved_at, r.quote_source, r.daily_day, r.period
+FROM leaderboard_rebuild.leaderboard_entries r
+WHERE ($1::text IS NULL OR r.bucket_key = $1::text)
+  AND NOT EXISTS (SELECT 1 FROM leaderboard_entries l
+                  WHERE l.bucket_key = r.bucket_key AND l.user_id = r.user_id)`
+
+	// dropShadowSlot empties one slot of the shadow. The catch-up uses it for a
+	// window the freezer closed while the shadow was being built: nothing
+	// writes a frozen window, so its slot is cleared rather than recomputed.
+	dropShadowSlot = `DELETE FROM leaderboard_rebuild.leaderboard_entries
+WHERE bucket_key = $1 AND user_id = $2`
+)
+
+// RebuildDiff is one slot the rebuild disagrees with the live board about.
+//
+// Live is the run holding the slot now, Rebuilt the run that holds it after
+// the rebuild; nil on either side means the slot is empty there. Both set and
+// equal is a slot whose run is right but whose copy of it is not — a stale
+// quote attribution, say.
+type RebuildDiff struct {
+	BucketKey string
+	UserID    uuid.UUID
+	Live      *uuid.UUID
+	Rebuilt   *uuid.UUID
+
+	// liveSig fingerprints the live row as the diff saw it; empty when the
+	// slot was empty.
+	liveSig string
+}
+
+// slot is the diff's identity: the table's primary key.
+func (d RebuildDiff) slot() string { return d.BucketKey + "|" + d.UserID.String() }
+
+// diffWithShadow runs diffShadow.
+func diffWithShadow(ctx context.Context, tx pgx.Tx, scope *string) ([]RebuildDiff, error) {
+	rows, err := tx.Query(ctx, diffShadow, scope)
+	if err != nil {
+		return nil, fmt.Errorf("leaderboard/pgstore: diff rebuild: %w", err)
+	}
+	out, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (RebuildDiff, error) {
+		var (
+			d   RebuildDiff
+			sig *string
+		)
+		if err := row.Scan(&d.BucketKey, &d.UserID, &d.Live, &d.Rebuilt, &sig); err != nil {
+			return d, err
+		}
+		if sig != nil {
+			d.liveSig = *sig
+		}
+		return d, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("leaderboard/pgstore: diff rebuild: %w", err)
+	}
+	return out, nil
+}
+
+// movedSince is the part of after that a live write explains: slots that were
+// not in before, or whose live row has changed since before was taken.
+func movedSince(before, after []RebuildDiff) []RebuildDiff {
+	seen := make(map[string]string, len(before))
+	for _, d := range before {
+		seen[d.slot()] = d.liveSig
+	}
+	var out []RebuildDiff
+	for _, d := range after {
+		if sig, ok := seen[d.slot()]; !ok || sig != d.liveSig {
+			out = append(out, d)
+		}
+	}
+	return out
+}
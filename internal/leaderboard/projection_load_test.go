@@ -14,6 +14,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
 
+	"github.com/typemore/typemore-server/internal/leaderboard"
 	leaderboardpg "github.com/typemore/typemore-server/internal/leaderboard/pgstore"
 	"github.com/typemore/typemore-server/internal/perf"
 	"github.com/typemore/typemore-server/internal/replay"
@@ -577,12 +578,13 @@ func TestLoadPlanRebuildEnumerate(t *testing.T) {
 // TestLoadRebuild reports the rebuild that populated the fixture: wall time,
 // statements on the wire, cells per second.
 //
-// The budget is not "a maintenance command may take as long as it likes".
-// ClearLeaderboard is a TRUNCATE, which takes ACCESS EXCLUSIVE on
-// leaderboard_entries and holds it until the transaction commits — and every
-// read goes through a view over that table. The rebuild's wall time IS the
-// board's downtime, so the ceiling is what an operator may take the public
-// board offline for without scheduling a maintenance window.
+// The rebuild no longer takes the board offline: it builds into a shadow table
+// and swaps in the diff (docs/LEADERBOARDS.md, "Rebuild"), and the strongest
+// lock it takes on leaderboard_entries lets every reader through. Its wall time
+// is still how long a policy change takes to reach the boards, and for the
+// settle and the swap it is how long verdicts queue behind it — here the
+// fixture starts empty, so the whole board is the diff and that is the worst
+// case.
 func TestLoadRebuild(t *testing.T) {
 	f := loadFixture(t)
 
@@ -607,10 +609,10 @@ func TestLoadRebuild(t *testing.T) {
 		Zone:     zone4,
 		Workload: fmt.Sprintf("rebuild of %d cells from %d runs, email gate OFF", f.rebuild.Cells, f.seed.TotalRuns),
 		Limit:    60 * time.Second,
-		Rationale: "TRUNCATE holds ACCESS EXCLUSIVE on leaderboard_entries until the " +
-			"rebuild commits, and every read goes through a view over that table, so " +
-			"this number is board downtime. A minute is the most an operator should " +
-			"be able to take the public board offline without planning to.",
+		Rationale: "readers are served throughout, but a policy change is not on the " +
+			"boards until this finishes, and when the diff is the whole board the " +
+			"projection's writes wait on most of it. A minute is the most verdicts " +
+			"should queue behind an operator's command without planning for it.",
 	}.Assert(t, f.rebuildWall)
 
 	// What the production configuration would cost. This is arithmetic over two
@@ -631,7 +633,7 @@ func TestLoadRebuild(t *testing.T) {
 		f.eligibleRuns, gate.Round(time.Microsecond),
 		f.rebuild.Cells, gate.Round(time.Microsecond)))
 
-	assertRebuildBlocksReads(t, f)
+	assertRebuildServesReads(t, f)
 }
 
 // gateCost is the measured cost of one verified-email lookup, cached so the
@@ -657,39 +659,36 @@ var (
 	gateMeasured time.Duration
 )
 
-// assertRebuildBlocksReads demonstrates the claim the budget rests on: while a
-// rebuild's TRUNCATE is uncommitted, a board page cannot be served.
+// assertRebuildServesReads demonstrates the claim the budget rests on: while a
+// rebuild holds its lock on the live table, a board page is still served.
 //
-// It fakes only the TRUNCATE, not the whole rebuild — the lock is taken by the
-// first statement and held to commit, so a rolled-back one proves the same
-// thing in a second instead of in minutes.
-func assertRebuildBlocksReads(t *testing.T, f *fixture) {
+// It takes only the lock, not the whole rebuild — EXCLUSIVE is the strongest
+// lock the rebuild ever holds on leaderboard_entries, and it holds it to
+// commit, so a rolled-back one proves the same thing in a second instead of in
+// minutes. The original finding was the opposite: a TRUNCATE blocked this read
+// for the whole rebuild.
+func assertRebuildServesReads(t *testing.T, f *fixture) {
 	t.Helper()
 	ctx := context.Background()
 
 	tx, err := f.pool.Begin(ctx)
 	require.NoError(t, err)
-	_, err = tx.Exec(ctx, `TRUNCATE leaderboard_entries`)
+	_, err = tx.Exec(ctx, `LOCK TABLE leaderboard_entries IN EXCLUSIVE MODE`)
 	require.NoError(t, err)
 
 	const wait = 2 * time.Second
-	blocked, cancel := context.WithTimeout(ctx, wait)
+	bounded, cancel := context.WithTimeout(ctx, wait)
 	start := time.Now()
-	_, readErr := f.store.Page(blocked, f.hot, nil, pageLimit)
+	page, readErr := f.store.Page(bounded, f.hot, leaderboard.OrderScore, nil, pageLimit)
 	waited := time.Since(start)
 	cancel()
 
-	require.NoError(t, tx.Rollback(ctx), "the rollback is what puts the board back")
-	require.Error(t, readErr, "a page read during an uncommitted rebuild must not succeed")
-	require.GreaterOrEqual(t, waited, wait-100*time.Millisecond,
-		"the read should have waited on the lock, not failed for another reason: %v", readErr)
+	require.NoError(t, tx.Rollback(ctx))
+	require.NoError(t, readErr, "a page read while the rebuild holds its lock must be served")
+	require.NotEmpty(t, page, "and served the board, not an empty one")
 	perf.Report(t, zone4, "rebuild lock", fmt.Sprintf(
-		"a board page blocked for the whole %s it was given (TRUNCATE holds ACCESS EXCLUSIVE until commit): %v",
-		wait, readErr))
-
-	var after int64
-	require.NoError(t, f.pool.QueryRow(ctx, `SELECT count(*) FROM leaderboard_entries`).Scan(&after))
-	require.Equal(t, f.entries, after, "rolling the TRUNCATE back must restore the board")
+		"a board page was served in %s while the rebuild's EXCLUSIVE lock was held",
+		waited.Round(time.Microsecond)))
 }
 
 // --- worker throughput ---
@@ -774,7 +773,7 @@ func timeBatch(ctx context.Context, t *testing.T, f *fixture, q *replaypg.Queue,
 	t.Helper()
 	before := f.stmts.n.Load()
 	start := time.Now()
-	n, err := q.ProcessBatch(ctx, limit, decideAccepted)
+	n, err := q.ProcessBatch(ctx, limit, nil, decideAccepted)
 	took := time.Since(start)
 	require.NoError(t, err)
 	require.Equal(t, int(limit), n, "the queue ran dry")
@@ -17,9 +17,18 @@
 -- document, so an id that names nothing yields NULL here exactly as it does in
 -- the eligible view — one row, one primary-key probe, on a query that already
 -- runs once per verdict.
+--
+-- daily_day is the day the run declared itself an attempt at, as the run spells
+-- it (00037), or NULL. A run that has one belongs to a SECOND cell — that day's
+-- board — which RecomputeDailyCell maintains beside the first.
+--
+-- created_at is when the run was played: the windows it counts towards (this
+-- week, this month, the season) are the ones that instant falls in.
 SELECT r.user_id, r.mode, r.duration_ms, r.word_count, r.lang,
        run_text_source_kind(r.setup)::text AS text_source_kind,
-       q.id AS quote_id
+       q.id AS quote_id,
+       run_daily_day(r.setup) AS daily_day,
+       r.created_at
 FROM runs r
          LEFT JOIN quotes q ON q.id = run_quote_id(r.setup)
 WHERE r.id = @run_id;
@@ -138,17 +147,156 @@ WHERE (NOT @require_verified_email::boolean
 ORDER BY e.user_id, e.mode, e.duration_ms, e.word_count, e.lang,
          e.text_source_kind, e.quote_id;
 
--- name: ClearLeaderboard :exec
--- Rebuild step one. TRUNCATE is transactional in Postgres, so a failed rebuild
--- leaves the old board untouched rather than an empty one.
-TRUNCATE leaderboard_entries;
+-- name: RecomputeDailyCell :exec
+-- Set one player's cell on one day's board to their attempt, or clear it.
+-- RecomputeLeaderboardCell's statement in every respect that matters — the
+-- same recompute-not-upsert shape, the same verified-email gate correlated the
+-- same way, the same no-op when nothing changed — read from daily_eligible_runs
+-- instead of the language coordinates.
+--
+-- There is at most one candidate: runs_one_daily_attempt allows one attempt per
+-- player per day. The ORDER BY is there so that if that ever stops being true
+-- the FIRST attempt keeps the slot, which is the rule the index enforces.
+--
+-- daily_day is written here and only here; it is what keeps this row out of
+-- the ordinary one-slot-per-run index and out of the PB cards (00037).
+WITH best AS (
+    SELECT e.*
+    FROM daily_eligible_runs e
+    WHERE e.user_id = @user_id
+      AND e.day = @day::date
+      AND (NOT @require_verified_email::boolean
+           OR EXISTS (SELECT 1 FROM auth_identities ai
+                      WHERE ai.user_id = @user_id AND ai.email_verified))
+    ORDER BY e.achieved_at ASC, e.run_id ASC
+    LIMIT 1
+),
+cleared AS (
+    DELETE FROM leaderboard_entries le
+    WHERE le.bucket_key = @bucket_key
+      AND le.user_id = @user_id
+      AND NOT EXISTS (SELECT 1 FROM best)
+)
+INSERT INTO leaderboard_entries
+    (bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods, achieved_at,
+     quote_source, daily_day)
+SELECT @bucket_key, b.user_id, b.run_id, b.score, b.wpm, b.raw, b.acc,
+       run_grade(b.acc), b.mods, b.achieved_at, q.source, b.day
+FROM best b
+         LEFT JOIN quotes q ON q.id = b.quote_id
+ON CONFLICT (bucket_key, user_id) DO UPDATE
+    SET run_id       = EXCLUDED.run_id,
+        score        = EXCLUDED.score,
+        wpm          = EXCLUDED.wpm,
+        raw          = EXCLUDED.raw,
+        acc          = EXCLUDED.acc,
+        grade        = EXCLUDED.grade,
+        mods         = EXCLUDED.mods,
+        achieved_at  = EXCLUDED.achieved_at,
+        quote_source = EXCLUDED.quote_source,
+        daily_day    = EXCLUDED.daily_day
+    WHERE leaderboard_entries.run_id <> EXCLUDED.run_id;
+
+-- name: EnumerateDailyCells :many
+-- Every (player, day) cell that currently has an eligible attempt — the daily
+-- half of the rebuild's walk, under the same gate as EnumerateLeaderboardCells.
+SELECT DISTINCT e.user_id, e.day
+FROM daily_eligible_runs e
+WHERE (NOT @require_verified_email::boolean
+       OR EXISTS (SELECT 1 FROM auth_identities ai
+                  WHERE ai.user_id = e.user_id AND ai.email_verified))
+ORDER BY e.day, e.user_id;
+
+-- name: RecomputePeriodCell :exec
+-- Set one player's cell on one WINDOWED board — a language or quote board read
+-- over [starts_at, ends_at) — to their best eligible run played inside the
+-- window, or clear it. RecomputeLeaderboardCell's statement with the window
+-- added to the candidate filter and `period` written: the same sibling rule,
+-- the same verified-email gate correlated the same way, the same no-op on an
+-- unchanged best. A windowed board is the all-time board with fewer runs in
+-- it, and sharing the statement is what keeps that true.
+--
+-- The window is on achieved_at, which the eligible view takes from
+-- runs.created_at: a run counts towards the week it was PLAYED in, whenever
+-- its verdict lands.
+WITH best AS (
+    SELECT e.*
+    FROM leaderboard_eligible_runs e
+    WHERE e.user_id = @user_id
+      AND e.quote_id IS NOT DISTINCT FROM sqlc.narg(quote_id)::uuid
+      AND (e.quote_id IS NOT NULL
+           OR (e.mode = @mode
+               AND e.duration_ms IS NOT DISTINCT FROM sqlc.narg(duration_ms)::int
+               AND e.word_count IS NOT DISTINCT FROM sqlc.narg(word_count)::int
+               AND e.lang = @lang
+               AND e.text_source_kind = @text_source_kind))
+      AND e.achieved_at >= @starts_at
+      AND e.achieved_at < @ends_at
+      AND (NOT @require_verified_email::boolean
+           OR EXISTS (SELECT 1 FROM auth_identities ai
+                      WHERE ai.user_id = @user_id AND ai.email_verified))
+    ORDER BY e.score DESC, e.achieved_at ASC, e.run_id ASC
+    LIMIT 1
+),
+cleared AS (
+    DELETE FROM leaderboard_entries le
+    WHERE le.bucket_key = @bucket_key
+      AND le.user_id = @user_id
+      AND NOT EXISTS (SELECT 1 FROM best)
+)
+INSERT INTO leaderboard_entries
+    (bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods, achieved_at,
+     quote_source, period)
+SELECT @bucket_key, b.user_id, b.run_id, b.score, b.wpm, b.raw, b.acc,
+       run_grade(b.acc), b.mods, b.achieved_at, q.source, @period::text
+FROM best b
+         LEFT JOIN quotes q ON q.id = b.quote_id
+ON CONFLICT (bucket_key, user_id) DO UPDATE
+    SET run_id       = EXCLUDED.run_id,
+        score        = EXCLUDED.score,
+        wpm          = EXCLUDED.wpm,
+        raw          = EXCLUDED.raw,
+        acc          = EXCLUDED.acc,
+        grade        = EXCLUDED.grade,
+        mods         = EXCLUDED.mods,
+        achieved_at  = EXCLUDED.achieved_at,
+        quote_source = EXCLUDED.quote_source,
+        period       = EXCLUDED.period
+    WHERE leaderboard_entries.run_id <> EXCLUDED.run_id;
+
+-- name: EnumeratePeriodCells :many
+-- EnumerateLeaderboardCells narrowed to runs played inside one window — the
+-- windowed half of the rebuild's walk.
+SELECT DISTINCT e.user_id, e.mode, e.duration_ms, e.word_count, e.lang,
+                e.text_source_kind, e.quote_id
+FROM leaderboard_eligible_runs e
+WHERE e.achieved_at >= @starts_at
+  AND e.achieved_at < @ends_at
+  AND (NOT @require_verified_email::boolean
+       OR EXISTS (SELECT 1 FROM auth_identities ai
+                  WHERE ai.user_id = e.user_id AND ai.email_verified))
+ORDER BY e.user_id, e.mode, e.duration_ms, e.word_count, e.lang,
+         e.text_source_kind, e.quote_id;
+
+-- name: ListLivePeriods :many
+-- Every window that still has live rows: the freezer's worklist, and what the
+-- rebuild must put back.
+SELECT DISTINCT period::text AS period
+FROM leaderboard_entries
+WHERE period IS NOT NULL
+ORDER BY period;
 
 -- name: CountLeaderboardEntries :one
-SELECT count(*) FROM leaderboard_entries;
+-- Every live entry, or one board's when a key is given: the rebuild's before
+-- count, over whatever it was asked to rebuild.
+SELECT count(*) FROM leaderboard_entries
+WHERE sqlc.narg(bucket_key)::text IS NULL OR bucket_key = sqlc.narg(bucket_key)::text;
 
 -- name: ListLeaderboardBuckets :many
--- The board index. Counts are ban-filtered like every other read, so a bucket
--- whose only player is banned reports zero rather than one.
+-- Every board and its visible count, by a scan over every entry: the board
+-- index before leaderboard_boards (00044), and still the reference its maintained
+-- counts are checked against. Counts are ban-filtered like every other read, so a
+-- bucket whose only player is banned reports zero rather than one.
 --
 -- Quote boards are in here alongside the language ones, and they are the reason
 -- this result is no longer bounded by the schema — see docs/LEADERBOARDS.md.
@@ -157,6 +305,102 @@ FROM leaderboard_ranked
 GROUP BY bucket_key
 ORDER BY bucket_key;
 
+-- name: ListLeaderboardBoards :many
+-- The board index in key order, one page of it (00044). It reads
+-- leaderboard_boards, one row per board, where the catalogue above reads every
+-- entry; the counts there are maintained, and bans are taken off them here.
+--
+-- `hidden` is the banned players' share of each board: their entries, through
+-- leaderboard_entries_user_idx. It is as many rows as banned players have
+-- boards, which is small, and it is what lets a ban stay a read-time rule
+-- (see "Why bans are filtered on read") without a count to keep per ban.
+--
+-- Every filter is optional and ANDs with the rest. A withdrawn quote's board
+-- is left out here and nowhere else (docs/REPORTS.md). Filtering in SQL keeps
+-- the page full: a page trimmed after LIMIT is one a client has to ask again.
+WITH hidden AS (
+    SELECT e.bucket_key, count(*) AS entries
+    FROM leaderboard_entries e
+    WHERE e.period IS NULL
+      AND e.user_id IN (SELECT user_id FROM active_bans)
+    GROUP BY e.bucket_key
+), boards AS (
+    SELECT b.bucket_key, b.mode, b.lang, b.text_source, b.quote_id, b.len_group,
+           b.changed_at, b.entries - coalesce(h.entries, 0) AS entries
+    FROM leaderboard_boards b
+             LEFT JOIN hidden h ON h.bucket_key = b.bucket_key
+)
+SELECT bucket_key, lang, len_group, changed_at, entries::bigint AS entries
+FROM boards
+WHERE entries >= greatest(@min_entries::bigint, 1)
+  AND (sqlc.narg(mode)::text IS NULL OR mode = sqlc.narg(mode)::text)
+  AND (sqlc.narg(lang)::text IS NULL OR lang = sqlc.narg(lang)::text)
+  AND (sqlc.narg(text_source)::text IS NULL OR text_source = sqlc.narg(text_source)::text)
+  AND (sqlc.narg(len_group)::smallint IS NULL OR len_group = sqlc.narg(len_group)::smallint)
+  AND (quote_id IS NULL OR quote_id <> ALL (@withdrawn::uuid[]))
+  AND (sqlc.narg(after_key)::text IS NULL OR bucket_key > sqlc.narg(after_key)::text)
+ORDER BY bucket_key
+LIMIT @row_limit;
+
+-- name: ListLeaderboardBoardsByEntries :many
+-- ListLeaderboardBoards with the biggest boards first, the key breaking a tie.
+-- The order is on the VISIBLE count, so it has to be sorted after the ban
+-- correction; with one row per board that sort is cheap.
+WITH hidden AS (
+    SELECT e.bucket_key, count(*) AS entries
+    FROM leaderboard_entries e
+    WHERE e.period IS NULL
+      AND e.user_id IN (SELECT user_id FROM active_bans)
+    GROUP BY e.bucket_key
+), boards AS (
+    SELECT b.bucket_key, b.mode, b.lang, b.text_source, b.quote_id, b.len_group,
+           b.changed_at, b.entries - coalesce(h.entries, 0) AS entries
+    FROM leaderboard_boards b
+             LEFT JOIN hidden h ON h.bucket_key = b.bucket_key
+)
+SELECT bucket_key, lang, len_group, changed_at, entries::bigint AS entries
+FROM boards
+WHERE entries >= greatest(@min_entries::bigint, 1)
+  AND (sqlc.narg(mode)::text IS NULL OR mode = sqlc.narg(mode)::text)
+  AND (sqlc.narg(lang)::text IS NULL OR lang = sqlc.narg(lang)::text)
+  AND (sqlc.narg(text_source)::text IS NULL OR text_source = sqlc.narg(text_source)::text)
+  AND (sqlc.narg(len_group)::smallint IS NULL OR len_group = sqlc.narg(len_group)::smallint)
+  AND (quote_id IS NULL OR quote_id <> ALL (@withdrawn::uuid[]))
+  AND (sqlc.narg(after_key)::text IS NULL
+       OR entries < @after_entries::bigint
+       OR (entries = @after_entries::bigint AND bucket_key > sqlc.narg(after_key)::text))
+ORDER BY entries DESC, bucket_key
+LIMIT @row_limit;
+
+-- name: ListLeaderboardBoardsByRecent :many
+-- ListLeaderboardBoards with the most recently changed board first, the key
+-- breaking a tie.
+WITH hidden AS (
+    SELECT e.bucket_key, count(*) AS entries
+    FROM leaderboard_entries e
+    WHERE e.period IS NULL
+      AND e.user_id IN (SELECT user_id FROM active_bans)
+    GROUP BY e.bucket_key
+), boards AS (
+    SELECT b.bucket_key, b.mode, b.lang, b.text_source, b.quote_id, b.len_group,
+           b.changed_at, b.entries - coalesce(h.entries, 0) AS entries
+    FROM leaderboard_boards b
+             LEFT JOIN hidden h ON h.bucket_key = b.bucket_key
+)
+SELECT bucket_key, lang, len_group, changed_at, entries::bigint AS entries
+FROM boards
+WHERE entries >= greatest(@min_entries::bigint, 1)
+  AND (sqlc.narg(mode)::text IS NULL OR mode = sqlc.narg(mode)::text)
+  AND (sqlc.narg(lang)::text IS NULL OR lang = sqlc.narg(lang)::text)
+  AND (sqlc.narg(text_source)::text IS NULL OR text_source = sqlc.narg(text_source)::text)
+  AND (sqlc.narg(len_group)::smallint IS NULL OR len_group = sqlc.narg(len_group)::smallint)
+  AND (quote_id IS NULL OR quote_id <> ALL (@withdrawn::uuid[]))
+  AND (sqlc.narg(after_key)::text IS NULL
+       OR changed_at < @after_changed_at::timestamptz
+       OR (changed_at = @after_changed_at::timestamptz AND bucket_key > sqlc.narg(after_key)::text))
+ORDER BY changed_at DESC, bucket_key
+LIMIT @row_limit;
+
 -- name: ListLeaderboardPageFirst :many
 -- Page one: best score first, earliest achievement first on a tie, user_id as
 -- the final tiebreak so the order is total (and therefore pageable).
@@ -241,3 +485,435 @@ SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
        achieved_at, quote_source
 FROM leaderboard_rows
 WHERE bucket_key = @bucket_key AND user_id = @user_id;
+
+-- name: ListLeaderboardWPMPageFirst :many
+-- Page one of the WPM order (00036): fastest first, then the score order's own
+-- tie rule. The rows are the same entries the score order ranks — each one
+-- still the player's best-SCORING run here — read off leaderboard_wpm_idx.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT @row_limit;
+
+-- name: ListLeaderboardWPMPageAfter :many
+-- The WPM keyset continuation. Same shape as ListLeaderboardPageAfter with wpm
+-- in sort_key's place: `wpm <= cursor` is the start condition, and the second
+-- clause only orders the rows that share the cursor's exact wpm.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND wpm <= @wpm::numeric
+  AND (wpm < @wpm::numeric
+       OR achieved_at > @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id > @user_id::uuid))
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT @row_limit;
+
+-- name: ListLeaderboardWPMPageBefore :many
+-- The WPM continuation upward, nearest first: a backward scan of
+-- leaderboard_wpm_idx, re-reversed by the caller exactly as for the score order.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND wpm >= @wpm::numeric
+  AND (wpm > @wpm::numeric
+       OR achieved_at < @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid))
+ORDER BY wpm ASC, achieved_at DESC, user_id DESC
+LIMIT @row_limit;
+
+-- name: CountLeaderboardWPMAbove :one
+-- How many visible entries outrank this position in the WPM order. Reads
+-- leaderboard_ranked for the same reason CountLeaderboardAbove does, and
+-- leaderboard_wpm_idx covers it the same way.
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = @bucket_key
+  AND wpm >= @wpm::numeric
+  AND (wpm > @wpm::numeric
+       OR achieved_at < @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid));
+
+-- name: SeasonAt :one
+-- The season running at an instant, if any. Seasons never overlap
+-- (leaderboard_seasons_no_overlap), so this is at most one row.
+SELECT id, name, starts_at, ends_at
+FROM leaderboard_seasons
+WHERE starts_at <= @at AND @at < ends_at;
+
+-- name: GetSeason :one
+SELECT id, name, starts_at, ends_at
+FROM leaderboard_seasons
+WHERE id = @id;
+
+-- name: ListSeasons :many
+SELECT id, name, starts_at, ends_at
+FROM leaderboard_seasons
+ORDER BY starts_at;
+
+-- name: CreateSeason :exec
+INSERT INTO leaderboard_seasons (id, name, starts_at, ends_at)
+VALUES (@id, @name, @starts_at, @ends_at);
+
+-- name: FreezePeriod :execrows
+-- Freezing, step one: claim the window. Zero rows means another freezer (or an
+-- earlier pass) already archived it, and what is left live is a straggler to
+-- delete, not a second set of standings.
+INSERT INTO leaderboard_periods (id, kind, name, starts_at, ends_at, frozen_at)
+VALUES (@id, @kind, sqlc.narg(name), @starts_at, @ends_at, @frozen_at)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: SnapshotPeriod :execrows
+-- Freezing, step two: copy every board of the window into the archive, ranked
+-- the way its live page ranked it (sort_key, then achieved_at, then user_id)
+-- and filtered by the same active_bans predicate the live reads go through —
+-- the standings frozen are the standings a reader could see at the close.
+INSERT INTO leaderboard_snapshots
+    (period_id, bucket_key, rank, user_id, run_id, score, wpm, raw, acc, grade,
+     mods, achieved_at, quote_source)
+SELECT e.period, e.bucket_key,
+       row_number() OVER (PARTITION BY e.bucket_key
+                          ORDER BY e.sort_key DESC, e.achieved_at ASC, e.user_id ASC),
+       e.user_id, e.run_id, e.score, e.wpm, e.raw, e.acc, e.grade, e.mods,
+       e.achieved_at, e.quote_source
+FROM leaderboard_entries e
+WHERE e.period = @period
+  AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = e.user_id);
+
+-- name: DeletePeriodEntries :execrows
+-- Freezing, step three: the live boards of the window go. From here the
+-- archive is the only copy.
+DELETE FROM leaderboard_entries WHERE period = @period;
+
+-- name: GetFrozenPeriod :one
+SELECT id, kind, name, starts_at, ends_at, frozen_at
+FROM leaderboard_periods
+WHERE id = @id;
+
+-- name: ListSnapshotPage :many
+-- One page of a frozen board, by its frozen rank. A player banned since the
+-- freeze is hidden like on every other board, and their rank is left as a gap
+-- rather than closed: the standings are history, and closing it would move
+-- every player below them off the place they finished in.
+SELECT s.rank, s.user_id, u.display_name, s.run_id, s.score, s.wpm, s.raw,
+       s.acc, s.grade, s.mods, s.achieved_at, s.quote_source
+FROM leaderboard_snapshots s
+         JOIN users u ON u.id = s.user_id
+WHERE s.bucket_key = @bucket_key
+  AND s.rank > @after_rank
+  AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = s.user_id)
+ORDER BY s.rank
+LIMIT @row_limit;
+
+-- name: MarkRankDirty :exec
+-- The projection's whole share of rank history (00040): this board's ranks may
+-- have moved. A board already marked is left alone, so a verdict on a busy
+-- board costs one index probe and no write.
+INSERT INTO leaderboard_rank_dirty (bucket_key)
+VALUES (@bucket_key)
+ON CONFLICT (bucket_key) DO NOTHING;
+
+-- name: ClaimRankDirty :one
+-- Take one marked board to snapshot, marked no later than @marked_before — the
+-- start of the pass, so a board the projection keeps re-marking cannot keep
+-- one pass going. The delete is the claim: SKIP LOCKED hands concurrent
+-- snapshotters different boards, and a snapshot that fails rolls the mark
+-- back with it. A verdict that re-marks the board meanwhile waits on this row
+-- and lands after the commit, so the next pass sees it.
+DELETE FROM leaderboard_rank_dirty
+WHERE bucket_key = (SELECT d.bucket_key FROM leaderboard_rank_dirty d
+                    WHERE d.dirtied_at <= @marked_before
+                    ORDER BY d.dirtied_at, d.bucket_key
+                    LIMIT 1
+                    FOR UPDATE SKIP LOCKED)
+RETURNING bucket_key;
+
+-- name: SnapshotRanks :execrows
+-- One point for every visible player on a board whose rank or score is not
+-- what their latest point says. The rank is the score order's, counted the
+-- way CountLeaderboardAbove counts it — the ordering is total, so a
+-- row_number over it is exactly "players above you, plus one" and a chart
+-- never disagrees with /me about the same instant.
+INSERT INTO leaderboard_rank_history (bucket_key, user_id, taken_at, rank, score)
+SELECT r.bucket_key, r.user_id, @taken_at, r.rank, r.score
+FROM (SELECT bucket_key, user_id, score,
+             row_number() OVER (ORDER BY sort_key DESC, achieved_at ASC, user_id ASC) AS rank
+      FROM leaderboard_rows
+      WHERE bucket_key = @bucket_key) r
+         LEFT JOIN LATERAL (
+    SELECT h.rank, h.score
+    FROM leaderboard_rank_history h
+    WHERE h.bucket_key = r.bucket_key AND h.user_id = r.user_id
+    ORDER BY h.taken_at DESC
+    LIMIT 1) last ON true
+WHERE (last.rank, last.score) IS DISTINCT FROM (r.rank, r.score)
+ON CONFLICT (bucket_key, user_id, taken_at) DO UPDATE
+    SET rank  = EXCLUDED.rank,
+        score = EXCLUDED.score;
+
+-- name: ListRankHistory :many
+-- A player's points on one board, newest first.
+SELECT taken_at, rank, score
+FROM leaderboard_rank_history
+WHERE bucket_key = @bucket_key AND user_id = @user_id
+ORDER BY taken_at DESC
+LIMIT @row_limit;
+
+-- name: GetRankSeen :one
+SELECT rank, seen_at
+FROM leaderboard_rank_seen
+WHERE user_id = @user_id AND bucket_key = @bucket_key;
+
+-- name: MarkRankSeen :exec
+INSERT INTO leaderboard_rank_seen (user_id, bucket_key, rank, seen_at)
+VALUES (@user_id, @bucket_key, @rank, @seen_at)
+ON CONFLICT (user_id, bucket_key) DO UPDATE
+    SET rank    = EXCLUDED.rank,
+        seen_at = EXCLUDED.seen_at;
+
+-- name: ClearRankLadder :exec
+DELETE FROM leaderboard_rank_ladder
+WHERE bucket_key = @bucket_key;
+
+-- name: BuildRankLadder :execrows
+-- Every @step-th row of one board in each order, plus its last row, with the
+-- count of visible entries at or above it (00041). count(*) over the default
+-- RANGE frame takes in the row's whole tie, which is what the lookups below
+-- subtract from; row_number picks the rungs, so a long tie cannot stretch the
+-- gap between two of them. Rows of one tie share a key: the first one in wins
+-- and the rest are the same rung.
+INSERT INTO leaderboard_rank_ladder (bucket_key, rank_order, key, at_or_above)
+SELECT s.bucket_key, 'score', s.sort_key, s.at_or_above
+FROM (SELECT bucket_key, sort_key,
+             row_number() OVER (ORDER BY sort_key DESC) AS n,
+             count(*) OVER (ORDER BY sort_key DESC)     AS at_or_above,
+             count(*) OVER ()                           AS entries
+      FROM leaderboard_ranked
+      WHERE bucket_key = @bucket_key) s
+WHERE s.n % @step::bigint = 0 OR s.n = s.entries
+UNION ALL
+SELECT w.bucket_key, 'wpm', w.wpm, w.at_or_above
+FROM (SELECT bucket_key, wpm,
+             row_number() OVER (ORDER BY wpm DESC) AS n,
+             count(*) OVER (ORDER BY wpm DESC)     AS at_or_above,
+             count(*) OVER ()                      AS entries
+      FROM leaderboard_ranked
+      WHERE bucket_key = @bucket_key) w
+WHERE w.n % @step::bigint = 0 OR w.n = w.entries
+ON CONFLICT (bucket_key, rank_order, key) DO NOTHING;
+
+-- name: CountLeaderboardAboveByLadder :one
+-- CountLeaderboardAbove's answer, read off the ladder: the nearest rung
+-- strictly above the position, plus a live count of the rows between the two.
+-- The live part is CountLeaderboardAbove's own predicate with the rung as a
+-- ceiling, so it is the same index range scan cut to one rung's length.
+--
+-- With no rung above — the position is near the top, or the board has not
+-- been laddered yet — the ceiling is lifted and the count is the plain one,
+-- which near the top is short anyway. entries is the ladder's last rung, or a
+-- live count for a board that has none.
+WITH rung AS (
+    SELECT l.key::bigint AS key, l.at_or_above
+    FROM leaderboard_rank_ladder l
+    WHERE l.bucket_key = @bucket_key
+      AND l.rank_order = 'score'
+      AND l.key > leaderboard_sort_key(@score, @achieved_at)
+    ORDER BY l.key
+    LIMIT 1
+)
+SELECT (coalesce((SELECT at_or_above FROM rung), 0)
+        + (SELECT count(*)
+           FROM leaderboard_ranked
+           WHERE bucket_key = @bucket_key
+             AND sort_key < coalesce((SELECT key FROM rung), 9223372036854775807)
+             AND sort_key >= leaderboard_sort_key(@score, @achieved_at)
+             AND (sort_key > leaderboard_sort_key(@score, @achieved_at)
+                  OR achieved_at < @achieved_at::timestamptz
+                  OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid))))::bigint AS above,
+       coalesce((SELECT max(at_or_above) FROM leaderboard_rank_ladder
+                 WHERE bucket_key = @bucket_key AND rank_order = 'score'),
+                (SELECT count(*) FROM leaderboard_ranked WHERE bucket_key = @bucket_key))::bigint AS entries;
+
+-- name: CountLeaderboardWPMAboveByLadder :one
+-- CountLeaderboardAboveByLadder for the WPM order, over the wpm rungs and
+-- CountLeaderboardWPMAbove's predicate.
+WITH rung AS (
+    SELECT l.key, l.at_or_above
+    FROM leaderboard_rank_ladder l
+    WHERE l.bucket_key = @bucket_key
+      AND l.rank_order = 'wpm'
+      AND l.key > @wpm::numeric
+    ORDER BY l.key
+    LIMIT 1
+)
+SELECT (coalesce((SELECT at_or_above FROM rung), 0)
+        + (SELECT count(*)
+           FROM leaderboard_ranked
+           WHERE bucket_key = @bucket_key
+             AND wpm < coalesce((SELECT key FROM rung), 'Infinity'::numeric)
+             AND wpm >= @wpm::numeric
+             AND (wpm > @wpm::numeric
+                  OR achieved_at < @achieved_at::timestamptz
+                  OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid))))::bigint AS above,
+       coalesce((SELECT max(at_or_above) FROM leaderboard_rank_ladder
+                 WHERE bucket_key = @bucket_key AND rank_order = 'wpm'),
+                (SELECT count(*) FROM leaderboard_ranked WHERE bucket_key = @bucket_key))::bigint AS entries;
+
+-- name: EligibleRunPosition :one
+-- Where one run would sit if it held its slot: its cell's coordinates and its
+-- key in the score order. Read off the eligible view, so a run that is not
+-- accepted, or not rankable at all, has no row — and no standing.
+SELECT user_id, mode, duration_ms, word_count, lang,
+       text_source_kind::text AS text_source_kind, quote_id, score, wpm,
+       achieved_at
+FROM leaderboard_eligible_runs
+WHERE run_id = @run_id;
+
+-- Following (00042). The same reads as above, over one viewer's circle — the
+-- viewer and the open profiles they follow — instead of the whole board. The
+-- rows and the order are the board's own; what changes is who is counted.
+--
+-- The circle is capped at the follow limit plus one, so each of these is a
+-- primary-key probe per member and a sort of at most that many rows, at any
+-- board size. None of them needs an index of its own.
+
+-- name: ListFollowingPageFirst :many
+-- Page one of a circle's board, in the score order.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
+LIMIT @row_limit;
+
+-- name: ListFollowingPageAfter :many
+-- The downward continuation of a circle's board: ListLeaderboardPageAfter's
+-- predicate, over the circle.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+  AND sort_key <= leaderboard_sort_key(@score, @achieved_at)
+  AND (sort_key < leaderboard_sort_key(@score, @achieved_at)
+       OR achieved_at > @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id > @user_id::uuid))
+ORDER BY sort_key DESC, achieved_at ASC, user_id ASC
+LIMIT @row_limit;
+
+-- name: ListFollowingPageBefore :many
+-- The upward continuation of a circle's board, nearest first.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+  AND sort_key >= leaderboard_sort_key(@score, @achieved_at)
+  AND (sort_key > leaderboard_sort_key(@score, @achieved_at)
+       OR achieved_at < @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid))
+ORDER BY sort_key ASC, achieved_at DESC, user_id DESC
+LIMIT @row_limit;
+
+-- name: CountFollowingAbove :one
+-- How many of the circle outrank this position in the score order.
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+  AND sort_key >= leaderboard_sort_key(@score, @achieved_at)
+  AND (sort_key > leaderboard_sort_key(@score, @achieved_at)
+       OR achieved_at < @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid));
+
+-- name: ListFollowingWPMPageFirst :many
+-- Page one of a circle's board, in the WPM order.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT @row_limit;
+
+-- name: ListFollowingWPMPageAfter :many
+-- The downward WPM continuation of a circle's board.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+  AND wpm <= @wpm::numeric
+  AND (wpm < @wpm::numeric
+       OR achieved_at > @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id > @user_id::uuid))
+ORDER BY wpm DESC, achieved_at ASC, user_id ASC
+LIMIT @row_limit;
+
+-- name: ListFollowingWPMPageBefore :many
+-- The upward WPM continuation of a circle's board, nearest first.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+  AND wpm >= @wpm::numeric
+  AND (wpm > @wpm::numeric
+       OR achieved_at < @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid))
+ORDER BY wpm ASC, achieved_at DESC, user_id DESC
+LIMIT @row_limit;
+
+-- name: CountFollowingWPMAbove :one
+-- How many of the circle outrank this position in the WPM order.
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)
+  AND wpm >= @wpm::numeric
+  AND (wpm > @wpm::numeric
+       OR achieved_at < @achieved_at::timestamptz
+       OR (achieved_at = @achieved_at::timestamptz AND user_id < @user_id::uuid));
+
+-- name: CountFollowingEntries :one
+-- The size of a circle's board: the members with a visible entry on it.
+SELECT count(*)::bigint
+FROM leaderboard_ranked
+WHERE bucket_key = @bucket_key
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c);
+
+-- name: GetFollowingEntry :one
+-- One player's entry on a circle's board: none for a player outside the
+-- circle, exactly as none for a banned one.
+SELECT user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM leaderboard_rows
+WHERE bucket_key = @bucket_key
+  AND user_id = @user_id
+  AND user_id IN (SELECT c.user_id FROM following_circle(@viewer) c);
+
+-- name: ListFollowingSnapshotPage :many
+-- One page of a circle's share of a frozen board, renumbered within the
+-- circle by the order the board froze in. The circle is today's, not the one
+-- at the freeze — it is the viewer's question about players they follow now.
+--
+-- The numbering is taken BEFORE the ban filter, for the reason the global
+-- read leaves a banned player's rank as a gap: the standings are history.
+SELECT rank, user_id, display_name, run_id, score, wpm, raw, acc, grade, mods,
+       achieved_at, quote_source
+FROM (SELECT row_number() OVER (ORDER BY s.rank)::bigint AS rank,
+             s.user_id, u.display_name, s.run_id, s.score, s.wpm, s.raw,
+             s.acc, s.grade, s.mods, s.achieved_at, s.quote_source
+      FROM leaderboard_snapshots s
+               JOIN users u ON u.id = s.user_id
+      WHERE s.bucket_key = @bucket_key
+        AND s.user_id IN (SELECT c.user_id FROM following_circle(@viewer) c)) circle
+WHERE rank > @after_rank
+  AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = circle.user_id)
+ORDER BY rank
+LIMIT @row_limit;
@@ -37,7 +37,7 @@ func TestQuoteRunLandsOnItsQuoteBoard(t *testing.T) {
 	topRun := b.addRun(runSpec{user: fastest, quote: quoteID, score: 2000, achievedAt: minutesAgo(30)})
 	b.addRun(runSpec{user: slower, quote: quoteID, score: 900, achievedAt: minutesAgo(20)})
 
-	rows, err := b.store.Page(ctx, board, nil, 10)
+	rows, err := b.store.Page(ctx, board, leaderboard.OrderScore, nil, 10)
 	require.NoError(t, err)
 	require.Len(t, rows, 2)
 
@@ -53,7 +53,7 @@ func TestQuoteRunLandsOnItsQuoteBoard(t *testing.T) {
 	})
 
 	t.Run("rank comes back through the /me path too", func(t *testing.T) {
-		entry, err := b.store.EntryFor(ctx, board, slower)
+		entry, err := b.store.EntryFor(ctx, board, leaderboard.OrderScore, slower)
 		require.NoError(t, err)
 		assert.EqualValues(t, 2, entry.Rank)
 		assert.Equal(t, "Aesop", entry.Source)
@@ -195,7 +195,7 @@ func TestQuoteBoardOrderingAndPaging(t *testing.T) {
 		})
 	}
 
-	first, err := b.store.Page(ctx, board, nil, 2)
+	first, err := b.store.Page(ctx, board, leaderboard.OrderScore, nil, 2)
 	require.NoError(t, err)
 	require.Len(t, first, 2)
 	assert.Equal(t, players[3].id, first[0].UserID, "earliest achievement wins the tie")
@@ -204,11 +204,11 @@ func TestQuoteBoardOrderingAndPaging(t *testing.T) {
 	cursor := leaderboard.Cursor{
 		Score: first[1].Score, AchievedAt: first[1].AchievedAt, UserID: first[1].UserID,
 	}
-	above, err := b.store.RankAbove(ctx, board, cursor)
+	above, err := b.store.RankAbove(ctx, board, leaderboard.OrderScore, cursor)
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, above, "the rank of the next page's first row is counted, not carried")
 
-	next, err := b.store.Page(ctx, board, &cursor, 2)
+	next, err := b.store.Page(ctx, board, leaderboard.OrderScore, &cursor, 2)
 	require.NoError(t, err)
 	require.Len(t, next, 2)
 	assert.Equal(t, players[4].id, next[0].UserID)
@@ -278,12 +278,12 @@ func TestQuoteBoardsHonourBansAndTheEmailGate(t *testing.T) {
 
 	b.ban(cheat, nil)
 
-	rows, err := b.store.Page(ctx, board, nil, 10)
+	rows, err := b.store.Page(ctx, board, leaderboard.OrderScore, nil, 10)
 	require.NoError(t, err)
 	require.Len(t, rows, 1, "a banned player must be hidden on a quote board too")
 	assert.Equal(t, honest, rows[0].UserID)
 
-	_, err = b.store.EntryFor(ctx, board, cheat)
+	_, err = b.store.EntryFor(ctx, board, leaderboard.OrderScore, cheat)
 	assert.ErrorIs(t, err, leaderboard.ErrNoEntry)
 
 	_, kept := b.storedEntry(board, cheat)
@@ -291,8 +291,8 @@ func TestQuoteBoardsHonourBansAndTheEmailGate(t *testing.T) {
 }
 
 // The HTTP surface: a quote board is reachable by its key, renders its quote id
-// instead of a mode it does not have, and answers 404 for a key that names no
-// board.
+// and its quote's language instead of a mode it does not have, and answers 404
+// for a key that names no board.
 func TestQuoteBoardOverHTTP(t *testing.T) {
 	b := newBoard(t)
 
@@ -323,7 +323,8 @@ func TestQuoteBoardOverHTTP(t *testing.T) {
 		require.NotNil(t, got.QuoteID)
 		assert.Equal(t, quoteID, *got.QuoteID)
 		assert.Empty(t, got.Mode, "a quote board has no mode dimension")
-		assert.Empty(t, got.Lang, "a quote board has no language dimension")
+		assert.Equal(t, "english", got.Lang,
+			"a quote board is listed under its quote's language, which its key does not carry")
 		assert.Empty(t, got.TextSource, "a quote board is not a text-source flavour")
 		assert.Nil(t, got.DurationMs)
 		assert.Nil(t, got.WordCount)
@@ -0,0 +1,126 @@
+package leaderboard_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type historyBody struct {
+	Bucket    string `json:"bucket"`
+	Rank      *int64 `json:"rank"`
+	LastVisit *struct {
+		Rank int64 `json:"rank"`
+	} `json:"lastVisit"`
+	Moved  *int64 `json:"moved"`
+	Points []struct {
+		Rank  int64 `json:"rank"`
+		Score int64 `json:"score"`
+	} `json:"points"`
+}
+
+const history15s = "/api/v1/leaderboards/time:15000:en:seeded/me/history"
+
+// A snapshot records a point only for a player whose standing moved, and only
+// on a board the projection has marked since the last pass.
+func TestRankSnapshotsRecordOnlyChanges(t *testing.T) {
+	b := newBoard(t)
+	ctx := context.Background()
+	first := b.user("first", true)
+	second := b.user("second", true)
+	b.addRun(runSpec{user: first, score: 2000, achievedAt: minutesAgo(30)})
+	b.addRun(runSpec{user: second, score: 1000, achievedAt: minutesAgo(20)})
+
+	stats, err := b.store.SnapshotRanks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Boards)
+	assert.EqualValues(t, 2, stats.Points)
+
+	again, err := b.store.SnapshotRanks(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, again.Boards, "nothing was written, so nothing is marked")
+
+	// second overtakes first: both ranks move, so both get a point.
+	b.addRun(runSpec{user: second, score: 3000, achievedAt: minutesAgo(10)})
+	stats, err = b.store.SnapshotRanks(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, stats.Points)
+
+	points, err := b.store.RankHistory(ctx, bucket15s(t), second, 10)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.EqualValues(t, 1, points[0].Rank, "newest first")
+	assert.EqualValues(t, 3000, points[0].Score)
+	assert.EqualValues(t, 2, points[1].Rank)
+}
+
+func TestRankHistoryEndpoint(t *testing.T) {
+	b := newBoard(t)
+	ctx := context.Background()
+	rival := b.user("rival", true)
+	me := b.user("mine", true)
+	b.addRun(runSpec{user: me, score: 2000, achievedAt: minutesAgo(30)})
+	b.addRun(runSpec{user: rival, score: 1000, achievedAt: minutesAgo(20)})
+	_, err := b.store.SnapshotRanks(ctx)
+	require.NoError(t, err)
+
+	t.Run("anonymous is 401", func(t *testing.T) {
+		b.asUser = uuid.Nil
+		assert.Equal(t, http.StatusUnauthorized, b.get(history15s).StatusCode)
+	})
+
+	t.Run("first visit has no movement to report", func(t *testing.T) {
+		b.asUser = me
+		resp := b.get(history15s)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body := decodeInto[historyBody](t, resp)
+		require.NotNil(t, body.Rank)
+		assert.EqualValues(t, 1, *body.Rank)
+		assert.Nil(t, body.LastVisit)
+		assert.Nil(t, body.Moved)
+		require.Len(t, body.Points, 1)
+	})
+
+	t.Run("being overtaken shows as a drop since the last visit", func(t *testing.T) {
+		b.addRun(runSpec{user: rival, score: 5000, achievedAt: minutesAgo(5)})
+		_, err := b.store.SnapshotRanks(ctx)
+		require.NoError(t, err)
+
+		b.asUser = me
+		body := decodeInto[historyBody](t, b.get(history15s))
+		require.NotNil(t, body.LastVisit)
+		assert.EqualValues(t, 1, body.LastVisit.Rank)
+		require.NotNil(t, body.Moved)
+		assert.EqualValues(t, -1, *body.Moved)
+		require.Len(t, body.Points, 2)
+		assert.EqualValues(t, 2, body.Points[0].Rank)
+
+		// The visit just made is now the baseline.
+		body = decodeInto[historyBody](t, b.get(history15s))
+		require.NotNil(t, body.Moved)
+		assert.Zero(t, *body.Moved)
+	})
+
+	t.Run("no entry still returns the chart", func(t *testing.T) {
+		b.asUser = b.user("newcomer", true)
+		resp := b.get(history15s)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body := decodeInto[historyBody](t, resp)
+		assert.Nil(t, body.Rank)
+		assert.Empty(t, body.Points)
+	})
+
+	for name, path := range map[string]string{
+		"a windowed board": "/api/v1/leaderboards/time:15000:en:seeded@2026-W10/me/history",
+		"a daily board":    "/api/v1/leaderboards/daily:2026-03-04/me/history",
+	} {
+		t.Run(name+" keeps no history", func(t *testing.T) {
+			b.asUser = me
+			assert.Equal(t, http.StatusNotFound, b.get(path).StatusCode)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package leaderboard
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// RankPoint is where a player stood on a board at one instant. Score is the
+// score their slot held then, so a chart can tell a rank they earned from one
+// somebody else's run handed them.
+type RankPoint struct {
+	At    time.Time
+	Rank  int64
+	Score int64
+}
+
+// HasRankHistory reports whether the board keeps rank history. Only the
+// all-time language and quote boards do: a day's board and a window's are
+// over before a trend on them means anything, and their final standings are
+// already kept by the freezer.
+func (b Bucket) HasRankHistory() bool { return !b.IsDaily() && !b.IsWindowed() }
+
+// Standing is where a position sits on a board: the rank it holds or would
+// take, out of how many, and that as a share — the "top 7%" a results screen
+// shows.
+type Standing struct {
+	Rank int64
+	// Entries is the board's size as of the last snapshot pass (or counted
+	// live, for a board that has not had one).
+	Entries int64
+	// Percentile is Rank as a percentage of Entries, rounded UP to two
+	// places: rank 1 of 20 000 is the top 0.01%, never the top 0%.
+	Percentile float64
+}
+
+// StandingOf turns a count of the entries outranking a position into its
+// Standing.
+//
+// Entries comes from the ladder and can trail the board by one snapshot
+// interval, so a board that grew since can put a rank past it; entries is
+// raised to the rank rather than reporting the bottom of a board as the top
+// 104%.
+func StandingOf(above, entries int64) Standing {
+	rank := above + 1
+	entries = max(entries, rank)
+	return Standing{
+		Rank:       rank,
+		Entries:    entries,
+		Percentile: math.Ceil(float64(rank)*10_000/float64(entries)) / 100,
+	}
+}
+
+// RankSnapshotStats is what one snapshot pass did.
+type RankSnapshotStats struct {
+	// Boards is how many marked boards were snapshotted.
+	Boards int
+	// Points is how many rank points they produced: one per player whose rank
+	// or score had moved since their last point.
+	Points int64
+	// Rungs is how many rank ladder rows those boards were rebuilt with.
+	Rungs int64
+}
+
+// RankSnapshotter records rank points, and rebuilds the rank ladder, for the
+// boards the projection has written since the last pass. The Postgres store implements it; declared
+// here, at the consumer, like Freezer.
+type RankSnapshotter interface {
+	SnapshotRanks(ctx context.Context) (RankSnapshotStats, error)
+}
+
+// RunRankSnapshots snapshots marked boards once immediately and then every
+// interval, until ctx is cancelled. Started as a goroutine from the
+// composition root.
+//
+// The interval is the resolution of every player's chart: a rank that moved
+// and moved back between two passes was never recorded. That is the trade —
+// the projection marks a board in the verdict's transaction and counts
+// nothing, and the counting happens here, once per board per pass, however
+// many verdicts landed on it.
+func RunRankSnapshots(ctx context.Context, s RankSnapshotter, interval time.Duration, log *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stats, err := s.SnapshotRanks(ctx)
+		switch {
+		case ctx.Err() != nil:
+		case err != nil:
+			// A board whose snapshot failed keeps its mark and is retried on
+			// the next tick; a chart one interval coarser is not worth a crash.
+			log.ErrorContext(ctx, "leaderboard: rank snapshot failed", "err", err)
+		case stats.Boards > 0:
+			log.DebugContext(ctx, "leaderboard: snapshotted ranks",
+				"boards", stats.Boards, "points", stats.Points, "rungs", stats.Rungs)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
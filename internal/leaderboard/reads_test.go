@@ -27,7 +27,7 @@ func TestBanHidesTheEntryEverywhereAndKeepsIt(t *testing.T) {
 	b.addRun(runSpec{user: honest, score: 100, achievedAt: minutesAgo(20)})
 
 	// Before the ban: two entries, cheat on top.
-	page, err := b.store.Page(ctx, bucket, nil, 10)
+	page, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 10)
 	require.NoError(t, err)
 	require.Len(t, page, 2)
 	require.Equal(t, cheat, page[0].UserID)
@@ -35,7 +35,7 @@ func TestBanHidesTheEntryEverywhereAndKeepsIt(t *testing.T) {
 	b.ban(cheat, nil)
 
 	t.Run("page", func(t *testing.T) {
-		rows, err := b.store.Page(ctx, bucket, nil, 10)
+		rows, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 10)
 		require.NoError(t, err)
 		require.Len(t, rows, 1)
 		assert.Equal(t, honest, rows[0].UserID)
@@ -49,13 +49,13 @@ func TestBanHidesTheEntryEverywhereAndKeepsIt(t *testing.T) {
 	})
 
 	t.Run("own entry lookup", func(t *testing.T) {
-		_, err := b.store.EntryFor(ctx, bucket, cheat)
+		_, err := b.store.EntryFor(ctx, bucket, leaderboard.OrderScore, cheat)
 		assert.ErrorIs(t, err, leaderboard.ErrNoEntry,
 			"a banned player must not be able to see their own hidden slot either")
 	})
 
 	t.Run("rank arithmetic", func(t *testing.T) {
-		entry, err := b.store.EntryFor(ctx, bucket, honest)
+		entry, err := b.store.EntryFor(ctx, bucket, leaderboard.OrderScore, honest)
 		require.NoError(t, err)
 		assert.EqualValues(t, 1, entry.Rank,
 			"the honest player is now first: a hidden entry must not occupy a rank")
@@ -68,7 +68,7 @@ func TestBanHidesTheEntryEverywhereAndKeepsIt(t *testing.T) {
 
 	t.Run("unban restores it with no rebuild", func(t *testing.T) {
 		b.unban(cheat)
-		rows, err := b.store.Page(ctx, bucket, nil, 10)
+		rows, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 10)
 		require.NoError(t, err)
 		require.Len(t, rows, 2)
 		assert.Equal(t, cheat, rows[0].UserID)
@@ -86,7 +86,7 @@ func TestExpiredBanDoesNotHide(t *testing.T) {
 	past := time.Now().Add(-time.Hour)
 	b.ban(user, &past)
 
-	rows, err := b.store.Page(context.Background(), bucket, nil, 10)
+	rows, err := b.store.Page(context.Background(), bucket, leaderboard.OrderScore, nil, 10)
 	require.NoError(t, err)
 	assert.Len(t, rows, 1, "a ban that has expired must stop hiding immediately")
 }
@@ -107,7 +107,7 @@ func TestTiesGoToTheEarliestAchievement(t *testing.T) {
 	b.addRun(runSpec{user: early, score: 1000, achievedAt: minutesAgo(30)})
 	b.addRun(runSpec{user: middle, score: 1000, achievedAt: minutesAgo(20)})
 
-	rows, err := b.store.Page(context.Background(), bucket, nil, 10)
+	rows, err := b.store.Page(context.Background(), bucket, leaderboard.OrderScore, nil, 10)
 	require.NoError(t, err)
 	require.Len(t, rows, 3)
 	assert.Equal(t, []uuid.UUID{early, middle, late},
@@ -135,7 +135,7 @@ func TestPaginationIsStableWhenEverythingTies(t *testing.T) {
 	seen := make([]uuid.UUID, 0, players)
 	var after *leaderboard.Cursor
 	for range players + 2 { // generous bound: a broken cursor must not loop forever
-		rows, err := b.store.Page(ctx, bucket, after, 3)
+		rows, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, after, 3)
 		require.NoError(t, err)
 		if len(rows) == 0 {
 			break
@@ -173,20 +173,20 @@ func TestRanksAreContinuousAcrossPages(t *testing.T) {
 		ids = append(ids, u)
 	}
 
-	first, err := b.store.Page(ctx, bucket, nil, 2)
+	first, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 2)
 	require.NoError(t, err)
 	require.Len(t, first, 2)
 
 	cursor := leaderboard.Cursor{
 		Score: first[1].Score, AchievedAt: first[1].AchievedAt, UserID: first[1].UserID,
 	}
-	above, err := b.store.RankAbove(ctx, bucket, cursor)
+	above, err := b.store.RankAbove(ctx, bucket, leaderboard.OrderScore, cursor)
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, above, "one entry outranks the second row")
 
 	// Every player's own rank, via the /me path, must match their position.
 	for _, id := range ids {
-		entry, err := b.store.EntryFor(ctx, bucket, id)
+		entry, err := b.store.EntryFor(ctx, bucket, leaderboard.OrderScore, id)
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, entry.Rank, int64(1))
 		assert.LessOrEqual(t, entry.Rank, int64(5))
@@ -203,7 +203,7 @@ func TestHigherScoreOutranksEarlierAchievement(t *testing.T) {
 	b.addRun(runSpec{user: early, score: 900, achievedAt: minutesAgo(60)})
 	b.addRun(runSpec{user: strong, score: 1000, achievedAt: minutesAgo(1)})
 
-	rows, err := b.store.Page(context.Background(), bucket, nil, 10)
+	rows, err := b.store.Page(context.Background(), bucket, leaderboard.OrderScore, nil, 10)
 	require.NoError(t, err)
 	require.Len(t, rows, 2)
 	assert.Equal(t, strong, rows[0].UserID)
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/typemore/typemore-server/internal/leaderboard"
+	leaderboardpg "github.com/typemore/typemore-server/internal/leaderboard/pgstore"
 )
 
 // The projection is only trustworthy if it can be thrown away. A rebuild after
@@ -93,6 +94,7 @@ func TestRebuildReproducesIncrementalMaintenance(t *testing.T) {
 	assert.EqualValues(t, len(rebuilt), stats.After)
 	assert.Equal(t, len(rebuilt), stats.Cells,
 		"every enumerated cell must have produced exactly one entry")
+	assert.Empty(t, stats.Diff, "a healthy projection has nothing to swap in")
 
 	// And it is idempotent: running it again changes nothing.
 	_, err = b.store.Rebuild(ctx)
@@ -144,3 +146,143 @@ func TestRebuildOnAnEmptyDatabase(t *testing.T) {
 	assert.Zero(t, stats.After)
 	assert.Empty(t, b.storedEntries())
 }
+
+// drift plants a board, remembers it as correct, and then breaks it three ways,
+// one per kind of diff line: a wrong score on a real slot (changed), a phantom
+// slot the player never earned (removed), and a real slot gone missing (added).
+type drift struct {
+	correct           []storedEntry
+	racer, other      uuid.UUID
+	best, runnerUp    uuid.UUID
+	otherRun          uuid.UUID
+	words25, words100 leaderboard.Bucket
+}
+
+func driftBoard(t *testing.T, b *board) drift {
+	t.Helper()
+	ctx := context.Background()
+
+	var d drift
+	var err error
+	d.words25, err = leaderboard.NewBucket(leaderboard.ModeWords, nil, new(int32(25)), "en", leaderboard.TextSourceSeeded)
+	require.NoError(t, err)
+	d.words100, err = leaderboard.NewBucket(leaderboard.ModeWords, nil, new(int32(100)), "en", leaderboard.TextSourceSeeded)
+	require.NoError(t, err)
+
+	d.racer = b.user("racer", true)
+	d.other = b.user("other", true)
+	d.best = b.addRun(runSpec{user: d.racer, score: 1500, achievedAt: minutesAgo(30)})
+	d.runnerUp = b.addRun(runSpec{user: d.racer, score: 900, achievedAt: minutesAgo(20)})
+	d.otherRun = b.addRun(runSpec{user: d.other, mode: leaderboard.ModeWords, wordCount: new(int32(25)), score: 700})
+	d.correct = b.storedEntries()
+	require.Len(t, d.correct, 2)
+
+	_, err = b.pool.Exec(ctx, `UPDATE leaderboard_entries SET score = 999999 WHERE bucket_key = $1`, bucket15s(t).Key())
+	require.NoError(t, err)
+	_, err = b.pool.Exec(ctx, `
+		INSERT INTO leaderboard_entries
+			(bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods, achieved_at)
+		VALUES ($1, $2, $3, 4242, 1, 1, 1, 'SS', '{}'::jsonb, now())`,
+		d.words100.Key(), d.racer, d.runnerUp)
+	require.NoError(t, err)
+	_, err = b.pool.Exec(ctx, `DELETE FROM leaderboard_entries WHERE bucket_key = $1`, d.words25.Key())
+	require.NoError(t, err)
+	return d
+}
+
+// A dry run is the question "what would a rebuild change?" asked of a live
+// system — after a policy flip, before committing to it. It has to answer with
+// every slot, and it must not write a single row while doing so.
+func TestRebuildDryRunReportsTheDiffAndWritesNothing(t *testing.T) {
+	b := newBoard(t)
+	ctx := context.Background()
+	d := driftBoard(t, b)
+	drifted := b.storedEntries()
+
+	stats, err := b.store.RebuildWith(ctx, leaderboardpg.RebuildOptions{DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, drifted, b.storedEntries(), "a dry run must leave the live boards as they were")
+	assert.Equal(t, 1, stats.Added)
+	assert.Equal(t, 1, stats.Removed)
+	assert.Equal(t, 1, stats.Changed)
+	assert.EqualValues(t, 3, stats.Before)
+	assert.EqualValues(t, 3, stats.After, "one slot in, one slot out")
+
+	// In key order, with the run on each side of every slot.
+	require.Len(t, stats.Diff, 3)
+	assert.Equal(t, bucket15s(t).Key(), stats.Diff[0].BucketKey)
+	assert.Equal(t, &d.best, stats.Diff[0].Live, "the score was wrong, not the run")
+	assert.Equal(t, &d.best, stats.Diff[0].Rebuilt)
+	assert.Equal(t, d.words100.Key(), stats.Diff[1].BucketKey)
+	assert.Equal(t, &d.runnerUp, stats.Diff[1].Live)
+	assert.Nil(t, stats.Diff[1].Rebuilt, "the phantom slot is emptied")
+	assert.Equal(t, d.words25.Key(), stats.Diff[2].BucketKey)
+	assert.Equal(t, d.other, stats.Diff[2].UserID)
+	assert.Nil(t, stats.Diff[2].Live)
+	assert.Equal(t, &d.otherRun, stats.Diff[2].Rebuilt, "the missing slot is filled")
+
+	var shadow *string
+	require.NoError(t, b.pool.QueryRow(ctx,
+		`SELECT to_regclass('leaderboard_rebuild.leaderboard_entries')::text`).Scan(&shadow))
+	assert.Nil(t, shadow, "the shadow goes with the dry run's rollback")
+
+	// And the real thing applies exactly what the dry run reported.
+	applied, err := b.store.Rebuild(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, stats.Diff, applied.Diff)
+	assert.Equal(t, d.correct, b.storedEntries())
+}
+
+// -bucket scopes everything: what is rebuilt, what is compared, what is
+// written. Drift on any other board is neither reported nor repaired.
+func TestRebuildOneBucket(t *testing.T) {
+	b := newBoard(t)
+	ctx := context.Background()
+	d := driftBoard(t, b)
+
+	scope := bucket15s(t)
+	stats, err := b.store.RebuildWith(ctx, leaderboardpg.RebuildOptions{Bucket: &scope})
+	require.NoError(t, err)
+
+	require.Len(t, stats.Diff, 1)
+	assert.Equal(t, scope.Key(), stats.Diff[0].BucketKey)
+	assert.EqualValues(t, 1, stats.Before, "counted over the one board")
+	assert.Equal(t, 1, stats.Cells)
+
+	fixed, ok := b.storedEntry(scope, d.racer)
+	require.True(t, ok)
+	assert.EqualValues(t, 1500, fixed.Score, "the board in scope is repaired")
+	_, ok = b.storedEntry(d.words100, d.racer)
+	assert.True(t, ok, "a phantom on another board is not this rebuild's to remove")
+	_, ok = b.storedEntry(d.words25, d.other)
+	assert.False(t, ok, "nor a missing slot on another board its to fill")
+}
+
+// The swap writes the diff and nothing else. A row the shadow agrees with is
+// not rewritten — not even with identical values — so a rebuild of a healthy
+// board costs the table no churn and holds no row lock a reader could notice.
+func TestRebuildLeavesAgreeingRowsInPlace(t *testing.T) {
+	b := newBoard(t)
+	ctx := context.Background()
+	d := driftBoard(t, b)
+
+	ctid := func(key string, user uuid.UUID) string {
+		var loc string
+		require.NoError(t, b.pool.QueryRow(ctx,
+			`SELECT ctid::text FROM leaderboard_entries WHERE bucket_key = $1 AND user_id = $2`,
+			key, user).Scan(&loc))
+		return loc
+	}
+	// Repair the one board first, so it is the slot the shadow agrees with.
+	scope := bucket15s(t)
+	_, err := b.store.RebuildWith(ctx, leaderboardpg.RebuildOptions{Bucket: &scope})
+	require.NoError(t, err)
+	before := ctid(scope.Key(), d.racer)
+
+	stats, err := b.store.Rebuild(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats.Diff, 2, "the two boards that still drifted")
+	assert.Equal(t, before, ctid(scope.Key(), d.racer), "an agreeing row must not be rewritten")
+	assert.Equal(t, d.correct, b.storedEntries())
+}
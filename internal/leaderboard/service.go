@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -23,15 +24,29 @@ type UserIDFunc func(ctx context.Context) (uuid.UUID, bool)
 // stay ranked and replayable, because withdrawal is a discovery rule and the
 // results were legitimately played.
 //
-// It is a function supplied by the composition root rather than SQL inside this
-// domain's own queries on purpose. Excluding those boards in SQL would mean
-// rebuilding the bucket key from a quote id — `'quote:' || q.id` — which would
-// make a SECOND producer of a format this codebase deliberately keeps to one
-// (Bucket.Key / ParseBucketKey). The two spellings would then have to be kept
-// in step by nobody, and the failure would be silent: the index would quietly
+// It is a function supplied by the composition root rather than a join inside
+// this domain's own queries on purpose: which quotes are withdrawn is the
+// quote domain's fact. The index's query matches the ids against the quote id
+// its board rows carry, and never builds a bucket key from one — `'quote:' ||
+// q.id` would make a SECOND producer of a format this codebase deliberately
+// keeps to one (Bucket.Key / ParseBucketKey), with nobody keeping the two
+// spellings in step, and the failure would be silent: the index would quietly
 // stop hiding withdrawn boards.
 type WithdrawnQuotesFunc func(ctx context.Context) (map[uuid.UUID]struct{}, error)
 
+// QuoteGroups translates a quote's length group between the name a client
+// sends (`group=short`) and the number the quotes table stores. The names are
+// the quote domain's, and this one only passes them through to the index, so
+// they are supplied by the composition root rather than spelled a second time
+// here.
+type QuoteGroups interface {
+	// ParseGroup resolves a group name, reporting false for anything that
+	// names no group.
+	ParseGroup(name string) (int16, bool)
+	// GroupName is the name of a stored group.
+	GroupName(group int16) string
+}
+
 // RateLimiter is the narrow token-bucket seam, the same shape the auth, runs
 // and profile domains declare, so the composition root can hand this one an
 // instance without this package importing a sibling.
@@ -44,17 +59,26 @@ type Service struct {
 	store  Store
 	userID UserIDFunc
 	// indexLimiter rations the ONE route here whose cost is not bounded by a
-	// single board: `GET /` aggregates over EVERY board (up to ~9 881 quote
-	// ones) plus the withdrawn-quote read, on every hit, for an anonymous
-	// caller. A page read is bounded by its limit; the index is bounded by how
-	// much the product has grown.
+	// single board: `GET /` pages through leaderboard_boards, which has a row
+	// for every board (up to ~9 881 quote ones), and a filter that matches
+	// few of them reads the rest to find out. It also reads the withdrawn
+	// quotes and the banned players' entries, on every hit, for an anonymous
+	// caller. A board page is bounded by its limit. The index is bounded by
+	// how much the product has grown.
 	//
 	// Keyed by IP, like every other anonymous surface's bucket, and its own
 	// instance rather than a share of auth's: an index flood must not spend the
 	// budget that exists to protect argon2id.
 	indexLimiter RateLimiter
 	withdrawn    WithdrawnQuotesFunc
-	log          *slog.Logger
+	// quoteGroups names the length groups on the index; nil refuses `group=`
+	// and leaves quote boards' group unnamed.
+	quoteGroups QuoteGroups
+	// windows is which `?window=` readings are served; it must name the same
+	// kinds the projection maintains, or a window reads as an empty board.
+	windows Windows
+	now     func() time.Time
+	log     *slog.Logger
 }
 
 // NewService wires the leaderboard service. withdrawn must not be nil; pass one
@@ -67,10 +91,32 @@ func NewService(store Store, userID UserIDFunc, withdrawn WithdrawnQuotesFunc, i
 		userID:       userID,
 		withdrawn:    withdrawn,
 		indexLimiter: indexLimiter,
+		now:          time.Now,
 		log:          log,
 	}
 }
 
+// WithWindows serves the windowed readings of every board (`?window=`). Pass
+// the same Windows the store projects with.
+func (s *Service) WithWindows(w Windows) *Service {
+	s.windows = w
+	return s
+}
+
+// WithQuoteGroups lets the board index filter quote boards by length group and
+// name each one's group.
+func (s *Service) WithQuoteGroups(g QuoteGroups) *Service {
+	s.quoteGroups = g
+	return s
+}
+
+// WithClock replaces the clock that decides which week, month and season is
+// current. For tests.
+func (s *Service) WithClock(now func() time.Time) *Service {
+	s.now = now
+	return s
+}
+
 // --- shared HTTP helpers (mirroring the auth/runs domains', kept private) ---
 
 func (s *Service) writeJSON(w http.ResponseWriter, status int, v any) {
@@ -144,7 +144,7 @@ func TestKeysetWalkVisitsEveryRowExactlyOnce(t *testing.T) {
 	var cursor *leaderboard.Cursor
 	for page := 0; ; page++ {
 		require.Less(t, page, rows, "the walk did not terminate")
-		entries, err := b.store.Page(ctx, bucket, cursor, 7)
+		entries, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, cursor, 7)
 		require.NoError(t, err)
 		if len(entries) == 0 {
 			break
@@ -178,19 +178,19 @@ func TestRankAboveAgreesWithThePageOrder(t *testing.T) {
 		})
 	}
 
-	entries, err := b.store.Page(ctx, bucket, nil, rows)
+	entries, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, rows)
 	require.NoError(t, err)
 	require.Len(t, entries, rows)
 
 	for i, e := range entries {
-		above, err := b.store.RankAbove(ctx, bucket, leaderboard.Cursor{
+		above, err := b.store.RankAbove(ctx, bucket, leaderboard.OrderScore, leaderboard.Cursor{
 			Score: e.Score, AchievedAt: e.AchievedAt, UserID: e.UserID,
 		})
 		require.NoError(t, err)
 		require.Equalf(t, int64(i), above,
 			"the row at position %d reports %d entries above it", i, above)
 
-		full, err := b.store.EntryFor(ctx, bucket, e.UserID)
+		full, err := b.store.EntryFor(ctx, bucket, leaderboard.OrderScore, e.UserID)
 		require.NoError(t, err)
 		assert.Equalf(t, int64(i+1), full.Rank, "/me rank for the row at position %d", i)
 	}
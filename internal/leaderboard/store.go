@@ -14,6 +14,21 @@ import (
 // are deliberately the same answer: a board must not leak who is banned.
 var ErrNoEntry = errors.New("leaderboard: no entry")
 
+// ErrNoSeason is returned by Store.CurrentSeason when no season is running.
+var ErrNoSeason = errors.New("leaderboard: no season running")
+
+// ErrNotFrozen is returned by Store.FrozenPeriod for a window that has not been
+// archived — it is still open, it has not been frozen yet, or it never existed.
+var ErrNotFrozen = errors.New("leaderboard: period not frozen")
+
+// FrozenPeriod is a window whose boards have been archived. Name is set for a
+// season and empty for a week or a month.
+type FrozenPeriod struct {
+	Period
+	Name     string
+	FrozenAt time.Time
+}
+
 // Entry is one board row: a snapshot of the player's best eligible run in a
 // bucket, taken when it was projected. The numbers are the SERVER's — the
 // client's reported ones never reach a leaderboard.
@@ -42,11 +57,136 @@ type BucketCount struct {
 	Entries int64
 }
 
-// Cursor is a keyset position in a bucket's ranking: the (score, achieved_at,
-// user_id) of the last row a page returned. The triple is unique, which is what
-// makes paging stable when many players share a score.
+// Board is one row of the paged board index: a bucket, how many visible
+// players it holds, and when it last changed. Lang and LenGroup are the quote's
+// on a quote board, whose key carries neither. Lang is the bucket's own on a
+// language board, and a daily board has neither.
+type Board struct {
+	Bucket    Bucket
+	Lang      string
+	LenGroup  *int16
+	Entries   int64
+	ChangedAt time.Time
+}
+
+// BoardFilter narrows the board index. A zero field matches every board, so
+// the zero filter is the whole index. Withdrawn names the quotes whose boards
+// are left out (see WithdrawnQuotesFunc).
+type BoardFilter struct {
+	Mode       string
+	Lang       string
+	TextSource string
+	LenGroup   *int16
+	MinEntries int64
+	Withdrawn  []uuid.UUID
+}
+
+// BoardSort is an order the board index can be read in. Every sort breaks a
+// tie on the bucket key, so each is total and pageable.
+type BoardSort string
+
+const (
+	// BoardSortKey is bucket key order, the default and the order the index
+	// was served in before it was paged.
+	BoardSortKey BoardSort = "key"
+	// BoardSortEntries puts the boards with the most visible players first.
+	BoardSortEntries BoardSort = "entries"
+	// BoardSortRecent puts the board that changed last first: a new entry, a
+	// new best, or a player leaving it.
+	BoardSortRecent BoardSort = "recent"
+)
+
+// ErrUnknownBoardSort is returned by ParseBoardSort for anything that names no
+// sort.
+var ErrUnknownBoardSort = errors.New("leaderboard: unknown board sort")
+
+// ParseBoardSort reads the index's `sort` query parameter. Absent means
+// BoardSortKey.
+func ParseBoardSort(s string) (BoardSort, error) {
+	switch BoardSort(s) {
+	case "", BoardSortKey:
+		return BoardSortKey, nil
+	case BoardSortEntries, BoardSortRecent:
+		return BoardSort(s), nil
+	}
+	return "", ErrUnknownBoardSort
+}
+
+// BoardCursor is a keyset position in the board index: the last board a page
+// returned. Key is read under every sort; Entries only under BoardSortEntries,
+// and ChangedAt only under BoardSortRecent.
+type BoardCursor struct {
+	Key       string
+	Entries   int64
+	ChangedAt time.Time
+}
+
+// Order is one ranking of a bucket's entries. Both orders rank the SAME rows —
+// each player's best-scoring eligible run in the bucket — so an order is a way
+// of reading a board, not a second board: nothing is projected per order, and
+// a ban or a demotion moves every order at once.
+type Order string
+
+const (
+	// OrderScore ranks by score, earliest achievement first on a tie. It is
+	// the default and the order a board is defined by.
+	OrderScore Order = "score"
+	// OrderWPM ranks the same entries by the server's wpm for the run that
+	// holds the slot, with the same tie rule.
+	OrderWPM Order = "wpm"
+)
+
+// ErrUnknownOrder is returned by ParseOrder for anything that names no order.
+var ErrUnknownOrder = errors.New("leaderboard: unknown order")
+
+// ParseOrder reads the `order` query parameter. Absent means OrderScore, so
+// every URL minted before the WPM order existed still reads the board it did.
+func ParseOrder(s string) (Order, error) {
+	switch Order(s) {
+	case "", OrderScore:
+		return OrderScore, nil
+	case OrderWPM:
+		return OrderWPM, nil
+	}
+	return "", ErrUnknownOrder
+}
+
+// Scope is who a board is read over. Both scopes read the same rows in the
+// same order; the following scope only leaves out everyone the viewer does
+// not follow, so a rank in it is a place among the players they chose.
+type Scope string
+
+const (
+	// ScopeGlobal is the whole board — the default, and the only scope an
+	// anonymous reader has.
+	ScopeGlobal Scope = "global"
+	// ScopeFollowing is the viewer and the players they follow whose profiles
+	// are open (docs/LEADERBOARDS.md, "Following").
+	ScopeFollowing Scope = "following"
+)
+
+// ErrUnknownScope is returned by ParseScope for anything that names no scope.
+var ErrUnknownScope = errors.New("leaderboard: unknown scope")
+
+// ParseScope reads the `scope` query parameter. Absent means ScopeGlobal.
+func ParseScope(s string) (Scope, error) {
+	switch Scope(s) {
+	case "", ScopeGlobal:
+		return ScopeGlobal, nil
+	case ScopeFollowing:
+		return ScopeFollowing, nil
+	}
+	return "", ErrUnknownScope
+}
+
+// Cursor is a keyset position in a bucket's ranking: the key of the last row a
+// page returned, under the order being paged. The score order reads
+// (Score, AchievedAt, UserID) and the WPM order (WPM, AchievedAt, UserID);
+// either triple is unique, which is what makes paging stable when many players
+// share a number.
 type Cursor struct {
 	Score      int64
+	WPM        float64
 	AchievedAt time.Time
 	UserID     uuid.UUID
 }
@@ -59,19 +199,57 @@ type Cursor struct {
 // operator tool, and the HTTP surface has no business reaching it.
 type Store interface {
 	// Buckets lists every bucket that currently has at least one visible entry,
-	// with its count.
+	// with its count, by counting the entries. It is the unpaged answer Boards
+	// must agree with.
 	Buckets(ctx context.Context) ([]BucketCount, error)
-	// Page returns up to limit entries of a bucket's ranking, continuing after
-	// the given keyset position when non-nil. Rank is not set by the store.
-	Page(ctx context.Context, b Bucket, after *Cursor, limit int32) ([]Entry, error)
+	// Boards returns up to limit all-time boards with at least one visible
+	// entry that match the filter, in the sort's order, continuing after the
+	// given position when non-nil.
+	Boards(ctx context.Context, f BoardFilter, sort BoardSort, after *BoardCursor, limit int32) ([]Board, error)
+	// Page returns up to limit entries of a bucket's ranking under an order,
+	// continuing after the given keyset position when non-nil. Rank is not set
+	// by the store.
+	Page(ctx context.Context, b Bucket, o Order, after *Cursor, limit int32) ([]Entry, error)
 	// PageBefore returns up to limit entries strictly OUTRANKING the given
 	// position, in ranking order (the row nearest the position last) — the
 	// upward continuation. Rank is not set by the store.
-	PageBefore(ctx context.Context, b Bucket, before Cursor, limit int32) ([]Entry, error)
+	PageBefore(ctx context.Context, b Bucket, o Order, before Cursor, limit int32) ([]Entry, error)
 	// RankAbove counts the visible entries that outrank a position — the rank of
 	// the row at that position is this plus one.
-	RankAbove(ctx context.Context, b Bucket, at Cursor) (int64, error)
-	// EntryFor returns one player's entry in a bucket, with its rank filled in,
-	// or ErrNoEntry.
-	EntryFor(ctx context.Context, b Bucket, userID uuid.UUID) (Entry, error)
+	RankAbove(ctx context.Context, b Bucket, o Order, at Cursor) (int64, error)
+	// Standing is RankAbove for a position nobody need hold, answered off the
+	// rank ladder rather than by counting from rank 1, with the board's size.
+	// Only boards with rank history have a ladder; on any other it is exact
+	// and as slow as RankAbove.
+	Standing(ctx context.Context, b Bucket, o Order, at Cursor) (Standing, error)
+	// EntryFor returns one player's entry in a bucket, with its rank under the
+	// order filled in, or ErrNoEntry.
+	EntryFor(ctx context.Context, b Bucket, o Order, userID uuid.UUID) (Entry, error)
+
+	// CurrentSeason returns the season running at an instant, or ErrNoSeason.
+	CurrentSeason(ctx context.Context, at time.Time) (Season, error)
+	// FrozenPeriod returns an archived window, or ErrNotFrozen.
+	FrozenPeriod(ctx context.Context, id string) (FrozenPeriod, error)
+	// SnapshotPage returns up to limit rows of a windowed board's frozen
+	// standings, after the given rank. Rank IS set by the store: it is the
+	// rank the row was frozen with.
+	SnapshotPage(ctx context.Context, b Bucket, afterRank int64, limit int32) ([]Entry, error)
+
+	// RankHistory returns up to limit of a player's rank points on a board,
+	// newest first.
+	RankHistory(ctx context.Context, b Bucket, userID uuid.UUID, limit int32) ([]RankPoint, error)
+	// Following returns this read model narrowed to one viewer's circle: the
+	// viewer and the open profiles they follow. Page, PageBefore, RankAbove,
+	// Standing, EntryFor and SnapshotPage answer within the circle — ranks
+	// count members only, and a player outside it has no entry — while every
+	// other method reads exactly as the receiver does. Standing is counted
+	// live there: a circle is small enough that a ladder would cost more than
+	// it saves.
+	Following(viewer uuid.UUID) Store
+
+	// SwapSeenRank records the rank a player is being shown on a board now and
+	// returns the one they were shown on their previous visit; ok is false on
+	// their first. The only write on this interface: "since your last visit"
+	// needs the server to remember the visit.
+	SwapSeenRank(ctx context.Context, b Bucket, userID uuid.UUID, now RankPoint) (prev RankPoint, ok bool, err error)
 }
@@ -0,0 +1,280 @@
+package leaderboard_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/leaderboard"
+)
+
+// windowClock is a settable clock for the windowed boards. The runs these tests
+// plant are dated in March 2026 so that which windows are open is a fact of the
+// fixture, not of the day the suite happens to run.
+type windowClock struct{ at time.Time }
+
+func (c *windowClock) now() time.Time { return c.at }
+
+// wednesday is inside 2026-W10 (2 to 9 March), month 2026-03 and season s1.
+var wednesday = time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func newWindowedBoard(t *testing.T, kinds ...leaderboard.PeriodKind) (*board, *windowClock) {
+	t.Helper()
+	if len(kinds) == 0 {
+		kinds = leaderboard.PeriodKinds
+	}
+	clock := &windowClock{at: wednesday.Add(24 * time.Hour)}
+	b := newBoard(t, func(o *boardOpts) {
+		o.windows = leaderboard.Windows{Kinds: kinds, Settle: time.Hour}
+		o.now = clock.now
+	})
+	return b, clock
+}
+
+func (b *board) season(id string, from, to time.Time) {
+	b.t.Helper()
+	require.NoError(b.t, b.store.CreateSeason(context.Background(),
+		leaderboard.Season{ID: id, Name: "Season " + id, StartsAt: from, EndsAt: to}))
+}
+
+func windowOf(t *testing.T, b leaderboard.Bucket, period string) leaderboard.Bucket {
+	t.Helper()
+	w, err := b.Over(period)
+	require.NoError(t, err)
+	return w
+}
+
+// A windowed board holds each player's best run PLAYED inside the window, and
+// moves with the verdict exactly like the all-time board: the all-time slot
+// here is a February run no March window has ever heard of.
+func TestWindowedBoardsHoldTheBestRunInsideTheWindow(t *testing.T) {
+	b, _ := newWindowedBoard(t)
+	b.season("s1", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
+	user := b.user("racer", true)
+	bucket := bucket15s(t)
+
+	february := b.addRun(runSpec{user: user, score: 5000, achievedAt: time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC)})
+	march := b.addRun(runSpec{user: user, score: 1000, achievedAt: wednesday})
+	better := b.addRun(runSpec{user: user, score: 2000, achievedAt: wednesday.Add(time.Hour)})
+
+	allTime, ok := b.storedEntry(bucket, user)
+	require.True(t, ok)
+	assert.Equal(t, february, allTime.RunID)
+	for _, period := range []string{"2026-W10", "2026-03", "s1"} {
+		e, ok := b.storedEntry(windowOf(t, bucket, period), user)
+		require.True(t, ok, period)
+		assert.Equal(t, better, e.RunID, period)
+	}
+
+	t.Run("a closed window is not projected into", func(t *testing.T) {
+		for _, period := range []string{"2026-W08", "2026-02"} {
+			_, ok := b.storedEntry(windowOf(t, bucket, period), user)
+			assert.False(t, ok, "%s closed before the February run was judged", period)
+		}
+	})
+
+	t.Run("demotion hands the window to the next best run inside it", func(t *testing.T) {
+		b.judge(better, "rejected")
+		e, ok := b.storedEntry(windowOf(t, bucket, "2026-W10"), user)
+		require.True(t, ok)
+		assert.Equal(t, march, e.RunID)
+
+		b.judge(march, "rejected")
+		_, ok = b.storedEntry(windowOf(t, bucket, "2026-W10"), user)
+		assert.False(t, ok, "the February run is outside the window, so nothing takes the slot")
+		_, ok = b.storedEntry(bucket, user)
+		assert.True(t, ok)
+	})
+}
+
+func TestWindowParam(t *testing.T) {
+	b, _ := newWindowedBoard(t, leaderboard.PeriodWeek, leaderboard.PeriodSeason)
+	user := b.user("racer", true)
+	b.addRun(runSpec{user: user, score: 1000, achievedAt: wednesday})
+	const board15s = "/api/v1/leaderboards/time:15000:en:seeded"
+
+	page := decodeInto[pageBody](t, b.get(board15s+"?window=week"))
+	assert.Equal(t, "time:15000:en:seeded@2026-W10", page.Bucket)
+	require.Len(t, page.Entries, 1)
+	assert.EqualValues(t, 1, page.Entries[0].Rank)
+
+	b.asUser = user
+	resp := b.get(board15s + "/me?window=week")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	me := decodeInto[struct {
+		Bucket string `json:"bucket"`
+	}](t, resp)
+	assert.Equal(t, "time:15000:en:seeded@2026-W10", me.Bucket)
+
+	t.Run("the index lists each board once", func(t *testing.T) {
+		index := decodeInto[bucketsBody](t, b.get("/api/v1/leaderboards"))
+		require.Len(t, index.Buckets, 1)
+		assert.Equal(t, "time:15000:en:seeded", index.Buckets[0].Bucket)
+	})
+
+	t.Run("no season running", func(t *testing.T) {
+		resp := b.get(board15s + "?window=season")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Contains(t, string(readBody(t, resp)), "no_season")
+	})
+
+	for name, path := range map[string]string{
+		"an unknown kind":          board15s + "?window=fortnight",
+		"a kind not maintained":    board15s + "?window=month",
+		"a board already windowed": board15s + "@2026-W10?window=week",
+		"a daily board":            "/api/v1/leaderboards/daily:2026-03-04?window=week",
+		"on /me too":               board15s + "/me?window=fortnight",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, b.get(path).StatusCode)
+		})
+	}
+}
+
+type seasonBody struct {
+	Bucket string `json:"bucket"`
+	Season struct {
+		ID       string    `json:"id"`
+		Kind     string    `json:"kind"`
+		Name     string    `json:"name"`
+		StartsAt time.Time `json:"startsAt"`
+		EndsAt   time.Time `json:"endsAt"`
+	} `json:"season"`
+	pageBody
+}
+
+// Freezing turns a closed window's live board into final standings: archived
+// with the ranks it had, deleted from the live table, readable at its own
+// route — and never touched again, by a second pass or by a late verdict.
+func TestFreezerArchivesClosedWindows(t *testing.T) {
+	b, clock := newWindowedBoard(t)
+	b.season("s1", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC))
+	first := b.user("first", true)
+	second := b.user("second", true)
+	third := b.user("third", true)
+	b.addRun(runSpec{user: first, score: 3000, achievedAt: wednesday})
+	b.addRun(runSpec{user: second, score: 2000, achievedAt: wednesday})
+	b.addRun(runSpec{user: third, score: 1000, achievedAt: wednesday})
+	const archive = "/api/v1/leaderboards/time:15000:en:seeded/seasons/"
+
+	t.Run("an open window is not archived", func(t *testing.T) {
+		stats, err := b.store.FreezeClosed(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, stats.Periods)
+		assert.Equal(t, http.StatusNotFound, b.get(archive+"2026-W10").StatusCode)
+	})
+
+	// The season ended on Friday and the week on Monday; an hour of settle
+	// later both may freeze, and the month is still running.
+	clock.at = time.Date(2026, 3, 9, 1, 0, 0, 0, time.UTC)
+	stats, err := b.store.FreezeClosed(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Periods)
+	assert.EqualValues(t, 6, stats.Archived)
+
+	for _, e := range b.storedEntries() {
+		assert.NotContains(t, []string{"time:15000:en:seeded@2026-W10", "time:15000:en:seeded@s1"}, e.BucketKey,
+			"a frozen window's live rows are gone")
+	}
+	_, live := b.storedEntry(windowOf(t, bucket15s(t), "2026-03"), first)
+	assert.True(t, live, "the month is still open")
+
+	body := decodeInto[seasonBody](t, b.get(archive+"s1"))
+	assert.Equal(t, "time:15000:en:seeded", body.Bucket)
+	assert.Equal(t, "s1", body.Season.ID)
+	assert.Equal(t, "season", body.Season.Kind)
+	assert.Equal(t, "Season s1", body.Season.Name)
+	require.Len(t, body.Entries, 3)
+	for i, want := range []uuid.UUID{first, second, third} {
+		assert.Equal(t, want, body.Entries[i].UserID)
+		assert.EqualValues(t, i+1, body.Entries[i].Rank)
+	}
+
+	t.Run("paging by rank", func(t *testing.T) {
+		one := decodeInto[seasonBody](t, b.get(archive+"2026-W10?limit=2"))
+		require.Len(t, one.Entries, 2)
+		require.NotEmpty(t, one.NextCursor)
+		two := decodeInto[seasonBody](t, b.get(archive+"2026-W10?limit=2&cursor="+one.NextCursor))
+		require.Len(t, two.Entries, 1)
+		assert.Equal(t, third, two.Entries[0].UserID)
+		assert.EqualValues(t, 3, two.Entries[0].Rank)
+		assert.Empty(t, two.NextCursor)
+
+		assert.Equal(t, http.StatusBadRequest, b.get(archive+"2026-W10?cursor=junk").StatusCode)
+	})
+
+	t.Run("the archive does not renumber", func(t *testing.T) {
+		b.ban(second, nil)
+		defer b.unban(second)
+		body := decodeInto[seasonBody](t, b.get(archive+"2026-W10"))
+		require.Len(t, body.Entries, 2)
+		assert.EqualValues(t, 1, body.Entries[0].Rank)
+		assert.EqualValues(t, 3, body.Entries[1].Rank, "a hidden row leaves a gap")
+	})
+
+	t.Run("a second pass finds nothing", func(t *testing.T) {
+		stats, err := b.store.FreezeClosed(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, leaderboard.FreezeStats{}, stats)
+	})
+
+	t.Run("a late verdict does not count", func(t *testing.T) {
+		late := b.user("late", true)
+		b.addRun(runSpec{user: late, score: 9000, achievedAt: wednesday})
+		_, ok := b.storedEntry(windowOf(t, bucket15s(t), "2026-W10"), late)
+		assert.False(t, ok)
+		assert.Len(t, decodeInto[seasonBody](t, b.get(archive+"2026-W10")).Entries, 3)
+		_, ok = b.storedEntry(windowOf(t, bucket15s(t), "2026-03"), late)
+		assert.True(t, ok, "the month it was played in is still open")
+	})
+
+	for name, id := range map[string]string{
+		"a window still open":   "2026-03",
+		"a window never played": "2026-W11",
+		"not a window":          "week-ten",
+		"not even a period":     "2026-W5",
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := b.get(archive + id)
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+			assert.Contains(t, string(readBody(t, resp)), "unknown_season")
+		})
+	}
+	assert.Equal(t, http.StatusNotFound,
+		b.get("/api/v1/leaderboards/time:15000:en:seeded@2026-W10/seasons/2026-W10").StatusCode)
+}
+
+// The rebuild puts back every live window — including one that has closed but
+// not frozen, which the projection no longer writes — and leaves the frozen
+// ones in the archive.
+func TestRebuildRestoresLiveWindows(t *testing.T) {
+	b, clock := newWindowedBoard(t, leaderboard.PeriodWeek, leaderboard.PeriodMonth)
+	user := b.user("racer", true)
+	b.addRun(runSpec{user: user, score: 1000, achievedAt: wednesday})
+	b.addRun(runSpec{user: user, score: 2000, achievedAt: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)})
+
+	clock.at = time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	before := b.storedEntries()
+	require.Len(t, before, 4, "all time, two weeks, one month")
+
+	stats, err := b.store.Rebuild(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, stats.Before, stats.After)
+	assert.Equal(t, before, b.storedEntries())
+
+	clock.at = time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC)
+	_, err = b.store.FreezeClosed(context.Background())
+	require.NoError(t, err)
+	_, err = b.store.Rebuild(context.Background())
+	require.NoError(t, err)
+	for _, e := range b.storedEntries() {
+		assert.NotContains(t, []string{"time:15000:en:seeded@2026-W10", "time:15000:en:seeded@2026-W11"}, e.BucketKey,
+			"a frozen window is not revived")
+	}
+	assert.Len(t, b.storedEntries(), 2)
+}
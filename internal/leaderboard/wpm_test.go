@@ -0,0 +1,178 @@
+package leaderboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// `?order=wpm` — the same entries as the score order, ranked by speed.
+//
+// The fixtures make the two orders DISAGREE on purpose: a board whose fastest
+// player also scores highest would pass every assertion here with the order
+// parameter ignored.
+
+const board15s = "/api/v1/leaderboards/time:15000:en:seeded"
+
+type wpmBody struct {
+	Bucket  string `json:"bucket"`
+	Order   string `json:"order"`
+	Entries []struct {
+		Rank   int64     `json:"rank"`
+		UserID uuid.UUID `json:"userId"`
+		Score  int64     `json:"score"`
+		WPM    float64   `json:"wpm"`
+	} `json:"entries"`
+	PrevCursor string `json:"prevCursor"`
+	NextCursor string `json:"nextCursor"`
+}
+
+// seedInverted plants n players whose scores climb as their speeds fall:
+// player i scores 1000*i at 200-10*i wpm, with a fractional part so the cursor
+// has to carry a wpm that is not a round number. Returned ids are indexed by
+// WPM rank: ids[0] is the fastest, and the lowest score.
+func seedInverted(b *board, n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := 1; i <= n; i++ {
+		u := b.user(fmt.Sprintf("inverted-%d", i), true)
+		b.addRun(runSpec{
+			user: u, score: int64(1000 * i), wpm: float64(200-10*i) + 0.123456789,
+			achievedAt: minutesAgo(i),
+		})
+		ids[i-1] = u
+	}
+	return ids
+}
+
+func TestWPMOrderRanksTheSameEntriesBySpeed(t *testing.T) {
+	b := newBoard(t)
+	ids := seedInverted(b, 4)
+
+	byScore := decodeInto[wpmBody](t, b.get(board15s))
+	assert.Equal(t, "score", byScore.Order, "score stays the default order")
+	assert.Equal(t, ids[3], byScore.Entries[0].UserID)
+
+	resp := b.get(board15s + "?order=wpm")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	byWPM := decodeInto[wpmBody](t, resp)
+	assert.Equal(t, "wpm", byWPM.Order)
+	require.Len(t, byWPM.Entries, 4)
+	for i, e := range byWPM.Entries {
+		assert.Equal(t, ids[i], e.UserID, "wpm rank %d", i+1)
+		assert.EqualValues(t, i+1, e.Rank)
+	}
+	assert.Greater(t, byWPM.Entries[0].WPM, byWPM.Entries[1].WPM)
+}
+
+// The cursor walk reproduces the first page exactly, rank for rank — which is
+// the proof the wpm survives the token round trip to the stored numeric — and
+// `before=` from any of those cursors tiles back up to the top.
+func TestWPMCursorContinuesInBothDirections(t *testing.T) {
+	b := newBoard(t)
+	ids := seedInverted(b, 7)
+
+	var walked []uuid.UUID
+	var ranks []int64
+	var cursors []string
+	path := board15s + "?order=wpm&limit=3"
+	for {
+		page := decodeInto[wpmBody](t, b.get(path))
+		for _, e := range page.Entries {
+			walked = append(walked, e.UserID)
+			ranks = append(ranks, e.Rank)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursors = append(cursors, page.NextCursor)
+		path = board15s + "?order=wpm&limit=3&cursor=" + page.NextCursor
+	}
+	assert.Equal(t, ids, walked)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7}, ranks)
+
+	// The second cursor is rank 6's position; everything above it comes back.
+	require.Len(t, cursors, 2)
+	up := decodeInto[wpmBody](t, b.get(board15s+"?order=wpm&limit=10&before="+cursors[1]))
+	require.Len(t, up.Entries, 5)
+	assert.EqualValues(t, 1, up.Entries[0].Rank)
+	assert.Equal(t, ids[:5], []uuid.UUID{
+		up.Entries[0].UserID, up.Entries[1].UserID, up.Entries[2].UserID,
+		up.Entries[3].UserID, up.Entries[4].UserID,
+	})
+	assert.Empty(t, up.PrevCursor, "rank 1 is on this page")
+}
+
+// Two players at the exact same speed are ordered by who got there first — the
+// score order's own tie rule — whatever their scores say.
+func TestWPMTiesBreakOnAchievedAt(t *testing.T) {
+	b := newBoard(t)
+	early, late := b.user("early", true), b.user("late", true)
+	b.addRun(runSpec{user: late, score: 9000, wpm: 120.5, achievedAt: minutesAgo(5)})
+	b.addRun(runSpec{user: early, score: 1000, wpm: 120.5, achievedAt: minutesAgo(50)})
+
+	body := decodeInto[wpmBody](t, b.get(board15s+"?order=wpm"))
+	require.Len(t, body.Entries, 2)
+	assert.Equal(t, early, body.Entries[0].UserID)
+	assert.Equal(t, late, body.Entries[1].UserID)
+}
+
+// /me and around=me count the caller's rank in the order asked for, and a ban
+// hides the player from the WPM order exactly as from the score order.
+func TestWPMOrderForMeAndAroundMe(t *testing.T) {
+	b := newBoard(t)
+	ids := seedInverted(b, 5)
+	b.asUser = ids[0] // fastest, lowest score
+
+	me := decodeInto[struct {
+		Order string `json:"order"`
+		Entry struct {
+			Rank int64 `json:"rank"`
+		} `json:"entry"`
+	}](t, b.get(board15s+"/me?order=wpm"))
+	assert.Equal(t, "wpm", me.Order)
+	assert.EqualValues(t, 1, me.Entry.Rank)
+
+	scoreMe := decodeInto[struct {
+		Entry struct {
+			Rank int64 `json:"rank"`
+		} `json:"entry"`
+	}](t, b.get(board15s+"/me"))
+	assert.EqualValues(t, 5, scoreMe.Entry.Rank, "the same entry, last by score")
+
+	b.asUser = ids[2]
+	window := decodeInto[wpmBody](t, b.get(board15s+"?order=wpm&around=me&limit=3"))
+	require.Len(t, window.Entries, 3)
+	assert.Equal(t, []uuid.UUID{ids[1], ids[2], ids[3]},
+		[]uuid.UUID{window.Entries[0].UserID, window.Entries[1].UserID, window.Entries[2].UserID})
+	assert.EqualValues(t, 2, window.Entries[0].Rank)
+
+	b.ban(ids[0], nil)
+	body := decodeInto[wpmBody](t, b.get(board15s+"?order=wpm"))
+	require.Len(t, body.Entries, 4)
+	assert.Equal(t, ids[1], body.Entries[0].UserID)
+	assert.EqualValues(t, 1, body.Entries[0].Rank)
+}
+
+// An unknown order is a bad request, and a cursor only continues the order
+// that minted it.
+func TestWPMOrderRejectsJunkAndForeignCursors(t *testing.T) {
+	b := newBoard(t)
+	seedInverted(b, 3)
+
+	assert.Equal(t, http.StatusBadRequest, b.get(board15s+"?order=raw").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, b.get(board15s+"/me?order=raw").StatusCode)
+
+	byScore := decodeInto[wpmBody](t, b.get(board15s+"?limit=1"))
+	byWPM := decodeInto[wpmBody](t, b.get(board15s+"?order=wpm&limit=1"))
+	require.NotEmpty(t, byScore.NextCursor)
+	require.NotEmpty(t, byWPM.NextCursor)
+
+	assert.Equal(t, http.StatusBadRequest,
+		b.get(board15s+"?order=wpm&cursor="+byScore.NextCursor).StatusCode)
+	assert.Equal(t, http.StatusBadRequest,
+		b.get(board15s+"?cursor="+byWPM.NextCursor).StatusCode)
+}
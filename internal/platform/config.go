@@ -2,6 +2,8 @@ package platform
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/caarlos0/env/v11"
@@ -58,6 +60,11 @@ type Config struct {
 	// origin, the Origin header value mutating endpoints require (CSRF defense),
 	// and the base URL for links embedded in emails.
 	FrontendOrigin string `env:"FRONTEND_ORIGIN" envDefault:"http://localhost:5173"`
+	// PasskeyRPID is the WebAuthn relying-party ID passkeys are bound to. Empty
+	// (the default) means the FrontendOrigin's host. Set it to a registrable
+	// parent domain (example.com for app.example.com) only if passkeys must
+	// keep working across subdomains — every passkey is bound to it for good.
+	PasskeyRPID string `env:"PASSKEY_RP_ID"`
 	// Admins is the admin bootstrap list (docs/MODERATION.md): emails whose
 	// VERIFIED accounts are promoted to the admin role at startup. Promotion
 	// only — removing an email demotes nobody. Empty (the default) means no
@@ -75,10 +82,18 @@ type Config struct {
 
 	// --- OAuth ---
 
-	GitHubClientID     string `env:"GITHUB_CLIENT_ID"`
-	GitHubClientSecret string `env:"GITHUB_CLIENT_SECRET"`
-	GoogleClientID     string `env:"GOOGLE_CLIENT_ID"`
-	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET"`
+	GitHubClientID      string `env:"GITHUB_CLIENT_ID"`
+	GitHubClientSecret  string `env:"GITHUB_CLIENT_SECRET"`
+	GoogleClientID      string `env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret  string `env:"GOOGLE_CLIENT_SECRET"`
+	DiscordClientID     string `env:"DISCORD_CLIENT_ID"`
+	DiscordClientSecret string `env:"DISCORD_CLIENT_SECRET"`
+	// OIDCProviders are additional OpenID Connect issuers (a self-hosted
+	// Keycloak, Authentik, ...), numbered from zero:
+	// TYPEMORE_OIDC_0_NAME, TYPEMORE_OIDC_0_ISSUER, TYPEMORE_OIDC_0_CLIENT_ID
+	// and so on. LoadConfig rejects an entry that is incomplete, reuses a
+	// name, or takes a built-in provider's name.
+	OIDCProviders []OIDCProvider `envPrefix:"OIDC_"`
 	// OAuthRedirectBase is the public base URL of THIS server, used to build the
 	// provider redirect URIs (must match what is registered with the provider).
 	OAuthRedirectBase string `env:"OAUTH_REDIRECT_BASE" envDefault:"http://localhost:8080"`
@@ -118,6 +133,13 @@ type Config struct {
 	RunsRateEvery time.Duration `env:"RUNS_RATE_EVERY" envDefault:"30s"`
 	RunsRateBurst int           `env:"RUNS_RATE_BURST" envDefault:"120"`
 
+	// RunsTimelineRateEvery / RunsTimelineRateBurst are the per-IP token bucket
+	// on GET /runs/{id}/timeline. Each request is a full fold on the process's
+	// shared replay core, so it is tighter than the replay pair, which only
+	// passes stored bytes through: burst 6 with one token every 10s.
+	RunsTimelineRateEvery time.Duration `env:"RUNS_TIMELINE_RATE_EVERY" envDefault:"10s"`
+	RunsTimelineRateBurst int           `env:"RUNS_TIMELINE_RATE_BURST" envDefault:"6"`
+
 	// AuthHashConcurrency bounds how many argon2id password hashes run at once.
 	// Each costs ~19 MiB of live heap and is paid BEFORE any check that could
 	// reject the caller, so unbounded hashing is a memory-exhaustion DoS made of
@@ -134,6 +156,12 @@ type Config struct {
 	// AuthHashWait is how long a request may queue for a hashing slot before it
 	// is shed with 503. Zero uses the auth domain's default.
 	AuthHashWait time.Duration `env:"AUTH_HASH_WAIT"`
+	// AccessTokenRateEvery / AccessTokenRateBurst are the token bucket each
+	// personal access token gets on the routes it may call: one request a
+	// second with a burst of 60 by default. Per token rather than per IP,
+	// because a bot's address says nothing about whose quota it is spending.
+	AccessTokenRateEvery time.Duration `env:"ACCESS_TOKEN_RATE_EVERY" envDefault:"1s"`
+	AccessTokenRateBurst int           `env:"ACCESS_TOKEN_RATE_BURST" envDefault:"60"`
 
 	// --- Background cleanup ---
 
@@ -172,6 +200,24 @@ type Config struct {
 	// submission now parks a goroutine for ~7 s rather than over a minute, but
 	// 4 still buys the headroom cheaply and three keep draining regardless.
 	ReplayConcurrency int `env:"REPLAY_CONCURRENCY" envDefault:"4"`
+	// ReplayEngine selects what replays a run (docs/REPLAY.md, "Engines"):
+	//
+	//   goja         the vendored bundle in goja — the reference, and the default
+	//   native       the Go port of the core, ~75× faster on the 2026-08-03
+	//                population; anything it does not model goes to goja
+	//   differential both; goja's verdict stands, and any disagreement is
+	//                logged and written into the run's validation document
+	//
+	// native and differential refuse to start against a bundle the port was not
+	// proven on, so a re-vendor without a port update fails loudly at boot.
+	ReplayEngine string `env:"REPLAY_ENGINE" envDefault:"goja"`
+	// ReplayBundleDir is a directory of extra core bundles (*.js) that
+	// `replayctl` may replay runs on, beside the vendored build and the
+	// embedded archive (docs/REPLAY.md, "Bundles"). Each is keyed by the
+	// SHA-256 of its content. Empty — the default — means the archive alone;
+	// the live worker never reads it, because new runs are judged on the
+	// vendored build and nothing else.
+	ReplayBundleDir string `env:"REPLAY_BUNDLE_DIR"`
 	// ReplayTimeout bounds one core call. A run that exceeds it is flagged
 	// replay_timeout rather than allowed to occupy a worker.
 	//
@@ -223,6 +269,24 @@ type Config struct {
 	// TYPEMORE_REPLAY_CANARY_EPOCH=2026-08-01T12:00:00Z.
 	ReplayCanaryEpoch time.Time `env:"REPLAY_CANARY_EPOCH"`
 
+	// ReplayMatchesEnabled adds the match-capture goroutine to the worker: one
+	// more goja runtime that judges every persisted match and places its seats
+	// (docs/MATCH.md §6). On by default — an unjudged match has no results at
+	// all — and only meaningful when ReplayEnabled is.
+	ReplayMatchesEnabled bool `env:"REPLAY_MATCHES_ENABLED" envDefault:"true"`
+
+	// ReplayRetryMaxAttempts is how many failed replays (replay_timeout /
+	// replay_error) a pending run may accumulate before the worker stops
+	// retrying it and dead-letters it (docs/REPLAY.md, "Retry and dead
+	// letters"). 1 restores the old rule: the first failure is final.
+	ReplayRetryMaxAttempts int16 `env:"REPLAY_RETRY_MAX_ATTEMPTS" envDefault:"5"`
+	// ReplayRetryBaseDelay is the wait before the first retry; each further
+	// failure doubles it, up to ReplayRetryMaxDelay. 30s × (1+2+4+8) puts a
+	// run's fifth and last attempt 7½ minutes after its first — long enough to
+	// outlast a load spike, short enough that a player's PB is not a day late.
+	ReplayRetryBaseDelay time.Duration `env:"REPLAY_RETRY_BASE_DELAY" envDefault:"30s"`
+	ReplayRetryMaxDelay  time.Duration `env:"REPLAY_RETRY_MAX_DELAY" envDefault:"1h"`
+
 	// --- Replay review policy (docs/REPLAY.md, "Review policy") ---
 	//
 	// Which plausibility flags are worth what, and how much weighted severity
@@ -245,6 +309,31 @@ type Config struct {
 	// ReplaySustainedBurstSec is the duration floor for the sustained-burst
 	// combination rule. Zero keeps the calibrated default.
 	ReplaySustainedBurstSec float64 `env:"REPLAY_SUSTAINED_BURST_SEC"`
+	// ReplayHistoryEnabled measures every run against its player's own past —
+	// recent wpm, past review, keyboard rhythm — and hands the judge what that
+	// raises (docs/REPLAY.md, "Player history"). Off by default: turn it on
+	// after `replayctl calibrate` has shown what it would change, and on a
+	// build without a policy it reads nothing, because nothing would weigh it.
+	ReplayHistoryEnabled bool `env:"REPLAY_HISTORY_ENABLED" envDefault:"false"`
+
+	// ReplayShadowEnabled evaluates a second, candidate policy beside the live
+	// one on every run the worker judges, and stores what it would have decided
+	// next to the real verdict without acting on it (docs/REPLAY.md, "Shadow
+	// policy"). GET /api/v1/admin/runs/shadow lists where the two disagree.
+	// Needs a build with a policy: a candidate that judges nothing is refused
+	// at startup.
+	ReplayShadowEnabled bool `env:"REPLAY_SHADOW_ENABLED" envDefault:"false"`
+	// ReplayShadowLabel names the candidate in the stored rows. Change it with
+	// every new tuning, so the report never mixes two experiments.
+	ReplayShadowLabel string `env:"REPLAY_SHADOW_LABEL" envDefault:"candidate"`
+	// ReplayShadowFlagWeights / ReplayShadowReviewThreshold /
+	// ReplayShadowSustainedBurstSec are the candidate's tuning, in the same
+	// syntax as their REPLAY_* counterparts. They start from the calibrated
+	// defaults, NOT from the live overrides: a candidate is described in full,
+	// so what is in the report is exactly what these say.
+	ReplayShadowFlagWeights       string  `env:"REPLAY_SHADOW_FLAG_WEIGHTS"`
+	ReplayShadowReviewThreshold   float64 `env:"REPLAY_SHADOW_REVIEW_THRESHOLD"`
+	ReplayShadowSustainedBurstSec float64 `env:"REPLAY_SHADOW_SUSTAINED_BURST_SEC"`
 
 	// --- Leaderboards (docs/LEADERBOARDS.md) ---
 
@@ -263,6 +352,25 @@ type Config struct {
 	LeaderboardReplayRateEvery time.Duration `env:"LEADERBOARD_REPLAY_RATE_EVERY" envDefault:"2s"`
 	LeaderboardReplayRateBurst int           `env:"LEADERBOARD_REPLAY_RATE_BURST" envDefault:"30"`
 
+	// LeaderboardWindows are the windowed readings maintained beside every
+	// all-time board (docs/LEADERBOARDS.md, "Windows"): any of week, month and
+	// season, or "none". Each costs one more statement per verdict; turning one
+	// on takes effect for runs already judged after `make rebuild-leaderboards`.
+	LeaderboardWindows []string `env:"LEADERBOARD_WINDOWS" envSeparator:"," envDefault:"week,month,season"`
+	// LeaderboardWindowSettle is how long past a window's end a late verdict
+	// still lands on it — a run played at 23:59 on Sunday is judged into
+	// Monday — and so how long the freezer waits before archiving it.
+	LeaderboardWindowSettle time.Duration `env:"LEADERBOARD_WINDOW_SETTLE" envDefault:"1h"`
+	// LeaderboardFreezeInterval is how often closed windows are looked for and
+	// archived. Zero or negative disables the freezer; a closed window then
+	// stays readable live until `leaderboardctl freeze` is run.
+	LeaderboardFreezeInterval time.Duration `env:"LEADERBOARD_FREEZE_INTERVAL" envDefault:"10m"`
+	// LeaderboardRankSnapshotInterval is how often the boards the projection
+	// wrote are re-ranked into rank history — the resolution of every player's
+	// chart. Zero or negative disables it; `leaderboardctl snapshot-ranks` runs
+	// one pass by hand.
+	LeaderboardRankSnapshotInterval time.Duration `env:"LEADERBOARD_RANK_SNAPSHOT_INTERVAL" envDefault:"1h"`
+
 	// ProfileSearchRateEvery / ProfileSearchRateBurst are the per-IP token
 	// bucket on the public GET /api/v1/users?q= player search. The numbers are
 	// sized for a search box that queries as the user types: a 300 ms debounce
@@ -286,6 +394,27 @@ type Config struct {
 	ReportRateEvery time.Duration `env:"REPORT_RATE_EVERY" envDefault:"1m"`
 	ReportRateBurst int           `env:"REPORT_RATE_BURST" envDefault:"5"`
 
+	// --- Daily challenge (docs/DAILY.md) ---
+
+	// DailySalt is mixed into every day's draw. Set it in production: without
+	// it the challenge for any future date can be computed from the source, and
+	// a player who knows tomorrow's seed can practise its exact text tonight.
+	// Changing it re-draws only days not yet frozen.
+	DailySalt string `env:"DAILY_SALT"`
+	// DailyLangs are the languages a seeded day is drawn from. Each must be a
+	// language the dictionaries serve, or that day's challenge is unplayable.
+	DailyLangs []string `env:"DAILY_LANGS" envSeparator:"," envDefault:"en"`
+	// DailyGrace is how long past midnight UTC an attempt at the previous day
+	// is still admitted — a run started at 23:59:50 has to be submittable.
+	DailyGrace time.Duration `env:"DAILY_GRACE" envDefault:"15m"`
+	// DailyQuoteEvery makes about one day in N a quote of the day. Zero means
+	// every day is seeded.
+	DailyQuoteEvery int `env:"DAILY_QUOTE_EVERY" envDefault:"4"`
+	// DailyPrepareInterval is how often today's and tomorrow's challenges are
+	// frozen ahead of their first read. Zero or negative disables the
+	// scheduler; reads still freeze a missing day on demand.
+	DailyPrepareInterval time.Duration `env:"DAILY_PREPARE_INTERVAL" envDefault:"1h"`
+
 	// --- Lobby discovery (docs/PROTOCOL.md §5) ---
 
 	// LobbyRateEvery / LobbyRateBurst are the per-IP token bucket on the public
@@ -324,5 +453,55 @@ func LoadConfig() (Config, error) {
 	if err := env.ParseWithOptions(&c, env.Options{Prefix: envPrefix}); err != nil {
 		return Config{}, fmt.Errorf("parse env config: %w", err)
 	}
+	if err := validateOIDCProviders(c.OIDCProviders); err != nil {
+		return Config{}, err
+	}
 	return c, nil
 }
+
+// OIDCProvider is one generic OpenID Connect sign-in provider. Its endpoints
+// are not configured: they come from the issuer's discovery document
+// (Issuer + "/.well-known/openid-configuration").
+type OIDCProvider struct {
+	// Name identifies the provider in URLs (/auth/oauth/{name}/start) and is
+	// stored on every identity it creates, so it must outlive the deployment:
+	// renaming it orphans those identities. Lower-case letters, digits and
+	// hyphens, 2–32 characters, starting with a letter.
+	Name string `env:"NAME"`
+	// DisplayName is what the sign-in button says; empty uses Name.
+	DisplayName  string `env:"DISPLAY_NAME"`
+	Issuer       string `env:"ISSUER"`
+	ClientID     string `env:"CLIENT_ID"`
+	ClientSecret string `env:"CLIENT_SECRET"`
+}
+
+// oidcNamePattern is the shape of an OIDC provider name. It is the CHECK on
+// auth_identities.provider (migration 00049) verbatim: a name the database
+// would refuse has to fail here, at boot, not on somebody's first sign-in.
+var oidcNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,31}$`)
+
+// builtinProviderNames are the names the auth domain already gives a meaning
+// to. Repeated here rather than imported because platform sits below every
+// domain; an OIDC provider called "github" would silently shadow, or be
+// shadowed by, the real one.
+var builtinProviderNames = []string{"email", "github", "google", "discord"}
+
+// validateOIDCProviders rejects configuration that could only fail later:
+// missing fields, malformed or duplicate names, and names already taken.
+func validateOIDCProviders(providers []OIDCProvider) error {
+	seen := make(map[string]bool, len(providers))
+	for i, p := range providers {
+		switch {
+		case !oidcNamePattern.MatchString(p.Name):
+			return fmt.Errorf("%sOIDC_%d_NAME %q: want 2-32 lower-case letters, digits or hyphens, starting with a letter", envPrefix, i, p.Name)
+		case slices.Contains(builtinProviderNames, p.Name):
+			return fmt.Errorf("%sOIDC_%d_NAME %q: reserved for a built-in provider", envPrefix, i, p.Name)
+		case seen[p.Name]:
+			return fmt.Errorf("%sOIDC_%d_NAME %q: configured twice", envPrefix, i, p.Name)
+		case p.Issuer == "" || p.ClientID == "" || p.ClientSecret == "":
+			return fmt.Errorf("%sOIDC_%d (%s): ISSUER, CLIENT_ID and CLIENT_SECRET are all required", envPrefix, i, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return nil
+}
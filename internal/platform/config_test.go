@@ -0,0 +1,70 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// OIDC providers are a numbered list in the environment, which is the one
+// shape of configuration here that env struct tags alone cannot validate: a
+// half-filled entry or a name the database would refuse has to stop the
+// server at boot, not on a player's first sign-in.
+func TestLoadConfigOIDCProviders(t *testing.T) {
+	t.Setenv("TYPEMORE_OIDC_0_NAME", "keycloak")
+	t.Setenv("TYPEMORE_OIDC_0_DISPLAY_NAME", "Club SSO")
+	t.Setenv("TYPEMORE_OIDC_0_ISSUER", "https://sso.example.com/realms/club")
+	t.Setenv("TYPEMORE_OIDC_0_CLIENT_ID", "typemore")
+	t.Setenv("TYPEMORE_OIDC_0_CLIENT_SECRET", "s3cret")
+	t.Setenv("TYPEMORE_OIDC_1_NAME", "authentik")
+	t.Setenv("TYPEMORE_OIDC_1_ISSUER", "https://auth.example.org/application/o/typemore/")
+	t.Setenv("TYPEMORE_OIDC_1_CLIENT_ID", "tm")
+	t.Setenv("TYPEMORE_OIDC_1_CLIENT_SECRET", "other")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []OIDCProvider{
+		{
+			Name:         "keycloak",
+			DisplayName:  "Club SSO",
+			Issuer:       "https://sso.example.com/realms/club",
+			ClientID:     "typemore",
+			ClientSecret: "s3cret",
+		},
+		{
+			Name:         "authentik",
+			Issuer:       "https://auth.example.org/application/o/typemore/",
+			ClientID:     "tm",
+			ClientSecret: "other",
+		},
+	}, cfg.OIDCProviders)
+}
+
+func TestLoadConfigRejectsBadOIDCProviders(t *testing.T) {
+	valid := OIDCProvider{
+		Name: "keycloak", Issuer: "https://sso.example.com", ClientID: "id", ClientSecret: "secret",
+	}
+	with := func(mut func(*OIDCProvider)) []OIDCProvider {
+		p := valid
+		mut(&p)
+		return []OIDCProvider{p}
+	}
+	cases := map[string][]OIDCProvider{
+		"upper-case name":  with(func(p *OIDCProvider) { p.Name = "Keycloak" }),
+		"one-letter name":  with(func(p *OIDCProvider) { p.Name = "k" }),
+		"leading digit":    with(func(p *OIDCProvider) { p.Name = "1sso" }),
+		"built-in name":    with(func(p *OIDCProvider) { p.Name = "github" }),
+		"password name":    with(func(p *OIDCProvider) { p.Name = "email" }),
+		"missing issuer":   with(func(p *OIDCProvider) { p.Issuer = "" }),
+		"missing secret":   with(func(p *OIDCProvider) { p.ClientSecret = "" }),
+		"duplicated entry": {valid, valid},
+	}
+	for name, providers := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, validateOIDCProviders(providers))
+		})
+	}
+	assert.NoError(t, validateOIDCProviders([]OIDCProvider{valid}))
+	assert.NoError(t, validateOIDCProviders(nil))
+}
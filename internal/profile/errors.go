@@ -39,6 +39,14 @@ var (
 	// on the one section rather than misreporting the whole profile.
 	apiErrPortraitClosed = newAPIError(http.StatusForbidden, "portrait_closed",
 		"the keyboard portrait is private")
+	// apiErrFollowSelf: the caller named themselves. Every board's following
+	// scope already includes the caller, so there is nothing to add.
+	apiErrFollowSelf = apiErrBadRequest("you cannot follow yourself")
+	// apiErrFollowLimit: the caller follows maxFollowing players already.
+	// 409 rather than 400: the request is well-formed, the account's state is
+	// what refuses it, and unfollowing somebody is the fix.
+	apiErrFollowLimit = newAPIError(http.StatusConflict, "following_limit",
+		"you follow as many players as an account can")
 	// apiErrRateLimited: the search bucket is empty. Same code and shape the
 	// auth and runs domains use for the same condition — a client backing off
 	// should not have to learn a second vocabulary per surface.
@@ -0,0 +1,108 @@
+package profile
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// /api/v1/me/following — the players the caller follows, and the two writes
+// that change the list (docs/PROFILE.md, "Following").
+//
+// Following is how a player builds the circle the leaderboards' following
+// scope ranks them in. It is one-way and asks nobody's permission, so the
+// followee's privacy switch is what stands in for consent: a CLOSED profile
+// cannot be followed, and one that closes later leaves its followers' boards
+// until it reopens. The routes hang off /me rather than /users/{name} because
+// the list they change is the caller's, not the followee's — nothing about
+// being followed is shown on anybody's profile.
+
+// maxFollowing is how many players one account may follow. It bounds the
+// circle every following-scope board read filters by, which is what keeps
+// those reads a fixed cost however large the board behind them grows.
+const maxFollowing = 1000
+
+type followeeView struct {
+	Name string `json:"name"`
+	// Public is the followee's profile switch as it is now. A closed one
+	// stays on this list — the caller may still want to unfollow it — but is
+	// on none of their boards.
+	Public     bool      `json:"public"`
+	FollowedAt time.Time `json:"followedAt"`
+}
+
+type followingResponse struct {
+	Following []followeeView `json:"following"`
+}
+
+// HandleFollowing serves GET /api/v1/me/following.
+func (s *Service) HandleFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.currentUser(w, r)
+	if !ok {
+		return
+	}
+	followees, err := s.store.Following(r.Context(), userID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	views := make([]followeeView, len(followees))
+	for i, f := range followees {
+		views[i] = followeeView{Name: f.DisplayName, Public: f.ProfilePublic, FollowedAt: f.FollowedAt}
+	}
+	s.writeJSON(w, http.StatusOK, followingResponse{Following: views})
+}
+
+// HandleFollow serves POST /api/v1/me/following/{name}: 204 once the caller
+// follows the player, whether or not they already did.
+//
+// The name is resolved exactly as the public profile resolves it, and the
+// profile gate is the same one: a closed profile answers 403 profile_closed
+// here as it does on every route that would show its data.
+func (s *Service) HandleFollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.currentUser(w, r)
+	if !ok {
+		return
+	}
+	user, ok := s.resolvePublicUser(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == userID {
+		s.writeError(w, r, apiErrFollowSelf)
+		return
+	}
+	if !user.ProfilePublic {
+		s.writeError(w, r, apiErrProfileClosed)
+		return
+	}
+	err := s.store.Follow(r.Context(), userID, user.ID, maxFollowing)
+	if errors.Is(err, ErrFollowLimit) {
+		s.writeError(w, r, apiErrFollowLimit)
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleUnfollow serves DELETE /api/v1/me/following/{name}: 204 once the
+// caller does not follow the player. No profile gate — leaving is always
+// allowed, and a closed profile is exactly the one somebody may want to leave.
+func (s *Service) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.currentUser(w, r)
+	if !ok {
+		return
+	}
+	user, ok := s.resolvePublicUser(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.Unfollow(r.Context(), userID, user.ID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
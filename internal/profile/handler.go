@@ -20,8 +20,9 @@ const (
 // dateOnly is the wire format for day buckets.
 const dateOnly = "2006-01-02"
 
-// Routes mounts the profile surface. Every route requires a session — the
-// profile answers about the caller and no one else (doc.go).
+// Routes mounts the profile surface. Every route requires an authenticated
+// caller — a session, or an access token the composition root lets through —
+// since the profile answers about the caller and no one else (doc.go).
 func (s *Service) Routes(requireAuth func(http.Handler) http.Handler) http.Handler {
 	r := chi.NewRouter()
 	r.Group(func(r chi.Router) {
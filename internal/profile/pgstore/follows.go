@@ -0,0 +1,57 @@
+package pgstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/profile"
+	"github.com/typemore/typemore-server/internal/profile/profiledb"
+)
+
+// The follow graph (00042). The leaderboards read it too, through
+// following_circle; this side only writes and lists it.
+
+// Follow inserts the edge under the cap. A zero-row insert is the cap or an
+// edge that was already there, and only the first is an error — so the
+// second statement runs only on that path, and asks which.
+func (s *Store) Follow(ctx context.Context, follower, followee uuid.UUID, limit int64) error {
+	n, err := s.q.FollowUser(ctx, profiledb.FollowUserParams{
+		FollowerID: follower, FolloweeID: followee, MaxFollowing: limit,
+	})
+	if err != nil || n > 0 {
+		return err
+	}
+	already, err := s.q.IsFollowing(ctx, profiledb.IsFollowingParams{
+		FollowerID: follower, FolloweeID: followee,
+	})
+	if err != nil {
+		return err
+	}
+	if !already {
+		return profile.ErrFollowLimit
+	}
+	return nil
+}
+
+// Unfollow deletes the edge, if any.
+func (s *Store) Unfollow(ctx context.Context, follower, followee uuid.UUID) error {
+	return s.q.UnfollowUser(ctx, profiledb.UnfollowUserParams{
+		FollowerID: follower, FolloweeID: followee,
+	})
+}
+
+// Following lists the account's followees.
+func (s *Store) Following(ctx context.Context, follower uuid.UUID) ([]profile.Followee, error) {
+	rows, err := s.q.ListFollowing(ctx, follower)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]profile.Followee, len(rows))
+	for i, r := range rows {
+		out[i] = profile.Followee{
+			DisplayName: r.DisplayName, ProfilePublic: r.ProfilePublic, FollowedAt: r.FollowedAt,
+		}
+	}
+	return out, nil
+}
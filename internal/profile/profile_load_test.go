@@ -277,6 +277,8 @@ SELECT bucket_key, run_id, score, wpm::float8, raw::float8,
        acc::float8, grade, mods, quote_source, achieved_at
 FROM leaderboard_entries
 WHERE user_id = $1
+  AND daily_day IS NULL
+  AND period IS NULL
 ORDER BY achieved_at DESC`
 
 // The runs-list page, restated from internal/runs/queries.sql: the owner's
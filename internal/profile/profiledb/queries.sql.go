@@ -45,6 +45,32 @@ func (q *Queries) DeleteUserLink(ctx context.Context, arg DeleteUserLinkParams)
 	return err
 }
 
+const followUser = `-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id)
+SELECT $1::uuid, $2::uuid
+WHERE (SELECT count(*) FROM follows WHERE follower_id = $1) < $3::bigint
+ON CONFLICT (follower_id, followee_id) DO NOTHING
+`
+
+type FollowUserParams struct {
+	FollowerID   uuid.UUID
+	FolloweeID   uuid.UUID
+	MaxFollowing int64
+}
+
+// Follow one player, at most @max_following of them in all. The cap is in the
+// statement rather than a count the handler read first; two follows racing at
+// the limit can still both land, which overshoots a bound on cost by one row.
+// Zero rows is either the cap or a follow that already existed; the store
+// tells them apart.
+func (q *Queries) FollowUser(ctx context.Context, arg FollowUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, followUser, arg.FollowerID, arg.FolloweeID, arg.MaxFollowing)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getProfileActivity = `-- name: GetProfileActivity :many
 SELECT (r.created_at AT TIME ZONE 'UTC')::date                        AS day,
        count(*)::int                                                AS tests,
@@ -330,6 +356,8 @@ SELECT bucket_key, run_id, score, wpm::float8 AS wpm, raw::float8 AS raw,
        acc::float8 AS acc, grade, mods, quote_source, achieved_at
 FROM leaderboard_entries
 WHERE user_id = $1
+  AND daily_day IS NULL
+  AND period IS NULL
 ORDER BY achieved_at DESC
 `
 
@@ -351,6 +379,11 @@ type GetProfilePBsRow struct {
 // not a computation. Own-profile view — deliberately the raw entries table,
 // not the ban-filtered leaderboard_rows: a player's own bests are their own
 // data, and this surface is session-scoped to its owner.
+//
+// A daily-challenge slot is not a personal best — it is the one attempt that
+// day allowed, and the same run already holds its ordinary slot — so the
+// daily boards' rows (00037) are not cards. Nor are a window's (00038): this
+// week's best is the all-time card or a worse run, never a second record.
 func (q *Queries) GetProfilePBs(ctx context.Context, userID uuid.UUID) ([]GetProfilePBsRow, error) {
 	rows, err := q.db.Query(ctx, getProfilePBs, userID)
 	if err != nil {
@@ -587,6 +620,8 @@ SELECT bucket_key, run_id, score, wpm::float8 AS wpm, raw::float8 AS raw,
        acc::float8 AS acc, grade, mods, quote_source, achieved_at
 FROM leaderboard_entries e
 WHERE e.user_id = $1
+  AND e.daily_day IS NULL
+  AND e.period IS NULL
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = e.user_id)
 ORDER BY achieved_at DESC
 `
@@ -831,6 +866,65 @@ func (q *Queries) GetPublicProfileUser(ctx context.Context, displayName string)
 	return i, err
 }
 
+const isFollowing = `-- name: IsFollowing :one
+SELECT EXISTS (SELECT 1 FROM follows
+               WHERE follower_id = $1 AND followee_id = $2)
+`
+
+type IsFollowingParams struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+}
+
+func (q *Queries) IsFollowing(ctx context.Context, arg IsFollowingParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isFollowing, arg.FollowerID, arg.FolloweeID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listFollowing = `-- name: ListFollowing :many
+SELECT u.display_name, u.profile_public, f.created_at AS followed_at
+FROM follows f
+         JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = $1
+ORDER BY f.created_at DESC, u.display_name
+`
+
+type ListFollowingRow struct {
+	DisplayName   string
+	ProfilePublic bool
+	FollowedAt    time.Time
+}
+
+// The players one account follows, newest follow first. Closed profiles are
+// listed, flagged: this is the follower's own list, and the flag is what the
+// header shows anyone — while the leaderboards leave them out
+// (following_circle, 00042).
+func (q *Queries) ListFollowing(ctx context.Context, followerID uuid.UUID) ([]ListFollowingRow, error) {
+	rows, err := q.db.Query(ctx, listFollowing, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingRow{}
+	for rows.Next() {
+		var i ListFollowingRow
+		if err := rows.Scan(
+			&i.DisplayName,
+			&i.ProfilePublic,
+			&i.FollowedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listGrantedBadges = `-- name: ListGrantedBadges :many
 SELECT badge_code, granted_at, display_order
 FROM user_badges
@@ -1045,6 +1139,20 @@ func (q *Queries) SearchUsersByName(ctx context.Context, arg SearchUsersByNamePa
 	return items, nil
 }
 
+const unfollowUser = `-- name: UnfollowUser :exec
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2
+`
+
+type UnfollowUserParams struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+}
+
+func (q *Queries) UnfollowUser(ctx context.Context, arg UnfollowUserParams) error {
+	_, err := q.db.Exec(ctx, unfollowUser, arg.FollowerID, arg.FolloweeID)
+	return err
+}
+
 const updateProfileIdentity = `-- name: UpdateProfileIdentity :exec
 UPDATE users
 SET bio      = $1,
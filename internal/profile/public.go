@@ -187,6 +187,13 @@ type headerView struct {
 	// (docs/MODERATION.md). omitempty keeps every unbanned header
 	// byte-identical to what it served before the mark existed.
 	Restricted bool `json:"restricted,omitempty"`
+	// TP and TPRank are the player's profile rating and their place in the
+	// global ranking (GET /rating). They are on the header, closed profiles
+	// included, for the reason the name is: the ranking already shows both to
+	// anyone. Absent for a player with no rating yet, and for a restricted
+	// one — the ranking hides them, so there is no rank to give.
+	TP     *float64 `json:"tp,omitempty"`
+	TPRank *int64   `json:"tpRank,omitempty"`
 
 	// The identity half (00029), present only on an OPEN profile and only when
 	// its owner filled it in. omitempty throughout: an untouched profile serves
@@ -238,6 +245,18 @@ func (s *Service) handlePublicHeader(w http.ResponseWriter, r *http.Request) {
 		}
 		view.Restricted = restricted
 	}
+	if s.ratings != nil && !view.Restricted {
+		tp, rank, ok, err := s.ratings.UserRating(r.Context(), user.ID)
+		if err != nil {
+			// Reported, not fatal, like the mark above: the header is the
+			// player's identity first, and a rating it cannot read is a rating
+			// it does not show.
+			s.log.Error("resolve public rating", "err", err, "userId", user.ID)
+		}
+		if ok {
+			view.TP, view.TPRank = &tp, &rank
+		}
+	}
 	if user.ProfilePublic || s.isOwner(r, user) {
 		if err := s.decorateHeader(r, user.ID, &view); err != nil {
 			s.writeError(w, r, err)
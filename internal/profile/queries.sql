@@ -181,10 +181,17 @@ FROM pts;
 -- not a computation. Own-profile view — deliberately the raw entries table,
 -- not the ban-filtered leaderboard_rows: a player's own bests are their own
 -- data, and this surface is session-scoped to its owner.
+--
+-- A daily-challenge slot is not a personal best — it is the one attempt that
+-- day allowed, and the same run already holds its ordinary slot — so the
+-- daily boards' rows (00037) are not cards. Nor are a window's (00038): this
+-- week's best is the all-time card or a worse run, never a second record.
 SELECT bucket_key, run_id, score, wpm::float8 AS wpm, raw::float8 AS raw,
        acc::float8 AS acc, grade, mods, quote_source, achieved_at
 FROM leaderboard_entries
 WHERE user_id = $1
+  AND daily_day IS NULL
+  AND period IS NULL
 ORDER BY achieved_at DESC;
 
 -- name: GetProfileKeyboard :many
@@ -271,6 +278,8 @@ SELECT bucket_key, run_id, score, wpm::float8 AS wpm, raw::float8 AS raw,
        acc::float8 AS acc, grade, mods, quote_source, achieved_at
 FROM leaderboard_entries e
 WHERE e.user_id = $1
+  AND e.daily_day IS NULL
+  AND e.period IS NULL
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = e.user_id)
 ORDER BY achieved_at DESC;
 
@@ -417,3 +426,32 @@ WHERE user_id = @user_id AND revoked_at IS NULL;
 UPDATE user_badges
 SET display_order = @display_order
 WHERE user_id = @user_id AND badge_code = @badge_code AND revoked_at IS NULL;
+
+-- name: FollowUser :execrows
+-- Follow one player, at most @max_following of them in all. The cap is in the
+-- statement rather than a count the handler read first; two follows racing at
+-- the limit can still both land, which overshoots a bound on cost by one row.
+-- Zero rows is either the cap or a follow that already existed; the store
+-- tells them apart.
+INSERT INTO follows (follower_id, followee_id)
+SELECT @follower_id::uuid, @followee_id::uuid
+WHERE (SELECT count(*) FROM follows WHERE follower_id = @follower_id) < @max_following::bigint
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: IsFollowing :one
+SELECT EXISTS (SELECT 1 FROM follows
+               WHERE follower_id = @follower_id AND followee_id = @followee_id);
+
+-- name: UnfollowUser :exec
+DELETE FROM follows WHERE follower_id = @follower_id AND followee_id = @followee_id;
+
+-- name: ListFollowing :many
+-- The players one account follows, newest follow first. Closed profiles are
+-- listed, flagged: this is the follower's own list, and the flag is what the
+-- header shows anyone — while the leaderboards leave them out
+-- (following_circle, 00042).
+SELECT u.display_name, u.profile_public, f.created_at AS followed_at
+FROM follows f
+         JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = @follower_id
+ORDER BY f.created_at DESC, u.display_name;
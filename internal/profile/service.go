@@ -58,12 +58,21 @@ type Restrictions interface {
 	IsRestricted(ctx context.Context, userID uuid.UUID) (bool, error)
 }
 
+// Ratings answers a player's TP and global rank for the public header. ok is
+// false for a player with no rating yet. Declared here, at the consumer, with
+// plain values so the rating domain's types never reach this package.
+type Ratings interface {
+	UserRating(ctx context.Context, userID uuid.UUID) (tp float64, rank int64, ok bool, err error)
+}
+
 // Service serves the session-scoped profile read model.
 type Service struct {
 	store  Store
 	userID UserIDFunc
 	// restrictions marks a banned account's PUBLIC header (nil = never marked).
 	restrictions Restrictions
+	// ratings puts TP on the public header (nil = never shown).
+	ratings Ratings
 	// searchLimiter rations the ONE public route whose cost does not scale with
 	// a single account: /users?q= reads an index built over every user, while
 	// every other route on this surface is bounded by one player's history. It
@@ -91,6 +100,13 @@ func (s *Service) WithRestrictions(r Restrictions) *Service {
 	return s
 }
 
+// WithRatings wires the TP lookup behind the public header's `tp` and
+// `tpRank`. Without it neither is ever present.
+func (s *Service) WithRatings(r Ratings) *Service {
+	s.ratings = r
+	return s
+}
+
 // --- shared HTTP helpers (mirroring the sibling domains', kept private) -----
 
 func (s *Service) writeJSON(w http.ResponseWriter, status int, v any) {
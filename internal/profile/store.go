@@ -103,6 +103,18 @@ type KeyboardKey struct {
 	IntervalCount int64
 }
 
+// ErrFollowLimit is returned by Store.Follow when the follower already follows
+// as many players as one account may.
+var ErrFollowLimit = errors.New("profile: follow limit reached")
+
+// Followee is one entry of an account's following list: who, whether their
+// profile is open right now, and since when.
+type Followee struct {
+	DisplayName   string
+	ProfilePublic bool
+	FollowedAt    time.Time
+}
+
 // PublicUser is the public surface's name resolution: the row the header
 // answers from, and the two switches every other public route gates on.
 type PublicUser struct {
@@ -266,4 +278,16 @@ type Store interface {
 	// and the showcase move together or not at all. A half-applied patch would
 	// leave a profile the owner never asked for and cannot see they have.
 	ApplyProfilePatch(ctx context.Context, userID uuid.UUID, patch Patch) error
+
+	// --- the follow graph (00042) ---
+
+	// Follow records that follower follows followee, at most limit players in
+	// all, or reports ErrFollowLimit. Following somebody already followed is
+	// a success that changes nothing.
+	Follow(ctx context.Context, follower, followee uuid.UUID, limit int64) error
+	// Unfollow removes the edge; removing one that is not there is not an
+	// error.
+	Unfollow(ctx context.Context, follower, followee uuid.UUID) error
+	// Following lists the players an account follows, newest follow first.
+	Following(ctx context.Context, follower uuid.UUID) ([]Followee, error)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/typemore/typemore-server/internal/difficulty"
 	"github.com/typemore/typemore-server/internal/platform/httpx"
 )
 
@@ -48,13 +49,20 @@ type metaView struct {
 	Length     int32     `json:"length"`
 	LenGroup   string    `json:"lenGroup"`
 	TextHash   string    `json:"textHash"`
+	// Stars is the difficulty rating on the catalogue's 1–5 scale, absent on
+	// a row no import has rated yet rather than a made-up middle.
+	Stars *float64 `json:"stars,omitempty"`
 }
 
 func toMetaView(m Meta) metaView {
-	return metaView{
+	v := metaView{
 		ID: m.ID, Lang: m.Lang, UpstreamID: m.UpstreamID, Source: m.Source,
 		Length: m.Length, LenGroup: m.LenGroup.String(), TextHash: m.TextHash,
 	}
+	if m.Difficulty != nil {
+		v.Stars = new(difficulty.Stars(*m.Difficulty))
+	}
+	return v
 }
 
 // quoteView is a full quote: the metadata above plus the bytes to type.
@@ -204,12 +204,17 @@ type storedQuote struct {
 	TextHash   string
 	Superseded bool
 	CreatedAt  time.Time
+	// Difficulty and DifficultyVersion are the stored rating, nil together
+	// until an import rates the row.
+	Difficulty        *float64
+	DifficultyVersion *int16
 }
 
 func (r *registry) storedQuotes() []storedQuote {
 	r.t.Helper()
 	rows, err := r.pool.Query(context.Background(), `
-		SELECT id, lang, upstream_id, text, length, len_group, text_hash, superseded, created_at
+		SELECT id, lang, upstream_id, text, length, len_group, text_hash, superseded, created_at,
+		       difficulty, difficulty_version
 		FROM quotes ORDER BY lang, upstream_id, created_at, id`)
 	require.NoError(r.t, err)
 	defer rows.Close()
@@ -218,7 +223,7 @@ func (r *registry) storedQuotes() []storedQuote {
 	for rows.Next() {
 		var q storedQuote
 		require.NoError(r.t, rows.Scan(&q.ID, &q.Lang, &q.UpstreamID, &q.Text, &q.Length,
-			&q.LenGroup, &q.TextHash, &q.Superseded, &q.CreatedAt))
+			&q.LenGroup, &q.TextHash, &q.Superseded, &q.CreatedAt, &q.Difficulty, &q.DifficultyVersion))
 		out = append(out, q)
 	}
 	require.NoError(r.t, rows.Err())
@@ -21,6 +21,7 @@ type metaBody struct {
 	Length     int32     `json:"length"`
 	LenGroup   string    `json:"lenGroup"`
 	TextHash   string    `json:"textHash"`
+	Stars      *float64  `json:"stars"`
 }
 
 type listBody struct {
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/typemore/typemore-server/internal/difficulty"
 	"github.com/typemore/typemore-server/internal/quote"
 	"github.com/typemore/typemore-server/internal/quote/corpus"
 )
@@ -235,3 +236,55 @@ func TestAFailedLanguageLeavesTheCorpusAlone(t *testing.T) {
 		assert.False(t, row.Superseded, "nothing may be retired by a pass that failed")
 	}
 }
+
+// A rating is derived from the bytes, not part of them (00043), so it is the
+// one thing an import may rewrite on an existing row. The test walks the three
+// passes that matter: rated on insert, re-rated in place by a newer model with
+// the quote still Unchanged, and left alone by a pass that does not rate.
+func TestImportRatesAndReratesInPlace(t *testing.T) {
+	r := newRegistry(t)
+	rows := r.incoming(
+		spec{upstreamID: 1, length: 40, group: quote.LenShort},
+		spec{upstreamID: 2, length: 150, group: quote.LenMedium},
+	)
+	rate := func(version int16, value float64) {
+		for i := range rows {
+			rows[i].Difficulty = difficulty.Rating{Version: version, Value: value}
+		}
+	}
+
+	rate(1, 0.25)
+	require.Equal(t, quote.ImportStats{Inserted: 2}, r.importLang("german", rows))
+	before := r.storedQuotes()
+	for _, row := range before {
+		require.NotNil(t, row.Difficulty)
+		assert.Equal(t, 0.25, *row.Difficulty)
+		assert.EqualValues(t, 1, *row.DifficultyVersion)
+	}
+	assert.Equal(t, quote.ImportStats{Unchanged: 2}, r.importLang("german", rows),
+		"a matching rating must not be rewritten")
+
+	rate(2, 0.5)
+	assert.Equal(t, quote.ImportStats{Unchanged: 2, Rerated: 2}, r.importLang("german", rows))
+	after := r.storedQuotes()
+	require.Len(t, after, 2, "a re-rating must never add a revision")
+	for i, row := range after {
+		assert.Equal(t, before[i].ID, row.ID)
+		assert.Equal(t, before[i].Text, row.Text)
+		assert.Equal(t, 0.5, *row.Difficulty)
+		assert.EqualValues(t, 2, *row.DifficultyVersion)
+	}
+
+	body := decodeInto[listBody](t, r.get("/api/v1/quotes"))
+	require.Len(t, body.Quotes, 2)
+	for _, q := range body.Quotes {
+		require.NotNil(t, q.Stars)
+		assert.Equal(t, difficulty.Stars(0.5), *q.Stars)
+	}
+
+	rate(0, 0)
+	assert.Equal(t, quote.ImportStats{Unchanged: 2}, r.importLang("german", rows))
+	for _, row := range r.storedQuotes() {
+		assert.Equal(t, 0.5, *row.Difficulty, "an unrated pass must not erase a rating")
+	}
+}
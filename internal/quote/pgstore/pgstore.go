@@ -61,7 +61,7 @@ func (s *Store) Page(ctx context.Context, f quote.Filter, after *quote.Cursor, l
 			ID: rows[i].ID, Lang: rows[i].Lang, UpstreamID: rows[i].UpstreamID,
 			Source: rows[i].Source, Length: rows[i].Length,
 			LenGroup: quote.LenGroup(rows[i].LenGroup), TextHash: rows[i].TextHash,
-			CreatedAt: rows[i].CreatedAt,
+			CreatedAt: rows[i].CreatedAt, Difficulty: rows[i].Difficulty,
 		}
 	}
 	return out, nil
@@ -102,6 +102,7 @@ func (s *Store) ByID(ctx context.Context, id uuid.UUID) (quote.Quote, error) {
 			Length: row.Length, LenGroup: quote.LenGroup(row.LenGroup),
 			TextHash: row.TextHash, Superseded: row.Superseded,
 			Withdrawn: row.Withdrawn, CreatedAt: row.CreatedAt,
+			Difficulty: row.Difficulty,
 		},
 		Text: row.Text,
 	}, nil
@@ -132,6 +133,7 @@ func randomRowToQuote(r quotedb.PickRandomQuoteRow) quote.Quote {
 			ID: r.ID, Lang: r.Lang, UpstreamID: r.UpstreamID, Source: r.Source,
 			Length: r.Length, LenGroup: quote.LenGroup(r.LenGroup),
 			TextHash: r.TextHash, Superseded: r.Superseded, CreatedAt: r.CreatedAt,
+			Difficulty: r.Difficulty,
 		},
 		Text: r.Text,
 	}
@@ -154,6 +156,11 @@ func randomRowToQuote(r quotedb.PickRandomQuoteRow) quote.Quote {
 //	the bytes differ               -> INSERT beside it and retire
 //	                                  the previous revision(s)     (Superseded)
 //
+// The difficulty rating is the one thing written to a row that already exists:
+// it is derived from the bytes rather than part of them (00043), so bringing it
+// up to date changes nothing a run is replayed against. That is counted apart
+// (Rerated) and does not move a quote out of Unchanged.
+//
 // It is driven per quote rather than as one bulk statement on purpose: the
 // three outcomes have to be COUNTED, and a COPY that reports "2286 rows" tells
 // an operator nothing about whether a re-import quietly replaced the corpus.
@@ -168,10 +175,13 @@ func (s *Store) Import(ctx context.Context, lang string, quotes []quote.Incoming
 	q := s.q.WithTx(tx)
 
 	for i := range quotes {
-		outcome, err := publish(ctx, q, lang, &quotes[i])
+		outcome, rerated, err := publish(ctx, q, lang, &quotes[i])
 		if err != nil {
 			return quote.ImportStats{}, err
 		}
+		if rerated {
+			stats.Rerated++
+		}
 		switch outcome {
 		case outcomeInserted:
 			stats.Inserted++
@@ -208,8 +218,9 @@ const (
 )
 
 // publish brings one (lang, upstream_id) in line with the incoming bytes and
-// reports which of the three things it did.
-func publish(ctx context.Context, q *quotedb.Queries, lang string, in *quote.Incoming) (outcome, error) {
+// reports which of the three things it did, and whether it re-rated a revision
+// that was already there.
+func publish(ctx context.Context, q *quotedb.Queries, lang string, in *quote.Incoming) (outcome, bool, error) {
 	rev, err := q.FindQuoteRevision(ctx, quotedb.FindQuoteRevisionParams{
 		Lang: lang, UpstreamID: in.UpstreamID, TextHash: in.TextHash,
 	})
@@ -220,37 +231,67 @@ func publish(ctx context.Context, q *quotedb.Queries, lang string, in *quote.Inc
 		// reverted to a text it had previously replaced.
 		restored, err := q.RepublishQuoteRevision(ctx, rev.ID)
 		if err != nil {
-			return 0, fmt.Errorf("quote/pgstore: republish %s#%d: %w", lang, in.UpstreamID, err)
+			return 0, false, fmt.Errorf("quote/pgstore: republish %s#%d: %w", lang, in.UpstreamID, err)
 		}
 		retired, err := supersedeOthers(ctx, q, lang, in.UpstreamID, rev.ID)
 		if err != nil {
-			return 0, err
+			return 0, false, err
+		}
+		rerated, err := rerate(ctx, q, lang, in, rev)
+		if err != nil {
+			return 0, false, err
 		}
 		if restored > 0 || retired > 0 {
-			return outcomeSuperseded, nil
+			return outcomeSuperseded, rerated, nil
 		}
-		return outcomeUnchanged, nil
+		return outcomeUnchanged, rerated, nil
 
 	case errors.Is(err, pgx.ErrNoRows):
-		id, err := q.InsertQuoteRevision(ctx, quotedb.InsertQuoteRevisionParams{
+		params := quotedb.InsertQuoteRevisionParams{
 			Lang: lang, UpstreamID: in.UpstreamID, Text: in.Text, Source: in.Source,
 			Length: in.Length, LenGroup: int16(in.LenGroup), TextHash: in.TextHash,
-		})
+		}
+		if in.Difficulty.Rated() {
+			params.Difficulty = &in.Difficulty.Value
+			params.DifficultyVersion = &in.Difficulty.Version
+		}
+		id, err := q.InsertQuoteRevision(ctx, params)
 		if err != nil {
-			return 0, fmt.Errorf("quote/pgstore: insert %s#%d: %w", lang, in.UpstreamID, err)
+			return 0, false, fmt.Errorf("quote/pgstore: insert %s#%d: %w", lang, in.UpstreamID, err)
 		}
 		retired, err := supersedeOthers(ctx, q, lang, in.UpstreamID, id)
 		if err != nil {
-			return 0, err
+			return 0, false, err
 		}
 		if retired > 0 {
-			return outcomeSuperseded, nil
+			return outcomeSuperseded, false, nil
 		}
-		return outcomeInserted, nil
+		return outcomeInserted, false, nil
 
 	default:
-		return 0, fmt.Errorf("quote/pgstore: find revision %s#%d: %w", lang, in.UpstreamID, err)
+		return 0, false, fmt.Errorf("quote/pgstore: find revision %s#%d: %w", lang, in.UpstreamID, err)
+	}
+}
+
+// rerate brings a stored revision's rating in line with the incoming one. The
+// comparison is made here first so an unchanged re-import sends no UPDATE at
+// all; a double round-trips through Postgres exactly, so equality is safe.
+func rerate(ctx context.Context, q *quotedb.Queries, lang string, in *quote.Incoming, rev quotedb.FindQuoteRevisionRow) (bool, error) {
+	r := in.Difficulty
+	if !r.Rated() {
+		return false, nil
 	}
+	if rev.Difficulty != nil && rev.DifficultyVersion != nil &&
+		*rev.Difficulty == r.Value && *rev.DifficultyVersion == r.Version {
+		return false, nil
+	}
+	n, err := q.RateQuoteRevision(ctx, quotedb.RateQuoteRevisionParams{
+		Difficulty: &r.Value, DifficultyVersion: &r.Version, ID: rev.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("quote/pgstore: rate %s#%d: %w", lang, in.UpstreamID, err)
+	}
+	return n > 0, nil
 }
 
 func supersedeOthers(ctx context.Context, q *quotedb.Queries, lang string, upstreamID int32, keep uuid.UUID) (int64, error) {
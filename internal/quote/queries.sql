@@ -28,7 +28,7 @@
 -- (00025). Both are in quotes_browse_idx's partial predicate, so neither costs a
 -- filter; they are separate columns because they have separate owners — the
 -- importer moves one, a moderator moves the other.
-SELECT id, lang, upstream_id, source, length, len_group, text_hash, created_at
+SELECT id, lang, upstream_id, source, length, len_group, text_hash, created_at, difficulty
 FROM quotes
 WHERE NOT superseded
   AND withdrawn_at IS NULL
@@ -73,7 +73,7 @@ pick AS (
     LIMIT 1
 )
 SELECT q.id, q.lang, q.upstream_id, q.text, q.source, q.length, q.len_group,
-       q.text_hash, q.superseded, q.created_at
+       q.text_hash, q.superseded, q.created_at, q.difficulty
 FROM quotes q
          JOIN pick ON pick.id = q.id;
 
@@ -88,7 +88,7 @@ FROM quotes q
 -- longer offered" instead of silently linking to something the browse endpoint
 -- will never return again.
 SELECT id, lang, upstream_id, text, source, length, len_group, text_hash,
-       superseded, (withdrawn_at IS NOT NULL)::boolean AS withdrawn, created_at
+       superseded, (withdrawn_at IS NOT NULL)::boolean AS withdrawn, created_at, difficulty
 FROM quotes
 WHERE id = @id;
 
@@ -97,8 +97,9 @@ WHERE id = @id;
 -- name: FindQuoteRevision :one
 -- The revision of (lang, upstream_id) that already carries these exact bytes.
 -- Keyed on the hash rather than on the text so the comparison is an index
--- lookup on quotes_revision_idx instead of a full text equality.
-SELECT id, superseded
+-- lookup on quotes_revision_idx instead of a full text equality. The rating
+-- rides along so the importer can tell a stale one from a current one.
+SELECT id, superseded, difficulty, difficulty_version
 FROM quotes
 WHERE lang = @lang AND upstream_id = @upstream_id AND text_hash = @text_hash;
 
@@ -106,8 +107,10 @@ WHERE lang = @lang AND upstream_id = @upstream_id AND text_hash = @text_hash;
 -- Publish new bytes under an existing or new (lang, upstream_id). The id is
 -- generated here rather than in Go so the whole import is one round trip per
 -- statement and the database owns row identity, as everywhere else.
-INSERT INTO quotes (id, lang, upstream_id, text, source, length, len_group, text_hash)
-VALUES (gen_random_uuid(), @lang, @upstream_id, @text, @source, @length, @len_group, @text_hash)
+INSERT INTO quotes (id, lang, upstream_id, text, source, length, len_group, text_hash,
+                    difficulty, difficulty_version)
+VALUES (gen_random_uuid(), @lang, @upstream_id, @text, @source, @length, @len_group, @text_hash,
+        sqlc.narg(difficulty), sqlc.narg(difficulty_version))
 RETURNING id;
 
 -- name: SupersedeOtherRevisions :execrows
@@ -128,6 +131,18 @@ UPDATE quotes
 SET superseded = false
 WHERE id = @id AND superseded;
 
+-- name: RateQuoteRevision :execrows
+-- Store a revision's difficulty rating (00043). The one UPDATE the importer
+-- makes to a row it did not just insert apart from the superseded flag, and
+-- like that one it never touches `text`: a rating is derived from the bytes,
+-- not part of them. The rowcount is 0 when the stored rating already matches,
+-- which keeps an unchanged re-import writing nothing.
+UPDATE quotes
+SET difficulty = @difficulty, difficulty_version = @difficulty_version
+WHERE id = @id
+  AND (difficulty, difficulty_version)
+      IS DISTINCT FROM (@difficulty::double precision, @difficulty_version::smallint);
+
 -- name: CountQuotes :one
 SELECT count(*) FROM quotes;
 
@@ -25,7 +25,7 @@ func (q *Queries) CountQuotes(ctx context.Context) (int64, error) {
 
 const findQuoteRevision = `-- name: FindQuoteRevision :one
 
-SELECT id, superseded
+SELECT id, superseded, difficulty, difficulty_version
 FROM quotes
 WHERE lang = $1 AND upstream_id = $2 AND text_hash = $3
 `
@@ -37,24 +37,32 @@ type FindQuoteRevisionParams struct {
 }
 
 type FindQuoteRevisionRow struct {
-	ID         uuid.UUID
-	Superseded bool
+	ID                uuid.UUID
+	Superseded        bool
+	Difficulty        *float64
+	DifficultyVersion *int16
 }
 
 // --- import (make import-quotes) ---
 // The revision of (lang, upstream_id) that already carries these exact bytes.
 // Keyed on the hash rather than on the text so the comparison is an index
-// lookup on quotes_revision_idx instead of a full text equality.
+// lookup on quotes_revision_idx instead of a full text equality. The rating
+// rides along so the importer can tell a stale one from a current one.
 func (q *Queries) FindQuoteRevision(ctx context.Context, arg FindQuoteRevisionParams) (FindQuoteRevisionRow, error) {
 	row := q.db.QueryRow(ctx, findQuoteRevision, arg.Lang, arg.UpstreamID, arg.TextHash)
 	var i FindQuoteRevisionRow
-	err := row.Scan(&i.ID, &i.Superseded)
+	err := row.Scan(
+		&i.ID,
+		&i.Superseded,
+		&i.Difficulty,
+		&i.DifficultyVersion,
+	)
 	return i, err
 }
 
 const getQuote = `-- name: GetQuote :one
 SELECT id, lang, upstream_id, text, source, length, len_group, text_hash,
-       superseded, (withdrawn_at IS NOT NULL)::boolean AS withdrawn, created_at
+       superseded, (withdrawn_at IS NOT NULL)::boolean AS withdrawn, created_at, difficulty
 FROM quotes
 WHERE id = $1
 `
@@ -71,6 +79,7 @@ type GetQuoteRow struct {
 	Superseded bool
 	Withdrawn  bool
 	CreatedAt  time.Time
+	Difficulty *float64
 }
 
 // One quote by id, text included, SUPERSEDED AND WITHDRAWN REVISIONS INCLUDED.
@@ -97,6 +106,7 @@ func (q *Queries) GetQuote(ctx context.Context, id uuid.UUID) (GetQuoteRow, erro
 		&i.Superseded,
 		&i.Withdrawn,
 		&i.CreatedAt,
+		&i.Difficulty,
 	)
 	return i, err
 }
@@ -147,19 +157,23 @@ func (q *Queries) GetQuoteWithdrawal(ctx context.Context, id uuid.UUID) (GetQuot
 }
 
 const insertQuoteRevision = `-- name: InsertQuoteRevision :one
-INSERT INTO quotes (id, lang, upstream_id, text, source, length, len_group, text_hash)
-VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7)
+INSERT INTO quotes (id, lang, upstream_id, text, source, length, len_group, text_hash,
+                    difficulty, difficulty_version)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7,
+        $8, $9)
 RETURNING id
 `
 
 type InsertQuoteRevisionParams struct {
-	Lang       string
-	UpstreamID int32
-	Text       string
-	Source     string
-	Length     int32
-	LenGroup   int16
-	TextHash   string
+	Lang              string
+	UpstreamID        int32
+	Text              string
+	Source            string
+	Length            int32
+	LenGroup          int16
+	TextHash          string
+	Difficulty        *float64
+	DifficultyVersion *int16
 }
 
 // Publish new bytes under an existing or new (lang, upstream_id). The id is
@@ -174,6 +188,8 @@ func (q *Queries) InsertQuoteRevision(ctx context.Context, arg InsertQuoteRevisi
 		arg.Length,
 		arg.LenGroup,
 		arg.TextHash,
+		arg.Difficulty,
+		arg.DifficultyVersion,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
@@ -182,7 +198,7 @@ func (q *Queries) InsertQuoteRevision(ctx context.Context, arg InsertQuoteRevisi
 
 const listQuotes = `-- name: ListQuotes :many
 
-SELECT id, lang, upstream_id, source, length, len_group, text_hash, created_at
+SELECT id, lang, upstream_id, source, length, len_group, text_hash, created_at, difficulty
 FROM quotes
 WHERE NOT superseded
   AND withdrawn_at IS NULL
@@ -213,6 +229,7 @@ type ListQuotesRow struct {
 	LenGroup   int16
 	TextHash   string
 	CreatedAt  time.Time
+	Difficulty *float64
 }
 
 // The quote registry (docs/QUOTES.md).
@@ -269,6 +286,7 @@ func (q *Queries) ListQuotes(ctx context.Context, arg ListQuotesParams) ([]ListQ
 			&i.LenGroup,
 			&i.TextHash,
 			&i.CreatedAt,
+			&i.Difficulty,
 		); err != nil {
 			return nil, err
 		}
@@ -326,7 +344,7 @@ pick AS (
     LIMIT 1
 )
 SELECT q.id, q.lang, q.upstream_id, q.text, q.source, q.length, q.len_group,
-       q.text_hash, q.superseded, q.created_at
+       q.text_hash, q.superseded, q.created_at, q.difficulty
 FROM quotes q
          JOIN pick ON pick.id = q.id
 `
@@ -347,6 +365,7 @@ type PickRandomQuoteRow struct {
 	TextHash   string
 	Superseded bool
 	CreatedAt  time.Time
+	Difficulty *float64
 }
 
 // One published quote, drawn uniformly from the rows the filter admits.
@@ -382,10 +401,38 @@ func (q *Queries) PickRandomQuote(ctx context.Context, arg PickRandomQuoteParams
 		&i.TextHash,
 		&i.Superseded,
 		&i.CreatedAt,
+		&i.Difficulty,
 	)
 	return i, err
 }
 
+const rateQuoteRevision = `-- name: RateQuoteRevision :execrows
+UPDATE quotes
+SET difficulty = $1, difficulty_version = $2
+WHERE id = $3
+  AND (difficulty, difficulty_version)
+      IS DISTINCT FROM ($1::double precision, $2::smallint)
+`
+
+type RateQuoteRevisionParams struct {
+	Difficulty        *float64
+	DifficultyVersion *int16
+	ID                uuid.UUID
+}
+
+// Store a revision's difficulty rating (00043). The one UPDATE the importer
+// makes to a row it did not just insert apart from the superseded flag, and
+// like that one it never touches `text`: a rating is derived from the bytes,
+// not part of them. The rowcount is 0 when the stored rating already matches,
+// which keeps an unchanged re-import writing nothing.
+func (q *Queries) RateQuoteRevision(ctx context.Context, arg RateQuoteRevisionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rateQuoteRevision, arg.Difficulty, arg.DifficultyVersion, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const republishQuoteRevision = `-- name: RepublishQuoteRevision :execrows
 UPDATE quotes
 SET superseded = false
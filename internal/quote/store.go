@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/difficulty"
 )
 
 // ErrNotFound is returned by the store when no quote matches — an unknown id,
@@ -79,6 +81,11 @@ type Meta struct {
 	// return it is false by construction.
 	Withdrawn bool
 	CreatedAt time.Time
+	// Difficulty is the stored rating's value on [0, 1] (docs/DIFFICULTY.md),
+	// nil until an import has rated the row. The version is not carried: the
+	// importer keeps every published row on the current model, and a reader
+	// has nothing to do with an older one but show it.
+	Difficulty *float64
 }
 
 // Quote is a full quote, text included. Only the two single-quote endpoints
@@ -223,9 +230,9 @@ func HashText(dv DictVersioner, text string) (string, error) {
 }
 
 // Incoming is one quote as the importer presents it: upstream's own fields plus
-// the two values this system derives (LenGroup from the corpus's own thresholds,
-// TextHash from the core). The language is not on the row because a whole
-// language is published in one transaction.
+// the values this system derives (LenGroup from the corpus's own thresholds,
+// TextHash from the core, Difficulty from the analyser). The language is not on
+// the row because a whole language is published in one transaction.
 type Incoming struct {
 	UpstreamID int32
 	Text       string
@@ -233,6 +240,10 @@ type Incoming struct {
 	Length     int32
 	LenGroup   LenGroup
 	TextHash   string
+	// Difficulty is the rating to store. The zero Rating leaves the row
+	// unrated — and leaves an existing rating alone, so an importer that does
+	// not rate cannot erase what one that does has written.
+	Difficulty difficulty.Rating
 }
 
 // ImportStats is what publishing one language did. The three counts partition
@@ -246,6 +257,13 @@ type ImportStats struct {
 	Superseded int
 	// Unchanged: the published revision already carries these exact bytes.
 	Unchanged int
+
+	// Rerated counts the existing revisions whose stored rating this pass
+	// rewrote — a model bump, or a dictionary that moved under the language.
+	// It sits OUTSIDE the partition above: a re-rated quote is also Unchanged,
+	// because its text is, and a rating written with a fresh insert is not
+	// counted at all.
+	Rerated int
 }
 
 // Total is the number of quotes the pass considered.
@@ -256,4 +274,5 @@ func (s *ImportStats) Add(o ImportStats) {
 	s.Inserted += o.Inserted
 	s.Superseded += o.Superseded
 	s.Unchanged += o.Unchanged
+	s.Rerated += o.Rerated
 }
@@ -0,0 +1,39 @@
+// Package rating is TP, the profile rating: one number per player, comparable
+// across modes, sizes, languages and quotes (SCORING_CONCEPT §5,
+// docs/LEADERBOARDS.md "TP").
+//
+// # What it counts
+//
+// Exactly leaderboard_eligible_runs — the whole view, with no extra predicate.
+// Quote runs are in (a product decision: a quote run earns TP exactly as a
+// seeded run does), seeded repeats are out (the view already excludes them),
+// and there is no second "TP-eligible" view to drift from the first.
+//
+// # The formula
+//
+// Formula is a versioned pure function, the TP counterpart of scoreV1: a run is
+// worth RunTP on its own, a player keeps their best run per bucket, and their
+// rating is the decayed sum of the top N of those (Total). It is deliberately
+// NOT derived from a run's score. Score is only comparable within a bucket, and
+// TP exists to compare across them; tying the two together would make every
+// score rebalance a rating rebalance too.
+//
+// Every stored value carries the version that produced it. A formula change is
+// a new Formula value and a bump of Current, followed by `make rebuild-tp`.
+//
+// # A projection, not a source of truth
+//
+// Like a board slot, a rating is maintained INSIDE the transaction that writes
+// a run's status — the replay worker's verdict and an operator's override both
+// reach it through the same projection seam the leaderboard uses — and can be
+// recomputed from the runs table alone. The rebuild computing the same numbers
+// as the incremental path is the proof that it is derived and not a second
+// truth.
+//
+// # Layering
+//
+// Like the other domains, rating declares its read dependency as a
+// consumer-side interface (Store) and imports no sibling domain. The write side
+// lives in rating/pgstore and is reached by the replay and runs stores through
+// interfaces declared at their end.
+package rating
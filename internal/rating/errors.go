@@ -0,0 +1,26 @@
+package rating
+
+import "net/http"
+
+// apiError is a client-facing error carrying an HTTP status, a stable machine
+// code (JSON "error"), and a human message (JSON "message"). Mirrors the other
+// domains' shape so the wire format is uniform across the API; kept private
+// here so each domain owns its own error surface.
+type apiError struct {
+	status  int
+	Code    string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string { return e.Code + ": " + e.Message }
+
+func newAPIError(status int, code, message string) *apiError {
+	return &apiError{status: status, Code: code, Message: message}
+}
+
+var (
+	apiErrBadCursor = newAPIError(http.StatusBadRequest, "bad_request",
+		"invalid cursor")
+	apiErrInternal = newAPIError(http.StatusInternalServerError, "internal",
+		"an unexpected error occurred")
+)
@@ -0,0 +1,71 @@
+package rating
+
+import (
+	"math"
+	"slices"
+)
+
+// Formula is one version of the TP formula. The zero value is not a formula;
+// use V1 or Current.
+//
+// The per-run value is wpm × acc^AccExponent: speed is the currency, and
+// accuracy discounts it steeply enough that a sloppy fast run is not worth a
+// clean one a few wpm slower. Nothing else enters. In particular there is no
+// length or source coefficient — a quote run is worth what a seeded run at the
+// same speed and accuracy is worth (docs/LEADERBOARDS.md, "Quote runs DO earn
+// TP") — and no difficulty term yet, because there is no text difficulty to
+// read; when there is, that is a new version, not an edit to this one.
+type Formula struct {
+	// Version is stored beside every value this formula produced.
+	Version int16
+	// TopN is how many per-bucket bests the total is taken over.
+	TopN int
+	// Decay is the weight ratio between consecutive bests: the i-th best
+	// (0-based) counts Decay^i. Below 1, so farming many buckets at a mediocre
+	// level cannot outweigh a few excellent runs.
+	Decay float64
+	// AccExponent is how hard accuracy discounts speed.
+	AccExponent float64
+}
+
+// V1 is the first TP formula: top 100 with a 0.95 decay, the osu! pp shape
+// ARCHITECTURE.md names, over wpm × acc⁴.
+var V1 = Formula{Version: 1, TopN: 100, Decay: 0.95, AccExponent: 4}
+
+// Current is the formula the projection writes with and the rebuild walks
+// stale rows forward to.
+var Current = V1
+
+// RunTP is what one run is worth on its own. acc is a fraction in [0, 1], as
+// the eligible view carries it; anything outside that range, and any wpm that
+// is not a positive finite number, is clamped rather than trusted, so a
+// malformed verdict can cost a player TP but never inflate it.
+func (f Formula) RunTP(wpm, acc float64) float64 {
+	if !(wpm > 0) || math.IsInf(wpm, 0) {
+		return 0
+	}
+	if !(acc > 0) {
+		return 0
+	}
+	acc = min(acc, 1)
+	return wpm * math.Pow(acc, f.AccExponent)
+}
+
+// Total is a player's rating over their per-bucket bests — ONE value per
+// bucket; deduplicating is the caller's job, because only the caller knows the
+// buckets. The bests are taken in descending order whatever order they arrive
+// in, and the returned count is how many of them the total counted.
+func (f Formula) Total(bests []float64) (tp float64, counted int) {
+	sorted := slices.Clone(bests)
+	slices.Sort(sorted)
+	slices.Reverse(sorted)
+	if len(sorted) > f.TopN {
+		sorted = sorted[:f.TopN]
+	}
+	weight := 1.0
+	for _, v := range sorted {
+		tp += v * weight
+		weight *= f.Decay
+	}
+	return tp, len(sorted)
+}
@@ -0,0 +1,47 @@
+package rating
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTPDiscountsSpeedByAccuracy(t *testing.T) {
+	assert.InDelta(t, 100, V1.RunTP(100, 1), 1e-9)
+	assert.InDelta(t, 100*math.Pow(0.9, 4), V1.RunTP(100, 0.9), 1e-9)
+	// A clean run a few wpm slower beats a sloppy fast one.
+	assert.Greater(t, V1.RunTP(95, 1), V1.RunTP(100, 0.95))
+}
+
+// A malformed verdict can cost a player TP but never inflate it.
+func TestRunTPClampsWhatItCannotTrust(t *testing.T) {
+	assert.InDelta(t, 100, V1.RunTP(100, 1.5), 1e-9, "acc above 1")
+	assert.Zero(t, V1.RunTP(100, -0.1))
+	assert.Zero(t, V1.RunTP(-50, 1))
+	assert.Zero(t, V1.RunTP(math.NaN(), 1))
+	assert.Zero(t, V1.RunTP(math.Inf(1), 1))
+	assert.Zero(t, V1.RunTP(100, math.NaN()))
+}
+
+func TestTotalDecaysOverTheBestsInDescendingOrder(t *testing.T) {
+	tp, n := V1.Total([]float64{50, 100, 80})
+	assert.Equal(t, 3, n)
+	assert.InDelta(t, 100+80*0.95+50*0.95*0.95, tp, 1e-9)
+
+	// Order in, bits out: the rebuild and the projection arrive at the same
+	// bests in different orders and must agree exactly.
+	again, _ := V1.Total([]float64{80, 50, 100})
+	assert.Equal(t, tp, again)
+}
+
+func TestTotalCountsOnlyTheTopN(t *testing.T) {
+	f := Formula{Version: 99, TopN: 2, Decay: 0.5, AccExponent: 1}
+	tp, n := f.Total([]float64{1, 10, 4})
+	assert.Equal(t, 2, n)
+	assert.InDelta(t, 10+4*0.5, tp, 1e-9)
+
+	tp, n = f.Total(nil)
+	assert.Zero(t, tp)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,126 @@
+package rating
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/platform/httpx"
+)
+
+// Pagination bounds for a ranking page — the same as a board page's.
+const (
+	defaultLimit = 50
+	maxLimit     = 100
+)
+
+// Routes returns the rating router, mounted at /api/v1/rating.
+//
+// PUBLIC and unauthenticated, like the boards: the ranking shows what every
+// leaderboard already shows — a name and a number earned on it.
+func (s *Service) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", s.handlePage)
+	return r
+}
+
+type entryView struct {
+	Rank        int64     `json:"rank"`
+	UserID      uuid.UUID `json:"userId"`
+	DisplayName string    `json:"displayName"`
+	TP          float64   `json:"tp"`
+	Runs        int32     `json:"runs"`
+	TPVersion   int16     `json:"tpVersion"`
+}
+
+type pageResponse struct {
+	// Version is the formula the server rates with today. An entry whose
+	// tpVersion is behind it is waiting on a rebuild.
+	Version    int16       `json:"version"`
+	Entries    []entryView `json:"entries"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// handlePage returns one page of the global ranking, keyset-paginated on
+// (tp DESC, user_id ASC).
+//
+// As on a board, the first row's rank on a continuation page is COUNTED rather
+// than carried in the cursor: ratings move with every accepted run, and a rank
+// minted into a token is stale by the time it is read.
+func (s *Service) handlePage(w http.ResponseWriter, r *http.Request) {
+	limit := httpx.ParseLimit(r.URL.Query().Get("limit"), defaultLimit, maxLimit)
+
+	var after *Cursor
+	firstRank := int64(1)
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		cur, err := decodeCursor(raw)
+		if err != nil {
+			s.writeError(w, r, apiErrBadCursor)
+			return
+		}
+		after = &cur
+		above, err := s.store.RankAbove(r.Context(), cur)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		// The cursor row itself is above everything on this page.
+		firstRank = above + 2
+	}
+
+	// One extra row tells us whether another page exists, without a second
+	// query or an empty trailing page.
+	rows, err := s.store.Page(r.Context(), after, int32(limit+1))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		next = encodeCursor(Cursor{TP: last.TP, UserID: last.UserID})
+	}
+
+	views := make([]entryView, len(rows))
+	for i := range rows {
+		e := &rows[i]
+		views[i] = entryView{
+			Rank: firstRank + int64(i), UserID: e.UserID, DisplayName: e.DisplayName,
+			TP: e.TP, Runs: e.Runs, TPVersion: e.Version,
+		}
+	}
+	s.writeJSON(w, http.StatusOK, pageResponse{Version: Current.Version, Entries: views, NextCursor: next})
+}
+
+// encodeCursor packs a keyset position into an opaque token. 'g' with -1
+// precision is the shortest spelling that parses back to the identical float64,
+// which the equality tie-break needs.
+func encodeCursor(c Cursor) string {
+	return httpx.EncodeCursor(strconv.FormatFloat(c.TP, 'g', -1, 64), c.UserID.String())
+}
+
+// decodeCursor reverses encodeCursor, rejecting anything malformed — including
+// a TP no row could hold.
+func decodeCursor(token string) (Cursor, error) {
+	parts, err := httpx.DecodeCursor(token, 2)
+	if err != nil {
+		return Cursor{}, err
+	}
+	tp, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return Cursor{}, err
+	}
+	if tp < 0 || math.IsInf(tp, 0) || math.IsNaN(tp) {
+		return Cursor{}, strconv.ErrRange
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Cursor{}, err
+	}
+	return Cursor{TP: tp, UserID: id}, nil
+}
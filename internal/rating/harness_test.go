@@ -0,0 +1,253 @@
+package rating_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/typemore/typemore-server/internal/platform/db"
+	"github.com/typemore/typemore-server/internal/platform/migrate"
+	"github.com/typemore/typemore-server/internal/rating"
+	ratingpg "github.com/typemore/typemore-server/internal/rating/pgstore"
+)
+
+// The Postgres testcontainer is started lazily on first use and torn down in
+// TestMain, mirroring the leaderboard suite.
+var (
+	dbOnce      sync.Once
+	dbContainer *postgres.PostgresContainer
+	testDSN     string
+	dbErr       error
+)
+
+func ensureDB(t *testing.T) string {
+	t.Helper()
+	dbOnce.Do(func() {
+		ctx := context.Background()
+		dbContainer, dbErr = postgres.Run(ctx, "postgres:17",
+			postgres.WithDatabase("typemore"),
+			postgres.WithUsername("typemore"),
+			postgres.WithPassword("typemore"),
+			testcontainers.WithWaitStrategy(
+				wait.ForLog("database system is ready to accept connections").
+					WithOccurrence(2).
+					WithStartupTimeout(90*time.Second),
+			),
+		)
+		if dbErr != nil {
+			return
+		}
+		testDSN, dbErr = dbContainer.ConnectionString(ctx, "sslmode=disable")
+		if dbErr != nil {
+			return
+		}
+		dbErr = migrate.Up(ctx, testDSN)
+	})
+	require.NoError(t, dbErr, "start/migrate postgres testcontainer")
+	return testDSN
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if dbContainer != nil {
+		_ = dbContainer.Terminate(context.Background())
+	}
+	os.Exit(code)
+}
+
+// rated is the TP test harness: a real Postgres, the real projection and the
+// real ranking route. Runs are planted directly and judged through the
+// projection the way the leaderboard suite does it — the goja replay that
+// produces a verdict has its own suite, and what is under test here is what a
+// status change does to a rating.
+type rated struct {
+	t      *testing.T
+	pool   *pgxpool.Pool
+	store  *ratingpg.Store
+	server *httptest.Server
+}
+
+func newRated(t *testing.T) *rated {
+	t.Helper()
+	ctx := context.Background()
+
+	pool, err := db.NewPool(ctx, ensureDB(t), 5)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	_, err = pool.Exec(ctx, `TRUNCATE run_tp, user_tp, bans, runs, users, quotes CASCADE`)
+	require.NoError(t, err)
+
+	store := ratingpg.New(pool)
+	svc := rating.NewService(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	r := chi.NewRouter()
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Mount("/rating", svc.Routes())
+	})
+	h := &rated{t: t, pool: pool, store: store, server: httptest.NewServer(r)}
+	t.Cleanup(h.server.Close)
+	return h
+}
+
+// --- fixtures ---
+
+const seededSetup = `{
+  "config":      {"mode":"time","durationMs":15000,"maxExtraChars":20,"difficulty":"normal","nospace":false,"minWpm":0},
+  "generation":  {"mode":"time","length":0,"punctuation":false,"numbers":false,"randomCase":false,"reverse":false},
+  "declaration": {"blind":false,"fading":false,"flashlight":false}
+}`
+
+func quoteSetup(quoteID uuid.UUID) string {
+	return fmt.Sprintf(`{
+  "config":      {"mode":"quote","maxExtraChars":20,"difficulty":"normal","nospace":false,"minWpm":0},
+  "generation":  {"mode":"quote","length":0,"punctuation":false,"numbers":false,"randomCase":false,"reverse":false,
+                  "textSource":{"kind":"quote","quoteId":%q,"quoteHash":"deadbeef"}},
+  "declaration": {"blind":false,"fading":false,"flashlight":false}
+}`, quoteID)
+}
+
+func (h *rated) user(name string) uuid.UUID {
+	h.t.Helper()
+	var id uuid.UUID
+	require.NoError(h.t, h.pool.QueryRow(context.Background(),
+		`INSERT INTO users (display_name) VALUES ($1) RETURNING id`, name).Scan(&id))
+	return id
+}
+
+func (h *rated) quote() uuid.UUID {
+	h.t.Helper()
+	var id uuid.UUID
+	require.NoError(h.t, h.pool.QueryRow(context.Background(), `
+		INSERT INTO quotes (id, lang, upstream_id, text, source, length, len_group, text_hash)
+		VALUES (gen_random_uuid(), 'en',
+		        (SELECT coalesce(max(upstream_id), 0) + 1 FROM quotes WHERE lang = 'en'),
+		        'to be or not to be', 'Hamlet', 18, 0, 'deadbeef')
+		RETURNING id`).Scan(&id))
+	return id
+}
+
+// runSpec is one run to plant. The zero value is an accepted 15-second English
+// seeded run at 100 wpm and full accuracy.
+type runSpec struct {
+	user        uuid.UUID
+	durationMs  int32
+	quote       uuid.UUID
+	adoptedFrom uuid.UUID
+	wpm, acc    float64
+}
+
+// addRun plants a run and judges it accepted.
+func (h *rated) addRun(spec runSpec) uuid.UUID {
+	h.t.Helper()
+	ctx := context.Background()
+	if spec.wpm == 0 {
+		spec.wpm = 100
+	}
+	if spec.acc == 0 {
+		spec.acc = 1
+	}
+	mode, setup := "time", seededSetup
+	var duration *int32
+	if spec.quote != uuid.Nil {
+		mode, setup = "quote", quoteSetup(spec.quote)
+	} else {
+		d := spec.durationMs
+		if d == 0 {
+			d = 15000
+		}
+		duration = &d
+	}
+	if spec.adoptedFrom != uuid.Nil {
+		var doc map[string]any
+		require.NoError(h.t, json.Unmarshal([]byte(setup), &doc))
+		doc["adoptedFromRunId"] = spec.adoptedFrom.String()
+		b, err := json.Marshal(doc)
+		require.NoError(h.t, err)
+		setup = string(b)
+	}
+
+	var id uuid.UUID
+	require.NoError(h.t, h.pool.QueryRow(ctx, `
+		INSERT INTO runs (user_id, mode, duration_ms, word_count, lang, seed, dict_hash,
+		                  setup, client_metrics, client_score, score_version, log, log_bytes)
+		VALUES ($1, $2, $3, NULL, 'en', 1, 'testhash',
+		        $4::jsonb, '{}'::jsonb, '{}'::jsonb, 2, '\x1f8b'::bytea, 0)
+		RETURNING id`, spec.user, mode, duration, setup).Scan(&id))
+	_, err := h.pool.Exec(ctx, `
+		INSERT INTO run_verdicts (run_id, user_id, server_metrics, server_score,
+		                          validation, bundle_sha, policy_version, validated_at)
+		VALUES ($1, $2,
+		        jsonb_build_object('wpm', $3::float8, 'raw', $3::float8, 'accuracy', $4::float8),
+		        jsonb_build_object('version', 2, 'total', 1000),
+		        '{"verdict":"valid","flags":[],"policy":{"version":1,"suspicion":0,"threshold":1}}'::jsonb, 'testbundle', 1, now())`,
+		id, spec.user, spec.wpm, spec.acc)
+	require.NoError(h.t, err)
+	h.judge(id, "accepted")
+	return id
+}
+
+// judge writes a run's status and projects it in one transaction — what the
+// replay worker and an operator override both do around the seam.
+func (h *rated) judge(runID uuid.UUID, status string) {
+	h.t.Helper()
+	ctx := context.Background()
+	tx, err := h.pool.Begin(ctx)
+	require.NoError(h.t, err)
+	defer func() { _ = tx.Rollback(ctx) }()
+	_, err = tx.Exec(ctx, `UPDATE runs SET status = $1 WHERE id = $2`, status, runID)
+	require.NoError(h.t, err)
+	require.NoError(h.t, h.store.ProjectRun(ctx, tx, runID))
+	require.NoError(h.t, tx.Commit(ctx))
+}
+
+// rating reads a player's stored total straight off user_tp; ok is false when
+// the player has no row.
+func (h *rated) rating(userID uuid.UUID) (tp float64, runs int32, ok bool) {
+	h.t.Helper()
+	err := h.pool.QueryRow(context.Background(),
+		`SELECT tp, runs FROM user_tp WHERE user_id = $1`, userID).Scan(&tp, &runs)
+	if err != nil {
+		return 0, 0, false
+	}
+	return tp, runs, true
+}
+
+type pageBody struct {
+	Version int16 `json:"version"`
+	Entries []struct {
+		Rank        int64     `json:"rank"`
+		UserID      uuid.UUID `json:"userId"`
+		DisplayName string    `json:"displayName"`
+		TP          float64   `json:"tp"`
+		Runs        int32     `json:"runs"`
+	} `json:"entries"`
+	NextCursor string `json:"nextCursor"`
+}
+
+func (h *rated) page(query string) (int, pageBody) {
+	h.t.Helper()
+	resp, err := http.Get(h.server.URL + "/api/v1/rating" + query)
+	require.NoError(h.t, err)
+	defer resp.Body.Close()
+	var body pageBody
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(h.t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body
+}
@@ -0,0 +1,422 @@
+// Package pgstore implements TP against Postgres: the projection seam the
+// replay worker and an operator override both call inside the transaction that
+// moved a run's status, the rebuild `make rebuild-tp` runs, and the read side
+// of the ranking (migration 00035).
+//
+// Raw SQL, like the keyboard projection, rather than a generated query set: the
+// statements are few, they are shaped around a Go-side formula, and every one
+// of them is in this file next to the code that relies on its exact shape.
+package pgstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/typemore/typemore-server/internal/rating"
+)
+
+// Store is the rating projection and its read side.
+type Store struct {
+	pool    *pgxpool.Pool
+	formula rating.Formula
+}
+
+// Compile-time check that Store satisfies the consumer interface.
+var _ rating.Store = (*Store)(nil)
+
+// New builds a Store that rates with rating.Current.
+func New(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool, formula: rating.Current}
+}
+
+// eligibleRunTP selects, from leaderboard_eligible_runs and nothing else, what
+// the formula needs about a run and the bucket it is deduplicated within. The
+// bucket spelling is internal to these two tables (00035): a quote run's bucket
+// is its quote alone, exactly as its board is, and every other run's is its
+// language coordinates.
+const eligibleRunTP = `
+SELECT e.run_id, e.user_id, e.wpm::float8, e.acc::float8,
+       CASE WHEN e.quote_id IS NOT NULL
+            THEN 'quote:' || e.quote_id::text
+            ELSE concat_ws(':', e.mode, COALESCE(e.duration_ms, e.word_count),
+                           e.lang, e.text_source_kind)
+       END
+FROM leaderboard_eligible_runs e`
+
+// --- write side: projection ---
+
+// ProjectRun brings runID's TP contribution, and its player's total, back in
+// line with the runs table, INSIDE the caller's transaction. It has the
+// leaderboard projector's signature on purpose: the composition root hands both
+// to the same seam, so whichever writer moved a status — the worker or a
+// person — moves the rating with it.
+//
+// Like a board cell it is a recompute, not an "add if better": a demoted run
+// drops out of the eligible view, its run_tp row is deleted, and the player's
+// total is retaken over what is left. When the run's row did not change — by
+// far the common case, since most verdicts are on runs that rank nowhere — the
+// total is left alone and the player's row is never locked.
+func (s *Store) ProjectRun(ctx context.Context, tx pgx.Tx, runID uuid.UUID) error {
+	var userID uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT user_id FROM runs WHERE id = $1`, runID).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// A vanished run (deleted account) took its row with it by cascade.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("rating/pgstore: read run %s: %w", runID, err)
+	}
+
+	var (
+		id, owner uuid.UUID
+		wpm, acc  float64
+		bucket    string
+	)
+	err = tx.QueryRow(ctx, eligibleRunTP+` WHERE e.run_id = $1`, runID).
+		Scan(&id, &owner, &wpm, &acc, &bucket)
+	var changed bool
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		tag, err := tx.Exec(ctx, `DELETE FROM run_tp WHERE run_id = $1`, runID)
+		if err != nil {
+			return fmt.Errorf("rating/pgstore: drop run %s: %w", runID, err)
+		}
+		changed = tag.RowsAffected() > 0
+	case err != nil:
+		return fmt.Errorf("rating/pgstore: read eligible run %s: %w", runID, err)
+	default:
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO run_tp (run_id, user_id, bucket, tp, tp_version)
+			 VALUES ($1, $2, $3, $4, $5)
+			 ON CONFLICT (run_id) DO UPDATE
+			     SET bucket = EXCLUDED.bucket, tp = EXCLUDED.tp, tp_version = EXCLUDED.tp_version
+			     WHERE (run_tp.bucket, run_tp.tp, run_tp.tp_version)
+			           IS DISTINCT FROM (EXCLUDED.bucket, EXCLUDED.tp, EXCLUDED.tp_version)`,
+			runID, owner, bucket, s.formula.RunTP(wpm, acc), s.formula.Version)
+		if err != nil {
+			return fmt.Errorf("rating/pgstore: write run %s: %w", runID, err)
+		}
+		changed = tag.RowsAffected() > 0
+	}
+	if !changed {
+		return nil
+	}
+	return s.retotal(ctx, tx, userID)
+}
+
+// retotal recomputes one player's user_tp row from their run_tp rows.
+//
+// Two verdict transactions can touch the same player at once — the claim locks
+// RUNS, not players — and each sees only its own uncommitted run_tp write. So
+// the player's user_tp row is locked first: the placeholder INSERT makes sure
+// there is a row to lock (a concurrent inserter waits on the unique index and
+// then does nothing), and the FOR UPDATE serialises the rest. Under READ
+// COMMITTED the per-bucket read that follows is a fresh snapshot, so the second
+// transaction through totals over the first one's committed rows too.
+//
+// Two batches locking the same two players in opposite orders deadlock; Postgres
+// aborts one, it rolls back whole, and its runs are claimed again — the same
+// outcome as a worker crash, and as rare as two of one player's runs landing in
+// two concurrent batches alongside two of another's.
+func (s *Store) retotal(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO user_tp (user_id, tp, tp_version, runs) VALUES ($1, 0, $2, 0)
+		 ON CONFLICT (user_id) DO NOTHING`, userID, s.formula.Version); err != nil {
+		return fmt.Errorf("rating/pgstore: seed total for %s: %w", userID, err)
+	}
+	if _, err := tx.Exec(ctx,
+		`SELECT 1 FROM user_tp WHERE user_id = $1 FOR UPDATE`, userID); err != nil {
+		return fmt.Errorf("rating/pgstore: lock total for %s: %w", userID, err)
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT DISTINCT ON (bucket) tp, tp_version
+		 FROM run_tp
+		 WHERE user_id = $1
+		 ORDER BY bucket, tp DESC`, userID)
+	if err != nil {
+		return fmt.Errorf("rating/pgstore: read bests for %s: %w", userID, err)
+	}
+	var bests []float64
+	version := s.formula.Version
+	for rows.Next() {
+		var tp float64
+		var v int16
+		if err := rows.Scan(&tp, &v); err != nil {
+			rows.Close()
+			return fmt.Errorf("rating/pgstore: scan best for %s: %w", userID, err)
+		}
+		bests = append(bests, tp)
+		version = min(version, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rating/pgstore: read bests for %s: %w", userID, err)
+	}
+
+	total, counted := s.formula.Total(bests)
+	if counted == 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM user_tp WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("rating/pgstore: clear total for %s: %w", userID, err)
+		}
+		return nil
+	}
+	// The stored version is the OLDEST formula among the bests: a total is
+	// only as current as the stalest number it was summed from.
+	if _, err := tx.Exec(ctx,
+		`UPDATE user_tp SET tp = $2, tp_version = $3, runs = $4, updated_at = now()
+		 WHERE user_id = $1`, userID, total, version, counted); err != nil {
+		return fmt.Errorf("rating/pgstore: write total for %s: %w", userID, err)
+	}
+	return nil
+}
+
+// RebuildStats is what one rebuild did.
+type RebuildStats struct {
+	// Runs is how many eligible runs were rated.
+	Runs int
+	// Before / After are the rated-player counts on either side.
+	Before int64
+	After  int
+	// Stale is how many run_tp rows were on an older formula going in — the
+	// number a formula bump's rebuild exists to bring to zero.
+	Stale int64
+	// Moved is how many players' totals came out different. Zero after a
+	// rebuild on an unchanged formula is the proof the incremental path was
+	// right — and exact equality is the right test for it, because Total
+	// sorts its input first: the same bests in any order sum to the same bits.
+	Moved int
+}
+
+// runRow is one rated run, as the rebuild holds it in memory between the read
+// and the COPY — a connection cannot stream a result and a COPY at once.
+type runRow struct {
+	runID, userID uuid.UUID
+	bucket        string
+	tp            float64
+}
+
+// Rebuild recomputes both tables from leaderboard_eligible_runs in ONE
+// transaction: truncate, rate every eligible run with the current formula,
+// total every player. TRUNCATE is transactional, so a failure leaves the old
+// ratings intact; it also holds the tables exclusively until commit, so verdict
+// transactions wait for the rebuild rather than interleave with it.
+//
+// Unlike the board rebuild this is a bulk load, not a replay of the incremental
+// statement per cell: the per-run value is Go's to compute either way, and the
+// totals go through the same Formula.Total the projection calls, which is where
+// agreement between the two paths has to come from.
+func (s *Store) Rebuild(ctx context.Context) (RebuildStats, error) {
+	var stats RebuildStats
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("rating/pgstore: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := tx.QueryRow(ctx,
+		`SELECT count(*) FROM run_tp WHERE tp_version <> $1`, s.formula.Version).
+		Scan(&stats.Stale); err != nil {
+		return stats, fmt.Errorf("rating/pgstore: count stale: %w", err)
+	}
+	before := map[uuid.UUID]float64{}
+	rows, err := tx.Query(ctx, `SELECT user_id, tp FROM user_tp`)
+	if err != nil {
+		return stats, fmt.Errorf("rating/pgstore: read totals: %w", err)
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		var tp float64
+		if err := rows.Scan(&id, &tp); err != nil {
+			rows.Close()
+			return stats, fmt.Errorf("rating/pgstore: scan total: %w", err)
+		}
+		before[id] = tp
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("rating/pgstore: read totals: %w", err)
+	}
+	stats.Before = int64(len(before))
+
+	if _, err := tx.Exec(ctx, `TRUNCATE run_tp, user_tp`); err != nil {
+		return stats, fmt.Errorf("rating/pgstore: clear: %w", err)
+	}
+
+	var runs []runRow
+	rows, err = tx.Query(ctx, eligibleRunTP)
+	if err != nil {
+		return stats, fmt.Errorf("rating/pgstore: read eligible runs: %w", err)
+	}
+	for rows.Next() {
+		var r runRow
+		var wpm, acc float64
+		if err := rows.Scan(&r.runID, &r.userID, &wpm, &acc, &r.bucket); err != nil {
+			rows.Close()
+			return stats, fmt.Errorf("rating/pgstore: scan eligible run: %w", err)
+		}
+		r.tp = s.formula.RunTP(wpm, acc)
+		runs = append(runs, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("rating/pgstore: read eligible runs: %w", err)
+	}
+	stats.Runs = len(runs)
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"run_tp"},
+		[]string{"run_id", "user_id", "bucket", "tp", "tp_version"},
+		pgx.CopyFromSlice(len(runs), func(i int) ([]any, error) {
+			return []any{runs[i].runID, runs[i].userID, runs[i].bucket, runs[i].tp, s.formula.Version}, nil
+		})); err != nil {
+		return stats, fmt.Errorf("rating/pgstore: load runs: %w", err)
+	}
+
+	// Per player, per bucket, the best — then the same Total the projection
+	// takes.
+	bests := map[uuid.UUID]map[string]float64{}
+	for i := range runs {
+		byBucket := bests[runs[i].userID]
+		if byBucket == nil {
+			byBucket = map[string]float64{}
+			bests[runs[i].userID] = byBucket
+		}
+		if tp, ok := byBucket[runs[i].bucket]; !ok || runs[i].tp > tp {
+			byBucket[runs[i].bucket] = runs[i].tp
+		}
+	}
+	type totalRow struct {
+		userID  uuid.UUID
+		tp      float64
+		counted int
+	}
+	totals := make([]totalRow, 0, len(bests))
+	for userID, byBucket := range bests {
+		values := make([]float64, 0, len(byBucket))
+		for _, tp := range byBucket {
+			values = append(values, tp)
+		}
+		tp, counted := s.formula.Total(values)
+		totals = append(totals, totalRow{userID: userID, tp: tp, counted: counted})
+		if old, ok := before[userID]; !ok || old != tp {
+			stats.Moved++
+		}
+	}
+	for userID := range before {
+		if _, ok := bests[userID]; !ok {
+			stats.Moved++
+		}
+	}
+	stats.After = len(totals)
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"user_tp"},
+		[]string{"user_id", "tp", "tp_version", "runs"},
+		pgx.CopyFromSlice(len(totals), func(i int) ([]any, error) {
+			return []any{totals[i].userID, totals[i].tp, s.formula.Version, int32(totals[i].counted)}, nil
+		})); err != nil {
+		return stats, fmt.Errorf("rating/pgstore: load totals: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return stats, fmt.Errorf("rating/pgstore: commit: %w", err)
+	}
+	return stats, nil
+}
+
+// --- read side ---
+
+// The visibility rule is the boards' rule, spelled on user_tp: a player under an
+// active ban is hidden, and reappears the moment the ban lifts.
+const visible = `NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = t.user_id)`
+
+// Page returns up to limit ranking rows, continuing after the keyset position
+// when non-nil. The continuation is written as a start condition on
+// user_tp_rank_idx — `tp <= cursor` lands the scan on the cursor row and the
+// second clause only sorts out its ties — the shape the board pages use.
+func (s *Store) Page(ctx context.Context, after *rating.Cursor, limit int32) ([]rating.Entry, error) {
+	var (
+		rows pgx.Rows
+		err  error
+	)
+	if after == nil {
+		rows, err = s.pool.Query(ctx,
+			`SELECT t.user_id, u.display_name, t.tp, t.runs, t.tp_version
+			 FROM user_tp t
+			          JOIN users u ON u.id = t.user_id
+			 WHERE `+visible+`
+			 ORDER BY t.tp DESC, t.user_id ASC
+			 LIMIT $1`, limit)
+	} else {
+		rows, err = s.pool.Query(ctx,
+			`SELECT t.user_id, u.display_name, t.tp, t.runs, t.tp_version
+			 FROM user_tp t
+			          JOIN users u ON u.id = t.user_id
+			 WHERE t.tp <= $1
+			   AND (t.tp < $1 OR t.user_id > $2)
+			   AND `+visible+`
+			 ORDER BY t.tp DESC, t.user_id ASC
+			 LIMIT $3`, after.TP, after.UserID, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rating/pgstore: page: %w", err)
+	}
+	defer rows.Close()
+
+	var out []rating.Entry
+	for rows.Next() {
+		var e rating.Entry
+		if err := rows.Scan(&e.UserID, &e.DisplayName, &e.TP, &e.Runs, &e.Version); err != nil {
+			return nil, fmt.Errorf("rating/pgstore: scan page: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rating/pgstore: page: %w", err)
+	}
+	return out, nil
+}
+
+// RankAbove counts the visible players outranking a position.
+func (s *Store) RankAbove(ctx context.Context, at rating.Cursor) (int64, error) {
+	var n int64
+	err := s.pool.QueryRow(ctx,
+		`SELECT count(*)
+		 FROM user_tp t
+		 WHERE t.tp >= $1
+		   AND (t.tp > $1 OR t.user_id < $2)
+		   AND `+visible, at.TP, at.UserID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("rating/pgstore: rank above: %w", err)
+	}
+	return n, nil
+}
+
+// UserRating is one player's TP and global rank, for the profile header. ok is
+// false when the player has no rating — no eligible run yet — which is an
+// absence to render, not an error. The rank is counted among visible players
+// only, so it agrees with the ranking page a reader would click through to.
+func (s *Store) UserRating(ctx context.Context, userID uuid.UUID) (tp float64, rank int64, ok bool, err error) {
+	var above int64
+	err = s.pool.QueryRow(ctx,
+		`SELECT me.tp,
+		        (SELECT count(*)
+		         FROM user_tp t
+		         WHERE t.tp >= me.tp
+		           AND (t.tp > me.tp OR t.user_id < me.user_id)
+		           AND `+visible+`)
+		 FROM user_tp me
+		 WHERE me.user_id = $1`, userID).Scan(&tp, &above)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("rating/pgstore: rating for %s: %w", userID, err)
+	}
+	return tp, above + 1, true, nil
+}
@@ -0,0 +1,146 @@
+package rating_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/rating"
+)
+
+// A player's TP is the decayed sum over their best run PER BUCKET: a second,
+// faster run on the same board replaces the first rather than stacking on it,
+// and a quote run counts like any other (docs/LEADERBOARDS.md, "TP").
+func TestTPSumsTheBestRunPerBucket(t *testing.T) {
+	h := newRated(t)
+	ada := h.user("ada")
+	q := h.quote()
+
+	h.addRun(runSpec{user: ada, wpm: 100})
+	h.addRun(runSpec{user: ada, wpm: 120})                   // same bucket, better
+	h.addRun(runSpec{user: ada, wpm: 90, durationMs: 30000}) // another bucket
+	h.addRun(runSpec{user: ada, wpm: 80, quote: q})          // a quote is a bucket
+
+	tp, runs, ok := h.rating(ada)
+	require.True(t, ok)
+	assert.Equal(t, int32(3), runs)
+	want, _ := rating.Current.Total([]float64{120, 90, 80})
+	assert.InDelta(t, want, tp, 1e-9)
+}
+
+// A seeded repeat is ranked nowhere, and earns nothing: the view already says
+// so, and TP adds no predicate of its own.
+func TestASeededRepeatEarnsNoTP(t *testing.T) {
+	h := newRated(t)
+	ada, bob := h.user("ada"), h.user("bob")
+
+	original := h.addRun(runSpec{user: ada, wpm: 100})
+	h.addRun(runSpec{user: bob, wpm: 150, adoptedFrom: original})
+
+	_, _, ok := h.rating(bob)
+	assert.False(t, ok)
+}
+
+// Demotion is the case that rots quietly: the run leaves the total in the
+// same transaction, the next best takes its bucket, and a player with nothing
+// left has no rating at all.
+func TestADemotedRunLeavesTheTotal(t *testing.T) {
+	h := newRated(t)
+	ada := h.user("ada")
+
+	slow := h.addRun(runSpec{user: ada, wpm: 100})
+	fast := h.addRun(runSpec{user: ada, wpm: 140})
+
+	h.judge(fast, "rejected")
+	tp, _, ok := h.rating(ada)
+	require.True(t, ok)
+	assert.InDelta(t, 100, tp, 1e-9)
+
+	h.judge(slow, "flagged")
+	_, _, ok = h.rating(ada)
+	assert.False(t, ok)
+
+	h.judge(fast, "accepted")
+	tp, _, ok = h.rating(ada)
+	require.True(t, ok)
+	assert.InDelta(t, 140, tp, 1e-9)
+}
+
+// The rebuild reproduces the incremental totals exactly, repairs a total that
+// drifted, and walks a row on an old formula version forward.
+func TestRebuildAgreesWithTheProjection(t *testing.T) {
+	h := newRated(t)
+	ctx := context.Background()
+	ada, bob := h.user("ada"), h.user("bob")
+	h.addRun(runSpec{user: ada, wpm: 100})
+	h.addRun(runSpec{user: ada, wpm: 90, durationMs: 60000})
+	h.addRun(runSpec{user: bob, wpm: 70, acc: 0.95})
+
+	stats, err := h.store.Rebuild(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Runs)
+	assert.Equal(t, int64(2), stats.Before)
+	assert.Equal(t, 2, stats.After)
+	assert.Zero(t, stats.Moved, "the incremental path was already right")
+	assert.Zero(t, stats.Stale)
+
+	_, err = h.pool.Exec(ctx, `UPDATE user_tp SET tp = tp + 1 WHERE user_id = $1`, ada)
+	require.NoError(t, err)
+	_, err = h.pool.Exec(ctx, `UPDATE run_tp SET tp_version = 0 WHERE user_id = $1`, bob)
+	require.NoError(t, err)
+
+	stats, err = h.store.Rebuild(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Moved)
+	assert.Equal(t, int64(1), stats.Stale)
+
+	var stale int
+	require.NoError(t, h.pool.QueryRow(ctx,
+		`SELECT count(*) FROM run_tp WHERE tp_version <> $1`, rating.Current.Version).Scan(&stale))
+	assert.Zero(t, stale)
+}
+
+// The ranking pages on (tp, user_id) with counted ranks, and hides a banned
+// player exactly as the boards do — from the page, the rank count and the
+// header lookup alike.
+func TestTheRankingPagesAndHidesBannedPlayers(t *testing.T) {
+	h := newRated(t)
+	ctx := context.Background()
+	ada, bob, cy := h.user("ada"), h.user("bob"), h.user("cyd")
+	h.addRun(runSpec{user: ada, wpm: 150})
+	h.addRun(runSpec{user: bob, wpm: 120})
+	h.addRun(runSpec{user: cy, wpm: 90})
+
+	status, first := h.page("?limit=2")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, first.Entries, 2)
+	assert.Equal(t, rating.Current.Version, first.Version)
+	assert.Equal(t, "ada", first.Entries[0].DisplayName)
+	assert.Equal(t, int64(2), first.Entries[1].Rank)
+	require.NotEmpty(t, first.NextCursor)
+
+	_, second := h.page("?limit=2&cursor=" + first.NextCursor)
+	require.Len(t, second.Entries, 1)
+	assert.Equal(t, "cyd", second.Entries[0].DisplayName)
+	assert.Equal(t, int64(3), second.Entries[0].Rank)
+	assert.Empty(t, second.NextCursor)
+
+	_, err := h.pool.Exec(ctx, `INSERT INTO bans (user_id, reason) VALUES ($1, 'testing')`, ada)
+	require.NoError(t, err)
+
+	_, all := h.page("")
+	require.Len(t, all.Entries, 2)
+	assert.Equal(t, "bob", all.Entries[0].DisplayName)
+	assert.Equal(t, int64(1), all.Entries[0].Rank)
+
+	_, rank, ok, err := h.store.UserRating(ctx, cy)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, int64(2), rank)
+
+	status, _ = h.page("?cursor=not-a-cursor")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,44 @@
+package rating
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/typemore/typemore-server/internal/platform/httpx"
+)
+
+// Service serves the global TP ranking.
+//
+// Like the quote service it has no UserIDFunc: the one route is anonymous, and
+// who is asking changes nothing about the answer. A player's own rating and
+// rank ride on their profile header instead.
+type Service struct {
+	store Store
+	log   *slog.Logger
+}
+
+// NewService wires the rating service.
+func NewService(store Store, log *slog.Logger) *Service {
+	return &Service{store: store, log: log}
+}
+
+// --- shared HTTP helpers (mirroring the sibling domains', kept private) ---
+
+func (s *Service) writeJSON(w http.ResponseWriter, status int, v any) {
+	if err := httpx.WriteJSON(w, status, v); err != nil {
+		s.log.Error("encode response", "err", err)
+	}
+}
+
+// writeError renders err. Known apiErrors are sent with their status/code;
+// anything else is logged and returned as a generic 500 so internals never leak.
+func (s *Service) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		s.writeJSON(w, apiErr.status, apiErr)
+		return
+	}
+	s.log.ErrorContext(r.Context(), "rating request failed", "err", err, "path", r.URL.Path)
+	s.writeJSON(w, apiErrInternal.status, apiErrInternal)
+}
@@ -0,0 +1,37 @@
+package rating
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Entry is one row of the global TP ranking.
+type Entry struct {
+	// Rank is filled by the service, not the store: the store returns rows in
+	// order and the first row's rank is counted separately.
+	Rank        int64
+	UserID      uuid.UUID
+	DisplayName string
+	TP          float64
+	// Runs is how many per-bucket bests the total was taken over.
+	Runs int32
+	// Version is the formula that produced TP. Behind Current.Version means a
+	// formula change has shipped and `make rebuild-tp` has not run yet.
+	Version int16
+}
+
+// Cursor is a keyset position in the ranking: (tp DESC, user_id ASC).
+type Cursor struct {
+	TP     float64
+	UserID uuid.UUID
+}
+
+// Store is the read side of the ranking, declared here at the consumer.
+// Every read hides players under an active ban, exactly as board reads do.
+type Store interface {
+	// Page returns up to limit rows after the cursor (nil = from rank 1).
+	Page(ctx context.Context, after *Cursor, limit int32) ([]Entry, error)
+	// RankAbove counts the visible players outranking a position.
+	RankAbove(ctx context.Context, at Cursor) (int64, error)
+}
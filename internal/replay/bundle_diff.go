@@ -0,0 +1,109 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/runstatus"
+)
+
+// BundleOutcome is what one build, under one decider, made of one run: the
+// fields a rollout review reads, lifted out of the Decision that would have
+// been written.
+type BundleOutcome struct {
+	Status  runstatus.Status
+	Reason  string
+	Flags   []Flag
+	Score   json.RawMessage
+	Metrics json.RawMessage
+	// Total is the score object's "total", when it has one.
+	Total *float64
+	// Error is the replay failure, when the build could not replay the run at
+	// all. It is the decision's last_error, not a Go error: a build that newly
+	// fails on a run is a finding, not a reason to stop the diff.
+	Error string
+}
+
+// RunDiff is one run replayed on two builds. Changed lists, in a fixed order,
+// the fields on which the two outcomes differ; an empty list means the new
+// build changes nothing about this run.
+type RunDiff struct {
+	ID       uuid.UUID
+	Old, New BundleOutcome
+	Changed  []string
+}
+
+// The fields RunDiff.Changed can name, in the order they are checked.
+const (
+	DiffStatus  = "status"
+	DiffReason  = "reason"
+	DiffFlags   = "flags"
+	DiffScore   = "score"
+	DiffMetrics = "metrics"
+	DiffError   = "error"
+)
+
+// TotalDelta is the new build's score total minus the old one's, and false
+// when either side has none (an invalid log, a failed replay).
+func (d RunDiff) TotalDelta() (float64, bool) {
+	if d.Old.Total == nil || d.New.Total == nil {
+		return 0, false
+	}
+	return *d.New.Total - *d.Old.Total, true
+}
+
+// DiffRun replays run on both engines and decides each replay under the same
+// decider, by the same route Judge takes. It is the unit of `replayctl
+// bundle-diff`, which runs it over stored history before a build is vendored:
+// the question is what WOULD move, so the run's own pin is cleared and nothing
+// is written.
+//
+// The decider should be a ForRejudgement one. Against a stored run the
+// client's metrics were computed by whichever build was vendored when the run
+// was submitted; comparing them would report every run of a changed build as
+// a mismatch, which is the noise the diff exists to see past.
+func DiffRun(ctx context.Context, old, next Engine, reg *Registry, quotes QuoteResolver, decider Decider, run PendingRun, canaryEpoch time.Time) RunDiff {
+	run.ReplayWith = ""
+	diff := RunDiff{
+		ID:  run.ID,
+		Old: outcomeOf(Judge(ctx, old, reg, quotes, decider, run, canaryEpoch)),
+		New: outcomeOf(Judge(ctx, next, reg, quotes, decider, run, canaryEpoch)),
+	}
+	o, n := diff.Old, diff.New
+	for _, c := range []struct {
+		field string
+		same  bool
+	}{
+		{DiffStatus, o.Status == n.Status},
+		{DiffReason, o.Reason == n.Reason},
+		{DiffFlags, slices.Equal(o.Flags, n.Flags)},
+		{DiffScore, bytes.Equal(o.Score, n.Score)},
+		{DiffMetrics, bytes.Equal(o.Metrics, n.Metrics)},
+		{DiffError, o.Error == n.Error},
+	} {
+		if !c.same {
+			diff.Changed = append(diff.Changed, c.field)
+		}
+	}
+	return diff
+}
+
+func outcomeOf(d Decision) BundleOutcome {
+	out := BundleOutcome{Status: d.Status, Score: d.ServerScore, Metrics: d.ServerMetrics, Error: d.LastError}
+	var doc validationDoc
+	if json.Unmarshal(d.Validation, &doc) == nil {
+		out.Reason, out.Flags = doc.Reason, doc.Flags
+	}
+	var score struct {
+		Total *float64 `json:"total"`
+	}
+	if len(d.ServerScore) > 0 && json.Unmarshal(d.ServerScore, &score) == nil {
+		out.Total = score.Total
+	}
+	return out
+}
@@ -0,0 +1,204 @@
+package replay
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// archivedBundles are the earlier builds of core.bundle.js kept beside it, each
+// named by its own SHA-256 (corejs/archive/README.md). README.md rides along so
+// the directory always has something to embed; LoadBundles ignores it.
+//
+//go:embed corejs/archive
+var archivedBundles embed.FS
+
+// archiveDir is archivedBundles' root, and bundleSuffix the name every archived
+// bundle ends in after its digest.
+const (
+	archiveDir   = "corejs/archive"
+	bundleSuffix = ".bundle.js"
+)
+
+// Bundle is one build of the game core: the exact bytes that judge a run, and
+// the digest every verdict records as bundle_sha.
+type Bundle struct {
+	// SHA is the SHA-256 (hex) of Source — computed, never taken from a name.
+	SHA string
+	// Source is the IIFE itself, as goja evaluates it.
+	Source string
+	// Origin says where it was loaded from: "embedded", "archive", or a path.
+	Origin string
+}
+
+// Short is the twelve-character prefix every log line and report prints.
+func (b Bundle) Short() string { return shortSHA(b.SHA) }
+
+// ErrUnknownBundle is returned for a bundle reference no loaded bundle answers
+// to. A run pinned to one is unreplayable here, not misjudged: the worker
+// records it as a replay error like any other.
+var ErrUnknownBundle = errors.New("replay: unknown core bundle")
+
+// Bundles is every core build this process can replay with, keyed by SHA: the
+// vendored one, which judges new runs, and whatever older builds were kept so
+// runs can be replayed on the code that first judged them (docs/REPLAY.md,
+// "Bundles").
+//
+// A Bundles is built once at startup and read-only afterwards, so it is safe to
+// share between goroutines; the Cores built from it are not (see Core).
+type Bundles struct {
+	byID    map[string]Bundle
+	current string
+}
+
+// LoadBundles collects the vendored bundle, the embedded archive, and every
+// *.js file in extraDir (empty means none). A file in extraDir is keyed by the
+// digest of its content, whatever it is called; an archived one must ALSO be
+// named after that digest, because the name is what a reviewer reads in a diff
+// and a misnamed file would replay runs on code that never judged them.
+//
+// Loading does not evaluate anything: a bundle is compiled by NewCoreFor, the
+// first time a run needs it.
+func LoadBundles(extraDir string) (*Bundles, error) {
+	b := &Bundles{
+		byID:    map[string]Bundle{bundleSHA: {SHA: bundleSHA, Source: coreBundle, Origin: "embedded"}},
+		current: bundleSHA,
+	}
+	if err := b.addArchive(archivedBundles, archiveDir); err != nil {
+		return nil, err
+	}
+	if extraDir == "" {
+		return b, nil
+	}
+	entries, err := os.ReadDir(extraDir)
+	if err != nil {
+		return nil, fmt.Errorf("replay: read bundle directory: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".js" {
+			continue
+		}
+		bundle, err := ReadBundleFile(filepath.Join(extraDir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		b.add(bundle)
+	}
+	return b, nil
+}
+
+// addArchive loads every <sha>.bundle.js under dir, refusing a file whose name
+// and content disagree.
+func (b *Bundles) addArchive(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("replay: read bundle archive: %w", err)
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), bundleSuffix)
+		if e.IsDir() || !ok {
+			continue
+		}
+		raw, err := fs.ReadFile(fsys, dir+"/"+e.Name())
+		if err != nil {
+			return fmt.Errorf("replay: read archived bundle %s: %w", e.Name(), err)
+		}
+		if sha := sumBundle(raw); sha != name {
+			return fmt.Errorf("replay: archived bundle %s has SHA-256 %s; an archived bundle is named by its own digest",
+				e.Name(), shortSHA(sha))
+		}
+		b.add(Bundle{SHA: name, Source: string(raw), Origin: "archive"})
+	}
+	return nil
+}
+
+// add keeps the first bundle loaded under a digest: the vendored one outranks
+// an archived copy of itself, and the archive outranks a loose file.
+func (b *Bundles) add(bundle Bundle) {
+	if _, ok := b.byID[bundle.SHA]; !ok {
+		b.byID[bundle.SHA] = bundle
+	}
+}
+
+// ReadBundleFile loads a core bundle from disk, keyed by its content.
+func ReadBundleFile(path string) (Bundle, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("replay: read bundle: %w", err)
+	}
+	return Bundle{SHA: sumBundle(raw), Source: string(raw), Origin: path}, nil
+}
+
+func sumBundle(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// Current returns the vendored bundle: the one new runs are judged with.
+func (b *Bundles) Current() Bundle { return b.byID[b.current] }
+
+// Get returns the bundle with exactly this SHA.
+func (b *Bundles) Get(sha string) (Bundle, bool) {
+	bundle, ok := b.byID[sha]
+	return bundle, ok
+}
+
+// SHAs lists every loaded digest, the current one first and the rest sorted.
+func (b *Bundles) SHAs() []string {
+	out := make([]string, 0, len(b.byID))
+	for sha := range b.byID {
+		if sha != b.current {
+			out = append(out, sha)
+		}
+	}
+	slices.Sort(out)
+	return append([]string{b.current}, out...)
+}
+
+// minBundlePrefix is the shortest SHA prefix Resolve accepts. Anything shorter
+// is too easy to type by accident for a command that decides which code judges
+// a run.
+const minBundlePrefix = 8
+
+// Resolve turns an operator's reference into a bundle: "current", a full SHA,
+// a unique prefix of at least eight characters, or the path of a .js file
+// (which need not be loaded — that is how a candidate build is tried before it
+// is vendored).
+func (b *Bundles) Resolve(ref string) (Bundle, error) {
+	switch {
+	case ref == "current":
+		return b.Current(), nil
+	case strings.HasSuffix(ref, ".js"):
+		bundle, err := ReadBundleFile(ref)
+		if err != nil {
+			return Bundle{}, err
+		}
+		if known, ok := b.byID[bundle.SHA]; ok {
+			return known, nil
+		}
+		return bundle, nil
+	case len(ref) < minBundlePrefix:
+		return Bundle{}, fmt.Errorf("%w: %q is shorter than %d characters", ErrUnknownBundle, ref, minBundlePrefix)
+	}
+	var found []Bundle
+	for sha, bundle := range b.byID {
+		if strings.HasPrefix(sha, ref) {
+			found = append(found, bundle)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Bundle{}, fmt.Errorf("%w: %s", ErrUnknownBundle, ref)
+	case 1:
+		return found[0], nil
+	default:
+		return Bundle{}, fmt.Errorf("%w: %s matches %d bundles", ErrUnknownBundle, ref, len(found))
+	}
+}
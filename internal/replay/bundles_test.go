@@ -0,0 +1,174 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay/policy/policytest"
+)
+
+// twinBundle is the vendored bundle under another digest: the same code with a
+// trailing comment, so a replay on it must produce the vendored numbers while
+// every piece of plumbing has to keep the two builds apart.
+func twinBundle(t *testing.T, dir string) (Bundle, string) {
+	t.Helper()
+	path := filepath.Join(dir, "twin.js")
+	require.NoError(t, os.WriteFile(path, []byte(coreBundle+"\n// twin\n"), 0o600))
+	b, err := ReadBundleFile(path)
+	require.NoError(t, err)
+	require.NotEqual(t, BundleSHA(), b.SHA)
+	return b, path
+}
+
+func TestLoadBundlesKeysEveryBuildByItsContent(t *testing.T) {
+	dir := t.TempDir()
+	twin, path := twinBundle(t, dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a bundle"), 0o600))
+
+	bundles, err := LoadBundles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, BundleSHA(), bundles.Current().SHA)
+	assert.Equal(t, []string{BundleSHA(), twin.SHA}, bundles.SHAs(), "current first, and nothing but .js")
+
+	got, ok := bundles.Get(twin.SHA)
+	require.True(t, ok)
+	assert.Equal(t, path, got.Origin)
+
+	for ref, want := range map[string]string{
+		"current":       BundleSHA(),
+		twin.SHA:        twin.SHA,
+		twin.SHA[:8]:    twin.SHA,
+		BundleSHA()[:8]: BundleSHA(),
+		path:            twin.SHA,
+	} {
+		b, err := bundles.Resolve(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, want, b.SHA, ref)
+	}
+	_, err = bundles.Resolve(twin.SHA[:7])
+	require.ErrorIs(t, err, ErrUnknownBundle, "too short to be typed on purpose")
+	_, err = bundles.Resolve("0123456789abcdef")
+	require.ErrorIs(t, err, ErrUnknownBundle)
+}
+
+// An archived file's name is what a reviewer reads; content that does not hash
+// to it would replay runs on code that never judged them.
+func TestArchivedBundlesMustBeNamedByTheirDigest(t *testing.T) {
+	source := []byte("var TypeMoreCore = {};\n")
+	sha := sumBundle(source)
+
+	b := &Bundles{byID: map[string]Bundle{}}
+	require.NoError(t, b.addArchive(fstest.MapFS{
+		"archive/README.md":             {Data: []byte("# kept builds")},
+		"archive/" + sha + ".bundle.js": {Data: source},
+	}, "archive"))
+	got, ok := b.Get(sha)
+	require.True(t, ok)
+	assert.Equal(t, "archive", got.Origin)
+
+	misnamed := &Bundles{byID: map[string]Bundle{}}
+	err := misnamed.addArchive(fstest.MapFS{
+		"archive/" + BundleSHA() + ".bundle.js": {Data: source},
+	}, "archive")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "named by its own digest")
+}
+
+// The embedded archive ships valid: every build in it loads and compiles.
+func TestEmbeddedArchiveLoads(t *testing.T) {
+	bundles, err := LoadBundles("")
+	require.NoError(t, err)
+	for _, sha := range bundles.SHAs() {
+		b, _ := bundles.Get(sha)
+		_, err := NewCoreFor(b, DefaultReplayTimeout)
+		require.NoError(t, err, "bundle %s (%s)", b.Short(), b.Origin)
+	}
+}
+
+// A pinned run is replayed on its own build and only its own: a Core refuses a
+// pin it does not run, and the pinned engine refuses a pin it does not hold.
+func TestPinnedReplayRunsOnThePinnedBuild(t *testing.T) {
+	dir := t.TempDir()
+	twin, _ := twinBundle(t, dir)
+	bundles, err := LoadBundles(dir)
+	require.NoError(t, err)
+	_, reg := sharedDicts(t)
+	ctx := context.Background()
+
+	run := firstVector(t, "words-clean").pendingRun(t)
+	current := mustCore(t, DefaultReplayTimeout)
+	want, err := ReplayRun(ctx, current, reg, goldenQuotes(t), run, time.Time{})
+	require.NoError(t, err)
+
+	run.ReplayWith = twin.SHA
+	_, err = ReplayRun(ctx, current, reg, goldenQuotes(t), run, time.Time{})
+	require.ErrorIs(t, err, ErrUnknownBundle, "the vendored Core must not replay a run pinned elsewhere")
+
+	engine := NewPinnedEngine(bundles, current, DefaultReplayTimeout)
+	got, err := ReplayRun(ctx, engine, reg, goldenQuotes(t), run, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, want.Verdict, got.Verdict)
+	assert.JSONEq(t, string(want.Score), string(got.Score))
+
+	run.ReplayWith = sumBundle([]byte("never loaded"))
+	_, err = ReplayRun(ctx, engine, reg, goldenQuotes(t), run, time.Time{})
+	require.ErrorIs(t, err, ErrUnknownBundle)
+}
+
+// The decision records the build that produced the numbers, which for a pinned
+// replay is the pin.
+func TestAPinnedDecisionRecordsThePinnedBundle(t *testing.T) {
+	run, res := shadowCase()
+	d := testDecider(t, policytest.NewFake())
+	assert.Equal(t, BundleSHA(), d.Decide(run, res, nil).BundleSHA)
+	run.ReplayWith = "abc123"
+	assert.Equal(t, "abc123", d.Decide(run, res, nil).BundleSHA)
+}
+
+// rescored is an Engine whose builds agree on everything but the score total.
+type rescored struct {
+	Engine
+	total string
+}
+
+func (e rescored) Replay(ctx context.Context, in Input) (Result, error) {
+	res, err := e.Engine.Replay(ctx, in)
+	if err == nil && res.Score != nil {
+		var score map[string]json.RawMessage
+		if json.Unmarshal(res.Score, &score) == nil {
+			score["total"] = json.RawMessage(e.total)
+			res.Score, _ = json.Marshal(score)
+		}
+	}
+	return res, err
+}
+
+func TestDiffRunReportsWhatTheNewBuildChanges(t *testing.T) {
+	_, reg := sharedDicts(t)
+	decider := testDecider(t, policytest.NewFake()).ForRejudgement()
+	core := mustCore(t, DefaultReplayTimeout)
+	run := firstVector(t, "words-clean").pendingRun(t)
+	ctx := context.Background()
+
+	same := DiffRun(ctx, core, core, reg, goldenQuotes(t), decider, run, time.Time{})
+	assert.Empty(t, same.Changed, "a build diffed against itself changes nothing")
+	assert.Equal(t, StatusAccepted, same.Old.Status)
+	delta, ok := same.TotalDelta()
+	require.True(t, ok)
+	assert.Zero(t, delta)
+
+	moved := DiffRun(ctx, core, rescored{Engine: core, total: "1"}, reg, goldenQuotes(t), decider, run, time.Time{})
+	require.Contains(t, moved.Changed, DiffScore)
+	assert.NotContains(t, moved.Changed, DiffMetrics)
+	delta, ok = moved.TotalDelta()
+	require.True(t, ok)
+	assert.InDelta(t, 1-*moved.Old.Total, delta, 1e-9)
+}
@@ -90,24 +90,25 @@ type BuildInfo struct {
 // same prefix is parsed by `make core-bundle` and the freshness gate.
 const bundleTrailerPrefix = "//# typemore-core-build "
 
-// bundleBuildInfo parses the provenance trailer once. Absent or malformed
+// bundleTrailer parses a bundle's provenance trailer. Absent or malformed
 // trailers yield the zero value — see BuildInfo on why that is not an error.
-var bundleBuildInfo = func() (info struct {
+func bundleTrailer(source string) (info struct {
 	GitSHA   string `json:"gitSha"`
 	GitDirty bool   `json:"gitDirty"`
 }) {
-	trimmed := strings.TrimRight(coreBundle, "\n")
+	trimmed := strings.TrimRight(source, "\n")
 	if i := strings.LastIndexByte(trimmed, '\n'); i >= 0 && strings.HasPrefix(trimmed[i+1:], bundleTrailerPrefix) {
 		// Best-effort by design; a bad trailer leaves the zero value.
 		_ = json.Unmarshal([]byte(strings.TrimPrefix(trimmed[i+1:], bundleTrailerPrefix)), &info)
 	}
 	return info
-}()
+}
 
 // readBuildInfo lifts the identity constants off the bundle's export object.
 // Missing exports (an older vendored bundle) leave zero values.
-func readBuildInfo(exports *goja.Object) BuildInfo {
-	info := BuildInfo{GitSHA: bundleBuildInfo.GitSHA, GitDirty: bundleBuildInfo.GitDirty}
+func readBuildInfo(source string, exports *goja.Object) BuildInfo {
+	trailer := bundleTrailer(source)
+	info := BuildInfo{GitSHA: trailer.GitSHA, GitDirty: trailer.GitDirty}
 	if v := exports.Get("CORE_PACKAGE_VERSION"); v != nil {
 		if s, ok := v.Export().(string); ok {
 			info.PackageVersion = s
@@ -146,6 +147,9 @@ type Core struct {
 	rt      *goja.Runtime
 	timeout time.Duration
 
+	// bundle is the build this runtime evaluated. Every Result it returns was
+	// computed by exactly this code, whichever bundle is vendored.
+	bundle Bundle
 	// buildInfo is the bundle's self-reported identity, read once at NewCore.
 	buildInfo BuildInfo
 
@@ -160,6 +164,16 @@ type Core struct {
 	// charObservationsOf feeds the user_keyboard_profile projection: per typed
 	// character — presses, errors, inter-key intervals (docs/PROFILE.md).
 	charObservationsOf goja.Callable
+	// The fold's own steps, driven one event at a time by Timeline: the loop
+	// foldLog runs, with the state read back out between events.
+	initialStateOf      goja.Callable
+	settle              goja.Callable
+	reduce              goja.Callable
+	sortEvents          goja.Callable
+	isTelemetryEvent    goja.Callable
+	bufferOf            goja.Callable
+	correctPrefixLength goja.Callable
+	netCharsOf          goja.Callable
 
 	// Host intrinsics used to move plain JSON across the boundary.
 	jsonParse     goja.Callable
@@ -188,25 +202,36 @@ type Core struct {
 // answerable from the boot log alone.
 func (c *Core) BuildInfo() BuildInfo { return c.buildInfo }
 
+// BundleSHA returns the SHA-256 of the bundle this Core evaluated — the
+// package-level BundleSHA for a NewCore, an archived build's for NewCoreFor.
+func (c *Core) BundleSHA() string { return c.bundle.SHA }
+
 // NewCore evaluates the vendored bundle and binds the exports the pipeline
 // calls. A failure here means the vendored artifact is broken, was built with a
 // syntax goja cannot parse, or no longer exports something the server needs —
 // all startup failures, never first-request surprises.
 func NewCore(timeout time.Duration) (*Core, error) {
+	return NewCoreFor(Bundle{SHA: bundleSHA, Source: coreBundle, Origin: "embedded"}, timeout)
+}
+
+// NewCoreFor is NewCore over any bundle the registry holds (see Bundles). The
+// exports bound are the same for every build: a bundle too old to export one
+// of them cannot replay a run the way the pipeline asks, and fails here.
+func NewCoreFor(b Bundle, timeout time.Duration) (*Core, error) {
 	if timeout <= 0 {
 		timeout = DefaultReplayTimeout
 	}
 	rt := goja.New()
-	if _, err := rt.RunScript(bundleName, coreBundle); err != nil {
-		return nil, fmt.Errorf("replay: evaluate core bundle: %w", err)
+	if _, err := rt.RunScript(bundleName, b.Source); err != nil {
+		return nil, fmt.Errorf("replay: evaluate core bundle %s: %w", b.Short(), err)
 	}
 
 	exports, ok := rt.Get(coreGlobal).(*goja.Object)
 	if !ok || exports == nil {
-		return nil, fmt.Errorf("replay: core bundle did not define global %q", coreGlobal)
+		return nil, fmt.Errorf("replay: core bundle %s did not define global %q", b.Short(), coreGlobal)
 	}
 
-	c := &Core{rt: rt, timeout: timeout, dicts: make(map[string]goja.Value), buildInfo: readBuildInfo(exports)}
+	c := &Core{rt: rt, timeout: timeout, bundle: b, dicts: make(map[string]goja.Value), buildInfo: readBuildInfo(b.Source, exports)}
 	bind := func(dst *goja.Callable, name string) error {
 		fn, ok := goja.AssertFunction(exports.Get(name))
 		if !ok {
@@ -226,6 +251,14 @@ func NewCore(timeout time.Duration) (*Core, error) {
 		{&c.scoreV2OfLog, "scoreV2OfLog"},
 		{&c.scoreV3OfLog, "scoreV3OfLog"},
 		{&c.charObservationsOf, "charObservationsOf"},
+		{&c.initialStateOf, "initialStateOf"},
+		{&c.settle, "settle"},
+		{&c.reduce, "reduce"},
+		{&c.sortEvents, "sortEvents"},
+		{&c.isTelemetryEvent, "isTelemetryEvent"},
+		{&c.bufferOf, "bufferOf"},
+		{&c.correctPrefixLength, "correctPrefixLength"},
+		{&c.netCharsOf, "netCharsOf"},
 	} {
 		if err := bind(b.dst, b.name); err != nil {
 			return nil, err
@@ -330,8 +363,10 @@ func (c *Core) stringifyJSON(ctx context.Context, v goja.Value) ([]byte, error)
 
 // DictVersion returns the core's FNV-1a fingerprint of a word list — the same
 // `dict_hash` the client computes, byte-for-byte, because it is literally the
-// same code. Never reimplement this in Go: a drifting hash silently invalidates
-// every stored run.
+// same code. The registry takes its fingerprints from here and nowhere else: a
+// drifting hash silently invalidates every stored run. (Native carries its own
+// copy for replay, and TestNativeDictVersionMatchesTheBundle is what holds it
+// to this one.)
 func (c *Core) DictVersion(words []string) (string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -513,6 +548,11 @@ type Input struct {
 	// vector and every re-judgement of history reproducible. Set only from
 	// CanariesArmedAt — the epoch gate — and never from a per-call default.
 	CanariesArmed bool
+	// Bundle is the SHA of the core build this Input must be replayed on, or
+	// empty for whichever build the Engine runs. A Core refuses an Input pinned
+	// to any build but its own; NewPinnedEngine is what routes one to the
+	// right Core.
+	Bundle string
 }
 
 // QuoteText is one quote as the registry holds it: the bytes the run was
@@ -550,6 +590,9 @@ type Result struct {
 	// core from the same replay (nil when the verdict is invalid — a refused
 	// log's keystrokes are not evidence of anything).
 	CharObservations []CharObservation `json:"-"`
+	// Diverged is set only by the differential engine, when the native core's
+	// report for the same Input differed from this one. See Engine.
+	Diverged *EngineDivergence `json:"-"`
 }
 
 // CharObservation mirrors the core's CharObservation (shared/core/keyboard.ts):
@@ -580,15 +623,60 @@ type setupParts struct {
 // are the same functions the browser calls. Nothing here re-derives a metric, a
 // multiplier, or a hash.
 func (c *Core) Replay(ctx context.Context, in Input) (Result, error) {
+	if in.Bundle != "" && in.Bundle != c.bundle.SHA {
+		// Replaying anyway would record one build's numbers under another's
+		// bundle_sha, which is the one lie that field exists to rule out.
+		return Result{}, fmt.Errorf("%w: input is pinned to %s, this core runs %s",
+			ErrUnknownBundle, shortSHA(in.Bundle), c.bundle.Short())
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	input, dictionary, err := c.assemble(ctx, in)
+	if err != nil {
+		return Result{}, err
+	}
+
+	report, err := c.validate(ctx, input)
+	if err != nil {
+		return Result{}, err
+	}
+	if report.Verdict != verdictValid {
+		// An invalid log has no meaningful numbers: the core returns zeroed
+		// metrics and scoring a log the reducer rejected is nonsense.
+		return report, nil
+	}
+
+	score, err := c.score(ctx, input, in.ScoreVersion, dictionary)
+	if err != nil {
+		return Result{}, err
+	}
+	report.Score = score
+
+	// The keyboard observations for the user_keyboard_profile projection —
+	// extracted HERE, while the log is already parsed in this runtime, so the
+	// aggregates never require replaying a log anywhere else. Valid logs only:
+	// an invalid log returned above, and its keystrokes are not evidence of
+	// anything.
+	observations, err := c.charObservations(ctx, input, dictionary)
+	if err != nil {
+		return Result{}, err
+	}
+	report.CharObservations = observations
+	return report, nil
+}
+
+// assemble builds the one input object validateLog reads, in this runtime, and
+// returns it with the dictionary value attached to it. Replay and Timeline both
+// start here, so a timeline is folded over exactly the words and events a
+// verdict is. The caller holds mu.
+func (c *Core) assemble(ctx context.Context, in Input) (*goja.Object, goja.Value, error) {
 	var setup setupParts
 	if err := json.Unmarshal(in.Setup, &setup); err != nil {
-		return Result{}, fmt.Errorf("replay: setup is not an object: %w", err)
+		return nil, nil, fmt.Errorf("replay: setup is not an object: %w", err)
 	}
 	if len(setup.Config) == 0 || len(setup.Generation) == 0 {
-		return Result{}, errors.New("replay: setup is missing config or generation")
+		return nil, nil, errors.New("replay: setup is missing config or generation")
 	}
 
 	// A quote run has no dictionary and no seeded regeneration: its words ARE
@@ -602,7 +690,7 @@ func (c *Core) Replay(ctx context.Context, in Input) (Result, error) {
 	} else {
 		parsedDict, err := c.dictionaryValue(ctx, in.DictHash, in.DictBody)
 		if err != nil {
-			return Result{}, err
+			return nil, nil, err
 		}
 		dictionary, dictVersion = parsedDict, in.DictHash
 	}
@@ -639,14 +727,14 @@ func (c *Core) Replay(ctx context.Context, in Input) (Result, error) {
 
 	parsed, err := c.parseJSON(ctx, doc.String())
 	if err != nil {
-		return Result{}, err
+		return nil, nil, err
 	}
 	input, ok := parsed.(*goja.Object)
 	if !ok {
-		return Result{}, errors.New("replay: assembled input did not parse to an object")
+		return nil, nil, errors.New("replay: assembled input did not parse to an object")
 	}
 	if err := input.Set("dictionary", dictionary); err != nil {
-		return Result{}, fmt.Errorf("replay: attach dictionary: %w", err)
+		return nil, nil, fmt.Errorf("replay: attach dictionary: %w", err)
 	}
 	if in.Quote != nil {
 		// The resolved text is injected into the PARSED input, not spliced into
@@ -656,42 +744,42 @@ func (c *Core) Replay(ctx context.Context, in Input) (Result, error) {
 		// client's own `text` — refused at ingestion — could not reach here
 		// even if a stored row carried one, because this overwrites it.
 		if err := setQuoteText(input, in.Quote.Text); err != nil {
-			return Result{}, err
+			return nil, nil, err
 		}
 	}
+	return input, dictionary, nil
+}
 
-	report, err := c.validate(ctx, input)
+// charObservations calls the core's charObservationsOf over the same words and
+// events the verdict was computed from, and decodes the per-character rows.
+func (c *Core) charObservations(ctx context.Context, input *goja.Object, dictionary goja.Value) ([]CharObservation, error) {
+	coreCtx, err := c.coreContext(ctx, input, dictionary)
 	if err != nil {
-		return Result{}, err
+		return nil, err
 	}
-	if report.Verdict != verdictValid {
-		// An invalid log has no meaningful numbers: the core returns zeroed
-		// metrics and scoring a log the reducer rejected is nonsense.
-		return report, nil
+	logObj, ok := input.Get("log").(*goja.Object)
+	if !ok {
+		return nil, errors.New("replay: log is not an object")
 	}
-
-	score, err := c.score(ctx, input, in.ScoreVersion, dictionary)
+	rows, err := c.call(ctx, c.charObservationsOf, coreCtx, logObj.Get("events"))
 	if err != nil {
-		return Result{}, err
+		return nil, err
 	}
-	report.Score = score
-
-	// The keyboard observations for the user_keyboard_profile projection —
-	// extracted HERE, while the log is already parsed in this runtime, so the
-	// aggregates never require replaying a log anywhere else. Valid logs only:
-	// an invalid log returned above, and its keystrokes are not evidence of
-	// anything.
-	observations, err := c.charObservations(ctx, input, dictionary)
+	raw, err := c.stringifyJSON(ctx, rows)
 	if err != nil {
-		return Result{}, err
+		return nil, err
 	}
-	report.CharObservations = observations
-	return report, nil
+	var out []CharObservation
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("replay: decode char observations: %w", err)
+	}
+	return out, nil
 }
 
-// charObservations calls the core's charObservationsOf over the same words and
-// events the verdict was computed from, and decodes the per-character rows.
-func (c *Core) charObservations(ctx context.Context, input *goja.Object, dictionary goja.Value) ([]CharObservation, error) {
+// coreContext regenerates the run's words and returns the {config, words}
+// context the core's per-event functions take — the ctx foldLog builds inside
+// validateLog, built here for the exports validateLog does not reach.
+func (c *Core) coreContext(ctx context.Context, input *goja.Object, dictionary goja.Value) (*goja.Object, error) {
 	snapshot, ok := input.Get("configSnapshot").(*goja.Object)
 	if !ok {
 		return nil, errors.New("replay: configSnapshot is not an object")
@@ -716,30 +804,14 @@ func (c *Core) charObservations(ctx context.Context, input *goja.Object, diction
 	if !ok {
 		return nil, errors.New("replay: generateWords returned a non-object")
 	}
-	logObj, ok := input.Get("log").(*goja.Object)
-	if !ok {
-		return nil, errors.New("replay: log is not an object")
-	}
 	coreCtx := c.rt.NewObject()
 	if err := errors.Join(
 		coreCtx.Set("config", snapshot.Get("config")),
 		coreCtx.Set("words", genObj.Get("words")),
 	); err != nil {
-		return nil, fmt.Errorf("replay: build observation context: %w", err)
-	}
-	rows, err := c.call(ctx, c.charObservationsOf, coreCtx, logObj.Get("events"))
-	if err != nil {
-		return nil, err
-	}
-	raw, err := c.stringifyJSON(ctx, rows)
-	if err != nil {
-		return nil, err
-	}
-	var out []CharObservation
-	if err := json.Unmarshal(raw, &out); err != nil {
-		return nil, fmt.Errorf("replay: decode char observations: %w", err)
+		return nil, fmt.Errorf("replay: build core context: %w", err)
 	}
-	return out, nil
+	return coreCtx, nil
 }
 
 // validate runs validateLog and lifts its report into Go, keeping the metrics
@@ -16,7 +16,7 @@ const rebuildHint = "run `pnpm --filter @typemore/core build` in the frontend, t
 
 // buildInfoTrailerPrefix marks the machine-readable last line the package build
 // appends to dist/core.bundle.js. The same prefix is parsed by `make
-// core-bundle` (provenance refusal) and by bundleBuildInfo in core.go.
+// core-bundle` (provenance refusal) and by bundleTrailer in core.go.
 const buildInfoTrailerPrefix = "//# typemore-core-build "
 
 // TestVendoredBundleIsFresh diffs the vendored artifact against the frontend's
@@ -1,12 +1,16 @@
 package replay
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/runstatus"
 )
 
 // Verdicts. 'valid' and 'invalid' come from the core's validateLog; 'error' is
@@ -94,11 +98,23 @@ type validationDoc struct {
 	// flags summed to, what it was compared against, and which combination
 	// rules fired.
 	Policy *policyDoc `json:"policy,omitempty"`
+	// History is the player-history comparison, when one ran: the baseline and
+	// the flags it raised. Those flags reached the judge beside Flags and are
+	// recorded here rather than there.
+	History *historyDoc `json:"history,omitempty"`
+	// WallClock is a match seat's capture held against the server's arrival
+	// stamps (DecideCapture). Its flags reached the judge the way History's
+	// did, and are recorded here for the same reason.
+	WallClock *wallClockDoc `json:"wallClock,omitempty"`
 	// Divergence names the first field whose value did not match the client's.
 	// Both numbers are stored so a reviewer never has to re-run anything; the
 	// full objects live in client_metrics/client_score and
 	// server_metrics/server_score beside it.
 	Divergence *divergence `json:"divergence,omitempty"`
+	// Engine is set only under REPLAY_ENGINE=differential, when the native
+	// core disagreed with goja about this run. The verdict above is goja's
+	// either way; this is evidence against the port, never against the player.
+	Engine *EngineDivergence `json:"engine,omitempty"`
 }
 
 // policyDoc is the audit trail for one policy evaluation. The effective
@@ -163,6 +179,23 @@ type Decider struct {
 	// the only thing that changes in the table below: the client-vs-server
 	// metric comparison is skipped. See ForRejudgement.
 	rejudging bool
+	// capture marks a judgement of a server-held capture that no client ever
+	// submitted numbers for: both client comparisons are skipped. See
+	// ForCapture.
+	capture bool
+	// retry is how a transient replay failure of a PENDING run is retried. The
+	// zero value retries nothing. See WithRetry.
+	retry RetryPolicy
+	// history reads the player's baseline before a judge is consulted. Nil
+	// judges every run on its own, as every decider did before it existed. See
+	// WithHistory.
+	history HistorySource
+	// shadow is a candidate judge asked the same question as judge and never
+	// acted on. Nil asks nobody. See WithShadow.
+	shadow *Shadow
+	// trace records each step of the decision for Explain. Nil, as every
+	// other decider holds it, records nothing.
+	trace *trace
 }
 
 // ForRejudgement returns this decider set up for a pass over runs that have
@@ -207,6 +240,54 @@ func (p Decider) ForRejudgement() Decider {
 	return p
 }
 
+// ForCapture returns this decider set up for a log the SERVER captured rather
+// than one a client submitted — a multiplayer seat (docs/MATCH.md §6).
+//
+// Both client comparisons go, for the plainest possible reason: there is no
+// client claim to compare against. A match seat is never submitted; its capture
+// is the relayed event stream the server stamped as it arrived, and the numbers
+// each client showed its own player never leave that client. score_mismatch and
+// metric_mismatch would be comparing the server's arithmetic with nothing.
+//
+// Everything else in the table is untouched, and that is the point of routing a
+// seat through Decide at all rather than writing a second table for matches: a
+// refused log is rejected, an unreplayable one is flagged, and the judge sees
+// the seat's flags exactly as it sees a solo run's.
+func (p Decider) ForCapture() Decider {
+	p.capture = true
+	p.shadow = nil
+	return p
+}
+
+// WithRetry returns this decider set up to retry transient replay failures
+// under r rather than flagging them on the spot (docs/REPLAY.md, "Retry and
+// dead letters").
+//
+// It applies to the ingestion queue only. A re-judgement has a verdict row
+// and, possibly, a board slot to keep consistent, and a capture is judged as
+// one seat of a match that is placed as a unit; putting either back to
+// 'pending' would have to undo far more than a flag. Both keep the old rule —
+// the first failure is final — and are dead-lettered at once, where
+// `replayctl retry` can release them deliberately.
+func (p Decider) WithRetry(r RetryPolicy) Decider {
+	p.retry = r
+	return p
+}
+
+// WithHistory returns this decider set up to measure each run against its
+// player's own past before the judge sees it (docs/REPLAY.md, "Player
+// history"). Nil turns the layer off.
+//
+// Decide stays pure: the baseline is read by Resolve, once the replay has
+// produced something worth comparing, and handed in. A capture is never
+// compared — a match seat belongs to a player id the room minted, not
+// necessarily to an account with a past — and a judge that judges nothing is
+// not worth a query per run.
+func (p Decider) WithHistory(h HistorySource) Decider {
+	p.history = h
+	return p
+}
+
 // NewDecider binds a judge to the decision path, rejecting one whose version
 // cannot be recorded on a run. A nil judge is the open default, policy.Noop.
 func NewDecider(j policy.Judge) (Decider, error) {
@@ -228,6 +309,37 @@ func (p Decider) Judge() policy.Judge { return p.judge }
 // with the open default rather than dereferencing a nil judge.
 func (p Decider) isZero() bool { return p.judge == nil }
 
+// Resolve is Decide with the player's history read first, when this decider
+// has a source for it and the outcome is one a judge will see. Judge calls it;
+// so does `replayctl calibrate`, which is how the dry run and the worker stay
+// the same path.
+//
+// A history that cannot be READ is a replay_error, retried under WithRetry
+// like a quote registry that did not answer. Judging the run without it would
+// make the verdict depend on whether the database blinked.
+func (p Decider) Resolve(ctx context.Context, run PendingRun, res Result, replayErr error) Decision {
+	if replayErr != nil || res.Verdict == verdictInvalid {
+		return p.Decide(run, res, replayErr)
+	}
+	switch {
+	case p.history == nil:
+		p.trace.note(StepHistory, OutcomeSkipped, "player history is not enabled")
+		return p.Decide(run, res, replayErr)
+	case p.capture:
+		p.trace.note(StepHistory, OutcomeSkipped, "a server capture is never compared with a player's history")
+		return p.Decide(run, res, replayErr)
+	case policy.IsNoop(p.judge):
+		p.trace.note(StepHistory, OutcomeSkipped, "no judge would read the comparison")
+		return p.Decide(run, res, replayErr)
+	}
+	h, err := p.history.History(ctx, run.ID, res.CharObservations)
+	if err != nil {
+		p.trace.note(StepHistory, OutcomeFailed, "could not read the player's history: %v", err)
+		return p.Decide(run, Result{Diverged: res.Diverged}, fmt.Errorf("replay: read player history: %w", err))
+	}
+	return p.decide(run, res, nil, &h, nil)
+}
+
 // Decide maps a replay outcome onto the run's new state.
 //
 // It is pure and total: every input produces a decision, which is what keeps a
@@ -235,8 +347,8 @@ func (p Decider) isZero() bool { return p.judge == nil }
 //
 //	unknown dictionary          → flagged  unknown_dict         (attempts unchanged)
 //	unresolvable quote          → flagged  unknown_quote        (attempts unchanged)
-//	replay timed out            → flagged  replay_timeout       (attempts + 1)
-//	core threw / undecodable    → flagged  replay_error         (attempts + 1)
+//	replay timed out            → flagged  replay_timeout       (attempts + 1) ¹
+//	core threw / undecodable    → flagged  replay_error         (attempts + 1) ¹
 //	verdict invalid             → rejected reason from the core
 //	score total differs         → flagged  score_mismatch
 //	a metric differs > 1e-9     → flagged  metric_mismatch
@@ -244,6 +356,16 @@ func (p Decider) isZero() bool { return p.judge == nil }
 //	suspicion ≥ threshold       → flagged  suspicion_threshold
 //	otherwise                   → accepted (flags and suspicion still recorded)
 //
+// ¹ Or, under a WithRetry policy with attempts to spare, back to pending with
+// a backoff and no verdict at all (failed). A run that is flagged for either
+// reason is marked a dead letter.
+//
+// Player history (Resolve) adds no row. Its flags join the core's on the way
+// into the judge, so they can only ever move a run through the last two.
+//
+// Nor does a shadow candidate (WithShadow): it is asked about the same flags as
+// the judge, and its answer is recorded on Decision.Shadow and nowhere else.
+//
 // An invalid log outranks a mismatch: numbers recomputed from a log the reducer
 // refused are meaningless, so they are not stored at all.
 //
@@ -263,7 +385,26 @@ func (p Decider) isZero() bool { return p.judge == nil }
 // TestHardVerdictsDoNotDependOnTheJudge runs the tamper matrix against judges
 // from "review nothing, ever" to "review everything" and gets the same verdicts.
 func (p Decider) Decide(run PendingRun, res Result, replayErr error) Decision {
-	base := Decision{BundleSHA: bundleSHA, PolicyVersion: p.version, Attempts: run.Attempts}
+	return p.decide(run, res, replayErr, nil, nil)
+}
+
+// DecideCapture is Decide for a match seat whose capture carried arrival
+// stamps: the same table, with the wall-clock flags (policy.WallClockFlags)
+// read off clock and put before the judge beside the core's.
+//
+// Only a seat has a clock to read. A solo run's log is timed by the client
+// alone, so there is nothing on the server's side to hold it against.
+func (p Decider) DecideCapture(run PendingRun, res Result, replayErr error, clock policy.WallClock) Decision {
+	return p.decide(run, res, replayErr, nil, &clock)
+}
+
+// decide is Decide with an optional history already read and an optional
+// capture clock to read.
+func (p Decider) decide(run PendingRun, res Result, replayErr error, hist *History, clock *policy.WallClock) Decision {
+	// A run replayed on a pinned bundle records THAT bundle: bundle_sha names
+	// the code that produced the numbers, not the code that happens to be
+	// vendored while they were produced.
+	base := Decision{BundleSHA: cmp.Or(run.ReplayWith, bundleSHA), PolicyVersion: p.version, Attempts: run.Attempts, engine: res.Diverged}
 
 	switch {
 	case errors.Is(replayErr, ErrUnknownDict):
@@ -288,16 +429,14 @@ func (p Decider) Decide(run PendingRun, res Result, replayErr error) Decision {
 		}, replayErr.Error())
 
 	case errors.Is(replayErr, ErrReplayTimeout):
-		base.Attempts = run.Attempts + 1
-		return withValidation(base, StatusFlagged, validationDoc{
+		return p.failed(run, base, validationDoc{
 			Verdict: verdictError,
 			Reason:  ReasonReplayTimeout,
 			Flags:   []Flag{},
 		}, replayErr.Error())
 
 	case replayErr != nil:
-		base.Attempts = run.Attempts + 1
-		return withValidation(base, StatusFlagged, validationDoc{
+		return p.failed(run, base, validationDoc{
 			Verdict: verdictError,
 			Reason:  ReasonReplayError,
 			Flags:   []Flag{},
@@ -320,12 +459,53 @@ func (p Decider) Decide(run PendingRun, res Result, replayErr error) Decision {
 	base.ServerScore = res.Score
 	base.CharObservations = res.CharObservations
 
+	// The history flags go to the judge beside the core's and nowhere else:
+	// doc.Flags stays the core's report, and the comparison gets a block of its
+	// own below.
+	judged := res.Flags
+	var hflags []Flag
+	var history *historyDoc
+	if hist != nil {
+		hflags = policy.HistoryFlags(res.Flags, sampleOf(res.Metrics, hist.Rhythm), hist.Baseline)
+		judged = append(slices.Clip(res.Flags), hflags...)
+		history = &historyDoc{
+			Runs:        hist.Baseline.Runs,
+			WPMMean:     hist.Baseline.WPM.Mean,
+			WPMMax:      hist.Baseline.WPM.Max,
+			Judged:      hist.Baseline.Judged,
+			Reviewed:    hist.Baseline.Reviewed,
+			ProfileKeys: len(hist.Baseline.Rhythm),
+			Flags:       hflags,
+		}
+		if history.Flags == nil {
+			history.Flags = []Flag{}
+		}
+		p.trace.note(StepHistory, OutcomeOK, "compared with %d earlier run(s) (mean %g wpm, best %g): %d flag(s) raised",
+			history.Runs, history.WPMMean, history.WPMMax, len(hflags))
+	}
+	var wallClock *wallClockDoc
+	if clock != nil {
+		wallClock = readWallClock(*clock)
+		judged = append(slices.Clip(judged), wallClock.Flags...)
+	}
+
 	// The only place the judge is consulted. Everything above this line was
 	// decided without it and stays decided without it.
-	verdict := p.judge.Judge(res.Flags, policy.RunMeta{
+	meta := policy.RunMeta{
 		DurationSec:  runSeconds(res.Metrics),
 		ScoreVersion: run.ScoreVersion,
-	})
+	}
+	verdict := p.judge.Judge(judged, meta)
+	p.trace.judged(p.judge, res.Flags, hflags, verdict)
+
+	// The candidate, if there is one, is asked the same question with the
+	// same flags. Its answer is attached to whatever the real path decides
+	// below and acted on nowhere (WithShadow).
+	var candidate *policy.Decision
+	if p.shadow != nil {
+		v := p.shadow.judge.Judge(judged, meta)
+		candidate = &v
+	}
 
 	// The policy block is attached to every JUDGED decision — accepted included.
 	// An accepted run KEEPS its flags and its suspicion so moderation can audit
@@ -340,7 +520,7 @@ func (p Decider) Decide(run PendingRun, res Result, replayErr error) Decision {
 	// Keyed on the resolved column value rather than on asking the judge again,
 	// so "no policy block" and "NULL policy_version" are the same decision and
 	// cannot drift into a row that has one but not the other.
-	doc := validationDoc{Verdict: verdictValid, Flags: res.Flags}
+	doc := validationDoc{Verdict: verdictValid, Flags: res.Flags, History: history, WallClock: wallClock}
 	if p.version != policy.ColumnNone {
 		doc.Policy = &policyDoc{
 			Version:      p.version,
@@ -351,17 +531,24 @@ func (p Decider) Decide(run PendingRun, res Result, replayErr error) Decision {
 		}
 	}
 
-	if d, err := compareScore(run.ClientScore, res.Score); err != nil {
-		base.Attempts = run.Attempts + 1
-		return withValidation(base, StatusFlagged, validationDoc{
-			Verdict: verdictError,
-			Reason:  ReasonReplayError,
-			Flags:   res.Flags,
-			Policy:  doc.Policy,
-		}, err.Error())
-	} else if d != nil {
-		doc.Reason, doc.Divergence = ReasonScoreMismatch, d
-		return withValidation(base, StatusFlagged, doc, "")
+	// Skipped for a server capture, and only there: a capture has no client
+	// score to disagree with (ForCapture).
+	if p.capture {
+		p.trace.note(StepScore, OutcomeSkipped, "a server capture has no client score")
+	} else {
+		d, err := compareScore(run.ClientScore, res.Score)
+		p.trace.compared(StepScore, d, err)
+		if err != nil {
+			return p.failed(run, base, validationDoc{
+				Verdict: verdictError,
+				Reason:  ReasonReplayError,
+				Flags:   res.Flags,
+				Policy:  doc.Policy,
+			}, err.Error())
+		} else if d != nil {
+			doc.Reason, doc.Divergence = ReasonScoreMismatch, d
+			return p.withShadow(withValidation(base, StatusFlagged, doc, ""), doc, candidate)
+		}
 	}
 
 	// Skipped on a re-judgement: the client's stored numbers are an archival
@@ -370,10 +557,16 @@ func (p Decider) Decide(run PendingRun, res Result, replayErr error) Decision {
 	// computed metrics are still written — they are the ones that were always
 	// authoritative. See ForRejudgement for why the score check above survives
 	// this and the metric check does not.
-	if !p.rejudging {
-		if d, err := compareMetrics(run.ClientMetrics, res.Metrics); err != nil {
-			base.Attempts = run.Attempts + 1
-			return withValidation(base, StatusFlagged, validationDoc{
+	switch {
+	case p.capture:
+		p.trace.note(StepMetrics, OutcomeSkipped, "a server capture has no client metrics")
+	case p.rejudging:
+		p.trace.note(StepMetrics, OutcomeSkipped, "a re-judgement does not compare metrics (see ForRejudgement)")
+	default:
+		d, err := compareMetrics(run.ClientMetrics, res.Metrics)
+		p.trace.compared(StepMetrics, d, err)
+		if err != nil {
+			return p.failed(run, base, validationDoc{
 				Verdict: verdictError,
 				Reason:  ReasonReplayError,
 				Flags:   res.Flags,
@@ -381,22 +574,34 @@ func (p Decider) Decide(run PendingRun, res Result, replayErr error) Decision {
 			}, err.Error())
 		} else if d != nil {
 			doc.Reason, doc.Divergence = ReasonMetricMismatch, d
-			return withValidation(base, StatusFlagged, doc, "")
+			return p.withShadow(withValidation(base, StatusFlagged, doc, ""), doc, candidate)
 		}
 	}
 
-	// The judge's routing, recorded with the distinction the reason codes have
-	// always drawn: a run routed because a SHAPE fired reads differently from
-	// one routed because a magnitude crossed a line. Which shapes exist, and
-	// where the line is, is the judge's business and not recorded here.
-	if verdict.NeedsReview {
-		doc.Reason = ReasonSuspicionThreshold
-		if len(verdict.Reasons) > 0 {
-			doc.Reason = ReasonBotPattern
-		}
-		return withValidation(base, StatusFlagged, doc, "")
+	// The judge's routing (see routing for the two reasons). Which shapes
+	// exist, and where the line is, is the judge's business and not recorded
+	// here.
+	var status runstatus.Status
+	status, doc.Reason = routing(verdict)
+	return p.withShadow(withValidation(base, status, doc, ""), doc, candidate)
+}
+
+// failed is the decision for a replay that did not produce a judgeable result:
+// an attempt is burnt, and the run either goes back to the queue for another
+// one or, out of attempts, is flagged and dead-lettered.
+//
+// The retry decision carries the validation document it WOULD have been
+// flagged with, for the worker's log line; the queue writes no verdict for it.
+func (p Decider) failed(run PendingRun, base Decision, doc validationDoc, lastError string) Decision {
+	base.Attempts = run.Attempts + 1
+	d := withValidation(base, StatusFlagged, doc, lastError)
+	if !p.rejudging && !p.capture && p.retry.retries(d.Attempts) {
+		d.Status = StatusPending
+		d.RetryAfter = p.retry.Backoff(d.Attempts)
+		return d
 	}
-	return withValidation(base, StatusAccepted, doc, "")
+	d.DeadLetter = true
+	return d
 }
 
 // roundSuspicion trims the stored suspicion to six decimals. The comparison
@@ -412,6 +617,7 @@ func withValidation(d Decision, status string, doc validationDoc, lastError stri
 	if doc.Flags == nil {
 		doc.Flags = []Flag{}
 	}
+	doc.Engine = d.engine
 	raw, err := json.Marshal(doc)
 	if err != nil {
 		raw = []byte(`{"verdict":"error","reason":"replay_error","flags":[]}`)
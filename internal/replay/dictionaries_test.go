@@ -93,9 +93,18 @@ func TestCatalogueListsEverySeededDictionary(t *testing.T) {
 		assert.NotEmpty(t, e.DictHash, "%s: dictHash", e.Lang)
 		assert.Positive(t, e.WordCount, "%s: wordCount", e.Lang)
 		assert.Positive(t, e.Bytes, "%s: bytes", e.Lang)
+		assert.GreaterOrEqual(t, e.Stars, 1.0, "%s: stars", e.Lang)
+		assert.LessOrEqual(t, e.Stars, 5.0, "%s: stars", e.Lang)
+		rating, ok := reg.Difficulty(e.DictHash)
+		require.True(t, ok, "%s: every seeded dictionary is rated", e.Lang)
+		assert.Equal(t, rating.Stars(), e.Stars, "%s: stars", e.Lang)
 		byLang[e.Lang] = e
 	}
 
+	// The scale means something: code is harder to type than the prose most
+	// people learn on.
+	assert.Greater(t, byLang["code_rust"].Stars, byLang["english"].Stars)
+
 	for _, f := range files {
 		lang := f.Name()[:len(f.Name())-len(".json")]
 		e, ok := byLang[lang]
@@ -15,6 +15,11 @@
 // core algorithm in Go. The dictionary fingerprint is whatever
 // TypeMoreCore.dictVersion returns — never a Go FNV-1a that "should" agree.
 //
+// The one exception is Native, and it is an exception by construction rather
+// than by trust: a port of the whole core, pinned to the SHA of one bundle,
+// selectable per deployment (Engine), and held to that bundle byte for byte by
+// the golden vectors. The registry, quotectl and replayctl never use it.
+//
 // # Dictionaries
 //
 // corejs/core.bundle.js and dicts/*.json are compiled into the binary with
@@ -0,0 +1,292 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Engine is whatever computes a Result from an Input: the replay half of the
+// pipeline, with everything around it — text resolution, policy, persistence —
+// left where it was. Core (the bundle in goja) and Native (the port) both
+// satisfy it, and a deployment picks between them with REPLAY_ENGINE.
+//
+// An Engine must be safe to call from the goroutine that owns it; the worker
+// gives every goroutine its own, exactly as it did with Core.
+type Engine interface {
+	Replay(ctx context.Context, in Input) (Result, error)
+}
+
+var (
+	_ Engine = (*Core)(nil)
+	_ Engine = (*Native)(nil)
+)
+
+// EngineKind selects the replay engine a worker runs. See docs/REPLAY.md.
+type EngineKind string
+
+const (
+	// EngineGoja is the vendored bundle in goja: the reference, and the default.
+	EngineGoja EngineKind = "goja"
+	// EngineNative is the Go port, handing anything it does not model to goja.
+	EngineNative EngineKind = "native"
+	// EngineDifferential runs both on every run. goja's report is the one that
+	// is acted on; the native report is compared against it and any difference
+	// is logged and written into the run's validation document. It costs a
+	// goja replay plus a native one, and it is how the port earns trust on real
+	// traffic before a deployment switches to native.
+	EngineDifferential EngineKind = "differential"
+)
+
+// ParseEngineKind reads a REPLAY_ENGINE value. Empty means goja, so an
+// environment that predates the switch keeps the engine it always had.
+func ParseEngineKind(s string) (EngineKind, error) {
+	switch k := EngineKind(s); k {
+	case "":
+		return EngineGoja, nil
+	case EngineGoja, EngineNative, EngineDifferential:
+		return k, nil
+	default:
+		return "", fmt.Errorf("replay: unknown engine %q (want goja, native or differential)", s)
+	}
+}
+
+// NewEngine builds one engine of the given kind; timeout bounds each replay as
+// it does for NewCore. log receives the differential engine's divergence
+// reports and the native engine's fallbacks (slog.Default when nil).
+//
+// A native or differential engine is refused outright when the vendored
+// bundle is not the one the port was proven against (nativeBundleSHA): a
+// stale port would not crash, it would quietly judge runs by last release's
+// rules, and refusing at startup is the only point where that is loud.
+func NewEngine(kind EngineKind, timeout time.Duration, log *slog.Logger) (Engine, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+	if kind != EngineGoja && nativeBundleSHA != BundleSHA() {
+		return nil, fmt.Errorf("replay: the native core was ported from bundle %s, but %s is vendored; run REPLAY_ENGINE=goja until the port is updated",
+			shortSHA(nativeBundleSHA), shortSHA(BundleSHA()))
+	}
+	switch kind {
+	case EngineGoja:
+		return NewCore(timeout)
+	case EngineNative:
+		return &nativeEngine{native: NewNative(timeout), timeout: timeout, log: log}, nil
+	case EngineDifferential:
+		core, err := NewCore(timeout)
+		if err != nil {
+			return nil, err
+		}
+		return &differentialEngine{goja: core, native: NewNative(timeout), log: log}, nil
+	default:
+		return nil, fmt.Errorf("replay: unknown engine %q", kind)
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// nativeEngine is Native with goja behind it for what the port refuses. The
+// goja Core is built on the first fallback rather than up front: evaluating
+// the bundle is the slow part of startup, and a worker whose traffic the port
+// fully models never needs it.
+type nativeEngine struct {
+	native  *Native
+	timeout time.Duration
+	log     *slog.Logger
+
+	once    sync.Once
+	core    *Core
+	coreErr error
+}
+
+func (e *nativeEngine) Replay(ctx context.Context, in Input) (Result, error) {
+	res, err := e.native.Replay(ctx, in)
+	if !errors.Is(err, errUnmodelled) {
+		return res, err
+	}
+	e.once.Do(func() { e.core, e.coreErr = NewCore(e.timeout) })
+	if e.coreErr != nil {
+		return Result{}, e.coreErr
+	}
+	e.log.Debug("native core declined a run; replaying it in goja", "seed", in.Seed, "dictHash", in.DictHash)
+	return e.core.Replay(ctx, in)
+}
+
+// Timeline is goja's on a native deployment too: the port reproduces reports,
+// not the reducer's state between events.
+func (e *nativeEngine) Timeline(ctx context.Context, in Input) ([]TimelineFrame, error) {
+	e.once.Do(func() { e.core, e.coreErr = NewCore(e.timeout) })
+	if e.coreErr != nil {
+		return nil, e.coreErr
+	}
+	return e.core.Timeline(ctx, in)
+}
+
+// NewPinnedEngine routes each Input to a Core running the bundle it is pinned
+// to (Input.Bundle), and everything unpinned — or pinned to the vendored build
+// — to def. Cores for other builds are compiled the first time a run needs
+// one and kept for the engine's lifetime; like any Engine, the result belongs
+// to one goroutine.
+//
+// A pin the registry does not hold fails the replay with ErrUnknownBundle
+// rather than falling back to def: a run re-judged on the wrong code would
+// come back with a bundle_sha that does not describe its numbers.
+func NewPinnedEngine(bundles *Bundles, def Engine, timeout time.Duration) Engine {
+	return &pinnedEngine{bundles: bundles, def: def, timeout: timeout, cores: make(map[string]*Core)}
+}
+
+type pinnedEngine struct {
+	bundles *Bundles
+	def     Engine
+	timeout time.Duration
+	cores   map[string]*Core
+}
+
+func (e *pinnedEngine) Replay(ctx context.Context, in Input) (Result, error) {
+	if in.Bundle == "" || in.Bundle == e.bundles.Current().SHA {
+		return e.def.Replay(ctx, in)
+	}
+	core, err := e.coreFor(in.Bundle)
+	if err != nil {
+		return Result{}, err
+	}
+	return core.Replay(ctx, in)
+}
+
+func (e *pinnedEngine) Timeline(ctx context.Context, in Input) ([]TimelineFrame, error) {
+	if in.Bundle == "" || in.Bundle == e.bundles.Current().SHA {
+		def, ok := e.def.(timelineEngine)
+		if !ok {
+			return nil, ErrNoTimeline
+		}
+		return def.Timeline(ctx, in)
+	}
+	core, err := e.coreFor(in.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	return core.Timeline(ctx, in)
+}
+
+// coreFor returns the Core running bundle sha, compiling it on first use.
+func (e *pinnedEngine) coreFor(sha string) (*Core, error) {
+	if core, ok := e.cores[sha]; ok {
+		return core, nil
+	}
+	bundle, known := e.bundles.Get(sha)
+	if !known {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBundle, shortSHA(sha))
+	}
+	core, err := NewCoreFor(bundle, e.timeout)
+	if err != nil {
+		return nil, err
+	}
+	e.cores[sha] = core
+	return core, nil
+}
+
+// EngineDivergence is the differential engine's record of one disagreement:
+// the first field, in report order, whose goja and native values differ, and
+// both values as text. It is written into the run's validation document under
+// "engine" — the run's own verdict is goja's and is unaffected.
+type EngineDivergence struct {
+	Field  string `json:"field"`
+	Goja   string `json:"goja"`
+	Native string `json:"native"`
+}
+
+// divergenceValueLimit caps each side of a recorded divergence. A metrics or
+// score object fits with room to spare; a full flags array or a keyboard
+// projection may not, and the point is to name the field, not to archive it.
+const divergenceValueLimit = 512
+
+type differentialEngine struct {
+	goja   *Core
+	native *Native
+	log    *slog.Logger
+}
+
+func (e *differentialEngine) Timeline(ctx context.Context, in Input) ([]TimelineFrame, error) {
+	return e.goja.Timeline(ctx, in)
+}
+
+func (e *differentialEngine) Replay(ctx context.Context, in Input) (Result, error) {
+	res, err := e.goja.Replay(ctx, in)
+	shadow, shadowErr := e.native.Replay(ctx, in)
+	if errors.Is(shadowErr, errUnmodelled) {
+		// A refusal is the port being honest about its edges, not a
+		// disagreement: the native engine would have asked goja too.
+		return res, err
+	}
+	if d := divergenceOf(res, err, shadow, shadowErr); d != nil {
+		e.log.Warn("replay engines diverged",
+			"field", d.Field, "goja", d.Goja, "native", d.Native,
+			"seed", in.Seed, "dictHash", in.DictHash, "scoreVersion", in.ScoreVersion)
+		res.Diverged = d
+	}
+	return res, err
+}
+
+// divergenceOf compares two replays of the same Input, goja's first. Errors are
+// compared by text: the kinds and messages the native core returns are the
+// bundle's own, so even a refusal has to be the same refusal.
+func divergenceOf(want Result, wantErr error, got Result, gotErr error) *EngineDivergence {
+	if wantErr != nil || gotErr != nil {
+		if a, b := errText(wantErr), errText(gotErr); a != b {
+			return newDivergence("error", a, b)
+		}
+		return nil
+	}
+	switch {
+	case want.Verdict != got.Verdict:
+		return newDivergence("verdict", want.Verdict, got.Verdict)
+	case want.Reason != got.Reason:
+		return newDivergence("reason", want.Reason, got.Reason)
+	case !slices.Equal(want.Flags, got.Flags):
+		return newDivergence("flags", jsonText(want.Flags), jsonText(got.Flags))
+	case !bytes.Equal(want.Metrics, got.Metrics):
+		return newDivergence("metrics", string(want.Metrics), string(got.Metrics))
+	case !bytes.Equal(want.Score, got.Score):
+		return newDivergence("score", string(want.Score), string(got.Score))
+	case !slices.Equal(want.CharObservations, got.CharObservations):
+		return newDivergence("charObservations", jsonText(want.CharObservations), jsonText(got.CharObservations))
+	}
+	return nil
+}
+
+func newDivergence(field, goja, native string) *EngineDivergence {
+	return &EngineDivergence{Field: field, Goja: clip(goja), Native: clip(native)}
+}
+
+func errText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func jsonText(v any) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(raw)
+}
+
+func clip(s string) string {
+	if len(s) <= divergenceValueLimit {
+		return s
+	}
+	return s[:divergenceValueLimit] + "…"
+}
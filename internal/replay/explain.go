@@ -0,0 +1,353 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/runstatus"
+)
+
+// A RUN'S VERDICT, STEP BY STEP.
+//
+// The validation document says what was decided and the numbers behind it. It
+// does not say how they were arrived at — which text the log was folded
+// against, which comparisons ran and which were skipped, what each flag was
+// worth — and a reviewer reading a flagged run was left to reconstruct that
+// from raw JSON and this package's source.
+//
+// Explain answers it by judging the run again with a trace attached. The
+// trace is recorded by the decision path itself (replayRun, Resolve, decide)
+// rather than rebuilt from its output, so an explanation cannot describe a
+// route the worker does not take: the steps are the route.
+
+// Trace steps, in the order the decision path takes them. The judge is
+// consulted before the client comparisons even though a mismatch outranks it,
+// because its arithmetic is recorded on a mismatched run too; the decision
+// step names the row of Decide's table that settled the run.
+const (
+	StepQuote      = "quote"
+	StepDictionary = "dictionary"
+	StepReplay     = "replay"
+	StepHistory    = "history"
+	StepJudge      = "judge"
+	StepRules      = "rules"
+	StepThreshold  = "threshold"
+	StepScore      = "score"
+	StepMetrics    = "metrics"
+	StepDecision   = "decision"
+)
+
+// Step outcomes. A step that did not apply to this run is skipped and says
+// why, rather than being left out: "no metric comparison ran" is an answer a
+// reviewer needs to see. The decision step's outcome is the status itself.
+const (
+	OutcomeOK       = "ok"
+	OutcomeSkipped  = "skipped"
+	OutcomeFailed   = "failed"
+	OutcomeRefused  = "refused"
+	OutcomeMismatch = "mismatch"
+	OutcomeFired    = "fired"
+	OutcomeReached  = "reached"
+)
+
+// Flag sources in a judge step.
+const (
+	FlagSourceCore    = "core"
+	FlagSourceHistory = "history"
+)
+
+// TraceStep is one step of a traced decision. Detail is written for a person;
+// the typed fields beside it carry the same numbers for a program.
+type TraceStep struct {
+	Step    string `json:"step"`
+	Outcome string `json:"outcome"`
+	Detail  string `json:"detail,omitempty"`
+	// Divergence is set on a score or metrics step that did not agree.
+	Divergence *divergence `json:"divergence,omitempty"`
+	// Flags is set on the judge step: every flag the judge was handed.
+	Flags []FlagContribution `json:"flags,omitempty"`
+	// Rules is set on the rules step when a combination rule fired.
+	Rules []string `json:"rules,omitempty"`
+	// Suspicion and Threshold are set on the threshold step.
+	Suspicion *float64 `json:"suspicion,omitempty"`
+	Threshold *float64 `json:"threshold,omitempty"`
+}
+
+// FlagContribution is one flag as the judge weighed it. Weight and
+// Contribution are absent when the judge does not describe its arithmetic
+// (policy.Describer) or has no weight for the code, which Unknown tells apart.
+type FlagContribution struct {
+	Code         string   `json:"code"`
+	Score        float64  `json:"score"`
+	Detail       string   `json:"detail,omitempty"`
+	Source       string   `json:"source"`
+	Weight       *float64 `json:"weight,omitempty"`
+	Contribution *float64 `json:"contribution,omitempty"`
+	Unknown      bool     `json:"unknown,omitempty"`
+}
+
+// trace collects the steps of one decision. Every method is safe on a nil
+// receiver, which is what an untraced decider holds, so the decision path
+// records unconditionally and pays nothing for it outside Explain.
+type trace struct {
+	steps []TraceStep
+}
+
+func (t *trace) add(s TraceStep) {
+	if t != nil {
+		t.steps = append(t.steps, s)
+	}
+}
+
+func (t *trace) note(step, outcome, format string, args ...any) {
+	if t != nil {
+		t.add(TraceStep{Step: step, Outcome: outcome, Detail: fmt.Sprintf(format, args...)})
+	}
+}
+
+// replayed records what the core made of the log.
+func (t *trace) replayed(in Input, res Result, err error) {
+	if t == nil {
+		return
+	}
+	bundle := shortSHA(in.Bundle)
+	if in.Bundle == "" {
+		bundle = shortSHA(bundleSHA) + " (vendored)"
+	}
+	switch {
+	case err != nil:
+		t.note(StepReplay, OutcomeFailed, "bundle %s could not replay the log: %v", bundle, err)
+	case res.Verdict == verdictInvalid:
+		t.note(StepReplay, OutcomeRefused, "bundle %s refused the log: %s", bundle, res.Reason)
+	default:
+		armed := "unarmed"
+		if in.CanariesArmed {
+			armed = "armed"
+		}
+		t.note(StepReplay, OutcomeOK, "bundle %s replayed the log: %d flag(s) raised, canary detectors %s",
+			bundle, len(res.Flags), armed)
+	}
+}
+
+// judged records the judge's arithmetic: what each flag was worth, which
+// combination rules fired, and where the suspicion landed against the line.
+func (t *trace) judged(j policy.Judge, core, history []Flag, v policy.Decision) {
+	if t == nil {
+		return
+	}
+	if policy.IsNoop(j) {
+		t.note(StepJudge, OutcomeSkipped, "policy %s judges nothing: this instance decides runs for correctness only", j.Version())
+		return
+	}
+	desc, described := policy.Describe(j)
+	flags := make([]FlagContribution, 0, len(core)+len(history))
+	var sum float64
+	add := func(source string, fs []Flag) {
+		for _, f := range fs {
+			c := FlagContribution{Code: f.Code, Score: f.Score, Detail: f.Detail, Source: source}
+			if w, ok := desc.Weights[f.Code]; ok {
+				contribution := w * f.Score
+				c.Weight, c.Contribution = &w, &contribution
+				sum += contribution
+			} else if described {
+				c.Unknown = true
+			}
+			flags = append(flags, c)
+		}
+	}
+	add(FlagSourceCore, core)
+	add(FlagSourceHistory, history)
+
+	detail := fmt.Sprintf("policy %s weighed %d flag(s) to a suspicion of %g", j.Version(), len(flags), roundSuspicion(v.Suspicion))
+	switch {
+	case !described:
+		detail += "; this judge does not describe its weights, so only the total is known"
+	case math.Abs(sum-v.Suspicion) > 1e-9:
+		detail += fmt.Sprintf("; the weights above account for %g of it", roundSuspicion(sum))
+	}
+	if len(v.UnknownFlags) > 0 {
+		detail += "; no weight for " + strings.Join(v.UnknownFlags, ", ")
+	}
+	t.add(TraceStep{Step: StepJudge, Outcome: OutcomeOK, Detail: detail, Flags: flags})
+
+	if len(v.Reasons) > 0 {
+		t.add(TraceStep{Step: StepRules, Outcome: OutcomeFired, Rules: v.Reasons,
+			Detail: "combination rule(s) fired: " + strings.Join(v.Reasons, ", ")})
+	} else {
+		t.note(StepRules, OutcomeOK, "no combination rule fired")
+	}
+
+	suspicion, threshold := roundSuspicion(v.Suspicion), v.Threshold
+	step := TraceStep{Step: StepThreshold, Outcome: OutcomeOK, Suspicion: &suspicion, Threshold: &threshold,
+		Detail: fmt.Sprintf("suspicion %g is below the review threshold %g", suspicion, threshold)}
+	if v.Suspicion >= v.Threshold {
+		step.Outcome = OutcomeReached
+		step.Detail = fmt.Sprintf("suspicion %g reached the review threshold %g", suspicion, threshold)
+	}
+	if v.NeedsReview {
+		step.Detail += "; the judge routes the run to review"
+	} else {
+		step.Detail += "; the judge does not route the run to review"
+	}
+	t.add(step)
+}
+
+// compared records one client comparison as compareScore or compareMetrics
+// returned it.
+func (t *trace) compared(step string, d *divergence, err error) {
+	if t == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		t.note(step, OutcomeFailed, "could not compare: %v", err)
+	case d != nil:
+		client := "nothing"
+		if d.Client != nil {
+			client = fmt.Sprint(*d.Client)
+		}
+		t.add(TraceStep{Step: step, Outcome: OutcomeMismatch, Divergence: d,
+			Detail: fmt.Sprintf("%s: the client sent %s, the server computed %g", d.Field, client, d.Server)})
+	case step == StepScore:
+		t.note(step, OutcomeOK, "the client's total is the server's, exactly")
+	default:
+		t.note(step, OutcomeOK, "wpm, raw and acc agree within %g", metricTolerance)
+	}
+}
+
+// Explanation is one run judged again with a trace attached, beside the
+// verdict it carries now.
+type Explanation struct {
+	RunID uuid.UUID `json:"runId"`
+	// Mode is the table the trace ran under: "ingestion", or "rejudgement"
+	// when the metric comparison was skipped (Decider.ForRejudgement).
+	Mode          string      `json:"mode"`
+	BundleSHA     string      `json:"bundleSha"`
+	PolicyVersion string      `json:"policyVersion"`
+	Steps         []TraceStep `json:"steps"`
+	// Status and Reason are what this judgement arrives at.
+	Status runstatus.Status `json:"status"`
+	Reason string           `json:"reason,omitempty"`
+	// Stored is the verdict the run carries now. Agrees reports whether the
+	// trace arrived at the same status and reason; it is false for a run a
+	// moderator overrode, a run judged under another policy or bundle, and a
+	// pending run, and the stored verdict is there to say which.
+	Stored StoredVerdict `json:"stored"`
+	Agrees bool          `json:"agrees"`
+}
+
+// StoredVerdict is the verdict a run carries, as read.
+type StoredVerdict struct {
+	Status        runstatus.Status `json:"status"`
+	Reason        string           `json:"reason,omitempty"`
+	BundleSHA     *string          `json:"bundleSha,omitempty"`
+	PolicyVersion *int16           `json:"policyVersion,omitempty"`
+	Validation    json.RawMessage  `json:"validation,omitempty"`
+}
+
+// Explanation modes.
+const (
+	ModeIngestion   = "ingestion"
+	ModeRejudgement = "rejudgement"
+)
+
+// Explain judges a stored run again under decider, by the same route Judge
+// takes, and returns every step of the way. Nothing is written.
+//
+// The decider's retry policy is dropped: an explanation is one attempt, and a
+// failure that the worker would retry is reported as the failure it is. Which
+// table applies is the caller's choice — ingestion reproduces the verdict a
+// submission got, ForRejudgement the one `make revalidate` would give it.
+func Explain(ctx context.Context, core Engine, reg *Registry, quotes QuoteResolver, decider Decider, run StoredRun, canaryEpoch time.Time) Explanation {
+	if decider.isZero() {
+		decider, _ = NewDecider(nil)
+	}
+	t := &trace{}
+	decider.trace, decider.retry = t, RetryPolicy{}
+
+	res, err := replayRun(ctx, core, reg, quotes, run.PendingRun, canaryEpoch, t)
+	d := decider.Resolve(ctx, run.PendingRun, res, err)
+
+	var doc validationDoc
+	_ = json.Unmarshal(d.Validation, &doc)
+	if doc.Reason != "" {
+		t.note(StepDecision, string(d.Status), "%s: %s", d.Status, doc.Reason)
+	} else {
+		t.note(StepDecision, string(d.Status), "%s: nothing in the table above sends the run anywhere else", d.Status)
+	}
+
+	out := Explanation{
+		RunID:         run.ID,
+		Mode:          ModeIngestion,
+		BundleSHA:     d.BundleSHA,
+		PolicyVersion: decider.judge.Version(),
+		Steps:         t.steps,
+		Status:        d.Status,
+		Reason:        doc.Reason,
+		Stored: StoredVerdict{
+			Status:        run.Status,
+			BundleSHA:     run.BundleSHA,
+			PolicyVersion: run.PolicyVersion,
+			Validation:    run.Validation,
+		},
+	}
+	if decider.rejudging {
+		out.Mode = ModeRejudgement
+	}
+	if len(run.Validation) > 0 {
+		var stored validationDoc
+		if json.Unmarshal(run.Validation, &stored) == nil {
+			out.Stored.Reason = stored.Reason
+		}
+	}
+	out.Agrees = out.Status == out.Stored.Status && out.Reason == out.Stored.Reason
+	return out
+}
+
+// RunSource reads one stored run. pgstore.Queue implements it.
+type RunSource interface {
+	StoredRun(ctx context.Context, id uuid.UUID) (StoredRun, error)
+}
+
+// Explainer is Explain behind a run id, for the admin explain endpoint. It
+// shares the server's Core, whose own lock serialises it with the dictionary
+// service; an explanation is an operator request, not a hot path.
+type Explainer struct {
+	runs        RunSource
+	core        Engine
+	reg         *Registry
+	quotes      QuoteResolver
+	decider     Decider
+	canaryEpoch time.Time
+}
+
+// NewExplainer binds Explain to a store and the server's replay inputs. The
+// decider should be the one the worker judges with; Explain drops its retries.
+func NewExplainer(runs RunSource, core Engine, reg *Registry, quotes QuoteResolver, decider Decider, canaryEpoch time.Time) *Explainer {
+	return &Explainer{runs: runs, core: core, reg: reg, quotes: quotes, decider: decider, canaryEpoch: canaryEpoch}
+}
+
+// ExplainRun reads run id and explains it, under the rejudgement table when
+// rejudge is set. A run that does not exist is ErrRunNotFound.
+func (e *Explainer) ExplainRun(ctx context.Context, id uuid.UUID, rejudge bool) (Explanation, error) {
+	run, err := e.runs.StoredRun(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRunNotFound) {
+			return Explanation{}, err
+		}
+		return Explanation{}, fmt.Errorf("replay: explain run %s: %w", id, err)
+	}
+	decider := e.decider
+	if rejudge {
+		decider = decider.ForRejudgement()
+	}
+	return Explain(ctx, e.core, e.reg, e.quotes, decider, run, e.canaryEpoch), nil
+}
@@ -0,0 +1,175 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/replay/policy/policytest"
+)
+
+func explainVector(t *testing.T, name string, decider Decider, edit func(*PendingRun)) Explanation {
+	t.Helper()
+	_, reg := sharedDicts(t)
+	run := firstVector(t, name).pendingRun(t)
+	if edit != nil {
+		edit(&run)
+	}
+	stored := StoredRun{PendingRun: run, Status: StatusPending}
+	return Explain(context.Background(), mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t),
+		decider, stored, time.Time{})
+}
+
+func stepNames(e Explanation) []string {
+	out := make([]string, len(e.Steps))
+	for i, s := range e.Steps {
+		out[i] = s.Step
+	}
+	return out
+}
+
+func stepOf(t *testing.T, e Explanation, name string) TraceStep {
+	t.Helper()
+	for _, s := range e.Steps {
+		if s.Step == name {
+			return s
+		}
+	}
+	t.Fatalf("no %s step in %v", name, stepNames(e))
+	return TraceStep{}
+}
+
+// The trace is the route: a clean run passes every step, in the order the
+// decision path takes them, and lands where Judge lands.
+func TestExplainWalksEveryStepOfACleanRun(t *testing.T) {
+	decider := testDecider(t, policytest.NewFake())
+	e := explainVector(t, "words-clean", decider, nil)
+
+	assert.Equal(t, []string{StepQuote, StepDictionary, StepReplay, StepHistory, StepJudge,
+		StepRules, StepThreshold, StepScore, StepMetrics, StepDecision}, stepNames(e))
+	assert.Equal(t, OutcomeSkipped, stepOf(t, e, StepQuote).Outcome, "a seeded run has no quote")
+	assert.Equal(t, OutcomeOK, stepOf(t, e, StepDictionary).Outcome)
+	assert.Equal(t, OutcomeOK, stepOf(t, e, StepScore).Outcome)
+	assert.Equal(t, OutcomeOK, stepOf(t, e, StepMetrics).Outcome)
+	assert.Equal(t, ModeIngestion, e.Mode)
+	assert.Equal(t, StatusAccepted, e.Status)
+	assert.Equal(t, policytest.FakeVersion, e.PolicyVersion)
+	assert.False(t, e.Agrees, "a pending run has no verdict to agree with")
+
+	_, reg := sharedDicts(t)
+	run := firstVector(t, "words-clean").pendingRun(t)
+	want := Judge(context.Background(), mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), decider, run, time.Time{})
+	assert.Equal(t, want.Status, e.Status, "an explanation must arrive where the worker does")
+}
+
+// Each flag carries what it was worth, and the contributions are the
+// suspicion the judge routed on.
+func TestExplainWeighsEveryFlag(t *testing.T) {
+	e := explainVector(t, "words-bot-cadence", testDecider(t, policytest.NewFake()), nil)
+
+	judge := stepOf(t, e, StepJudge)
+	require.NotEmpty(t, judge.Flags)
+	weights, ok := policy.Describe(policytest.NewFake())
+	require.True(t, ok)
+	var sum float64
+	for _, f := range judge.Flags {
+		assert.Equal(t, FlagSourceCore, f.Source)
+		require.NotNil(t, f.Weight, f.Code)
+		require.NotNil(t, f.Contribution, f.Code)
+		assert.Equal(t, weights.Weights[f.Code], *f.Weight, f.Code)
+		assert.InDelta(t, *f.Weight*f.Score, *f.Contribution, 1e-12, f.Code)
+		sum += *f.Contribution
+	}
+	threshold := stepOf(t, e, StepThreshold)
+	require.NotNil(t, threshold.Suspicion)
+	assert.InDelta(t, *threshold.Suspicion, sum, 1e-6)
+	assert.Equal(t, policytest.FakeThreshold, *threshold.Threshold)
+
+	rules := stepOf(t, e, StepRules)
+	assert.Equal(t, OutcomeFired, rules.Outcome)
+	assert.Equal(t, []string{policytest.FakeShapeRule}, rules.Rules)
+	assert.Equal(t, ReasonBotPattern, e.Reason)
+}
+
+// A mismatch names the field and both numbers, and it is the last comparison
+// made: the table stops there.
+func TestExplainShowsTheScoreDivergence(t *testing.T) {
+	e := explainVector(t, "words-clean", testDecider(t, policytest.NewFake()), func(run *PendingRun) {
+		run.ClientScore = json.RawMessage(`{"total":1}`)
+	})
+
+	score := stepOf(t, e, StepScore)
+	assert.Equal(t, OutcomeMismatch, score.Outcome)
+	require.NotNil(t, score.Divergence)
+	assert.Equal(t, "total", score.Divergence.Field)
+	assert.Equal(t, 1.0, *score.Divergence.Client)
+	assert.NotContains(t, stepNames(e), StepMetrics)
+	assert.Equal(t, StatusFlagged, e.Status)
+	assert.Equal(t, ReasonScoreMismatch, e.Reason)
+}
+
+func TestExplainSaysWhichChecksDidNotRun(t *testing.T) {
+	t.Run("a re-judgement does not compare metrics", func(t *testing.T) {
+		e := explainVector(t, "words-clean", testDecider(t, policytest.NewFake()).ForRejudgement(), nil)
+		assert.Equal(t, ModeRejudgement, e.Mode)
+		assert.Equal(t, OutcomeSkipped, stepOf(t, e, StepMetrics).Outcome)
+	})
+
+	t.Run("a judge that judges nothing is skipped", func(t *testing.T) {
+		e := explainVector(t, "words-clean", testDecider(t, policy.Noop{}), nil)
+		assert.Equal(t, OutcomeSkipped, stepOf(t, e, StepJudge).Outcome)
+		assert.NotContains(t, stepNames(e), StepThreshold)
+		assert.Equal(t, StatusAccepted, e.Status)
+	})
+
+	t.Run("a quote run never reaches the dictionary registry", func(t *testing.T) {
+		e := explainVector(t, "quote-fixed-text", testDecider(t, policytest.NewFake()), nil)
+		assert.Equal(t, OutcomeOK, stepOf(t, e, StepQuote).Outcome)
+		assert.Equal(t, OutcomeSkipped, stepOf(t, e, StepDictionary).Outcome)
+	})
+
+	t.Run("an unknown dictionary stops the trace", func(t *testing.T) {
+		e := explainVector(t, "words-clean", testDecider(t, policytest.NewFake()), func(run *PendingRun) {
+			run.DictHash = "0000000000000000"
+		})
+		assert.Equal(t, []string{StepQuote, StepDictionary, StepDecision}, stepNames(e))
+		assert.Equal(t, OutcomeFailed, stepOf(t, e, StepDictionary).Outcome)
+		assert.Equal(t, ReasonUnknownDict, e.Reason)
+	})
+}
+
+type storedRuns map[uuid.UUID]StoredRun
+
+func (s storedRuns) StoredRun(_ context.Context, id uuid.UUID) (StoredRun, error) {
+	run, ok := s[id]
+	if !ok {
+		return StoredRun{}, ErrRunNotFound
+	}
+	return run, nil
+}
+
+// The explainer reads the run it is asked about and sets the verdict it
+// carries beside the trace.
+func TestExplainerReadsTheStoredVerdict(t *testing.T) {
+	_, reg := sharedDicts(t)
+	decider := testDecider(t, policytest.NewFake())
+	run := firstVector(t, "words-clean").pendingRun(t)
+	judged := Judge(context.Background(), mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), decider, run, time.Time{})
+	explainer := NewExplainer(storedRuns{run.ID: {PendingRun: run, Status: judged.Status, Validation: judged.Validation}},
+		mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), decider.WithRetry(RetryPolicy{MaxAttempts: 3}), time.Time{})
+
+	e, err := explainer.ExplainRun(context.Background(), run.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, run.ID, e.RunID)
+	assert.Equal(t, judged.Status, e.Stored.Status)
+	assert.True(t, e.Agrees)
+
+	_, err = explainer.ExplainRun(context.Background(), uuid.New(), false)
+	require.ErrorIs(t, err, ErrRunNotFound)
+}
@@ -0,0 +1,72 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+)
+
+// Player history (docs/REPLAY.md, "Player history").
+//
+// A judge sees one run. That is deliberate — RunMeta is small so a judge
+// cannot start re-deciding correctness — and it is also why a 60 wpm jump
+// from an account that has typed 70 for months, or a run whose per-key
+// timing belongs to different hands, looked like any other clean run.
+//
+// The history layer closes that gap without widening the judge. It reads the
+// account's own baseline, compares the run against it with the open detectors
+// in policy.HistoryFlags, and hands the judge whatever they raise as ordinary
+// flags beside the core's. The judge weighs them like any other flag, or —
+// having never heard of them — reports them unknown and weighs them at
+// nothing. Either way the Judge interface, and the policytest contract every
+// judge is held to, are exactly what they were.
+
+// History is what a HistorySource knows about a run's player, and the run's
+// own share of the comparison that needs the player's keyboard to express.
+type History struct {
+	Baseline policy.Baseline
+	// Rhythm is the judged run's per-key timing, on the key ids the baseline's
+	// profile uses. Nil when the source has no keyboard profile.
+	Rhythm policy.Rhythm
+}
+
+// HistorySource reads a run's player history. Declared here at the consumer;
+// internal/replay/pgstore implements it.
+//
+// observations are the run's own, from the replay that just ran: the source
+// folds them onto physical keys for the run's rhythm, and uses them to take
+// the run's contribution back out of the profile when a re-judgement finds it
+// already counted there.
+type HistorySource interface {
+	History(ctx context.Context, runID uuid.UUID, observations []CharObservation) (History, error)
+}
+
+// historyDoc is the audit trail of one history comparison: the baseline the
+// run was measured against, and the flags it raised. Kept apart from the
+// core's flags so "what the log says" and "what the account's past says" are
+// never confused in review.
+type historyDoc struct {
+	Runs     int     `json:"runs"`
+	WPMMean  float64 `json:"wpmMean"`
+	WPMMax   float64 `json:"wpmMax"`
+	Judged   int     `json:"judged"`
+	Reviewed int     `json:"reviewed"`
+	// ProfileKeys is how many keys the account's keyboard profile holds; zero
+	// means the rhythm detector had nothing to compare against.
+	ProfileKeys int    `json:"profileKeys"`
+	Flags       []Flag `json:"flags"`
+}
+
+// sampleOf builds the run's side of the comparison from the server's own
+// metrics. A missing or unreadable metric reads as zero, which no detector
+// mistakes for a jump.
+func sampleOf(metrics json.RawMessage, rhythm policy.Rhythm) policy.Sample {
+	var m serverMetricsDoc
+	if len(metrics) > 0 {
+		_ = json.Unmarshal(metrics, &m)
+	}
+	return policy.Sample{WPM: m.WPM, Accuracy: m.Accuracy, Rhythm: rhythm}
+}
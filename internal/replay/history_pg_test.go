@@ -0,0 +1,58 @@
+package replay_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay"
+	replaypg "github.com/typemore/typemore-server/internal/replay/pgstore"
+)
+
+// The baseline is the account's judged past and nothing else: runs created
+// after the subject, other accounts' runs, and the subject itself are not in
+// it, and a flagged run sits in the review count but never in the spreads.
+func TestPlayerBaselineIsTheAccountsOwnPast(t *testing.T) {
+	pool := newPool(t)
+	ctx := context.Background()
+	user := seedUser(t, pool)
+	clean := loadVector(t, "words-clean")
+
+	var past []uuid.UUID
+	for range 4 {
+		past = append(past, insertPending(t, pool, user, clean))
+	}
+	insertPending(t, pool, seedUser(t, pool), clean)
+
+	core, err := replay.NewCore(replay.DefaultReplayTimeout)
+	require.NoError(t, err)
+	n, err := newTestWorker(t, replaypg.New(pool, nil), replay.WorkerConfig{BatchSize: 50}).
+		RunBatch(ctx, core, discardLogger())
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	// One of the four went to review: it counts as reviewed, not as speed.
+	_, err = pool.Exec(ctx, `
+		UPDATE run_verdicts SET validation = jsonb_set(validation, '{reason}', '"suspicion_threshold"')
+		WHERE run_id = $1`, past[0])
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `UPDATE runs SET status = 'flagged' WHERE id = $1`, past[0])
+	require.NoError(t, err)
+
+	subject := insertPending(t, pool, user, clean)
+	insertPending(t, pool, user, clean) // after the subject: not its past
+
+	h, err := replaypg.NewHistory(pool, nil).History(ctx, subject, nil)
+	require.NoError(t, err)
+	b := h.Baseline
+	assert.Equal(t, 3, b.Runs, "accepted, same account, created before the subject")
+	assert.Equal(t, 4, b.Judged)
+	assert.Equal(t, 1, b.Reviewed)
+	assert.Positive(t, b.WPM.Mean)
+	assert.Equal(t, b.WPM.Mean, b.WPM.Max, "three replays of one log type at one speed")
+	assert.Zero(t, b.WPM.StdDev)
+	assert.Nil(t, b.Rhythm, "no rhythm reader, no profile")
+}
@@ -0,0 +1,129 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/replay/policy/policytest"
+)
+
+// fakeHistory serves one canned history and counts how often it was asked.
+type fakeHistory struct {
+	h     History
+	err   error
+	reads int
+}
+
+func (f *fakeHistory) History(context.Context, uuid.UUID, []CharObservation) (History, error) {
+	f.reads++
+	return f.h, f.err
+}
+
+// replayedClean is the clean golden vector, replayed once, with the wpm the
+// server recomputed for it.
+func replayedClean(t *testing.T) (PendingRun, Result, float64) {
+	t.Helper()
+	_, reg := sharedDicts(t)
+	run := firstVector(t, "words-clean").pendingRun(t)
+	res, err := ReplayRun(context.Background(), mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), run, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, verdictValid, res.Verdict)
+
+	var m serverMetricsDoc
+	require.NoError(t, json.Unmarshal(res.Metrics, &m))
+	require.Positive(t, m.WPM)
+	return run, res, m.WPM
+}
+
+// slowerPast is a settled account whose best is 30 wpm under the run and whose
+// mean is 50 under it: a maximal jump.
+func slowerPast(wpm float64) History {
+	return History{Baseline: policy.Baseline{
+		Runs:   40,
+		WPM:    policy.Spread{Mean: wpm - 50, StdDev: 4, Max: wpm - 30},
+		Judged: 40,
+	}}
+}
+
+// The layer's whole contract with the judge: its flags arrive as ordinary
+// flags, beside the core's, and can move a clean run to review — while the
+// audit keeps them apart, so the core's report is still only the core's.
+func TestHistoryFlagsReachTheJudgeAndTheAudit(t *testing.T) {
+	run, res, wpm := replayedClean(t)
+	src := &fakeHistory{h: slowerPast(wpm)}
+
+	without := testDecider(t, policytest.NewFakeWithThreshold(0.3)).Resolve(context.Background(), run, res, nil)
+	require.Equal(t, StatusAccepted, without.Status)
+
+	got := testDecider(t, policytest.NewFakeWithThreshold(0.3)).WithHistory(src).Resolve(context.Background(), run, res, nil)
+	assert.Equal(t, 1, src.reads)
+	assert.Equal(t, StatusFlagged, got.Status)
+
+	doc := audit(t, got)
+	assert.Equal(t, ReasonSuspicionThreshold, doc.Reason)
+	assert.Equal(t, flagCodes(res.Flags), flagCodes(doc.Flags), "the core's flags are recorded as the core raised them")
+	require.NotNil(t, doc.History)
+	assert.Equal(t, 40, doc.History.Runs)
+	assert.Equal(t, []string{policy.FlagHistoryWPMJump}, flagCodes(doc.History.Flags))
+	require.NotNil(t, doc.Policy)
+	assert.Greater(t, doc.Policy.Suspicion, audit(t, without).Policy.Suspicion)
+}
+
+// An account with no past to speak of is judged exactly as it would be with
+// the layer off — the block is still written, so review can see the layer ran.
+func TestAThinHistoryChangesNothing(t *testing.T) {
+	run, res, wpm := replayedClean(t)
+	thin := slowerPast(wpm)
+	thin.Baseline.Runs, thin.Baseline.Judged = policy.MinBaselineRuns-1, policy.MinBaselineRuns-1
+
+	off := testDecider(t, policytest.NewFake()).Resolve(context.Background(), run, res, nil)
+	on := testDecider(t, policytest.NewFake()).WithHistory(&fakeHistory{h: thin}).Resolve(context.Background(), run, res, nil)
+
+	assert.Equal(t, off.Status, on.Status)
+	assert.Equal(t, audit(t, off).Policy.Suspicion, audit(t, on).Policy.Suspicion)
+	require.NotNil(t, audit(t, on).History)
+	assert.Empty(t, audit(t, on).History.Flags)
+	assert.Nil(t, audit(t, off).History, "with the layer off there is no block at all")
+}
+
+// Decide stays pure: it never reads a history, even on a decider that has one.
+// Neither does a capture seat, a Noop judge (which would weigh the flags at
+// nothing), or a replay that failed or refused the log.
+func TestHistoryIsOnlyReadWhenItCanMatter(t *testing.T) {
+	run, res, _ := replayedClean(t)
+	ctx := context.Background()
+
+	src := &fakeHistory{}
+	d := testDecider(t, policytest.NewFake()).WithHistory(src)
+	d.Decide(run, res, nil)
+	d.ForCapture().Resolve(ctx, run, res, nil)
+	testDecider(t, policy.Noop{}).WithHistory(src).Resolve(ctx, run, res, nil)
+	d.Resolve(ctx, run, Result{}, ErrReplayTimeout)
+	d.Resolve(ctx, run, Result{Verdict: verdictInvalid, Reason: "non_monotonic_time"}, nil)
+	assert.Zero(t, src.reads)
+
+	d.ForRejudgement().Resolve(ctx, run, res, nil)
+	assert.Equal(t, 1, src.reads, "a re-judgement measures the run against the same past")
+}
+
+// A baseline that cannot be read is an infrastructure failure, not evidence:
+// the run takes the replay_error row — retried, never judged without it.
+func TestAnUnreadableHistoryIsRetried(t *testing.T) {
+	run, res, _ := replayedClean(t)
+	d := testDecider(t, policytest.NewFake()).
+		WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second}).
+		WithHistory(&fakeHistory{err: errors.New("connection reset")})
+
+	got := d.Resolve(context.Background(), run, res, nil)
+	assert.Equal(t, StatusPending, got.Status)
+	assert.EqualValues(t, 1, got.Attempts)
+	assert.Contains(t, got.LastError, "player history")
+}
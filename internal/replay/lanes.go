@@ -0,0 +1,87 @@
+package replay
+
+// Priority lanes (docs/REPLAY.md, "Priority lanes"; migration 00034).
+//
+// Every pending run sits in one lane, filed at ingestion by run_lane() from
+// what the run claims about itself: a run that would move a board if accepted,
+// a run played for a scheduled event, a ranked run that would not move a
+// board, and everything that ranks nowhere. The claim
+// used to be one created_at scan, so a burst of throwaway runs queued a
+// possible record behind all of them; lanes let the record go first.
+//
+// Strict priority would starve the bottom lane behind any sustained burst in
+// the top one, so the worker does not claim strictly. Each batch has a LEAD
+// lane chosen by a weighted round-robin, claims from it first, and fills what
+// is left of the batch from the other lanes in priority order. A lane with
+// work therefore leads at least its share of batches however busy the others
+// are, and a lead lane with nothing due costs one empty index probe before the
+// batch moves on — an idle queue never waits on the schedule.
+
+// Lane values, mirroring the runs.lane CHECK constraint, in priority order.
+const (
+	// LaneContender is a ranked run whose client score beats the player's own
+	// slot on its board, or who holds no slot there yet.
+	LaneContender = "contender"
+	// LaneEvent is a run played for a scheduled event — the daily challenge, a
+	// tournament — that did not make contender. An event's board is one the
+	// player enters once, so the run will place on it whatever it scores, but
+	// the board is read at the event's close rather than live. 00050 added the
+	// lane; an event kind files into it with its own branch in run_lane(), the
+	// daily challenge's since 00051.
+	LaneEvent = "event"
+	// LaneRanked is a ranked run that would not move its board.
+	LaneRanked = "ranked"
+	// LaneUnranked is every run no board reads: custom text, unranked sizes,
+	// adopted seeds.
+	LaneUnranked = "unranked"
+)
+
+// Lanes lists every lane in priority order. It is the claim order of a batch
+// with no lead lane, and the fill order behind one.
+var Lanes = []string{LaneContender, LaneEvent, LaneRanked, LaneUnranked}
+
+// laneWeights is each lane's share of lead turns, in Lanes order. 4:3:2:1 means
+// that under a backlog in every lane, an unranked run still leads one batch in
+// ten.
+var laneWeights = []int{4, 3, 2, 1}
+
+// laneCycle is one period of the schedule, interleaved by smooth weighted
+// round-robin so the contender lane's turns are spread out rather than bunched:
+// contender, event, ranked, contender, event, unranked, contender, ranked,
+// event, contender.
+var laneCycle = smoothRoundRobin(Lanes, laneWeights)
+
+func smoothRoundRobin(lanes []string, weights []int) []string {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	current := make([]int, len(weights))
+	cycle := make([]string, 0, total)
+	for range total {
+		best := 0
+		for i, w := range weights {
+			current[i] += w
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		cycle = append(cycle, lanes[best])
+	}
+	return cycle
+}
+
+// laneOrder is the claim order for turn n of the schedule: that turn's lead
+// lane, then the others in priority order.
+func laneOrder(n uint64) []string {
+	lead := laneCycle[n%uint64(len(laneCycle))]
+	order := make([]string, 0, len(Lanes))
+	order = append(order, lead)
+	for _, l := range Lanes {
+		if l != lead {
+			order = append(order, l)
+		}
+	}
+	return order
+}
@@ -0,0 +1,73 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// One period of the schedule leads with each lane in proportion to its weight,
+// and every turn still claims from all four.
+func TestLaneScheduleGivesEveryLaneItsShare(t *testing.T) {
+	require.Equal(t, []string{
+		LaneContender, LaneEvent, LaneRanked, LaneContender, LaneEvent,
+		LaneUnranked, LaneContender, LaneRanked, LaneEvent, LaneContender,
+	}, laneCycle)
+
+	leads := map[string]int{}
+	for n := range uint64(10 * 100) {
+		order := laneOrder(n)
+		assert.ElementsMatch(t, Lanes, order, "turn %d", n)
+		leads[order[0]]++
+	}
+	assert.Equal(t, map[string]int{LaneContender: 400, LaneEvent: 300, LaneRanked: 200, LaneUnranked: 100}, leads)
+}
+
+// The lead lane is claimed first and the rest of the batch fills in priority
+// order, so a backlog of contenders holds the unranked lane to its share
+// rather than to nothing.
+func TestABacklogInOneLaneDoesNotStarveTheOthers(t *testing.T) {
+	var runs []PendingRun
+	for range 70 {
+		runs = append(runs, PendingRun{Lane: LaneContender})
+	}
+	for range 10 {
+		runs = append(runs, PendingRun{Lane: LaneUnranked})
+	}
+	q := newFakeQueue(runs...)
+
+	var claimed []string
+	for n := range uint64(20) {
+		_, err := q.ProcessBatch(context.Background(), 1, laneOrder(n),
+			func(_ context.Context, run PendingRun) Decision {
+				claimed = append(claimed, run.Lane)
+				return Decision{}
+			})
+		require.NoError(t, err)
+	}
+	unranked := 0
+	for _, l := range claimed {
+		if l == LaneUnranked {
+			unranked++
+		}
+	}
+	assert.Equal(t, 2, unranked, "one batch in ten, with contenders still queued")
+}
+
+// The worker walks the schedule one batch at a time, across every goroutine.
+func TestTheWorkerHandsItsQueueTheSchedule(t *testing.T) {
+	q := newFakeQueue()
+	w := NewWorker(q, nil, nil, WorkerConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for range 10 {
+		_, err := w.RunBatch(context.Background(), nil, w.log)
+		require.NoError(t, err)
+	}
+	require.Len(t, q.orders, 10)
+	for n, order := range q.orders {
+		assert.Equal(t, laneOrder(uint64(n)), order)
+	}
+}
@@ -0,0 +1,441 @@
+package replay
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+)
+
+// Match capture judgement (docs/MATCH.md §6, docs/REPLAY.md "Match captures").
+//
+// A multiplayer seat is judged by the same core, the same decision table and
+// the same judge as a solo run. What differs is only where the inputs come
+// from: a solo run SUBMITS its setup and its log, while a seat's setup is
+// assembled here from the match's frozen settings and that seat's frozen
+// freemods, and its log is the relayed stream the server itself captured.
+
+const (
+	// DefaultMatchBatchSize is one match per transaction. A match is up to a
+	// roomful of replays, not one, so the claim is sized in matches and kept
+	// small for the same reason the run batch is: the row locks are held for
+	// the whole judgement.
+	DefaultMatchBatchSize = 1
+
+	// matchScoreVersion is the formula every seat is scored under. A seat has
+	// no submitted scoreVersion to honour, so it gets the current one; placement
+	// only ever compares seats of one match, which are all scored alike.
+	matchScoreVersion = scoreVersionV3
+	// matchLogVersion is the event-log format of every captured batch. The relay
+	// checks event_batch.version is log-v1 before it stamps a batch, and the
+	// capture does not store it again per batch (docs/PROTOCOL.md §3).
+	matchLogVersion = 1
+	// matchMaxExtraChars is the overflow allowance the match client plays with —
+	// the same fixed value the solo client sends in every seeded setup.
+	matchMaxExtraChars = 20
+	// seatFinished is match_runs.final_status for a seat that reached the end.
+	// Only those are placed: a dnf or a leaver has a score for what they typed,
+	// and no place in a race they did not complete.
+	seatFinished = "finished"
+)
+
+// PendingMatch is one claimed match and every one of its seats. The settings
+// and freemods are the frozen countdown snapshot exactly as persisted.
+type PendingMatch struct {
+	ID       string
+	Settings json.RawMessage
+	Seed     int64
+	DictHash string
+	GoAt     time.Time
+	EndedAt  time.Time
+	Seats    []MatchSeat
+}
+
+// MatchSeat is one participant's capture.
+type MatchSeat struct {
+	ID       uuid.UUID
+	PlayerID string
+	// Freemods is this seat's frozen {difficulty, minWpm, nospace}.
+	Freemods json.RawMessage
+	// Log is gzip(JSON([]CapturedBatch)) as the relay persisted it.
+	Log         []byte
+	FinalStatus string
+}
+
+// SeatVerdict is the worker's judgement of one seat: the same Decision a solo
+// run gets, plus the two facts only a match has.
+type SeatVerdict struct {
+	SeatID uuid.UUID
+	Decision
+	// Score is ServerScore.total, nil when the capture produced no numbers.
+	Score *int64
+	// Placement is the seat's 1-based place among the match's accepted
+	// finishers, nil for every other seat.
+	Placement *int32
+}
+
+// MatchQueue is the match half of the worker's persistence contract, declared
+// here at the consumer like Queue and with the same unit-of-work shape: claim,
+// judge and commit in one transaction, so a crash mid-match leaves the whole
+// match unvalidated rather than half of it ranked.
+type MatchQueue interface {
+	// ProcessMatchBatch claims up to limit unvalidated matches (oldest first),
+	// calls judge for each, writes every seat's verdict, marks the match
+	// validated, and commits. It returns the number of matches claimed.
+	ProcessMatchBatch(ctx context.Context, limit int32, judge func(context.Context, PendingMatch) []SeatVerdict) (int, error)
+}
+
+// matchSettings is the sliver of protocol.Settings a seat's setup is built
+// from. Declared here rather than imported: the frozen snapshot is opaque jsonb
+// to every layer but this one, and this one needs a handful of its fields.
+type matchSettings struct {
+	Mode       string `json:"mode"`
+	DurationMs int64  `json:"durationMs"`
+	WordCount  int64  `json:"wordCount"`
+	TextMods   struct {
+		Punctuation bool `json:"punctuation"`
+		Numbers     bool `json:"numbers"`
+		RandomCase  bool `json:"randomCase"`
+		Reverse     bool `json:"reverse"`
+		Lazy        bool `json:"lazy"`
+	} `json:"textMods"`
+	TextSource struct {
+		Kind    string `json:"kind"`
+		QuoteID string `json:"quoteId"`
+	} `json:"textSource"`
+}
+
+// seatFreemods is protocol.Freemods, read the same way.
+type seatFreemods struct {
+	Difficulty string `json:"difficulty"`
+	MinWpm     int    `json:"minWpm"`
+	Nospace    bool   `json:"nospace"`
+}
+
+// capturedBatch is ws.CapturedBatch as it reads back out of the capture. The
+// events stay opaque: they are spliced into the log the core parses, and only
+// their t is read again, to hold it against RecvServerMs.
+type capturedBatch struct {
+	BatchSeq     int               `json:"batchSeq"`
+	RecvServerMs int64             `json:"recvServerMs"`
+	Events       []json.RawMessage `json:"events"`
+}
+
+// wallClockDoc is the audit trail of one seat's wall-clock reading: what was
+// measured and the flags it raised. Kept out of the core's flags the way the
+// history block is — the log did not say these things, the server's clock did.
+type wallClockDoc struct {
+	Batches      int     `json:"batches"`
+	Events       int     `json:"events"`
+	MedianLagMs  float64 `json:"medianLagMs"`
+	Ahead        int     `json:"ahead"`
+	CompressedMs float64 `json:"compressedMs"`
+	Flags        []Flag  `json:"flags"`
+}
+
+func readWallClock(c policy.WallClock) *wallClockDoc {
+	r := policy.ReadWallClock(c)
+	doc := &wallClockDoc{
+		Batches:      r.Batches,
+		Events:       r.Events,
+		MedianLagMs:  r.MedianLagMs,
+		Ahead:        r.Ahead,
+		CompressedMs: r.CompressedMs,
+		Flags:        policy.WallClockFlags(r),
+	}
+	if doc.Flags == nil {
+		doc.Flags = []Flag{}
+	}
+	return doc
+}
+
+// JudgeMatch replays every seat of one match and places the accepted
+// finishers. Like Judge it never returns an error: a seat that cannot be
+// replayed is a flagged seat, and a match whose text cannot be produced is a
+// match of flagged seats — never a match that wedges the queue.
+//
+// The freemod multipliers of docs/MATCH.md §3 are applied by the core, not
+// here: a seat's difficulty, minWpm and nospace go into its setup's config,
+// and the score fold multiplies by exactly the mods that setup enables. The
+// room's text mods ride in the same setup, because the words cannot be
+// regenerated without them; they multiply every seat of the match by the same
+// factor, so they move nobody's placement.
+func JudgeMatch(ctx context.Context, core Engine, reg *Registry, quotes QuoteResolver, decider Decider, m PendingMatch) []SeatVerdict {
+	decider = decider.ForCapture()
+	out := make([]SeatVerdict, len(m.Seats))
+
+	var settings matchSettings
+	textErr := json.Unmarshal(m.Settings, &settings)
+	if textErr != nil {
+		textErr = fmt.Errorf("replay: match settings are unreadable: %w", textErr)
+	}
+
+	// The text is resolved ONCE per match: every seat typed the same words, and
+	// resolving per seat could hand two seats two answers under a concurrent
+	// quote re-import.
+	var in Input
+	if textErr == nil {
+		in, textErr = matchText(ctx, reg, quotes, m, settings)
+	}
+
+	for i, seat := range m.Seats {
+		run := PendingRun{
+			ID:           seat.ID,
+			Seed:         m.Seed,
+			DictHash:     m.DictHash,
+			ScoreVersion: matchScoreVersion,
+			CreatedAt:    m.GoAt,
+		}
+		out[i] = SeatVerdict{SeatID: seat.ID}
+		if textErr != nil {
+			out[i].Decision = decider.Decide(run, Result{}, textErr)
+			continue
+		}
+		out[i].Decision = judgeSeat(ctx, core, decider, run, in, m, settings, seat)
+		out[i].Score = scoreTotal(out[i].ServerScore)
+	}
+	placeSeats(m.Seats, out)
+	return out
+}
+
+// judgeSeat assembles one seat's setup and log and runs them through the core.
+func judgeSeat(
+	ctx context.Context, core Engine, decider Decider, run PendingRun,
+	in Input, m PendingMatch, settings matchSettings, seat MatchSeat,
+) Decision {
+	var err error
+	if run.Setup, err = seatSetup(m, settings, seat.Freemods, in.Quote); err != nil {
+		return decider.Decide(run, Result{}, err)
+	}
+	in.Setup = run.Setup
+	batches, err := readCapture(seat.Log)
+	if err != nil {
+		return decider.Decide(run, Result{}, err)
+	}
+	in.Log = seatLog(batches)
+	in.Seed = m.Seed
+	in.DictHash = m.DictHash
+	in.ScoreVersion = matchScoreVersion
+	// Disarmed, whatever the canary epoch says. The epoch dates the SOLO client
+	// that renders canaries; nothing records that the match client draws them,
+	// and a detector armed against a client that never drew one reads
+	// coincidence as evidence (CanariesArmedAt).
+	in.CanariesArmed = false
+
+	res, err := core.Replay(ctx, in)
+	return decider.DecideCapture(run, res, err, seatClock(m.GoAt, batches))
+}
+
+// matchText resolves the words every seat of a match typed: the dictionary
+// body for a seeded match, the registry's bytes for a quote match.
+//
+// Unlike a quote RUN there is no client quoteHash to check the bytes against —
+// the room carries only the quote id the host drew — so the registry's own
+// text_hash is the digest the core checks, and it is written into each seat's
+// setup as the quoteHash that regenerates the words.
+func matchText(ctx context.Context, reg *Registry, quotes QuoteResolver, m PendingMatch, s matchSettings) (Input, error) {
+	if s.TextSource.Kind != TextSourceQuote {
+		body, ok := reg.Body(m.DictHash)
+		if !ok {
+			return Input{}, ErrUnknownDict
+		}
+		return Input{DictBody: body}, nil
+	}
+	id, err := uuid.Parse(s.TextSource.QuoteID)
+	if err != nil {
+		return Input{}, fmt.Errorf("%w: quoteId %q is not a uuid", ErrUnknownQuote, s.TextSource.QuoteID)
+	}
+	if quotes == nil {
+		return Input{}, fmt.Errorf("%w: this worker has no quote registry wired in", ErrUnknownQuote)
+	}
+	text, hash, ok, err := quotes.ResolveQuote(ctx, id)
+	switch {
+	case err != nil:
+		return Input{}, fmt.Errorf("replay: resolve quote %s: %w", id, err)
+	case !ok:
+		return Input{}, fmt.Errorf("%w: no quote %s in the registry", ErrUnknownQuote, id)
+	}
+	return Input{Quote: &QuoteText{Text: text, Hash: hash}}, nil
+}
+
+// seatSetup builds the {config, generation, declaration} snapshot a solo client
+// would have submitted for this seat, from the frozen room settings and the
+// seat's frozen freemods.
+//
+//   - config carries the seat's freemods — the per-player, score-multiplying
+//     half — and startPolicy "go": a race starts on the shared t=0 for
+//     everyone, not on each player's first keystroke.
+//   - generation carries the room's text mods and source, identical for every
+//     seat, which is what makes it one race on one map.
+//   - declaration is all false. Personal visual mods never travel on the wire
+//     and are never scored (docs/MATCH.md §2.3); the server has nothing to
+//     declare on a player's behalf.
+func seatSetup(m PendingMatch, s matchSettings, freemods json.RawMessage, quote *QuoteText) (json.RawMessage, error) {
+	var fm seatFreemods
+	if err := json.Unmarshal(freemods, &fm); err != nil {
+		return nil, fmt.Errorf("replay: seat freemods are unreadable: %w", err)
+	}
+	if fm.Difficulty == "" {
+		fm.Difficulty = "normal"
+	}
+
+	// durationMs is the clock for a time match. A counted match has no clock —
+	// it ends on the text — so the field carries the span the match actually
+	// lasted, which the core reads for nothing but keeps the config well-formed.
+	durationMs := s.DurationMs
+	length := s.WordCount
+	switch s.Mode {
+	case "time":
+		// The solo client sizes a timed text in seconds; the core turns that
+		// into a word budget. Same rule, same words.
+		length = (s.DurationMs + 999) / 1000
+	default:
+		durationMs = max(1, m.EndedAt.Sub(m.GoAt).Milliseconds())
+	}
+	if durationMs <= 0 || length <= 0 {
+		return nil, fmt.Errorf("replay: match settings carry no usable dimension for mode %q", s.Mode)
+	}
+
+	generation := map[string]any{
+		"mode":        s.Mode,
+		"length":      length,
+		"punctuation": s.TextMods.Punctuation,
+		"numbers":     s.TextMods.Numbers,
+		"randomCase":  s.TextMods.RandomCase,
+		"reverse":     s.TextMods.Reverse,
+		"lazy":        s.TextMods.Lazy,
+	}
+	if quote != nil {
+		generation["textSource"] = map[string]any{
+			"kind": TextSourceQuote, "quoteId": s.TextSource.QuoteID, "quoteHash": quote.Hash,
+		}
+	}
+	raw, err := json.Marshal(map[string]any{
+		"config": map[string]any{
+			"mode":          s.Mode,
+			"durationMs":    durationMs,
+			"maxExtraChars": matchMaxExtraChars,
+			"difficulty":    fm.Difficulty,
+			"nospace":       fm.Nospace,
+			"minWpm":        fm.MinWpm,
+			"startPolicy":   "go",
+		},
+		"generation":  generation,
+		"declaration": map[string]any{"blind": false, "fading": false, "flashlight": false},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("replay: encode seat setup: %w", err)
+	}
+	return raw, nil
+}
+
+// readCapture unpacks a seat's capture into its batches, in batchSeq order.
+//
+// The relay appends batches in arrival order and refuses a gap or a repeat, so
+// the stored order already IS batchSeq order; the stable sort is cheap
+// insurance that a capture persisted by some future path is judged on the
+// order the client sent, not the order it landed.
+func readCapture(gz []byte) ([]capturedBatch, error) {
+	raw, err := gunzip(gz)
+	if err != nil {
+		return nil, fmt.Errorf("replay: decompress capture: %w", err)
+	}
+	var batches []capturedBatch
+	if err := json.Unmarshal(raw, &batches); err != nil {
+		return nil, fmt.Errorf("replay: capture is not a batch stream: %w", err)
+	}
+	slices.SortStableFunc(batches, func(a, b capturedBatch) int { return cmp.Compare(a.BatchSeq, b.BatchSeq) })
+	return batches, nil
+}
+
+// seatLog turns a seat's batches into the {version, events} log the core
+// validates: every batch's events as one stream.
+func seatLog(batches []capturedBatch) json.RawMessage {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `{"version":%d,"events":[`, matchLogVersion)
+	first := true
+	for _, b := range batches {
+		for _, e := range b.Events {
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			buf.Write(e)
+		}
+	}
+	buf.WriteString(`]}`)
+	return buf.Bytes()
+}
+
+// seatClock pairs each batch's arrival stamp with the log times it carried,
+// for the wall-clock detectors. An event whose t does not read is left out:
+// the core has already refused that log, and a refused log never reaches the
+// judge.
+func seatClock(goAt time.Time, batches []capturedBatch) policy.WallClock {
+	c := policy.WallClock{GoAtMs: goAt.UnixMilli(), Arrivals: make([]policy.Arrival, 0, len(batches))}
+	for _, b := range batches {
+		a := policy.Arrival{RecvMs: b.RecvServerMs, EventsMs: make([]float64, 0, len(b.Events))}
+		for _, e := range b.Events {
+			var ev struct {
+				T *float64 `json:"t"`
+			}
+			if json.Unmarshal(e, &ev) == nil && ev.T != nil {
+				a.EventsMs = append(a.EventsMs, *ev.T)
+			}
+		}
+		c.Arrivals = append(c.Arrivals, a)
+	}
+	return c
+}
+
+// scoreTotal lifts ScoreResult.total out of the core's score JSON. Nil when
+// there is no score, or when it is not a whole non-negative number — the
+// column's CHECK would refuse anything else, and the core's Math.round never
+// produces it.
+func scoreTotal(score json.RawMessage) *int64 {
+	if len(score) == 0 {
+		return nil
+	}
+	var s serverScoreDoc
+	if err := json.Unmarshal(score, &s); err != nil {
+		return nil
+	}
+	total := int64(s.Total)
+	if float64(total) != s.Total || total < 0 {
+		return nil
+	}
+	return &total
+}
+
+// placeSeats assigns competition-ranked places ("1, 1, 3") to the seats that
+// earned one: accepted by the worker, finished the race, and carrying a score.
+// Higher score places higher. Every other seat keeps a nil placement, which is
+// also what the schema holds it to: a place requires a score.
+func placeSeats(seats []MatchSeat, verdicts []SeatVerdict) {
+	var placed []int
+	for i := range verdicts {
+		if verdicts[i].Status == StatusAccepted && seats[i].FinalStatus == seatFinished && verdicts[i].Score != nil {
+			placed = append(placed, i)
+		}
+	}
+	slices.SortStableFunc(placed, func(a, b int) int {
+		return cmp.Compare(*verdicts[b].Score, *verdicts[a].Score)
+	})
+	for rank, i := range placed {
+		place := int32(rank + 1)
+		if rank > 0 {
+			prev := placed[rank-1]
+			if *verdicts[prev].Score == *verdicts[i].Score {
+				place = *verdicts[prev].Placement
+			}
+		}
+		verdicts[i].Placement = &place
+	}
+}
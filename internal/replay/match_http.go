@@ -0,0 +1,151 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/typemore/typemore-server/internal/platform/httpx"
+)
+
+// ErrMatchNotFound is returned by a MatchResultsReader for an unknown match id.
+var ErrMatchNotFound = errors.New("replay: match not found")
+
+// MatchResults is one match's published results: the header and every seat
+// with whatever the worker has decided about it so far.
+type MatchResults struct {
+	ID       string
+	Name     string
+	Settings json.RawMessage
+	GoAt     time.Time
+	EndedAt  time.Time
+	// ValidatedAt is nil until the worker has judged the match.
+	ValidatedAt *time.Time
+	Seats       []MatchResultSeat
+}
+
+// MatchResultSeat is one seat of MatchResults.
+type MatchResultSeat struct {
+	PlayerID    string
+	Nick        string
+	FinalStatus string
+	Status      string
+	Score       *int64
+	Placement   *int32
+	WPM         *float64
+	Accuracy    *float64
+}
+
+// MatchResultsReader reads published match results. Declared at the consumer;
+// internal/replay/pgstore implements it.
+type MatchResultsReader interface {
+	MatchResults(ctx context.Context, id string) (MatchResults, error)
+}
+
+// MatchResultsService serves GET /api/v1/matches/{id}/results.
+//
+// Public and anonymous, like the lobby it follows on from: the people who want
+// a scrim's results are the people who played it and the people who watched,
+// and neither group should need an account to read a scoreboard whose every
+// name was already on screen in the room.
+type MatchResultsService struct {
+	reader MatchResultsReader
+	log    *slog.Logger
+}
+
+// NewMatchResultsService wires the results surface.
+func NewMatchResultsService(reader MatchResultsReader, log *slog.Logger) *MatchResultsService {
+	return &MatchResultsService{reader: reader, log: log}
+}
+
+// Routes returns the results router, mounted at /api/v1/matches.
+func (s *MatchResultsService) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{id}/results", s.handleResults)
+	return r
+}
+
+// matchResultsView is the wire shape. `verified` is the one field a client has
+// to read before trusting anything else on the page: false means the worker
+// has not reached this match yet and every seat is still pending.
+type matchResultsView struct {
+	MatchID     string          `json:"matchId"`
+	Name        string          `json:"name"`
+	Settings    json.RawMessage `json:"settings"`
+	GoAt        time.Time       `json:"goAt"`
+	EndedAt     time.Time       `json:"endedAt"`
+	Verified    bool            `json:"verified"`
+	ValidatedAt *time.Time      `json:"validatedAt,omitempty"`
+	Seats       []seatView      `json:"seats"`
+}
+
+// seatView is one seat. It carries the nick the room showed and the
+// peer-visible player id — nothing that identifies the account behind a seat,
+// which the room never showed either. Placement and the numbers are null until
+// the seat has earned them.
+type seatView struct {
+	PlayerID    string   `json:"playerId"`
+	Nick        string   `json:"nick"`
+	FinalStatus string   `json:"finalStatus"`
+	Status      string   `json:"status"`
+	Placement   *int32   `json:"placement"`
+	Score       *int64   `json:"score"`
+	WPM         *float64 `json:"wpm"`
+	Accuracy    *float64 `json:"accuracy"`
+}
+
+// matchResultsError is the sibling domains' error shape.
+type matchResultsError struct {
+	Code    string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (s *MatchResultsService) handleResults(w http.ResponseWriter, r *http.Request) {
+	res, err := s.reader.MatchResults(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, ErrMatchNotFound) {
+		s.writeJSON(w, http.StatusNotFound, matchResultsError{Code: "not_found", Message: "no such match"})
+		return
+	}
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "match results request failed", "err", err, "path", r.URL.Path)
+		s.writeJSON(w, http.StatusInternalServerError, matchResultsError{
+			Code: "internal", Message: "an unexpected error occurred",
+		})
+		return
+	}
+
+	view := matchResultsView{
+		MatchID:     res.ID,
+		Name:        res.Name,
+		Settings:    res.Settings,
+		GoAt:        res.GoAt,
+		EndedAt:     res.EndedAt,
+		Verified:    res.ValidatedAt != nil,
+		ValidatedAt: res.ValidatedAt,
+		Seats:       make([]seatView, len(res.Seats)),
+	}
+	for i, seat := range res.Seats {
+		view.Seats[i] = seatView{
+			PlayerID:    seat.PlayerID,
+			Nick:        seat.Nick,
+			FinalStatus: seat.FinalStatus,
+			Status:      seat.Status,
+			Placement:   seat.Placement,
+			Score:       seat.Score,
+			WPM:         seat.WPM,
+			Accuracy:    seat.Accuracy,
+		}
+	}
+	s.writeJSON(w, http.StatusOK, view)
+}
+
+func (s *MatchResultsService) writeJSON(w http.ResponseWriter, status int, v any) {
+	if err := httpx.WriteJSON(w, status, v); err != nil {
+		s.log.Error("encode response", "err", err)
+	}
+}
@@ -0,0 +1,131 @@
+package replay_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay"
+	replaypg "github.com/typemore/typemore-server/internal/replay/pgstore"
+)
+
+// insertMatch persists a match the way the relay does — header, then one row
+// per seat — with the given final statuses, and returns the seat ids in order.
+func insertMatch(t *testing.T, pool *pgxpool.Pool, id string, statuses ...string) []uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	goAt := time.Now().Add(-time.Minute).UTC()
+	_, err := pool.Exec(ctx, `
+		INSERT INTO matches (id, room_code, name, settings, freemods, seed, dict_hash, lang, go_at, ended_at)
+		VALUES ($1, 'ABCD', 'scrim', '{"mode":"words","wordCount":10}', '[]', 7, 'deadbeef', 'english', $2, $3)`,
+		id, goAt, goAt.Add(30*time.Second))
+	require.NoError(t, err)
+
+	ids := make([]uuid.UUID, len(statuses))
+	for i, st := range statuses {
+		err := pool.QueryRow(ctx, `
+			INSERT INTO match_runs (match_id, player_id, nick, freemods, log, batch_count, final_status)
+			VALUES ($1, $2, $3, '{}', $4, 0, $5) RETURNING id`,
+			id, uuid.NewString(), "p"+string(rune('a'+i)), gzipBytes(t, []byte(`[]`)), st,
+		).Scan(&ids[i])
+		require.NoError(t, err)
+	}
+	return ids
+}
+
+// The queue half of match judgement, against a stub judge: the whole match is
+// claimed as one unit, every seat's verdict and status land with the claim,
+// the match is stamped validated, and the results read returns the lot.
+func TestMatchQueueJudgesAMatchOnceAndPublishesIt(t *testing.T) {
+	pool := newPool(t)
+	_, err := pool.Exec(context.Background(), `TRUNCATE matches CASCADE`)
+	require.NoError(t, err)
+	seats := insertMatch(t, pool, "m-1", "finished", "finished", "dnf")
+
+	q := replaypg.NewMatchQueue(pool)
+	var judged []string
+	judge := func(_ context.Context, m replay.PendingMatch) []replay.SeatVerdict {
+		judged = append(judged, m.ID)
+		require.Len(t, m.Seats, 3)
+		out := make([]replay.SeatVerdict, len(m.Seats))
+		for i, s := range m.Seats {
+			score, place := int64(1000-100*i), int32(i+1)
+			out[i] = replay.SeatVerdict{
+				SeatID: s.ID,
+				Decision: replay.Decision{
+					Status:        replay.StatusAccepted,
+					ServerMetrics: json.RawMessage(`{"wpm":88.5,"accuracy":97.25}`),
+					ServerScore:   json.RawMessage(`{"total":1000}`),
+					Validation:    json.RawMessage(`{"verdict":"valid"}`),
+					BundleSHA:     replay.BundleSHA(),
+				},
+				Score: &score,
+			}
+			if s.FinalStatus == "finished" {
+				out[i].Placement = &place
+			}
+		}
+		return out
+	}
+
+	n, err := q.ProcessMatchBatch(context.Background(), 5, judge)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// Judged once: a validated match is never claimed again.
+	n, err = q.ProcessMatchBatch(context.Background(), 5, judge)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, []string{"m-1"}, judged)
+
+	res, err := q.MatchResults(context.Background(), "m-1")
+	require.NoError(t, err)
+	require.NotNil(t, res.ValidatedAt)
+	require.Len(t, res.Seats, 3)
+	for _, s := range res.Seats {
+		assert.Equal(t, replay.StatusAccepted, s.Status)
+		require.NotNil(t, s.WPM)
+		assert.InDelta(t, 88.5, *s.WPM, 1e-9)
+	}
+	// Placed seats first, in place order; the dnf last and unplaced.
+	require.NotNil(t, res.Seats[0].Placement)
+	assert.EqualValues(t, 1, *res.Seats[0].Placement)
+	assert.Equal(t, "dnf", res.Seats[2].FinalStatus)
+	assert.Nil(t, res.Seats[2].Placement)
+
+	var statuses []string
+	rows, err := pool.Query(context.Background(), `SELECT status FROM match_runs WHERE id = ANY($1)`, seats)
+	require.NoError(t, err)
+	for rows.Next() {
+		var s string
+		require.NoError(t, rows.Scan(&s))
+		statuses = append(statuses, s)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"accepted", "accepted", "accepted"}, statuses)
+}
+
+// An unjudged match is a normal answer, not a missing one; an unknown id is.
+func TestMatchResultsBeforeJudgementAndForUnknownMatches(t *testing.T) {
+	pool := newPool(t)
+	_, err := pool.Exec(context.Background(), `TRUNCATE matches CASCADE`)
+	require.NoError(t, err)
+	insertMatch(t, pool, "m-2", "finished")
+	q := replaypg.NewMatchQueue(pool)
+
+	res, err := q.MatchResults(context.Background(), "m-2")
+	require.NoError(t, err)
+	assert.Nil(t, res.ValidatedAt)
+	require.Len(t, res.Seats, 1)
+	assert.Equal(t, replay.StatusPending, res.Seats[0].Status)
+	assert.Nil(t, res.Seats[0].Score)
+
+	_, err = q.MatchResults(context.Background(), "no-such-match")
+	assert.ErrorIs(t, err, replay.ErrMatchNotFound)
+}
@@ -0,0 +1,325 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/replay/policy/policytest"
+)
+
+// matchRig is one seeded "words" match on a published dictionary: the words
+// every seat was dealt, and a match header to hang seats off.
+type matchRig struct {
+	core  *Core
+	reg   *Registry
+	words []string
+	match PendingMatch
+}
+
+func newMatchRig(t *testing.T) matchRig {
+	t.Helper()
+	core, reg := sharedDicts(t)
+	var entry CatalogueEntry
+	for _, e := range reg.Catalogue() {
+		if e.Lang == "polish" {
+			entry = e
+		}
+	}
+	require.Equal(t, "polish", entry.Lang, "polish is not in the catalogue")
+	body, ok := reg.Body(entry.DictHash)
+	require.True(t, ok)
+
+	goAt := matchGoAt
+	return matchRig{
+		core:  core,
+		reg:   reg,
+		words: generateSanityWords(t, core, body, entry.DictHash),
+		match: PendingMatch{
+			ID:       "m-test",
+			Settings: json.RawMessage(`{"mode":"words","wordCount":8,"lang":"polish","textMods":{},"textSource":{"kind":"seeded"}}`),
+			Seed:     sanitySeed,
+			DictHash: entry.DictHash,
+			GoAt:     goAt,
+			EndedAt:  goAt.Add(30 * time.Second),
+		},
+	}
+}
+
+// matchGoAt is the go instant of every rig's match, on the server's clock.
+var matchGoAt = time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+
+// capture packs a {version, events} log into the relay's persisted form: the
+// events split across batches, stored in the order given, each stamped as
+// arriving 40 ms after its last event.
+func capture(t *testing.T, log json.RawMessage, order ...int) []byte {
+	t.Helper()
+	return captureSkewed(t, log, 0, order...)
+}
+
+// captureSkewed is capture from a client whose clock runs skewMs ahead of the
+// server's: every arrival lands that much before the events claim to.
+func captureSkewed(t *testing.T, log json.RawMessage, skewMs int64, order ...int) []byte {
+	t.Helper()
+	var doc struct {
+		Events []json.RawMessage `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(log, &doc))
+	half := len(doc.Events) / 2
+	parts := [][]json.RawMessage{doc.Events[:half], doc.Events[half:]}
+	if len(order) == 0 {
+		order = []int{0, 1}
+	}
+	batches := make([]map[string]any, 0, len(order))
+	for _, i := range order {
+		var last struct {
+			T float64 `json:"t"`
+		}
+		require.NoError(t, json.Unmarshal(parts[i][len(parts[i])-1], &last))
+		batches = append(batches, map[string]any{
+			"batchSeq":     i + 1,
+			"recvServerMs": matchGoAt.UnixMilli() + int64(last.T) + 40 - skewMs,
+			"events":       parts[i],
+		})
+	}
+	raw, err := json.Marshal(batches)
+	require.NoError(t, err)
+	return gzipJSON(t, raw)
+}
+
+// slowed stretches every timestamp of a log by factor: the same keystrokes,
+// typed slower.
+func slowed(t *testing.T, log json.RawMessage, factor int) json.RawMessage {
+	t.Helper()
+	var doc struct {
+		Version int              `json:"version"`
+		Events  []map[string]any `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(log, &doc))
+	for _, e := range doc.Events {
+		e["t"] = e["t"].(float64) * float64(factor)
+	}
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+	return raw
+}
+
+func seat(fin string, log []byte) MatchSeat {
+	return MatchSeat{
+		ID:          uuid.New(),
+		PlayerID:    uuid.NewString(),
+		Freemods:    json.RawMessage(`{"difficulty":"normal","minWpm":0,"nospace":false}`),
+		Log:         log,
+		FinalStatus: fin,
+	}
+}
+
+func judgeMatch(t *testing.T, rig matchRig) []SeatVerdict {
+	t.Helper()
+	return JudgeMatch(context.Background(), mustCore(t, DefaultReplayTimeout), rig.reg, fakeQuotes{},
+		testDecider(t, policytest.NewFake()), rig.match)
+}
+
+// The whole path on a real bundle: captures are replayed with no client claim
+// to compare against, and the accepted finishers are placed by the server's own
+// score — not by who the room said finished first.
+func TestJudgeMatchVerifiesAndPlacesSeats(t *testing.T) {
+	rig := newMatchRig(t)
+	fast := typeOut(rig.words)
+	slow := slowed(t, fast, 2)
+
+	rig.match.Seats = []MatchSeat{
+		seat("finished", capture(t, slow)),
+		seat("finished", capture(t, fast, 1, 0)), // batches stored out of order
+		seat("dnf", capture(t, fast)),
+		seat("finished", []byte("not gzip")),
+	}
+	got := judgeMatch(t, rig)
+	require.Len(t, got, 4)
+
+	slowV, fastV, dnfV, brokenV := got[0], got[1], got[2], got[3]
+	for i, v := range got[:3] {
+		require.Equalf(t, StatusAccepted, v.Status, "seat %d: %s", i, string(v.Validation))
+		require.NotNilf(t, v.Score, "seat %d has no score", i)
+		assert.Equal(t, rig.match.Seats[i].ID, v.SeatID)
+	}
+	assert.Greater(t, *fastV.Score, *slowV.Score)
+
+	require.NotNil(t, fastV.Placement)
+	require.NotNil(t, slowV.Placement)
+	assert.EqualValues(t, 1, *fastV.Placement)
+	assert.EqualValues(t, 2, *slowV.Placement)
+	assert.Nil(t, dnfV.Placement, "a dnf keeps its score and has no place")
+	assert.Equal(t, *fastV.Score, *dnfV.Score, "the same keystrokes score the same, placed or not")
+
+	assert.Equal(t, StatusFlagged, brokenV.Status)
+	assert.Nil(t, brokenV.Score)
+	assert.Nil(t, brokenV.Placement)
+}
+
+// A match whose text cannot be produced is a match of flagged seats — the
+// worker moves on, and nobody is placed on words the server never saw.
+func TestJudgeMatchOnAnUnknownDictionaryFlagsEverySeat(t *testing.T) {
+	rig := newMatchRig(t)
+	rig.match.DictHash = "00000000"
+	rig.match.Seats = []MatchSeat{
+		seat("finished", capture(t, typeOut(rig.words))),
+		seat("finished", capture(t, typeOut(rig.words))),
+	}
+	for _, v := range judgeMatch(t, rig) {
+		assert.Equal(t, StatusFlagged, v.Status)
+		assert.Equal(t, ReasonUnknownDict, audit(t, v.Decision).Reason)
+		assert.Nil(t, v.Placement)
+	}
+}
+
+func TestSeatSetupStartsOnGoAndCarriesTheSeatsFreemods(t *testing.T) {
+	goAt := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	m := PendingMatch{GoAt: goAt, EndedAt: goAt.Add(41500 * time.Millisecond)}
+
+	decode := func(raw json.RawMessage) map[string]map[string]any {
+		var out map[string]map[string]any
+		require.NoError(t, json.Unmarshal(raw, &out))
+		return out
+	}
+
+	t.Run("time", func(t *testing.T) {
+		raw, err := seatSetup(m, matchSettings{Mode: "time", DurationMs: 30_500},
+			json.RawMessage(`{"difficulty":"expert","minWpm":60,"nospace":true}`), nil)
+		require.NoError(t, err)
+		s := decode(raw)
+		assert.Equal(t, "go", s["config"]["startPolicy"])
+		assert.EqualValues(t, 30_500, s["config"]["durationMs"], "a timed match runs on the room's clock")
+		assert.EqualValues(t, 31, s["generation"]["length"], "a timed text is sized in whole seconds")
+		assert.Equal(t, "expert", s["config"]["difficulty"])
+		assert.Equal(t, true, s["config"]["nospace"])
+		assert.EqualValues(t, 60, s["config"]["minWpm"])
+		assert.NotContains(t, s["generation"], "textSource")
+		assert.Equal(t, map[string]any{"blind": false, "fading": false, "flashlight": false}, s["declaration"])
+	})
+
+	t.Run("words", func(t *testing.T) {
+		raw, err := seatSetup(m, matchSettings{Mode: "words", WordCount: 25}, json.RawMessage(`{}`), nil)
+		require.NoError(t, err)
+		s := decode(raw)
+		assert.EqualValues(t, 25, s["generation"]["length"])
+		assert.EqualValues(t, 41_500, s["config"]["durationMs"], "a counted match carries the span it lasted")
+		assert.Equal(t, "normal", s["config"]["difficulty"], "absent difficulty is the room default")
+	})
+
+	t.Run("quote", func(t *testing.T) {
+		var s matchSettings
+		s.Mode, s.WordCount = "quote", 1
+		s.TextSource.Kind, s.TextSource.QuoteID = TextSourceQuote, "7d3f0c1e-0000-4000-8000-000000000001"
+		raw, err := seatSetup(m, s, json.RawMessage(`{}`), &QuoteText{Text: "x", Hash: "abcd1234"})
+		require.NoError(t, err)
+		src := decode(raw)["generation"]["textSource"].(map[string]any)
+		assert.Equal(t, "abcd1234", src["quoteHash"], "the registry's hash stands in for the client's")
+		assert.Equal(t, s.TextSource.QuoteID, src["quoteId"])
+	})
+
+	t.Run("no dimension", func(t *testing.T) {
+		_, err := seatSetup(m, matchSettings{Mode: "time"}, json.RawMessage(`{}`), nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestSeatLogSplicesBatchesInSeqOrder(t *testing.T) {
+	log := typeOut([]string{"ab", "cd"})
+	batches, err := readCapture(capture(t, log, 1, 0))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(log), string(seatLog(batches)))
+
+	_, err = readCapture(gzipJSON(t, json.RawMessage(`{"not":"a stream"}`)))
+	assert.Error(t, err)
+}
+
+// Every replayed seat is held against the server's arrival stamps, and the
+// reading is written beside the core's flags whether or not it raised any.
+func TestJudgeMatchReadsEachSeatAgainstTheServerClock(t *testing.T) {
+	rig := newMatchRig(t)
+	log := typeOut(rig.words)
+	rig.match.Seats = []MatchSeat{
+		seat("finished", capture(t, log)),
+		seat("finished", captureSkewed(t, log, 4000)),
+	}
+	got := judgeMatch(t, rig)
+	require.Len(t, got, 2)
+
+	var docs [2]struct {
+		Flags     []Flag        `json:"flags"`
+		WallClock *wallClockDoc `json:"wallClock"`
+	}
+	for i := range got {
+		require.NoError(t, json.Unmarshal(got[i].Validation, &docs[i]))
+		require.NotNilf(t, docs[i].WallClock, "seat %d has no wall-clock reading", i)
+		assert.Equal(t, 2, docs[i].WallClock.Batches)
+	}
+	assert.Empty(t, docs[0].WallClock.Flags)
+	assert.InDelta(t, 40, docs[0].WallClock.MedianLagMs, 1)
+
+	require.Len(t, docs[1].WallClock.Flags, 1)
+	assert.Equal(t, policy.FlagWallClockSkew, docs[1].WallClock.Flags[0].Code)
+	for _, f := range docs[1].Flags {
+		assert.NotEqual(t, policy.FlagWallClockSkew, f.Code, "the core's flags stay the core's")
+	}
+}
+
+// The clock is read off the capture as stored: arrival stamps and event times
+// pair up batch by batch, and a batch without a stamp is carried as one.
+func TestSeatClockPairsArrivalsWithEventTimes(t *testing.T) {
+	batches := []capturedBatch{
+		{BatchSeq: 1, RecvServerMs: matchGoAt.UnixMilli() + 250, Events: []json.RawMessage{
+			json.RawMessage(`{"seq":1,"t":100,"kind":"insert","data":"a"}`),
+			json.RawMessage(`{"seq":2,"t":210.5,"kind":"insert","data":"b"}`),
+		}},
+		{BatchSeq: 2, Events: []json.RawMessage{json.RawMessage(`{"seq":3,"kind":"insert"}`)}},
+	}
+	c := seatClock(matchGoAt, batches)
+	assert.Equal(t, matchGoAt.UnixMilli(), c.GoAtMs)
+	require.Len(t, c.Arrivals, 2)
+	assert.Equal(t, []float64{100, 210.5}, c.Arrivals[0].EventsMs)
+	assert.Zero(t, c.Arrivals[1].RecvMs)
+	assert.Empty(t, c.Arrivals[1].EventsMs, "an event without a t has no time to hold against anything")
+}
+
+func TestPlaceSeatsIsCompetitionRanking(t *testing.T) {
+	score := func(n int64) *int64 { return &n }
+	seats := []MatchSeat{
+		{FinalStatus: "finished"}, {FinalStatus: "finished"}, {FinalStatus: "finished"},
+		{FinalStatus: "finished"}, {FinalStatus: "left"}, {FinalStatus: "finished"},
+	}
+	verdicts := []SeatVerdict{
+		{Decision: Decision{Status: StatusAccepted}, Score: score(500)},
+		{Decision: Decision{Status: StatusAccepted}, Score: score(900)},
+		{Decision: Decision{Status: StatusAccepted}, Score: score(500)},
+		{Decision: Decision{Status: StatusFlagged}, Score: score(2000)},
+		{Decision: Decision{Status: StatusAccepted}, Score: score(3000)},
+		{Decision: Decision{Status: StatusAccepted}, Score: score(100)},
+	}
+	placeSeats(seats, verdicts)
+
+	places := make([]any, len(verdicts))
+	for i, v := range verdicts {
+		if v.Placement != nil {
+			places[i] = int(*v.Placement)
+		}
+	}
+	assert.Equal(t, []any{2, 1, 2, nil, nil, 4}, places,
+		"ties share a place and the next place skips; flagged and unfinished seats are unplaced")
+}
+
+func TestScoreTotalRefusesWhatTheColumnWould(t *testing.T) {
+	assert.Nil(t, scoreTotal(nil))
+	assert.Nil(t, scoreTotal(json.RawMessage(`{"total":12.5}`)))
+	assert.Nil(t, scoreTotal(json.RawMessage(`{"total":-1}`)))
+	got := scoreTotal(json.RawMessage(`{"total":1234}`))
+	require.NotNil(t, got)
+	assert.EqualValues(t, 1234, *got)
+}
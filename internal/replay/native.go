@@ -0,0 +1,245 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// nativeBundleSHA is the SHA-256 of the bundle the native core was ported
+// from and proven against. A port is a claim about one specific bundle: the
+// moment corejs/core.bundle.js is re-vendored, the claim is unverified, and
+// NewEngine refuses to build a native engine until the port has been brought
+// up to date and this pin moved — by a person, with the golden vectors green.
+// TestNativeCoreIsPinnedToTheVendoredBundle fails first, so the pin cannot go
+// stale quietly.
+const nativeBundleSHA = "eea91ff78918601e464181206ececbf7b953f6e6c8fc7b28fffcb214faceae1c"
+
+// Native is the game core ported to Go: the ARCHITECTURE.md §3 escape hatch.
+// It computes exactly what Core computes — the same verdicts, reasons and
+// flags, the same metrics and score JSON byte for byte, the same keyboard
+// observations — and it is held to that by the golden vectors and by the
+// differential engine, not by inspection.
+//
+// It judges only what it models. A setup or log whose JavaScript behaviour
+// rests on a coercion the port does not reproduce (a null where a field is
+// read, a string where a number is compared, a timed run with no duration)
+// is refused with errUnmodelled, and the native engine hands that run to goja.
+// The port is allowed to be narrower than the bundle; it is never allowed to
+// disagree with it.
+//
+// Unlike Core, a Native is safe for concurrent use: the only shared state is
+// the parsed-dictionary cache, and that is behind a mutex.
+type Native struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	dicts map[string]*nativeDict
+}
+
+// NewNative returns a native core whose Replay calls are bounded by timeout
+// (DefaultReplayTimeout when zero). One budget covers the whole Replay, where
+// Core grants one per bundle call; the port is fast enough that the stricter
+// reading never binds on a run goja could judge in time.
+func NewNative(timeout time.Duration) *Native {
+	if timeout <= 0 {
+		timeout = DefaultReplayTimeout
+	}
+	return &Native{timeout: timeout, dicts: make(map[string]*nativeDict)}
+}
+
+// dictFile is a dictionary as the registry stores it. Only name (for the
+// empty-dictionary message) and words are read.
+type dictFile struct {
+	Name  opt[jsString] `json:"name"`
+	Words []jsString    `json:"words"`
+}
+
+// dictionary returns the parsed dictionary for a hash, caching it for the
+// lifetime of this Native the way Core caches its parsed JS object — keyed by
+// the claimed hash, with the version its words actually hash to computed once.
+func (n *Native) dictionary(dictHash string, body []byte) (*nativeDict, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if d, ok := n.dicts[dictHash]; ok {
+		return d, nil
+	}
+	var file dictFile
+	if err := decodeObject(body, &file); err != nil || file.Words == nil {
+		return nil, errUnmodelled
+	}
+	d := &nativeDict{name: toU16("undefined"), words: make([]u16, len(file.Words))}
+	if file.Name.set {
+		d.name = u16(file.Name.v)
+	}
+	for i, w := range file.Words {
+		d.words[i] = u16(w)
+	}
+	d.version = nativeDictVersion(d.words)
+	n.dicts[dictHash] = d
+	return d, nil
+}
+
+// decodeObject decodes a JSON object into v. encoding/json accepts a bare
+// null for a struct and leaves it untouched; the bundle would throw reading a
+// field off it, so null is unmodelled.
+func decodeObject(raw []byte, v any) error {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return errUnmodelled
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errUnmodelled
+	}
+	return nil
+}
+
+// wireLog is the submitted EventLog.
+type wireLog struct {
+	Version opt[float64]    `json:"version"`
+	Events  json.RawMessage `json:"events"`
+}
+
+// Replay is Core.Replay, natively. The composition is the same — validate,
+// then score under the run's formula version, then the keyboard observations —
+// and so are the errors it returns, CoreError kinds and messages included.
+// errUnmodelled means "not mine to judge", never a verdict.
+func (n *Native) Replay(ctx context.Context, in Input) (res Result, err error) {
+	if in.Bundle != "" && in.Bundle != nativeBundleSHA {
+		// The port is one build's arithmetic; any other build is unmodelled.
+		return Result{}, errUnmodelled
+	}
+	var setup setupParts
+	if err := json.Unmarshal(in.Setup, &setup); err != nil {
+		return Result{}, fmt.Errorf("replay: setup is not an object: %w", err)
+	}
+	if len(setup.Config) == 0 || len(setup.Generation) == 0 {
+		return Result{}, errors.New("replay: setup is missing config or generation")
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	if callCtx.Err() != nil {
+		return Result{}, ErrReplayTimeout
+	}
+	// The fold loops draw on one budget; every 1024th step looks at the clock.
+	// Running out unwinds with a panic, as does a value the port discovers
+	// mid-fold it cannot model — both are recovered here and nothing else is.
+	steps := 0
+	tick := func() {
+		if steps++; steps&1023 == 0 && callCtx.Err() != nil {
+			panic(ErrReplayTimeout)
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			res = Result{}
+			if e, ok := r.(error); ok && (errors.Is(e, ErrReplayTimeout) || errors.Is(e, errUnmodelled)) {
+				err = e
+				return
+			}
+			err = fmt.Errorf("replay: native core panicked: %v", r)
+		}
+	}()
+
+	var (
+		config coreConfig
+		gen    generation
+		decl   declaration
+		log    wireLog
+	)
+	if err := errors.Join(
+		decodeObject(setup.Config, &config),
+		decodeObject(setup.Generation, &gen),
+		decodeObject(in.Log, &log),
+	); err != nil {
+		return Result{}, errUnmodelled
+	}
+	if len(setup.Declaration) > 0 {
+		if err := decodeObject(setup.Declaration, &decl); err != nil {
+			return Result{}, err
+		}
+	}
+	r, err := rulesOf(&config)
+	if err != nil {
+		return Result{}, err
+	}
+	events, err := decodeEvents(log.Events)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// The quote/seeded split, as Core.Replay makes it. A quote run whose setup
+	// the bundle would not read as a quote (or the reverse) is Core's to
+	// explain — it is an error there, and which error is not worth porting.
+	var (
+		dict      *nativeDict
+		quoteText u16
+		claimed   string
+		actual    string
+	)
+	if in.Quote != nil {
+		if !gen.isQuote() {
+			return Result{}, errUnmodelled
+		}
+		quoteText = toU16(in.Quote.Text)
+		claimed, actual = in.Quote.Hash, nativeDictVersion([]u16{quoteText})
+	} else {
+		if gen.isQuote() {
+			return Result{}, errUnmodelled
+		}
+		if dict, err = n.dictionary(in.DictHash, in.DictBody); err != nil {
+			return Result{}, err
+		}
+		claimed, actual = in.DictHash, dict.version
+	}
+	if claimed != actual {
+		return Result{}, &CoreError{Kind: "DictVersionMismatch",
+			Message: fmt.Sprintf("claimed dictVersion %s != dictionary %s", claimed, actual)}
+	}
+
+	seed := float64(in.Seed)
+	words, err := generateWords(dict, quoteText, claimed, seed, &gen, tick)
+	if err != nil {
+		var ce *CoreError
+		if errors.As(err, &ce) {
+			return Result{}, &CoreError{Kind: "GenerationFailed", Message: ce.Message}
+		}
+		return Result{}, err
+	}
+
+	f := &fold{rules: r, words: words, tick: tick}
+	v := f.validateLog(log.Version, events, seed, in.CanariesArmed)
+	for i := range v.flags {
+		v.flags[i].Score = jsonFloat(v.flags[i].Score)
+	}
+	res = Result{Verdict: v.verdict, Reason: v.reason, Flags: v.flags}
+	if v.verdict != verdictValid {
+		return res, nil
+	}
+	res.Metrics = v.metrics.json()
+
+	stateEvents := stateEventsOf(events)
+	lastT := 0.0
+	if len(stateEvents) > 0 {
+		lastT = stateEvents[len(stateEvents)-1].t
+	}
+	switch in.ScoreVersion {
+	case scoreVersionV1:
+		m := f.metricsFrom(&v.analysis, lastT)
+		st := f.scoreSteps(stateEvents, false)
+		res.Score = f.scoreJSON(scoreVersionV1, &st, &m, 0)
+	case scoreVersionV2, scoreVersionV3:
+		m := f.metricsFrom(&v.analysis, f.scoreEnd(&v.analysis, lastT))
+		st := f.scoreSteps(stateEvents, in.ScoreVersion == scoreVersionV3)
+		res.Score = f.scoreJSON(in.ScoreVersion, &st, &m, modMultiplierV1(&gen, &f.rules, &decl))
+	default:
+		return Result{}, fmt.Errorf("replay: unsupported score version %d", in.ScoreVersion)
+	}
+
+	res.CharObservations = f.charObservationsOf(stateEvents)
+	return res, nil
+}
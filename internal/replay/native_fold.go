@@ -0,0 +1,519 @@
+package replay
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// The reducer, ported from the bundle's game-core.ts: one event at a time, a
+// state that is either accepted whole or refused whole.
+//
+// Two liberties are taken with the original's shape, neither with its
+// behaviour. The bundle keeps every state immutable and shares buffers through
+// a journaled store; here the scalars are a value (settle returns a copy) and
+// the typed buffers are one mutable array that reduce writes only after every
+// check that could refuse the event has passed — which is exactly the
+// immutability the callers rely on, since none of them ever reads a state
+// after handing it to a successful reduce. And the incremental counters the
+// store carries (separators, net-correct, target characters of committed
+// words) are always in play, because every fold here builds its own state from
+// its own word list.
+
+// coreConfig is the part of setup.config the reducer, the scorer and the
+// validator read.
+type coreConfig struct {
+	Mode          opt[string]  `json:"mode"`
+	DurationMs    opt[float64] `json:"durationMs"`
+	MinWpm        opt[float64] `json:"minWpm"`
+	MaxExtraChars opt[float64] `json:"maxExtraChars"`
+	Nospace       opt[bool]    `json:"nospace"`
+	Difficulty    opt[string]  `json:"difficulty"`
+	StopOnError   opt[string]  `json:"stopOnError"`
+	QuickEnd      opt[bool]    `json:"quickEnd"`
+	FreedomMode   opt[bool]    `json:"freedomMode"`
+	StartPolicy   opt[string]  `json:"startPolicy"`
+}
+
+// rules is coreConfig with every `=== x` and truthiness test answered once.
+type rules struct {
+	timed       bool
+	countsWords bool // isCountMode: neither "time" nor "free"
+	durationMs  float64
+	minWpm      float64
+	maxExtra    float64 // +Inf when absent: `len > target + undefined` is never true
+	nospace     bool
+	expert      bool
+	master      bool
+	stopLetter  bool
+	stopWord    bool
+	quickEnd    bool
+	freedom     bool
+	startGo     bool
+}
+
+func rulesOf(c *coreConfig) (rules, error) {
+	mode := c.Mode.or("")
+	r := rules{
+		timed:       mode == "time",
+		countsWords: mode != "time" && mode != "free",
+		durationMs:  c.DurationMs.or(math.NaN()),
+		minWpm:      c.MinWpm.or(0),
+		maxExtra:    c.MaxExtraChars.or(math.Inf(1)),
+		nospace:     c.Nospace.is(true),
+		expert:      c.Difficulty.is("expert"),
+		master:      c.Difficulty.is("master"),
+		stopLetter:  c.StopOnError.is("letter"),
+		stopWord:    c.StopOnError.is("word"),
+		quickEnd:    c.QuickEnd.is(true),
+		freedom:     c.FreedomMode.is(true),
+		startGo:     c.StartPolicy.is("go"),
+	}
+	// A timed run without a duration folds with NaN deadlines: every
+	// comparison false, no finish ever. Reproducible, and never a real run.
+	if r.timed && !c.DurationMs.set {
+		return rules{}, errUnmodelled
+	}
+	return r, nil
+}
+
+type eventKind uint8
+
+const (
+	evUnknown eventKind = iota
+	evInsert
+	evReplace
+	evDelete
+	evCommit
+	evDown
+	evUp
+)
+
+// wireEvent is one element of log.events as the client wrote it.
+type wireEvent struct {
+	Seq    opt[float64]  `json:"seq"`
+	T      opt[float64]  `json:"t"`
+	Kind   opt[string]   `json:"kind"`
+	Text   opt[jsString] `json:"text"`
+	From   opt[float64]  `json:"from"`
+	To     opt[float64]  `json:"to"`
+	Unit   opt[string]   `json:"unit"`
+	Source opt[string]   `json:"source"`
+	Code   opt[string]   `json:"code"`
+}
+
+// foldEvent is a wireEvent the port has checked it can reproduce.
+type foldEvent struct {
+	seq, t   float64
+	kind     eventKind
+	text     u16
+	from, to int
+	wordUnit bool
+	source   string
+	code     opt[string]
+}
+
+func (e *foldEvent) telemetry() bool { return e.kind == evDown || e.kind == evUp }
+
+// decodeEvents parses log.events and returns them in sortEvents order. The
+// fields a kind reads must be present and of the type the bundle assumes; a
+// replace range must be integral, because String.prototype.slice truncates and
+// the scorer does arithmetic on the untruncated number.
+func decodeEvents(raw json.RawMessage) ([]foldEvent, error) {
+	var wire []wireEvent
+	if err := json.Unmarshal(raw, &wire); err != nil || wire == nil {
+		return nil, errUnmodelled
+	}
+	events := make([]foldEvent, len(wire))
+	for i := range wire {
+		w := &wire[i]
+		if !w.Seq.set || !w.T.set {
+			return nil, errUnmodelled
+		}
+		e := foldEvent{seq: w.Seq.v, t: w.T.v, text: u16(w.Text.v), source: w.Source.v, code: w.Code, wordUnit: w.Unit.is("word")}
+		switch w.Kind.v {
+		case "insert":
+			e.kind = evInsert
+			if !w.Text.set {
+				return nil, errUnmodelled
+			}
+		case "replace":
+			e.kind = evReplace
+			from, okFrom := asGojaInt(w.From.v)
+			to, okTo := asGojaInt(w.To.v)
+			if !w.Text.set || !w.From.set || !w.To.set || !okFrom || !okTo {
+				return nil, errUnmodelled
+			}
+			e.from, e.to = int(from), int(to)
+		case "delete":
+			e.kind = evDelete
+		case "commit":
+			e.kind = evCommit
+		case "down":
+			e.kind = evDown
+		case "up":
+			e.kind = evUp
+		}
+		events[i] = e
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].seq < events[i-1].seq {
+			sort.SliceStable(events, func(a, b int) bool { return events[a].seq < events[b].seq })
+			break
+		}
+	}
+	return events, nil
+}
+
+// stateEventsOf drops key telemetry, which never moves the game.
+func stateEventsOf(events []foldEvent) []foldEvent {
+	out := make([]foldEvent, 0, len(events))
+	for i := range events {
+		if !events[i].telemetry() {
+			out = append(out, events[i])
+		}
+	}
+	return out
+}
+
+type gamePhase uint8
+
+const (
+	phaseIdle gamePhase = iota
+	phaseRunning
+	phaseFinished
+)
+
+// wordBuffers is what the player has typed per word, plus the store's
+// incremental counters over the committed words.
+type wordBuffers struct {
+	slots   []u16
+	sep     int // separators owed by committed words
+	correct int // netCreditOf summed over committed words
+	target  int // target length summed over committed words
+}
+
+func (b *wordBuffers) at(i int) u16 {
+	if i < len(b.slots) {
+		return b.slots[i]
+	}
+	return nil
+}
+
+func (b *wordBuffers) write(i int, v u16) {
+	for len(b.slots) <= i {
+		b.slots = append(b.slots, nil)
+	}
+	b.slots[i] = v
+}
+
+// gameState mirrors GameState. The nullable instants carry a has-flag each.
+type gameState struct {
+	phase      gamePhase
+	wordIndex  int
+	buf        *wordBuffers
+	startedAt  float64
+	started    bool
+	finishedAt float64
+	finished   bool
+	lastSeq    float64
+	hasSeq     bool
+	failReason string
+}
+
+// fold is one folding context: the rules and the words, and the budget every
+// loop draws on.
+type fold struct {
+	rules
+	words []u16
+	tick  func()
+}
+
+func (f *fold) word(i int) u16 {
+	if i < len(f.words) {
+		return f.words[i]
+	}
+	return nil
+}
+
+func (f *fold) initialState() gameState {
+	s := gameState{phase: phaseIdle, buf: &wordBuffers{}}
+	if f.startGo {
+		s.phase, s.started = phaseRunning, true
+	}
+	return s
+}
+
+func netCreditOf(target, typed u16) int {
+	if !equalU16(target, typed) {
+		return 0
+	}
+	if endsWithNewline(target) {
+		return len(target)
+	}
+	return len(target) + 1
+}
+
+func (f *fold) commitBuffers(b *wordBuffers, index int, typed u16) {
+	if index >= len(f.words) {
+		return
+	}
+	w := f.words[index]
+	if !endsWithNewline(w) {
+		b.sep++
+	}
+	b.correct += netCreditOf(w, typed)
+	b.target += len(w)
+}
+
+func (f *fold) uncommitBuffers(b *wordBuffers, index int, typed u16) {
+	if index >= len(f.words) {
+		return
+	}
+	w := f.words[index]
+	if !endsWithNewline(w) {
+		b.sep--
+	}
+	b.correct -= netCreditOf(w, typed)
+	b.target -= len(w)
+}
+
+func (f *fold) finishedByCount(s gameState) bool { return s.phase == phaseFinished && f.countsWords }
+
+func (f *fold) separatorsOf(s gameState) int {
+	committed := min(s.wordIndex, len(f.words))
+	if f.finishedByCount(s) && committed > 0 && !endsWithNewline(f.words[committed-1]) {
+		return s.buf.sep - 1
+	}
+	return s.buf.sep
+}
+
+func correctPrefixLength(target, buffer u16) int {
+	shared := min(len(target), len(buffer))
+	i := 0
+	for i < shared && buffer[i] == target[i] {
+		i++
+	}
+	return i
+}
+
+func (f *fold) netCharsOf(s gameState) int {
+	credited := s.buf.correct
+	committed := min(s.wordIndex, len(f.words))
+	if f.finishedByCount(s) && committed > 0 {
+		last := committed - 1
+		target := f.words[last]
+		if equalU16(s.buf.at(last), target) && !endsWithNewline(target) {
+			credited--
+		}
+	}
+	if s.wordIndex < len(f.words) {
+		credited += correctPrefixLength(f.words[s.wordIndex], s.buf.at(s.wordIndex))
+	}
+	return credited
+}
+
+const minSpeedGraceMs = 3e3
+
+// minSpeedFailInstant is the instant the run falls below its minSpeed floor
+// if nothing more is typed; ok is false when the floor does not apply.
+func (f *fold) minSpeedFailInstant(s gameState) (float64, bool) {
+	floor := f.minWpm
+	if !(floor > 0) || !s.started || s.phase != phaseRunning {
+		return 0, false
+	}
+	netChars := float64(f.netCharsOf(s))
+	return s.startedAt + jsMax(12e3*netChars/floor, minSpeedGraceMs), true
+}
+
+func (f *fold) settle(s gameState, nowMs float64) gameState {
+	if s.phase != phaseRunning || !s.started {
+		return s
+	}
+	finishAt, hasFinish, reason := 0.0, false, ""
+	if f.timed {
+		finishAt, hasFinish = s.startedAt+f.durationMs, true
+	}
+	if f.minWpm > 0 {
+		if failAt, ok := f.minSpeedFailInstant(s); ok && (!hasFinish || failAt < finishAt) {
+			finishAt, hasFinish, reason = failAt, true, "minSpeed"
+		}
+	}
+	if hasFinish && nowMs >= finishAt {
+		s.phase, s.finishedAt, s.finished, s.failReason = phaseFinished, finishAt, true, reason
+	}
+	return s
+}
+
+func hasWrongChar(target, text u16, at int) bool {
+	for k := range text {
+		pos := at + k
+		if pos < 0 || pos >= len(target) || target[pos] != text[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// stepped is the state after an accepted event: lastSeq moves to it.
+func stepped(s gameState, e *foldEvent) gameState {
+	s.lastSeq, s.hasSeq = e.seq, true
+	return s
+}
+
+func finishedAt(s gameState, e *foldEvent, wordIndex int, reason string) gameState {
+	s = stepped(s, e)
+	s.phase, s.wordIndex, s.finishedAt, s.finished, s.failReason = phaseFinished, wordIndex, e.t, true, reason
+	return s
+}
+
+func (f *fold) commitWord(s gameState, e *foldEvent, buffer u16) gameState {
+	wordIndex := s.wordIndex
+	if f.expert && !equalU16(buffer, f.word(wordIndex)) {
+		return finishedAt(s, e, wordIndex, "expert")
+	}
+	next := wordIndex + 1
+	f.commitBuffers(s.buf, wordIndex, buffer)
+	if f.countsWords && next >= len(f.words) {
+		return finishedAt(s, e, next, "")
+	}
+	s = stepped(s, e)
+	s.phase, s.wordIndex, s.finished, s.failReason = phaseRunning, next, false, ""
+	return s
+}
+
+// applyEdit is the shared tail of insert and replace: next is the word's new
+// buffer, inserted the text that entered it at insertedAt.
+func (f *fold) applyEdit(s gameState, e *foldEvent, next, inserted u16, insertedAt int) (gameState, string) {
+	wordIndex := s.wordIndex
+	target := f.word(wordIndex)
+	if float64(len(next)) > float64(len(target))+f.maxExtra {
+		return s, "WordLengthExceeded"
+	}
+	wrong := hasWrongChar(target, inserted, insertedAt)
+	if wrong && !f.master && f.stopLetter {
+		return s, "StoppedOnError"
+	}
+	s.buf.write(wordIndex, next)
+	if !s.started {
+		s.startedAt, s.started = e.t, true
+	}
+	if f.master && wrong {
+		return finishedAt(s, e, wordIndex, "master"), ""
+	}
+	isLastWord := wordIndex+1 >= len(f.words)
+	if len(next) >= len(target) && (f.nospace || (f.quickEnd && f.countsWords && isLastWord)) {
+		return f.commitWord(s, e, next), ""
+	}
+	s = stepped(s, e)
+	s.phase = phaseRunning
+	return s, ""
+}
+
+func (f *fold) prevWordLocked(s gameState) bool {
+	if f.freedom {
+		return false
+	}
+	previous := s.wordIndex - 1
+	if previous < 0 {
+		return false
+	}
+	return equalU16(s.buf.at(previous), f.word(previous))
+}
+
+func (f *fold) reduceDelete(s gameState, e *foldEvent) (gameState, string) {
+	wordIndex := s.wordIndex
+	buffer := s.buf.at(wordIndex)
+	crossesBoundary := len(buffer) == 0 && wordIndex > 0
+	if crossesBoundary && f.prevWordLocked(s) {
+		return s, "BackspaceLocked"
+	}
+	switch {
+	case len(buffer) > 0 && e.wordUnit:
+		s.buf.write(wordIndex, nil)
+	case len(buffer) > 0:
+		s.buf.write(wordIndex, buffer[:len(buffer)-1:len(buffer)-1])
+	case crossesBoundary:
+		previous := wordIndex - 1
+		f.uncommitBuffers(s.buf, previous, s.buf.at(previous))
+		if e.wordUnit {
+			s.buf.write(previous, nil)
+		}
+		s.wordIndex = previous
+	}
+	return stepped(s, e), ""
+}
+
+func (f *fold) reduceCommit(s gameState, e *foldEvent) (gameState, string) {
+	if f.nospace {
+		return s, "NospaceCommit"
+	}
+	if s.phase != phaseRunning {
+		return stepped(s, e), ""
+	}
+	buffer := s.buf.at(s.wordIndex)
+	stoppedOnWrongWord := f.stopWord && !equalU16(buffer, f.word(s.wordIndex))
+	if len(buffer) == 0 {
+		if stoppedOnWrongWord {
+			return s, "StoppedOnError"
+		}
+		return stepped(s, e), ""
+	}
+	if stoppedOnWrongWord && !f.expert {
+		return s, "StoppedOnError"
+	}
+	return f.commitWord(s, e, buffer), ""
+}
+
+// reduce applies one event. A non-empty second result is the refusal's kind,
+// and the returned state is then the one passed in, untouched.
+func (f *fold) reduce(s gameState, e *foldEvent) (gameState, string) {
+	if s.hasSeq && e.seq <= s.lastSeq {
+		return s, "NonMonotonicSeq"
+	}
+	if e.telemetry() {
+		return s, ""
+	}
+	if s.phase == phaseFinished {
+		return s, "TestFinished"
+	}
+	switch e.kind {
+	case evInsert:
+		buffer := s.buf.at(s.wordIndex)
+		return f.applyEdit(s, e, concatU16(buffer, e.text), e.text, len(buffer))
+	case evReplace:
+		buffer := s.buf.at(s.wordIndex)
+		if e.from < 0 || e.to < e.from || e.to > len(buffer) {
+			return s, "InvalidRange"
+		}
+		return f.applyEdit(s, e, concatU16(buffer[:e.from], e.text, buffer[e.to:]), e.text, e.from)
+	case evDelete:
+		return f.reduceDelete(s, e)
+	case evCommit:
+		return f.reduceCommit(s, e)
+	}
+	return s, "UnknownEventKind"
+}
+
+// foldLog folds the whole log, settling before every event and once more at
+// the end. hasEnd false is the bundle's `endMs` undefined.
+func (f *fold) foldLog(events []foldEvent, endMs float64, hasEnd bool) (s gameState, refusedKind string, refusedAt float64) {
+	s = f.initialState()
+	for i := range events {
+		f.tick()
+		e := &events[i]
+		s = f.settle(s, e.t)
+		var kind string
+		if s, kind = f.reduce(s, e); kind != "" {
+			return s, kind, e.seq
+		}
+	}
+	end := 0.0
+	switch {
+	case hasEnd:
+		end = endMs
+	case len(events) > 0:
+		end = events[len(events)-1].t
+	}
+	return f.settle(s, end), "", 0
+}
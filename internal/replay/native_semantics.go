@@ -0,0 +1,367 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/dop251/goja/ftoa"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// The JavaScript the native core has to reproduce, and nothing more.
+//
+// The port is held to the bundle AS GOJA RUNS IT, not to a browser: goja is
+// what judged every stored run, so where goja and V8 could round a corner
+// differently the port rounds it goja's way. In practice that means four
+// things — strings are UTF-16 code units, numbers print through goja's own
+// ftoa, Math.round and `**` are goja's, and case mapping is the x/text caser
+// goja calls. Each lives here, once, so the fold code reads like the
+// TypeScript it was ported from.
+
+// u16 is a JS string: UTF-16 code units, lone surrogates and all. Every
+// length, index and comparison in the core is over code units, so the port
+// keeps the representation rather than translating each rule to runes.
+type u16 = []uint16
+
+// errUnmodelled marks an input the native core declines to judge: a value of
+// a type, or a shape, whose JavaScript behaviour the port does not reproduce
+// (a null where the bundle reads a field, a string where it does arithmetic).
+// Never a verdict about the run — the native engine hands such a run to goja,
+// which is what always judged it. See NewEngine.
+var errUnmodelled = errors.New("replay: input is outside the native core's model")
+
+// jsString decodes a JSON string literal to code units without passing
+// through a Go string. encoding/json would replace an escaped lone surrogate
+// with U+FFFD; JSON.parse keeps it, and a word's length is its code units.
+type jsString u16
+
+// UnmarshalJSON implements json.Unmarshaler. encoding/json has validated the
+// literal before calling it, so every escape here is well-formed.
+func (s *jsString) UnmarshalJSON(b []byte) error {
+	if len(b) < 2 || b[0] != '"' {
+		return errUnmodelled
+	}
+	body := b[1 : len(b)-1]
+	out := make(u16, 0, len(body))
+	for i := 0; i < len(body); {
+		c := body[i]
+		switch {
+		case c == '\\':
+			switch e := body[i+1]; e {
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'u':
+				v, err := strconv.ParseUint(string(body[i+2:i+6]), 16, 16)
+				if err != nil {
+					return errUnmodelled
+				}
+				out = append(out, uint16(v))
+				i += 6
+				continue
+			default: // '"', '\\', '/'
+				out = append(out, uint16(e))
+			}
+			i += 2
+		case c < utf8.RuneSelf:
+			out = append(out, uint16(c))
+			i++
+		default:
+			r, size := utf8.DecodeRune(body[i:])
+			out = utf16.AppendRune(out, r)
+			i += size
+		}
+	}
+	*s = out
+	return nil
+}
+
+// toU16 is a Go string as goja imports it.
+func toU16(s string) u16 { return utf16.Encode([]rune(s)) }
+
+// goString is a JS string as goja exports it: lone surrogates become U+FFFD.
+func goString(s u16) string { return string(utf16.Decode(s)) }
+
+// u16Key is a code-unit sequence usable as a map key.
+func u16Key(s u16) string {
+	var b strings.Builder
+	b.Grow(2 * len(s))
+	for _, c := range s {
+		b.WriteByte(byte(c >> 8))
+		b.WriteByte(byte(c))
+	}
+	return b.String()
+}
+
+func equalU16(a, b u16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// concatU16 always allocates: a buffer handed to the next state must never
+// share a backing array with the one it replaced.
+func concatU16(parts ...u16) u16 {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	out := make(u16, 0, n)
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// endsWithNewline is the core's endsLine.
+func endsWithNewline(s u16) bool { return len(s) > 0 && s[len(s)-1] == '\n' }
+
+func isSurrogate(c uint16) bool { return c >= 0xD800 && c <= 0xDFFF }
+
+// codePoints splits a string the way `for…of` and `[...s]` do: a surrogate
+// pair is one element, anything else — a lone surrogate included — is one
+// unit.
+func codePoints(s u16) []u16 {
+	out := make([]u16, 0, len(s))
+	for i := 0; i < len(s); {
+		n := 1
+		if s[i] >= 0xD800 && s[i] <= 0xDBFF && i+1 < len(s) && s[i+1] >= 0xDC00 && s[i+1] <= 0xDFFF {
+			n = 2
+		}
+		out = append(out, s[i:i+n])
+		i += n
+	}
+	return out
+}
+
+func isASCII(s u16) bool {
+	for _, c := range s {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// jsUpper is String.prototype.toUpperCase as goja implements it.
+func jsUpper(s u16) u16 {
+	if isASCII(s) {
+		out := make(u16, len(s))
+		for i, c := range s {
+			if 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			out[i] = c
+		}
+		return out
+	}
+	return toU16(cases.Upper(language.Und).String(goString(s)))
+}
+
+// jsLower is String.prototype.toLowerCase as goja implements it, final-sigma
+// workaround included.
+func jsLower(s u16) u16 {
+	if isASCII(s) {
+		out := make(u16, len(s))
+		for i, c := range s {
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			out[i] = c
+		}
+		return out
+	}
+	r := []rune(cases.Lower(language.Und).String(goString(s)))
+	for i := 0; i < len(r)-1; i++ {
+		if (i == 0 || r[i-1] != 0x3b1) && r[i] == 0x345 && r[i+1] == 0x3c2 {
+			i++
+			r[i] = 0x3c3
+		}
+	}
+	return utf16.Encode(r)
+}
+
+// jsNumber is Number.prototype.toString(): what a template literal prints.
+func jsNumber(f float64) string { return string(ftoa.FToStr(f, ftoa.ModeStandard, 0, nil)) }
+
+// appendJSONNumber is a number as JSON.stringify writes it.
+func appendJSONNumber(dst []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(dst, "null"...)
+	}
+	return ftoa.FToStr(f, ftoa.ModeStandard, 0, dst)
+}
+
+// jsonFloat is what a number becomes on the way through JSON.stringify and
+// back into a Go float64: a non-finite value is written as null, and null
+// leaves the field at zero; -0 is written as 0.
+func jsonFloat(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f == 0 {
+		return 0
+	}
+	return f
+}
+
+// jsRound is goja's Math.round.
+func jsRound(f float64) float64 {
+	if math.IsNaN(f) || (f == 0 && math.Signbit(f)) {
+		return f
+	}
+	t := math.Trunc(f)
+	if f >= 0 {
+		if f-t >= 0.5 {
+			return t + 1
+		}
+	} else if t-f > 0.5 {
+		return t - 1
+	}
+	return t
+}
+
+// jsMin / jsMax are the two-argument Math.min / Math.max: NaN wins, and the
+// zeroes are ordered.
+func jsMin(a, b float64) float64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.NaN()
+	}
+	return math.Min(a, b)
+}
+
+func jsMax(a, b float64) float64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.NaN()
+	}
+	return math.Max(a, b)
+}
+
+// maxSafeInt is the bound inside which goja holds an integral number as an
+// int64 rather than a float64 — which decides how `**` computes it.
+const maxSafeInt = 1 << 53
+
+func asGojaInt(f float64) (int64, bool) {
+	if (f != 0 || !math.Signbit(f)) && !math.IsInf(f, 0) && f == math.Trunc(f) && f >= -maxSafeInt && f <= maxSafeInt {
+		return int64(f), true
+	}
+	return 0, false
+}
+
+// jsPow is goja's `**`: exact integer power when both sides are integers and
+// the result fits, math.Pow otherwise.
+func jsPow(x, y float64) float64 {
+	if xi, ok := asGojaInt(x); ok {
+		if yi, ok := asGojaInt(y); ok && yi >= 0 {
+			if yi == 0 {
+				return 1
+			}
+			if xi == 0 {
+				return 0
+			}
+			if p, ok := intPow(xi, yi); ok {
+				return float64(p)
+			}
+		}
+	}
+	if math.Abs(x) == 1 && math.IsInf(y, 0) {
+		return math.NaN()
+	}
+	if x == 1 && math.IsNaN(y) {
+		return math.NaN()
+	}
+	return math.Pow(x, y)
+}
+
+// intPow is base**exp when it fits an int64 — goja's ipow, whose zero means
+// "did not fit".
+func intPow(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 != 0 {
+			hi, lo := mulOverflows(result, base)
+			if hi {
+				return 0, false
+			}
+			result = lo
+		}
+		exp >>= 1
+		if exp > 0 {
+			hi, sq := mulOverflows(base, base)
+			if hi {
+				return 0, false
+			}
+			base = sq
+		}
+	}
+	return result, result != 0
+}
+
+func mulOverflows(a, b int64) (bool, int64) {
+	if a == 0 || b == 0 {
+		return false, 0
+	}
+	p := a * b
+	return p/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64), p
+}
+
+// toUint32 is ECMAScript ToUint32 — what `x >>> 0` does to a number.
+func toUint32(f float64) uint32 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	m := math.Mod(math.Trunc(f), 1<<32)
+	if m < 0 {
+		m += 1 << 32
+	}
+	return uint32(m)
+}
+
+// opt is one optional field of the client's JSON. JavaScript has three states
+// where Go has two — absent, null, a value — and the bundle treats absent and
+// null differently in places (Math.floor(null) is 0, Math.floor(undefined) is
+// NaN), so null is refused outright instead of being folded into either.
+type opt[T any] struct {
+	v   T
+	set bool
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A value of the wrong JSON type is
+// unmodelled too: the bundle would coerce it, and the port does not.
+func (o *opt[T]) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return errUnmodelled
+	}
+	if err := json.Unmarshal(b, &o.v); err != nil {
+		return errUnmodelled
+	}
+	o.set = true
+	return nil
+}
+
+// or is the value, or def when the field was absent.
+func (o opt[T]) or(def T) T {
+	if o.set {
+		return o.v
+	}
+	return def
+}
+
+// is reports `field === want`.
+func (o opt[T]) is(want T) bool { return o.set && any(o.v) == any(want) }
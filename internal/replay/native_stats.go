@@ -0,0 +1,626 @@
+package replay
+
+import (
+	"math"
+	"sort"
+)
+
+// Metrics, keyboard observations and the three score formulas, ported from the
+// bundle's stats.ts, keyboard.ts, mods.ts and score.ts. Every number is
+// computed in the order the TypeScript computes it — `a / 5 / m` is not
+// `a / (5 * m)` in floating point, and the metrics are compared byte for byte.
+
+// analysis is what analyzeLog returns, minus the per-word timings only the
+// client's timeline reads.
+type analysis struct {
+	final       gameState
+	aborted     bool
+	correctKeys int
+	totalKeys   int
+	keyTimes    []float64
+}
+
+// analyzeLog folds the state events without a final settle, recording every
+// typed code unit. It does not depend on where the run ends, so one analysis
+// serves the validator's metrics and every score formula.
+func (f *fold) analyzeLog(stateEvents []foldEvent) analysis {
+	var a analysis
+	s := f.initialState()
+	for i := range stateEvents {
+		f.tick()
+		e := &stateEvents[i]
+		s = f.settle(s, e.t)
+		if s.phase == phaseFinished {
+			a.aborted = true
+			break
+		}
+		if e.kind == evInsert || e.kind == evReplace {
+			target := f.word(s.wordIndex)
+			startPos := len(s.buf.at(s.wordIndex))
+			if e.kind == evReplace {
+				startPos = e.from
+			}
+			for k, c := range e.text {
+				pos := startPos + k
+				a.totalKeys++
+				if pos >= 0 && pos < len(target) && target[pos] == c {
+					a.correctKeys++
+				}
+				a.keyTimes = append(a.keyTimes, e.t)
+			}
+		}
+		next, refused := f.reduce(s, e)
+		if refused != "" {
+			a.aborted = true
+			break
+		}
+		s = next
+	}
+	a.final = s
+	return a
+}
+
+type charCounts struct {
+	correct, incorrect, extra, missed int
+}
+
+func compareWord(target, typed u16, includeMissed bool) charCounts {
+	common := min(len(target), len(typed))
+	var c charCounts
+	for i := range common {
+		if typed[i] == target[i] {
+			c.correct++
+		} else {
+			c.incorrect++
+		}
+	}
+	c.extra = max(0, len(typed)-len(target))
+	if includeMissed {
+		c.missed = max(0, len(target)-len(typed))
+	}
+	return c
+}
+
+func (f *fold) getChars(s gameState) (charCounts, int) {
+	committed := min(s.wordIndex, len(f.words))
+	var total charCounts
+	add := func(c charCounts) {
+		total.correct += c.correct
+		total.incorrect += c.incorrect
+		total.extra += c.extra
+		total.missed += c.missed
+	}
+	for i := range committed {
+		add(compareWord(f.words[i], s.buf.at(i), true))
+	}
+	if s.wordIndex < len(f.words) {
+		if buffer := s.buf.at(s.wordIndex); len(buffer) > 0 {
+			add(compareWord(f.words[s.wordIndex], buffer, false))
+		}
+	}
+	return total, f.separatorsOf(s)
+}
+
+func kogasa(cov float64) float64 {
+	return 1 - math.Tanh(cov+jsPow(cov, 3)/3+jsPow(cov, 5)/5)
+}
+
+func consistencyOf(rawPerSecond []float64) float64 {
+	if len(rawPerSecond) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, r := range rawPerSecond {
+		sum += r
+	}
+	n := float64(len(rawPerSecond))
+	mean := sum / n
+	if mean == 0 {
+		return 0
+	}
+	sq := 0.0
+	for _, r := range rawPerSecond {
+		sq += jsPow(r-mean, 2)
+	}
+	value := kogasa(math.Sqrt(sq/n) / mean)
+	if math.IsNaN(value) {
+		return 0
+	}
+	return value
+}
+
+// maxRunSeconds bounds the per-second arrays the port allocates. The bundle
+// allocates whatever a run's span asks for, and a span long enough to matter
+// here is one no honest log has; such a run is goja's to judge.
+const maxRunSeconds = 10_000_000
+
+func (f *fold) rawPerSecondOf(a *analysis, endMs float64) []float64 {
+	if !a.final.started {
+		return nil
+	}
+	startedAt := a.final.startedAt
+	end := endMs
+	if a.final.finished {
+		end = a.final.finishedAt
+	}
+	secondsF := math.Ceil(jsMax(0, (end-startedAt)/1e3))
+	if !(secondsF > 0) {
+		return nil
+	}
+	if secondsF > maxRunSeconds {
+		panic(errUnmodelled)
+	}
+	seconds := int(secondsF)
+	counts := make([]float64, seconds+1)
+	for _, t := range a.keyTimes {
+		offset := t - startedAt
+		if offset < 0 {
+			continue
+		}
+		if bucket := math.Floor(offset/1e3) + 1; bucket <= secondsF {
+			counts[int(bucket)]++
+		}
+	}
+	const fullRateMin = 1e3 / 6e4
+	out := make([]float64, 0, seconds)
+	for s := 1; s < seconds; s++ {
+		out = append(out, counts[s]/5/fullRateMin)
+	}
+	bucketEnd := startedAt + secondsF*1e3
+	checkpoint := jsMin(bucketEnd, end)
+	if checkpoint < bucketEnd {
+		rateStart := jsMax(startedAt, checkpoint-1e3)
+		rawInWindow := 0
+		for _, t := range a.keyTimes {
+			if t >= rateStart {
+				rawInWindow++
+			}
+		}
+		rateMin := (checkpoint - rateStart) / 6e4
+		if rateMin > 0 {
+			out = append(out, float64(rawInWindow)/5/rateMin)
+		} else {
+			out = append(out, 0)
+		}
+	} else {
+		out = append(out, counts[seconds]/5/fullRateMin)
+	}
+	return out
+}
+
+// coreMetrics is the core's Metrics object.
+type coreMetrics struct {
+	wpm, raw, accuracy, consistency float64
+	chars                           charCounts
+	spaces                          int
+	durationSec                     float64
+}
+
+// json is JSON.stringify(metrics), key order included.
+func (m *coreMetrics) json() []byte {
+	b := make([]byte, 0, 192)
+	b = append(b, `{"wpm":`...)
+	b = appendJSONNumber(b, m.wpm)
+	b = append(b, `,"raw":`...)
+	b = appendJSONNumber(b, m.raw)
+	b = append(b, `,"accuracy":`...)
+	b = appendJSONNumber(b, m.accuracy)
+	b = append(b, `,"consistency":`...)
+	b = appendJSONNumber(b, m.consistency)
+	b = append(b, `,"chars":{"correct":`...)
+	b = appendJSONNumber(b, float64(m.chars.correct))
+	b = append(b, `,"incorrect":`...)
+	b = appendJSONNumber(b, float64(m.chars.incorrect))
+	b = append(b, `,"extra":`...)
+	b = appendJSONNumber(b, float64(m.chars.extra))
+	b = append(b, `,"missed":`...)
+	b = appendJSONNumber(b, float64(m.chars.missed))
+	b = append(b, `},"spaces":`...)
+	b = appendJSONNumber(b, float64(m.spaces))
+	b = append(b, `,"durationSec":`...)
+	b = appendJSONNumber(b, m.durationSec)
+	return append(b, '}')
+}
+
+func elapsedMinutes(startedAt, at float64) float64 { return jsMax(0, (at-startedAt)/1e3) / 60 }
+
+func (f *fold) metricsFrom(a *analysis, endMs float64) coreMetrics {
+	chars, spaces := f.getChars(a.final)
+	end := endMs
+	if a.final.finished {
+		end = a.final.finishedAt
+	}
+	var durationSec, minutes float64
+	if a.final.started {
+		durationSec = jsMax(0, (end-a.final.startedAt)/1e3)
+		minutes = elapsedMinutes(a.final.startedAt, end)
+	}
+	netChars := float64(f.netCharsOf(a.final))
+	rawChars := float64(chars.correct + chars.incorrect + chars.extra + spaces)
+	m := coreMetrics{chars: chars, spaces: spaces, durationSec: durationSec}
+	if minutes > 0 {
+		m.wpm = netChars / 5 / minutes
+		m.raw = rawChars / 5 / minutes
+	}
+	if a.totalKeys != 0 {
+		m.accuracy = float64(a.correctKeys) / float64(a.totalKeys)
+	}
+	m.consistency = consistencyOf(f.rawPerSecondOf(a, endMs))
+	return m
+}
+
+// --- keyboard.ts ---
+
+const keyIntervalCapMs = 2e3
+
+type charRow struct {
+	presses, errors int64
+	sum             float64
+	n               int64
+}
+
+// charObservationsOf folds the state events once more, recording per typed
+// code unit how often it was pressed, how often wrongly, and the interval
+// since the keystroke before it.
+func (f *fold) charObservationsOf(stateEvents []foldEvent) []CharObservation {
+	byChar := map[uint16]*charRow{}
+	observe := func(c uint16, wrong bool, interval float64, hasInterval bool) {
+		row := byChar[c]
+		if row == nil {
+			row = &charRow{}
+			byChar[c] = row
+		}
+		row.presses++
+		if wrong {
+			row.errors++
+		}
+		if hasInterval && interval >= 0 && interval <= keyIntervalCapMs {
+			row.sum += interval
+			row.n++
+		}
+	}
+	s := f.initialState()
+	prevT, hasPrev := 0.0, false
+	for i := range stateEvents {
+		f.tick()
+		e := &stateEvents[i]
+		s = f.settle(s, e.t)
+		if s.phase == phaseFinished {
+			break
+		}
+		if e.kind == evInsert {
+			target := f.word(s.wordIndex)
+			startPos := len(s.buf.at(s.wordIndex))
+			for k, c := range e.text {
+				pos := startPos + k
+				wrong := !(pos < len(target) && target[pos] == c)
+				observe(c, wrong, e.t-prevT, k == 0 && hasPrev)
+			}
+		}
+		before := s.wordIndex
+		next, refused := f.reduce(s, e)
+		if refused != "" {
+			break
+		}
+		s = next
+		switch {
+		case e.kind == evInsert:
+			prevT, hasPrev = e.t, true
+		case e.kind == evCommit && s.wordIndex > before:
+			observe(' ', false, e.t-prevT, hasPrev)
+			prevT, hasPrev = e.t, true
+		default:
+			hasPrev = false
+		}
+	}
+	units := make([]uint16, 0, len(byChar))
+	for c := range byChar {
+		units = append(units, c)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i] < units[j] })
+	out := make([]CharObservation, 0, len(units))
+	for _, c := range units {
+		row := byChar[c]
+		out = append(out, CharObservation{
+			Char:          goString(u16{c}),
+			Presses:       row.presses,
+			Errors:        row.errors,
+			IntervalSumMs: jsonFloat(row.sum),
+			IntervalCount: row.n,
+		})
+	}
+	return out
+}
+
+// --- mods.ts ---
+
+const modMultiplierCap = 4
+
+// declaration is the run's view-only mods, taken on trust.
+type declaration struct {
+	Blind      opt[bool] `json:"blind"`
+	Fading     opt[bool] `json:"fading"`
+	Flashlight opt[bool] `json:"flashlight"`
+}
+
+var minSpeedMultipliers = map[float64]float64{60: 1.1, 80: 1.25, 100: 1.45}
+
+// modMultiplierV1 multiplies the active mods in activeModsV1's order.
+func modMultiplierV1(g *generation, r *rules, d *declaration) float64 {
+	transformed := !g.emitsRawTokens()
+	product := 1.0
+	apply := func(on bool, multiplier float64) {
+		if on {
+			product *= multiplier
+		}
+	}
+	apply(transformed && g.Punctuation.is(true), 1.1)
+	apply(transformed && g.Numbers.is(true), 1.08)
+	apply(transformed && g.RandomCase.is(true), 1.15)
+	apply(r.nospace, 1.12)
+	apply(r.expert, 1.15)
+	apply(r.master, 1.25)
+	apply(transformed && g.Reverse.is(true), 1.25)
+	if m, ok := minSpeedMultipliers[r.minWpm]; ok && r.minWpm > 0 {
+		product *= m
+	}
+	apply(d.Blind.is(true), 1.3)
+	apply(d.Fading.is(true), 1.35)
+	apply(d.Flashlight.is(true), 1.4)
+	return jsMin(product, modMultiplierCap)
+}
+
+// --- score.ts ---
+
+const (
+	pointsPerKeystroke = 10
+	comboTier          = 25
+	comboStep          = 0.25
+	maxComboMultiplier = 2.5
+	referenceWpm       = 80
+	charsPerWord       = 5
+)
+
+func comboMultiplier(streak int) float64 {
+	mult := 1 + comboStep*math.Floor(float64(streak)/comboTier)
+	if mult > maxComboMultiplier {
+		return maxComboMultiplier
+	}
+	return mult
+}
+
+// scoreState is the scorer's own, much simpler fold: it tracks buffer lengths,
+// not buffers.
+type scoreState struct {
+	base      float64
+	streak    int
+	comboPeak int
+	wordIndex int
+	finished  bool
+	bufLen    []int
+	reached   []int
+}
+
+func grownTo(s []int, i int) []int {
+	for len(s) <= i {
+		s = append(s, 0)
+	}
+	return s
+}
+
+func (st *scoreState) lenAt(i int) int {
+	if i < len(st.bufLen) {
+		return st.bufLen[i]
+	}
+	return 0
+}
+
+func (st *scoreState) reachedAt(i int) int {
+	if i < len(st.reached) {
+		return st.reached[i]
+	}
+	return 0
+}
+
+func (st *scoreState) setLen(i, n int) {
+	st.bufLen = grownTo(st.bufLen, i)
+	st.bufLen[i] = n
+}
+
+func (st *scoreState) reach(i, n int) {
+	if n > st.reachedAt(i) {
+		st.reached = grownTo(st.reached, i)
+		st.reached[i] = n
+	}
+}
+
+func (st *scoreState) credit(correct, firstAttempt bool) {
+	if correct && firstAttempt {
+		st.streak++
+		if st.streak > st.comboPeak {
+			st.comboPeak = st.streak
+		}
+		st.base += pointsPerKeystroke * comboMultiplier(st.streak)
+	} else if !correct {
+		st.streak = 0
+	}
+}
+
+func (f *fold) advanceWord(st *scoreState) {
+	st.wordIndex++
+	if f.countsWords && st.wordIndex >= len(f.words) {
+		st.finished = true
+	}
+}
+
+// unitAt reports whether the code point ch is the single code unit at
+// target[pos] — `target[pos] === char`, where a surrogate pair never equals one
+// unit.
+func unitAt(target u16, pos int, ch u16) bool {
+	return pos >= 0 && pos < len(target) && len(ch) == 1 && target[pos] == ch[0]
+}
+
+func (f *fold) applyInsert(st *scoreState, text u16) {
+	for _, ch := range codePoints(text) {
+		wi := st.wordIndex
+		target := f.word(wi)
+		pos := st.lenAt(wi)
+		st.credit(unitAt(target, pos, ch), pos >= st.reachedAt(wi))
+		nextLen := pos + 1
+		st.setLen(wi, nextLen)
+		st.reach(wi, nextLen)
+		if f.nospace && nextLen >= len(target) {
+			f.advanceWord(st)
+			if st.finished {
+				return
+			}
+		}
+	}
+}
+
+func (f *fold) applyReplace(st *scoreState, e *foldEvent) {
+	wi := st.wordIndex
+	nextLen := e.from + len(e.text) + (st.lenAt(wi) - e.to)
+	st.setLen(wi, nextLen)
+	st.reach(wi, nextLen)
+	if f.nospace && nextLen >= len(f.word(wi)) {
+		f.advanceWord(st)
+	}
+}
+
+func (f *fold) applyDelete(st *scoreState, wordUnit bool) {
+	wi := st.wordIndex
+	bufLen := st.lenAt(wi)
+	switch {
+	case bufLen > 0 && wordUnit:
+		st.setLen(wi, 0)
+	case bufLen > 0:
+		st.setLen(wi, bufLen-1)
+	case wi > 0:
+		st.wordIndex = wi - 1
+		if wordUnit {
+			st.setLen(wi-1, 0)
+		}
+	}
+}
+
+func (f *fold) applyCommit(st *scoreState) {
+	if f.nospace {
+		return
+	}
+	wi := st.wordIndex
+	bufLen := st.lenAt(wi)
+	if bufLen == 0 {
+		return
+	}
+	if bufLen < len(f.word(wi)) {
+		st.streak = 0
+	}
+	f.advanceWord(st)
+}
+
+// applyReplaceV3 credits an IME composition's characters the way inserts are
+// credited, then applies the replace.
+func (f *fold) applyReplaceV3(st *scoreState, e *foldEvent) {
+	if e.source == "ime" {
+		wi := st.wordIndex
+		target := f.word(wi)
+		reached := st.reachedAt(wi)
+		pos := e.from
+		for _, ch := range codePoints(e.text) {
+			st.credit(unitAt(target, pos, ch), pos >= reached)
+			pos++
+		}
+	}
+	f.applyReplace(st, e)
+}
+
+func (f *fold) scoreSteps(stateEvents []foldEvent, v3 bool) scoreState {
+	var st scoreState
+	for i := range stateEvents {
+		if st.finished {
+			break
+		}
+		f.tick()
+		e := &stateEvents[i]
+		switch e.kind {
+		case evInsert:
+			f.applyInsert(&st, e.text)
+		case evReplace:
+			if v3 {
+				f.applyReplaceV3(&st, e)
+			} else {
+				f.applyReplace(&st, e)
+			}
+		case evDelete:
+			f.applyDelete(&st, e.wordUnit)
+		case evCommit:
+			f.applyCommit(&st)
+		}
+	}
+	return st
+}
+
+// scoreEnd is where a v2/v3 score's metrics stop: the settled finish instant
+// — the minSpeed fail instant included — unless the analysis aborted.
+func (f *fold) scoreEnd(a *analysis, lastT float64) float64 {
+	if a.aborted {
+		return lastT
+	}
+	s := f.settle(a.final, lastT)
+	if f.minWpm > 0 && s.phase == phaseRunning {
+		if failAt, ok := f.minSpeedFailInstant(s); ok {
+			s = f.settle(s, failAt)
+		}
+	}
+	if s.finished {
+		return s.finishedAt
+	}
+	return lastT
+}
+
+// scoreJSON is finalizeScore / finalizeScoreV2 / finalizeScoreV3, serialised.
+// modMultiplier is ignored for version 1.
+func (f *fold) scoreJSON(version int16, st *scoreState, m *coreMetrics, modMultiplier float64) []byte {
+	accMultiplier := m.accuracy * m.accuracy
+	timeBonus, hasTimeBonus := 0.0, false
+	if f.countsWords {
+		netChars := float64(m.chars.correct + m.spaces)
+		referenceMinutes := netChars / charsPerWord / referenceWpm
+		actualMinutes := m.durationSec / 60
+		timeBonus, hasTimeBonus = 1, true
+		if actualMinutes > 0 {
+			timeBonus = referenceMinutes / actualMinutes
+		}
+	}
+	bonus := 1.0
+	if hasTimeBonus {
+		bonus = timeBonus
+	}
+	total := jsRound(st.base * accMultiplier * bonus)
+	if version != scoreVersionV1 {
+		total = jsRound(st.base * accMultiplier * bonus * modMultiplier)
+	}
+	b := make([]byte, 0, 160)
+	b = append(b, `{"version":`...)
+	b = appendJSONNumber(b, float64(version))
+	b = append(b, `,"total":`...)
+	b = appendJSONNumber(b, total)
+	b = append(b, `,"base":`...)
+	b = appendJSONNumber(b, st.base)
+	b = append(b, `,"comboPeak":`...)
+	b = appendJSONNumber(b, float64(st.comboPeak))
+	b = append(b, `,"accMultiplier":`...)
+	b = appendJSONNumber(b, accMultiplier)
+	b = append(b, `,"timeBonus":`...)
+	if hasTimeBonus {
+		b = appendJSONNumber(b, timeBonus)
+	} else {
+		b = append(b, "null"...)
+	}
+	if version != scoreVersionV1 {
+		b = append(b, `,"modMultiplier":`...)
+		b = appendJSONNumber(b, modMultiplier)
+	}
+	return append(b, '}')
+}
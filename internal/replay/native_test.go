@@ -0,0 +1,314 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/perf"
+)
+
+// THE PORT IS HELD TO THE BUNDLE, NOT TO ITSELF.
+//
+// Nothing in this file states what a run should score. Every assertion replays
+// the same Input through goja and through the native core and demands the two
+// reports be identical — error text, verdict, reason, every flag's code, score
+// and detail, and the metrics and score JSON byte for byte. goja is in turn held
+// to V8 by the golden vectors, so the chain ends at the browser.
+
+// nativeInput is a vector as the worker would hand it to an engine.
+func nativeInput(t *testing.T, reg *Registry, v vector, scoreVersion int16, armed bool) Input {
+	t.Helper()
+	in := Input{
+		Seed:          v.Payload.Seed,
+		DictHash:      v.Payload.DictHash,
+		Setup:         v.Payload.Setup,
+		Log:           v.Payload.Log,
+		ScoreVersion:  scoreVersion,
+		CanariesArmed: armed,
+	}
+	switch {
+	case v.Quote != nil:
+		in.Quote = &QuoteText{Text: v.Quote.Text, Hash: v.Quote.Hash}
+	case v.Dictionary != nil:
+		body, err := json.Marshal(v.Dictionary)
+		require.NoError(t, err)
+		in.DictBody = body
+	default:
+		body, ok := reg.Body(v.Payload.DictHash)
+		require.True(t, ok, "vector %s: dictionary %s is not published", v.Name, v.Payload.DictHash)
+		in.DictBody = body
+	}
+	return in
+}
+
+// requireSameReplay replays in through both cores and fails on any difference
+// the differential engine would record.
+func requireSameReplay(t *testing.T, core *Core, native *Native, in Input) Result {
+	t.Helper()
+	ctx := context.Background()
+	want, wantErr := core.Replay(ctx, in)
+	got, gotErr := native.Replay(ctx, in)
+	require.False(t, errors.Is(gotErr, errUnmodelled), "the native core declined a run goja judged: %v", wantErr)
+	d := divergenceOf(want, wantErr, got, gotErr)
+	require.Nil(t, d, "native diverged from goja: %+v", d)
+	return want
+}
+
+func TestNativeCoreReplaysEveryGoldenVectorLikeGoja(t *testing.T) {
+	core, reg := sharedDicts(t)
+	native := NewNative(DefaultReplayTimeout)
+	for _, v := range loadVectors(t) {
+		t.Run(v.Name, func(t *testing.T) {
+			// Every formula version and both canary settings, not just the ones
+			// the vector was submitted under: the port must agree with the
+			// bundle on inputs the client never produced as much as on the
+			// ones it did.
+			for _, sv := range []int16{scoreVersionV1, scoreVersionV2, scoreVersionV3} {
+				for _, armed := range []bool{false, true} {
+					requireSameReplay(t, core, native, nativeInput(t, reg, v, sv, armed))
+				}
+			}
+		})
+	}
+}
+
+func TestNativeCoreReplaysThePopulationLikeGoja(t *testing.T) {
+	if testing.Short() {
+		t.Skip("replays a 138-run population through both cores")
+	}
+	core, reg := sharedDicts(t)
+	native := NewNative(DefaultReplayTimeout)
+	runs, _ := loadPopulation(t)
+	require.NotEmpty(t, runs)
+
+	compared := 0
+	for _, run := range runs {
+		body, ok := reg.Body(run.dictHash)
+		if !ok {
+			continue
+		}
+		for _, armed := range []bool{false, true} {
+			requireSameReplay(t, core, native, Input{
+				Seed:          run.seed,
+				DictHash:      run.dictHash,
+				DictBody:      body,
+				Setup:         run.setup,
+				Log:           run.log,
+				ScoreVersion:  run.scoreVersion,
+				CanariesArmed: armed,
+			})
+		}
+		compared++
+	}
+	t.Logf("population: %d runs compared under both canary settings", compared)
+	require.Positive(t, compared)
+}
+
+// The vectors and the population play a handful of languages. Every published
+// dictionary gets a flawless run through both cores, so a script whose case
+// mapping or grapheme split the port gets wrong fails here before a player
+// picks it.
+func TestNativeCoreReplaysEveryLanguageLikeGoja(t *testing.T) {
+	if testing.Short() {
+		t.Skip("replays a run on every published dictionary through both cores")
+	}
+	core, reg := sharedDicts(t)
+	native := NewNative(DefaultReplayTimeout)
+	setup := perf.MustJSON(perf.BuildSetup(perf.SetupSpec{
+		Mode: "words", WordCount: sanityWords, DurationMs: 600_000,
+	}))
+	for _, e := range reg.Catalogue() {
+		t.Run(e.Lang, func(t *testing.T) {
+			body, ok := reg.Body(e.DictHash)
+			require.True(t, ok)
+			words := generateSanityWords(t, core, body, e.DictHash)
+			res := requireSameReplay(t, core, native, Input{
+				Seed:         sanitySeed,
+				DictHash:     e.DictHash,
+				DictBody:     body,
+				Setup:        setup,
+				Log:          typeOut(words),
+				ScoreVersion: scoreVersionV2,
+			})
+			require.Equal(t, verdictValid, res.Verdict, res.Reason)
+		})
+	}
+}
+
+// A real run is a narrow sample of what a client can submit. These are the
+// shapes a tampered or broken client sends, each of which the bundle answers
+// with a specific reason string or CoreError — and the port must give the
+// same answer, not merely a refusal.
+func TestNativeCoreRefusesTamperedLogsLikeGoja(t *testing.T) {
+	core, reg := sharedDicts(t)
+	native := NewNative(DefaultReplayTimeout)
+	base := firstSeededVector(t)
+
+	mutate := func(t *testing.T, edit func(log map[string]any)) json.RawMessage {
+		t.Helper()
+		var log map[string]any
+		require.NoError(t, json.Unmarshal(base.Payload.Log, &log))
+		edit(log)
+		out, err := json.Marshal(log)
+		require.NoError(t, err)
+		return out
+	}
+	events := func(log map[string]any) []any { return log["events"].([]any) }
+	event := func(log map[string]any, i int) map[string]any { return events(log)[i].(map[string]any) }
+
+	cases := map[string]func(log map[string]any){
+		"wrong version":       func(log map[string]any) { log["version"] = 7 },
+		"missing version":     func(log map[string]any) { delete(log, "version") },
+		"seq gap":             func(log map[string]any) { event(log, 3)["seq"] = 99 },
+		"time went backwards": func(log map[string]any) { event(log, 4)["t"] = -1 },
+		"negative start":      func(log map[string]any) { event(log, 0)["t"] = -5 },
+		"truncated":           func(log map[string]any) { log["events"] = events(log)[:len(events(log))/2] },
+		"empty":               func(log map[string]any) { log["events"] = []any{} },
+		"reordered": func(log map[string]any) {
+			ev := events(log)
+			ev[2], ev[3] = ev[3], ev[2]
+		},
+		"pasted": func(log map[string]any) {
+			for _, e := range events(log) {
+				if m := e.(map[string]any); m["kind"] == "insert" {
+					m["kind"], m["source"], m["from"], m["to"] = "replace", "paste", 0, 0
+					break
+				}
+			}
+		},
+		"machine rhythm": func(log map[string]any) {
+			for i, e := range events(log) {
+				e.(map[string]any)["t"] = i * 20
+			}
+		},
+		"impossible speed": func(log map[string]any) {
+			for i, e := range events(log) {
+				e.(map[string]any)["t"] = i * 3
+			}
+		},
+		"long idle tail": func(log map[string]any) {
+			ev := events(log)
+			ev[len(ev)-1].(map[string]any)["t"] = 10_000_000
+		},
+	}
+	for name, edit := range cases {
+		t.Run(name, func(t *testing.T) {
+			in := nativeInput(t, reg, base, base.Payload.ScoreVersion, true)
+			in.Log = mutate(t, edit)
+			requireSameReplay(t, core, native, in)
+		})
+	}
+
+	t.Run("claimed dictionary is not this dictionary", func(t *testing.T) {
+		in := nativeInput(t, reg, base, base.Payload.ScoreVersion, false)
+		in.DictHash = "00000000"
+		requireSameReplay(t, core, native, in)
+	})
+	t.Run("unsupported score version", func(t *testing.T) {
+		requireSameReplay(t, core, native, nativeInput(t, reg, base, 9, false))
+	})
+	t.Run("setup is not an object", func(t *testing.T) {
+		in := nativeInput(t, reg, base, base.Payload.ScoreVersion, false)
+		in.Setup = json.RawMessage(`[1]`)
+		requireSameReplay(t, core, native, in)
+	})
+}
+
+func firstSeededVector(t *testing.T) vector {
+	t.Helper()
+	for _, v := range loadVectors(t) {
+		if v.Quote == nil && v.Dictionary == nil && v.Expect.Verdict == verdictValid {
+			return v
+		}
+	}
+	t.Fatal("no valid seeded golden vector")
+	return vector{}
+}
+
+// The native dictionary fingerprint is the one goja computes: a dictionary the
+// port hashes differently would refuse every run played on it.
+func TestNativeDictVersionMatchesTheBundle(t *testing.T) {
+	core, _ := sharedDicts(t)
+	for _, words := range [][]string{
+		{"the", "quick", "brown", "fox"},
+		{"naïve", "café", "😀", "\t", "\n"},
+		{"ײַ", "אָ"},
+		{},
+	} {
+		want, err := core.DictVersion(words)
+		require.NoError(t, err)
+		units := make([]u16, len(words))
+		for i, w := range words {
+			units[i] = toU16(w)
+		}
+		assert.Equal(t, want, nativeDictVersion(units), "%q", words)
+	}
+}
+
+// A port is a claim about ONE bundle. Re-vendoring corejs/core.bundle.js fails
+// this first; moving the pin is a statement that the native core has been
+// brought up to date and the parity tests above re-run green against it.
+func TestNativeCoreIsPinnedToTheVendoredBundle(t *testing.T) {
+	require.Equal(t, BundleSHA(), nativeBundleSHA,
+		"core.bundle.js changed: port the change to the native core, then move nativeBundleSHA")
+}
+
+// What the port does not model it refuses, and the native engine hands the run
+// to goja instead of inventing an answer.
+func TestNativeEngineFallsBackOnWhatItDoesNotModel(t *testing.T) {
+	core, reg := sharedDicts(t)
+	base := firstSeededVector(t)
+	in := nativeInput(t, reg, base, base.Payload.ScoreVersion, false)
+
+	var setup map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(in.Setup, &setup))
+	setup["generation"] = json.RawMessage(`{"mode":null}`)
+	var err error
+	in.Setup, err = json.Marshal(setup)
+	require.NoError(t, err)
+
+	_, err = NewNative(0).Replay(context.Background(), in)
+	require.ErrorIs(t, err, errUnmodelled)
+
+	engine, err := NewEngine(EngineNative, 0, nil)
+	require.NoError(t, err)
+	want, wantErr := core.Replay(context.Background(), in)
+	got, gotErr := engine.Replay(context.Background(), in)
+	assert.Nil(t, divergenceOf(want, wantErr, got, gotErr))
+}
+
+func TestDifferentialEngineRecordsNoDivergenceOnTheGoldenVectors(t *testing.T) {
+	_, reg := sharedDicts(t)
+	engine, err := NewEngine(EngineDifferential, 0, nil)
+	require.NoError(t, err)
+	for _, v := range loadVectors(t) {
+		res, err := engine.Replay(context.Background(), nativeInput(t, reg, v, v.Payload.ScoreVersion, false))
+		require.NoError(t, err, v.Name)
+		assert.Nil(t, res.Diverged, v.Name)
+	}
+}
+
+// The differential engine's whole value is in the record it leaves when the
+// two cores disagree, so the comparison is exercised against a disagreement
+// made on purpose.
+func TestDivergenceNamesTheFirstFieldThatDiffers(t *testing.T) {
+	a := Result{Verdict: verdictValid, Flags: []Flag{}, Metrics: json.RawMessage(`{"wpm":100}`)}
+	b := a
+	b.Metrics = json.RawMessage(`{"wpm":101}`)
+	d := divergenceOf(a, nil, b, nil)
+	require.NotNil(t, d)
+	assert.Equal(t, "metrics", d.Field)
+	assert.Equal(t, `{"wpm":100}`, d.Goja)
+	assert.Equal(t, `{"wpm":101}`, d.Native)
+
+	d = divergenceOf(a, nil, Result{}, &CoreError{Kind: "GenerationFailed", Message: "x"})
+	require.NotNil(t, d)
+	assert.Equal(t, "error", d.Field)
+
+	assert.Nil(t, divergenceOf(a, nil, a, nil))
+}
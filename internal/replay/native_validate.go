@@ -0,0 +1,379 @@
+package replay
+
+import (
+	"math"
+	"strconv"
+)
+
+// validateLog, ported from the bundle's validate.ts: the structural checks
+// that make a log invalid, the fold that must accept every event, and the
+// plausibility flags a judge weighs. Reasons and flag details are reproduced
+// character for character — they are stored in validation.reason and
+// validation.flags, and an operator greps for them.
+
+// Thresholds are the bundle's DEFAULT_THRESHOLDS; the server never overrides
+// them.
+const (
+	minKeyIntervalMs   = 15
+	uniformToleranceMs = 2
+	uniformFlagRatio   = 0.9
+	afkFlagShare       = 0.5
+	trailingAfkMs      = 1e4
+	afkBucketMs        = 1e3
+
+	superhumanSeverityScale = 500
+	accuracyWeightFloor     = 0.25
+	accuracyWeightExponent  = 4
+)
+
+var burstCeiling = []struct{ durationSec, wpm float64 }{
+	{15, 290}, {30, 275}, {60, 260}, {300, 240}, {600, 230},
+}
+
+func maxBurstWpmFor(durationSec float64) float64 {
+	first := burstCeiling[0]
+	if !(durationSec > first.durationSec) {
+		return first.wpm
+	}
+	for i := 1; i < len(burstCeiling); i++ {
+		prev, next := burstCeiling[i-1], burstCeiling[i]
+		if durationSec <= next.durationSec {
+			span := next.durationSec - prev.durationSec
+			return prev.wpm + (durationSec-prev.durationSec)*(next.wpm-prev.wpm)/span
+		}
+	}
+	return burstCeiling[len(burstCeiling)-1].wpm
+}
+
+func burstAccuracyWeight(accuracy float64) float64 {
+	acc := jsMin(1, jsMax(0, accuracy))
+	return accuracyWeightFloor + (1-accuracyWeightFloor)*jsPow(acc, accuracyWeightExponent)
+}
+
+// afkBetween counts the whole idle seconds of a run: buckets no state event
+// landed in.
+func afkBetween(events []foldEvent, startedAt float64, started bool, endMs float64) (afkMs float64, buckets int) {
+	if !started {
+		return 0, 0
+	}
+	bucketCountF := math.Floor((endMs - startedAt) / afkBucketMs)
+	if !(bucketCountF > 0) {
+		return 0, 0
+	}
+	if bucketCountF > maxRunSeconds {
+		panic(errUnmodelled)
+	}
+	bucketCount := int(bucketCountF)
+	active := make([]bool, bucketCount+1)
+	activeCount := 0
+	for i := range events {
+		e := &events[i]
+		if e.telemetry() {
+			continue
+		}
+		offset := e.t - startedAt
+		if offset < 0 {
+			continue
+		}
+		bucket := 1.0
+		if offset > 0 {
+			bucket = math.Ceil(offset / afkBucketMs)
+		}
+		if bucket > bucketCountF || active[int(bucket)] {
+			continue
+		}
+		active[int(bucket)] = true
+		activeCount++
+	}
+	buckets = bucketCount - activeCount
+	return float64(buckets) * afkBucketMs, buckets
+}
+
+// roundStr is `${Math.round(x)}`.
+func roundStr(x float64) string { return jsNumber(jsRound(x)) }
+
+// validation is validateLog's Ok report, plus what the scorer reuses.
+type validation struct {
+	verdict  string
+	reason   string
+	flags    []Flag
+	metrics  coreMetrics
+	analysis analysis
+}
+
+// validateLog judges a log whose words have already been regenerated. logVersion
+// is absent when the log carries none.
+func (f *fold) validateLog(logVersion opt[float64], events []foldEvent, seed float64, canariesArmed bool) validation {
+	stateEvents := stateEventsOf(events)
+	telemetry := len(events) - len(stateEvents)
+	v := validation{flags: []Flag{}}
+	invalid := func(reason string) validation {
+		v.verdict, v.reason = verdictInvalid, reason
+		return v
+	}
+
+	if !logVersion.is(1) && !logVersion.is(2) {
+		version := "undefined"
+		if logVersion.set {
+			version = jsNumber(logVersion.v)
+		}
+		return invalid("log version " + version + " != 1")
+	}
+	if logVersion.is(1) && telemetry > 0 {
+		return invalid("log version 1 must not contain telemetry events")
+	}
+	for i := range events {
+		if events[i].seq != float64(i+1) {
+			return invalid("seq gap or duplicate at index " + strconv.Itoa(i) + ": expected " +
+				strconv.Itoa(i+1) + ", got " + jsNumber(events[i].seq))
+		}
+		if i > 0 && events[i].t < events[i-1].t {
+			return invalid("time went backwards at seq " + jsNumber(events[i].seq))
+		}
+	}
+	if len(events) > 0 && events[0].t < 0 {
+		return invalid("first event has negative t")
+	}
+	if telemetry > 0 {
+		held := map[string]int{}
+		unpaired := 0
+		for i := range events {
+			e := &events[i]
+			if !e.telemetry() {
+				continue
+			}
+			key := "u" // undefined is a key of its own
+			if e.code.set {
+				key = "s" + e.code.v
+			}
+			switch {
+			case e.kind == evDown:
+				held[key]++
+			case held[key] > 0:
+				held[key]--
+			default:
+				unpaired++
+			}
+		}
+		if unpaired > 0 {
+			v.flags = append(v.flags, Flag{
+				Code:   "unpaired-keyup",
+				Score:  jsMin(1, float64(unpaired)/float64(telemetry)),
+				Detail: strconv.Itoa(unpaired) + " key release(s) without a preceding press",
+			})
+		}
+	}
+	if f.nospace {
+		for i := range events {
+			if events[i].kind == evCommit {
+				return invalid("nospace log must contain no commit events (progression is derived from inserts)")
+			}
+		}
+	}
+
+	startT := 0.0
+	if !f.startGo && len(stateEvents) > 0 {
+		startT = stateEvents[0].t
+	}
+	deadline := startT + f.durationMs
+	if f.timed {
+		for i := range stateEvents {
+			if e := &stateEvents[i]; e.t >= deadline {
+				return invalid("event at seq " + jsNumber(e.seq) + " (t=" + jsNumber(e.t) +
+					") is at/after the deadline " + jsNumber(deadline))
+			}
+		}
+	}
+	final, refused, at := f.foldLog(events, deadline, f.timed)
+	if refused != "" {
+		return invalid("replay rejected event seq " + jsNumber(at) + ": " + refused)
+	}
+	if f.minWpm > 0 && final.phase == phaseRunning {
+		if failAt, ok := f.minSpeedFailInstant(final); ok {
+			final = f.settle(final, failAt)
+		}
+	}
+	// runEnd is finishedAt ?? endMs ?? …; the metrics end stops one step
+	// earlier, at startT.
+	metricsEnd, runEnd := startT, startT
+	if len(stateEvents) > 0 {
+		runEnd = stateEvents[len(stateEvents)-1].t
+	}
+	switch {
+	case final.finished:
+		metricsEnd, runEnd = final.finishedAt, final.finishedAt
+	case f.timed:
+		metricsEnd, runEnd = deadline, deadline
+	}
+	v.analysis = f.analyzeLog(stateEvents)
+	v.metrics = f.metricsFrom(&v.analysis, metricsEnd)
+	m := &v.metrics
+
+	multiGrapheme, pastes := 0, 0
+	var insertTimes []float64
+	for i := range events {
+		e := &events[i]
+		switch e.kind {
+		case evInsert:
+			if len(codePoints(e.text)) > 1 {
+				multiGrapheme++
+			}
+			insertTimes = append(insertTimes, e.t)
+		case evReplace:
+			if e.source == "paste" {
+				pastes++
+			}
+		}
+	}
+	eventCount := jsMax(1, float64(len(events)))
+	if multiGrapheme > 0 {
+		v.flags = append(v.flags, Flag{
+			Code:   "multi-grapheme-insert",
+			Score:  jsMin(1, float64(multiGrapheme)/eventCount),
+			Detail: strconv.Itoa(multiGrapheme) + " insert event(s) carried more than one grapheme",
+		})
+	}
+	if pastes > 0 {
+		v.flags = append(v.flags, Flag{
+			Code:   "paste",
+			Score:  jsMin(1, float64(pastes)/eventCount),
+			Detail: strconv.Itoa(pastes) + " paste event(s)",
+		})
+	}
+
+	if len(insertTimes) >= 3 {
+		intervals := make([]float64, len(insertTimes)-1)
+		for i := range intervals {
+			intervals[i] = insertTimes[i+1] - insertTimes[i]
+		}
+		n := float64(len(intervals))
+		tooFast, sum := 0, 0.0
+		for _, d := range intervals {
+			if d < minKeyIntervalMs {
+				tooFast++
+			}
+			sum += d
+		}
+		if tooFast > 0 {
+			v.flags = append(v.flags, Flag{
+				Code:   "min-interval",
+				Score:  float64(tooFast) / n,
+				Detail: strconv.Itoa(tooFast) + "/" + strconv.Itoa(len(intervals)) + " intervals < 15ms",
+			})
+		}
+		mean := sum / n
+		variance, uniform := 0.0, 0
+		for _, d := range intervals {
+			variance += jsPow(d-mean, 2)
+		}
+		variance /= n
+		for _, d := range intervals {
+			if math.Abs(d-mean) <= uniformToleranceMs {
+				uniform++
+			}
+		}
+		if uniformRatio := float64(uniform) / n; uniformRatio >= uniformFlagRatio {
+			v.flags = append(v.flags, Flag{
+				Code:   "uniform-intervals",
+				Score:  uniformRatio,
+				Detail: roundStr(uniformRatio*100) + "% of intervals within ±2ms of the mean",
+			})
+		}
+		if variance == 0 {
+			v.flags = append(v.flags, Flag{Code: "zero-variance", Score: 1, Detail: "all keystroke intervals identical"})
+		}
+	}
+
+	if ceiling := maxBurstWpmFor(m.durationSec); m.wpm > ceiling {
+		v.flags = append(v.flags, Flag{
+			Code:  "superhuman-burst",
+			Score: jsMin(1, m.wpm/superhumanSeverityScale) * burstAccuracyWeight(m.accuracy),
+			Detail: roundStr(m.wpm) + " wpm over " + roundStr(m.durationSec) + "s (ceiling " +
+				roundStr(ceiling) + ") at " + roundStr(m.accuracy*100) + "% accuracy",
+		})
+	}
+
+	afkMs, afkBuckets := afkBetween(events, final.startedAt, final.started, runEnd)
+	runStart := startT
+	if final.started {
+		runStart = final.startedAt
+	}
+	runMs := jsMax(0, runEnd-runStart)
+	if afkMs > 0 && runMs > 0 {
+		if share := afkMs / runMs; share >= afkFlagShare {
+			v.flags = append(v.flags, Flag{
+				Code:   "afk-heavy",
+				Score:  jsMin(1, share),
+				Detail: strconv.Itoa(afkBuckets) + "s of " + roundStr(runMs/1e3) + "s idle (" + roundStr(share*100) + "%)",
+			})
+		}
+	}
+	if len(stateEvents) > 0 {
+		if tailMs := runEnd - stateEvents[len(stateEvents)-1].t; tailMs >= trailingAfkMs {
+			score := 1.0
+			if runMs > 0 {
+				score = jsMin(1, tailMs/runMs)
+			}
+			v.flags = append(v.flags, Flag{
+				Code:   "trailing-afk",
+				Score:  score,
+				Detail: roundStr(tailMs/1e3) + "s idle after the last keystroke",
+			})
+		}
+	}
+
+	if canariesArmed {
+		f.canaryFlags(&v, stateEvents, seed)
+	}
+	v.verdict = verdictValid
+	return v
+}
+
+// canaryFlags runs the two canary detectors.
+func (f *fold) canaryFlags(v *validation, stateEvents []foldEvent, seed float64) {
+	canaryInserts := 0
+	for i := range stateEvents {
+		if e := &stateEvents[i]; e.kind == evInsert {
+			for _, c := range e.text {
+				if isCanaryUnit(c) {
+					canaryInserts++
+					break
+				}
+			}
+		}
+	}
+	if canaryInserts > 0 {
+		v.flags = append(v.flags, Flag{
+			Code:   "canary-grapheme",
+			Score:  1,
+			Detail: strconv.Itoa(canaryInserts) + " insert event(s) carry an invisible canary codepoint",
+		})
+	}
+	if f.nospace {
+		return
+	}
+	hits := 0
+	s := f.initialState()
+	for i := range stateEvents {
+		f.tick()
+		e := &stateEvents[i]
+		s = f.settle(s, e.t)
+		if e.kind == evCommit {
+			index := s.wordIndex
+			if slot := canarySlot(seed, index, f.word(index)); slot != 0 && len(s.buf.at(index)) == slot {
+				hits++
+			}
+		}
+		var refused string
+		if s, refused = f.reduce(s, e); refused != "" {
+			return
+		}
+	}
+	if hits >= 3 {
+		v.flags = append(v.flags, Flag{
+			Code:   "canary-commit",
+			Score:  jsMin(1, float64(hits-2)*0.25),
+			Detail: strconv.Itoa(hits) + " commit(s) landed exactly on a seed-scheduled canary offset",
+		})
+	}
+}
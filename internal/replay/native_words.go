@@ -0,0 +1,508 @@
+package replay
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// Text generation, ported from the bundle's words.ts, accents.ts and
+// canary.ts. The functions keep the TypeScript names so a reader can hold the
+// two side by side; where a rule is subtle the comment says which line of the
+// original it mirrors, not why the rule exists — that is the TypeScript's job.
+
+// mulberry32 is the core's seeded PRNG. Every arithmetic step is uint32
+// wrap-around, which is exactly what `| 0`, `>>> 0` and Math.imul compute.
+func mulberry32(seed uint32) func() float64 {
+	a := seed
+	return func() float64 {
+		a += 1831565813
+		t := (a ^ a>>15) * (1 | a)
+		t = (t + (t^t>>7)*(61|t)) ^ t
+		return float64(t^t>>14) / 4294967296
+	}
+}
+
+// fnv1a hashes code units, not bytes: the digest of a word list is defined
+// over the JS string.
+func fnv1a(s u16) uint32 {
+	h := uint32(2166136261)
+	for _, c := range s {
+		h ^= uint32(c)
+		h *= 16777619
+	}
+	return h
+}
+
+// nativeDictVersion is dictVersion(words): FNV-1a over the words joined by NUL,
+// as eight hex digits.
+func nativeDictVersion(words []u16) string {
+	h := uint32(2166136261)
+	for i, w := range words {
+		if i > 0 {
+			h *= 16777619 // h ^= 0 is a no-op
+		}
+		for _, c := range w {
+			h ^= uint32(c)
+			h *= 16777619
+		}
+	}
+	return fmt.Sprintf("%08x", h)
+}
+
+// generation is the part of setup.generation the core reads. Optional fields
+// are pointers: absent and false are different values in JavaScript, and the
+// bundle tests some of these with `=== true` and others for truthiness.
+type generation struct {
+	Mode        opt[string]  `json:"mode"`
+	Length      opt[float64] `json:"length"`
+	Punctuation opt[bool]    `json:"punctuation"`
+	Numbers     opt[bool]    `json:"numbers"`
+	RandomCase  opt[bool]    `json:"randomCase"`
+	Reverse     opt[bool]    `json:"reverse"`
+	Lazy        opt[bool]    `json:"lazy"`
+	RawTokens   opt[bool]    `json:"rawTokens"`
+	Language    opt[string]  `json:"language"`
+	TextSource  opt[struct {
+		Kind    opt[string] `json:"kind"`
+		QuoteID opt[string] `json:"quoteId"`
+	}] `json:"textSource"`
+}
+
+func (g *generation) isQuote() bool {
+	return g.TextSource.set && g.TextSource.v.Kind.is(TextSourceQuote)
+}
+
+// emitsRawTokens: the tokens are typed as written — no decoration, no lazy
+// folding, no reversal.
+func (g *generation) emitsRawTokens() bool { return g.RawTokens.is(true) || g.isQuote() }
+
+// targetCount returns how many words a seeded run generates. A mode the core
+// does not know, or a missing length, generates none (or the free-mode
+// default) — the same NaN-driven outcomes the switch produces.
+func (g *generation) targetCount() float64 {
+	length := g.Length.or(math.NaN())
+	switch g.Mode.or("") {
+	case "words", "custom", "quote":
+		return jsMax(1, math.Floor(length))
+	case "time":
+		return jsMax(60, math.Ceil(length*6))
+	case "free":
+		n := math.Floor(length)
+		if n == 0 || math.IsNaN(n) {
+			n = 100
+		}
+		return jsMax(60, n)
+	}
+	return 0
+}
+
+var (
+	sentenceEnd    = []u16{{'.'}, {'?'}, {'!'}}
+	midPunctuation = []u16{{','}, {';'}, {':'}}
+)
+
+func randomInt(rng func() float64, max int) int { return int(math.Floor(rng() * float64(max))) }
+
+func decorate(word u16, g *generation, rng func() float64, capitalizeNext bool) u16 {
+	if g.Numbers.is(true) && rng() < 0.2 {
+		digits := 1 + randomInt(rng, 4)
+		out := make(u16, 0, digits)
+		for range digits {
+			out = append(out, uint16('0'+randomInt(rng, 10)))
+		}
+		return out
+	}
+	out := word
+	if g.RandomCase.is(true) {
+		var cased u16
+		for _, ch := range codePoints(out) {
+			if rng() < 0.5 {
+				cased = append(cased, jsUpper(ch)...)
+			} else {
+				cased = append(cased, jsLower(ch)...)
+			}
+		}
+		out = cased
+	}
+	if capitalizeNext && len(out) > 0 {
+		out = concatU16(jsUpper(out[:1]), out[1:])
+	}
+	if g.Punctuation.is(true) && rng() < 0.25 {
+		end := sentenceEnd[randomInt(rng, len(sentenceEnd))]
+		mid := midPunctuation[randomInt(rng, len(midPunctuation))]
+		if rng() < 0.5 {
+			out = concatU16(out, end)
+		} else {
+			out = concatU16(out, mid)
+		}
+	}
+	return out
+}
+
+func reverseWord(word u16) u16 {
+	cps := codePoints(word)
+	out := make(u16, 0, len(word))
+	for i := len(cps) - 1; i >= 0; i-- {
+		out = append(out, cps[i]...)
+	}
+	return out
+}
+
+// expandEllipsis spells U+2026 as three periods.
+func expandEllipsis(token u16) u16 {
+	n := 0
+	for _, c := range token {
+		if c == 0x2026 {
+			n++
+		}
+	}
+	if n == 0 {
+		return token
+	}
+	out := make(u16, 0, len(token)+2*n)
+	for _, c := range token {
+		if c == 0x2026 {
+			out = append(out, '.', '.', '.')
+		} else {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// quoteTokens is the quote branch of generateWords: every line break, with
+// the spaces around it, becomes "\n ", then the text is split on U+0020 and
+// empty tokens are dropped.
+func quoteTokens(text u16) []u16 {
+	normalized := make(u16, 0, len(text)+8)
+	for i := 0; i < len(text); {
+		j := i
+		for j < len(text) && text[j] == ' ' {
+			j++
+		}
+		if j < len(text) && (text[j] == '\r' || text[j] == '\n') {
+			if text[j] == '\r' && j+1 < len(text) && text[j+1] == '\n' {
+				j++
+			}
+			j++
+			for j < len(text) && text[j] == ' ' {
+				j++
+			}
+			normalized = append(normalized, '\n', ' ')
+			i = j
+			continue
+		}
+		if j > i {
+			// A run of spaces no line break follows: no match can start
+			// anywhere inside it.
+			normalized = append(normalized, text[i:j]...)
+			i = j
+			continue
+		}
+		normalized = append(normalized, text[i])
+		i++
+	}
+	var words []u16
+	start := 0
+	for i := 0; i <= len(normalized); i++ {
+		if i == len(normalized) || normalized[i] == ' ' {
+			if i > start {
+				words = append(words, expandEllipsis(normalized[start:i]))
+			}
+			start = i + 1
+		}
+	}
+	return words
+}
+
+// nativeDict is one parsed dictionary: its words as code units, and the
+// version those words hash to, computed once per dictionary rather than once
+// per call the way the bundle has to.
+type nativeDict struct {
+	name    u16
+	words   []u16
+	version string
+}
+
+// generateWords regenerates a run's target words, or reports why it cannot.
+// dict is nil for a quote run, whose words are text's.
+func generateWords(dict *nativeDict, quoteText u16, dictVersion string, seed float64, g *generation, tick func()) ([]u16, error) {
+	if g.isQuote() {
+		quoteID := g.TextSource.v.QuoteID.or("undefined")
+		actual := nativeDictVersion([]u16{quoteText})
+		if actual != dictVersion {
+			return nil, &CoreError{Kind: "DictVersionMismatch",
+				Message: fmt.Sprintf("quote %s text hash mismatch: context=%s actual=%s", quoteID, dictVersion, actual)}
+		}
+		words := quoteTokens(quoteText)
+		if len(words) == 0 {
+			return nil, &CoreError{Kind: "EmptyQuote", Message: fmt.Sprintf("quote %s has no typeable words", quoteID)}
+		}
+		return words, nil
+	}
+	if len(dict.words) == 0 {
+		return nil, &CoreError{Kind: "EmptyDictionary", Message: `dictionary "` + goString(dict.name) + `" has no words`}
+	}
+	if dict.version != dictVersion {
+		return nil, &CoreError{Kind: "DictVersionMismatch",
+			Message: fmt.Sprintf("dictionary version mismatch: context=%s actual=%s", dictVersion, dict.version)}
+	}
+
+	rng := mulberry32(toUint32(seed))
+	count := g.targetCount()
+	raw := g.emitsRawTokens()
+	lazy := !raw && g.Lazy.is(true)
+	n := len(dict.words)
+	words := make([]u16, 0, int(math.Min(count, 1<<16)))
+	prevIndex := -1
+	capitalizeNext := !raw && g.Punctuation.is(true)
+	for i := 0; float64(i) < count; i++ {
+		tick()
+		index := randomInt(rng, n)
+		if index == prevIndex && n > 1 {
+			index = (index + 1) % n
+		}
+		prevIndex = index
+		base := dict.words[index]
+		if raw {
+			words = append(words, expandEllipsis(base))
+			continue
+		}
+		decorated := decorate(base, g, rng, capitalizeNext)
+		out := decorated
+		if lazy {
+			out = replaceAccents(decorated, g.Language)
+		}
+		if g.Reverse.is(true) {
+			out = reverseWord(out)
+		}
+		words = append(words, expandEllipsis(out))
+		capitalizeNext = false
+		if g.Punctuation.is(true) && len(decorated) > 0 {
+			switch decorated[len(decorated)-1] {
+			case '.', '?', '!':
+				capitalizeNext = true
+			}
+		}
+	}
+	return words, nil
+}
+
+// --- accents.ts ---
+
+type accentRule struct {
+	from []string
+	to   string
+}
+
+var commonAccents = []accentRule{
+	{[]string{"á", "à", "â", "ä", "å", "ã", "ą", "ą́", "ā", "ą̄", "ă"}, "a"},
+	{[]string{"é", "è", "ê", "ë", "ẽ", "ę", "ę́", "ē", "ę̄", "ė", "ě"}, "e"},
+	{[]string{"í", "ì", "î", "ï", "ĩ", "į", "į́", "ī", "į̄", "ı"}, "i"},
+	{[]string{"ó", "ò", "ô", "ö", "ø", "õ", "ō", "ǫ", "ǫ́", "ǭ", "ő"}, "o"},
+	{[]string{"ú", "ù", "û", "ü", "ŭ", "ũ", "ū", "ů", "ű"}, "u"},
+	{[]string{"ń", "ň", "ṇ", "ṅ"}, "n"},
+	{[]string{"ç", "ĉ", "č", "ć"}, "c"},
+	{[]string{"ř", "ŕ", "ṛ"}, "r"},
+	{[]string{"ď", "đ", "ḍ"}, "d"},
+	{[]string{"ť", "ț", "ṭ"}, "t"},
+	{[]string{"ṃ"}, "m"},
+	{[]string{"æ"}, "ae"},
+	{[]string{"œ"}, "oe"},
+	{[]string{"ẅ", "ŵ"}, "w"},
+	{[]string{"ĝ", "ğ", "g̃"}, "g"},
+	{[]string{"ĥ"}, "h"},
+	{[]string{"ĵ"}, "j"},
+	{[]string{"ŝ", "ś", "š", "ș", "ş", "ṣ"}, "s"},
+	{[]string{"ß"}, "ss"},
+	{[]string{"ż", "ź", "ž"}, "z"},
+	{[]string{"ÿ", "ỹ", "ý", "ŷ"}, "y"},
+	{[]string{"ł", "ľ", "ĺ"}, "l"},
+	{[]string{"þ"}, "th"},
+	{[]string{"ё"}, "е"},
+	{[]string{"ά"}, "α"},
+	{[]string{"έ"}, "ε"},
+	{[]string{"ί"}, "ι"},
+	{[]string{"ύ"}, "υ"},
+	{[]string{"ό"}, "ο"},
+	{[]string{"ή"}, "η"},
+	{[]string{"ώ"}, "ω"},
+	{[]string{"أ", "إ", "آ"}, "ا"},
+	{[]string{"ً", "ٌ", "ٍ", "َ", "ُ", "ِ", "ّ", "ْ"}, ""},
+}
+
+// languageAccents is LANGUAGE_ACCENTS, in its key order: the first pack whose
+// name matches the language wins.
+var languageAccents = []struct {
+	name  string
+	rules []accentRule
+}{
+	{"german", []accentRule{
+		{[]string{"ä"}, "ae"},
+		{[]string{"ö"}, "oe"},
+		{[]string{"ü"}, "ue"},
+	}},
+	{"pinyin", []accentRule{
+		{[]string{"ā", "á", "ǎ", "à"}, "a"},
+		{[]string{"ō", "ó", "ǒ", "ò"}, "o"},
+		{[]string{"ē", "é", "ě", "è"}, "e"},
+		{[]string{"ī", "í", "ǐ", "ì"}, "i"},
+		{[]string{"ū", "ú", "ǔ", "ù"}, "u"},
+		{[]string{"ü", "ǖ", "ǘ", "ǚ", "ǜ"}, "v"},
+	}},
+	{"quenya", []accentRule{
+		{[]string{"ä", "á"}, "a"},
+		{[]string{"ö", "ó"}, "o"},
+		{[]string{"ë", "é"}, "e"},
+		{[]string{"í"}, "i"},
+		{[]string{"Ú", "ú"}, "u"},
+		{[]string{"χ"}, "x"},
+		{[]string{"þ"}, "p"},
+	}},
+	{"serbian_latin", []accentRule{{[]string{"đ"}, "dj"}}},
+	{"vietnamese", []accentRule{
+		{[]string{"á", "à", "ă", "ắ", "ằ", "ẵ", "ẳ", "â", "ấ", "ầ", "ẫ", "ẩ", "ã", "ả", "ạ", "ặ", "ậ"}, "a"},
+		{[]string{"đ"}, "d"},
+		{[]string{"é", "è", "ê", "ế", "ề", "ễ", "ể", "ẽ", "ẻ", "ẹ", "ệ"}, "e"},
+		{[]string{"í", "ì", "ĩ", "ỉ", "ị"}, "i"},
+		{[]string{"ó", "ò", "ô", "ố", "ồ", "ỗ", "ổ", "õ", "ỏ", "ơ", "ớ", "ờ", "ỡ", "ở", "ợ", "ọ", "ộ"}, "o"},
+		{[]string{"ú", "ù", "ũ", "ủ", "ư", "ứ", "ừ", "ữ", "ử", "ự", "ụ"}, "u"},
+		{[]string{"ý", "ỳ", "ỹ", "ỷ", "ỵ"}, "y"},
+	}},
+	{"yiddish", []accentRule{
+		{[]string{"אַ", "אָ"}, "א"},
+		{[]string{"בּ", "בֿ"}, "ב"},
+		{[]string{"וּ", "וֹ"}, "ו"},
+		{[]string{"יִ"}, "י"},
+		{[]string{"כּ"}, "כ"},
+		{[]string{"פּ", "פֿ"}, "פ"},
+		{[]string{"שׂ"}, "ש"},
+		{[]string{"תּ"}, "ת"},
+		{[]string{"ײַ", "ײ"}, "יי"},
+		{[]string{"ױ"}, "וי"},
+		{[]string{"װ"}, "וו"},
+	}},
+}
+
+// languageMatches: the pack's name, or the name followed by an underscore.
+func languageMatches(name string, lang opt[string]) bool {
+	if !lang.set {
+		return false
+	}
+	l := lang.v
+	return l == name || (len(l) > len(name) && l[:len(name)] == name && l[len(name)] == '_')
+}
+
+type accentTable struct {
+	bySource  map[string]u16
+	maxLength int
+}
+
+var (
+	accentTablesMu sync.Mutex
+	accentTables   = map[string]*accentTable{}
+)
+
+// accentTableFor compiles, once per language, the lookup replaceAccents walks.
+// Later rules overwrite earlier ones for the same source, as Map.set does.
+func accentTableFor(lang opt[string]) *accentTable {
+	key := lang.or("")
+	accentTablesMu.Lock()
+	defer accentTablesMu.Unlock()
+	if t, ok := accentTables[key]; ok {
+		return t
+	}
+	packs := [][]accentRule{commonAccents}
+	for _, p := range languageAccents {
+		if languageMatches(p.name, lang) {
+			packs = append(packs, p.rules)
+			break
+		}
+	}
+	t := &accentTable{bySource: map[string]u16{}}
+	for _, pack := range packs {
+		for _, rule := range pack {
+			for _, src := range rule.from {
+				s := toU16(src)
+				t.bySource[u16Key(jsLower(s))] = toU16(rule.to)
+				t.maxLength = max(t.maxLength, len(s))
+			}
+		}
+	}
+	accentTables[key] = t
+	return t
+}
+
+func isUpperUnit(c uint16) bool {
+	unit := u16{c}
+	return !equalU16(unit, jsLower(unit))
+}
+
+func replaceAccents(word u16, lang opt[string]) u16 {
+	t := accentTableFor(lang)
+	var out u16
+	replaced := false
+	for i := 0; i < len(word); {
+		var replacement u16
+		found := false
+		length := min(t.maxLength, len(word)-i)
+		for ; length > 0; length-- {
+			if replacement, found = t.bySource[u16Key(jsLower(word[i:i+length]))]; found {
+				break
+			}
+		}
+		if !found {
+			if replaced {
+				out = append(out, word[i])
+			}
+			i++
+			continue
+		}
+		if !replaced {
+			out = append(make(u16, 0, len(word)), word[:i]...)
+			replaced = true
+		}
+		for j, r := range replacement {
+			if i+j < len(word) && isUpperUnit(word[i+j]) {
+				out = append(out, jsUpper(u16{r})...)
+			} else {
+				out = append(out, r)
+			}
+		}
+		i += length
+	}
+	if !replaced {
+		return word
+	}
+	return out
+}
+
+// --- canary.ts / normalize.ts ---
+
+// spaceUnits is normalize.ts's SPACE_CHARS; every entry is one code unit.
+var spaceUnits = map[uint16]bool{
+	' ': true, 0xA0: true, 0x1680: true, 0x2002: true, 0x2003: true, 0x2004: true, 0x2007: true,
+	0x2008: true, 0x2009: true, 0x200A: true, 0x200B: true, 0x202F: true, 0x3000: true, 0xFEFF: true,
+}
+
+func isCanaryUnit(c uint16) bool { return c >= 0x2061 && c <= 0x2064 }
+
+// canarySlot is canaryAt's slot, or 0 when the word carries no canary. The
+// grapheme it would draw is never read by the detectors, so it is not drawn.
+func canarySlot(seed float64, wordIndex int, word u16) int {
+	if len(word) < 4 {
+		return 0
+	}
+	for _, c := range word {
+		if isSurrogate(c) || c == '\t' || c == '\n' || spaceUnits[c] || isCanaryUnit(c) {
+			return 0
+		}
+	}
+	rng := mulberry32(fnv1a(toU16(jsNumber(seed) + ":canary:" + strconv.Itoa(wordIndex))))
+	if rng() >= 0.12 {
+		return 0
+	}
+	return 1 + int(math.Floor(rng()*float64(len(word)-1)))
+}
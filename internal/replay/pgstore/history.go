@@ -0,0 +1,76 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/typemore/typemore-server/internal/replay"
+	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/replay/replaydb"
+)
+
+// HistoryWindow is how many of an account's most recent judged runs its
+// baseline covers. A constant rather than a knob: the server and `replayctl
+// calibrate` must measure a run against the same past, or the dry run stops
+// forecasting the worker.
+const HistoryWindow = 50
+
+// RhythmReader reads the per-key side of a player's history from the
+// keyboard projection: the account's profile, and the judged run's own
+// observations folded onto the same keys. Declared here, at the consumer, and
+// implemented by internal/keyboard/pgstore — the domain that owns
+// user_keyboard_profile and keyboard_projected_runs and knows the layouts.
+type RhythmReader interface {
+	Rhythm(ctx context.Context, runID uuid.UUID, observations []replay.CharObservation) (profile, run policy.Rhythm, err error)
+}
+
+// History implements replay.HistorySource against Postgres.
+//
+// It reads through the pool rather than the verdict transaction, and it can
+// afford to: everything it reads is other runs' settled verdicts and an
+// aggregate those verdicts maintain. The one row of the profile this run could
+// have touched is the run's own contribution, which RhythmReader takes back
+// out.
+type History struct {
+	q      *replaydb.Queries
+	rhythm RhythmReader
+}
+
+// Compile-time check that History satisfies the consumer interface.
+var _ replay.HistorySource = (*History)(nil)
+
+// NewHistory builds the history source. rhythm may be nil, which compares
+// speed and past suspicion but never rhythm.
+func NewHistory(pool *pgxpool.Pool, rhythm RhythmReader) *History {
+	return &History{q: replaydb.New(pool), rhythm: rhythm}
+}
+
+// History reads one run's baseline.
+func (h *History) History(ctx context.Context, runID uuid.UUID, observations []replay.CharObservation) (replay.History, error) {
+	row, err := h.q.GetPlayerBaseline(ctx, replaydb.GetPlayerBaselineParams{
+		RunID:      runID,
+		WindowSize: HistoryWindow,
+	})
+	if err != nil {
+		return replay.History{}, fmt.Errorf("replay/pgstore: baseline for run %s: %w", runID, err)
+	}
+	out := replay.History{Baseline: policy.Baseline{
+		Runs:          int(row.Runs),
+		WPM:           policy.Spread{Mean: row.WpmMean, StdDev: row.WpmStddev, Max: row.WpmMax},
+		Accuracy:      policy.Spread{Mean: row.AccuracyMean, StdDev: row.AccuracyStddev, Max: row.AccuracyMax},
+		Judged:        int(row.Judged),
+		Reviewed:      int(row.Reviewed),
+		SuspicionMean: row.SuspicionMean,
+	}}
+	if h.rhythm != nil {
+		profile, run, err := h.rhythm.Rhythm(ctx, runID, observations)
+		if err != nil {
+			return replay.History{}, err
+		}
+		out.Baseline.Rhythm, out.Rhythm = profile, run
+	}
+	return out, nil
+}
@@ -0,0 +1,166 @@
+package pgstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/typemore/typemore-server/internal/replay"
+	"github.com/typemore/typemore-server/internal/replay/replaydb"
+)
+
+// MatchQueue implements replay.MatchQueue and replay.MatchResultsReader against
+// Postgres. It is a separate type from Queue because a match has no projector:
+// nothing downstream reads match verdicts yet, and a Queue's projectors are
+// keyed on run ids a seat does not have.
+type MatchQueue struct {
+	pool *pgxpool.Pool
+	q    *replaydb.Queries
+}
+
+// Compile-time checks that MatchQueue satisfies both consumer interfaces.
+var (
+	_ replay.MatchQueue         = (*MatchQueue)(nil)
+	_ replay.MatchResultsReader = (*MatchQueue)(nil)
+)
+
+// NewMatchQueue builds a MatchQueue from a pgx pool.
+func NewMatchQueue(pool *pgxpool.Pool) *MatchQueue {
+	return &MatchQueue{pool: pool, q: replaydb.New(pool)}
+}
+
+// ProcessMatchBatch claims unvalidated matches with FOR UPDATE SKIP LOCKED,
+// judges each, writes every seat's verdict and status, marks the match
+// validated and commits — ProcessBatch's protocol with the match as the unit.
+func (m *MatchQueue) ProcessMatchBatch(ctx context.Context, limit int32, judge func(context.Context, replay.PendingMatch) []replay.SeatVerdict) (int, error) {
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("replay/pgstore: begin: %w", err)
+	}
+	// As in inTx: a no-op after commit, and what hands the claimed matches back
+	// to the queue on any early return.
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qtx := m.q.WithTx(tx)
+	headers, err := qtx.ClaimUnvalidatedMatches(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("replay/pgstore: claim matches: %w", err)
+	}
+	for _, h := range headers {
+		seats, err := qtx.ListMatchSeats(ctx, h.ID)
+		if err != nil {
+			return 0, fmt.Errorf("replay/pgstore: list seats of match %s: %w", h.ID, err)
+		}
+		pending := replay.PendingMatch{
+			ID:       h.ID,
+			Settings: h.Settings,
+			Seed:     h.Seed,
+			DictHash: h.DictHash,
+			GoAt:     h.GoAt,
+			EndedAt:  h.EndedAt,
+			Seats:    make([]replay.MatchSeat, len(seats)),
+		}
+		for i, s := range seats {
+			pending.Seats[i] = replay.MatchSeat{
+				ID:          s.ID,
+				PlayerID:    s.PlayerID,
+				Freemods:    s.Freemods,
+				Log:         s.Log,
+				FinalStatus: s.FinalStatus,
+			}
+		}
+
+		for _, v := range judge(ctx, pending) {
+			if err := qtx.InsertMatchRunVerdict(ctx, toMatchVerdictParams(h.ID, v)); err != nil {
+				return 0, fmt.Errorf("replay/pgstore: write verdict for seat %s: %w", v.SeatID, err)
+			}
+			if err := qtx.SetMatchRunStatus(ctx, replaydb.SetMatchRunStatusParams{
+				ID:     v.SeatID,
+				Status: v.Status,
+			}); err != nil {
+				return 0, fmt.Errorf("replay/pgstore: apply status for seat %s: %w", v.SeatID, err)
+			}
+		}
+		if err := qtx.MarkMatchValidated(ctx, h.ID); err != nil {
+			return 0, fmt.Errorf("replay/pgstore: mark match %s validated: %w", h.ID, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("replay/pgstore: commit: %w", err)
+	}
+	return len(headers), nil
+}
+
+func toMatchVerdictParams(matchID string, v replay.SeatVerdict) replaydb.InsertMatchRunVerdictParams {
+	var policy *int16
+	if v.PolicyVersion > 0 {
+		policy = &v.PolicyVersion
+	}
+	return replaydb.InsertMatchRunVerdictParams{
+		MatchRunID:    v.SeatID,
+		MatchID:       matchID,
+		ServerMetrics: v.ServerMetrics,
+		ServerScore:   v.ServerScore,
+		Score:         v.Score,
+		Placement:     v.Placement,
+		Validation:    v.Validation,
+		BundleSha:     v.BundleSHA,
+		PolicyVersion: policy,
+		LastError:     v.LastError,
+	}
+}
+
+// MatchResults reads one match's published results. A match that does not
+// exist is replay.ErrMatchNotFound; one the worker has not reached yet is a
+// normal answer with every seat still pending.
+func (m *MatchQueue) MatchResults(ctx context.Context, id string) (replay.MatchResults, error) {
+	h, err := m.q.GetMatchResultsHeader(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return replay.MatchResults{}, replay.ErrMatchNotFound
+	}
+	if err != nil {
+		return replay.MatchResults{}, fmt.Errorf("replay/pgstore: read match %s: %w", id, err)
+	}
+	rows, err := m.q.ListMatchResults(ctx, id)
+	if err != nil {
+		return replay.MatchResults{}, fmt.Errorf("replay/pgstore: read results of match %s: %w", id, err)
+	}
+
+	out := replay.MatchResults{
+		ID:          h.ID,
+		Name:        h.Name,
+		Settings:    h.Settings,
+		GoAt:        h.GoAt,
+		EndedAt:     h.EndedAt,
+		ValidatedAt: h.ValidatedAt,
+		Seats:       make([]replay.MatchResultSeat, len(rows)),
+	}
+	for i, r := range rows {
+		seat := replay.MatchResultSeat{
+			PlayerID:    r.PlayerID,
+			Nick:        r.Nick,
+			FinalStatus: r.FinalStatus,
+			Status:      r.Status,
+			Score:       r.Score,
+			Placement:   r.Placement,
+		}
+		// The two headline numbers, read out of the core's own metrics. A
+		// document that does not decode leaves them absent rather than failing
+		// the whole page over one seat.
+		if len(r.ServerMetrics) > 0 {
+			var metrics struct {
+				WPM      *float64 `json:"wpm"`
+				Accuracy *float64 `json:"accuracy"`
+			}
+			if json.Unmarshal(r.ServerMetrics, &metrics) == nil {
+				seat.WPM, seat.Accuracy = metrics.WPM, metrics.Accuracy
+			}
+		}
+		out.Seats[i] = seat
+	}
+	return out, nil
+}
@@ -7,6 +7,7 @@ package pgstore
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -32,6 +33,24 @@ type Projector interface {
 	ProjectRun(ctx context.Context, tx pgx.Tx, runID uuid.UUID) error
 }
 
+// Projectors fans one status change out to several read models, in order,
+// inside the caller's transaction — today the board and TP, which are two
+// projections of the same eligible runs. The first failure is returned and the
+// caller's rollback takes every projection already applied with it. It
+// satisfies any consumer's copy of the Projector interface, which is how the
+// composition root hands one list to the worker and to the override path alike.
+type Projectors []Projector
+
+// ProjectRun runs every projector in turn.
+func (ps Projectors) ProjectRun(ctx context.Context, tx pgx.Tx, runID uuid.UUID) error {
+	for _, p := range ps {
+		if err := p.ProjectRun(ctx, tx, runID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // KeyboardProjector maintains the user_keyboard_profile aggregates inside the
 // same verdict transaction, from the observations the core just extracted —
 // the same seam pattern as Projector, for the same reason: "accepted" and
@@ -75,17 +94,31 @@ func (q *Queue) WithKeyboard(kb KeyboardProjector) *Queue {
 // started from, and a concurrent worker's SKIP LOCKED walks straight past the
 // rows this one holds. The cost is transaction duration, which is bounded by
 // batchSize × the per-run interrupt budget. See docs/REPLAY.md.
-func (q *Queue) ProcessBatch(ctx context.Context, limit int32, decide func(context.Context, replay.PendingRun) replay.Decision) (int, error) {
+//
+// The claim is one scan per lane, in the caller's order, each asking only for
+// what the batch still has room for; a full batch skips the scans behind it.
+func (q *Queue) ProcessBatch(ctx context.Context, limit int32, lanes []string, decide func(context.Context, replay.PendingRun) replay.Decision) (int, error) {
+	if lanes == nil {
+		lanes = replay.Lanes
+	}
 	return q.inTx(ctx, decide, func(qtx *replaydb.Queries) ([]replay.PendingRun, error) {
-		rows, err := qtx.ClaimPendingRuns(ctx, limit)
-		if err != nil {
-			return nil, err
-		}
-		out := make([]replay.PendingRun, len(rows))
-		for i := range rows {
-			out[i] = toPendingRun(rows[i].ID, rows[i].Seed, rows[i].DictHash, rows[i].ScoreVersion,
-				rows[i].Setup, rows[i].ClientMetrics, rows[i].ClientScore, rows[i].Log, rows[i].Attempts,
-				rows[i].CreatedAt)
+		var out []replay.PendingRun
+		for _, lane := range lanes {
+			room := limit - int32(len(out))
+			if room <= 0 {
+				break
+			}
+			rows, err := qtx.ClaimPendingRuns(ctx, replaydb.ClaimPendingRunsParams{Lane: lane, RowLimit: room})
+			if err != nil {
+				return nil, err
+			}
+			for i := range rows {
+				run := toPendingRun(rows[i].ID, rows[i].Seed, rows[i].DictHash, rows[i].ScoreVersion,
+					rows[i].Setup, rows[i].ClientMetrics, rows[i].ClientScore, rows[i].Log, rows[i].Attempts,
+					rows[i].CreatedAt)
+				run.Lane = rows[i].Lane
+				out = append(out, run)
+			}
 		}
 		return out, nil
 	})
@@ -116,6 +149,31 @@ func (q *Queue) ProcessStalePolicyBatch(ctx context.Context, policyVersion int16
 	})
 }
 
+// ProcessRecordedBundleBatch re-judges runs behind policyVersion on the bundle
+// each was judged with, for as many of those bundles as the caller holds. The
+// claimed bundle_sha travels on the run as ReplayWith and comes back on the
+// decision unchanged, so a pass moves policy_version and nothing else.
+func (q *Queue) ProcessRecordedBundleBatch(ctx context.Context, policyVersion int16, bundles []string, limit int32, decide func(context.Context, replay.PendingRun) replay.Decision) (int, error) {
+	return q.inTx(ctx, decide, func(qtx *replaydb.Queries) ([]replay.PendingRun, error) {
+		rows, err := qtx.ClaimStalePolicyRunsOnRecordedBundles(ctx, replaydb.ClaimStalePolicyRunsOnRecordedBundlesParams{
+			PolicyVersion: &policyVersion,
+			Bundles:       bundles,
+			RowLimit:      limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out := make([]replay.PendingRun, len(rows))
+		for i := range rows {
+			out[i] = toPendingRun(rows[i].ID, rows[i].Seed, rows[i].DictHash, rows[i].ScoreVersion,
+				rows[i].Setup, rows[i].ClientMetrics, rows[i].ClientScore, rows[i].Log, rows[i].Attempts,
+				rows[i].CreatedAt)
+			out[i].ReplayWith = rows[i].BundleSha
+		}
+		return out, nil
+	})
+}
+
 // inTx is the shared unit of work behind both batch operations: one
 // transaction, one claim, one decision per row, one commit.
 func (q *Queue) inTx(
@@ -138,6 +196,19 @@ func (q *Queue) inTx(
 	}
 	for i := range runs {
 		d := decide(ctx, runs[i])
+		// A transient failure being retried is not a decision: the run stays
+		// pending with no verdict row, so there is nothing to project either.
+		if d.Status == replay.StatusPending {
+			if err := qtx.DeferRun(ctx, replaydb.DeferRunParams{
+				ID:           runs[i].ID,
+				Attempts:     d.Attempts,
+				LastError:    d.LastError,
+				RetryAfterMs: d.RetryAfter.Milliseconds(),
+			}); err != nil {
+				return 0, fmt.Errorf("replay/pgstore: defer run %s: %w", runs[i].ID, err)
+			}
+			continue
+		}
 		// One decision, two tables, one transaction: the verdict payload lands
 		// in run_verdicts, the lifecycle half (status, retry bookkeeping) on
 		// runs. Committing them together is the invariant
@@ -146,10 +217,11 @@ func (q *Queue) inTx(
 			return 0, fmt.Errorf("replay/pgstore: write verdict for run %s: %w", runs[i].ID, err)
 		}
 		if err := qtx.ApplyRunOutcome(ctx, replaydb.ApplyRunOutcomeParams{
-			ID:        runs[i].ID,
-			Status:    d.Status,
-			Attempts:  d.Attempts,
-			LastError: d.LastError,
+			ID:         runs[i].ID,
+			Status:     d.Status,
+			Attempts:   d.Attempts,
+			LastError:  d.LastError,
+			DeadLetter: d.DeadLetter,
 		}); err != nil {
 			return 0, fmt.Errorf("replay/pgstore: apply outcome for run %s: %w", runs[i].ID, err)
 		}
@@ -208,7 +280,7 @@ func toVerdictParams(id uuid.UUID, d replay.Decision) replaydb.UpsertRunVerdictP
 	if d.PolicyVersion > 0 {
 		policy = &d.PolicyVersion
 	}
-	return replaydb.UpsertRunVerdictParams{
+	p := replaydb.UpsertRunVerdictParams{
 		RunID:         id,
 		ServerMetrics: d.ServerMetrics,
 		ServerScore:   d.ServerScore,
@@ -216,6 +288,11 @@ func toVerdictParams(id uuid.UUID, d replay.Decision) replaydb.UpsertRunVerdictP
 		BundleSha:     bundle,
 		PolicyVersion: policy,
 	}
+	if s := d.Shadow; s != nil {
+		status := string(s.Status)
+		p.ShadowLabel, p.ShadowStatus, p.ShadowValidation = &s.Label, &status, s.Validation
+	}
+	return p
 }
 
 // ListForCalibration reads judged runs without locking or writing anything —
@@ -238,3 +315,72 @@ func (q *Queue) ListForCalibration(ctx context.Context, limit int32) ([]replay.C
 	}
 	return out, nil
 }
+
+// StoredRun reads one run and the verdict it carries, for `replayctl explain`
+// and the admin explain endpoint. An operator read like ListForCalibration:
+// nothing is locked, and the worker never sees it.
+func (q *Queue) StoredRun(ctx context.Context, id uuid.UUID) (replay.StoredRun, error) {
+	r, err := q.q.GetRunForExplain(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return replay.StoredRun{}, replay.ErrRunNotFound
+	}
+	if err != nil {
+		return replay.StoredRun{}, fmt.Errorf("replay/pgstore: read run %s: %w", id, err)
+	}
+	return replay.StoredRun{
+		PendingRun: toPendingRun(r.ID, r.Seed, r.DictHash, r.ScoreVersion,
+			r.Setup, r.ClientMetrics, r.ClientScore, r.Log, r.Attempts, r.CreatedAt),
+		Status:        r.Status,
+		Validation:    r.Validation,
+		BundleSHA:     r.BundleSha,
+		PolicyVersion: r.PolicyVersion,
+	}, nil
+}
+
+// DeadLetters lists the runs the worker stopped retrying, oldest first — the
+// input to `replayctl dead-letters`. Like ListForCalibration it is an operator
+// read and deliberately not part of replay.Queue.
+func (q *Queue) DeadLetters(ctx context.Context, limit int32) ([]replay.DeadLetter, error) {
+	rows, err := q.q.ListDeadLetters(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("replay/pgstore: list dead letters: %w", err)
+	}
+	out := make([]replay.DeadLetter, len(rows))
+	for i, r := range rows {
+		out[i] = replay.DeadLetter{
+			RunID:     r.ID,
+			UserID:    r.UserID,
+			Mode:      r.Mode,
+			Lang:      r.Lang,
+			Reason:    r.Reason,
+			Attempts:  r.Attempts,
+			CreatedAt: r.CreatedAt,
+		}
+		if r.LastError != nil {
+			out[i].LastError = *r.LastError
+		}
+		if r.DeadLetteredAt != nil {
+			out[i].DeadLetteredAt = *r.DeadLetteredAt
+		}
+	}
+	return out, nil
+}
+
+// ReleaseDeadLetters hands dead letters back to the queue as new work and
+// returns the ids it released. No ids releases the oldest, up to limit; ids
+// that are not dead letters, or that a human has overridden, are skipped
+// rather than refused, so the caller compares what it asked for with what came
+// back.
+//
+// No projector runs: a dead letter is flagged, so it holds no board slot and
+// no keyboard contribution to take back.
+func (q *Queue) ReleaseDeadLetters(ctx context.Context, ids []uuid.UUID, limit int32) ([]uuid.UUID, error) {
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	released, err := q.q.ReleaseDeadLetters(ctx, replaydb.ReleaseDeadLettersParams{Ids: ids, RowLimit: limit})
+	if err != nil {
+		return nil, fmt.Errorf("replay/pgstore: release dead letters: %w", err)
+	}
+	return released, nil
+}
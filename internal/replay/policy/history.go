@@ -0,0 +1,212 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Cross-run detectors: what a run looks like against the player's OWN past
+// (docs/REPLAY.md, "Player history").
+//
+// They sit on the open side of the seam for the same reason the core's
+// detectors do. What they measure — a wpm far above everything this account
+// has done, a per-key rhythm that is not this account's — is not a secret, and
+// pretending it was would be the same pretence as hiding validate.ts. What the
+// server DOES about a history flag is a weight, and the weights stay behind
+// the build tag with the rest of the answer key.
+//
+// Every flag here is an ordinary Flag. A judge that has never heard of these
+// codes reports them as unknown and weighs them at nothing, which is why this
+// layer plugs in without a single change to the Judge interface or to what
+// policytest asks of one.
+
+// History flag codes. Prefixed so nobody reading an audit document mistakes
+// one for something the core raised.
+const (
+	// FlagHistoryWPMJump is a run far faster than the account's own recent
+	// runs in the same language: above its best, and well above its mean by
+	// both an absolute and a spread-relative margin.
+	FlagHistoryWPMJump = "history-wpm-jump"
+	// FlagHistoryRhythm is a run whose per-key timing SHAPE does not match the
+	// account's keyboard profile. Speed is divided out first, so a good day
+	// does not read as somebody else's hands.
+	FlagHistoryRhythm = "history-rhythm"
+	// FlagHistoryRepeat is an account whose recent runs were routed to review
+	// on suspicion, raised only beside some other evidence on this run. Past
+	// suspicion is context for a present signal, never a signal on its own.
+	FlagHistoryRepeat = "history-repeat"
+)
+
+// HistoryFlagCodes lists every code HistoryFlags can raise.
+var HistoryFlagCodes = []string{FlagHistoryWPMJump, FlagHistoryRhythm, FlagHistoryRepeat}
+
+// The detectors' floors. Starting points, not calibrated numbers: there is no
+// population judged with history yet, and `replayctl calibrate` prints what
+// each would have raised on the runs there are.
+const (
+	// MinBaselineRuns is how much past an account needs before it has a
+	// baseline at all. Below it, every history detector abstains: ten runs is
+	// a player who has settled in, and anything less compares a run with a
+	// warm-up.
+	MinBaselineRuns = 10
+	// wpmJumpFloor is how far above its own mean a run has to land before it
+	// is a jump. wpmJumpSpan is the distance beyond that to full severity, so
+	// a run 60 wpm above the account's mean is a maximally severe jump.
+	wpmJumpFloor = 20.0
+	wpmJumpSpan  = 40.0
+	// wpmJumpSigma keeps a naturally erratic account from raising the flag on
+	// an ordinary good day: the jump must also clear this many of its own
+	// standard deviations.
+	wpmJumpSigma = 3.0
+	// A key takes part in the rhythm comparison when the run pressed it at
+	// least rhythmRunSamples times and the profile holds rhythmBaseSamples
+	// intervals for it; rhythmMinKeys such keys are needed for a shape.
+	rhythmRunSamples  = 3
+	rhythmBaseSamples = 30
+	rhythmMinKeys     = 8
+	// rhythmFloor is the mean |log ratio| per key that honest variation stays
+	// under; rhythmSpan is the distance beyond it to full severity.
+	rhythmFloor = 0.25
+	rhythmSpan  = 0.35
+)
+
+// Spread summarises one metric over the baseline's runs.
+type Spread struct {
+	Mean   float64
+	StdDev float64
+	Max    float64
+}
+
+// KeyTiming is one physical key's mean inter-key interval and how many
+// intervals it was measured over.
+type KeyTiming struct {
+	MeanMs  float64
+	Samples int64
+}
+
+// Rhythm is a per-key timing profile, keyed by the keyboard projection's key
+// ids (KeyboardEvent.code vocabulary).
+type Rhythm map[string]KeyTiming
+
+// Baseline is the account's own past as it stood before the run being judged.
+type Baseline struct {
+	// Runs is how many accepted runs the WPM and Accuracy spreads cover — the
+	// account's most recent, in the run's language, created before it.
+	Runs     int
+	WPM      Spread
+	Accuracy Spread
+	// Judged and Reviewed count the same window's runs with any verdict, and
+	// the ones of those a policy routed to review on suspicion or shape.
+	Judged   int
+	Reviewed int
+	// SuspicionMean is the window's mean recorded suspicion, accepted runs
+	// included.
+	SuspicionMean float64
+	// Rhythm is the account's keyboard profile, the judged run's own
+	// contribution removed if it had one. Nil when the profile is off.
+	Rhythm Rhythm
+}
+
+// Sample is the judged run's side of the comparison, from the server's own
+// recomputed numbers.
+type Sample struct {
+	WPM      float64
+	Accuracy float64
+	Rhythm   Rhythm
+}
+
+// HistoryFlags compares a run with its account's baseline and returns the
+// history flags it raises, in HistoryFlagCodes order. flags are the run's
+// other flags, which decide whether past suspicion is worth repeating.
+//
+// Pure, like a Judge: the same run against the same baseline raises the same
+// flags, which is what keeps a re-judgement reproducible.
+func HistoryFlags(flags []Flag, run Sample, base Baseline) []Flag {
+	var out []Flag
+	if base.Runs >= MinBaselineRuns {
+		if f, ok := wpmJump(run, base); ok {
+			out = append(out, f)
+		}
+	}
+	if f, ok := rhythmMismatch(run.Rhythm, base.Rhythm); ok {
+		out = append(out, f)
+	}
+
+	evidence := len(out) > 0
+	for _, f := range flags {
+		evidence = evidence || f.Score > 0
+	}
+	if evidence && base.Judged >= MinBaselineRuns && base.Reviewed > 0 {
+		out = append(out, Flag{
+			Code:   FlagHistoryRepeat,
+			Score:  float64(base.Reviewed) / float64(base.Judged),
+			Detail: fmt.Sprintf("%d of the last %d runs went to review", base.Reviewed, base.Judged),
+		})
+	}
+	return out
+}
+
+func wpmJump(run Sample, base Baseline) (Flag, bool) {
+	excess := run.WPM - base.WPM.Mean
+	if run.WPM <= base.WPM.Max || excess < wpmJumpFloor || excess < wpmJumpSigma*base.WPM.StdDev {
+		return Flag{}, false
+	}
+	return Flag{
+		Code:  FlagHistoryWPMJump,
+		Score: clamp01((excess - wpmJumpFloor) / wpmJumpSpan),
+		Detail: fmt.Sprintf("%.1f wpm against a mean of %.1f and a best of %.1f over %d runs",
+			run.WPM, base.WPM.Mean, base.WPM.Max, base.Runs),
+	}, true
+}
+
+// rhythmMismatch measures how differently a run spreads its time across keys.
+//
+// Both profiles are divided by their own mean over the shared keys first, so
+// what is compared is the shape — which keys are slow for THESE hands — and
+// not the speed, which the wpm detector already covers. The distance is the
+// mean absolute log ratio per key: symmetric, and a key twice as slow counts
+// as much as one twice as fast.
+func rhythmMismatch(run, base Rhythm) (Flag, bool) {
+	if len(run) == 0 || len(base) == 0 {
+		return Flag{}, false
+	}
+	keys := make([]string, 0, len(run))
+	for k, r := range run {
+		b, ok := base[k]
+		if ok && r.Samples >= rhythmRunSamples && b.Samples >= rhythmBaseSamples && r.MeanMs > 0 && b.MeanMs > 0 {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) < rhythmMinKeys {
+		return Flag{}, false
+	}
+	// Sorted so the float sums below add up in the same order every time.
+	sort.Strings(keys)
+
+	var runMean, baseMean float64
+	for _, k := range keys {
+		runMean += run[k].MeanMs
+		baseMean += base[k].MeanMs
+	}
+	runMean /= float64(len(keys))
+	baseMean /= float64(len(keys))
+
+	var dist float64
+	for _, k := range keys {
+		dist += math.Abs(math.Log((run[k].MeanMs / runMean) / (base[k].MeanMs / baseMean)))
+	}
+	dist /= float64(len(keys))
+	if dist < rhythmFloor {
+		return Flag{}, false
+	}
+	return Flag{
+		Code:   FlagHistoryRhythm,
+		Score:  clamp01((dist - rhythmFloor) / rhythmSpan),
+		Detail: fmt.Sprintf("per-key timing %.2f from the profile over %d keys", dist, len(keys)),
+	}, true
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The history detectors are open, so their tests are too: what raises a
+// history flag is not the secret, what the server does about one is.
+
+func settled(mean, stddev, best float64) Baseline {
+	return Baseline{Runs: 30, WPM: Spread{Mean: mean, StdDev: stddev, Max: best}, Judged: 30}
+}
+
+func codes(flags []Flag) []string {
+	out := make([]string, 0, len(flags))
+	for _, f := range flags {
+		out = append(out, f.Code)
+	}
+	return out
+}
+
+func TestAWPMJumpNeedsToClearEveryMargin(t *testing.T) {
+	base := settled(70, 4, 85)
+	tests := []struct {
+		name string
+		wpm  float64
+		want float64 // score; negative means no flag
+	}{
+		{"an ordinary good day", 84, -1},
+		{"a new best, inside the floor", 89, -1},
+		{"just past the floor", 90, 0},
+		{"halfway to full severity", 110, 0.5},
+		{"the 60 wpm jump", 130, 1},
+		{"well beyond it", 200, 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := HistoryFlags(nil, Sample{WPM: tc.wpm}, base)
+			if tc.want < 0 {
+				assert.Empty(t, got)
+				return
+			}
+			require.Len(t, got, 1)
+			assert.Equal(t, FlagHistoryWPMJump, got[0].Code)
+			assert.InDelta(t, tc.want, got[0].Score, 1e-9)
+			assert.NotEmpty(t, got[0].Detail)
+		})
+	}
+}
+
+// An erratic account earns a wider margin: 25 wpm over a mean that swings by
+// 10 is three of its own deviations short of a jump.
+func TestAnErraticAccountIsMeasuredAgainstItsOwnSpread(t *testing.T) {
+	assert.Empty(t, HistoryFlags(nil, Sample{WPM: 95}, settled(70, 10, 90)))
+	assert.Len(t, HistoryFlags(nil, Sample{WPM: 95}, settled(70, 1, 90)), 1)
+}
+
+func TestBelowTheMinimumBaselineSpeedIsNotCompared(t *testing.T) {
+	base := settled(40, 1, 45)
+	base.Runs = MinBaselineRuns - 1
+	assert.Empty(t, HistoryFlags(nil, Sample{WPM: 150}, base))
+}
+
+// profile builds a rhythm over n keys whose intervals follow shape, scaled.
+func profile(n int, scale float64, samples int64, shape func(i int) float64) Rhythm {
+	r := make(Rhythm, n)
+	for i := range n {
+		r[fmt.Sprintf("Key%c", 'A'+i)] = KeyTiming{MeanMs: scale * shape(i), Samples: samples}
+	}
+	return r
+}
+
+func TestRhythmComparesShapeNotSpeed(t *testing.T) {
+	rising := func(i int) float64 { return 100 + 20*float64(i) }
+	falling := func(i int) float64 { return 400 - 20*float64(i) }
+	base := Baseline{Rhythm: profile(12, 1, 200, rising)}
+
+	t.Run("the same hands on a fast day", func(t *testing.T) {
+		assert.Empty(t, HistoryFlags(nil, Sample{Rhythm: profile(12, 0.6, 5, rising)}, base))
+	})
+	t.Run("somebody else's hands", func(t *testing.T) {
+		got := HistoryFlags(nil, Sample{Rhythm: profile(12, 1, 5, falling)}, base)
+		require.Len(t, got, 1)
+		assert.Equal(t, FlagHistoryRhythm, got[0].Code)
+		assert.Positive(t, got[0].Score)
+	})
+	t.Run("too few shared keys", func(t *testing.T) {
+		assert.Empty(t, HistoryFlags(nil, Sample{Rhythm: profile(rhythmMinKeys-1, 1, 5, falling)}, base))
+	})
+	t.Run("keys the run barely touched", func(t *testing.T) {
+		assert.Empty(t, HistoryFlags(nil, Sample{Rhythm: profile(12, 1, rhythmRunSamples-1, falling)}, base))
+	})
+}
+
+// Past suspicion repeats evidence; it never is evidence.
+func TestPastReviewsOnlyRepeatPresentEvidence(t *testing.T) {
+	base := settled(70, 4, 85)
+	base.Reviewed = 6
+
+	assert.Empty(t, HistoryFlags(nil, Sample{WPM: 72}, base), "a clean run from a once-reviewed account")
+	assert.Empty(t, HistoryFlags([]Flag{{Code: "burst", Score: 0}}, Sample{WPM: 72}, base),
+		"a flag with no weight of its own is not evidence")
+
+	got := HistoryFlags([]Flag{{Code: "burst", Score: 0.4}}, Sample{WPM: 72}, base)
+	require.Len(t, got, 1)
+	assert.Equal(t, FlagHistoryRepeat, got[0].Code)
+	assert.InDelta(t, 0.2, got[0].Score, 1e-9)
+
+	got = HistoryFlags(nil, Sample{WPM: 130}, base)
+	assert.Equal(t, []string{FlagHistoryWPMJump, FlagHistoryRepeat}, codes(got), "in HistoryFlagCodes order")
+}
+
+func TestHistoryFlagsAreDeterministic(t *testing.T) {
+	base := settled(70, 4, 85)
+	base.Reviewed = 3
+	base.Rhythm = profile(20, 1, 200, func(i int) float64 { return 100 + float64(i*i) })
+	run := Sample{WPM: 120, Rhythm: profile(20, 1, 5, func(i int) float64 { return 500 - float64(i*i) })}
+
+	first := HistoryFlags(nil, run, base)
+	require.Len(t, first, 3)
+	for range 50 {
+		assert.Equal(t, first, HistoryFlags(nil, run, base))
+	}
+}
@@ -1,6 +1,7 @@
 package policytest
 
 import (
+	"maps"
 	"sort"
 
 	"github.com/typemore/typemore-server/internal/replay/policy"
@@ -35,6 +36,9 @@ var fakeWeights = map[string]float64{
 	"afk-heavy":             0.00,
 	"trailing-afk":          0.00,
 	"unpaired-keyup":        0.00,
+	"history-wpm-jump":      0.50,
+	"history-rhythm":        0.50,
+	"history-repeat":        0.25,
 }
 
 // Fake is a deterministic judge for the open repo's tests.
@@ -83,6 +87,13 @@ func NewFakeVersioned(version string, threshold float64) *Fake {
 // Version reports the fake's version.
 func (f *Fake) Version() string { return f.version }
 
+// Describe reports the fake's arithmetic, so operator tooling — calibrate's
+// arithmetic, an explanation's per-flag contributions — has numbers to show
+// under test. A copy, as policy.Description requires.
+func (f *Fake) Describe() policy.Description {
+	return policy.Description{Threshold: f.threshold, Weights: maps.Clone(f.weights)}
+}
+
 // Judge scores the flags, fires its one shape rule, and routes.
 func (f *Fake) Judge(flags []policy.Flag, _ policy.RunMeta) policy.Decision {
 	var suspicion float64
@@ -43,7 +43,19 @@ import (
 // different, so the version has to move for `revalidate` to walk stored runs
 // forward. Runs that were accepted at ~0.007 suspicion because one mistyped
 // character hid a 336 wpm minute are the runs that change.
-const currentVersion = 4
+//
+// v5 weights the player-history flags (history.go), and is inert the way v3
+// was: those flags exist only on a deployment that has switched the history
+// layer on (TYPEMORE_REPLAY_HISTORY_ENABLED). With it off, a revalidate pass
+// at v5 reproduces every v4 verdict exactly; with it on, `calibrate` lists the
+// runs it would move before anything moves.
+//
+// v6 weights the wall-clock flags (wallclock.go), and is inert for every run
+// there is: only a match capture carries the arrival stamps they read, and
+// `revalidate` walks runs, not seats. A seat judged before v6 keeps its
+// verdict; every seat judged after it has been held against the server's
+// clock.
+const currentVersion = 6
 
 // Flag codes emitted by the core's validateLog (shared/core/validate.ts).
 // Listed here so the weights table is exhaustive by construction — a code the
@@ -128,6 +140,18 @@ var TelemetryOnlyFlags = []string{FlagUnpairedKeyup}
 //
 //   - unpaired-keyup (0.00) — see TelemetryOnlyFlags.
 //
+//   - history-wpm-jump (0.70) / history-rhythm (0.70) — the run against the
+//     account's own past. Each is worth most of a review alone and neither is
+//     worth one: a player who finally breaks through, or types one run on a
+//     borrowed keyboard, is a real person having a real day, and taking that
+//     off the board on one comparison is the sustained_superhuman mistake made
+//     again. Both at full severity — someone else's speed in someone else's
+//     hands — reaches review on its own.
+//
+//   - history-repeat (0.30) — the share of the account's recent runs still
+//     flagged on suspicion, raised only beside other evidence. Tips a run that
+//     is already close; cannot carry one that is not.
+//
 //   - canary-grapheme (1.00) — an invisible codepoint that exists ONLY in the
 //     rendered text reached the event log. No keyboard, layout, IME or compose
 //     sequence produces it, and pasted text is a different flag entirely, so it
@@ -142,6 +166,22 @@ var TelemetryOnlyFlags = []string{FlagUnpairedKeyup}
 //     a STARTING point — the number to revisit with `make calibrate` once armed
 //     runs exist; there is no armed population to calibrate against yet, and
 //     inventing precision here would be inventing data.
+//
+//   - wallclock-ahead (0.90) — match events stamped after the server already
+//     had them, with the seat's constant skew taken out. Physically impossible
+//     for a client typing live, so it sits in the uniform-intervals class; the
+//     severity is the share of events, so one batch that lands oddly is small
+//     and a log that was ahead throughout is worth review alone.
+//
+//   - wallclock-skew (0.50) — a seat's clock further from the server's than
+//     an accepted NTP estimate can leave it. A bad estimate, or an OS clock
+//     sync landing mid-match, does this to honest players; a client shifting
+//     its own go instant does it on purpose. Half a review at full severity.
+//
+//   - wallclock-compressed (0.40) — typing delivered faster than it was
+//     typed. A log generated offline and streamed at the end is the loudest
+//     version, but a stalled connection that drains is the commonest, so it
+//     is a tipping signal beside something else, never a verdict.
 var defaultFlagWeights = map[string]float64{
 	FlagZeroVariance:        1.00,
 	FlagCanaryGrapheme:      1.00,
@@ -154,6 +194,12 @@ var defaultFlagWeights = map[string]float64{
 	FlagAfkHeavy:            0.02,
 	FlagTrailingAfk:         0.02,
 	FlagUnpairedKeyup:       0.00,
+	FlagHistoryWPMJump:      0.70,
+	FlagHistoryRhythm:       0.70,
+	FlagHistoryRepeat:       0.30,
+	FlagWallClockAhead:      0.90,
+	FlagWallClockSkew:       0.50,
+	FlagWallClockCompressed: 0.40,
 }
 
 const (
@@ -3,6 +3,7 @@
 package policy
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -166,13 +167,14 @@ func TestUnknownFlagCodeIsReportedNotGuessed(t *testing.T) {
 	assert.Equal(t, []string{"telepathy"}, d.UnknownFlags)
 }
 
-// Every code the core can emit needs a weight, or the table has a hole.
+// Every code the core can emit needs a weight, or the table has a hole — and
+// so does every code the history and wall-clock layers can raise beside them.
 func TestWeightsTableCoversEveryCoreFlag(t *testing.T) {
-	emitted := []string{
+	emitted := slices.Concat([]string{
 		FlagMultiGraphemeInsert, FlagPaste, FlagMinInterval, FlagUniformIntervals,
 		FlagZeroVariance, FlagSuperhumanBurst, FlagAfkHeavy, FlagTrailingAfk,
 		FlagUnpairedKeyup, FlagCanaryGrapheme, FlagCanaryCommit,
-	}
+	}, HistoryFlagCodes, WallClockFlagCodes)
 	desc, ok := Describe(mustPolicy(t, Config{}))
 	require.True(t, ok)
 	for _, code := range emitted {
@@ -303,10 +305,16 @@ func TestBotCadenceStillRoutesOnItsOwn(t *testing.T) {
 // gate and gained a duration-dependent ceiling — and `revalidate` keys on the
 // version to walk stored runs forward. A release that fixes a detector and
 // leaves the version alone judges the whole history by the broken one.
+//
+// v5 weighted the player-history flags. Nothing raises them until the history
+// layer is switched on, but the version has to have moved by then, or enabling
+// it would leave every stored run judged without it.
+//
+// v6 weighted the wall-clock flags, which only match seats raise.
 func TestPolicyVersionTracksTheCoreDetectorChange(t *testing.T) {
 	col, err := ParseVersion(mustPolicy(t, Config{}).Version())
 	require.NoError(t, err)
-	assert.EqualValues(t, 4, col,
+	assert.EqualValues(t, 6, col,
 		"bump this together with docs/REPLAY.md and a revalidate pass, never on its own")
 }
 
@@ -0,0 +1,179 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Wall-clock detectors: a match capture's log times against the server's own
+// arrival stamps (docs/PROTOCOL.md §6, docs/REPLAY.md "Match captures").
+//
+// A solo run's log is a set of claims nobody else witnessed. A match seat's is
+// not: the relay stamped every batch with recvServerMs as it landed, on the
+// same clock that set the go instant, so each event's claimed time can be held
+// against the moment the server actually had it. An event cannot be typed after
+// it arrived, and a stream typed live cannot arrive faster than it was typed.
+//
+// Open for the reason history.go is: the arithmetic is a statement about
+// physics, not a secret. The weights that decide what a violation is worth
+// stay behind the build tag.
+//
+// The server never sees the client's NTP offset — ntp_ping only echoes the
+// three stamps, and the client keeps its estimate to itself to schedule the
+// local 3-2-1. What the server CAN bound is how wrong that estimate may
+// honestly be, which is what ClockToleranceMs is.
+
+// Wall-clock flag codes. Prefixed like the history codes, for the same reason.
+const (
+	// FlagWallClockAhead is events stamped later than the server received
+	// them, beyond the tolerance and after the seat's constant skew is taken
+	// out. Typing that arrives before it happened was not typed live.
+	FlagWallClockAhead = "wallclock-ahead"
+	// FlagWallClockCompressed is batches arriving closer together than the
+	// events they carry were typed: the arrival lag falls well below where it
+	// had been. A stalled connection that drains produces some of it; a log
+	// produced offline and streamed at the end produces all of it.
+	FlagWallClockCompressed = "wallclock-compressed"
+	// FlagWallClockSkew is a seat whose clock sits a constant distance from
+	// the server's, further than any NTP estimate the protocol's procedure
+	// accepts could have put it. Either direction counts: a clock running
+	// ahead claims events before they happened, one running behind claims a
+	// shorter race than the room ran.
+	FlagWallClockSkew = "wallclock-skew"
+)
+
+// WallClockFlagCodes lists every code WallClockFlags can raise.
+var WallClockFlagCodes = []string{FlagWallClockAhead, FlagWallClockCompressed, FlagWallClockSkew}
+
+// The detectors' floors. Starting points, like the history floors: there are
+// no judged match captures to calibrate them against yet.
+const (
+	// ClockToleranceMs is how far an honest seat's arrival lag may wander.
+	// It covers a one-way trip, the ≤100 ms flush interval, and the NTP
+	// estimate's own error, which is at most half the round trip of the pair
+	// it came from; a client that discards pairs above 3× the minimum rtt
+	// keeps that well under a second on any connection a race is playable on.
+	ClockToleranceMs = 1500.0
+	// skewSpanMs is the distance beyond the tolerance to full severity: a
+	// seat five seconds off the server's clock is maximally skewed.
+	skewSpanMs = 3500.0
+	// compressedSpanMs is the same for compression: ten seconds of typing
+	// delivered faster than it was typed is maximally compressed.
+	compressedSpanMs = 10000.0
+)
+
+// Arrival is one relayed batch: when the server stamped it, and the log time
+// of every event it carried, in milliseconds after the go instant.
+type Arrival struct {
+	RecvMs   int64
+	EventsMs []float64
+}
+
+// WallClock is a seat's capture seen as a clock: the match's go instant on
+// the server's clock, and each batch in batchSeq order.
+type WallClock struct {
+	GoAtMs   int64
+	Arrivals []Arrival
+}
+
+// ClockReading is what the detectors measured. Lag is arrival minus the
+// claimed server time of a batch's last event — how long the server waited
+// for something the client says had already happened.
+type ClockReading struct {
+	// Batches and Events count what was measured: a batch with no events or
+	// no arrival stamp says nothing about either clock.
+	Batches int
+	Events  int
+	// MedianLagMs is the seat's constant offset from the server, latency
+	// included. Honest seats sit a little above zero.
+	MedianLagMs float64
+	// Ahead counts events that arrived before their own claimed time, once
+	// the median is taken out.
+	Ahead int
+	// CompressedMs is the largest drop of a batch's lag below the highest
+	// lag of any batch before it.
+	CompressedMs float64
+}
+
+// ReadWallClock measures a seat's capture. Pure, like the detectors: the same
+// capture always reads the same.
+func ReadWallClock(c WallClock) ClockReading {
+	var r ClockReading
+	lags := make([]float64, 0, len(c.Arrivals))
+	for _, a := range c.Arrivals {
+		if a.RecvMs == 0 || len(a.EventsMs) == 0 {
+			continue
+		}
+		lags = append(lags, float64(a.RecvMs-c.GoAtMs)-slices.Max(a.EventsMs))
+	}
+	if len(lags) == 0 {
+		return r
+	}
+	r.Batches = len(lags)
+
+	// The largest fall of a lag below the highest before it: how much typing
+	// the server received in less time than it claims to have taken. A
+	// connection that stalls and drains does this honestly, for as long as
+	// the stall lasted — which is the shape this measure cannot tell from a
+	// log held back and sent late, and the reason compression is the one of
+	// the three that is weighed as circumstantial.
+	peak := lags[0]
+	for _, lag := range lags[1:] {
+		r.CompressedMs = math.Max(r.CompressedMs, peak-lag)
+		peak = math.Max(peak, lag)
+	}
+
+	sorted := slices.Clone(lags)
+	slices.Sort(sorted)
+	r.MedianLagMs = sorted[(len(sorted)-1)/2]
+
+	// Only a clock running AHEAD is taken out before counting: a seat behind
+	// the server already arrives late, and subtracting that would excuse
+	// events that really did land before they were typed.
+	shift := math.Min(r.MedianLagMs, 0)
+	for _, a := range c.Arrivals {
+		if a.RecvMs == 0 {
+			continue
+		}
+		for _, t := range a.EventsMs {
+			r.Events++
+			if float64(a.RecvMs-c.GoAtMs)-t-shift < -ClockToleranceMs {
+				r.Ahead++
+			}
+		}
+	}
+	return r
+}
+
+// WallClockFlags returns the flags a reading raises, in WallClockFlagCodes
+// order.
+func WallClockFlags(r ClockReading) []Flag {
+	var out []Flag
+	if r.Ahead > 0 {
+		out = append(out, Flag{
+			Code:   FlagWallClockAhead,
+			Score:  float64(r.Ahead) / float64(r.Events),
+			Detail: fmt.Sprintf("%d of %d events stamped after they arrived", r.Ahead, r.Events),
+		})
+	}
+	if excess := r.CompressedMs - ClockToleranceMs; excess > 0 {
+		out = append(out, Flag{
+			Code:   FlagWallClockCompressed,
+			Score:  clamp01(excess / compressedSpanMs),
+			Detail: fmt.Sprintf("%.0f ms of typing arrived faster than it was typed", r.CompressedMs),
+		})
+	}
+	if excess := math.Abs(r.MedianLagMs) - ClockToleranceMs; excess > 0 {
+		dir := "behind"
+		if r.MedianLagMs < 0 {
+			dir = "ahead of"
+		}
+		out = append(out, Flag{
+			Code:   FlagWallClockSkew,
+			Score:  clamp01(excess / skewSpanMs),
+			Detail: fmt.Sprintf("clock %.0f ms %s the server's over %d batches", math.Abs(r.MedianLagMs), dir, r.Batches),
+		})
+	}
+	return out
+}
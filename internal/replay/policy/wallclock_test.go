@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goAtMs = 1_788_000_000_000
+
+// typedLive is n batches of four events 25 ms apart, each batch flushed and
+// received lag ms after its last event on a clock skewed by skew.
+func typedLive(n int, lag, skew float64) WallClock {
+	c := WallClock{GoAtMs: goAtMs}
+	for i := range n {
+		base := float64(i) * 100
+		events := []float64{base + 25, base + 50, base + 75, base + 100}
+		c.Arrivals = append(c.Arrivals, Arrival{
+			RecvMs:   goAtMs + int64(base+100+lag-skew),
+			EventsMs: events,
+		})
+	}
+	return c
+}
+
+func TestAnHonestSeatRaisesNothing(t *testing.T) {
+	c := typedLive(50, 80, 0)
+	// Jitter well inside the tolerance, both ways.
+	for i := range c.Arrivals {
+		c.Arrivals[i].RecvMs += int64((i%5 - 2) * 150)
+	}
+	r := ReadWallClock(c)
+	assert.Equal(t, 50, r.Batches)
+	assert.Equal(t, 200, r.Events)
+	assert.Zero(t, r.Ahead)
+	assert.InDelta(t, 80, r.MedianLagMs, 300)
+	assert.Empty(t, WallClockFlags(r))
+}
+
+// A clock off by a constant is skew and only skew: the events keep their
+// spacing, so nothing arrived before it was typed relative to the seat's own
+// clock.
+func TestAConstantOffsetIsSkewEitherWay(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		skew float64
+		dir  string
+	}{
+		{"a clock running ahead", 4000, "ahead of"},
+		{"a clock running behind", -4000, "behind"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			flags := WallClockFlags(ReadWallClock(typedLive(40, 80, tc.skew)))
+			require.Equal(t, []string{FlagWallClockSkew}, codes(flags))
+			assert.Greater(t, flags[0].Score, 0.5)
+			assert.Contains(t, flags[0].Detail, tc.dir)
+		})
+	}
+	assert.Empty(t, WallClockFlags(ReadWallClock(typedLive(40, 80, 1000))),
+		"an offset inside the tolerance is an NTP estimate, not a skew")
+}
+
+// Events stamped later than they arrived, against a seat otherwise on time.
+func TestEventsAheadOfTheirArrivalAreCounted(t *testing.T) {
+	c := typedLive(40, 80, 0)
+	for i := range 10 {
+		c.Arrivals[i*4].RecvMs -= 3000
+	}
+	r := ReadWallClock(c)
+	assert.Equal(t, 40, r.Ahead, "every event of the ten early batches")
+
+	flags := WallClockFlags(r)
+	require.Contains(t, codes(flags), FlagWallClockAhead)
+	assert.InDelta(t, 0.25, flags[0].Score, 1e-9)
+}
+
+// A log produced offline and streamed in one go: every batch lands inside a
+// second, however long the typing claims to have taken.
+func TestALogDeliveredAllAtOnceIsCompressed(t *testing.T) {
+	c := typedLive(100, 80, 0)
+	end := c.Arrivals[len(c.Arrivals)-1].RecvMs
+	for i := range c.Arrivals {
+		c.Arrivals[i].RecvMs = end + int64(i)
+	}
+	r := ReadWallClock(c)
+	assert.Greater(t, r.CompressedMs, 9000.0)
+	assert.Zero(t, r.Ahead, "late is not early")
+
+	flags := WallClockFlags(r)
+	assert.Contains(t, codes(flags), FlagWallClockCompressed)
+}
+
+// A connection that stalls and drains is compressed for as long as it
+// stalled: a second is inside the tolerance, three is a weak flag.
+func TestAStallIsCompressedForAsLongAsItLasted(t *testing.T) {
+	stalled := func(batches int) ClockReading {
+		c := typedLive(60, 80, 0)
+		drain := c.Arrivals[20+batches].RecvMs
+		for i := 20; i < 20+batches; i++ {
+			c.Arrivals[i].RecvMs = drain
+		}
+		return ReadWallClock(c)
+	}
+	assert.Empty(t, WallClockFlags(stalled(10)))
+
+	r := stalled(30)
+	assert.InDelta(t, 3000, r.CompressedMs, 1e-9)
+	flags := WallClockFlags(r)
+	require.Equal(t, []string{FlagWallClockCompressed}, codes(flags))
+	assert.Less(t, flags[0].Score, 0.25)
+}
+
+func TestUnstampedBatchesSayNothing(t *testing.T) {
+	c := typedLive(10, 80, 0)
+	for i := range c.Arrivals {
+		c.Arrivals[i].RecvMs = 0
+	}
+	c.Arrivals = append(c.Arrivals, Arrival{RecvMs: goAtMs + 5000})
+	r := ReadWallClock(c)
+	assert.Equal(t, ClockReading{}, r)
+	assert.Empty(t, WallClockFlags(r))
+}
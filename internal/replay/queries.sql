@@ -9,23 +9,32 @@
 -- transaction, which is the same atomicity the old single UPDATE had.
 
 -- name: ClaimPendingRuns :many
--- The queue scan. FOR UPDATE SKIP LOCKED lets N workers share one queue with no
--- broker and no 'processing' status: a row another worker already holds is
--- stepped over, and a worker that dies rolls its rows straight back to
--- claimable. Oldest first, so nothing starves. Uses runs_pending_idx.
+-- The queue scan, one lane at a time (00034). FOR UPDATE SKIP LOCKED lets N
+-- workers share one queue with no broker and no 'processing' status: a row
+-- another worker already holds is stepped over, and a worker that dies rolls
+-- its rows straight back to claimable. Oldest first within the lane; which
+-- lane goes first is the worker's weighted schedule (docs/REPLAY.md, "Priority
+-- lanes"), so no lane starves either. Uses runs_pending_lane_idx.
 --
 -- created_at rides along because the judgement depends on it: the canary
 -- detectors are armed per run against the canary epoch (docs/REPLAY.md), and a
 -- run created before the canary-rendering client shipped must be judged exactly
 -- as it was. It is an already-selected column of the same row, so carrying it
--- costs nothing.
+-- costs nothing. lane rides along for the worker's log.
+--
+-- next_attempt_at is the retry backoff (00032): a run whose replay failed
+-- transiently is still 'pending' but not claimable until its backoff has run.
+-- It is NULL for every run that never failed, so the common row costs one NULL
+-- test on top of the index scan.
 SELECT id, seed, dict_hash, score_version, setup, client_metrics, client_score,
-       log, attempts, created_at
+       log, attempts, created_at, lane
 FROM runs
 WHERE status = 'pending'
+  AND lane = @lane
+  AND (next_attempt_at IS NULL OR next_attempt_at <= now())
 ORDER BY created_at
 FOR UPDATE SKIP LOCKED
-LIMIT $1;
+LIMIT @row_limit;
 
 -- name: ClaimStalePolicyRuns :many
 -- The revalidation scan: runs already judged, but by rules or by CODE that are
@@ -68,6 +77,27 @@ ORDER BY r.created_at
 FOR UPDATE OF r, v SKIP LOCKED
 LIMIT @row_limit;
 
+-- name: ClaimStalePolicyRunsOnRecordedBundles :many
+-- The revalidation scan for a POLICY change alone: runs whose policy_version is
+-- behind, each handed back with the bundle that judged it, so the new rules are
+-- applied to the numbers that bundle produces and nothing else moves
+-- (docs/REPLAY.md, "Bundles"). bundle_sha is matched against the builds the
+-- caller can actually run; a run judged by one it does not hold is left for a
+-- pass that does, rather than replayed on code that never judged it.
+--
+-- Idempotent like its sibling: the decision writes the current policy_version
+-- and the SAME bundle_sha back, and the row stops matching.
+SELECT r.id, r.seed, r.dict_hash, r.score_version, r.setup, r.client_metrics,
+       r.client_score, r.log, r.attempts, r.created_at, v.bundle_sha::text AS bundle_sha
+FROM runs r
+         JOIN run_verdicts v ON v.run_id = r.id
+WHERE (v.policy_version IS NULL OR v.policy_version < @policy_version)
+  AND v.bundle_sha = ANY(@bundles::text[])
+  AND NOT EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = r.id)
+ORDER BY r.created_at
+FOR UPDATE OF r, v SKIP LOCKED
+LIMIT @row_limit;
+
 -- name: UpsertRunVerdict :exec
 -- Record one verdict's payload. client_metrics / client_score on runs are
 -- deliberately untouched: the client's numbers and the server's sit side by
@@ -79,31 +109,140 @@ LIMIT @row_limit;
 -- user_id is sourced from the runs row right here (INSERT..SELECT), so the
 -- snapshot column cannot be miswritten by a caller and the Decision type
 -- never needs to carry it. On conflict it is left alone: it is immutable.
+--
+-- The shadow columns (00033) are overwritten like the rest, NULLs included: a
+-- candidate's answer describes the judgement it was given beside, and one left
+-- over from an earlier pass would be compared against a verdict it never saw.
 INSERT INTO run_verdicts (run_id, user_id, server_metrics, server_score,
-                          validation, bundle_sha, policy_version, validated_at)
+                          validation, bundle_sha, policy_version, validated_at,
+                          shadow_label, shadow_status, shadow_validation)
 SELECT r.id, r.user_id, @server_metrics::jsonb, @server_score::jsonb,
-       @validation::jsonb, @bundle_sha, @policy_version, now()
+       @validation::jsonb, @bundle_sha, @policy_version, now(),
+       @shadow_label, @shadow_status, @shadow_validation::jsonb
 FROM runs r
 WHERE r.id = @run_id
 ON CONFLICT (run_id) DO UPDATE SET
-    server_metrics = excluded.server_metrics,
-    server_score   = excluded.server_score,
-    validation     = excluded.validation,
-    bundle_sha     = excluded.bundle_sha,
-    policy_version = excluded.policy_version,
-    validated_at   = now();
+    server_metrics    = excluded.server_metrics,
+    server_score      = excluded.server_score,
+    validation        = excluded.validation,
+    bundle_sha        = excluded.bundle_sha,
+    policy_version    = excluded.policy_version,
+    validated_at      = now(),
+    shadow_label      = excluded.shadow_label,
+    shadow_status     = excluded.shadow_status,
+    shadow_validation = excluded.shadow_validation;
 
 -- name: ApplyRunOutcome :exec
 -- The lifecycle half of the same decision: status transition plus the queue's
 -- retry bookkeeping. Always executed in the same transaction as
 -- UpsertRunVerdict; the invariant "status <> 'pending' <=> a verdict row
 -- exists" is exactly the pair of these two statements committing together.
+--
+-- A decision always ends the run's backoff, and either marks it a dead letter
+-- (the worker has stopped retrying and a human has to release it) or clears a
+-- mark an earlier pass left: a dead letter that revalidation later judges
+-- cleanly is no longer one.
 UPDATE runs
-SET status     = @status,
-    attempts   = @attempts,
-    last_error = NULLIF(@last_error::text, '')
+SET status           = @status,
+    attempts         = @attempts,
+    last_error       = NULLIF(@last_error::text, ''),
+    next_attempt_at  = NULL,
+    dead_lettered_at = CASE WHEN @dead_letter::boolean THEN now() END
 WHERE id = @id;
 
+-- name: DeferRun :exec
+-- The retry half of a transient failure (00032): the run stays 'pending' and
+-- gets NO verdict row — it has not been judged — but burns an attempt, keeps
+-- the error for the operator, and is hidden from ClaimPendingRuns until the
+-- backoff has run. The delay is computed by the worker; the instant is the
+-- database's, so the claim compares two readings of one clock.
+UPDATE runs
+SET attempts        = @attempts,
+    last_error      = NULLIF(@last_error::text, ''),
+    next_attempt_at = now() + @retry_after_ms::bigint * interval '1 millisecond'
+WHERE id = @id;
+
+-- name: ListDeadLetters :many
+-- `replayctl dead-letters`: runs the worker gave up on, oldest first, with the
+-- reason and the last error it recorded. Uses runs_dead_letter_idx.
+SELECT r.id, r.user_id, r.mode, r.lang, r.attempts, r.last_error,
+       r.dead_lettered_at, r.created_at,
+       (v.validation ->> 'reason')::text AS reason
+FROM runs r
+         JOIN run_verdicts v ON v.run_id = r.id
+WHERE r.dead_lettered_at IS NOT NULL
+ORDER BY r.dead_lettered_at
+LIMIT @row_limit;
+
+-- name: ReleaseDeadLetters :many
+-- `replayctl retry`: hand dead letters back to the queue as NEW work. The run
+-- returns to 'pending' with a fresh attempt budget and its verdict row is
+-- deleted in the same statement — a pending run has no verdict (00019), and
+-- the flag being released was never a judgement of the run anyway, only a
+-- record that the worker could not make one.
+--
+-- An empty id list releases every dead letter, oldest first, up to row_limit.
+-- A run a human has ruled on is never released: the override is the decision
+-- (00028), and re-queuing it would let the worker write over it.
+WITH released AS (
+    UPDATE runs
+    SET status           = 'pending',
+        attempts         = 0,
+        next_attempt_at  = NULL,
+        dead_lettered_at = NULL
+    WHERE id IN (SELECT d.id
+                 FROM runs d
+                 WHERE d.dead_lettered_at IS NOT NULL
+                   AND d.status = 'flagged'
+                   AND (cardinality(@ids::uuid[]) = 0 OR d.id = ANY (@ids::uuid[]))
+                   AND NOT EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = d.id)
+                 ORDER BY d.dead_lettered_at
+                 LIMIT @row_limit
+                 FOR UPDATE SKIP LOCKED)
+    RETURNING id
+)
+DELETE FROM run_verdicts v
+USING released
+WHERE v.run_id = released.id
+RETURNING v.run_id;
+
+-- name: GetPlayerBaseline :one
+-- The history layer's read (docs/REPLAY.md, "Player history"): the judged
+-- run's account, in the run's language, over its most recent judged runs
+-- created BEFORE it. Bounded by the run's own created_at rather than now() so
+-- a re-judgement measures a run against the past it was played in, not against
+-- what the account went on to do. Walks runs_user_created_idx.
+--
+-- The spreads cover accepted runs only: a flagged run's numbers are the thing
+-- in question, and a rejected run has none. "Reviewed" counts runs a policy
+-- routed on suspicion or shape that are STILL flagged — a run a moderator put
+-- back (00028) is not held against the account.
+WITH subject AS (
+    SELECT user_id, lang, created_at
+    FROM runs
+    WHERE id = @run_id
+),
+recent AS (
+    SELECT r.status, v.server_metrics, v.validation
+    FROM subject s
+             JOIN runs r ON r.user_id = s.user_id AND r.lang = s.lang AND r.created_at < s.created_at
+             JOIN run_verdicts v ON v.run_id = r.id
+    ORDER BY r.created_at DESC
+    LIMIT @window_size
+)
+SELECT count(*) FILTER (WHERE status = 'accepted')::int AS runs,
+       COALESCE(avg((server_metrics ->> 'wpm')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS wpm_mean,
+       COALESCE(stddev_samp((server_metrics ->> 'wpm')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS wpm_stddev,
+       COALESCE(max((server_metrics ->> 'wpm')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS wpm_max,
+       COALESCE(avg((server_metrics ->> 'accuracy')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS accuracy_mean,
+       COALESCE(stddev_samp((server_metrics ->> 'accuracy')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS accuracy_stddev,
+       COALESCE(max((server_metrics ->> 'accuracy')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS accuracy_max,
+       count(*)::int AS judged,
+       count(*) FILTER (WHERE status = 'flagged'
+           AND validation ->> 'reason' IN ('suspicion_threshold', 'bot_pattern'))::int AS reviewed,
+       COALESCE(avg((validation -> 'policy' ->> 'suspicion')::float8), 0)::float8 AS suspicion_mean
+FROM recent;
+
 -- name: ListRunsForCalibration :many
 -- Read-only sample for `make calibrate`: everything the decision needs, plus
 -- the status and policy_version the run currently carries so a dry run can
@@ -117,3 +256,80 @@ FROM runs r
          JOIN run_verdicts v ON v.run_id = r.id
 ORDER BY r.created_at
 LIMIT @row_limit;
+
+-- name: GetRunForExplain :one
+-- One run for `replayctl explain` and the admin explain endpoint: the inputs
+-- a decision needs, plus the verdict the run carries now, so the trace can be
+-- read against it. LEFT JOIN: a pending run has no verdict yet, and its trace
+-- is the decision the worker is about to make.
+SELECT r.id, r.seed, r.dict_hash, r.score_version, r.setup, r.client_metrics,
+       r.client_score, r.log, r.attempts, r.status, r.created_at,
+       v.validation, v.bundle_sha, v.policy_version
+FROM runs r
+         LEFT JOIN run_verdicts v ON v.run_id = r.id
+WHERE r.id = @id;
+
+-- name: ClaimUnvalidatedMatches :many
+-- The match queue scan (docs/MATCH.md §6). The unit is the MATCH: placement is
+-- only meaningful once every seat has a verdict, so the match row is what is
+-- locked, with the same FOR UPDATE SKIP LOCKED discipline as ClaimPendingRuns.
+-- The seats are read under that lock by ListMatchSeats; nothing else writes
+-- them, so locking the header is enough. Uses matches_unvalidated_idx.
+SELECT id, settings, seed, dict_hash, go_at, ended_at
+FROM matches
+WHERE validated_at IS NULL
+ORDER BY ended_at
+FOR UPDATE SKIP LOCKED
+LIMIT $1;
+
+-- name: ListMatchSeats :many
+-- Every seat of one claimed match, capture included. Served by the
+-- (match_id, player_id) unique index 00021 made the match's access path.
+SELECT id, player_id, freemods, log, final_status
+FROM match_runs
+WHERE match_id = $1
+ORDER BY player_id;
+
+-- name: InsertMatchRunVerdict :exec
+-- Record one seat's judgement. A plain INSERT, no ON CONFLICT: a match is
+-- claimed only while validated_at IS NULL and is marked in the same
+-- transaction, so a second verdict for a seat is a queue bug and should fail
+-- as loudly as a duplicate capture does (00021).
+INSERT INTO match_run_verdicts (match_run_id, match_id, server_metrics, server_score,
+                                score, placement, validation, bundle_sha,
+                                policy_version, last_error)
+VALUES (@match_run_id, @match_id, @server_metrics::jsonb, @server_score::jsonb,
+        @score, @placement, @validation::jsonb, @bundle_sha,
+        @policy_version, NULLIF(@last_error::text, ''));
+
+-- name: SetMatchRunStatus :exec
+-- The lifecycle half of a seat's decision, written beside its verdict row in
+-- the same transaction — the pair ApplyRunOutcome/UpsertRunVerdict, for seats.
+UPDATE match_runs
+SET status = @status
+WHERE id = @id;
+
+-- name: MarkMatchValidated :exec
+-- Take the match off the queue. Last statement of the judging transaction, so
+-- "validated" and "every seat has a verdict" commit as one fact.
+UPDATE matches
+SET validated_at = now()
+WHERE id = $1;
+
+-- name: GetMatchResultsHeader :one
+-- The public results read (GET /api/v1/matches/{id}/results): the match
+-- header, and whether the worker has reached it yet.
+SELECT id, name, settings, go_at, ended_at, validated_at
+FROM matches
+WHERE id = $1;
+
+-- name: ListMatchResults :many
+-- Every seat with its verdict, if it has one yet. LEFT JOIN because a pending
+-- seat is still a seat of the match and is listed as such. Placed seats first,
+-- in place order; then the rest by score. The capture itself is not read.
+SELECT r.player_id, r.nick, r.final_status, r.status,
+       v.score, v.placement, v.server_metrics
+FROM match_runs r
+         LEFT JOIN match_run_verdicts v ON v.match_run_id = r.id
+WHERE r.match_id = $1
+ORDER BY v.placement NULLS LAST, v.score DESC NULLS LAST, r.player_id;
@@ -3,6 +3,7 @@ package replay
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -42,6 +43,16 @@ type PendingRun struct {
 	// would read coincidence as evidence on runs whose client never rendered
 	// one. See docs/REPLAY.md, "Canary epoch".
 	CreatedAt time.Time
+	// ReplayWith pins the replay to one core bundle by SHA (see Bundles).
+	// Empty — every claim from the live queue — means the vendored bundle.
+	// Set by revalidation when a run is to be re-judged on the code that
+	// first judged it, or on one an operator chose; the decision then
+	// records this SHA as its bundle_sha.
+	ReplayWith string
+	// Lane is the priority lane the live queue claimed the run from (see
+	// Lanes). Scheduling only: nothing in the judgement reads it, and a run
+	// claimed for revalidation has none.
+	Lane string
 }
 
 // Decision is the worker's verdict for one run: the new status plus everything
@@ -62,6 +73,13 @@ type Decision struct {
 	Attempts int16
 	// LastError is the operator-facing failure detail; empty clears the column.
 	LastError string
+	// RetryAfter is set, with Status pending, when a transient failure is being
+	// retried rather than decided: the queue writes no verdict and hides the
+	// run from the claim for this long. See RetryPolicy.
+	RetryAfter time.Duration
+	// DeadLetter marks a failure the worker has stopped retrying. The run is
+	// flagged like any other; the mark is what `replayctl dead-letters` lists.
+	DeadLetter bool
 	// CharObservations feeds the keyboard projection (docs/PROFILE.md): the
 	// per-character rows the core extracted from THIS replay. Present whenever
 	// the log replayed cleanly — whatever the status — because the projector
@@ -69,6 +87,13 @@ type Decision struct {
 	// previously-projected run that was just demoted. Nil when the log could
 	// not be replayed or was invalid.
 	CharObservations []CharObservation
+	// Shadow is the candidate policy's answer for the same run, when the
+	// decider has one (WithShadow) and the run reached a judge. It is stored
+	// beside the verdict and decides nothing.
+	Shadow *ShadowDecision
+	// engine is the differential engine's divergence for this replay, if any;
+	// withValidation writes it into Validation. Never read back.
+	engine *EngineDivergence
 }
 
 // Queue is the worker's persistence contract, declared here at the consumer.
@@ -80,11 +105,15 @@ type Decision struct {
 // 'processing' state to reconcile, and a second worker's FOR UPDATE SKIP LOCKED
 // simply steps over the locked rows. See docs/REPLAY.md.
 type Queue interface {
-	// ProcessBatch claims up to limit pending runs (oldest first), calls decide
-	// for each, applies what it returns, and commits. It returns the number of
-	// runs claimed — zero means the queue is empty. An error from decide is
-	// impossible by construction: decide is total.
-	ProcessBatch(ctx context.Context, limit int32, decide func(context.Context, PendingRun) Decision) (int, error)
+	// ProcessBatch claims up to limit pending runs whose retry backoff, if
+	// any, has run out, calls decide for each, applies what it returns, and
+	// commits. The claim takes from lanes in the order given, oldest first
+	// within each, until limit is reached; nil means Lanes. A decision that is
+	// still pending (RetryAfter) is applied as a deferral: attempts and error
+	// recorded, no verdict written. It returns the number of runs claimed —
+	// zero means nothing is due. An error from decide is impossible by
+	// construction: decide is total.
+	ProcessBatch(ctx context.Context, limit int32, lanes []string, decide func(context.Context, PendingRun) Decision) (int, error)
 
 	// ProcessStalePolicyBatch is the same unit of work over runs that were
 	// already judged, but by rules or by code that are no longer current:
@@ -93,6 +122,13 @@ type Queue interface {
 	// applying a decision writes BOTH columns, so a re-judged run stops
 	// matching either arm of the claim.
 	ProcessStalePolicyBatch(ctx context.Context, policyVersion int16, bundleSHA string, limit int32, decide func(context.Context, PendingRun) Decision) (int, error)
+
+	// ProcessRecordedBundleBatch re-judges runs whose policy_version is behind
+	// and whose bundle_sha is one of bundles, handing each to decide with
+	// ReplayWith set to that bundle_sha. It is the policy-only revalidation:
+	// the numbers are reproduced by the code that produced them, and only the
+	// rules move.
+	ProcessRecordedBundleBatch(ctx context.Context, policyVersion int16, bundles []string, limit int32, decide func(context.Context, PendingRun) Decision) (int, error)
 }
 
 // CalibrationRun is a judged run as the read-only calibration pass sees it: the
@@ -103,3 +139,17 @@ type CalibrationRun struct {
 	Status        runstatus.Status
 	PolicyVersion *int16
 }
+
+// StoredRun is one run as `replayctl explain` reads it: the inputs a decision
+// needs, plus the verdict the row carries now. Validation is nil, and the two
+// pointers are too, for a run the worker has not judged yet.
+type StoredRun struct {
+	PendingRun
+	Status        runstatus.Status
+	Validation    json.RawMessage
+	BundleSHA     *string
+	PolicyVersion *int16
+}
+
+// ErrRunNotFound is returned by a store asked for a run that does not exist.
+var ErrRunNotFound = errors.New("replay: run not found")
@@ -296,6 +296,155 @@ func TestQueueProcessesAPendingRun(t *testing.T) {
 	assert.Zero(t, n)
 }
 
+// run_lane files a run from its own claim: a ranked shape that would take or
+// beat the player's slot is a contender, one that would not is ranked, and a
+// shape no board reads is unranked whatever it scores.
+func TestRunLaneFilesARunFromItsClaim(t *testing.T) {
+	pool := newPool(t)
+	ctx := context.Background()
+	user := seedUser(t, pool)
+	v := loadVector(t, "words-clean")
+	p := v.Payload
+
+	lane := func(wordCount int32, total int) string {
+		t.Helper()
+		var l string
+		require.NoError(t, pool.QueryRow(ctx,
+			`SELECT run_lane($1, 'words', NULL, $2, $3, $4, $5)`,
+			user, wordCount, p.Lang, p.Setup, fmt.Sprintf(`{"total":%d}`, total)).Scan(&l))
+		return l
+	}
+	assert.Equal(t, replay.LaneUnranked, lane(*p.WordCount, 1410), "ten words ranks nowhere")
+	assert.Equal(t, replay.LaneContender, lane(50, 1410), "no slot yet: any score takes one")
+
+	slot := insertPending(t, pool, user, v)
+	_, err := pool.Exec(ctx, `UPDATE runs SET word_count = 50 WHERE id = $1`, slot)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `
+		INSERT INTO leaderboard_entries
+			(bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods, achieved_at)
+		VALUES ('words:50:'||$3||':seeded', $1, $2, 2000, 1, 1, 1, 'S', '{}'::jsonb, now())`,
+		user, slot, p.Lang)
+	require.NoError(t, err)
+
+	assert.Equal(t, replay.LaneRanked, lane(50, 1410), "below the player's slot")
+	assert.Equal(t, replay.LaneContender, lane(50, 2001), "above it")
+	assert.Equal(t, replay.LaneContender, lane(100, 1410), "a different board is a different slot")
+}
+
+// A daily attempt places on the day's board whatever it scores, so one that
+// would not move the player's ordinary board is an event run rather than a
+// ranked one — and one that would is still a contender.
+func TestRunLaneFilesADailyAttemptAsAnEvent(t *testing.T) {
+	pool := newPool(t)
+	ctx := context.Background()
+	user := seedUser(t, pool)
+	v := loadVector(t, "words-clean")
+	p := v.Payload
+
+	lane := func(wordCount int32, total int, daily bool) string {
+		t.Helper()
+		declared := `{}`
+		if daily {
+			declared = `{"daily":"2026-10-17"}`
+		}
+		var l string
+		require.NoError(t, pool.QueryRow(ctx,
+			`SELECT run_lane($1, 'words', NULL, $2, $3, $4::jsonb || $5::jsonb, $6)`,
+			user, wordCount, p.Lang, p.Setup, declared, fmt.Sprintf(`{"total":%d}`, total)).Scan(&l))
+		return l
+	}
+
+	slot := insertPending(t, pool, user, v)
+	_, err := pool.Exec(ctx, `UPDATE runs SET word_count = 50 WHERE id = $1`, slot)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `
+		INSERT INTO leaderboard_entries
+			(bucket_key, user_id, run_id, score, wpm, raw, acc, grade, mods, achieved_at)
+		VALUES ('words:50:'||$3||':seeded', $1, $2, 2000, 1, 1, 1, 'S', '{}'::jsonb, now())`,
+		user, slot, p.Lang)
+	require.NoError(t, err)
+
+	assert.Equal(t, replay.LaneRanked, lane(50, 1410, false), "the same run, undeclared")
+	assert.Equal(t, replay.LaneEvent, lane(50, 1410, true), "below the player's slot, on the day's board")
+	assert.Equal(t, replay.LaneContender, lane(50, 2001, true), "above it: the ordinary board moves too")
+	assert.Equal(t, replay.LaneEvent, lane(*p.WordCount, 1410, true), "a day's shape the ordinary boards do not rank")
+	assert.Equal(t, replay.LaneUnranked, lane(*p.WordCount, 1410, false))
+}
+
+// The claim takes the lanes in the order it is given, oldest first within
+// each, and fills the batch from the lanes behind the lead.
+func TestClaimTakesLanesInTheOrderGiven(t *testing.T) {
+	pool := newPool(t)
+	ctx := context.Background()
+	user := seedUser(t, pool)
+	v := loadVector(t, "words-clean")
+
+	// Filed oldest first: unranked, ranked, event, contender.
+	byLane := map[string]uuid.UUID{}
+	for _, l := range []string{replay.LaneUnranked, replay.LaneRanked, replay.LaneEvent, replay.LaneContender} {
+		id := insertPending(t, pool, user, v)
+		_, err := pool.Exec(ctx, `UPDATE runs SET lane = $2 WHERE id = $1`, id, l)
+		require.NoError(t, err)
+		byLane[l] = id
+	}
+
+	q := replaypg.New(pool, nil)
+	claim := func(limit int32, lanes []string) []string {
+		t.Helper()
+		var got []string
+		_, err := q.ProcessBatch(ctx, limit, lanes, func(_ context.Context, run replay.PendingRun) replay.Decision {
+			assert.Equal(t, byLane[run.Lane], run.ID)
+			got = append(got, run.Lane)
+			// A deferral hides the run from the next claim and writes no verdict.
+			return replay.Decision{Status: replay.StatusPending, Attempts: 1, RetryAfter: time.Hour}
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	assert.Equal(t, []string{replay.LaneUnranked, replay.LaneContender},
+		claim(2, []string{replay.LaneUnranked, replay.LaneContender, replay.LaneEvent, replay.LaneRanked}),
+		"the lead lane first even though it holds the oldest run, then priority order")
+	assert.Equal(t, []string{replay.LaneEvent, replay.LaneRanked}, claim(5, nil),
+		"an event run goes ahead of an older ranked one")
+}
+
+// An explanation reads the run it explains, pending or judged, and sets the
+// verdict the row carries beside the one the trace arrives at.
+func TestExplainReadsTheStoredRun(t *testing.T) {
+	pool := newPool(t)
+	user := seedUser(t, pool)
+	id := insertPending(t, pool, user, loadVector(t, "words-clean"))
+	store := replaypg.New(pool, nil)
+	ctx := context.Background()
+
+	run, err := store.StoredRun(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, replay.StatusPending, run.Status)
+	assert.Nil(t, run.Validation, "a pending run has no verdict to carry")
+
+	w := newTestWorker(t, store, replay.WorkerConfig{BatchSize: 10})
+	core, err := replay.NewCore(replay.DefaultReplayTimeout)
+	require.NoError(t, err)
+	_, err = w.RunBatch(ctx, core, discardLogger())
+	require.NoError(t, err)
+
+	reg, err := replay.NewRegistry(core)
+	require.NoError(t, err)
+	explainer := replay.NewExplainer(store, core, reg, pgQuotes{}, fakeDecider(t), time.Time{})
+	e, err := explainer.ExplainRun(ctx, id, false)
+	require.NoError(t, err)
+	assert.Equal(t, replay.StatusAccepted, e.Stored.Status)
+	require.NotNil(t, e.Stored.BundleSHA)
+	assert.Equal(t, replay.BundleSHA(), *e.Stored.BundleSHA)
+	assert.NotEmpty(t, e.Stored.Validation)
+	assert.True(t, e.Agrees, "re-judging a run on the code that judged it must land where it landed")
+
+	_, err = explainer.ExplainRun(ctx, uuid.New(), false)
+	require.ErrorIs(t, err, replay.ErrRunNotFound)
+}
+
 // countingQueue wraps a real Queue and records which runs were handed to
 // `decide`. Shared between the two workers, it is the direct evidence for
 // "no run processed twice".
@@ -305,8 +454,8 @@ type countingQueue struct {
 	seen  map[uuid.UUID]int
 }
 
-func (q countingQueue) ProcessBatch(ctx context.Context, limit int32, decide func(context.Context, replay.PendingRun) replay.Decision) (int, error) {
-	return q.inner.ProcessBatch(ctx, limit, func(ctx context.Context, run replay.PendingRun) replay.Decision {
+func (q countingQueue) ProcessBatch(ctx context.Context, limit int32, lanes []string, decide func(context.Context, replay.PendingRun) replay.Decision) (int, error) {
+	return q.inner.ProcessBatch(ctx, limit, lanes, func(ctx context.Context, run replay.PendingRun) replay.Decision {
 		q.mu.Lock()
 		q.seen[run.ID]++
 		q.mu.Unlock()
@@ -323,6 +472,15 @@ func (q countingQueue) ProcessStalePolicyBatch(ctx context.Context, policyVersio
 	})
 }
 
+func (q countingQueue) ProcessRecordedBundleBatch(ctx context.Context, policyVersion int16, bundles []string, limit int32, decide func(context.Context, replay.PendingRun) replay.Decision) (int, error) {
+	return q.inner.ProcessRecordedBundleBatch(ctx, policyVersion, bundles, limit, func(ctx context.Context, run replay.PendingRun) replay.Decision {
+		q.mu.Lock()
+		q.seen[run.ID]++
+		q.mu.Unlock()
+		return decide(ctx, run)
+	})
+}
+
 // Two workers, one queue, no coordination beyond FOR UPDATE SKIP LOCKED: every
 // run must be judged exactly once. Run this under -race.
 func TestTwoWorkersNeverProcessTheSameRunTwice(t *testing.T) {
@@ -550,6 +708,55 @@ func TestRevalidateClaimsRunsJudgedByAnotherBundle(t *testing.T) {
 	}
 }
 
+// A policy-only pass re-judges on the build that judged each run and moves
+// nothing but policy_version — and it never touches a run judged by a build it
+// cannot run, because replaying that run on anything else would be a bundle
+// migration nobody asked for.
+func TestRevalidateOnRecordedBundlesMovesOnlyThePolicy(t *testing.T) {
+	pool := newPool(t)
+	user := seedUser(t, pool)
+	ctx := context.Background()
+
+	ids := []uuid.UUID{
+		insertPending(t, pool, user, loadVector(t, "words-clean")),
+		insertPending(t, pool, user, loadVector(t, "time-clean")),
+	}
+	w := newTestWorker(t, replaypg.New(pool, nil), replay.WorkerConfig{BatchSize: 10})
+	bundles, err := replay.LoadBundles("")
+	require.NoError(t, err)
+	core, err := replay.NewCore(replay.DefaultReplayTimeout)
+	require.NoError(t, err)
+	engine := replay.NewPinnedEngine(bundles, core, replay.DefaultReplayTimeout)
+
+	n, err := w.RunBatch(ctx, engine, discardLogger())
+	require.NoError(t, err)
+	require.Equal(t, len(ids), n)
+
+	_, err = pool.Exec(ctx, `UPDATE run_verdicts SET policy_version = NULL WHERE run_id = ANY($1)`, ids)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `UPDATE run_verdicts SET bundle_sha = 'deadbeef' WHERE run_id = $1`, ids[1])
+	require.NoError(t, err)
+
+	n, err = w.RevalidateOnRecordedBundles(ctx, engine, discardLogger(), bundles.SHAs())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the run judged by a build this process holds")
+
+	n, err = w.RevalidateOnRecordedBundles(ctx, engine, discardLogger(), bundles.SHAs())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	judged := fetchRun(t, pool, ids[0])
+	require.NotNil(t, judged.PolicyVersion)
+	assert.Equal(t, fakePolicyColumn(t), *judged.PolicyVersion)
+	require.NotNil(t, judged.BundleSha)
+	assert.Equal(t, replay.BundleSHA(), *judged.BundleSha)
+
+	skipped := fetchRun(t, pool, ids[1])
+	assert.Nil(t, skipped.PolicyVersion)
+	require.NotNil(t, skipped.BundleSha)
+	assert.Equal(t, "deadbeef", *skipped.BundleSha)
+}
+
 // The backfill contract of the consistency/chars re-vendor: `make revalidate`
 // walks history forward without moving a single verdict — the only change a
 // backfilled row may show is its server_metrics carrying the enriched fields
@@ -11,6 +11,9 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+
+	"github.com/typemore/typemore-server/internal/difficulty"
+	"github.com/typemore/typemore-server/internal/keyboard"
 )
 
 // dictFS holds the dictionaries. This directory is the ONLY copy of them: the
@@ -43,6 +46,10 @@ type CatalogueEntry struct {
 	WordCount int `json:"wordCount"`
 	// Bytes is the exact length of the uncompressed body.
 	Bytes int `json:"bytes"`
+	// Stars is the word list's difficulty rating (docs/DIFFICULTY.md), 1.0 to
+	// 5.0. It holds for every board size the dictionary ranks at: a seeded
+	// run draws its words uniformly from the list whatever its length.
+	Stars float64 `json:"stars"`
 }
 
 // entry is a seeded dictionary: its catalogue row plus the pre-built response
@@ -52,6 +59,10 @@ type entry struct {
 	CatalogueEntry
 	raw []byte
 	gz  []byte
+	// profile is the list's letter frequencies, kept for rating the quotes
+	// of the same language against it; rating is the list's own.
+	profile *difficulty.Profile
+	rating  difficulty.Rating
 }
 
 // Registry is the immutable, fully-seeded set of dictionaries. It is built once
@@ -59,6 +70,7 @@ type entry struct {
 type Registry struct {
 	entries []entry           // sorted by Lang (embed.FS walks in name order)
 	byHash  map[string]*entry // dictHash -> body, for the static endpoint
+	byLang  map[string]*entry // lang -> entry, for the difficulty lookups
 }
 
 // dictDoc is the on-disk dictionary shape. Only the fields the registry needs
@@ -571,11 +583,21 @@ func NewRegistry(core *Core) (*Registry, error) {
 		return nil, fmt.Errorf("replay: no dictionaries embedded from %s/", dictsDir)
 	}
 
-	loaded, err := seed(core, names)
+	// The layouts are an embedded asset like the dictionaries, so the registry
+	// loads its own copy rather than widening a constructor every command calls.
+	layouts, err := keyboard.Load()
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	loaded, err := seed(core, difficulty.New(layouts), names)
 	if err != nil {
 		return nil, err
 	}
-	reg := &Registry{entries: loaded, byHash: make(map[string]*entry, len(loaded))}
+	reg := &Registry{
+		entries: loaded,
+		byHash:  make(map[string]*entry, len(loaded)),
+		byLang:  make(map[string]*entry, len(loaded)),
+	}
 
 	// Index after the slice is final — earlier pointers would dangle on growth.
 	for i := range reg.entries {
@@ -586,6 +608,7 @@ func NewRegistry(core *Core) (*Registry, error) {
 				prev.Lang, e.Lang, e.DictHash)
 		}
 		reg.byHash[e.DictHash] = e
+		reg.byLang[e.Lang] = e
 	}
 	return reg, nil
 }
@@ -605,7 +628,7 @@ func NewRegistry(core *Core) (*Registry, error) {
 // hashing per worker share. The hash still comes from the vendored bundle and
 // from nowhere else; what is parallel is how many copies of that bundle run at
 // once, not what any of them computes.
-func seed(core *Core, names []string) ([]entry, error) {
+func seed(core *Core, analyser *difficulty.Analyser, names []string) ([]entry, error) {
 	workers := runtime.GOMAXPROCS(0)
 	if workers > len(names) {
 		workers = len(names)
@@ -628,7 +651,7 @@ func seed(core *Core, names []string) ([]entry, error) {
 			c := core
 			if w > 0 {
 				var err error
-				if c, err = NewCore(core.timeout); err != nil {
+				if c, err = NewCoreFor(core.bundle, core.timeout); err != nil {
 					errs[w] = fmt.Errorf("replay: seed worker %d: %w", w, err)
 				}
 			}
@@ -639,7 +662,7 @@ func seed(core *Core, names []string) ([]entry, error) {
 				if errs[w] != nil {
 					continue
 				}
-				e, err := loadDict(c, names[i])
+				e, err := loadDict(c, analyser, names[i])
 				if err != nil {
 					errs[w] = err
 					continue
@@ -664,7 +687,7 @@ func seed(core *Core, names []string) ([]entry, error) {
 	return out, nil
 }
 
-func loadDict(core *Core, fileName string) (entry, error) {
+func loadDict(core *Core, analyser *difficulty.Analyser, fileName string) (entry, error) {
 	lang := strings.TrimSuffix(fileName, ".json")
 	raw, err := dictFS.ReadFile(path.Join(dictsDir, fileName))
 	if err != nil {
@@ -700,6 +723,9 @@ func loadDict(core *Core, fileName string) (entry, error) {
 		return entry{}, fmt.Errorf("replay: compress dictionary %q: %w", lang, err)
 	}
 
+	profile := difficulty.NewProfile(doc.Words)
+	rating := analyser.Words(lang, doc.Words, profile).Rating
+
 	return entry{
 		CatalogueEntry: CatalogueEntry{
 			Lang:      lang,
@@ -707,9 +733,12 @@ func loadDict(core *Core, fileName string) (entry, error) {
 			DictHash:  hash,
 			WordCount: len(doc.Words),
 			Bytes:     len(raw),
+			Stars:     rating.Stars(),
 		},
-		raw: raw,
-		gz:  gz,
+		raw:     raw,
+		gz:      gz,
+		profile: profile,
+		rating:  rating,
 	}, nil
 }
 
@@ -722,6 +751,27 @@ func (r *Registry) Catalogue() []CatalogueEntry {
 	return out
 }
 
+// Difficulty returns the rating of the dictionary with the given hash — the
+// value a score version with a difficulty term reads for a seeded run, which
+// records the hash it was generated from.
+func (r *Registry) Difficulty(dictHash string) (difficulty.Rating, bool) {
+	e, ok := r.byHash[dictHash]
+	if !ok {
+		return difficulty.Rating{}, false
+	}
+	return e.rating, true
+}
+
+// Profile returns the letter frequencies of the language's dictionary, nil
+// when there is none. The quote importer rates a quote's letters against it:
+// rare is relative to the language, not to the quote.
+func (r *Registry) Profile(lang string) *difficulty.Profile {
+	if e, ok := r.byLang[lang]; ok {
+		return e.profile
+	}
+	return nil
+}
+
 // Body returns the exact stored bytes of the dictionary with the given hash.
 // The slice is the registry's own — callers MUST NOT modify it.
 func (r *Registry) Body(dictHash string) ([]byte, bool) {
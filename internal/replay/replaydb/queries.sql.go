@@ -15,28 +15,37 @@ import (
 
 const applyRunOutcome = `-- name: ApplyRunOutcome :exec
 UPDATE runs
-SET status     = $1,
-    attempts   = $2,
-    last_error = NULLIF($3::text, '')
-WHERE id = $4
+SET status           = $1,
+    attempts         = $2,
+    last_error       = NULLIF($3::text, ''),
+    next_attempt_at  = NULL,
+    dead_lettered_at = CASE WHEN $4::boolean THEN now() END
+WHERE id = $5
 `
 
 type ApplyRunOutcomeParams struct {
-	Status    string
-	Attempts  int16
-	LastError string
-	ID        uuid.UUID
+	Status     string
+	Attempts   int16
+	LastError  string
+	DeadLetter bool
+	ID         uuid.UUID
 }
 
 // The lifecycle half of the same decision: status transition plus the queue's
 // retry bookkeeping. Always executed in the same transaction as
 // UpsertRunVerdict; the invariant "status <> 'pending' <=> a verdict row
 // exists" is exactly the pair of these two statements committing together.
+//
+// A decision always ends the run's backoff, and either marks it a dead letter
+// (the worker has stopped retrying and a human has to release it) or clears a
+// mark an earlier pass left: a dead letter that revalidation later judges
+// cleanly is no longer one.
 func (q *Queries) ApplyRunOutcome(ctx context.Context, arg ApplyRunOutcomeParams) error {
 	_, err := q.db.Exec(ctx, applyRunOutcome,
 		arg.Status,
 		arg.Attempts,
 		arg.LastError,
+		arg.DeadLetter,
 		arg.ID,
 	)
 	return err
@@ -45,14 +54,21 @@ func (q *Queries) ApplyRunOutcome(ctx context.Context, arg ApplyRunOutcomeParams
 const claimPendingRuns = `-- name: ClaimPendingRuns :many
 
 SELECT id, seed, dict_hash, score_version, setup, client_metrics, client_score,
-       log, attempts, created_at
+       log, attempts, created_at, lane
 FROM runs
 WHERE status = 'pending'
+  AND lane = $1
+  AND (next_attempt_at IS NULL OR next_attempt_at <= now())
 ORDER BY created_at
 FOR UPDATE SKIP LOCKED
-LIMIT $1
+LIMIT $2
 `
 
+type ClaimPendingRunsParams struct {
+	Lane     string
+	RowLimit int32
+}
+
 type ClaimPendingRunsRow struct {
 	ID            uuid.UUID
 	Seed          int64
@@ -64,6 +80,7 @@ type ClaimPendingRunsRow struct {
 	Log           []byte
 	Attempts      int16
 	CreatedAt     time.Time
+	Lane          string
 }
 
 // Replay worker queue + revalidation (docs/REPLAY.md). The claim and the
@@ -75,18 +92,25 @@ type ClaimPendingRunsRow struct {
 // bookkeeping (attempts, last_error). A decision is therefore two statements —
 // UpsertRunVerdict + ApplyRunOutcome — issued back to back in the claim's
 // transaction, which is the same atomicity the old single UPDATE had.
-// The queue scan. FOR UPDATE SKIP LOCKED lets N workers share one queue with no
-// broker and no 'processing' status: a row another worker already holds is
-// stepped over, and a worker that dies rolls its rows straight back to
-// claimable. Oldest first, so nothing starves. Uses runs_pending_idx.
+// The queue scan, one lane at a time (00034). FOR UPDATE SKIP LOCKED lets N
+// workers share one queue with no broker and no 'processing' status: a row
+// another worker already holds is stepped over, and a worker that dies rolls
+// its rows straight back to claimable. Oldest first within the lane; which
+// lane goes first is the worker's weighted schedule (docs/REPLAY.md, "Priority
+// lanes"), so no lane starves either. Uses runs_pending_lane_idx.
 //
 // created_at rides along because the judgement depends on it: the canary
 // detectors are armed per run against the canary epoch (docs/REPLAY.md), and a
 // run created before the canary-rendering client shipped must be judged exactly
 // as it was. It is an already-selected column of the same row, so carrying it
-// costs nothing.
-func (q *Queries) ClaimPendingRuns(ctx context.Context, limit int32) ([]ClaimPendingRunsRow, error) {
-	rows, err := q.db.Query(ctx, claimPendingRuns, limit)
+// costs nothing. lane rides along for the worker's log.
+//
+// next_attempt_at is the retry backoff (00032): a run whose replay failed
+// transiently is still 'pending' but not claimable until its backoff has run.
+// It is NULL for every run that never failed, so the common row costs one NULL
+// test on top of the index scan.
+func (q *Queries) ClaimPendingRuns(ctx context.Context, arg ClaimPendingRunsParams) ([]ClaimPendingRunsRow, error) {
+	rows, err := q.db.Query(ctx, claimPendingRuns, arg.Lane, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +129,7 @@ func (q *Queries) ClaimPendingRuns(ctx context.Context, limit int32) ([]ClaimPen
 			&i.Log,
 			&i.Attempts,
 			&i.CreatedAt,
+			&i.Lane,
 		); err != nil {
 			return nil, err
 		}
@@ -208,6 +233,507 @@ func (q *Queries) ClaimStalePolicyRuns(ctx context.Context, arg ClaimStalePolicy
 	return items, nil
 }
 
+const claimStalePolicyRunsOnRecordedBundles = `-- name: ClaimStalePolicyRunsOnRecordedBundles :many
+SELECT r.id, r.seed, r.dict_hash, r.score_version, r.setup, r.client_metrics,
+       r.client_score, r.log, r.attempts, r.created_at, v.bundle_sha::text AS bundle_sha
+FROM runs r
+         JOIN run_verdicts v ON v.run_id = r.id
+WHERE (v.policy_version IS NULL OR v.policy_version < $1)
+  AND v.bundle_sha = ANY($2::text[])
+  AND NOT EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = r.id)
+ORDER BY r.created_at
+FOR UPDATE OF r, v SKIP LOCKED
+LIMIT $3
+`
+
+type ClaimStalePolicyRunsOnRecordedBundlesParams struct {
+	PolicyVersion *int16
+	Bundles       []string
+	RowLimit      int32
+}
+
+type ClaimStalePolicyRunsOnRecordedBundlesRow struct {
+	ID            uuid.UUID
+	Seed          int64
+	DictHash      string
+	ScoreVersion  int16
+	Setup         json.RawMessage
+	ClientMetrics json.RawMessage
+	ClientScore   json.RawMessage
+	Log           []byte
+	Attempts      int16
+	CreatedAt     time.Time
+	BundleSha     string
+}
+
+// The revalidation scan for a POLICY change alone: runs whose policy_version is
+// behind, each handed back with the bundle that judged it, so the new rules are
+// applied to the numbers that bundle produces and nothing else moves
+// (docs/REPLAY.md, "Bundles"). bundle_sha is matched against the builds the
+// caller can actually run; a run judged by one it does not hold is left for a
+// pass that does, rather than replayed on code that never judged it.
+//
+// Idempotent like its sibling: the decision writes the current policy_version
+// and the SAME bundle_sha back, and the row stops matching.
+func (q *Queries) ClaimStalePolicyRunsOnRecordedBundles(ctx context.Context, arg ClaimStalePolicyRunsOnRecordedBundlesParams) ([]ClaimStalePolicyRunsOnRecordedBundlesRow, error) {
+	rows, err := q.db.Query(ctx, claimStalePolicyRunsOnRecordedBundles, arg.PolicyVersion, arg.Bundles, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimStalePolicyRunsOnRecordedBundlesRow{}
+	for rows.Next() {
+		var i ClaimStalePolicyRunsOnRecordedBundlesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Seed,
+			&i.DictHash,
+			&i.ScoreVersion,
+			&i.Setup,
+			&i.ClientMetrics,
+			&i.ClientScore,
+			&i.Log,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.BundleSha,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimUnvalidatedMatches = `-- name: ClaimUnvalidatedMatches :many
+SELECT id, settings, seed, dict_hash, go_at, ended_at
+FROM matches
+WHERE validated_at IS NULL
+ORDER BY ended_at
+FOR UPDATE SKIP LOCKED
+LIMIT $1
+`
+
+type ClaimUnvalidatedMatchesRow struct {
+	ID       string
+	Settings json.RawMessage
+	Seed     int64
+	DictHash string
+	GoAt     time.Time
+	EndedAt  time.Time
+}
+
+// The match queue scan (docs/MATCH.md §6). The unit is the MATCH: placement is
+// only meaningful once every seat has a verdict, so the match row is what is
+// locked, with the same FOR UPDATE SKIP LOCKED discipline as ClaimPendingRuns.
+// The seats are read under that lock by ListMatchSeats; nothing else writes
+// them, so locking the header is enough. Uses matches_unvalidated_idx.
+func (q *Queries) ClaimUnvalidatedMatches(ctx context.Context, limit int32) ([]ClaimUnvalidatedMatchesRow, error) {
+	rows, err := q.db.Query(ctx, claimUnvalidatedMatches, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimUnvalidatedMatchesRow{}
+	for rows.Next() {
+		var i ClaimUnvalidatedMatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Settings,
+			&i.Seed,
+			&i.DictHash,
+			&i.GoAt,
+			&i.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deferRun = `-- name: DeferRun :exec
+UPDATE runs
+SET attempts        = $1,
+    last_error      = NULLIF($2::text, ''),
+    next_attempt_at = now() + $3::bigint * interval '1 millisecond'
+WHERE id = $4
+`
+
+type DeferRunParams struct {
+	Attempts     int16
+	LastError    string
+	RetryAfterMs int64
+	ID           uuid.UUID
+}
+
+// The retry half of a transient failure (00032): the run stays 'pending' and
+// gets NO verdict row — it has not been judged — but burns an attempt, keeps
+// the error for the operator, and is hidden from ClaimPendingRuns until the
+// backoff has run. The delay is computed by the worker; the instant is the
+// database's, so the claim compares two readings of one clock.
+func (q *Queries) DeferRun(ctx context.Context, arg DeferRunParams) error {
+	_, err := q.db.Exec(ctx, deferRun,
+		arg.Attempts,
+		arg.LastError,
+		arg.RetryAfterMs,
+		arg.ID,
+	)
+	return err
+}
+
+const getMatchResultsHeader = `-- name: GetMatchResultsHeader :one
+SELECT id, name, settings, go_at, ended_at, validated_at
+FROM matches
+WHERE id = $1
+`
+
+type GetMatchResultsHeaderRow struct {
+	ID          string
+	Name        string
+	Settings    json.RawMessage
+	GoAt        time.Time
+	EndedAt     time.Time
+	ValidatedAt *time.Time
+}
+
+// The public results read (GET /api/v1/matches/{id}/results): the match
+// header, and whether the worker has reached it yet.
+func (q *Queries) GetMatchResultsHeader(ctx context.Context, id string) (GetMatchResultsHeaderRow, error) {
+	row := q.db.QueryRow(ctx, getMatchResultsHeader, id)
+	var i GetMatchResultsHeaderRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Settings,
+		&i.GoAt,
+		&i.EndedAt,
+		&i.ValidatedAt,
+	)
+	return i, err
+}
+
+const getPlayerBaseline = `-- name: GetPlayerBaseline :one
+WITH subject AS (
+    SELECT user_id, lang, created_at
+    FROM runs
+    WHERE id = $1
+),
+recent AS (
+    SELECT r.status, v.server_metrics, v.validation
+    FROM subject s
+             JOIN runs r ON r.user_id = s.user_id AND r.lang = s.lang AND r.created_at < s.created_at
+             JOIN run_verdicts v ON v.run_id = r.id
+    ORDER BY r.created_at DESC
+    LIMIT $2
+)
+SELECT count(*) FILTER (WHERE status = 'accepted')::int AS runs,
+       COALESCE(avg((server_metrics ->> 'wpm')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS wpm_mean,
+       COALESCE(stddev_samp((server_metrics ->> 'wpm')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS wpm_stddev,
+       COALESCE(max((server_metrics ->> 'wpm')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS wpm_max,
+       COALESCE(avg((server_metrics ->> 'accuracy')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS accuracy_mean,
+       COALESCE(stddev_samp((server_metrics ->> 'accuracy')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS accuracy_stddev,
+       COALESCE(max((server_metrics ->> 'accuracy')::float8) FILTER (WHERE status = 'accepted'), 0)::float8 AS accuracy_max,
+       count(*)::int AS judged,
+       count(*) FILTER (WHERE status = 'flagged'
+           AND validation ->> 'reason' IN ('suspicion_threshold', 'bot_pattern'))::int AS reviewed,
+       COALESCE(avg((validation -> 'policy' ->> 'suspicion')::float8), 0)::float8 AS suspicion_mean
+FROM recent
+`
+
+type GetPlayerBaselineParams struct {
+	RunID      uuid.UUID
+	WindowSize int32
+}
+
+type GetPlayerBaselineRow struct {
+	Runs           int32
+	WpmMean        float64
+	WpmStddev      float64
+	WpmMax         float64
+	AccuracyMean   float64
+	AccuracyStddev float64
+	AccuracyMax    float64
+	Judged         int32
+	Reviewed       int32
+	SuspicionMean  float64
+}
+
+// The history layer's read (docs/REPLAY.md, "Player history"): the judged
+// run's account, in the run's language, over its most recent judged runs
+// created BEFORE it. Bounded by the run's own created_at rather than now() so
+// a re-judgement measures a run against the past it was played in, not against
+// what the account went on to do. Walks runs_user_created_idx.
+//
+// The spreads cover accepted runs only: a flagged run's numbers are the thing
+// in question, and a rejected run has none. "Reviewed" counts runs a policy
+// routed on suspicion or shape that are STILL flagged — a run a moderator put
+// back (00028) is not held against the account.
+func (q *Queries) GetPlayerBaseline(ctx context.Context, arg GetPlayerBaselineParams) (GetPlayerBaselineRow, error) {
+	row := q.db.QueryRow(ctx, getPlayerBaseline, arg.RunID, arg.WindowSize)
+	var i GetPlayerBaselineRow
+	err := row.Scan(
+		&i.Runs,
+		&i.WpmMean,
+		&i.WpmStddev,
+		&i.WpmMax,
+		&i.AccuracyMean,
+		&i.AccuracyStddev,
+		&i.AccuracyMax,
+		&i.Judged,
+		&i.Reviewed,
+		&i.SuspicionMean,
+	)
+	return i, err
+}
+
+const getRunForExplain = `-- name: GetRunForExplain :one
+SELECT r.id, r.seed, r.dict_hash, r.score_version, r.setup, r.client_metrics,
+       r.client_score, r.log, r.attempts, r.status, r.created_at,
+       v.validation, v.bundle_sha, v.policy_version
+FROM runs r
+         LEFT JOIN run_verdicts v ON v.run_id = r.id
+WHERE r.id = $1
+`
+
+type GetRunForExplainRow struct {
+	ID            uuid.UUID
+	Seed          int64
+	DictHash      string
+	ScoreVersion  int16
+	Setup         json.RawMessage
+	ClientMetrics json.RawMessage
+	ClientScore   json.RawMessage
+	Log           []byte
+	Attempts      int16
+	Status        string
+	CreatedAt     time.Time
+	Validation    json.RawMessage
+	BundleSha     *string
+	PolicyVersion *int16
+}
+
+// One run for `replayctl explain` and the admin explain endpoint: the inputs
+// a decision needs, plus the verdict the run carries now, so the trace can be
+// read against it. LEFT JOIN: a pending run has no verdict yet, and its trace
+// is the decision the worker is about to make.
+func (q *Queries) GetRunForExplain(ctx context.Context, id uuid.UUID) (GetRunForExplainRow, error) {
+	row := q.db.QueryRow(ctx, getRunForExplain, id)
+	var i GetRunForExplainRow
+	err := row.Scan(
+		&i.ID,
+		&i.Seed,
+		&i.DictHash,
+		&i.ScoreVersion,
+		&i.Setup,
+		&i.ClientMetrics,
+		&i.ClientScore,
+		&i.Log,
+		&i.Attempts,
+		&i.Status,
+		&i.CreatedAt,
+		&i.Validation,
+		&i.BundleSha,
+		&i.PolicyVersion,
+	)
+	return i, err
+}
+
+const insertMatchRunVerdict = `-- name: InsertMatchRunVerdict :exec
+INSERT INTO match_run_verdicts (match_run_id, match_id, server_metrics, server_score,
+                                score, placement, validation, bundle_sha,
+                                policy_version, last_error)
+VALUES ($1, $2, $3::jsonb, $4::jsonb,
+        $5, $6, $7::jsonb, $8,
+        $9, NULLIF($10::text, ''))
+`
+
+type InsertMatchRunVerdictParams struct {
+	MatchRunID    uuid.UUID
+	MatchID       string
+	ServerMetrics json.RawMessage
+	ServerScore   json.RawMessage
+	Score         *int64
+	Placement     *int32
+	Validation    json.RawMessage
+	BundleSha     string
+	PolicyVersion *int16
+	LastError     string
+}
+
+// Record one seat's judgement. A plain INSERT, no ON CONFLICT: a match is
+// claimed only while validated_at IS NULL and is marked in the same
+// transaction, so a second verdict for a seat is a queue bug and should fail
+// as loudly as a duplicate capture does (00021).
+func (q *Queries) InsertMatchRunVerdict(ctx context.Context, arg InsertMatchRunVerdictParams) error {
+	_, err := q.db.Exec(ctx, insertMatchRunVerdict,
+		arg.MatchRunID,
+		arg.MatchID,
+		arg.ServerMetrics,
+		arg.ServerScore,
+		arg.Score,
+		arg.Placement,
+		arg.Validation,
+		arg.BundleSha,
+		arg.PolicyVersion,
+		arg.LastError,
+	)
+	return err
+}
+
+const listDeadLetters = `-- name: ListDeadLetters :many
+SELECT r.id, r.user_id, r.mode, r.lang, r.attempts, r.last_error,
+       r.dead_lettered_at, r.created_at,
+       (v.validation ->> 'reason')::text AS reason
+FROM runs r
+         JOIN run_verdicts v ON v.run_id = r.id
+WHERE r.dead_lettered_at IS NOT NULL
+ORDER BY r.dead_lettered_at
+LIMIT $1
+`
+
+type ListDeadLettersRow struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Mode           string
+	Lang           string
+	Attempts       int16
+	LastError      *string
+	DeadLetteredAt *time.Time
+	CreatedAt      time.Time
+	Reason         string
+}
+
+// `replayctl dead-letters`: runs the worker gave up on, oldest first, with the
+// reason and the last error it recorded. Uses runs_dead_letter_idx.
+func (q *Queries) ListDeadLetters(ctx context.Context, rowLimit int32) ([]ListDeadLettersRow, error) {
+	rows, err := q.db.Query(ctx, listDeadLetters, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDeadLettersRow{}
+	for rows.Next() {
+		var i ListDeadLettersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Mode,
+			&i.Lang,
+			&i.Attempts,
+			&i.LastError,
+			&i.DeadLetteredAt,
+			&i.CreatedAt,
+			&i.Reason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMatchResults = `-- name: ListMatchResults :many
+SELECT r.player_id, r.nick, r.final_status, r.status,
+       v.score, v.placement, v.server_metrics
+FROM match_runs r
+         LEFT JOIN match_run_verdicts v ON v.match_run_id = r.id
+WHERE r.match_id = $1
+ORDER BY v.placement NULLS LAST, v.score DESC NULLS LAST, r.player_id
+`
+
+type ListMatchResultsRow struct {
+	PlayerID      string
+	Nick          string
+	FinalStatus   string
+	Status        string
+	Score         *int64
+	Placement     *int32
+	ServerMetrics []byte
+}
+
+// Every seat with its verdict, if it has one yet. LEFT JOIN because a pending
+// seat is still a seat of the match and is listed as such. Placed seats first,
+// in place order; then the rest by score. The capture itself is not read.
+func (q *Queries) ListMatchResults(ctx context.Context, matchID string) ([]ListMatchResultsRow, error) {
+	rows, err := q.db.Query(ctx, listMatchResults, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMatchResultsRow{}
+	for rows.Next() {
+		var i ListMatchResultsRow
+		if err := rows.Scan(
+			&i.PlayerID,
+			&i.Nick,
+			&i.FinalStatus,
+			&i.Status,
+			&i.Score,
+			&i.Placement,
+			&i.ServerMetrics,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMatchSeats = `-- name: ListMatchSeats :many
+SELECT id, player_id, freemods, log, final_status
+FROM match_runs
+WHERE match_id = $1
+ORDER BY player_id
+`
+
+type ListMatchSeatsRow struct {
+	ID          uuid.UUID
+	PlayerID    string
+	Freemods    json.RawMessage
+	Log         []byte
+	FinalStatus string
+}
+
+// Every seat of one claimed match, capture included. Served by the
+// (match_id, player_id) unique index 00021 made the match's access path.
+func (q *Queries) ListMatchSeats(ctx context.Context, matchID string) ([]ListMatchSeatsRow, error) {
+	rows, err := q.db.Query(ctx, listMatchSeats, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMatchSeatsRow{}
+	for rows.Next() {
+		var i ListMatchSeatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PlayerID,
+			&i.Freemods,
+			&i.Log,
+			&i.FinalStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRunsForCalibration = `-- name: ListRunsForCalibration :many
 SELECT r.id, r.seed, r.dict_hash, r.score_version, r.setup, r.client_metrics,
        r.client_score, r.log, r.attempts, r.status, v.policy_version,
@@ -271,29 +797,126 @@ func (q *Queries) ListRunsForCalibration(ctx context.Context, rowLimit int32) ([
 	return items, nil
 }
 
+const markMatchValidated = `-- name: MarkMatchValidated :exec
+UPDATE matches
+SET validated_at = now()
+WHERE id = $1
+`
+
+// Take the match off the queue. Last statement of the judging transaction, so
+// "validated" and "every seat has a verdict" commit as one fact.
+func (q *Queries) MarkMatchValidated(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, markMatchValidated, id)
+	return err
+}
+
+const releaseDeadLetters = `-- name: ReleaseDeadLetters :many
+WITH released AS (
+    UPDATE runs
+    SET status           = 'pending',
+        attempts         = 0,
+        next_attempt_at  = NULL,
+        dead_lettered_at = NULL
+    WHERE id IN (SELECT d.id
+                 FROM runs d
+                 WHERE d.dead_lettered_at IS NOT NULL
+                   AND d.status = 'flagged'
+                   AND (cardinality($1::uuid[]) = 0 OR d.id = ANY ($1::uuid[]))
+                   AND NOT EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = d.id)
+                 ORDER BY d.dead_lettered_at
+                 LIMIT $2
+                 FOR UPDATE SKIP LOCKED)
+    RETURNING id
+)
+DELETE FROM run_verdicts v
+USING released
+WHERE v.run_id = released.id
+RETURNING v.run_id
+`
+
+type ReleaseDeadLettersParams struct {
+	Ids      []uuid.UUID
+	RowLimit int32
+}
+
+// `replayctl retry`: hand dead letters back to the queue as NEW work. The run
+// returns to 'pending' with a fresh attempt budget and its verdict row is
+// deleted in the same statement — a pending run has no verdict (00019), and
+// the flag being released was never a judgement of the run anyway, only a
+// record that the worker could not make one.
+//
+// An empty id list releases every dead letter, oldest first, up to row_limit.
+// A run a human has ruled on is never released: the override is the decision
+// (00028), and re-queuing it would let the worker write over it.
+func (q *Queries) ReleaseDeadLetters(ctx context.Context, arg ReleaseDeadLettersParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, releaseDeadLetters, arg.Ids, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var run_id uuid.UUID
+		if err := rows.Scan(&run_id); err != nil {
+			return nil, err
+		}
+		items = append(items, run_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setMatchRunStatus = `-- name: SetMatchRunStatus :exec
+UPDATE match_runs
+SET status = $1
+WHERE id = $2
+`
+
+type SetMatchRunStatusParams struct {
+	Status string
+	ID     uuid.UUID
+}
+
+// The lifecycle half of a seat's decision, written beside its verdict row in
+// the same transaction — the pair ApplyRunOutcome/UpsertRunVerdict, for seats.
+func (q *Queries) SetMatchRunStatus(ctx context.Context, arg SetMatchRunStatusParams) error {
+	_, err := q.db.Exec(ctx, setMatchRunStatus, arg.Status, arg.ID)
+	return err
+}
+
 const upsertRunVerdict = `-- name: UpsertRunVerdict :exec
 INSERT INTO run_verdicts (run_id, user_id, server_metrics, server_score,
-                          validation, bundle_sha, policy_version, validated_at)
+                          validation, bundle_sha, policy_version, validated_at,
+                          shadow_label, shadow_status, shadow_validation)
 SELECT r.id, r.user_id, $1::jsonb, $2::jsonb,
-       $3::jsonb, $4, $5, now()
+       $3::jsonb, $4, $5, now(),
+       $6, $7, $8::jsonb
 FROM runs r
-WHERE r.id = $6
+WHERE r.id = $9
 ON CONFLICT (run_id) DO UPDATE SET
-    server_metrics = excluded.server_metrics,
-    server_score   = excluded.server_score,
-    validation     = excluded.validation,
-    bundle_sha     = excluded.bundle_sha,
-    policy_version = excluded.policy_version,
-    validated_at   = now()
+    server_metrics    = excluded.server_metrics,
+    server_score      = excluded.server_score,
+    validation        = excluded.validation,
+    bundle_sha        = excluded.bundle_sha,
+    policy_version    = excluded.policy_version,
+    validated_at      = now(),
+    shadow_label      = excluded.shadow_label,
+    shadow_status     = excluded.shadow_status,
+    shadow_validation = excluded.shadow_validation
 `
 
 type UpsertRunVerdictParams struct {
-	ServerMetrics json.RawMessage
-	ServerScore   json.RawMessage
-	Validation    json.RawMessage
-	BundleSha     *string
-	PolicyVersion *int16
-	RunID         uuid.UUID
+	ServerMetrics    json.RawMessage
+	ServerScore      json.RawMessage
+	Validation       json.RawMessage
+	BundleSha        *string
+	PolicyVersion    *int16
+	ShadowLabel      *string
+	ShadowStatus     *string
+	ShadowValidation json.RawMessage
+	RunID            uuid.UUID
 }
 
 // Record one verdict's payload. client_metrics / client_score on runs are
@@ -306,6 +929,10 @@ type UpsertRunVerdictParams struct {
 // user_id is sourced from the runs row right here (INSERT..SELECT), so the
 // snapshot column cannot be miswritten by a caller and the Decision type
 // never needs to carry it. On conflict it is left alone: it is immutable.
+//
+// The shadow columns (00033) are overwritten like the rest, NULLs included: a
+// candidate's answer describes the judgement it was given beside, and one left
+// over from an earlier pass would be compared against a verdict it never saw.
 func (q *Queries) UpsertRunVerdict(ctx context.Context, arg UpsertRunVerdictParams) error {
 	_, err := q.db.Exec(ctx, upsertRunVerdict,
 		arg.ServerMetrics,
@@ -313,6 +940,9 @@ func (q *Queries) UpsertRunVerdict(ctx context.Context, arg UpsertRunVerdictPara
 		arg.Validation,
 		arg.BundleSha,
 		arg.PolicyVersion,
+		arg.ShadowLabel,
+		arg.ShadowStatus,
+		arg.ShadowValidation,
 		arg.RunID,
 	)
 	return err
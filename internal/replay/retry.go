@@ -0,0 +1,91 @@
+package replay
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Automatic retry of transient replay failures (docs/REPLAY.md, "Retry and
+// dead letters").
+//
+// A replay that timed out or threw says nothing about the run: the same log on
+// a quiet box replays in milliseconds. So the worker does not flag it on the
+// first failure any more. It puts the run back in the queue with an attempt
+// burnt and an exponentially growing wait, and only when the ceiling is
+// reached does the run land where every such run used to land at once —
+// flagged, with the reason recorded — plus a dead-letter mark that puts it in
+// front of an operator.
+//
+// The worker cannot tell a transient failure from a deterministic one (a
+// setup the core will refuse every time throws exactly like an interrupted
+// one), and it does not try: the ceiling is what bounds the cost of retrying
+// the second kind, and at the shipped defaults (platform.Config) that is five
+// replays over about eight minutes.
+
+// RetryPolicy is how a transient replay failure is retried.
+//
+// The zero value retries nothing — the first failure is final and the run is
+// dead-lettered at once — which is exactly the behaviour every decider had
+// before retries existed. That keeps a Decider built without WithRetry, and
+// every test that builds one, judging as it always has.
+type RetryPolicy struct {
+	// MaxAttempts is how many failed replays a run may accumulate before it is
+	// dead-lettered. The attempt that reaches it is the last one.
+	MaxAttempts int16
+	// BaseDelay is the wait after the first failure; each further failure
+	// doubles it.
+	BaseDelay time.Duration
+	// MaxDelay caps the doubling. Zero means uncapped.
+	MaxDelay time.Duration
+}
+
+// retries reports whether a run that has now failed attempts times goes back
+// to the queue rather than to the dead letters.
+func (r RetryPolicy) retries(attempts int16) bool {
+	return attempts < r.MaxAttempts
+}
+
+// Backoff is the wait before the retry that follows a run's attempts-th
+// failure: BaseDelay, 2×, 4×, … capped at MaxDelay.
+//
+// No jitter, on purpose. The claim is FOR UPDATE SKIP LOCKED with a
+// poll interval, not a thundering herd of clients: a hundred runs that timed
+// out together come due together and are drained a batch at a time, which is
+// the rate-limiting jitter would have bought.
+func (r RetryPolicy) Backoff(attempts int16) time.Duration {
+	if attempts < 1 || r.BaseDelay <= 0 {
+		return r.BaseDelay
+	}
+	d := r.BaseDelay
+	for range attempts - 1 {
+		if r.MaxDelay > 0 && d >= r.MaxDelay {
+			break
+		}
+		// Doubling past 2^62 ns would overflow long before any sane cap; a
+		// policy with no cap stops growing there rather than going negative.
+		if d > time.Duration(1<<62) {
+			break
+		}
+		d *= 2
+	}
+	if r.MaxDelay > 0 && d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	return d
+}
+
+// DeadLetter is one run the worker stopped retrying, as `replayctl
+// dead-letters` reports it.
+type DeadLetter struct {
+	RunID    uuid.UUID
+	UserID   uuid.UUID
+	Mode     string
+	Lang     string
+	Reason   string
+	Attempts int16
+	// LastError is the failure the worker recorded on its final attempt.
+	LastError      string
+	DeadLetteredAt time.Time
+	CreatedAt      time.Time
+}
@@ -0,0 +1,119 @@
+package replay_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay"
+	replaypg "github.com/typemore/typemore-server/internal/replay/pgstore"
+)
+
+// The whole retry lifecycle against a real queue: a failure defers the run
+// with no verdict, the backoff hides it from the claim, the last attempt
+// dead-letters it, and `retry` hands it back as new work.
+func TestFailedReplayIsDeferredThenDeadLetteredThenReleased(t *testing.T) {
+	pool := newPool(t)
+	ctx := context.Background()
+	user := seedUser(t, pool)
+	id := insertPending(t, pool, user, loadVector(t, "words-clean"))
+	_, err := pool.Exec(ctx, `UPDATE runs SET score_version = 99 WHERE id = $1`, id)
+	require.NoError(t, err)
+
+	queue := replaypg.New(pool, nil)
+	w := newTestWorker(t, queue, replay.WorkerConfig{
+		BatchSize: 10,
+		Decider: fakeDecider(t).WithRetry(replay.RetryPolicy{
+			MaxAttempts: 2, BaseDelay: time.Hour,
+		}),
+	})
+	core, err := replay.NewCore(replay.DefaultReplayTimeout)
+	require.NoError(t, err)
+
+	// First failure: still pending, an attempt burnt, no verdict row.
+	n, err := w.RunBatch(ctx, core, discardLogger())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	row := fetchRun(t, pool, id)
+	assert.Equal(t, replay.StatusPending, row.Status)
+	assert.EqualValues(t, 1, row.Attempts)
+	require.NotNil(t, row.LastError)
+	assert.Contains(t, *row.LastError, "score version 99")
+	assert.Nil(t, row.Validation, "a deferred run has not been judged")
+
+	// The backoff hides it from the claim…
+	n, err = w.RunBatch(ctx, core, discardLogger())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// …until it has run.
+	_, err = pool.Exec(ctx, `UPDATE runs SET next_attempt_at = now() - interval '1 second' WHERE id = $1`, id)
+	require.NoError(t, err)
+	n, err = w.RunBatch(ctx, core, discardLogger())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	// Second failure of two: flagged, dead-lettered, listed.
+	row = fetchRun(t, pool, id)
+	assert.Equal(t, replay.StatusFlagged, row.Status)
+	assert.EqualValues(t, 2, row.Attempts)
+	dead, err := queue.DeadLetters(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, id, dead[0].RunID)
+	assert.Equal(t, replay.ReasonReplayError, dead[0].Reason)
+	assert.EqualValues(t, 2, dead[0].Attempts)
+
+	// An id that is not a dead letter is skipped, not refused.
+	released, err := queue.ReleaseDeadLetters(ctx, []uuid.UUID{id, uuid.New()}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{id}, released)
+
+	row = fetchRun(t, pool, id)
+	assert.Equal(t, replay.StatusPending, row.Status)
+	assert.Zero(t, row.Attempts, "a released run gets a fresh budget")
+	assert.Nil(t, row.Validation, "pending <=> no verdict row, released runs included")
+	dead, err = queue.DeadLetters(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+
+	// And it is claimable at once.
+	n, err = w.RunBatch(ctx, core, discardLogger())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+// A run a moderator has ruled on stays ruled on: `retry -all` steps over it.
+func TestReleaseNeverTouchesAnOverriddenRun(t *testing.T) {
+	pool := newPool(t)
+	ctx := context.Background()
+	user := seedUser(t, pool)
+	id := insertPending(t, pool, user, loadVector(t, "words-clean"))
+	_, err := pool.Exec(ctx, `UPDATE runs SET score_version = 99 WHERE id = $1`, id)
+	require.NoError(t, err)
+
+	queue := replaypg.New(pool, nil)
+	w := newTestWorker(t, queue, replay.WorkerConfig{BatchSize: 10})
+	core, err := replay.NewCore(replay.DefaultReplayTimeout)
+	require.NoError(t, err)
+	_, err = w.RunBatch(ctx, core, discardLogger())
+	require.NoError(t, err)
+	require.Equal(t, replay.StatusFlagged, fetchRun(t, pool, id).Status)
+
+	// Still flagged, but by a human: one moderator cleared it, a second one
+	// put it back. The worker's dead-letter mark is still on the row.
+	_, err = pool.Exec(ctx, `
+		INSERT INTO run_status_overrides (run_id, from_status, to_status, reason, decided_by)
+		VALUES ($1, 'flagged', 'accepted', 'looked fine', $2),
+		       ($1, 'accepted', 'flagged', 'second opinion', $2)`, id, user)
+	require.NoError(t, err)
+
+	released, err := queue.ReleaseDeadLetters(ctx, nil, 100)
+	require.NoError(t, err)
+	assert.Empty(t, released)
+	assert.Equal(t, replay.StatusFlagged, fetchRun(t, pool, id).Status)
+}
@@ -0,0 +1,92 @@
+package replay
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay/policy/policytest"
+)
+
+func TestBackoffDoublesUpToTheCap(t *testing.T) {
+	r := RetryPolicy{MaxAttempts: 10, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+	want := []time.Duration{
+		30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute,
+	}
+	for i, w := range want {
+		assert.Equalf(t, w, r.Backoff(int16(i+1)), "after failure %d", i+1)
+	}
+
+	uncapped := RetryPolicy{BaseDelay: time.Second}
+	assert.Positive(t, uncapped.Backoff(200), "an uncapped backoff must stop growing, not overflow")
+}
+
+// The retry rows of the decision table: a transient failure goes back to the
+// queue while attempts remain, and is flagged AND dead-lettered on the one that
+// exhausts them.
+func TestTransientFailuresRetryUntilTheCeiling(t *testing.T) {
+	d := testDecider(t, policytest.NewFake()).WithRetry(RetryPolicy{
+		MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Hour,
+	})
+
+	for _, failure := range []error{ErrReplayTimeout, fmt.Errorf("core threw")} {
+		run := PendingRun{}
+		got := d.Decide(run, Result{}, failure)
+		assert.Equal(t, StatusPending, got.Status)
+		assert.EqualValues(t, 1, got.Attempts)
+		assert.Equal(t, 10*time.Second, got.RetryAfter)
+		assert.NotEmpty(t, got.LastError, "the operator still sees why")
+		assert.False(t, got.DeadLetter)
+
+		run.Attempts = 1
+		got = d.Decide(run, Result{}, failure)
+		assert.Equal(t, StatusPending, got.Status)
+		assert.Equal(t, 20*time.Second, got.RetryAfter)
+
+		run.Attempts = 2
+		got = d.Decide(run, Result{}, failure)
+		assert.Equal(t, StatusFlagged, got.Status, "the third failure of three is final")
+		assert.EqualValues(t, 3, got.Attempts)
+		assert.Zero(t, got.RetryAfter)
+		assert.True(t, got.DeadLetter)
+	}
+}
+
+// The zero policy is the rule that predates retries, so a decider nobody
+// configured still flags on the first failure — now with the mark.
+func TestWithoutARetryPolicyTheFirstFailureIsFinal(t *testing.T) {
+	got := testDecider(t, policytest.NewFake()).Decide(PendingRun{}, Result{}, ErrReplayTimeout)
+	require.Equal(t, StatusFlagged, got.Status)
+	assert.Equal(t, ReasonReplayTimeout, audit(t, got).Reason)
+	assert.True(t, got.DeadLetter)
+}
+
+// Only the ingestion queue retries. A re-judged run and a match seat have
+// verdicts and placements to keep consistent, and are dead-lettered at once.
+func TestRejudgementAndCapturesNeverRetry(t *testing.T) {
+	d := testDecider(t, policytest.NewFake()).WithRetry(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second})
+	for name, dd := range map[string]Decider{
+		"rejudgement": d.ForRejudgement(),
+		"capture":     d.ForCapture(),
+	} {
+		got := dd.Decide(PendingRun{}, Result{}, ErrReplayTimeout)
+		assert.Equalf(t, StatusFlagged, got.Status, name)
+		assert.Truef(t, got.DeadLetter, name)
+	}
+}
+
+// Failures that are not the core's — a dictionary or quote the registry does
+// not have — are operator problems, not weather: never retried, never a dead
+// letter, exactly as before.
+func TestRegistryGapsAreNotRetried(t *testing.T) {
+	d := testDecider(t, policytest.NewFake()).WithRetry(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second})
+	for _, err := range []error{ErrUnknownDict, ErrUnknownQuote} {
+		got := d.Decide(PendingRun{}, Result{}, err)
+		assert.Equal(t, StatusFlagged, got.Status)
+		assert.Zero(t, got.Attempts)
+		assert.False(t, got.DeadLetter)
+	}
+}
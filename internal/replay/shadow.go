@@ -0,0 +1,142 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/runstatus"
+)
+
+// Shadow is a candidate judge evaluated beside the real one on every run the
+// worker judges, and never acted on (docs/REPLAY.md, "Shadow policy").
+//
+// The question it answers is the one `replayctl calibrate` answers over stored
+// runs, asked of live traffic instead: what WOULD these weights and this
+// threshold have done? Calibrate is a forecast over the past and needs a
+// version bump and a `make revalidate` before anything it says reaches a run.
+// A shadow costs one extra Judge call per run — the flags are already in hand
+// — and its answer is stored beside the real verdict, where the admin report
+// (GET /api/v1/admin/runs/shadow) lists every run on which the two disagree.
+//
+// Label names the candidate in the stored rows. A candidate built from the same
+// policy code as the live one reports the same Version(), so the label, not the
+// version, is what tells one tuning experiment's rows from the next one's.
+type Shadow struct {
+	label   string
+	judge   policy.Judge
+	version int16
+}
+
+// ErrNoopShadow is returned for a candidate that judges nothing. Its answer is
+// "accept everything", which is known without running it.
+var ErrNoopShadow = errors.New("replay: a shadow candidate must judge something")
+
+// NewShadow binds a candidate judge under label, refusing one whose version
+// cannot be recorded or that is policy.Noop.
+func NewShadow(label string, j policy.Judge) (Shadow, error) {
+	if label == "" {
+		return Shadow{}, errors.New("replay: a shadow candidate needs a label")
+	}
+	if j == nil || policy.IsNoop(j) {
+		return Shadow{}, ErrNoopShadow
+	}
+	version, err := policy.ParseVersion(j.Version())
+	if err != nil {
+		return Shadow{}, fmt.Errorf("replay: shadow candidate: %w", err)
+	}
+	return Shadow{label: label, judge: j, version: version}, nil
+}
+
+// ProvideShadow builds the candidate from its tuning, through the same
+// policy.Provide the live judge comes from. On a build without a policy there
+// is no candidate to build, and that is an error rather than a warning: the
+// operator asked for a comparison that cannot happen.
+func ProvideShadow(label string, cfg policy.Config) (Shadow, error) {
+	j, err := policy.Provide(cfg)
+	if err != nil {
+		return Shadow{}, fmt.Errorf("replay: shadow candidate: %w", err)
+	}
+	return NewShadow(label, j)
+}
+
+// Label returns the name the candidate's rows are stored under.
+func (s Shadow) Label() string { return s.label }
+
+// Judge returns the candidate judge, for the composition root to report.
+func (s Shadow) Judge() policy.Judge { return s.judge }
+
+// WithShadow returns this decider set up to evaluate s beside its own judge.
+// The zero Shadow turns it off.
+//
+// A capture drops it: match_run_verdicts has nowhere to store the answer, and
+// a seat's verdict is placed with the rest of its match rather than reviewed on
+// its own. A re-judgement keeps it, so `make revalidate` refreshes the
+// candidate's answer along with the real one.
+func (p Decider) WithShadow(s Shadow) Decider {
+	if s.judge == nil {
+		p.shadow = nil
+		return p
+	}
+	p.shadow = &s
+	return p
+}
+
+// ShadowDecision is what the candidate would have made of a run. It is written
+// to run_verdicts beside the real verdict and changes nothing else.
+type ShadowDecision struct {
+	Label string
+	// Status is the candidate's would-be status: accepted or flagged. A run the
+	// candidate never saw — refused, unreplayable — has no ShadowDecision.
+	Status runstatus.Status
+	// Validation is the validation document the candidate would have written:
+	// the same report with the candidate's policy block and reason.
+	Validation json.RawMessage
+}
+
+// routing maps a judge's verdict onto a status and reason, drawing the
+// distinction the reason codes have always drawn: a run routed because a
+// SHAPE fired reads differently from one routed because a magnitude crossed a
+// line.
+func routing(v policy.Decision) (runstatus.Status, string) {
+	switch {
+	case !v.NeedsReview:
+		return StatusAccepted, ""
+	case len(v.Reasons) > 0:
+		return StatusFlagged, ReasonBotPattern
+	default:
+		return StatusFlagged, ReasonSuspicionThreshold
+	}
+}
+
+// withShadow attaches the candidate's answer to a decision the real judge's
+// path has finished. doc is the real decision's document; a mismatch in it
+// (score or metrics) was decided before any judge and is the candidate's
+// answer too, so only the judge's own rows can come out differently.
+func (p Decider) withShadow(d Decision, doc validationDoc, v *policy.Decision) Decision {
+	if p.shadow == nil || v == nil {
+		return d
+	}
+	doc.Policy = &policyDoc{
+		Version:      p.shadow.version,
+		Suspicion:    roundSuspicion(v.Suspicion),
+		Threshold:    v.Threshold,
+		Rules:        v.Reasons,
+		UnknownFlags: v.UnknownFlags,
+	}
+	doc.Engine = nil
+	status := d.Status
+	if doc.Divergence == nil {
+		status, doc.Reason = routing(*v)
+	}
+	if doc.Flags == nil {
+		doc.Flags = []Flag{}
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return d
+	}
+	d.Shadow = &ShadowDecision{Label: p.shadow.label, Status: status, Validation: raw}
+	return d
+}
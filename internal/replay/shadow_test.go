@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/replay/policy"
+	"github.com/typemore/typemore-server/internal/replay/policy/policytest"
+)
+
+// A replayed run whose numbers agree with the client's, raising the flags
+// given: everything the judge sees, and nothing a hard check would refuse.
+func shadowCase(flags ...Flag) (PendingRun, Result) {
+	run := PendingRun{
+		ScoreVersion:  scoreVersionV2,
+		ClientScore:   json.RawMessage(`{"total":412}`),
+		ClientMetrics: json.RawMessage(`{"wpm":88.5,"raw":90,"acc":0.97}`),
+	}
+	res := Result{
+		Verdict: verdictValid,
+		Flags:   flags,
+		Score:   json.RawMessage(`{"total":412}`),
+		Metrics: json.RawMessage(`{"wpm":88.5,"raw":90,"accuracy":0.97,"durationSec":30}`),
+	}
+	return run, res
+}
+
+func testShadow(t *testing.T, label string, j policy.Judge) Shadow {
+	t.Helper()
+	s, err := NewShadow(label, j)
+	require.NoError(t, err)
+	return s
+}
+
+func shadowAudit(t *testing.T, d Decision) validationDoc {
+	t.Helper()
+	require.NotNil(t, d.Shadow, "the candidate's answer was not recorded")
+	var doc validationDoc
+	require.NoError(t, json.Unmarshal(d.Shadow.Validation, &doc))
+	return doc
+}
+
+// The candidate is measured, never obeyed: whatever it says, the real decision
+// is byte for byte the one a decider without it makes.
+func TestAShadowNeverChangesTheVerdict(t *testing.T) {
+	live := testDecider(t, policytest.NewFake())
+	for name, tc := range map[string]struct {
+		candidate policy.Judge
+		flags     []Flag
+		want      string
+	}{
+		"stricter candidate flags what live accepts": {
+			candidate: policytest.NewFakeWithThreshold(0.1),
+			flags:     []Flag{{Code: "min-interval", Score: 1}},
+			want:      StatusFlagged,
+		},
+		"laxer candidate accepts what live flags": {
+			candidate: policytest.NewFakeWithThreshold(1e9),
+			flags:     []Flag{{Code: "paste", Score: 1}, {Code: "superhuman-burst", Score: 1}},
+			want:      StatusAccepted,
+		},
+		"same tuning agrees": {
+			candidate: policytest.NewFake(),
+			flags:     []Flag{{Code: "min-interval", Score: 1}},
+			want:      StatusAccepted,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run, res := shadowCase(tc.flags...)
+			plain := live.Decide(run, res, nil)
+			got := live.WithShadow(testShadow(t, "next", tc.candidate)).Decide(run, res, nil)
+
+			assert.Equal(t, plain.Status, got.Status)
+			assert.JSONEq(t, string(plain.Validation), string(got.Validation))
+			assert.Equal(t, plain.PolicyVersion, got.PolicyVersion)
+			assert.Nil(t, plain.Shadow)
+
+			require.NotNil(t, got.Shadow)
+			assert.Equal(t, "next", got.Shadow.Label)
+			assert.Equal(t, tc.want, got.Shadow.Status)
+		})
+	}
+}
+
+// The candidate's document is the live one with the candidate's arithmetic:
+// same flags, its own suspicion, threshold and reason.
+func TestAShadowRecordsItsOwnArithmetic(t *testing.T) {
+	run, res := shadowCase(Flag{Code: "min-interval", Score: 1})
+	d := testDecider(t, policytest.NewFake()).
+		WithShadow(testShadow(t, "strict", policytest.NewFakeWithThreshold(0.1))).
+		Decide(run, res, nil)
+
+	live, shadow := audit(t, d), shadowAudit(t, d)
+	assert.Empty(t, live.Reason)
+	assert.Equal(t, ReasonSuspicionThreshold, shadow.Reason)
+	assert.Equal(t, live.Flags, shadow.Flags)
+	require.NotNil(t, live.Policy)
+	require.NotNil(t, shadow.Policy)
+	assert.Equal(t, live.Policy.Suspicion, shadow.Policy.Suspicion, "same weights, same flags")
+	assert.InDelta(t, 0.1, shadow.Policy.Threshold, 1e-12)
+	assert.InDelta(t, policytest.FakeThreshold, live.Policy.Threshold, 1e-12)
+}
+
+// A mismatch is decided before any judge, so it is the candidate's answer as
+// much as the live policy's — the two can only ever disagree about routing.
+func TestAShadowInheritsTheHardChecks(t *testing.T) {
+	run, res := shadowCase()
+	run.ClientScore = json.RawMessage(`{"total":9999}`)
+	d := testDecider(t, policytest.NewFake()).
+		WithShadow(testShadow(t, "lax", policytest.NewFakeWithThreshold(1e9))).
+		Decide(run, res, nil)
+
+	require.Equal(t, StatusFlagged, d.Status)
+	require.NotNil(t, d.Shadow)
+	assert.Equal(t, StatusFlagged, d.Shadow.Status)
+	assert.Equal(t, ReasonScoreMismatch, shadowAudit(t, d).Reason)
+}
+
+// A run no judge saw has no candidate answer either, and a match seat is never
+// shadowed: its verdict table has nowhere to put one.
+func TestAShadowOnlyAnswersWhatAJudgeSaw(t *testing.T) {
+	d := testDecider(t, policytest.NewFake()).
+		WithShadow(testShadow(t, "next", policytest.NewFakeWithThreshold(0)))
+
+	run, res := shadowCase(Flag{Code: "paste", Score: 1})
+	assert.Nil(t, d.Decide(run, res, ErrReplayTimeout).Shadow, "unreplayable")
+	assert.Nil(t, d.Decide(run, Result{Verdict: verdictInvalid, Reason: "seq"}, nil).Shadow, "refused")
+	assert.Nil(t, d.ForCapture().Decide(run, res, nil).Shadow, "capture")
+	assert.NotNil(t, d.ForRejudgement().Decide(run, res, nil).Shadow, "revalidation refreshes it")
+	assert.Nil(t, d.WithShadow(Shadow{}).Decide(run, res, nil).Shadow, "the zero Shadow turns it off")
+}
+
+func TestNewShadowRefusesACandidateThatCannotBeCompared(t *testing.T) {
+	_, err := NewShadow("next", policy.Noop{})
+	require.ErrorIs(t, err, ErrNoopShadow)
+	_, err = NewShadow("", policytest.NewFake())
+	require.Error(t, err, "an unlabelled candidate's rows could not be told apart")
+	_, err = NewShadow("next", brokenJudge("v5"))
+	require.Error(t, err)
+}
@@ -0,0 +1,414 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
+)
+
+// A timeline is a run's fold made visible: one frame per state event, carrying
+// the state the core holds after it — which word the cursor is in, what has
+// been typed there, whether it is still right, and the net WPM the run would
+// be credited with if it ended at that instant.
+//
+// It is produced by the bundle, not re-derived from it. Timeline drives
+// initialStateOf → settle → reduce over the sorted log exactly as foldLog does
+// inside validateLog, and reads every number back out of the core between
+// events (bufferOf, correctPrefixLength, netCharsOf). The only arithmetic in Go
+// is the division metricsFrom does — net chars / 5 / elapsed minutes — so the
+// last frame of a finished run carries the WPM its Metrics does. A viewer or a
+// "where did I lose time" chart built on these frames therefore cannot drift
+// from the verdict the way a second implementation of the reducer would.
+//
+// Telemetry events (key down/up) are not frames: reduce passes them through
+// without touching the state, so they would only repeat their neighbours.
+
+// FrameFinish is the Kind of the one frame no event produced: the core's clock
+// ending the run — a timed run's deadline, a min-speed failure — found by
+// settle between events or after the last one.
+const FrameFinish = "finish"
+
+// TimelineFrame is the state after one event (or after the finish).
+type TimelineFrame struct {
+	// Seq is the event's sequence number; absent on a FrameFinish frame.
+	Seq int64 `json:"seq,omitempty"`
+	// T is the instant in the log's own milliseconds: the event's t, or the
+	// finish instant the core settled on.
+	T    float64 `json:"t"`
+	Kind string  `json:"kind"`
+	// Phase is the core's phase: idle, running or finished.
+	Phase string `json:"phase"`
+	// Word is the index of the word the cursor is in; Target is that word and
+	// Typed is its buffer. Past the last word both are empty.
+	Word   int    `json:"word"`
+	Target string `json:"target"`
+	Typed  string `json:"typed"`
+	// Cursor is the position in Typed, in the UTF-16 units the core indexes
+	// words by — the offset a browser viewer can hand straight to a string.
+	Cursor int `json:"cursor"`
+	// Correct is whether Typed is still a prefix of Target.
+	Correct  bool    `json:"correct"`
+	NetChars int64   `json:"netChars"`
+	WPM      float64 `json:"wpm"`
+	// Completed is every word this event committed, in order. Usually one; an
+	// auto-committing insert at the end of a line can close more.
+	Completed []WordCompletion `json:"completed,omitempty"`
+	// FailReason is the core's reason for a finish that was not a clean one
+	// (minSpeed, master, expert).
+	FailReason string `json:"failReason,omitempty"`
+	// Refused is set on the last frame of a log the reducer rejected: the event
+	// it would not apply, with the state left as it was before it. It is the
+	// refusal validateLog turns into an invalid verdict.
+	Refused *CoreError `json:"refused,omitempty"`
+}
+
+// WordCompletion is one committed word and how long it took: Ms runs from the
+// previous word's commit, or from the run's start for the first.
+type WordCompletion struct {
+	Word    int     `json:"word"`
+	Target  string  `json:"target"`
+	Typed   string  `json:"typed"`
+	Correct bool    `json:"correct"`
+	At      float64 `json:"at"`
+	Ms      float64 `json:"ms"`
+}
+
+// ErrNoTimeline is an Engine with no goja core behind it to fold a timeline
+// with. Every engine NewEngine builds has one; a bare Native does not.
+var ErrNoTimeline = errors.New("replay: this engine cannot fold a timeline")
+
+// timelineEngine is the Engine side of Timeline. A timeline is always goja's:
+// the native port reproduces verdicts, not the intermediate state.
+type timelineEngine interface {
+	Timeline(ctx context.Context, in Input) ([]TimelineFrame, error)
+}
+
+var (
+	_ timelineEngine = (*Core)(nil)
+	_ timelineEngine = (*nativeEngine)(nil)
+	_ timelineEngine = (*differentialEngine)(nil)
+	_ timelineEngine = (*pinnedEngine)(nil)
+)
+
+// Timeline folds one run's log through core and returns its frames. The text
+// is resolved the way ReplayRun resolves it and the run's bundle pin holds, so
+// the frames are of the words and the build its verdict came from. A run
+// whose text cannot be resolved fails with the error ReplayRun would.
+func Timeline(ctx context.Context, core Engine, reg *Registry, quotes QuoteResolver, run PendingRun, canaryEpoch time.Time) ([]TimelineFrame, error) {
+	engine, ok := core.(timelineEngine)
+	if !ok {
+		return nil, ErrNoTimeline
+	}
+	in, err := inputOf(ctx, reg, quotes, run, canaryEpoch, nil)
+	if err != nil {
+		return nil, err
+	}
+	if in.Log, err = gunzip(run.Log); err != nil {
+		return nil, fmt.Errorf("replay: decompress log: %w", err)
+	}
+	return engine.Timeline(ctx, in)
+}
+
+// WriteTimeline writes frames as NDJSON: one frame per line, in order.
+func WriteTimeline(w io.Writer, frames []TimelineFrame) error {
+	enc := json.NewEncoder(w)
+	for _, f := range frames {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Timeliner serves timelines of stored runs.
+type Timeliner struct {
+	runs        RunSource
+	core        Engine
+	reg         *Registry
+	quotes      QuoteResolver
+	canaryEpoch time.Time
+}
+
+// NewTimeliner binds Timeline to a store and the server's replay inputs.
+func NewTimeliner(runs RunSource, core Engine, reg *Registry, quotes QuoteResolver, canaryEpoch time.Time) *Timeliner {
+	return &Timeliner{runs: runs, core: core, reg: reg, quotes: quotes, canaryEpoch: canaryEpoch}
+}
+
+// RunTimeline reads run id and folds its timeline. A run that does not exist
+// is ErrRunNotFound.
+func (t *Timeliner) RunTimeline(ctx context.Context, id uuid.UUID) ([]TimelineFrame, error) {
+	run, err := t.runs.StoredRun(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRunNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("replay: timeline of run %s: %w", id, err)
+	}
+	return Timeline(ctx, t.core, t.reg, t.quotes, run.PendingRun, t.canaryEpoch)
+}
+
+// Timeline folds in's log one event at a time and returns a frame per state
+// event. See the top of this file for what a frame is and why it is the core's.
+//
+// The whole fold runs under mu and the frames are returned rather than
+// streamed: a Core is shared by the server's explain and timeline reads, and a
+// slow client must not be able to hold its runtime. The caller streams the
+// encoding once the lock is released.
+func (c *Core) Timeline(ctx context.Context, in Input) ([]TimelineFrame, error) {
+	if in.Bundle != "" && in.Bundle != c.bundle.SHA {
+		return nil, fmt.Errorf("%w: input is pinned to %s, this core runs %s",
+			ErrUnknownBundle, shortSHA(in.Bundle), c.bundle.Short())
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	input, dictionary, err := c.assemble(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	coreCtx, err := c.coreContext(ctx, input, dictionary)
+	if err != nil {
+		return nil, err
+	}
+	logObj, ok := input.Get("log").(*goja.Object)
+	if !ok {
+		return nil, errors.New("replay: log is not an object")
+	}
+	sorted, err := c.call(ctx, c.sortEvents, logObj.Get("events"))
+	if err != nil {
+		return nil, err
+	}
+	events, ok := sorted.(*goja.Object)
+	if !ok {
+		return nil, errors.New("replay: sortEvents returned a non-array")
+	}
+	initial, err := c.call(ctx, c.initialStateOf, coreCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &timelineFold{c: c, ctx: coreCtx, state: initial, lastCommit: math.NaN()}
+	if f.words, ok = coreCtx.Get("words").(*goja.Object); !ok {
+		return nil, errors.New("replay: words is not an array")
+	}
+	var frames []TimelineFrame
+	n := int(events.Get("length").ToInteger())
+	for i := range n {
+		event, ok := events.Get(strconv.Itoa(i)).(*goja.Object)
+		if !ok {
+			return nil, fmt.Errorf("replay: event %d is not an object", i)
+		}
+		telemetry, err := c.call(ctx, c.isTelemetryEvent, event)
+		if err != nil {
+			return nil, err
+		}
+		if telemetry.ToBoolean() {
+			continue
+		}
+		at := event.Get("t")
+		finish, err := f.settle(ctx, at)
+		if err != nil {
+			return nil, err
+		}
+		if finish != nil {
+			frames = append(frames, *finish)
+		}
+
+		frame, refused, err := f.reduce(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+		if refused {
+			// foldLog stops at the first refusal, and so does the verdict.
+			return frames, nil
+		}
+	}
+	// foldLog's last settle, taken at the end of time: a timed run finishes at
+	// its deadline and a min-speed run at its fail instant, whichever comes
+	// first, and settle itself decides which — exactly as validateLog's settle
+	// at the deadline and its min-speed settle do together.
+	finish, err := f.settle(ctx, c.rt.ToValue(math.Inf(1)))
+	if err != nil {
+		return nil, err
+	}
+	if finish != nil {
+		frames = append(frames, *finish)
+	}
+	return frames, nil
+}
+
+// timelineFold is Timeline's walk through one log: the core's state between events,
+// and the last commit instant a word's time is measured from.
+type timelineFold struct {
+	c          *Core
+	ctx        *goja.Object
+	words      *goja.Object
+	state      goja.Value
+	lastCommit float64
+}
+
+// settle advances the state's clock to at. When that finishes the run, the
+// finish is returned as its own frame.
+func (f *timelineFold) settle(ctx context.Context, at goja.Value) (*TimelineFrame, error) {
+	was := phaseOf(f.state)
+	settled, err := f.c.call(ctx, f.c.settle, f.ctx, f.state, at)
+	if err != nil {
+		return nil, err
+	}
+	f.state = settled
+	if was == timelineFinished || phaseOf(settled) != timelineFinished {
+		return nil, nil
+	}
+	frame, err := f.frame(ctx, settled, numberOf(settled, "finishedAt"))
+	if err != nil {
+		return nil, err
+	}
+	frame.Kind = FrameFinish
+	return &frame, nil
+}
+
+// reduce applies one event. A refusal is reported, not returned as an error:
+// the frame it produces is the answer to "where did this log go wrong".
+func (f *timelineFold) reduce(ctx context.Context, event *goja.Object) (TimelineFrame, bool, error) {
+	seq := event.Get("seq").ToInteger()
+	at := event.Get("t").ToFloat()
+	kind := event.Get("kind").String()
+
+	result, err := f.c.call(ctx, f.c.reduce, f.ctx, f.state, event)
+	if err != nil {
+		return TimelineFrame{}, false, err
+	}
+	next, err := f.c.unwrapResult(ctx, result)
+	var refusal *CoreError
+	if errors.As(err, &refusal) {
+		frame, err := f.frame(ctx, f.state, at)
+		frame.Seq, frame.Kind, frame.Refused = seq, kind, refusal
+		return frame, true, err
+	}
+	if err != nil {
+		return TimelineFrame{}, false, err
+	}
+
+	before := wordIndexOf(f.state)
+	f.state = next
+	frame, err := f.frame(ctx, next, at)
+	if err != nil {
+		return TimelineFrame{}, false, err
+	}
+	frame.Seq, frame.Kind = seq, kind
+
+	// Every word the event moved the cursor past was committed by it.
+	if after := wordIndexOf(next); after > before {
+		if math.IsNaN(f.lastCommit) {
+			f.lastCommit = numberOf(next, "startedAt")
+		}
+		for w := before; w < after; w++ {
+			target, typed, err := f.word(ctx, next, w)
+			if err != nil {
+				return TimelineFrame{}, false, err
+			}
+			frame.Completed = append(frame.Completed, WordCompletion{
+				Word: w, Target: target.String(), Typed: typed.String(),
+				Correct: typed.String() == target.String(),
+				At:      at, Ms: at - f.lastCommit,
+			})
+			f.lastCommit = at
+		}
+	}
+	return frame, false, nil
+}
+
+// frame reads state back out of the core as it stands at instant at.
+func (f *timelineFold) frame(ctx context.Context, state goja.Value, at float64) (TimelineFrame, error) {
+	st, ok := state.(*goja.Object)
+	if !ok {
+		return TimelineFrame{}, errors.New("replay: fold state is not an object")
+	}
+	word := wordIndexOf(st)
+	target, typed, err := f.word(ctx, st, word)
+	if err != nil {
+		return TimelineFrame{}, err
+	}
+	prefix, err := f.c.call(ctx, f.c.correctPrefixLength, target, typed)
+	if err != nil {
+		return TimelineFrame{}, err
+	}
+	net, err := f.c.call(ctx, f.c.netCharsOf, f.ctx, st)
+	if err != nil {
+		return TimelineFrame{}, err
+	}
+	// bufferOf returns a JS string; its Length is in UTF-16 units, like the
+	// offsets correctPrefixLength counts in.
+	cursor := 0
+	if s, ok := typed.(goja.String); ok {
+		cursor = s.Length()
+	}
+	frame := TimelineFrame{
+		T:        at,
+		Phase:    phaseOf(st),
+		Word:     word,
+		Target:   target.String(),
+		Typed:    typed.String(),
+		Cursor:   cursor,
+		Correct:  int(prefix.ToInteger()) == cursor,
+		NetChars: net.ToInteger(),
+	}
+	// metricsFrom's wpm, at this instant: elapsedMinutes clamps at zero, and
+	// a run that has not started has typed nothing to be credited for.
+	if started := st.Get("startedAt"); !goja.IsNull(started) && !goja.IsUndefined(started) {
+		if minutes := math.Max(0, (at-started.ToFloat())/1e3) / 60; minutes > 0 {
+			frame.WPM = float64(frame.NetChars) / 5 / minutes
+		}
+	}
+	if reason := st.Get("failReason"); !goja.IsNull(reason) && !goja.IsUndefined(reason) {
+		frame.FailReason = reason.String()
+	}
+	return frame, nil
+}
+
+// word returns word w's target and its buffer in state. Past the end of the
+// word list both are the empty string, as they are to the core.
+func (f *timelineFold) word(ctx context.Context, state goja.Value, w int) (goja.Value, goja.Value, error) {
+	target := f.words.Get(strconv.Itoa(w))
+	if target == nil || goja.IsUndefined(target) {
+		target = f.c.rt.ToValue("")
+	}
+	typed, err := f.c.call(ctx, f.c.bufferOf, state, f.c.rt.ToValue(w))
+	if err != nil {
+		return nil, nil, err
+	}
+	return target, typed, nil
+}
+
+// timelineFinished is the core's finished phase, as the state spells it.
+const timelineFinished = "finished"
+
+func phaseOf(state goja.Value) string {
+	if st, ok := state.(*goja.Object); ok {
+		return st.Get("phase").String()
+	}
+	return ""
+}
+
+func wordIndexOf(state goja.Value) int {
+	if st, ok := state.(*goja.Object); ok {
+		return int(st.Get("wordIndex").ToInteger())
+	}
+	return 0
+}
+
+func numberOf(state goja.Value, field string) float64 {
+	if st, ok := state.(*goja.Object); ok {
+		return st.Get(field).ToFloat()
+	}
+	return math.NaN()
+}
@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func timelineOf(t *testing.T, run PendingRun) []TimelineFrame {
+	t.Helper()
+	_, reg := sharedDicts(t)
+	frames, err := Timeline(context.Background(), mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), run, time.Time{})
+	require.NoError(t, err)
+	require.NotEmpty(t, frames)
+	return frames
+}
+
+func metricsWPM(t *testing.T, run PendingRun) float64 {
+	t.Helper()
+	_, reg := sharedDicts(t)
+	res, err := ReplayRun(context.Background(), mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), run, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, verdictValid, res.Verdict)
+	var m struct {
+		WPM float64 `json:"wpm"`
+	}
+	require.NoError(t, json.Unmarshal(res.Metrics, &m))
+	return m.WPM
+}
+
+// The frames are the verdict's fold: a finished words run ends where its
+// Metrics do, and every word it committed is timed exactly once.
+func TestTimelineEndsWhereTheMetricsDo(t *testing.T) {
+	run := firstVector(t, "words-clean").pendingRun(t)
+	frames := timelineOf(t, run)
+
+	last := frames[len(frames)-1]
+	assert.Equal(t, timelineFinished, last.Phase)
+	assert.InDelta(t, metricsWPM(t, run), last.WPM, 1e-9)
+
+	var words []int
+	var prev int64
+	for _, f := range frames {
+		assert.Greater(t, f.Seq, prev, "one frame per event, in seq order")
+		prev = f.Seq
+		assert.Equal(t, f.Cursor, len(f.Typed), "an ASCII buffer's cursor is its length")
+		assert.Equal(t, strings.HasPrefix(f.Target, f.Typed), f.Correct, "seq %d", f.Seq)
+		for _, c := range f.Completed {
+			words = append(words, c.Word)
+			assert.Equal(t, f.T, c.At)
+			assert.Positive(t, c.Ms)
+		}
+	}
+	require.NotEmpty(t, words)
+	for i, w := range words {
+		assert.Equal(t, i, w, "words complete in order, once each")
+	}
+}
+
+// A timed run is ended by the clock, not by an event, and the timeline says
+// so with a frame of its own at the deadline.
+func TestTimelineShowsTheClockEndingATimedRun(t *testing.T) {
+	run := firstVector(t, "time-clean").pendingRun(t)
+	frames := timelineOf(t, run)
+
+	last := frames[len(frames)-1]
+	assert.Equal(t, FrameFinish, last.Kind)
+	assert.Zero(t, last.Seq)
+	assert.Equal(t, timelineFinished, last.Phase)
+	assert.Greater(t, last.T, frames[len(frames)-2].T)
+	assert.InDelta(t, metricsWPM(t, run), last.WPM, 1e-9)
+}
+
+// A log the reducer refuses stops at the refusal, and the last frame names it.
+func TestTimelineStopsAtTheRefusedEvent(t *testing.T) {
+	v := firstVector(t, "words-clean")
+	var log struct {
+		Version int               `json:"version"`
+		Events  []json.RawMessage `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(v.Payload.Log, &log))
+	require.Greater(t, len(log.Events), 10)
+	log.Events = append(log.Events[:6], append([]json.RawMessage{log.Events[5]}, log.Events[6:]...)...)
+	edited, err := json.Marshal(log)
+	require.NoError(t, err)
+	v.Payload.Log = edited
+
+	frames := timelineOf(t, v.pendingRun(t))
+	last := frames[len(frames)-1]
+	require.NotNil(t, last.Refused)
+	assert.Equal(t, "NonMonotonicSeq", last.Refused.Kind)
+	assert.Len(t, frames, 7, "six events applied, then the duplicate")
+	assert.Equal(t, frames[5].Typed, last.Typed, "a refused event leaves the state as it was")
+}
+
+func TestTimelineRefusesWhatItCannotFold(t *testing.T) {
+	_, reg := sharedDicts(t)
+	run := firstVector(t, "words-clean").pendingRun(t)
+
+	_, err := Timeline(context.Background(), NewNative(DefaultReplayTimeout), reg, goldenQuotes(t), run, time.Time{})
+	require.ErrorIs(t, err, ErrNoTimeline, "a bare port has no reducer state to show")
+
+	run.ReplayWith = sumBundle([]byte("never loaded"))
+	_, err = Timeline(context.Background(), mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), run, time.Time{})
+	require.ErrorIs(t, err, ErrUnknownBundle)
+
+	timeliner := NewTimeliner(storedRuns{}, mustCore(t, DefaultReplayTimeout), reg, goldenQuotes(t), time.Time{})
+	_, err = timeliner.RunTimeline(context.Background(), uuid.New())
+	require.ErrorIs(t, err, ErrRunNotFound)
+}
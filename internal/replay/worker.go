@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -38,9 +40,15 @@ type WorkerConfig struct {
 	PollInterval time.Duration
 	// BatchSize is how many runs one transaction claims.
 	BatchSize int32
-	// Concurrency is the number of independent workers. Each gets its own goja
-	// runtime; they share the queue through FOR UPDATE SKIP LOCKED.
+	// MatchBatchSize is how many MATCHES one transaction claims, when the
+	// worker judges match captures at all (WithMatches).
+	MatchBatchSize int32
+	// Concurrency is the number of independent workers. Each gets its own
+	// engine; they share the queue through FOR UPDATE SKIP LOCKED.
 	Concurrency int
+	// Engine selects what replays a run: the bundle in goja (the default), the
+	// native port, or both with their disagreements recorded. See Engine.
+	Engine EngineKind
 	// ReplayTimeout bounds a single core call.
 	ReplayTimeout time.Duration
 	// ShutdownGrace bounds how long an in-flight batch may take to finish after
@@ -72,12 +80,18 @@ func (c WorkerConfig) withDefaults() WorkerConfig {
 	if c.BatchSize <= 0 {
 		c.BatchSize = DefaultBatchSize
 	}
+	if c.MatchBatchSize <= 0 {
+		c.MatchBatchSize = DefaultMatchBatchSize
+	}
 	if c.Concurrency <= 0 {
 		c.Concurrency = DefaultConcurrency
 	}
 	if c.ReplayTimeout <= 0 {
 		c.ReplayTimeout = DefaultReplayTimeout
 	}
+	if c.Engine == "" {
+		c.Engine = EngineGoja
+	}
 	if c.ShutdownGrace <= 0 {
 		c.ShutdownGrace = 30 * time.Second
 	}
@@ -111,7 +125,7 @@ type QuoteResolver interface {
 // Worker turns pending runs into accepted/flagged/rejected ones.
 //
 // It owns no state beyond its dependencies: each goroutine claims a batch,
-// replays every run in it through its own goja runtime, and commits the
+// replays every run in it through its own engine, and commits the
 // verdicts in the transaction that claimed them.
 type Worker struct {
 	queue  Queue
@@ -119,6 +133,13 @@ type Worker struct {
 	quotes QuoteResolver
 	cfg    WorkerConfig
 	log    *slog.Logger
+	// matches is the match-capture queue; nil leaves captures unjudged, which
+	// is what a deployment without the relay, and every run-only test, has.
+	matches MatchQueue
+	// turn is the lane schedule's position (see laneOrder). Shared by every
+	// goroutine, so the schedule's shares hold across the whole worker rather
+	// than per goroutine.
+	turn atomic.Uint64
 }
 
 // NewWorker builds the worker. The registry supplies dictionary bodies by hash
@@ -128,13 +149,29 @@ func NewWorker(q Queue, reg *Registry, quotes QuoteResolver, cfg WorkerConfig, l
 	return &Worker{queue: q, reg: reg, quotes: quotes, cfg: cfg.withDefaults(), log: log}
 }
 
+// WithMatches attaches the match-capture queue (docs/MATCH.md §6). With it,
+// Run starts one more goroutine, with its own core, that drains unvalidated
+// matches beside the run queue. Nil leaves match captures unjudged.
+func (w *Worker) WithMatches(mq MatchQueue) *Worker {
+	w.matches = mq
+	return w
+}
+
 // Run blocks until ctx is cancelled, then returns once every in-flight batch has
-// finished. Each goroutine builds its own Core up front: a broken bundle is a
-// startup failure of the worker, not a per-run surprise.
+// finished. Each goroutine builds its own Engine up front: a broken bundle, or a
+// native port pinned to a bundle that is no longer vendored, is a startup
+// failure of the worker, not a per-run surprise.
 func (w *Worker) Run(ctx context.Context) error {
-	cores := make([]*Core, w.cfg.Concurrency)
+	n := w.cfg.Concurrency
+	if w.matches != nil {
+		// Its own goroutine and its own runtime rather than a turn in the run
+		// loop: a burst of match ends then never delays a solo verdict, and a
+		// solo backlog never leaves a scrim's results unpublished.
+		n++
+	}
+	cores := make([]Engine, n)
 	for i := range cores {
-		core, err := NewCore(w.cfg.ReplayTimeout)
+		core, err := NewEngine(w.cfg.Engine, w.cfg.ReplayTimeout, w.log)
 		if err != nil {
 			return fmt.Errorf("replay: build worker core: %w", err)
 		}
@@ -146,8 +183,12 @@ func (w *Worker) Run(ctx context.Context) error {
 		"batchSize", w.cfg.BatchSize,
 		"pollInterval", w.cfg.PollInterval,
 		"replayTimeout", w.cfg.ReplayTimeout,
+		"engine", w.cfg.Engine,
 		"bundleSha", bundleSHA[:12],
 		"policyVersion", w.cfg.Decider.Judge().Version(),
+		"retryMaxAttempts", w.cfg.Decider.retry.MaxAttempts,
+		"history", w.cfg.Decider.history != nil,
+		"matches", w.matches != nil,
 	)
 	if policy.IsNoop(w.cfg.Decider.Judge()) {
 		// The loudest place this can be said that is not the composition root.
@@ -157,11 +198,19 @@ func (w *Worker) Run(ctx context.Context) error {
 	}
 
 	var wg sync.WaitGroup
-	for i, core := range cores {
+	for i, core := range cores[:w.cfg.Concurrency] {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			w.loop(ctx, core, w.log.With("worker", i))
+			w.loop(ctx, core, w.log.With("worker", i), w.RunBatch, int(w.cfg.BatchSize))
+		}()
+	}
+	if w.matches != nil {
+		core := cores[len(cores)-1]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, core, w.log.With("worker", "matches"), w.RunMatchBatch, int(w.cfg.MatchBatchSize))
 		}()
 	}
 	wg.Wait()
@@ -170,8 +219,12 @@ func (w *Worker) Run(ctx context.Context) error {
 }
 
 // loop is one worker goroutine: drain the queue, sleep when it is empty, exit on
-// shutdown. A batch already started is always allowed to finish.
-func (w *Worker) loop(ctx context.Context, core *Core, log *slog.Logger) {
+// shutdown. A batch already started is always allowed to finish. batch is one
+// pass over whichever queue this goroutine drains, and full is the claim size
+// that means "there is probably more".
+func (w *Worker) loop(ctx context.Context, core Engine, log *slog.Logger,
+	batch func(context.Context, Engine, *slog.Logger) (int, error), full int,
+) {
 	timer := time.NewTimer(w.cfg.PollInterval)
 	defer timer.Stop()
 
@@ -184,13 +237,13 @@ func (w *Worker) loop(ctx context.Context, core *Core, log *slog.Logger) {
 		// want the verdicts committed, not rolled back on the way out. The
 		// grace timeout is what stops that from being unbounded.
 		batchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.cfg.ShutdownGrace)
-		claimed, err := w.RunBatch(batchCtx, core, log)
+		claimed, err := batch(batchCtx, core, log)
 		cancel()
 
 		switch {
 		case err != nil:
 			log.ErrorContext(ctx, "replay batch failed", "err", err)
-		case claimed == int(w.cfg.BatchSize):
+		case claimed == full:
 			// A full batch probably means a backlog: go straight round again.
 			continue
 		}
@@ -217,16 +270,29 @@ type batchTally struct {
 	flagged  int
 	rejected int
 	// failed counts runs whose replay itself failed (timeout / core error).
-	// They are also counted in `flagged` — the status they land in.
+	// Each is also counted in exactly one of `retried` (back to the queue with
+	// a backoff) or `flagged` (out of attempts: dead-lettered).
 	failed int
+	// retried counts failed runs deferred for another attempt.
+	retried int
+	// deadLettered counts failed runs the worker has stopped retrying.
+	deadLettered int
+	// shadowDisagreed counts runs a shadow candidate would have routed
+	// differently. Always zero without one.
+	shadowDisagreed int
+	// lanes counts claimed runs per priority lane, in Lanes order. All zero
+	// for a revalidation batch, whose runs are claimed from no lane.
+	lanes [4]int
 }
 
-// RunBatch claims and processes one batch of PENDING runs. Exported so tests
-// can drive exactly one pass without racing a poll loop.
-func (w *Worker) RunBatch(ctx context.Context, core *Core, log *slog.Logger) (int, error) {
-	return w.runBatch(ctx, core, log, "replay batch done", w.cfg.Decider,
+// RunBatch claims and processes one batch of PENDING runs, led by the lane
+// whose turn it is. Exported so tests can drive exactly one pass without racing
+// a poll loop.
+func (w *Worker) RunBatch(ctx context.Context, core Engine, log *slog.Logger) (int, error) {
+	lanes := laneOrder(w.turn.Add(1) - 1)
+	return w.runBatch(ctx, core, log.With("lead", lanes[0]), "replay batch done", w.cfg.Decider,
 		func(decide func(context.Context, PendingRun) Decision) (int, error) {
-			return w.queue.ProcessBatch(ctx, w.cfg.BatchSize, decide)
+			return w.queue.ProcessBatch(ctx, w.cfg.BatchSize, lanes, decide)
 		})
 }
 
@@ -241,19 +307,47 @@ func (w *Worker) RunBatch(ctx context.Context, core *Core, log *slog.Logger) (in
 // digests and revalidate the same rows forever.
 //
 // Idempotent: a run it touches stops matching both arms of the claim.
-func (w *Worker) RevalidateBatch(ctx context.Context, core *Core, log *slog.Logger) (int, error) {
+func (w *Worker) RevalidateBatch(ctx context.Context, core Engine, log *slog.Logger) (int, error) {
+	return w.RevalidateOnBundle(ctx, core, log, bundleSHA)
+}
+
+// RevalidateOnBundle is RevalidateBatch with the target build chosen: runs
+// behind the policy, or judged by any build but sha, are re-judged on sha. core
+// must be able to run it — NewPinnedEngine over a Bundles that holds it — and
+// the decisions record sha, so the pass converges on it exactly as
+// RevalidateBatch converges on the vendored build. Moving history BACK to an
+// archived build is how a bad rollout is undone.
+func (w *Worker) RevalidateOnBundle(ctx context.Context, core Engine, log *slog.Logger, sha string) (int, error) {
 	// ForRejudgement: these runs were judged once already, so the client's
 	// stored metrics are an archival record rather than a live claim and are not
 	// compared against. Every other row of the decision table is unchanged.
 	return w.runBatch(ctx, core, log, "revalidate batch done", w.cfg.Decider.ForRejudgement(),
 		func(decide func(context.Context, PendingRun) Decision) (int, error) {
-			return w.queue.ProcessStalePolicyBatch(ctx, w.cfg.Decider.version, bundleSHA, w.cfg.BatchSize, decide)
+			return w.queue.ProcessStalePolicyBatch(ctx, w.cfg.Decider.version, sha, w.cfg.BatchSize,
+				func(ctx context.Context, run PendingRun) Decision {
+					if sha != bundleSHA {
+						run.ReplayWith = sha
+					}
+					return decide(ctx, run)
+				})
+		})
+}
+
+// RevalidateOnRecordedBundles re-judges runs behind the policy on the build
+// that judged each of them, for every build in shas; bundle_sha is left as it
+// was. It is the pass for a policy change that should not also be a core
+// upgrade: a run judged by last month's bundle keeps last month's numbers and
+// gets this month's rules. core must hold every build in shas (NewPinnedEngine).
+func (w *Worker) RevalidateOnRecordedBundles(ctx context.Context, core Engine, log *slog.Logger, shas []string) (int, error) {
+	return w.runBatch(ctx, core, log, "revalidate batch done", w.cfg.Decider.ForRejudgement(),
+		func(decide func(context.Context, PendingRun) Decision) (int, error) {
+			return w.queue.ProcessRecordedBundleBatch(ctx, w.cfg.Decider.version, shas, w.cfg.BatchSize, decide)
 		})
 }
 
 func (w *Worker) runBatch(
 	ctx context.Context,
-	core *Core,
+	core Engine,
 	log *slog.Logger,
 	msg string,
 	decider Decider,
@@ -269,24 +363,97 @@ func (w *Worker) runBatch(
 			tally.accepted++
 		case StatusRejected:
 			tally.rejected++
+		case StatusPending:
+			tally.retried++
 		default:
 			tally.flagged++
 		}
 		if d.LastError != "" && d.Attempts > run.Attempts {
 			tally.failed++
 		}
+		if d.DeadLetter {
+			tally.deadLettered++
+		}
+		if d.Shadow != nil && d.Shadow.Status != d.Status {
+			tally.shadowDisagreed++
+		}
+		if i := slices.Index(Lanes, run.Lane); i >= 0 {
+			tally.lanes[i]++
+		}
 		return d
 	})
 	if err != nil {
 		return 0, err
 	}
 	if claimed > 0 {
-		log.InfoContext(ctx, msg,
+		attrs := []any{
 			"claimed", claimed,
 			"accepted", tally.accepted,
 			"flagged", tally.flagged,
 			"rejected", tally.rejected,
 			"failed", tally.failed,
+			"retried", tally.retried,
+			"deadLettered", tally.deadLettered,
+		}
+		if decider.shadow != nil {
+			attrs = append(attrs, "shadow", decider.shadow.label, "shadowDisagreed", tally.shadowDisagreed)
+		}
+		if tally.lanes != [4]int{} {
+			attrs = append(attrs, slog.Group("lanes",
+				LaneContender, tally.lanes[0],
+				LaneEvent, tally.lanes[1],
+				LaneRanked, tally.lanes[2],
+				LaneUnranked, tally.lanes[3],
+			))
+		}
+		log.InfoContext(ctx, msg, append(attrs, "tookMs", time.Since(started).Milliseconds())...)
+	}
+	return claimed, nil
+}
+
+// RunMatchBatch claims and judges one batch of unvalidated matches. Exported
+// for the same reason RunBatch is. A worker without a match queue claims
+// nothing.
+func (w *Worker) RunMatchBatch(ctx context.Context, core Engine, log *slog.Logger) (int, error) {
+	if w.matches == nil {
+		return 0, nil
+	}
+	var tally batchTally
+	seats := 0
+	started := time.Now()
+
+	claimed, err := w.matches.ProcessMatchBatch(ctx, w.cfg.MatchBatchSize,
+		func(ctx context.Context, m PendingMatch) []SeatVerdict {
+			verdicts := JudgeMatch(ctx, core, w.reg, w.quotes, w.cfg.Decider, m)
+			for _, v := range verdicts {
+				seats++
+				switch v.Status {
+				case StatusAccepted:
+					tally.accepted++
+				case StatusRejected:
+					tally.rejected++
+				default:
+					tally.flagged++
+				}
+				// A seat starts from zero attempts, so any increment is this
+				// replay failing — the same test runBatch applies to a run.
+				if v.LastError != "" && v.Attempts > 0 {
+					tally.failed++
+				}
+			}
+			return verdicts
+		})
+	if err != nil {
+		return 0, err
+	}
+	if claimed > 0 {
+		log.InfoContext(ctx, "match batch done",
+			"matches", claimed,
+			"seats", seats,
+			"accepted", tally.accepted,
+			"flagged", tally.flagged,
+			"rejected", tally.rejected,
+			"failed", tally.failed,
 			"tookMs", time.Since(started).Milliseconds(),
 		)
 	}
@@ -299,7 +466,17 @@ func (w *Worker) runBatch(
 //
 // Exported because the worker is not the only caller — `replayctl calibrate`
 // judges runs without writing, and it has to reach the verdict by exactly the
-// same route or its report would be a fiction.
+// same route or its report would be a fiction. When it has to decide one
+// replay under two deciders, it calls Judge's two halves — ReplayRun and
+// Decider.Resolve — itself.
+func Judge(ctx context.Context, core Engine, reg *Registry, quotes QuoteResolver, decider Decider, run PendingRun, canaryEpoch time.Time) Decision {
+	res, err := ReplayRun(ctx, core, reg, quotes, run, canaryEpoch)
+	return decider.Resolve(ctx, run, res, err)
+}
+
+// ReplayRun is the replay half of Judge: the run's text resolved and its log
+// folded through the core. An error is the replay failing, which Decide turns
+// into a decision like any other outcome.
 //
 // Where a run's TEXT comes from is decided here, once, before the core is
 // entered. A seeded run gets its dictionary body from the registry; a quote run
@@ -309,39 +486,66 @@ func (w *Worker) runBatch(
 //
 // canaryEpoch arms the core's canary detectors per run (see CanariesArmedAt);
 // the zero instant arms nothing at all.
-func Judge(ctx context.Context, core *Core, reg *Registry, quotes QuoteResolver, decider Decider, run PendingRun, canaryEpoch time.Time) Decision {
+func ReplayRun(ctx context.Context, core Engine, reg *Registry, quotes QuoteResolver, run PendingRun, canaryEpoch time.Time) (Result, error) {
+	return replayRun(ctx, core, reg, quotes, run, canaryEpoch, nil)
+}
+
+// replayRun is ReplayRun recording its steps on t, which Explain passes and
+// every other caller leaves nil.
+func replayRun(ctx context.Context, core Engine, reg *Registry, quotes QuoteResolver, run PendingRun, canaryEpoch time.Time, t *trace) (Result, error) {
+	in, err := inputOf(ctx, reg, quotes, run, canaryEpoch, t)
+	if err != nil {
+		return Result{}, err
+	}
+	if in.Log, err = gunzip(run.Log); err != nil {
+		err = fmt.Errorf("replay: decompress log: %w", err)
+		t.replayed(in, Result{}, err)
+		return Result{}, err
+	}
+	res, err := core.Replay(ctx, in)
+	t.replayed(in, res, err)
+	return res, err
+}
+
+// inputOf is everything a run is replayed with except its log: the text
+// resolved the way ReplayRun documents, the canary gate and the bundle pin.
+// Timeline builds its Input here too, so a timeline cannot be folded over
+// words the verdict was not.
+func inputOf(ctx context.Context, reg *Registry, quotes QuoteResolver, run PendingRun, canaryEpoch time.Time, t *trace) (Input, error) {
 	in := Input{
 		Seed:          run.Seed,
 		DictHash:      run.DictHash,
 		Setup:         run.Setup,
 		ScoreVersion:  run.ScoreVersion,
 		CanariesArmed: CanariesArmedAt(run.CreatedAt, canaryEpoch),
+		Bundle:        run.ReplayWith,
 	}
 
 	ref, isQuote, err := quoteRefOf(run.Setup)
 	if err != nil {
-		return decider.Decide(run, Result{}, err)
+		t.note(StepQuote, OutcomeFailed, "%v", err)
+		return Input{}, err
 	}
 	if isQuote {
 		quote, err := resolveQuote(ctx, quotes, ref)
 		if err != nil {
-			return decider.Decide(run, Result{}, err)
+			t.note(StepQuote, OutcomeFailed, "%v", err)
+			return Input{}, err
 		}
+		t.note(StepQuote, OutcomeOK, "quote %s resolved at text hash %s", ref.ID, quote.Hash)
+		t.note(StepDictionary, OutcomeSkipped, "a quote run's text comes from the quote registry")
 		in.Quote = quote
 	} else {
+		t.note(StepQuote, OutcomeSkipped, "a seeded run: its text is generated from a dictionary")
 		body, ok := reg.Body(run.DictHash)
 		if !ok {
-			return decider.Decide(run, Result{}, ErrUnknownDict)
+			t.note(StepDictionary, OutcomeFailed, "dict_hash %s is not in the registry", run.DictHash)
+			return Input{}, ErrUnknownDict
 		}
+		t.note(StepDictionary, OutcomeOK, "dict_hash %s resolved (%d bytes), seed %d", run.DictHash, len(body), run.Seed)
 		in.DictBody = body
 	}
-
-	if in.Log, err = gunzip(run.Log); err != nil {
-		return decider.Decide(run, Result{}, fmt.Errorf("replay: decompress log: %w", err))
-	}
-
-	res, err := core.Replay(ctx, in)
-	return decider.Decide(run, res, err)
+	return in, nil
 }
 
 // CanariesArmedAt reports whether a run created at createdAt is judged with the
@@ -166,18 +166,40 @@ type fakeQueue struct {
 	pending   []PendingRun
 	decisions map[uuid.UUID]Decision
 	batches   int
+	// orders is the lane order each ProcessBatch was asked to claim in.
+	orders [][]string
 }
 
 func newFakeQueue(runs ...PendingRun) *fakeQueue {
 	return &fakeQueue{pending: runs, decisions: make(map[uuid.UUID]Decision)}
 }
 
-func (q *fakeQueue) ProcessBatch(ctx context.Context, limit int32, decide func(context.Context, PendingRun) Decision) (int, error) {
+// ProcessBatch claims lane by lane in the order given, like the real claim. A
+// run with no Lane sits in the ranked lane, which is the column's default.
+func (q *fakeQueue) ProcessBatch(ctx context.Context, limit int32, lanes []string, decide func(context.Context, PendingRun) Decision) (int, error) {
 	q.mu.Lock()
 	q.batches++
-	n := min(int(limit), len(q.pending))
-	batch := q.pending[:n]
-	q.pending = q.pending[n:]
+	q.orders = append(q.orders, lanes)
+	if lanes == nil {
+		lanes = Lanes
+	}
+	var batch []PendingRun
+	for _, lane := range lanes {
+		rest := q.pending[:0:0]
+		for _, run := range q.pending {
+			in := run.Lane
+			if in == "" {
+				in = LaneRanked
+			}
+			if in == lane && len(batch) < int(limit) {
+				batch = append(batch, run)
+			} else {
+				rest = append(rest, run)
+			}
+		}
+		q.pending = rest
+	}
+	n := len(batch)
 	q.mu.Unlock()
 
 	for i := range batch {
@@ -198,6 +220,16 @@ func (q *fakeQueue) ProcessStalePolicyBatch(context.Context, int16, string, int3
 	return 0, nil
 }
 
+// ProcessRecordedBundleBatch hands out the fake's runs pinned to the first
+// bundle given, so the pinning can be asserted without a database; the claim's
+// SQL is exercised in queue_pg_test.go.
+func (q *fakeQueue) ProcessRecordedBundleBatch(ctx context.Context, _ int16, bundles []string, limit int32, decide func(context.Context, PendingRun) Decision) (int, error) {
+	return q.ProcessBatch(ctx, limit, nil, func(ctx context.Context, run PendingRun) Decision {
+		run.ReplayWith = bundles[0]
+		return decide(ctx, run)
+	})
+}
+
 func (q *fakeQueue) decision(t *testing.T, id uuid.UUID) Decision {
 	t.Helper()
 	q.mu.Lock()
@@ -1056,6 +1088,11 @@ func TestBundleExportsAreBound(t *testing.T) {
 		{"validateLog", core.validateLog},
 		{"scoreOfLog", core.scoreOfLog},
 		{"scoreV2OfLog", core.scoreV2OfLog},
+		{"initialStateOf", core.initialStateOf},
+		{"settle", core.settle},
+		{"reduce", core.reduce},
+		{"bufferOf", core.bufferOf},
+		{"netCharsOf", core.netCharsOf},
 	} {
 		assert.NotNil(t, fn.got, "core export %q is not bound", fn.name)
 	}
@@ -0,0 +1,89 @@
+package runs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// DailyAttempt is what the daily gate is shown about a run that declared itself
+// an attempt at a day's challenge: the day, and the coordinates the challenge
+// pins. Setup travels whole because the mods and the quote are read out of it
+// by the same SQL functions the boards use, never by a second parser here.
+type DailyAttempt struct {
+	Day        time.Time
+	Mode       string
+	DurationMs *int32
+	WordCount  *int32
+	Lang       string
+	Seed       int64
+	Setup      json.RawMessage
+}
+
+// DailyGate decides whether a declared daily attempt may be stored as one
+// (docs/DAILY.md). Declared here and implemented over internal/daily by the
+// composition root; nil refuses every declared attempt with 503, because a run
+// filed as an attempt at a challenge nobody is serving would spend the player's
+// one attempt on nothing.
+//
+// It answers nil, ErrDailyClosed or ErrDailyMismatch. "Already attempted" is
+// not its question: the runs table answers that, atomically, when the run is
+// inserted (ErrDailyAttempted).
+type DailyGate interface {
+	AdmitDaily(ctx context.Context, a DailyAttempt) error
+}
+
+// The daily gate's refusals, and the store's. Sentinels in this package so the
+// composition root's adapter has something to translate into.
+var (
+	// ErrDailyClosed: the declared day is not open for attempts — not today,
+	// and not yesterday inside the grace period either.
+	ErrDailyClosed = errors.New("runs: that day's challenge is not open")
+	// ErrDailyMismatch: the run is not the challenge it declared — another
+	// shape, language, seed, text or mod set, or a seeded repeat.
+	ErrDailyMismatch = errors.New("runs: run does not match the daily challenge")
+	// ErrDailyAttempted: the player already has an attempt stored for that day.
+	ErrDailyAttempted = errors.New("runs: daily challenge already attempted")
+)
+
+// WithDaily attaches the daily-challenge gate.
+func (s *Service) WithDaily(g DailyGate) *Service {
+	s.daily = g
+	return s
+}
+
+var (
+	apiErrDailyClosed = newAPIError(http.StatusUnprocessableEntity, codeDailyClosed,
+		"that day's challenge is not open for attempts")
+	apiErrDailyMismatch = newAPIError(http.StatusUnprocessableEntity, codeDailyMismatch,
+		"this run is not the challenge it declares; see GET /api/v1/daily")
+	// 409, not 422: the run is fine and would be accepted on any other day. It
+	// is the player's state that refuses it, exactly as a second override on an
+	// already-overridden run is refused.
+	apiErrDailyAttempted = newAPIError(http.StatusConflict, "daily_already_attempted",
+		"you have already attempted that day's challenge; the run was not stored")
+	apiErrDailyUnavailable = newAPIError(http.StatusServiceUnavailable, "unavailable",
+		"this deployment does not serve daily challenges")
+)
+
+// admitDaily puts a declared attempt in front of the gate, translating its
+// answer into the 422/503 a client can act on.
+func (s *Service) admitDaily(ctx context.Context, p CreateRunParams) error {
+	if s.daily == nil {
+		return apiErrDailyUnavailable
+	}
+	err := s.daily.AdmitDaily(ctx, DailyAttempt{
+		Day:  *p.DailyDay,
+		Mode: p.Mode, DurationMs: p.DurationMs, WordCount: p.WordCount,
+		Lang: p.Lang, Seed: p.Seed, Setup: p.Setup,
+	})
+	switch {
+	case errors.Is(err, ErrDailyClosed):
+		return apiErrDailyClosed
+	case errors.Is(err, ErrDailyMismatch):
+		return apiErrDailyMismatch
+	}
+	return err
+}
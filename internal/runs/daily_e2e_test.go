@@ -0,0 +1,103 @@
+package runs_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dailyPayload is the time-clean golden run declaring day, with the day's
+// challenge planted to be exactly that run — shape, language, seed and the
+// text-shaping mods read out of its own setup by the same function the board
+// uses.
+func dailyPayload(t *testing.T, h *harness, day string) map[string]any {
+	t.Helper()
+	payload := goldenPayload(t, "time-clean")
+	setup := payload["setup"].(map[string]any)
+	setup["daily"] = day
+
+	_, err := h.pool.Exec(context.Background(), `
+		INSERT INTO daily_challenges (day, mode, duration_ms, lang, seed, mods)
+		SELECT $1::date, 'time', 15000, 'german', 99117,
+		       (SELECT jsonb_object_agg(k, v) FROM jsonb_each(run_mods($2::jsonb)) AS m(k, v)
+		        WHERE k IN ('punctuation', 'numbers', 'randomCase', 'reverse'))
+		ON CONFLICT (day) DO NOTHING`, day, setup)
+	require.NoError(t, err)
+	return payload
+}
+
+// The whole daily chain through the real components: the attempt is admitted,
+// judged, and lands on the day's board AND its ordinary one — and the second
+// attempt is refused by the table before it is stored.
+func TestADailyAttemptRanksOnTheDayAndItsBoard(t *testing.T) {
+	h := newHarness(t)
+	h.login("daily@example.com", "correct horse battery", "dailyone")
+	today := time.Now().UTC().Format("2006-01-02")
+
+	payload := dailyPayload(t, h, today)
+	requireStatus(t, h.post("/api/v1/runs", payload), http.StatusAccepted)
+
+	again := h.post("/api/v1/runs", payload)
+	require.Equal(t, http.StatusConflict, again.StatusCode)
+	assert.Equal(t, "daily_already_attempted", decodeInto[errResp](t, again).Error)
+
+	var stored int
+	require.NoError(t, h.pool.QueryRow(context.Background(), `SELECT count(*) FROM runs`).Scan(&stored))
+	assert.Equal(t, 1, stored, "a refused attempt is not stored")
+
+	h.replayOnce(t)
+
+	type board struct {
+		Entries []struct {
+			DisplayName string `json:"displayName"`
+		} `json:"entries"`
+	}
+	day := decodeInto[board](t, h.get("/api/v1/leaderboards/daily:"+today))
+	require.Len(t, day.Entries, 1)
+	assert.Equal(t, "dailyone", day.Entries[0].DisplayName)
+
+	ordinary := decodeInto[board](t, h.get("/api/v1/leaderboards/time:15000:german:seeded"))
+	assert.Len(t, ordinary.Entries, 1, "the daily is an extra ranking, not a detour")
+
+	served := decodeInto[map[string]any](t, h.get("/api/v1/daily"))
+	assert.Equal(t, today, served["day"])
+	assert.Equal(t, "daily:"+today, served["board"])
+}
+
+func TestADailyAttemptMustBeTheChallengeOnAnOpenDay(t *testing.T) {
+	h := newHarness(t)
+	h.login("daily-refused@example.com", "correct horse battery", "dailytwo")
+	now := time.Now().UTC()
+
+	mismatched := dailyPayload(t, h, now.Format("2006-01-02"))
+	mismatched["seed"] = 99118
+	resp := h.post("/api/v1/runs", mismatched)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "daily_mismatch", decodeInto[errResp](t, resp).Error)
+
+	closed := dailyPayload(t, h, now.AddDate(0, 0, -2).Format("2006-01-02"))
+	resp = h.post("/api/v1/runs", closed)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "daily_closed", decodeInto[errResp](t, resp).Error)
+
+	for name, value := range map[string]any{
+		"not a date":      "today",
+		"not a calendar":  "2026-02-30",
+		"a number":        20261017,
+		"with a time":     "2026-10-17T00:00:00Z",
+		"an empty string": "",
+	} {
+		body := goldenPayload(t, "time-clean")
+		body["setup"].(map[string]any)["daily"] = value
+		resp := h.post("/api/v1/runs", body)
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, name)
+		assert.Equal(t, "invalid_daily", decodeInto[errResp](t, resp).Error, name)
+	}
+
+	// Nothing was stored, so nothing was spent: the player still has today.
+	requireStatus(t, h.post("/api/v1/runs", dailyPayload(t, h, now.Format("2006-01-02"))), http.StatusAccepted)
+}
@@ -0,0 +1,66 @@
+package runs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Explainer re-judges one stored run with a trace attached and returns the
+// explanation as JSON (docs/REPLAY.md, "`replayctl explain`"). Declared here
+// and implemented over the replay package by the composition root, which is
+// the only place that holds a replay core; this package never replays
+// anything itself.
+//
+// rejudge selects the table `make revalidate` judges by rather than the one a
+// submission is judged by. A run that does not exist is ErrNotFound.
+type Explainer interface {
+	ExplainRun(ctx context.Context, runID uuid.UUID, rejudge bool) (json.RawMessage, error)
+}
+
+// WithExplainer attaches the explain route's replay half. Nil leaves the route
+// answering 503, like the moderator seam beside it.
+func (s *Service) WithExplainer(e Explainer) *Service {
+	s.explainer = e
+	return s
+}
+
+// handleExplainRun is GET /admin/runs/{id}/explain. The explanation carries the
+// policy's weights flag by flag, which is exactly what the policy package keeps
+// out of every player-facing response — so it is a read-permission route and
+// nothing else.
+func (s *Service) handleExplainRun(w http.ResponseWriter, r *http.Request) {
+	if s.explainer == nil {
+		s.writeError(w, r, apiErrUnavailable)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, r, apiErrBadRequest("run id is not a uuid"))
+		return
+	}
+	rejudge := false
+	switch r.URL.Query().Get("mode") {
+	case "", "ingestion":
+	case "rejudgement":
+		rejudge = true
+	default:
+		s.writeError(w, r, apiErrBadRequest("mode must be one of ingestion, rejudgement"))
+		return
+	}
+	explanation, err := s.explainer.ExplainRun(r.Context(), id, rejudge)
+	if errors.Is(err, ErrNotFound) {
+		s.writeError(w, r, apiErrNotFound)
+		return
+	}
+	if err != nil {
+		s.log.Error("explain run", "err", err, "run", id)
+		s.writeError(w, r, apiErrInternal)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, explanation)
+}
@@ -0,0 +1,87 @@
+package runs_test
+
+// Following end-to-end (docs/PROFILE.md, "Following"): the three /me/following
+// routes over real HTTP, through the same session, Origin and profile gates
+// the production router mounts them behind. The board side of the scope is
+// covered in internal/leaderboard; what is worth defending here is that the
+// write refuses exactly what the doc says it refuses.
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type followingResp struct {
+	Following []struct {
+		Name       string    `json:"name"`
+		Public     bool      `json:"public"`
+		FollowedAt time.Time `json:"followedAt"`
+	} `json:"following"`
+}
+
+func (h *harness) followingNames(t *testing.T) []string {
+	t.Helper()
+	body := decodeInto[followingResp](t, h.get("/api/v1/me/following"))
+	names := make([]string, len(body.Following))
+	for i, f := range body.Following {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	h := newHarness(t)
+	h.login("alice@example.com", "correct horse battery", "alice")
+	h.login("bob@example.com", "correct horse battery", "bob")
+	h.login("me@example.com", "correct horse battery", "myself")
+
+	requireStatus(t, h.post("/api/v1/me/following/alice", nil), http.StatusNoContent)
+	requireStatus(t, h.post("/api/v1/me/following/BOB", nil), http.StatusNoContent)
+	requireStatus(t, h.post("/api/v1/me/following/bob", nil), http.StatusNoContent)
+	assert.Equal(t, []string{"bob", "alice"}, h.followingNames(t), "newest first, and once")
+
+	requireStatus(t, h.del("/api/v1/me/following/alice"), http.StatusNoContent)
+	requireStatus(t, h.del("/api/v1/me/following/alice"), http.StatusNoContent)
+	assert.Equal(t, []string{"bob"}, h.followingNames(t))
+}
+
+// A closed profile cannot be followed, but one that closes after the follow
+// stays on the list — flagged — and can still be left.
+func TestFollowRespectsTheProfileSwitch(t *testing.T) {
+	h := newHarness(t)
+	h.login("shy@example.com", "correct horse battery", "shy")
+	requireStatus(t, h.patch("/api/v1/me/settings", map[string]bool{"profilePublic": false}), http.StatusOK)
+	h.login("open@example.com", "correct horse battery", "open")
+	h.login("me@example.com", "correct horse battery", "myself")
+
+	requireStatus(t, h.post("/api/v1/me/following/shy", nil), http.StatusForbidden)
+	requireStatus(t, h.post("/api/v1/me/following/open", nil), http.StatusNoContent)
+
+	h.loginAs("open@example.com", "correct horse battery")
+	requireStatus(t, h.patch("/api/v1/me/settings", map[string]bool{"profilePublic": false}), http.StatusOK)
+
+	h.loginAs("me@example.com", "correct horse battery")
+	body := decodeInto[followingResp](t, h.get("/api/v1/me/following"))
+	require.Len(t, body.Following, 1)
+	assert.Equal(t, "open", body.Following[0].Name)
+	assert.False(t, body.Following[0].Public)
+
+	requireStatus(t, h.del("/api/v1/me/following/open"), http.StatusNoContent)
+	assert.Empty(t, h.followingNames(t))
+}
+
+func TestFollowRejects(t *testing.T) {
+	h := newHarness(t)
+	h.login("me@example.com", "correct horse battery", "myself")
+
+	requireStatus(t, h.post("/api/v1/me/following/myself", nil), http.StatusBadRequest)
+	requireStatus(t, h.post("/api/v1/me/following/nobody", nil), http.StatusNotFound)
+
+	h.logout()
+	requireStatus(t, h.get("/api/v1/me/following"), http.StatusUnauthorized)
+	requireStatus(t, h.post("/api/v1/me/following/myself", nil), http.StatusUnauthorized)
+}
@@ -40,17 +40,23 @@ const (
 // routes means the public ones cannot be widened by an unrelated change to the
 // mount.
 //
-// requireOrigin is the CSRF check (a no-op on safe methods) and requireAuth
-// rejects sessionless requests; both come from the auth domain via the
-// composition root.
-func (s *Service) Routes(requireOrigin, requireAuth func(http.Handler) http.Handler) http.Handler {
+// readGate guards the caller's own-runs reads and submitGate the ingestion.
+// Both come from the auth domain via the composition root, and both resolve
+// the caller (the CSRF check included where a browser is asking); they are two
+// because a personal access token may hold one capability without the other —
+// an exporter reads runs, it has no business submitting them.
+//
+// viewerGate sits between the two worlds, on GET /{id}/timeline: it resolves
+// the caller if there is one and rejects nobody, because that route is public
+// for an accepted run and the owner's for their own.
+func (s *Service) Routes(readGate, submitGate, viewerGate func(http.Handler) http.Handler) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/{id}/replay", s.handlePublicReplay)
 	r.Get("/{id}/replay/log", s.handlePublicReplayLog)
+	r.With(viewerGate).Get("/{id}/timeline", s.handleRunTimeline)
+	r.With(submitGate).Post("/", s.handleIngest)
 	r.Group(func(r chi.Router) {
-		r.Use(requireOrigin)
-		r.Use(requireAuth)
-		r.Post("/", s.handleIngest)
+		r.Use(readGate)
 		r.Get("/", s.handleList)
 		r.Get("/{id}", s.handleDetail)
 	})
@@ -65,7 +71,8 @@ type ingestResponse struct {
 }
 
 // handleIngest accepts a finished run: rate-limit → body cap → structural
-// validation → gzip + store 'pending' → 202 { id, status }.
+// validation → daily gate (declared attempts only) → gzip + store 'pending' →
+// 202 { id, status }.
 func (s *Service) handleIngest(w http.ResponseWriter, r *http.Request) {
 	userID, ok := s.currentUser(w, r)
 	if !ok {
@@ -122,7 +129,22 @@ func (s *Service) handleIngest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A declared daily attempt is checked against the day's challenge BEFORE it
+	// is stored: storing it is what spends the attempt, so a run that would
+	// have been filed against the wrong text has to be refused here, not
+	// discovered by the board later.
+	if params.DailyDay != nil {
+		if err := s.admitDaily(r.Context(), params); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+
 	run, err := s.store.CreateRun(r.Context(), params)
+	if errors.Is(err, ErrDailyAttempted) {
+		s.writeError(w, r, apiErrDailyAttempted)
+		return
+	}
 	if err != nil {
 		s.writeError(w, r, err)
 		return
@@ -224,8 +246,9 @@ func (s *Service) handleList(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, listResponse{Runs: views, NextCursor: next})
 }
 
-// handleDetail returns one own run's summary; with ?log=1 it streams the
-// gunzipped EventLog JSON instead (the frontend's later replay feature).
+// handleDetail returns one own run's summary, with its standing on its board
+// once it has been accepted; with ?log=1 it streams the gunzipped EventLog JSON
+// instead (the frontend's later replay feature).
 func (s *Service) handleDetail(w http.ResponseWriter, r *http.Request) {
 	userID, ok := s.currentUser(w, r)
 	if !ok {
@@ -260,7 +283,10 @@ func (s *Service) handleDetail(w http.ResponseWriter, r *http.Request) {
 		s.writeNotFoundOr(w, r, err)
 		return
 	}
-	s.writeJSON(w, http.StatusOK, toSummaryView(run))
+	s.writeJSON(w, http.StatusOK, detailView{
+		summaryView: toSummaryView(run),
+		Standing:    s.standingOf(r.Context(), run),
+	})
 }
 
 // replayView is one accepted run as a spectator sees it: everything needed to
@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
@@ -27,6 +28,8 @@ import (
 
 	"github.com/typemore/typemore-server/internal/auth"
 	authpg "github.com/typemore/typemore-server/internal/auth/pgstore"
+	"github.com/typemore/typemore-server/internal/daily"
+	dailypg "github.com/typemore/typemore-server/internal/daily/pgstore"
 	"github.com/typemore/typemore-server/internal/keyboard"
 	"github.com/typemore/typemore-server/internal/leaderboard"
 	leaderboardpg "github.com/typemore/typemore-server/internal/leaderboard/pgstore"
@@ -37,6 +40,8 @@ import (
 	profilepg "github.com/typemore/typemore-server/internal/profile/pgstore"
 	"github.com/typemore/typemore-server/internal/quote"
 	quotepg "github.com/typemore/typemore-server/internal/quote/pgstore"
+	"github.com/typemore/typemore-server/internal/rating"
+	ratingpg "github.com/typemore/typemore-server/internal/rating/pgstore"
 	"github.com/typemore/typemore-server/internal/runs"
 	runspg "github.com/typemore/typemore-server/internal/runs/pgstore"
 )
@@ -103,6 +108,10 @@ type harness struct {
 	// moderation is the same store the admin surface writes through — how these
 	// tests put a badge on an account without an admin session.
 	moderation *moderation.Store
+	// runs is the service behind the mount. The timeline route's replay half
+	// is attached through it by the tests that need one (enableTimeline),
+	// because building a replay core is not free and most tests never fold.
+	runs *runs.Service
 }
 
 type harnessOpts struct {
@@ -135,7 +144,7 @@ func newHarness(t *testing.T, mutators ...func(*harnessOpts)) *harness {
 	// `quotes` is in the list because the quote-run tests publish rows into it;
 	// a leftover revision would make a later test resolve a text it did not
 	// plant.
-	_, err = pool.Exec(ctx, `TRUNCATE quotes, leaderboard_entries, bans, runs, users, sessions, email_tokens, auth_identities, user_credentials RESTART IDENTITY CASCADE`)
+	_, err = pool.Exec(ctx, `TRUNCATE daily_challenges, quotes, leaderboard_entries, bans, runs, users, sessions, email_tokens, auth_identities, user_credentials RESTART IDENTITY CASCADE`)
 	require.NoError(t, err)
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -193,10 +202,21 @@ func newHarness(t *testing.T, mutators ...func(*harnessOpts)) *harness {
 	moderationSvc := moderation.NewService(moderationStore, actor, logger)
 	quoteAdminSvc := quote.NewAdminService(quoteStore, principal, logger)
 
+	// The daily challenge, wired as cmd/server does it: the real service behind
+	// the runs gate, the real board behind its archive. No quote picker — a
+	// quote day here would be a seeded one, and the daily suite covers quotes.
+	dailySvc := daily.NewService(dailypg.New(pool), nil, dailyBoards{boardStore},
+		daily.Config{
+			Langs: []string{"en"}, QuoteEvery: 0, Grace: 15 * time.Minute,
+			Shapes: []daily.Shape{{Mode: daily.ModeTime, Size: 15_000}},
+		}, logger)
+	runsSvc.WithDaily(dailyGate{dailySvc})
+	runsSvc.WithStandings(runStandings{boardStore})
+
 	boardSvc := leaderboard.NewService(boardStore, func(c context.Context) (uuid.UUID, bool) {
 		u, ok := auth.UserFrom(c)
 		return u.ID, ok
-	}, quoteStore.WithdrawnIDs, nil, logger)
+	}, quoteStore.WithdrawnIDs, nil, logger).WithQuoteGroups(quoteGroups{})
 
 	// Profile wired exactly as cmd/server does it, bucket parser adapter and
 	// layout namer included, so this suite exercises the same decoration
@@ -231,7 +251,7 @@ func newHarness(t *testing.T, mutators ...func(*harnessOpts)) *harness {
 				info.WordCount = &dim
 			}
 			return info, true
-		}, layouts.LayoutFor, logger)
+		}, layouts.LayoutFor, logger).WithRatings(ratingpg.New(pool))
 
 	r := chi.NewRouter()
 	r.Route("/api/v1", func(r chi.Router) {
@@ -239,11 +259,21 @@ func newHarness(t *testing.T, mutators ...func(*harnessOpts)) *harness {
 		r.With(authSvc.RequireAuth).Get("/me", authSvc.HandleMe)
 		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
 			Patch("/me/settings", authSvc.HandleUpdateSettings)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Post("/me/tokens", authSvc.HandleCreateAccessToken)
 		r.With(authSvc.RequireAuth).Get("/me/profile", profileSvc.HandleOwnProfile)
 		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
 			Patch("/me/profile", profileSvc.HandleUpdateProfile)
-		r.Mount("/runs", runsSvc.Routes(authSvc.RequireOrigin, authSvc.RequireAuth))
-		r.Mount("/profile", profileSvc.Routes(authSvc.RequireAuth))
+		r.With(authSvc.RequireAuth).Get("/me/following", profileSvc.HandleFollowing)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Post("/me/following/{name}", profileSvc.HandleFollow)
+		r.With(authSvc.RequireOrigin, authSvc.RequireAuth).
+			Delete("/me/following/{name}", profileSvc.HandleUnfollow)
+		r.Mount("/runs", runsSvc.Routes(
+			authSvc.RequireAuthOrToken(auth.ScopeRunsRead),
+			authSvc.RequireAuthOrToken(auth.ScopeRunsSubmit),
+			authSvc.OptionalAuthOrToken(auth.ScopeRunsRead)))
+		r.Mount("/profile", profileSvc.Routes(authSvc.RequireAuthOrToken(auth.ScopeProfileRead)))
 		r.Mount("/layouts", layouts.Routes(logger))
 		// /me needs a session; the auth middleware is applied inside the group
 		// so the two public routes stay public, as in cmd/server. The public
@@ -254,6 +284,8 @@ func newHarness(t *testing.T, mutators ...func(*harnessOpts)) *harness {
 			r.Mount("/leaderboards", boardSvc.Routes())
 			r.Mount("/users", profileSvc.PublicRoutes())
 		})
+		r.Mount("/rating", rating.NewService(ratingpg.New(pool), logger).Routes())
+		r.Mount("/daily", dailySvc.Routes())
 		// Reports and the admin subtree, wired exactly as cmd/server does it —
 		// real permission gates, and the ban surface mounted LAST on "/" under
 		// the sibling mounts. The mount ORDER is part of what this suite
@@ -287,7 +319,69 @@ func newHarness(t *testing.T, mutators ...func(*harnessOpts)) *harness {
 	}
 
 	return &harness{t: t, server: server, client: client, mailer: mailer, pool: pool,
-		board: boardStore, moderation: moderationStore}
+		board: boardStore, moderation: moderationStore, runs: runsSvc}
+}
+
+// dailyGate and dailyBoards are cmd/server's adapters between the daily
+// domain and its neighbours, repeated here because the composition root's are
+// unexported.
+type dailyGate struct{ svc *daily.Service }
+
+func (g dailyGate) AdmitDaily(ctx context.Context, a runs.DailyAttempt) error {
+	err := g.svc.Admit(ctx, daily.Attempt{
+		Day: a.Day, Mode: a.Mode, DurationMs: a.DurationMs, WordCount: a.WordCount,
+		Lang: a.Lang, Seed: a.Seed, Setup: a.Setup,
+	})
+	switch {
+	case errors.Is(err, daily.ErrClosed):
+		return runs.ErrDailyClosed
+	case errors.Is(err, daily.ErrMismatch):
+		return runs.ErrDailyMismatch
+	}
+	return err
+}
+
+type dailyBoards struct{ store *leaderboardpg.Store }
+
+// quoteGroups is cmd/server's adapter for the board index's group names.
+type quoteGroups struct{}
+
+func (quoteGroups) ParseGroup(name string) (int16, bool) {
+	g, err := quote.ParseLenGroup(name)
+	return int16(g), err == nil
+}
+
+func (quoteGroups) GroupName(group int16) string { return quote.LenGroup(group).String() }
+
+// runStandings is cmd/server's adapter for the detail view's standing.
+type runStandings struct{ store *leaderboardpg.Store }
+
+func (a runStandings) RunStanding(ctx context.Context, runID uuid.UUID) (runs.Standing, bool, error) {
+	bucket, st, ok, err := a.store.RunStanding(ctx, runID)
+	if err != nil || !ok {
+		return runs.Standing{}, false, err
+	}
+	return runs.Standing{
+		Board: bucket.Key(), Rank: st.Rank, Entries: st.Entries, Percentile: st.Percentile,
+	}, true, nil
+}
+
+func (b dailyBoards) BoardKey(day time.Time) string { return leaderboard.Bucket{Day: day}.Key() }
+
+func (b dailyBoards) Winner(ctx context.Context, day time.Time) (daily.Winner, bool, error) {
+	bucket, err := leaderboard.NewDailyBucket(day)
+	if err != nil {
+		return daily.Winner{}, false, err
+	}
+	top, err := b.store.Page(ctx, bucket, leaderboard.OrderScore, nil, 1)
+	if err != nil || len(top) == 0 {
+		return daily.Winner{}, false, err
+	}
+	e := top[0]
+	return daily.Winner{
+		UserID: e.UserID, DisplayName: e.DisplayName, RunID: e.RunID,
+		Score: e.Score, WPM: e.WPM, Acc: e.Acc,
+	}, true, nil
 }
 
 // --- HTTP helpers ---
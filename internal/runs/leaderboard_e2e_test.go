@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/typemore/typemore-server/internal/auth"
+	"github.com/typemore/typemore-server/internal/rating"
 )
 
 // The whole chain, once, through the real components: a real client payload is
@@ -42,10 +47,17 @@ func TestAcceptedRunAppearsOnItsLeaderboard(t *testing.T) {
 	}](t, h.get("/api/v1/leaderboards"))
 	require.Empty(t, empty.Buckets, "a pending run must not rank")
 
+	pending := decodeInto[map[string]any](t, h.get("/api/v1/runs/"+ingested.ID))
+	assert.NotContains(t, pending, "standing", "a pending run stands nowhere yet")
+
 	h.replayOnce(t)
 
 	after := decodeInto[map[string]any](t, h.get("/api/v1/runs/"+ingested.ID))
 	require.Equal(t, "accepted", after["status"], "validation: %v", after["validation"])
+	// Its detail says where it stands: alone on its board, so first of one.
+	assert.Equal(t, map[string]any{
+		"board": "time:15000:german:seeded", "rank": 1.0, "entries": 1.0, "percentile": 100.0,
+	}, after["standing"])
 
 	// The board index now knows the bucket the run was played in.
 	index := decodeInto[struct {
@@ -117,6 +129,53 @@ func TestAcceptedRunAppearsOnItsLeaderboard(t *testing.T) {
 // A run of an unranked shape is a perfectly good run — accepted, stored,
 // watchable — it just never reaches a board. words-clean is 10 words, and the
 // ranked word counts are 25/50/100 (SCORING_CONCEPT §4).
+// The same accepted run earns TP in the same verdict transaction, and the
+// rating shows on the global ranking and on its player's public header alike.
+func TestAcceptedRunEarnsTP(t *testing.T) {
+	h := newHarness(t)
+	userID := h.login("rated@example.com", "correct horse battery", "rated")
+
+	resp := h.post("/api/v1/runs", goldenPayload(t, "time-clean"))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	ingested := decodeInto[struct {
+		ID string `json:"id"`
+	}](t, resp)
+
+	type ranking struct {
+		Entries []struct {
+			Rank   int64   `json:"rank"`
+			UserID string  `json:"userId"`
+			TP     float64 `json:"tp"`
+			Runs   int32   `json:"runs"`
+		} `json:"entries"`
+	}
+	require.Empty(t, decodeInto[ranking](t, h.get("/api/v1/rating")).Entries,
+		"a pending run earns nothing")
+
+	h.replayOnce(t)
+
+	after := decodeInto[map[string]any](t, h.get("/api/v1/runs/"+ingested.ID))
+	require.Equal(t, "accepted", after["status"], "validation: %v", after["validation"])
+	metrics := after["serverMetrics"].(map[string]any)
+	want := rating.Current.RunTP(metrics["wpm"].(float64), metrics["accuracy"].(float64))
+
+	page := decodeInto[ranking](t, h.get("/api/v1/rating"))
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, userID, page.Entries[0].UserID)
+	assert.EqualValues(t, 1, page.Entries[0].Rank)
+	assert.EqualValues(t, 1, page.Entries[0].Runs)
+	assert.InDelta(t, want, page.Entries[0].TP, 1e-9)
+
+	header := decodeInto[struct {
+		TP     *float64 `json:"tp"`
+		TPRank *int64   `json:"tpRank"`
+	}](t, h.get("/api/v1/users/rated"))
+	require.NotNil(t, header.TP)
+	require.NotNil(t, header.TPRank)
+	assert.InDelta(t, want, *header.TP, 1e-9)
+	assert.EqualValues(t, 1, *header.TPRank)
+}
+
 func TestUnrankedShapeNeverRanks(t *testing.T) {
 	h := newHarness(t)
 	h.login("unranked@example.com", "correct horse battery", "unranked")
@@ -355,6 +414,81 @@ func TestBanHidesTheBoardRowAndTheReplay(t *testing.T) {
 	assert.Equal(t, http.StatusOK, h.get("/api/v1/runs/"+ingested.ID+"/replay/log").StatusCode)
 }
 
+// The timeline is the replay pair's third route and answers to the same
+// rules, with one addition: the owner reads their own run in any status, as
+// ?log=1 lets them — through the cookie or a runs:read token alike.
+func TestRunTimelineFollowsTheReplayRules(t *testing.T) {
+	h := newHarness(t)
+	tl := h.enableTimeline(t, auth.NewInMemoryRateLimiter(time.Millisecond, 1000))
+	userID := h.login("timeline@example.com", "correct horse battery", "timelined")
+
+	accepted := decodeInto[struct {
+		ID string `json:"id"`
+	}](t, h.post("/api/v1/runs", goldenPayload(t, "words-clean"))).ID
+	h.replayOnce(t)
+	pending := decodeInto[struct {
+		ID string `json:"id"`
+	}](t, h.post("/api/v1/runs", goldenPayload(t, "words-clean"))).ID
+	token := h.mintToken("runs:read")
+	h.logout()
+
+	timeline := func(id string) string { return "/api/v1/runs/" + id + "/timeline" }
+
+	// Anyone gets an accepted run's frames, one JSON object per line, ending
+	// where the run did.
+	resp := h.get(timeline(accepted))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(string(readBody(t, resp))), "\n")
+	require.NotEmpty(t, lines)
+	var last struct {
+		Phase string  `json:"phase"`
+		WPM   float64 `json:"wpm"`
+	}
+	for _, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &last), "every line is one frame")
+	}
+	assert.Equal(t, "finished", last.Phase)
+	assert.Positive(t, last.WPM)
+
+	// A run that is not public is the same 404 as one that never existed —
+	// until its owner asks.
+	assert.Equal(t, http.StatusNotFound, h.get(timeline(pending)).StatusCode)
+	assert.Equal(t, http.StatusNotFound,
+		h.get(timeline("00000000-0000-0000-0000-000000000000")).StatusCode)
+	assert.Equal(t, http.StatusNotFound, h.get(timeline("nonsense")).StatusCode)
+
+	resp = h.withToken(http.MethodGet, timeline(pending), token, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "a runs:read token is the owner")
+	assert.NotEmpty(t, readBody(t, resp))
+	resp = h.withToken(http.MethodGet, timeline(accepted), "tmpat_nonsense", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode,
+		"a bad token is refused, not downgraded to an anonymous caller")
+	_ = resp.Body.Close()
+
+	h.loginAs("timeline@example.com", "correct horse battery")
+	resp = h.get(timeline(pending))
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "the owner's cookie reads their own pending run")
+	_ = readBody(t, resp)
+	h.logout()
+
+	// A banned owner's run leaves this route with the replay pair.
+	h.ban(userID)
+	assert.Equal(t, http.StatusNotFound, h.get(timeline(accepted)).StatusCode)
+	h.unban(userID)
+
+	// The folds are rationed per IP, on a bucket of their own.
+	h.runs.WithTimeliner(tl, auth.NewInMemoryRateLimiter(time.Hour, 1))
+	resp = h.get(timeline(accepted))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = readBody(t, resp)
+	resp = h.get(timeline(accepted))
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, h.get("/api/v1/runs/"+accepted+"/replay/log").StatusCode,
+		"the replay pair's bucket is not the timeline's")
+}
+
 // A demotion applied to an already-ranked run must take its board slot with it,
 // through the same transaction that wrote the new status.
 func TestDemotionThroughTheWorkerLeavesTheBoard(t *testing.T) {
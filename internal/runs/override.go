@@ -85,6 +85,17 @@ type Moderator interface {
 	// by sortKey ('suspicion' | 'date' | 'player'), one page at a time; the
 	// second return is the pre-LIMIT total for pagination.
 	RunsForReview(ctx context.Context, minSuspicion float64, sortKey string, limit, offset int32) ([]ReviewRow, int64, error)
+	// ShadowTallies counts a candidate policy's answers against the live
+	// routing, per label ("" = every label).
+	ShadowTallies(ctx context.Context, label string) ([]ShadowTally, error)
+	// ShadowDisagreements lists one page of runs on which the candidate's
+	// would-be status differs from the live one, newest verdict first, narrowed
+	// to a label and to a candidate status ("" = no narrowing). The second
+	// return is the pre-LIMIT total.
+	ShadowDisagreements(ctx context.Context, label, shadowStatus string, limit, offset int32) ([]ShadowRow, int64, error)
+	// QueueLanes is the replay queue's depth per priority lane, every lane in
+	// priority order.
+	QueueLanes(ctx context.Context) ([]LaneStats, error)
 }
 
 // WithModerator attaches the operator surface. Nil leaves the admin routes
@@ -106,7 +117,10 @@ func (s *Service) AdminRoutes(requireRead, requireWrite func(http.Handler) http.
 	r.Group(func(r chi.Router) {
 		r.Use(requireRead)
 		r.Get("/review", s.handleReviewQueue)
+		r.Get("/shadow", s.handleShadowReport)
+		r.Get("/queue", s.handleQueueLanes)
 		r.Get("/{id}/overrides", s.handleRunOverrides)
+		r.Get("/{id}/explain", s.handleExplainRun)
 	})
 	r.Group(func(r chi.Router) {
 		r.Use(requireWrite)
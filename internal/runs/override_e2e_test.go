@@ -111,6 +111,95 @@ func TestOperatorOverrideRefusesNonDecisions(t *testing.T) {
 	assert.ErrorIs(t, err, runs.ErrNotFound)
 }
 
+// The shadow report compares a candidate policy against what the LIVE policy
+// decided, not against runs.status: an operator's override is nobody's policy.
+func TestShadowReportComparesTheCandidateWithTheLivePolicy(t *testing.T) {
+	ctx := context.Background()
+	h := newHarness(t)
+	store := runspg.New(h.pool)
+	player := h.player(t, "shadow-player")
+	admin := h.player(t, "shadow-admin")
+
+	agreed := h.judgedRun(t, player, runstatus.Accepted)
+	h.shadowed(t, agreed, "strict", runstatus.Accepted)
+	wouldFlag := h.judgedRun(t, player, runstatus.Accepted)
+	h.shadowed(t, wouldFlag, "strict", runstatus.Flagged)
+	wouldAccept := h.judgedRun(t, player, runstatus.Flagged)
+	h.liveReason(t, wouldAccept, "suspicion_threshold")
+	h.shadowed(t, wouldAccept, "strict", runstatus.Accepted)
+	otherLabel := h.judgedRun(t, player, runstatus.Accepted)
+	h.shadowed(t, otherLabel, "lax", runstatus.Flagged)
+	h.judgedRun(t, player, runstatus.Accepted) // never shadowed
+
+	// An override moves runs.status and nothing else: the candidate still
+	// disagrees with the policy that made the call.
+	_, err := store.OverrideRunStatus(ctx, runs.OverrideParams{
+		RunID: wouldFlag, ToStatus: runstatus.Flagged,
+		Reason: "looked at it", DecidedBy: admin,
+	})
+	require.NoError(t, err)
+
+	tallies, err := store.ShadowTallies(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tallies, 2)
+	assert.Equal(t, "lax", tallies[0].Label)
+	strict := tallies[1]
+	assert.Equal(t, "strict", strict.Label)
+	assert.EqualValues(t, 3, strict.Judged)
+	assert.EqualValues(t, 1, strict.Agree)
+	assert.EqualValues(t, 1, strict.WouldFlag)
+	assert.EqualValues(t, 1, strict.WouldAccept)
+
+	rows, total, err := store.ShadowDisagreements(ctx, "strict", "", 50, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	ids := []uuid.UUID{}
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+		if r.ID == wouldFlag {
+			assert.Equal(t, runstatus.Flagged, r.Status, "the current status")
+			assert.Equal(t, runstatus.Accepted, r.LiveStatus, "what the live policy decided")
+			assert.Equal(t, runstatus.Flagged, r.ShadowStatus)
+			assert.True(t, r.Overridden)
+			assert.InDelta(t, 0.9, r.ShadowSuspicion, 1e-9)
+		}
+	}
+	assert.ElementsMatch(t, []uuid.UUID{wouldFlag, wouldAccept}, ids)
+
+	rows, total, err = store.ShadowDisagreements(ctx, "strict", runstatus.Accepted, 50, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, rows, 1)
+	assert.Equal(t, wouldAccept, rows[0].ID)
+}
+
+// The queue stats name every lane, empty ones included, and count a run
+// sitting out a retry backoff as pending but not due.
+func TestQueueLanesCountTheBacklogPerLane(t *testing.T) {
+	ctx := context.Background()
+	h := newHarness(t)
+	store := runspg.New(h.pool)
+	player := h.player(t, "lane-player")
+
+	h.judgedRun(t, player, runstatus.Pending)
+	backedOff := h.judgedRun(t, player, runstatus.Pending)
+	contender := h.judgedRun(t, player, runstatus.Pending)
+	h.judgedRun(t, player, runstatus.Accepted) // judged: no longer waiting
+	_, err := h.pool.Exec(ctx, `UPDATE runs SET next_attempt_at = now() + interval '1 hour' WHERE id = $1`, backedOff)
+	require.NoError(t, err)
+	_, err = h.pool.Exec(ctx, `UPDATE runs SET lane = 'contender' WHERE id = $1`, contender)
+	require.NoError(t, err)
+
+	lanes, err := store.QueueLanes(ctx)
+	require.NoError(t, err)
+	require.Len(t, lanes, 4)
+	assert.Equal(t, runs.LaneStats{Lane: "contender", Pending: 1, Due: 1, OldestAgeMs: lanes[0].OldestAgeMs}, lanes[0])
+	assert.Equal(t, runs.LaneStats{Lane: "event"}, lanes[1], "an empty lane is still a lane")
+	assert.Equal(t, runs.LaneStats{Lane: "ranked", Pending: 2, Due: 1, OldestAgeMs: lanes[2].OldestAgeMs}, lanes[2])
+	assert.Equal(t, runs.LaneStats{Lane: "unranked"}, lanes[3])
+	assert.GreaterOrEqual(t, lanes[2].OldestAgeMs, lanes[0].OldestAgeMs, "the ranked lane's oldest run came first")
+}
+
 // --- helpers -----------------------------------------------------------------
 
 // judgedRun inserts one already-judged run directly: the submission plus the
@@ -186,3 +275,24 @@ func (h *harness) player(t *testing.T, name string) uuid.UUID {
 		`SELECT id FROM users WHERE display_name = $1`, name).Scan(&id))
 	return id
 }
+
+// shadowed records a candidate's answer on a judged run, as the worker's
+// upsert would.
+func (h *harness) shadowed(t *testing.T, runID uuid.UUID, label, status string) {
+	t.Helper()
+	_, err := h.pool.Exec(context.Background(), `
+		UPDATE run_verdicts
+		SET shadow_label = $2, shadow_status = $3,
+		    shadow_validation = '{"verdict":"valid","flags":[],"policy":{"suspicion":0.9,"threshold":0.5}}'::jsonb
+		WHERE run_id = $1`, runID, label, status)
+	require.NoError(t, err)
+}
+
+// liveReason marks a verdict as one the live policy flagged.
+func (h *harness) liveReason(t *testing.T, runID uuid.UUID, reason string) {
+	t.Helper()
+	_, err := h.pool.Exec(context.Background(), `
+		UPDATE run_verdicts SET validation = jsonb_set(validation, '{reason}', to_jsonb($2::text))
+		WHERE run_id = $1`, runID, reason)
+	require.NoError(t, err)
+}
@@ -193,3 +193,113 @@ func (s *Store) RunsForReview(ctx context.Context, minSuspicion float64, sortKey
 	}
 	return out, total, nil
 }
+
+// ShadowTallies folds the per-(label, live, candidate) counts into one tally
+// per candidate label, in label order.
+func (s *Store) ShadowTallies(ctx context.Context, label string) ([]runs.ShadowTally, error) {
+	rows, err := s.q.CountShadowVerdicts(ctx, label)
+	if err != nil {
+		return nil, fmt.Errorf("runs: shadow tallies: %w", err)
+	}
+	var out []runs.ShadowTally
+	for i := range rows {
+		if len(out) == 0 || out[len(out)-1].Label != rows[i].ShadowLabel {
+			out = append(out, runs.ShadowTally{
+				Label:         rows[i].ShadowLabel,
+				FirstJudgedAt: rows[i].FirstJudgedAt,
+				LastJudgedAt:  rows[i].LastJudgedAt,
+			})
+		}
+		t := &out[len(out)-1]
+		t.Judged += rows[i].Runs
+		switch {
+		case rows[i].LiveStatus == rows[i].ShadowStatus:
+			t.Agree += rows[i].Runs
+		case rows[i].ShadowStatus == runs.StatusFlagged:
+			t.WouldFlag += rows[i].Runs
+		default:
+			t.WouldAccept += rows[i].Runs
+		}
+		if rows[i].FirstJudgedAt.Before(t.FirstJudgedAt) {
+			t.FirstJudgedAt = rows[i].FirstJudgedAt
+		}
+		if rows[i].LastJudgedAt.After(t.LastJudgedAt) {
+			t.LastJudgedAt = rows[i].LastJudgedAt
+		}
+	}
+	return out, nil
+}
+
+// ShadowDisagreements lists one page of runs the candidate policy would have
+// routed differently. The second return is the pre-LIMIT total.
+func (s *Store) ShadowDisagreements(ctx context.Context, label, shadowStatus string, limit, offset int32) ([]runs.ShadowRow, int64, error) {
+	rows, err := s.q.ListShadowDisagreements(ctx, runsdb.ListShadowDisagreementsParams{
+		Label:        label,
+		ShadowStatus: shadowStatus,
+		RowLimit:     limit,
+		RowOffset:    offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("runs: shadow disagreements: %w", err)
+	}
+	var total int64
+	if len(rows) > 0 {
+		total = rows[0].Total
+	}
+	out := make([]runs.ShadowRow, 0, len(rows))
+	for i := range rows {
+		row := runs.ShadowRow{
+			ID:               rows[i].ID,
+			UserID:           rows[i].UserID,
+			Status:           rows[i].Status,
+			LiveStatus:       rows[i].LiveStatus,
+			ShadowStatus:     rows[i].ShadowStatus,
+			Label:            rows[i].ShadowLabel,
+			Mode:             rows[i].Mode,
+			Lang:             rows[i].Lang,
+			Suspicion:        numericFloat(rows[i].Suspicion),
+			ShadowSuspicion:  numericFloat(rows[i].ShadowSuspicion),
+			Overridden:       rows[i].AlreadyOverridden,
+			Validation:       rows[i].Validation,
+			ShadowValidation: rows[i].ShadowValidation,
+			CreatedAt:        rows[i].CreatedAt,
+			ValidatedAt:      rows[i].ValidatedAt,
+		}
+		if rows[i].DisplayName != nil {
+			row.DisplayName = *rows[i].DisplayName
+		}
+		out = append(out, row)
+	}
+	return out, total, nil
+}
+
+// numericFloat reads a suspicion out of a numeric column; NULL — a verdict
+// with no policy block — reads as zero, as the review queue reads it.
+func numericFloat(n pgtype.Numeric) float64 {
+	if !n.Valid {
+		return 0
+	}
+	f, err := n.Float64Value()
+	if err != nil {
+		return 0
+	}
+	return f.Float64
+}
+
+// QueueLanes reads the replay queue's depth per lane.
+func (s *Store) QueueLanes(ctx context.Context) ([]runs.LaneStats, error) {
+	rows, err := s.q.CountPendingByLane(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("runs: queue lanes: %w", err)
+	}
+	out := make([]runs.LaneStats, len(rows))
+	for i := range rows {
+		out[i] = runs.LaneStats{
+			Lane:        rows[i].Lane,
+			Pending:     rows[i].Pending,
+			Due:         rows[i].Due,
+			OldestAgeMs: rows[i].OldestAgeMs,
+		}
+	}
+	return out, nil
+}
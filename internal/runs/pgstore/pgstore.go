@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/typemore/typemore-server/internal/runs"
@@ -64,6 +65,13 @@ func (s *Store) CreateRun(ctx context.Context, p runs.CreateRunParams) (runs.Run
 		RestartsSinceLastSubmit: p.RestartsSinceLastSubmit,
 	})
 	if err != nil {
+		// The one-attempt-per-day rule is an index on runs (00037), so a second
+		// attempt is refused by this insert and by nothing before it — two
+		// tabs submitting at once cannot both get in.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "runs_one_daily_attempt" {
+			return runs.Run{}, runs.ErrDailyAttempted
+		}
 		return runs.Run{}, err
 	}
 	return runs.Run{ID: row.ID, Status: row.Status, CreatedAt: row.CreatedAt}, nil
@@ -4,12 +4,15 @@
 -- (the public log route); the summary queries deliberately never SELECT it.
 
 -- name: CreateRun :one
+-- The replay lane is filed here, from the run's own claim, and never moves
+-- (00034): it orders the replay queue and nothing else.
 INSERT INTO runs (
     user_id, mode, duration_ms, word_count, lang, seed, dict_hash,
     setup, client_metrics, client_score, score_version, log, log_bytes,
-    restarts_since_last_submit
+    restarts_since_last_submit, lane
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+    run_lane($1, $2, $3, $4, $5, $8, $10)
 )
 RETURNING id, status, created_at;
 
@@ -122,11 +125,12 @@ WHERE id = $1 AND user_id = $2;
 -- reach this data without them: the run must be ACCEPTED (a flagged, rejected
 -- or unjudged run is not a public artefact), its owner must not be banned, and
 -- — since public profiles — its owner's profile must be open OR the run must
--- hold a leaderboard slot. The last disjunct is the boundary between profile
--- privacy and the boards (docs/PROFILE.md, "Public profiles"): closing a
--- profile hides the aggregated history page, never a result its owner put into
--- a public ranking, so a board row's replay keeps working whatever the switch
--- says. All failures return no row, which the handler renders as one
+-- hold a leaderboard slot, live or archived. The last disjunct is the boundary
+-- between profile privacy and the boards (docs/PROFILE.md, "Public profiles"):
+-- closing a profile hides the aggregated history page, never a result its owner
+-- put into a public ranking, so a board row's replay keeps working whatever the
+-- switch says — and so does a row of last month's final standings, which is no
+-- longer in leaderboard_entries but is still on a page anyone can open. All failures return no row, which the handler renders as one
 -- indistinguishable 404 — a leaderboard must not leak who is under review.
 SELECT r.setup, v.server_metrics, v.server_score,
        run_grade((v.server_metrics ->> 'accuracy')::numeric)::text AS grade,
@@ -139,7 +143,9 @@ WHERE r.id = @run_id
   AND r.status = 'accepted'
   AND jsonb_typeof(v.server_metrics -> 'accuracy') = 'number'
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = r.user_id)
-  AND (u.profile_public OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id));
+  AND (u.profile_public
+       OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id)
+       OR EXISTS (SELECT 1 FROM leaderboard_snapshots s WHERE s.run_id = r.id));
 
 -- name: GetPublicReplayLog :one
 -- The stored gzip event log of one publicly watchable run, and nothing else, so
@@ -156,7 +162,9 @@ WHERE r.id = @run_id
   AND r.status = 'accepted'
   AND jsonb_typeof(v.server_metrics -> 'accuracy') = 'number'
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = r.user_id)
-  AND (u.profile_public OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id));
+  AND (u.profile_public
+       OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id)
+       OR EXISTS (SELECT 1 FROM leaderboard_snapshots s WHERE s.run_id = r.id));
 
 -- name: RunStatusForOverride :one
 -- The run as an operator override needs it: its current status, and whether a
@@ -221,3 +229,67 @@ ORDER BY
         THEN (v.validation -> 'policy' ->> 'suspicion')::numeric END DESC NULLS LAST,
     r.created_at DESC
 LIMIT @row_limit OFFSET @row_offset;
+
+-- name: ListShadowDisagreements :many
+-- The shadow report's list (00033): runs on which the candidate policy would
+-- have routed differently from the live one, newest verdict first.
+--
+-- The live side is the WORKER's routing, read off the verdict document — a
+-- valid run carries a reason exactly when the policy or a mismatch flagged it —
+-- and not runs.status. An operator's override is a human's answer, not the
+-- live policy's, and comparing the candidate against it would credit or blame
+-- the candidate for a decision no policy made. The current status and the
+-- override mark still ride along, because a reviewer reading "the candidate
+-- would have flagged this" wants to know a human already looked.
+--
+-- @label narrows to one candidate ('' = every label); @shadow_status to one
+-- direction of disagreement ('' = both).
+SELECT r.id, r.user_id, u.display_name, r.status, r.mode, r.lang, r.created_at,
+       v.shadow_label::text AS shadow_label,
+       (CASE WHEN v.validation ? 'reason' THEN 'flagged' ELSE 'accepted' END)::text AS live_status,
+       v.shadow_status::text AS shadow_status,
+       (v.validation -> 'policy' ->> 'suspicion')::numeric AS suspicion,
+       (v.shadow_validation -> 'policy' ->> 'suspicion')::numeric AS shadow_suspicion,
+       v.validation, v.shadow_validation, v.validated_at,
+       EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = r.id) AS already_overridden,
+       COUNT(*) OVER () AS total
+FROM run_verdicts v
+         JOIN runs r ON r.id = v.run_id
+         LEFT JOIN users u ON u.id = r.user_id
+WHERE v.shadow_label IS NOT NULL
+  AND (@label::text = '' OR v.shadow_label = @label::text)
+  AND (@shadow_status::text = '' OR v.shadow_status = @shadow_status::text)
+  AND v.shadow_status <> CASE WHEN v.validation ? 'reason' THEN 'flagged' ELSE 'accepted' END
+ORDER BY v.validated_at DESC, r.id
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: CountShadowVerdicts :many
+-- The shadow report's summary: every candidate answer tallied against the live
+-- routing, per label. Agreements are counted too — "disagreed on 40 runs" is
+-- unreadable without "out of how many".
+SELECT v.shadow_label::text AS shadow_label,
+       (CASE WHEN v.validation ? 'reason' THEN 'flagged' ELSE 'accepted' END)::text AS live_status,
+       v.shadow_status::text AS shadow_status,
+       COUNT(*) AS runs,
+       MIN(v.validated_at)::timestamptz AS first_judged_at,
+       MAX(v.validated_at)::timestamptz AS last_judged_at
+FROM run_verdicts v
+WHERE v.shadow_label IS NOT NULL
+  AND (@label::text = '' OR v.shadow_label = @label::text)
+GROUP BY 1, 2, 3
+ORDER BY 1, 2, 3;
+
+-- name: CountPendingByLane :many
+-- The replay queue's depth per priority lane (00034), every lane present even
+-- when empty: "nothing waiting" is an answer the admin stats should give, not
+-- a missing row. due excludes runs still sitting out a retry backoff, which are
+-- pending but not claimable. The age is the database's reading of its own
+-- clock, like the backoff it is compared with. Walks runs_pending_lane_idx.
+SELECT l.lane::text AS lane,
+       COUNT(r.id) AS pending,
+       COUNT(r.id) FILTER (WHERE r.next_attempt_at IS NULL OR r.next_attempt_at <= now()) AS due,
+       COALESCE((EXTRACT(EPOCH FROM now() - MIN(r.created_at)) * 1000)::bigint, 0)::bigint AS oldest_age_ms
+FROM unnest(ARRAY['contender', 'event', 'ranked', 'unranked']) WITH ORDINALITY AS l(lane, priority)
+         LEFT JOIN runs r ON r.lane = l.lane AND r.status = 'pending'
+GROUP BY l.lane, l.priority
+ORDER BY l.priority;
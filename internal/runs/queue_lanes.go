@@ -0,0 +1,46 @@
+package runs
+
+import "net/http"
+
+// THE REPLAY QUEUE, AS AN OPERATOR SEES IT.
+//
+// Runs wait for the replay worker in one of four priority lanes (docs/REPLAY.md,
+// "Priority lanes"), filed when they are submitted. The worker logs what each
+// batch took from which lane; this is the other half of the picture — what is
+// still waiting, per lane, and for how long. A lane whose oldest run keeps
+// ageing while the others drain is the schedule not doing its job.
+
+// LaneStats is one lane's backlog.
+type LaneStats struct {
+	Lane string `json:"lane"`
+	// Pending is every run waiting in the lane; Due is the part of it the
+	// worker may claim now, the rest sitting out a retry backoff.
+	Pending int64 `json:"pending"`
+	Due     int64 `json:"due"`
+	// OldestAgeMs is how long the lane's oldest pending run has waited; zero
+	// for an empty lane.
+	OldestAgeMs int64 `json:"oldestAgeMs"`
+}
+
+func (s *Service) handleQueueLanes(w http.ResponseWriter, r *http.Request) {
+	if s.moderator == nil {
+		s.writeError(w, r, apiErrUnavailable)
+		return
+	}
+	lanes, err := s.moderator.QueueLanes(r.Context())
+	if err != nil {
+		s.log.Error("queue lanes", "err", err)
+		s.writeError(w, r, apiErrInternal)
+		return
+	}
+	var pending, due int64
+	for _, l := range lanes {
+		pending += l.Pending
+		due += l.Due
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"lanes":   lanes,
+		"pending": pending,
+		"due":     due,
+	})
+}
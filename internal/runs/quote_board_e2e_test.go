@@ -107,8 +107,9 @@ func TestQuoteRunIsJudgedAndLandsOnItsOwnBoard(t *testing.T) {
 	assert.EqualValues(t, 1, board.Entries)
 	// A quote board has none of a language board's dimensions — the quote IS the
 	// dimension, and any second component could only repeat what the id fixes.
+	// Its language is listed, and it is the quote's: the index filters on it.
 	assert.Empty(t, board.Mode)
-	assert.Empty(t, board.Lang)
+	assert.Equal(t, "german", board.Lang)
 	assert.Empty(t, board.TextSource)
 	assert.Nil(t, board.DurationMs)
 	assert.Nil(t, board.WordCount)
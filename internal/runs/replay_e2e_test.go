@@ -3,6 +3,7 @@ package runs_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
@@ -20,10 +21,12 @@ import (
 	leaderboardpg "github.com/typemore/typemore-server/internal/leaderboard/pgstore"
 	"github.com/typemore/typemore-server/internal/quote"
 	quotepg "github.com/typemore/typemore-server/internal/quote/pgstore"
+	ratingpg "github.com/typemore/typemore-server/internal/rating/pgstore"
 	"github.com/typemore/typemore-server/internal/replay"
 	replaypg "github.com/typemore/typemore-server/internal/replay/pgstore"
 	"github.com/typemore/typemore-server/internal/replay/policy"
 	"github.com/typemore/typemore-server/internal/replay/policy/policytest"
+	"github.com/typemore/typemore-server/internal/runs"
 )
 
 // End-to-end: a real client payload goes in through the HTTP ingest path, one
@@ -65,7 +68,7 @@ func goldenVector(t *testing.T, name string) goldenVectorFile {
 }
 
 // replayOnce runs exactly one worker batch against the harness's database, with
-// the leaderboard projector and the quote registry attached exactly as
+// the board and TP projectors and the quote registry attached exactly as
 // cmd/server attaches them — so a verdict and the board move together, in one
 // transaction, and a quote run resolves its text out of real Postgres.
 func (h *harness) replayOnce(t *testing.T) {
@@ -78,7 +81,7 @@ func (h *harness) replayOnce(t *testing.T) {
 	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
 	layouts, err := keyboard.Load()
 	require.NoError(t, err)
-	queue := replaypg.New(h.pool, leaderboardpg.New(h.pool, false)).
+	queue := replaypg.New(h.pool, replaypg.Projectors{leaderboardpg.New(h.pool, false), ratingpg.New(h.pool)}).
 		WithKeyboard(keyboardpg.New(layouts))
 	w := replay.NewWorker(queue, reg, quote.ReplayResolver{Store: quotepg.New(h.pool)},
 		replay.WorkerConfig{BatchSize: 10, Decider: fakeDecider(t)}, discard)
@@ -86,6 +89,36 @@ func (h *harness) replayOnce(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// enableTimeline attaches the timeline route's replay half as cmd/server does,
+// behind limiter, and returns the seam so a test can re-attach it behind a
+// different one.
+func (h *harness) enableTimeline(t *testing.T, limiter runs.RateLimiter) runs.Timeliner {
+	t.Helper()
+	core, err := replay.NewCore(replay.DefaultReplayTimeout)
+	require.NoError(t, err)
+	reg, err := replay.NewRegistry(core)
+	require.NoError(t, err)
+	tl := timelineAdapter{replay.NewTimeliner(replaypg.New(h.pool, nil), core, reg,
+		quote.ReplayResolver{Store: quotepg.New(h.pool)}, time.Time{})}
+	h.runs.WithTimeliner(tl, limiter)
+	return tl
+}
+
+// timelineAdapter is cmd/server's, repeated here because the composition
+// root's is unexported.
+type timelineAdapter struct{ timeliner *replay.Timeliner }
+
+func (a timelineAdapter) RunTimeline(ctx context.Context, id uuid.UUID, w io.Writer) error {
+	frames, err := a.timeliner.RunTimeline(ctx, id)
+	if errors.Is(err, replay.ErrRunNotFound) {
+		return runs.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return replay.WriteTimeline(w, frames)
+}
+
 // publishQuote plants one quote row the way `make import-quotes` would. The
 // caller supplies the hash, so a test can stage the case where the registry's
 // bytes and the run's claim have drifted apart.
@@ -366,7 +399,7 @@ func (h *harness) revalidateOnce(t *testing.T, judge policy.Judge) {
 	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
 	layouts, err := keyboard.Load()
 	require.NoError(t, err)
-	queue := replaypg.New(h.pool, leaderboardpg.New(h.pool, false)).
+	queue := replaypg.New(h.pool, replaypg.Projectors{leaderboardpg.New(h.pool, false), ratingpg.New(h.pool)}).
 		WithKeyboard(keyboardpg.New(layouts))
 	w := replay.NewWorker(queue, reg, quote.ReplayResolver{Store: quotepg.New(h.pool)},
 		replay.WorkerConfig{BatchSize: 50, Decider: decider}, discard)
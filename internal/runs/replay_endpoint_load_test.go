@@ -310,7 +310,9 @@ WHERE r.id = $1
   AND r.status = 'accepted'
   AND jsonb_typeof(v.server_metrics -> 'accuracy') = 'number'
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = r.user_id)
-  AND (u.profile_public OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id))`
+  AND (u.profile_public
+       OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id)
+       OR EXISTS (SELECT 1 FROM leaderboard_snapshots s WHERE s.run_id = r.id))`
 
 const getPublicReplayLogSQL = `SELECT r.log
 FROM runs r
@@ -320,7 +322,9 @@ WHERE r.id = $1
   AND r.status = 'accepted'
   AND jsonb_typeof(v.server_metrics -> 'accuracy') = 'number'
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = r.user_id)
-  AND (u.profile_public OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id))`
+  AND (u.profile_public
+       OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id)
+       OR EXISTS (SELECT 1 FROM leaderboard_snapshots s WHERE s.run_id = r.id))`
 
 // TestLoadPlanPublicReplay pins the SHAPE of BOTH queries the pair of public
 // routes runs — watching a row is two requests now, so one pinned plan would
@@ -14,14 +14,116 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countPendingByLane = `-- name: CountPendingByLane :many
+SELECT l.lane::text AS lane,
+       COUNT(r.id) AS pending,
+       COUNT(r.id) FILTER (WHERE r.next_attempt_at IS NULL OR r.next_attempt_at <= now()) AS due,
+       COALESCE((EXTRACT(EPOCH FROM now() - MIN(r.created_at)) * 1000)::bigint, 0)::bigint AS oldest_age_ms
+FROM unnest(ARRAY['contender', 'event', 'ranked', 'unranked']) WITH ORDINALITY AS l(lane, priority)
+         LEFT JOIN runs r ON r.lane = l.lane AND r.status = 'pending'
+GROUP BY l.lane, l.priority
+ORDER BY l.priority
+`
+
+type CountPendingByLaneRow struct {
+	Lane        string
+	Pending     int64
+	Due         int64
+	OldestAgeMs int64
+}
+
+// The replay queue's depth per priority lane (00034), every lane present even
+// when empty: "nothing waiting" is an answer the admin stats should give, not
+// a missing row. due excludes runs still sitting out a retry backoff, which are
+// pending but not claimable. The age is the database's reading of its own
+// clock, like the backoff it is compared with. Walks runs_pending_lane_idx.
+func (q *Queries) CountPendingByLane(ctx context.Context) ([]CountPendingByLaneRow, error) {
+	rows, err := q.db.Query(ctx, countPendingByLane)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountPendingByLaneRow{}
+	for rows.Next() {
+		var i CountPendingByLaneRow
+		if err := rows.Scan(
+			&i.Lane,
+			&i.Pending,
+			&i.Due,
+			&i.OldestAgeMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countShadowVerdicts = `-- name: CountShadowVerdicts :many
+SELECT v.shadow_label::text AS shadow_label,
+       (CASE WHEN v.validation ? 'reason' THEN 'flagged' ELSE 'accepted' END)::text AS live_status,
+       v.shadow_status::text AS shadow_status,
+       COUNT(*) AS runs,
+       MIN(v.validated_at)::timestamptz AS first_judged_at,
+       MAX(v.validated_at)::timestamptz AS last_judged_at
+FROM run_verdicts v
+WHERE v.shadow_label IS NOT NULL
+  AND ($1::text = '' OR v.shadow_label = $1::text)
+GROUP BY 1, 2, 3
+ORDER BY 1, 2, 3
+`
+
+type CountShadowVerdictsRow struct {
+	ShadowLabel   string
+	LiveStatus    string
+	ShadowStatus  string
+	Runs          int64
+	FirstJudgedAt time.Time
+	LastJudgedAt  time.Time
+}
+
+// The shadow report's summary: every candidate answer tallied against the live
+// routing, per label. Agreements are counted too — "disagreed on 40 runs" is
+// unreadable without "out of how many".
+func (q *Queries) CountShadowVerdicts(ctx context.Context, label string) ([]CountShadowVerdictsRow, error) {
+	rows, err := q.db.Query(ctx, countShadowVerdicts, label)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountShadowVerdictsRow{}
+	for rows.Next() {
+		var i CountShadowVerdictsRow
+		if err := rows.Scan(
+			&i.ShadowLabel,
+			&i.LiveStatus,
+			&i.ShadowStatus,
+			&i.Runs,
+			&i.FirstJudgedAt,
+			&i.LastJudgedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createRun = `-- name: CreateRun :one
 
 INSERT INTO runs (
     user_id, mode, duration_ms, word_count, lang, seed, dict_hash,
     setup, client_metrics, client_score, score_version, log, log_bytes,
-    restarts_since_last_submit
+    restarts_since_last_submit, lane
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+    run_lane($1, $2, $3, $4, $5, $8, $10)
 )
 RETURNING id, status, created_at
 `
@@ -53,6 +155,8 @@ type CreateRunRow struct {
 // internal/runs/runsdb. The gzip log blob is written once at ingestion and read
 // back only through GetRunLog (the ?log=1 detail flag) and GetPublicReplayLog
 // (the public log route); the summary queries deliberately never SELECT it.
+// The replay lane is filed here, from the run's own claim, and never moves
+// (00034): it orders the replay queue and nothing else.
 func (q *Queries) CreateRun(ctx context.Context, arg CreateRunParams) (CreateRunRow, error) {
 	row := q.db.QueryRow(ctx, createRun,
 		arg.UserID,
@@ -87,7 +191,9 @@ WHERE r.id = $1
   AND r.status = 'accepted'
   AND jsonb_typeof(v.server_metrics -> 'accuracy') = 'number'
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = r.user_id)
-  AND (u.profile_public OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id))
+  AND (u.profile_public
+       OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id)
+       OR EXISTS (SELECT 1 FROM leaderboard_snapshots s WHERE s.run_id = r.id))
 `
 
 type GetPublicReplayRow struct {
@@ -121,11 +227,12 @@ type GetPublicReplayRow struct {
 // reach this data without them: the run must be ACCEPTED (a flagged, rejected
 // or unjudged run is not a public artefact), its owner must not be banned, and
 // — since public profiles — its owner's profile must be open OR the run must
-// hold a leaderboard slot. The last disjunct is the boundary between profile
-// privacy and the boards (docs/PROFILE.md, "Public profiles"): closing a
-// profile hides the aggregated history page, never a result its owner put into
-// a public ranking, so a board row's replay keeps working whatever the switch
-// says. All failures return no row, which the handler renders as one
+// hold a leaderboard slot, live or archived. The last disjunct is the boundary
+// between profile privacy and the boards (docs/PROFILE.md, "Public profiles"):
+// closing a profile hides the aggregated history page, never a result its owner
+// put into a public ranking, so a board row's replay keeps working whatever the
+// switch says — and so does a row of last month's final standings, which is no
+// longer in leaderboard_entries but is still on a page anyone can open. All failures return no row, which the handler renders as one
 // indistinguishable 404 — a leaderboard must not leak who is under review.
 func (q *Queries) GetPublicReplay(ctx context.Context, runID uuid.UUID) (GetPublicReplayRow, error) {
 	row := q.db.QueryRow(ctx, getPublicReplay, runID)
@@ -156,7 +263,9 @@ WHERE r.id = $1
   AND r.status = 'accepted'
   AND jsonb_typeof(v.server_metrics -> 'accuracy') = 'number'
   AND NOT EXISTS (SELECT 1 FROM active_bans b WHERE b.user_id = r.user_id)
-  AND (u.profile_public OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id))
+  AND (u.profile_public
+       OR EXISTS (SELECT 1 FROM leaderboard_entries e WHERE e.run_id = r.id)
+       OR EXISTS (SELECT 1 FROM leaderboard_snapshots s WHERE s.run_id = r.id))
 `
 
 // The stored gzip event log of one publicly watchable run, and nothing else, so
@@ -300,56 +409,6 @@ func (q *Queries) InsertRunStatusOverride(ctx context.Context, arg InsertRunStat
 	return i, err
 }
 
-const listRunStatusOverrides = `-- name: ListRunStatusOverrides :many
-SELECT o.id, o.run_id, o.from_status, o.to_status, o.reason,
-       o.decided_by, u.display_name AS decided_by_name, o.decided_at
-FROM run_status_overrides o
-         JOIN users u ON u.id = o.decided_by
-WHERE o.run_id = $1
-ORDER BY o.decided_at DESC
-`
-
-type ListRunStatusOverridesRow struct {
-	ID            uuid.UUID
-	RunID         uuid.UUID
-	FromStatus    string
-	ToStatus      string
-	Reason        string
-	DecidedBy     uuid.UUID
-	DecidedByName string
-	DecidedAt     time.Time
-}
-
-// The audit read, newest first.
-func (q *Queries) ListRunStatusOverrides(ctx context.Context, runID uuid.UUID) ([]ListRunStatusOverridesRow, error) {
-	rows, err := q.db.Query(ctx, listRunStatusOverrides, runID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []ListRunStatusOverridesRow{}
-	for rows.Next() {
-		var i ListRunStatusOverridesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.RunID,
-			&i.FromStatus,
-			&i.ToStatus,
-			&i.Reason,
-			&i.DecidedBy,
-			&i.DecidedByName,
-			&i.DecidedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const listRunsAfter = `-- name: ListRunsAfter :many
 SELECT r.id, r.mode, r.duration_ms, r.word_count, r.lang, r.seed, r.dict_hash,
        r.setup, r.client_metrics, r.client_score, r.score_version, r.status,
@@ -659,6 +718,160 @@ func (q *Queries) ListRunsForReview(ctx context.Context, arg ListRunsForReviewPa
 	return items, nil
 }
 
+const listRunStatusOverrides = `-- name: ListRunStatusOverrides :many
+SELECT o.id, o.run_id, o.from_status, o.to_status, o.reason,
+       o.decided_by, u.display_name AS decided_by_name, o.decided_at
+FROM run_status_overrides o
+         JOIN users u ON u.id = o.decided_by
+WHERE o.run_id = $1
+ORDER BY o.decided_at DESC
+`
+
+type ListRunStatusOverridesRow struct {
+	ID            uuid.UUID
+	RunID         uuid.UUID
+	FromStatus    string
+	ToStatus      string
+	Reason        string
+	DecidedBy     uuid.UUID
+	DecidedByName string
+	DecidedAt     time.Time
+}
+
+// The audit read, newest first.
+func (q *Queries) ListRunStatusOverrides(ctx context.Context, runID uuid.UUID) ([]ListRunStatusOverridesRow, error) {
+	rows, err := q.db.Query(ctx, listRunStatusOverrides, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRunStatusOverridesRow{}
+	for rows.Next() {
+		var i ListRunStatusOverridesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RunID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Reason,
+			&i.DecidedBy,
+			&i.DecidedByName,
+			&i.DecidedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShadowDisagreements = `-- name: ListShadowDisagreements :many
+SELECT r.id, r.user_id, u.display_name, r.status, r.mode, r.lang, r.created_at,
+       v.shadow_label::text AS shadow_label,
+       (CASE WHEN v.validation ? 'reason' THEN 'flagged' ELSE 'accepted' END)::text AS live_status,
+       v.shadow_status::text AS shadow_status,
+       (v.validation -> 'policy' ->> 'suspicion')::numeric AS suspicion,
+       (v.shadow_validation -> 'policy' ->> 'suspicion')::numeric AS shadow_suspicion,
+       v.validation, v.shadow_validation, v.validated_at,
+       EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = r.id) AS already_overridden,
+       COUNT(*) OVER () AS total
+FROM run_verdicts v
+         JOIN runs r ON r.id = v.run_id
+         LEFT JOIN users u ON u.id = r.user_id
+WHERE v.shadow_label IS NOT NULL
+  AND ($1::text = '' OR v.shadow_label = $1::text)
+  AND ($2::text = '' OR v.shadow_status = $2::text)
+  AND v.shadow_status <> CASE WHEN v.validation ? 'reason' THEN 'flagged' ELSE 'accepted' END
+ORDER BY v.validated_at DESC, r.id
+LIMIT $4 OFFSET $3
+`
+
+type ListShadowDisagreementsParams struct {
+	Label        string
+	ShadowStatus string
+	RowOffset    int32
+	RowLimit     int32
+}
+
+type ListShadowDisagreementsRow struct {
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	DisplayName       *string
+	Status            string
+	Mode              string
+	Lang              string
+	CreatedAt         time.Time
+	ShadowLabel       string
+	LiveStatus        string
+	ShadowStatus      string
+	Suspicion         pgtype.Numeric
+	ShadowSuspicion   pgtype.Numeric
+	Validation        json.RawMessage
+	ShadowValidation  []byte
+	ValidatedAt       time.Time
+	AlreadyOverridden bool
+	Total             int64
+}
+
+// The shadow report's list (00033): runs on which the candidate policy would
+// have routed differently from the live one, newest verdict first.
+//
+// The live side is the WORKER's routing, read off the verdict document — a
+// valid run carries a reason exactly when the policy or a mismatch flagged it —
+// and not runs.status. An operator's override is a human's answer, not the
+// live policy's, and comparing the candidate against it would credit or blame
+// the candidate for a decision no policy made. The current status and the
+// override mark still ride along, because a reviewer reading "the candidate
+// would have flagged this" wants to know a human already looked.
+//
+// @label narrows to one candidate (” = every label); @shadow_status to one
+// direction of disagreement (” = both).
+func (q *Queries) ListShadowDisagreements(ctx context.Context, arg ListShadowDisagreementsParams) ([]ListShadowDisagreementsRow, error) {
+	rows, err := q.db.Query(ctx, listShadowDisagreements,
+		arg.Label,
+		arg.ShadowStatus,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListShadowDisagreementsRow{}
+	for rows.Next() {
+		var i ListShadowDisagreementsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.DisplayName,
+			&i.Status,
+			&i.Mode,
+			&i.Lang,
+			&i.CreatedAt,
+			&i.ShadowLabel,
+			&i.LiveStatus,
+			&i.ShadowStatus,
+			&i.Suspicion,
+			&i.ShadowSuspicion,
+			&i.Validation,
+			&i.ShadowValidation,
+			&i.ValidatedAt,
+			&i.AlreadyOverridden,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const runStatusForOverride = `-- name: RunStatusForOverride :one
 SELECT r.status,
        EXISTS (SELECT 1 FROM run_status_overrides o WHERE o.run_id = r.id) AS already_overridden
@@ -36,15 +36,27 @@ type Service struct {
 	store Store
 	// moderator is the operator surface (override.go). Nil = not wired, and the
 	// admin routes answer 503 rather than panicking.
-	moderator     Moderator
-	limiter       RateLimiter
-	replayLimiter RateLimiter
-	userID        UserIDFunc
+	moderator Moderator
+	// explainer re-judges a run with a trace (explain.go). Nil, likewise.
+	explainer Explainer
+	// timeliner folds a run's per-event timeline (timeline.go), and
+	// timelineLimiter rations the folds per IP. Nil, likewise.
+	timeliner       Timeliner
+	timelineLimiter RateLimiter
+	limiter         RateLimiter
+	replayLimiter   RateLimiter
+	userID          UserIDFunc
 	// restrictions gates run submission. Nil means nothing is wired and no
 	// account is restricted — the correct behaviour for a deployment with no
 	// moderation store, and for every test that is not about bans.
 	restrictions Restrictions
-	log          *slog.Logger
+	// daily admits declared daily-challenge attempts (daily.go). Nil, and every
+	// declared attempt is refused 503; undeclared runs never reach it.
+	daily DailyGate
+	// standings places an accepted run on its board for the detail view
+	// (standing.go). Nil, and the detail carries no standing.
+	standings Standings
+	log       *slog.Logger
 }
 
 // Restrictions answers whether an account is under an active ban. Declared here
@@ -0,0 +1,126 @@
+package runs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// THE CANDIDATE POLICY'S REPORT CARD.
+//
+// A replay worker configured with a shadow policy (docs/REPLAY.md, "Shadow
+// policy") stores, beside every verdict it writes, what a second set of weights
+// and threshold WOULD have made of the run. Nothing acts on that answer. This
+// is where an operator reads it: how often the candidate agreed with the live
+// policy, and every run on which it did not, so a tuning can be argued from
+// real incoming runs before it becomes a policy version.
+
+// ShadowTally is one candidate's answers counted against the live routing.
+type ShadowTally struct {
+	Label string `json:"label"`
+	// Judged is every run the candidate was asked about.
+	Judged int64 `json:"judged"`
+	Agree  int64 `json:"agree"`
+	// WouldFlag counts runs the live policy accepted and the candidate would
+	// have sent to review; WouldAccept the reverse.
+	WouldFlag     int64     `json:"wouldFlag"`
+	WouldAccept   int64     `json:"wouldAccept"`
+	FirstJudgedAt time.Time `json:"firstJudgedAt"`
+	LastJudgedAt  time.Time `json:"lastJudgedAt"`
+}
+
+// ShadowRow is one run on which the candidate and the live policy disagree.
+type ShadowRow struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"userId,omitempty"`
+	DisplayName string    `json:"displayName,omitempty"`
+	// Status is the run's status now, which an operator may have overridden;
+	// LiveStatus is what the live policy decided, which is what the candidate
+	// is compared against.
+	Status          string  `json:"status"`
+	LiveStatus      string  `json:"liveStatus"`
+	ShadowStatus    string  `json:"shadowStatus"`
+	Label           string  `json:"label"`
+	Mode            string  `json:"mode"`
+	Lang            string  `json:"lang,omitempty"`
+	Suspicion       float64 `json:"suspicion"`
+	ShadowSuspicion float64 `json:"shadowSuspicion"`
+	Overridden      bool    `json:"overridden"`
+	// Validation and ShadowValidation are the two verdict documents in full:
+	// the same flags, two policy blocks.
+	Validation       json.RawMessage `json:"validation,omitempty"`
+	ShadowValidation json.RawMessage `json:"shadowValidation,omitempty"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	ValidatedAt      time.Time       `json:"validatedAt"`
+}
+
+const shadowReportMaxLimit = 200
+
+func (s *Service) handleShadowReport(w http.ResponseWriter, r *http.Request) {
+	if s.moderator == nil {
+		s.writeError(w, r, apiErrUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	label := q.Get("label")
+
+	// Named by what the candidate would DO, which is the question a tuning
+	// asks: "what would this start flagging", "what would this let through".
+	shadowStatus := ""
+	switch raw := q.Get("direction"); raw {
+	case "":
+	case "flag":
+		shadowStatus = StatusFlagged
+	case "accept":
+		shadowStatus = StatusAccepted
+	default:
+		s.writeError(w, r, apiErrBadRequest("direction must be one of flag, accept"))
+		return
+	}
+	limit := int32(50)
+	if raw := q.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			s.writeError(w, r, apiErrBadRequest("limit must be a positive integer"))
+			return
+		}
+		limit = int32(min(parsed, shadowReportMaxLimit))
+	}
+	offset := int32(0)
+	if raw := q.Get("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			s.writeError(w, r, apiErrBadRequest("offset must be a non-negative integer"))
+			return
+		}
+		offset = int32(parsed)
+	}
+
+	tallies, err := s.moderator.ShadowTallies(r.Context(), label)
+	if err != nil {
+		s.log.Error("shadow report tallies", "err", err)
+		s.writeError(w, r, apiErrInternal)
+		return
+	}
+	rows, total, err := s.moderator.ShadowDisagreements(r.Context(), label, shadowStatus, limit, offset)
+	if err != nil {
+		s.log.Error("shadow report", "err", err)
+		s.writeError(w, r, apiErrInternal)
+		return
+	}
+	if tallies == nil {
+		tallies = []ShadowTally{}
+	}
+	if rows == nil {
+		rows = []ShadowRow{}
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"candidates": tallies,
+		"runs":       rows,
+		"total":      total,
+		"offset":     offset,
+	})
+}
@@ -0,0 +1,66 @@
+package runs
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Standing is where a judged run's score sits on the board its shape ranks
+// on: the rank it would take there, out of how many, and that as "top N%".
+// It is reported whether or not the run is the player's best — a run that
+// came second to their PB still came somewhere.
+type Standing struct {
+	Board      string
+	Rank       int64
+	Entries    int64
+	Percentile float64
+}
+
+// Standings looks up a run's Standing. Implemented over the leaderboard store
+// by the composition root, like Explainer over replay; ok is false for a run
+// that ranks nowhere — not accepted, or a shape no board is kept for.
+type Standings interface {
+	RunStanding(ctx context.Context, runID uuid.UUID) (Standing, bool, error)
+}
+
+// WithStandings attaches the standing shown on a run's detail. Nil leaves the
+// field off every response.
+func (s *Service) WithStandings(st Standings) *Service {
+	s.standings = st
+	return s
+}
+
+// standingView is Standing on the wire, as the detail view's `standing`.
+type standingView struct {
+	Board      string  `json:"board"`
+	Rank       int64   `json:"rank"`
+	Entries    int64   `json:"entries"`
+	Percentile float64 `json:"percentile"`
+}
+
+// detailView is the one-run response: the summary every listing shows, plus
+// where the run stands. The standing is a read of the board as it is NOW, so
+// it belongs to this view and not to the Summary a list repeats per row.
+type detailView struct {
+	summaryView
+	Standing *standingView `json:"standing,omitempty"`
+}
+
+// standingOf looks the run's standing up for its detail view. A failure is
+// logged and leaves the field off: the run is what was asked for, and a
+// leaderboard that cannot be read right now should not take it down too.
+func (s *Service) standingOf(ctx context.Context, run Summary) *standingView {
+	if s.standings == nil || run.Status != StatusAccepted {
+		return nil
+	}
+	st, ok, err := s.standings.RunStanding(ctx, run.ID)
+	if err != nil {
+		s.log.WarnContext(ctx, "runs: standing lookup failed", "run", run.ID, "err", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return &standingView{Board: st.Board, Rank: st.Rank, Entries: st.Entries, Percentile: st.Percentile}
+}
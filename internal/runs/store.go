@@ -42,6 +42,12 @@ type CreateRunParams struct {
 	// by validation and the runs_restarts_range CHECK; accepted on trust, like
 	// the declared view-only mods — a restarted run leaves no evidence to verify.
 	RestartsSinceLastSubmit int32
+	// DailyDay is the UTC day the run declared itself an attempt at
+	// (`setup.daily`), nil for every other run. Not a column of its own: the
+	// declaration is stored inside Setup, verbatim, and SQL reads it back from
+	// there (00037). It rides here so the handler can put it in front of the
+	// daily gate before anything is stored.
+	DailyDay *time.Time
 }
 
 // Run is the persisted outcome of CreateRun: the server-assigned id, the landed
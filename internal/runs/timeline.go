@@ -0,0 +1,126 @@
+package runs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/typemore/typemore-server/internal/platform/httpx"
+)
+
+// Timeliner folds one stored run's log through the replay core and writes its
+// per-event state timeline to w as NDJSON (docs/REPLAY.md, "`replayctl
+// timeline`"). Implemented over the replay package by the composition root,
+// like Explainer. A run that does not exist is ErrNotFound.
+//
+// Nothing may reach w before the fold has succeeded: an error returned before
+// the first write is still the handler's to answer with a status.
+type Timeliner interface {
+	RunTimeline(ctx context.Context, runID uuid.UUID, w io.Writer) error
+}
+
+// WithTimeliner attaches the timeline route's replay half and the per-IP
+// limiter it draws on. Nil leaves the route answering 503.
+//
+// The limiter is its own, not the replay pair's: a timeline costs a fold on the
+// shared replay core where /replay/log costs a passthrough of stored bytes, so
+// the budget a spectator has for watching says nothing about the budget the
+// core has for folding.
+func (s *Service) WithTimeliner(t Timeliner, limiter RateLimiter) *Service {
+	s.timeliner = t
+	s.timelineLimiter = limiter
+	return s
+}
+
+// ndjsonContentType is the media type a timeline is served as: one frame per
+// line, so a viewer can start drawing before it has parsed the last one.
+const ndjsonContentType = "application/x-ndjson"
+
+// frameWriter flushes after every write, which is every frame: the encoder
+// writes one line per Encode. The fold itself has finished by the time the
+// first frame arrives (replay.Core.Timeline holds the shared runtime for it and
+// no longer), so what streams is the encoding — no frame waits in a buffer for
+// the last one, and no copy of the whole timeline is built to send it.
+type frameWriter struct {
+	w     io.Writer
+	rc    *http.ResponseController
+	wrote bool
+}
+
+func (f *frameWriter) Write(p []byte) (int, error) {
+	f.wrote = true
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	// A writer that cannot flush still gets every byte, just not line by line.
+	if err := f.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
+
+// handleRunTimeline is GET /{id}/timeline: the run's fold as NDJSON, for a
+// frame-accurate viewer or a "where did I lose time" chart built on the core's
+// own numbers rather than on a second reducer.
+//
+// Who may read it is who may watch the run: an accepted run is anyone's, by the
+// same rules as /replay/log, and the owner reads their own in any status, as
+// ?log=1 lets them. Anything else is the public routes' one 404. The fold is
+// what the route costs, so the limiter is checked before anything else and
+// visibility before the fold.
+func (s *Service) handleRunTimeline(w http.ResponseWriter, r *http.Request) {
+	if s.timeliner == nil {
+		s.writeError(w, r, apiErrUnavailable)
+		return
+	}
+	if !s.timelineLimiter.Allow(httpx.ClientIP(r)) {
+		s.writeError(w, r, apiErrRateLimited)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, r, apiErrNotFound)
+		return
+	}
+	if err := s.canWatch(r.Context(), id); err != nil {
+		s.writeNotFoundOr(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	fw := &frameWriter{w: w, rc: http.NewResponseController(w)}
+	err = s.timeliner.RunTimeline(r.Context(), id, fw)
+	switch {
+	case err == nil:
+		if !fw.wrote {
+			// A log with no state events folds to no frames; the 200 is still owed.
+			w.WriteHeader(http.StatusOK)
+		}
+	case fw.wrote:
+		// The status is on the wire; all that is left is to stop.
+		s.log.Error("write run timeline", "err", err, "run", id)
+	case errors.Is(err, ErrNotFound):
+		s.writeError(w, r, apiErrNotFound)
+	default:
+		s.log.Error("run timeline", "err", err, "run", id)
+		s.writeError(w, r, apiErrInternal)
+	}
+}
+
+// canWatch reports whether the caller may read run id: their own run, or one
+// the public replay routes would serve. Neither is ErrNotFound.
+func (s *Service) canWatch(ctx context.Context, id uuid.UUID) error {
+	if userID, ok := s.userID(ctx); ok {
+		_, err := s.store.Run(ctx, id, userID)
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	_, err := s.store.PublicReplay(ctx, id)
+	return err
+}
@@ -0,0 +1,79 @@
+package runs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The two tools personal access tokens were built for, against the real run
+// and profile routes: an exporter that reads, and a bot that submits. Each
+// gets exactly its scope's routes, with no cookie and no Origin.
+
+func (h *harness) mintToken(scopes ...string) string {
+	h.t.Helper()
+	resp := h.post("/api/v1/me/tokens", map[string]any{
+		"name": "tool", "scopes": scopes, "expiresInDays": 7,
+	})
+	require.Equal(h.t, http.StatusCreated, resp.StatusCode)
+	return decodeInto[struct {
+		Token string `json:"token"`
+	}](h.t, resp).Token
+}
+
+func (h *harness) withToken(method, path, token string, body any) *http.Response {
+	h.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(h.t, json.NewEncoder(&buf).Encode(body))
+	}
+	req, err := http.NewRequest(method, h.server.URL+path, &buf)
+	require.NoError(h.t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(h.t, err)
+	return resp
+}
+
+func TestAccessTokenScopesOnRunRoutes(t *testing.T) {
+	h := newHarness(t)
+	h.login("tools@example.com", "password-123", "Toolsmith")
+	exporter := h.mintToken("runs:read", "profile:read")
+	bot := h.mintToken("runs:submit")
+
+	// The bot submits; the exporter may not.
+	resp := h.withToken(http.MethodPost, "/api/v1/runs", bot, validRun())
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	runID := decodeInto[ingestResp](t, resp).ID
+	resp = h.withToken(http.MethodPost, "/api/v1/runs", exporter, validRun())
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	// The exporter reads what the bot submitted; the bot may not read.
+	resp = h.withToken(http.MethodGet, "/api/v1/runs", exporter, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	list := decodeInto[struct {
+		Runs []struct {
+			ID string `json:"id"`
+		} `json:"runs"`
+	}](t, resp)
+	require.Len(t, list.Runs, 1)
+	assert.Equal(t, runID, list.Runs[0].ID)
+	requireStatus(t, h.withToken(http.MethodGet, "/api/v1/runs/"+runID, exporter, nil), http.StatusOK)
+	requireStatus(t, h.withToken(http.MethodGet, "/api/v1/runs", bot, nil), http.StatusForbidden)
+
+	requireStatus(t, h.withToken(http.MethodGet, "/api/v1/profile/summary", exporter, nil), http.StatusOK)
+	requireStatus(t, h.withToken(http.MethodGet, "/api/v1/profile/summary", bot, nil), http.StatusForbidden)
+
+	// A browser without the Origin is still refused: only the token skips it.
+	req, err := http.NewRequest(http.MethodPost, h.server.URL+"/api/v1/runs", http.NoBody)
+	require.NoError(t, err)
+	resp, err = h.client.Do(req)
+	require.NoError(t, err)
+	requireStatus(t, resp, http.StatusForbidden)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -133,6 +134,14 @@ const (
 	codeLogTooLarge        = "log_too_large"
 	codeInvalidRestarts    = "invalid_restarts"
 	codeInvalidAdoptedFrom = "invalid_adopted_from"
+	// The daily challenge's three (docs/DAILY.md): a declaration that is not a
+	// date, a date whose challenge is not open, and a run that is not the
+	// challenge it declares. Only the first is structural; the other two are
+	// the daily gate's answers, kept in this list so every 422 code is in one
+	// place.
+	codeInvalidDaily  = "invalid_daily"
+	codeDailyClosed   = "daily_closed"
+	codeDailyMismatch = "daily_mismatch"
 )
 
 // ingestRequest is the POST /runs body. The opaque snapshots and the log are
@@ -191,7 +200,14 @@ type setupEnvelope struct {
 	// as this field's problem rather than collapsing the whole envelope decode
 	// into "setup.generation is not an object".
 	AdoptedFromRunID json.RawMessage `json:"adoptedFromRunId"`
-	Generation       *struct {
+	// Daily declares the run an attempt at one day's challenge: "YYYY-MM-DD",
+	// UTC. Absent on every other run. Read for its shape here and for its
+	// meaning by the daily gate; SQL reads it back out of the stored setup
+	// (run_daily_day, 00037), which is why a malformed value is refused rather
+	// than ignored — ignored, it would be a run the player believes counts.
+	// Raw, for the same reason as AdoptedFromRunID.
+	Daily      json.RawMessage `json:"daily"`
+	Generation *struct {
 		TextSource *struct {
 			Kind      string `json:"kind"`
 			QuoteID   string `json:"quoteId"`
@@ -285,6 +301,13 @@ func validateIngest(userID uuid.UUID, req *ingestRequest) (CreateRunParams, *api
 		return CreateRunParams{}, verr
 	}
 
+	// Daily declaration. Shape only; whether it matches the day's challenge is
+	// the gate's question, asked by the handler.
+	daily, verr := validateDaily(env)
+	if verr != nil {
+		return CreateRunParams{}, verr
+	}
+
 	// Dimensions: conditional on the text source (see validateDimensions).
 	if verr := validateDimensions(req, kind); verr != nil {
 		return CreateRunParams{}, verr
@@ -328,6 +351,7 @@ func validateIngest(userID uuid.UUID, req *ingestRequest) (CreateRunParams, *api
 		Log:                     gz,
 		LogBytes:                int32(len(req.Log)),
 		RestartsSinceLastSubmit: restarts,
+		DailyDay:                daily,
 	}, nil
 }
 
@@ -372,6 +396,31 @@ func validateAdoptedFrom(env setupEnvelope) *apiError {
 	return nil
 }
 
+// validateDaily checks `setup.daily`: absent, or a calendar date spelled
+// YYYY-MM-DD. The spelling is the one run_daily_day matches and the one the
+// day's board key carries, and time.Parse with that layout is strict about it —
+// no missing zero-pad, no 31st of February — so a date that passes here has
+// exactly one spelling everywhere downstream.
+func validateDaily(env setupEnvelope) (*time.Time, *apiError) {
+	if len(env.Daily) == 0 || string(env.Daily) == "null" {
+		return nil, nil
+	}
+	var value string
+	if err := json.Unmarshal(env.Daily, &value); err != nil {
+		return nil, apiErrUnprocessable(codeInvalidDaily,
+			"setup.daily must be a YYYY-MM-DD date when present")
+	}
+	day, err := time.Parse(dailyDayLayout, value)
+	if err != nil {
+		return nil, apiErrUnprocessable(codeInvalidDaily,
+			"setup.daily must be a YYYY-MM-DD date when present")
+	}
+	return &day, nil
+}
+
+// dailyDayLayout is the one spelling of a declared day.
+const dailyDayLayout = "2006-01-02"
+
 // isCanonicalUUID reports whether s is a uuid in the ONE spelling the rest of
 // the system agrees on: 36 characters, lowercase, dashed.
 //